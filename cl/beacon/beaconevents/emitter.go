@@ -0,0 +1,168 @@
+package beaconevents
+
+import (
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+)
+
+// Topics which can be subscribed to through /eth/v1/events.
+const (
+	TopicHead                 = "head"
+	TopicBlock                = "block"
+	TopicAttestation          = "attestation"
+	TopicVoluntaryExit        = "voluntary_exit"
+	TopicFinalizedCheckpoint  = "finalized_checkpoint"
+	TopicChainReorg           = "chain_reorg"
+	TopicContributionAndProof = "contribution_and_proof"
+
+	TopicLightClientFinalityUpdate   = "light_client_finality_update"
+	TopicLightClientOptimisticUpdate = "light_client_optimistic_update"
+)
+
+// DefaultSubscriptionBufferSize is how many events a subscriber may lag behind before being dropped.
+const DefaultSubscriptionBufferSize = 256
+
+var knownTopics = map[string]struct{}{
+	TopicHead:                 {},
+	TopicBlock:                {},
+	TopicAttestation:          {},
+	TopicVoluntaryExit:        {},
+	TopicFinalizedCheckpoint:  {},
+	TopicChainReorg:           {},
+	TopicContributionAndProof: {},
+
+	TopicLightClientFinalityUpdate:   {},
+	TopicLightClientOptimisticUpdate: {},
+}
+
+// IsKnownTopic returns whether the topic is one of the supported event topics.
+func IsKnownTopic(topic string) bool {
+	_, ok := knownTopics[topic]
+	return ok
+}
+
+type HeadData struct {
+	Slot                      uint64         `json:"slot,string"`
+	Block                     libcommon.Hash `json:"block"`
+	State                     libcommon.Hash `json:"state"`
+	EpochTransition           bool           `json:"epoch_transition"`
+	PreviousDutyDependentRoot libcommon.Hash `json:"previous_duty_dependent_root"`
+	CurrentDutyDependentRoot  libcommon.Hash `json:"current_duty_dependent_root"`
+	ExecutionOptimistic       bool           `json:"execution_optimistic"`
+}
+
+type BlockData struct {
+	Slot                uint64         `json:"slot,string"`
+	Block               libcommon.Hash `json:"block"`
+	ExecutionOptimistic bool           `json:"execution_optimistic"`
+}
+
+type FinalizedCheckpointData struct {
+	Block               libcommon.Hash `json:"block"`
+	State               libcommon.Hash `json:"state"`
+	Epoch               uint64         `json:"epoch,string"`
+	ExecutionOptimistic bool           `json:"execution_optimistic"`
+}
+
+type ChainReorgData struct {
+	Slot                uint64         `json:"slot,string"`
+	Depth               uint64         `json:"depth,string"`
+	OldHeadBlock        libcommon.Hash `json:"old_head_block"`
+	NewHeadBlock        libcommon.Hash `json:"new_head_block"`
+	OldHeadState        libcommon.Hash `json:"old_head_state"`
+	NewHeadState        libcommon.Hash `json:"new_head_state"`
+	Epoch               uint64         `json:"epoch,string"`
+	ExecutionOptimistic bool           `json:"execution_optimistic"`
+}
+
+// Event is a single notification. Data is either one of the *Data structs above or the consensus object itself
+// (*solid.Attestation, *cltypes.SignedVoluntaryExit, *cltypes.SignedContributionAndProof,
+// *cltypes.LightClientFinalityUpdate, *cltypes.LightClientOptimisticUpdate), it is up to the consumer to format it.
+type Event struct {
+	Topic string
+	Data  interface{}
+}
+
+// Subscription receives the events of the topics it is interested in.
+type Subscription struct {
+	id     uint64
+	topics map[string]struct{}
+	ch     chan *Event
+}
+
+// Events returns the channel the events are delivered on. It is closed once the subscription is over, either
+// because it was unsubscribed or because the subscriber was too slow to keep up.
+func (s *Subscription) Events() <-chan *Event {
+	return s.ch
+}
+
+// Emitter fans out the events produced by forkchoice and gossip to the subscribers.
+// Publishing never blocks: a subscriber whose buffer is full is dropped, so that a slow consumer cannot stall the
+// producers.
+type Emitter struct {
+	mu          sync.Mutex
+	nextId      uint64
+	subscribers map[uint64]*Subscription
+}
+
+func NewEmitter() *Emitter {
+	return &Emitter{subscribers: make(map[uint64]*Subscription)}
+}
+
+// Subscribe registers a new subscriber for the given topics, the returned function must be called to unsubscribe.
+func (e *Emitter) Subscribe(topics []string, bufferSize int) (*Subscription, func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	sub := &Subscription{
+		id:     e.nextId,
+		topics: make(map[string]struct{}, len(topics)),
+		ch:     make(chan *Event, bufferSize),
+	}
+	for _, topic := range topics {
+		sub.topics[topic] = struct{}{}
+	}
+	e.nextId++
+	e.subscribers[sub.id] = sub
+	return sub, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.remove(sub)
+	}
+}
+
+// Publish sends the event to all the subscribers of the topic. It is safe to call on a nil emitter.
+func (e *Emitter) Publish(topic string, data interface{}) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	event := &Event{Topic: topic, Data: data}
+	for _, sub := range e.subscribers {
+		if _, ok := sub.topics[topic]; !ok {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// The subscriber is lagging behind, cut it off.
+			e.remove(sub)
+		}
+	}
+}
+
+// SubscribersCount returns the number of active subscribers.
+func (e *Emitter) SubscribersCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.subscribers)
+}
+
+func (e *Emitter) remove(sub *Subscription) {
+	if _, ok := e.subscribers[sub.id]; !ok {
+		return
+	}
+	delete(e.subscribers, sub.id)
+	close(sub.ch)
+}
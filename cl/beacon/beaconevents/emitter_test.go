@@ -0,0 +1,55 @@
+package beaconevents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitterTopicFiltering(t *testing.T) {
+	e := NewEmitter()
+	sub, unsubscribe := e.Subscribe([]string{TopicHead}, 4)
+	defer unsubscribe()
+
+	e.Publish(TopicBlock, &BlockData{Slot: 1})
+	e.Publish(TopicHead, &HeadData{Slot: 2})
+
+	ev := <-sub.Events()
+	require.Equal(t, TopicHead, ev.Topic)
+	require.Equal(t, uint64(2), ev.Data.(*HeadData).Slot)
+	require.Len(t, sub.Events(), 0)
+}
+
+func TestEmitterDropsSlowSubscriber(t *testing.T) {
+	e := NewEmitter()
+	slow, _ := e.Subscribe([]string{TopicBlock}, 1)
+	fast, unsubscribe := e.Subscribe([]string{TopicBlock}, 4)
+	defer unsubscribe()
+
+	e.Publish(TopicBlock, &BlockData{Slot: 1})
+	e.Publish(TopicBlock, &BlockData{Slot: 2})
+	require.Equal(t, 1, e.SubscribersCount())
+
+	// The slow subscriber still gets what was buffered, then the channel is closed.
+	ev, ok := <-slow.Events()
+	require.True(t, ok)
+	require.Equal(t, uint64(1), ev.Data.(*BlockData).Slot)
+	_, ok = <-slow.Events()
+	require.False(t, ok)
+
+	require.Len(t, fast.Events(), 2)
+}
+
+func TestEmitterUnsubscribe(t *testing.T) {
+	e := NewEmitter()
+	sub, unsubscribe := e.Subscribe([]string{TopicBlock}, 1)
+	unsubscribe()
+	unsubscribe()
+	_, ok := <-sub.Events()
+	require.False(t, ok)
+	require.Equal(t, 0, e.SubscribersCount())
+	// Publishing to nobody and to a nil emitter is fine.
+	e.Publish(TopicBlock, &BlockData{})
+	var nilEmitter *Emitter
+	nilEmitter.Publish(TopicBlock, &BlockData{})
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+)
+
+type blobSidecarJSON struct {
+	BlockRoot       libcommon.Hash   `json:"block_root"`
+	Index           uint64           `json:"index,string"`
+	Slot            uint64           `json:"slot,string"`
+	BlockParentRoot libcommon.Hash   `json:"block_parent_root"`
+	ProposerIndex   uint64           `json:"proposer_index,string"`
+	Blob            hexutility.Bytes `json:"blob"`
+	KzgCommitment   hexutility.Bytes `json:"kzg_commitment"`
+	KzgProof        hexutility.Bytes `json:"kzg_proof"`
+}
+
+func newBlobSidecarJSON(sidecar *cltypes.BlobSidecar) blobSidecarJSON {
+	return blobSidecarJSON{
+		BlockRoot:       sidecar.BlockRoot,
+		Index:           sidecar.Index,
+		Slot:            sidecar.Slot,
+		BlockParentRoot: sidecar.BlockParentRoot,
+		ProposerIndex:   sidecar.ProposerIndex,
+		Blob:            sidecar.Blob[:],
+		KzgCommitment:   sidecar.KzgCommitment[:],
+		KzgProof:        sidecar.KzgProof[:],
+	}
+}
+
+func (a *ApiHandler) getBlobSidecars(r *http.Request) (*beaconResponse, error) {
+	if a.blobStore == nil {
+		return nil, newApiError(http.StatusNotImplemented, "blob sidecars are not served")
+	}
+	blockId, err := blockIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	var indices map[uint64]struct{}
+	for _, str := range stringListFromQueryParam(r, "indices") {
+		index, err := strconv.ParseUint(str, 10, 64)
+		if err != nil || index >= a.beaconChainCfg.MaxBlobsPerBlock {
+			return nil, newApiError(http.StatusBadRequest, "invalid blob index: %s", str)
+		}
+		if indices == nil {
+			indices = make(map[uint64]struct{})
+		}
+		indices[index] = struct{}{}
+	}
+	root, err := a.rootFromBlockId(r.Context(), blockId)
+	if err != nil {
+		return nil, err
+	}
+	sidecars, err := a.blobStore.BlobSidecarsByRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(sidecars) == 0 {
+		// a block without blobs, or older than the retention window
+		if _, _, err := a.blockByRoot(r.Context(), root); err != nil {
+			return nil, err
+		}
+	}
+	filtered := make([]*cltypes.BlobSidecar, 0, len(sidecars))
+	resp := make([]blobSidecarJSON, 0, len(sidecars))
+	for _, sidecar := range sidecars {
+		if _, ok := indices[sidecar.Index]; indices != nil && !ok {
+			continue
+		}
+		filtered = append(filtered, sidecar)
+		resp = append(resp, newBlobSidecarJSON(sidecar))
+	}
+	return newBeaconResponse(resp).
+		withVersion(clparams.DenebVersion).
+		withSSZ(solid.NewStaticListSSZFromList(filtered, int(a.beaconChainCfg.MaxBlobsPerBlock), (&cltypes.BlobSidecar{}).EncodingSizeSSZ())), nil
+}
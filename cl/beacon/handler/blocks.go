@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
+)
+
+type headerResponse struct {
+	Root      libcommon.Hash   `json:"root"`
+	Canonical bool             `json:"canonical"`
+	Header    signedHeaderJSON `json:"header"`
+}
+
+type rootResponse struct {
+	Root libcommon.Hash `json:"root"`
+}
+
+// rootFromBlockId resolves a {block_id} into a block root.
+func (a *ApiHandler) rootFromBlockId(ctx context.Context, blockId *segmentID) (root libcommon.Hash, err error) {
+	switch {
+	case blockId.tag == segmentTagHead:
+		root, _, err = a.forkchoiceStore.GetHead()
+		return
+	case blockId.tag == segmentTagFinalized:
+		return a.forkchoiceStore.FinalizedCheckpoint().BlockRoot(), nil
+	case blockId.tag == segmentTagJustified:
+		return a.forkchoiceStore.JustifiedCheckpoint().BlockRoot(), nil
+	case blockId.tag == segmentTagGenesis:
+		return a.rootFromSlot(ctx, a.beaconChainCfg.GenesisSlot)
+	case blockId.slot != nil:
+		return a.rootFromSlot(ctx, *blockId.slot)
+	case blockId.root != nil:
+		return *blockId.root, nil
+	}
+	return libcommon.Hash{}, newApiError(http.StatusBadRequest, "cannot parse block id")
+}
+
+// rootFromSlot looks for the canonical block at the given slot in forkchoice, then among the finalized blocks of
+// the indices database.
+func (a *ApiHandler) rootFromSlot(ctx context.Context, slot uint64) (libcommon.Hash, error) {
+	root, ok, err := a.forkchoiceStore.GetCanonicalBlockRoot(slot)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	if !ok && a.indicesDB != nil {
+		if err := a.indicesDB.View(ctx, func(tx kv.Tx) error {
+			root, err = rawdb.ReadFinalizedBlockRoot(tx, slot)
+			return err
+		}); err != nil {
+			return libcommon.Hash{}, err
+		}
+		ok = root != (libcommon.Hash{})
+	}
+	if !ok {
+		return libcommon.Hash{}, newApiError(http.StatusNotFound, "block not found for slot %d", slot)
+	}
+	return root, nil
+}
+
+// blockByRoot retrieves a block held by forkchoice, or else stored in the indices database, together with whether
+// it is canonical.
+func (a *ApiHandler) blockByRoot(ctx context.Context, root libcommon.Hash) (*cltypes.SignedBeaconBlock, bool, error) {
+	if block, ok := a.forkchoiceStore.GetBlock(root); ok {
+		canonical, err := a.forkchoiceStore.IsCanonical(root)
+		if err != nil {
+			return nil, false, err
+		}
+		return block, canonical, nil
+	}
+	if a.indicesDB == nil {
+		return nil, false, newApiError(http.StatusNotFound, "block not found %x", root)
+	}
+	var (
+		block     *cltypes.SignedBeaconBlock
+		canonical bool
+	)
+	if err := a.indicesDB.View(ctx, func(tx kv.Tx) error {
+		slot, err := rawdb.ReadBlockSlotByBlockRoot(tx, root)
+		if err != nil || slot == nil {
+			return err
+		}
+		version := a.beaconChainCfg.GetCurrentStateVersion(*slot / a.beaconChainCfg.SlotsPerEpoch)
+		if block, _, _, err = rawdb.ReadBeaconBlock(tx, root, *slot, version); err != nil {
+			return err
+		}
+		finalizedRoot, err := rawdb.ReadFinalizedBlockRoot(tx, *slot)
+		canonical = finalizedRoot == root
+		return err
+	}); err != nil {
+		return nil, false, err
+	}
+	if block == nil {
+		return nil, false, newApiError(http.StatusNotFound, "block not found %x", root)
+	}
+	return block, canonical, nil
+}
+
+func (a *ApiHandler) isFinalizedSlot(slot uint64) bool {
+	return slot <= a.forkchoiceStore.FinalizedSlot()
+}
+
+func (a *ApiHandler) getBlockHeader(r *http.Request) (*beaconResponse, error) {
+	blockId, err := blockIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := a.rootFromBlockId(r.Context(), blockId)
+	if err != nil {
+		return nil, err
+	}
+	block, canonical, err := a.blockByRoot(r.Context(), root)
+	if err != nil {
+		return nil, err
+	}
+	header, err := newSignedHeaderJSON(block)
+	if err != nil {
+		return nil, err
+	}
+	return newBeaconResponse(headerResponse{
+		Root:      root,
+		Canonical: canonical,
+		Header:    header,
+	}).withFinalized(canonical && a.isFinalizedSlot(block.Block.Slot)).withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getBlockRoot(r *http.Request) (*beaconResponse, error) {
+	blockId, err := blockIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := a.rootFromBlockId(r.Context(), blockId)
+	if err != nil {
+		return nil, err
+	}
+	// Make sure the root actually points to a known block.
+	block, canonical, err := a.blockByRoot(r.Context(), root)
+	if err != nil {
+		return nil, err
+	}
+	return newBeaconResponse(rootResponse{Root: root}).
+		withFinalized(canonical && a.isFinalizedSlot(block.Block.Slot)).
+		withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getBlock(r *http.Request) (*beaconResponse, error) {
+	blockId, err := blockIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := a.rootFromBlockId(r.Context(), blockId)
+	if err != nil {
+		return nil, err
+	}
+	block, canonical, err := a.blockByRoot(r.Context(), root)
+	if err != nil {
+		return nil, err
+	}
+	return newBeaconResponse(newSignedBlockJSON(block)).
+		withSSZ(block).
+		withVersion(block.Version()).
+		withFinalized(canonical && a.isFinalizedSlot(block.Block.Slot)).
+		withExecutionOptimistic(false), nil
+}
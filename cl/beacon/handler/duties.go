@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+)
+
+type proposerDuty struct {
+	Pubkey         hexutility.Bytes `json:"pubkey"`
+	ValidatorIndex uint64           `json:"validator_index,string"`
+	Slot           uint64           `json:"slot,string"`
+}
+
+type attesterDuty struct {
+	Pubkey                  hexutility.Bytes `json:"pubkey"`
+	ValidatorIndex          uint64           `json:"validator_index,string"`
+	CommitteeIndex          uint64           `json:"committee_index,string"`
+	CommitteeLength         uint64           `json:"committee_length,string"`
+	CommitteesAtSlot        uint64           `json:"committees_at_slot,string"`
+	ValidatorCommitteeIndex uint64           `json:"validator_committee_index,string"`
+	Slot                    uint64           `json:"slot,string"`
+}
+
+type syncDuty struct {
+	Pubkey                        hexutility.Bytes `json:"pubkey"`
+	ValidatorIndex                uint64           `json:"validator_index,string"`
+	ValidatorSyncCommitteeIndices []string         `json:"validator_sync_committee_indices"`
+}
+
+// dutiesState returns a copy of the head state advanced so that the duties of the requested epoch can be computed.
+// Only the duties up to the epoch following the current one can be known in advance.
+func (a *ApiHandler) dutiesState(ctx context.Context, epoch uint64) (*state.BeaconState, error) {
+	currentEpoch := a.forkchoiceStore.CurrentSlot() / a.beaconChainCfg.SlotsPerEpoch
+	if epoch > currentEpoch+1 {
+		return nil, newApiError(http.StatusBadRequest, "epoch %d is too far in the future, current epoch is %d", epoch, currentEpoch)
+	}
+	s, err := a.stateFromStateId(ctx, &segmentID{tag: segmentTagHead})
+	if err != nil {
+		return nil, err
+	}
+	stateEpoch := state.Epoch(s.BeaconState)
+	if epoch+1 < stateEpoch {
+		return nil, newApiError(http.StatusBadRequest, "epoch %d is too old, head is at epoch %d", epoch, stateEpoch)
+	}
+	// Head may be lagging behind the wall clock, catch up with empty slots.
+	if epoch > stateEpoch+a.beaconChainCfg.MinSeedLookahead {
+		if err := transition.ProcessSlots(s, (epoch-a.beaconChainCfg.MinSeedLookahead)*a.beaconChainCfg.SlotsPerEpoch); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// dependentRoot is the block root at the last slot of the epoch preceding the one determining the duties.
+func dependentRoot(s *state.BeaconState, epoch uint64) (libcommon.Hash, error) {
+	startSlot := epoch * s.BeaconConfig().SlotsPerEpoch
+	if startSlot == 0 || startSlot-1 >= s.Slot() {
+		return s.BlockRoot()
+	}
+	return s.GetBlockRootAtSlot(startSlot - 1)
+}
+
+// validatorIndicesFromBody decodes the list of validator indices posted to the duties endpoints.
+func validatorIndicesFromBody(r *http.Request) ([]uint64, error) {
+	var ids []string
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		return nil, newApiError(http.StatusBadRequest, "could not decode request body: %s", err)
+	}
+	indices := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		idx, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, newApiError(http.StatusBadRequest, "invalid validator index: %s", id)
+		}
+		indices = append(indices, idx)
+	}
+	return indices, nil
+}
+
+func (a *ApiHandler) getProposerDuties(r *http.Request) (*beaconResponse, error) {
+	epoch, err := uint64FromURLParam(r, "epoch")
+	if err != nil {
+		return nil, err
+	}
+	s, err := a.dutiesState(r.Context(), epoch)
+	if err != nil {
+		return nil, err
+	}
+	// Proposers are shuffled with the active set and balances of their own epoch, so the state has to be within it.
+	if stateEpoch := state.Epoch(s.BeaconState); epoch < stateEpoch {
+		return nil, newApiError(http.StatusBadRequest, "proposer duties of epoch %d are no longer available, head is at epoch %d", epoch, stateEpoch)
+	}
+	if startSlot := epoch * a.beaconChainCfg.SlotsPerEpoch; s.Slot() < startSlot {
+		if err := transition.ProcessSlots(s, startSlot); err != nil {
+			return nil, err
+		}
+	}
+	duties := make([]proposerDuty, 0, a.beaconChainCfg.SlotsPerEpoch)
+	startSlot := epoch * a.beaconChainCfg.SlotsPerEpoch
+	for slot := startSlot; slot < startSlot+a.beaconChainCfg.SlotsPerEpoch; slot++ {
+		// The genesis slot has no proposer.
+		if slot == 0 {
+			continue
+		}
+		proposerIndex, err := s.GetBeaconProposerIndexForSlot(slot)
+		if err != nil {
+			return nil, err
+		}
+		validator, err := s.ValidatorForValidatorIndex(int(proposerIndex))
+		if err != nil {
+			return nil, err
+		}
+		duties = append(duties, proposerDuty{
+			Pubkey:         validator.PublicKeyBytes(),
+			ValidatorIndex: proposerIndex,
+			Slot:           slot,
+		})
+	}
+	root, err := dependentRoot(s, epoch)
+	if err != nil {
+		return nil, err
+	}
+	return newBeaconResponse(duties).withExtraField("dependent_root", root).withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getAttesterDuties(r *http.Request) (*beaconResponse, error) {
+	epoch, err := uint64FromURLParam(r, "epoch")
+	if err != nil {
+		return nil, err
+	}
+	indices, err := validatorIndicesFromBody(r)
+	if err != nil {
+		return nil, err
+	}
+	s, err := a.dutiesState(r.Context(), epoch)
+	if err != nil {
+		return nil, err
+	}
+	requested := make(map[uint64]struct{}, len(indices))
+	for _, idx := range indices {
+		requested[idx] = struct{}{}
+	}
+	duties := []attesterDuty{}
+	committeesPerSlot := s.CommitteeCount(epoch)
+	startSlot := epoch * a.beaconChainCfg.SlotsPerEpoch
+	for slot := startSlot; slot < startSlot+a.beaconChainCfg.SlotsPerEpoch; slot++ {
+		for committeeIndex := uint64(0); committeeIndex < committeesPerSlot; committeeIndex++ {
+			committee, err := s.GetBeaconCommitee(slot, committeeIndex)
+			if err != nil {
+				return nil, err
+			}
+			for position, validatorIndex := range committee {
+				if _, ok := requested[validatorIndex]; !ok {
+					continue
+				}
+				validator, err := s.ValidatorForValidatorIndex(int(validatorIndex))
+				if err != nil {
+					return nil, err
+				}
+				duties = append(duties, attesterDuty{
+					Pubkey:                  validator.PublicKeyBytes(),
+					ValidatorIndex:          validatorIndex,
+					CommitteeIndex:          committeeIndex,
+					CommitteeLength:         uint64(len(committee)),
+					CommitteesAtSlot:        committeesPerSlot,
+					ValidatorCommitteeIndex: uint64(position),
+					Slot:                    slot,
+				})
+			}
+		}
+	}
+	// Attester duties depend on the shuffling decided one epoch in advance.
+	dependentEpoch := epoch
+	if dependentEpoch > 0 {
+		dependentEpoch--
+	}
+	root, err := dependentRoot(s, dependentEpoch)
+	if err != nil {
+		return nil, err
+	}
+	return newBeaconResponse(duties).withExtraField("dependent_root", root).withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getSyncDuties(r *http.Request) (*beaconResponse, error) {
+	epoch, err := uint64FromURLParam(r, "epoch")
+	if err != nil {
+		return nil, err
+	}
+	indices, err := validatorIndicesFromBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if epoch < a.beaconChainCfg.AltairForkEpoch {
+		return nil, newApiError(http.StatusBadRequest, "sync committees are not available before altair")
+	}
+	s, err := a.stateFromStateId(r.Context(), &segmentID{tag: segmentTagHead})
+	if err != nil {
+		return nil, err
+	}
+	period := epoch / a.beaconChainCfg.EpochsPerSyncCommitteePeriod
+	statePeriod := state.Epoch(s.BeaconState) / a.beaconChainCfg.EpochsPerSyncCommitteePeriod
+	var committee [][48]byte
+	switch period {
+	case statePeriod:
+		committee = s.CurrentSyncCommittee().GetCommittee()
+	case statePeriod + 1:
+		committee = s.NextSyncCommittee().GetCommittee()
+	default:
+		return nil, newApiError(http.StatusBadRequest, "epoch %d is not within the current or next sync committee period", epoch)
+	}
+	duties := []syncDuty{}
+	for _, idx := range indices {
+		validator, err := s.ValidatorForValidatorIndex(int(idx))
+		if err != nil {
+			return nil, newApiError(http.StatusBadRequest, "unknown validator index %d", idx)
+		}
+		pk := validator.PublicKey()
+		var positions []string
+		for position, member := range committee {
+			if member == pk {
+				positions = append(positions, strconv.Itoa(position))
+			}
+		}
+		if len(positions) == 0 {
+			continue
+		}
+		duties = append(duties, syncDuty{
+			Pubkey:                        validator.PublicKeyBytes(),
+			ValidatorIndex:                idx,
+			ValidatorSyncCommitteeIndices: positions,
+		})
+	}
+	return newBeaconResponse(duties).withExecutionOptimistic(false), nil
+}
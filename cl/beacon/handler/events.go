@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// eventData converts the event payload into its JSON view.
+func (a *ApiHandler) eventData(ev *beaconevents.Event) interface{} {
+	switch data := ev.Data.(type) {
+	case *solid.Attestation:
+		return newAttestationJSON(data)
+	case *cltypes.SignedVoluntaryExit:
+		return newVoluntaryExitJSON(data)
+	case *cltypes.SignedContributionAndProof:
+		return newContributionAndProofJSON(data)
+	case *cltypes.LightClientFinalityUpdate:
+		return versionedData{
+			Version: clparams.ClVersionToString(a.versionAtSlot(data.AttestedHeader.Beacon.Slot)),
+			Data:    newLightClientFinalityUpdateJSON(data),
+		}
+	case *cltypes.LightClientOptimisticUpdate:
+		return versionedData{
+			Version: clparams.ClVersionToString(a.versionAtSlot(data.AttestedHeader.Beacon.Slot)),
+			Data:    newLightClientOptimisticUpdateJSON(data),
+		}
+	default:
+		return data
+	}
+}
+
+// getEvents streams the requested topics as server-sent events until the client goes away. Clients which cannot
+// keep up are disconnected by the emitter and are expected to reconnect.
+func (a *ApiHandler) getEvents(w http.ResponseWriter, r *http.Request) {
+	if a.emitter == nil {
+		notImplemented(w, r)
+		return
+	}
+	topics := stringListFromQueryParam(r, "topics")
+	if len(topics) == 0 {
+		writeApiError(w, r, newApiError(http.StatusBadRequest, "no topics requested"))
+		return
+	}
+	for _, topic := range topics {
+		if !beaconevents.IsKnownTopic(topic) {
+			writeApiError(w, r, newApiError(http.StatusBadRequest, "invalid topic: %s", topic))
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeApiError(w, r, fmt.Errorf("streaming is not supported by the connection"))
+		return
+	}
+	sub, unsubscribe := a.emitter.Subscribe(topics, beaconevents.DefaultSubscriptionBufferSize)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-sub.Events():
+			if !ok {
+				log.Debug("[Beacon API] dropping slow events subscriber", "remote", r.RemoteAddr)
+				return
+			}
+			data, err := json.Marshal(a.eventData(ev))
+			if err != nil {
+				log.Warn("[Beacon API] failed to encode event", "topic", ev.Topic, "err", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Topic, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ledgerwatch/erigon-lib/types/ssz"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// apiError is an error carrying the http status code which should be returned to the caller.
+type apiError struct {
+	code int
+	err  error
+}
+
+func newApiError(code int, format string, args ...interface{}) *apiError {
+	return &apiError{code: code, err: fmt.Errorf(format, args...)}
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+type apiErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// beaconResponse is the envelope shared by all the Beacon API responses.
+type beaconResponse struct {
+	Data                interface{}
+	Finalized           *bool
+	Version             *clparams.StateVersion
+	ExecutionOptimistic *bool
+	// extraFields are additional top-level fields required by some endpoints (e.g. dependent_root).
+	extraFields map[string]interface{}
+	// sszData is what gets served if the client asked for application/octet-stream.
+	sszData ssz.Marshaler
+}
+
+func newBeaconResponse(data interface{}) *beaconResponse {
+	return &beaconResponse{Data: data}
+}
+
+func (r *beaconResponse) withFinalized(finalized bool) *beaconResponse {
+	r.Finalized = &finalized
+	return r
+}
+
+func (r *beaconResponse) withExecutionOptimistic(optimistic bool) *beaconResponse {
+	r.ExecutionOptimistic = &optimistic
+	return r
+}
+
+func (r *beaconResponse) withVersion(version clparams.StateVersion) *beaconResponse {
+	r.Version = &version
+	return r
+}
+
+func (r *beaconResponse) withExtraField(key string, value interface{}) *beaconResponse {
+	if r.extraFields == nil {
+		r.extraFields = make(map[string]interface{})
+	}
+	r.extraFields[key] = value
+	return r
+}
+
+func (r *beaconResponse) withSSZ(obj ssz.Marshaler) *beaconResponse {
+	r.sszData = obj
+	return r
+}
+
+func (r *beaconResponse) MarshalJSON() ([]byte, error) {
+	fields := map[string]interface{}{}
+	for k, v := range r.extraFields {
+		fields[k] = v
+	}
+	fields["data"] = r.Data
+	if r.Finalized != nil {
+		fields["finalized"] = *r.Finalized
+	}
+	if r.ExecutionOptimistic != nil {
+		fields["execution_optimistic"] = *r.ExecutionOptimistic
+	}
+	if r.Version != nil {
+		fields["version"] = clparams.ClVersionToString(*r.Version)
+	}
+	return json.Marshal(fields)
+}
+
+type beaconHandlerFn func(r *http.Request) (*beaconResponse, error)
+
+// beaconHandlerWrapper takes care of content negotiation and error formatting for the Beacon API handlers.
+func beaconHandlerWrapper(fn beaconHandlerFn, supportSSZ bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wantsSSZ := supportSSZ && strings.Contains(r.Header.Get("Accept"), "application/octet-stream")
+		resp, err := fn(r)
+		if err != nil {
+			writeApiError(w, r, err)
+			return
+		}
+		if resp.Version != nil {
+			w.Header().Set("Eth-Consensus-Version", clparams.ClVersionToString(*resp.Version))
+		}
+		if wantsSSZ {
+			if resp.sszData == nil {
+				writeApiError(w, r, newApiError(http.StatusNotAcceptable, "ssz encoding is not available for this resource"))
+				return
+			}
+			encoded, err := resp.sszData.EncodeSSZ(nil)
+			if err != nil {
+				writeApiError(w, r, err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write(encoded); err != nil {
+				log.Debug("[Beacon API] failed to write response", "path", r.URL.Path, "err", err)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Debug("[Beacon API] failed to write response", "path", r.URL.Path, "err", err)
+		}
+	}
+}
+
+func writeApiError(w http.ResponseWriter, r *http.Request, err error) {
+	code := http.StatusInternalServerError
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		code = apiErr.code
+	} else {
+		log.Warn("[Beacon API] request failed", "path", r.URL.Path, "err", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(apiErrorResponse{Code: code, Message: err.Error()})
+}
+
+// notImplemented is used for the routes Caplin does not serve yet.
+func notImplemented(w http.ResponseWriter, r *http.Request) {
+	writeApiError(w, r, newApiError(http.StatusNotImplemented, "%s is not implemented", r.URL.Path))
+}
@@ -5,18 +5,30 @@ import (
 	"sync"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
 	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/phase1/blob_storage"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+	"github.com/ledgerwatch/erigon/cl/phase1/light_client"
+	"github.com/ledgerwatch/erigon/cl/phase1/state_regen"
 )
 
 type ApiHandler struct {
-	o              sync.Once
-	mux            chi.Router
-	genesisCfg     *clparams.GenesisConfig
-	beaconChainCfg *clparams.BeaconChainConfig
+	o               sync.Once
+	mux             chi.Router
+	genesisCfg      *clparams.GenesisConfig
+	beaconChainCfg  *clparams.BeaconChainConfig
+	forkchoiceStore *forkchoice.ForkChoiceStore
+	emitter         *beaconevents.Emitter
+	indicesDB       kv.RoDB                  // optional, used to serve finalized blocks no longer held by forkchoice.
+	stateRegen      *state_regen.Regenerator // optional, used to serve finalized states no longer held by forkchoice.
+	lightClient     *light_client.Store      // optional, used to serve light clients.
+	blobStore       *blob_storage.BlobStore  // optional, used to serve blob sidecars.
 }
 
-func NewApiHandler(genesisConfig *clparams.GenesisConfig, beaconChainConfig *clparams.BeaconChainConfig) *ApiHandler {
-	return &ApiHandler{o: sync.Once{}, genesisCfg: genesisConfig, beaconChainCfg: beaconChainConfig}
+func NewApiHandler(genesisConfig *clparams.GenesisConfig, beaconChainConfig *clparams.BeaconChainConfig, forkchoiceStore *forkchoice.ForkChoiceStore, emitter *beaconevents.Emitter, indicesDB kv.RoDB, stateRegen *state_regen.Regenerator, lightClient *light_client.Store, blobStore *blob_storage.BlobStore) *ApiHandler {
+	return &ApiHandler{o: sync.Once{}, genesisCfg: genesisConfig, beaconChainCfg: beaconChainConfig, forkchoiceStore: forkchoiceStore, emitter: emitter, indicesDB: indicesDB, stateRegen: stateRegen, lightClient: lightClient, blobStore: blobStore}
 }
 
 func (a *ApiHandler) init() {
@@ -26,52 +38,68 @@ func (a *ApiHandler) init() {
 	// otterscn specific ones are commented as such
 	r.Route("/eth", func(r chi.Router) {
 		r.Route("/v1", func(r chi.Router) {
-			r.Get("/events", nil)
+			r.Get("/events", a.getEvents)
 			r.Route("/beacon", func(r chi.Router) {
-				r.Get("/headers/{tag}", nil)      // otterscan
-				r.Get("/blocks/{slot}/root", nil) //otterscan
+				r.Get("/headers/{block_id}", beaconHandlerWrapper(a.getBlockHeader, false))   // otterscan
+				r.Get("/blocks/{block_id}/root", beaconHandlerWrapper(a.getBlockRoot, false)) //otterscan
 				r.Get("/genesis", a.getGenesis)
-				r.Post("/binded_blocks", nil)
-				r.Post("/blocks", nil)
+				r.Get("/blob_sidecars/{block_id}", beaconHandlerWrapper(a.getBlobSidecars, true))
+				r.Post("/binded_blocks", notImplemented)
+				r.Post("/blocks", notImplemented)
 				r.Route("/pool", func(r chi.Router) {
-					r.Post("/attestations", nil)
-					r.Post("/sync_committees", nil)
+					r.Post("/attestations", notImplemented)
+					r.Post("/sync_committees", notImplemented)
+				})
+				r.Route("/light_client", func(r chi.Router) {
+					r.Get("/bootstrap/{block_root}", beaconHandlerWrapper(a.getLightClientBootstrap, true))
+					r.Get("/updates", a.getLightClientUpdates)
+					r.Get("/finality_update", beaconHandlerWrapper(a.getLightClientFinalityUpdate, true))
+					r.Get("/optimistic_update", beaconHandlerWrapper(a.getLightClientOptimisticUpdate, true))
+				})
+				r.Route("/rewards", func(r chi.Router) {
+					r.Post("/attestations/{epoch}", beaconHandlerWrapper(a.getAttestationRewards, false))
+					r.Get("/blocks/{block_id}", beaconHandlerWrapper(a.getBlockRewards, false))
+					r.Post("/sync_committee/{block_id}", beaconHandlerWrapper(a.getSyncCommitteeRewards, false))
 				})
-				r.Get("/node/syncing", nil)
-				r.Get("/config/spec", nil)
 				r.Route("/states", func(r chi.Router) {
-					r.Get("/head/validators/{index}", nil) // otterscan
-					r.Get("/head/committees", nil)         // otterscan
 					r.Route("/{state_id}", func(r chi.Router) {
-						r.Get("/validators", nil)
-						r.Get("/fork", nil)
-						r.Get("/validators/{id}", nil)
+						r.Get("/root", beaconHandlerWrapper(a.getStateRoot, false))
+						r.Get("/fork", beaconHandlerWrapper(a.getStateFork, false))
+						r.Get("/finality_checkpoints", beaconHandlerWrapper(a.getStateFinalityCheckpoints, false))
+						r.Get("/validators", beaconHandlerWrapper(a.getStateValidators, false))
+						r.Get("/validators/{validator_id}", beaconHandlerWrapper(a.getStateValidator, false)) // otterscan
+						r.Get("/committees", beaconHandlerWrapper(a.getStateCommittees, false))               // otterscan
 					})
 				})
 			})
+			r.Get("/node/syncing", beaconHandlerWrapper(a.getSyncing, false))
+			r.Get("/config/spec", beaconHandlerWrapper(a.getSpec, false))
 			r.Route("/validator", func(r chi.Router) {
 				r.Route("/duties", func(r chi.Router) {
-					r.Post("/attester/{epoch}", nil)
-					r.Get("/proposer/{epoch}", nil)
-					r.Post("/sync/{epoch}", nil)
+					r.Post("/attester/{epoch}", beaconHandlerWrapper(a.getAttesterDuties, false))
+					r.Get("/proposer/{epoch}", beaconHandlerWrapper(a.getProposerDuties, false))
+					r.Post("/sync/{epoch}", beaconHandlerWrapper(a.getSyncDuties, false))
 				})
-				r.Get("/blinded_blocks/{slot}", nil)
-				r.Get("/attestation_data", nil)
-				r.Get("/aggregate_attestation", nil)
-				r.Post("/aggregate_and_proofs", nil)
-				r.Post("/beacon_committee_subscriptions", nil)
-				r.Post("/sync_committee_subscriptions", nil)
-				r.Get("/sync_committee_contribution", nil)
-				r.Post("/contribution_and_proofs", nil)
-				r.Post("/prepare_beacon_proposer", nil)
+				r.Get("/blinded_blocks/{slot}", notImplemented)
+				r.Get("/attestation_data", notImplemented)
+				r.Get("/aggregate_attestation", notImplemented)
+				r.Post("/aggregate_and_proofs", notImplemented)
+				r.Post("/beacon_committee_subscriptions", notImplemented)
+				r.Post("/sync_committee_subscriptions", notImplemented)
+				r.Get("/sync_committee_contribution", notImplemented)
+				r.Post("/contribution_and_proofs", notImplemented)
+				r.Post("/prepare_beacon_proposer", notImplemented)
 			})
 		})
 		r.Route("/v2", func(r chi.Router) {
+			r.Route("/debug", func(r chi.Router) {
+				r.Get("/beacon/states/{state_id}", beaconHandlerWrapper(a.getState, true))
+			})
 			r.Route("/beacon", func(r chi.Router) {
-				r.Post("/blocks/{slot}", nil) //otterscan
+				r.Get("/blocks/{block_id}", beaconHandlerWrapper(a.getBlock, true)) //otterscan
 			})
 			r.Route("/validator", func(r chi.Router) {
-				r.Post("/blocks/{slot}", nil)
+				r.Post("/blocks/{slot}", notImplemented)
 			})
 		})
 	})
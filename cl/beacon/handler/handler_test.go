@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	libkzg "github.com/ledgerwatch/erigon-lib/crypto/kzg"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/blob_storage"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSegmentID(t *testing.T) {
+	id, err := parseSegmentID("head")
+	require.NoError(t, err)
+	require.Equal(t, segmentTagHead, id.tag)
+
+	id, err = parseSegmentID("1234")
+	require.NoError(t, err)
+	require.NotNil(t, id.slot)
+	require.Equal(t, uint64(1234), *id.slot)
+
+	id, err = parseSegmentID("0x0100000000000000000000000000000000000000000000000000000000000000")
+	require.NoError(t, err)
+	require.NotNil(t, id.root)
+	require.Equal(t, byte(1), id.root[0])
+
+	_, err = parseSegmentID("0x01")
+	require.Error(t, err)
+	_, err = parseSegmentID("latest")
+	require.Error(t, err)
+}
+
+func TestGetSpec(t *testing.T) {
+	_, _, beaconCfg := clparams.GetConfigsByNetwork(clparams.MainnetNetwork)
+	api := NewApiHandler(nil, beaconCfg, nil, nil, nil, nil, nil, nil)
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/eth/v1/config/spec")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var out struct {
+		Data map[string]string `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	require.Equal(t, "32", out.Data["SLOTS_PER_EPOCH"])
+	require.Equal(t, "0x01000000", out.Data["ALTAIR_FORK_VERSION"])
+}
+
+func TestGetEvents(t *testing.T) {
+	emitter := beaconevents.NewEmitter()
+	api := NewApiHandler(nil, nil, nil, emitter, nil, nil, nil, nil)
+
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/eth/v1/events?topics=unknown")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/eth/v1/events?topics=head,block")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	require.Eventually(t, func() bool { return emitter.SubscribersCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	emitter.Publish(beaconevents.TopicFinalizedCheckpoint, &beaconevents.FinalizedCheckpointData{})
+	emitter.Publish(beaconevents.TopicBlock, &beaconevents.BlockData{Slot: 10, Block: libcommon.Hash{1}})
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "event: block\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, `data: {"slot":"10","block":"0x0100000000000000000000000000000000000000000000000000000000000000","execution_optimistic":false}`+"\n", line)
+}
+
+// newTestForkchoice starts forkchoice from the anchor state of its own tests, one slot after the anchor.
+func newTestForkchoice(t *testing.T) (*forkchoice.ForkChoiceStore, *state.BeaconState) {
+	encoded, err := os.ReadFile("../../phase1/forkchoice/test_data/anchor_state.ssz_snappy")
+	require.NoError(t, err)
+	anchorState := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(anchorState, encoded, int(clparams.AltairVersion)))
+	store, err := forkchoice.NewForkChoiceStore(anchorState, nil, nil, nil, nil, false)
+	require.NoError(t, err)
+	store.OnTick(anchorState.GenesisTime() + (anchorState.Slot()+1)*clparams.MainnetBeaconConfig.SecondsPerSlot)
+	return store, anchorState
+}
+
+func TestGetStateBySlot(t *testing.T) {
+	store, anchorState := newTestForkchoice(t)
+	api := NewApiHandler(nil, &clparams.MainnetBeaconConfig, store, nil, nil, nil, nil, nil)
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	// the slot after the anchor has no block, its state is advanced from the anchor one
+	resp, err := http.Get(server.URL + "/eth/v1/beacon/states/" + strconv.FormatUint(anchorState.Slot()+1, 10) + "/root")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// slots after the current one are not advanced to
+	resp, err = http.Get(server.URL + "/eth/v1/beacon/states/" + strconv.FormatUint(anchorState.Slot()+2, 10) + "/root")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/eth/v1/beacon/states/100000000/root")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGetProposerDuties(t *testing.T) {
+	store, anchorState := newTestForkchoice(t)
+	cfg := &clparams.MainnetBeaconConfig
+	api := NewApiHandler(nil, cfg, store, nil, nil, nil, nil, nil)
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	currentEpoch := store.CurrentSlot() / cfg.SlotsPerEpoch
+	resp, err := http.Get(server.URL + "/eth/v1/validator/duties/proposer/" + strconv.FormatUint(currentEpoch+2, 10))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// the proposers of the previous epoch can no longer be computed from the head state
+	resp, err = http.Get(server.URL + "/eth/v1/validator/duties/proposer/" + strconv.FormatUint(state.Epoch(anchorState.BeaconState)-1, 10))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// the duties of the next epoch are computed on the state advanced into it
+	nextEpoch := currentEpoch + 1
+	resp, err = http.Get(server.URL + "/eth/v1/validator/duties/proposer/" + strconv.FormatUint(nextEpoch, 10))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var out struct {
+		Data []struct {
+			ValidatorIndex uint64 `json:"validator_index,string"`
+			Slot           uint64 `json:"slot,string"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	require.Len(t, out.Data, int(cfg.SlotsPerEpoch))
+
+	advanced, err := anchorState.Copy()
+	require.NoError(t, err)
+	require.NoError(t, transition.ProcessSlots(advanced, nextEpoch*cfg.SlotsPerEpoch))
+	for _, duty := range out.Data {
+		expected, err := advanced.GetBeaconProposerIndexForSlot(duty.Slot)
+		require.NoError(t, err)
+		require.Equal(t, expected, duty.ValidatorIndex)
+	}
+}
+
+// newBlobBlock returns a Deneb block with the given number of blobs, and the sidecars of its blobs.
+func newBlobBlock(t *testing.T, slot uint64, blobs int) (*cltypes.BeaconBlock, []*cltypes.BlobSidecar) {
+	version := clparams.DenebVersion
+	block := &cltypes.BeaconBlock{
+		Slot: slot,
+		Body: &cltypes.BeaconBody{
+			Eth1Data:           &cltypes.Eth1Data{},
+			ProposerSlashings:  solid.NewStaticListSSZ[*cltypes.ProposerSlashing](cltypes.MaxProposerSlashings, 416),
+			AttesterSlashings:  solid.NewDynamicListSSZ[*cltypes.AttesterSlashing](cltypes.MaxAttesterSlashings),
+			Attestations:       solid.NewDynamicListSSZ[*solid.Attestation](cltypes.MaxAttestations),
+			Deposits:           solid.NewStaticListSSZ[*cltypes.Deposit](cltypes.MaxDeposits, 1240),
+			VoluntaryExits:     solid.NewStaticListSSZ[*cltypes.SignedVoluntaryExit](cltypes.MaxVoluntaryExits, 112),
+			SyncAggregate:      &cltypes.SyncAggregate{},
+			ExecutionPayload:   cltypes.NewEth1Block(version),
+			ExecutionChanges:   solid.NewStaticListSSZ[*cltypes.SignedBLSToExecutionChange](cltypes.MaxExecutionChanges, 172),
+			BlobKzgCommitments: solid.NewStaticListSSZ[*cltypes.KZGCommitment](cltypes.MaxBlobsCommittmentsPerBlock, 48),
+			Version:            version,
+		},
+	}
+	sidecars := make([]*cltypes.BlobSidecar, blobs)
+	for i := range sidecars {
+		sidecar := &cltypes.BlobSidecar{Index: uint64(i), Slot: slot}
+		sidecar.Blob[1] = byte(i + 1)
+		commitment, err := libkzg.Ctx().BlobToKZGCommitment(gokzg4844.Blob(sidecar.Blob), 1)
+		require.NoError(t, err)
+		proof, err := libkzg.Ctx().ComputeBlobKZGProof(gokzg4844.Blob(sidecar.Blob), commitment, 1)
+		require.NoError(t, err)
+		sidecar.KzgCommitment, sidecar.KzgProof = cltypes.KZGCommitment(commitment), cltypes.KZGProof(proof)
+		block.Body.BlobKzgCommitments.Append(&sidecar.KzgCommitment)
+		sidecars[i] = sidecar
+	}
+	blockRoot, err := block.HashSSZ()
+	require.NoError(t, err)
+	for _, sidecar := range sidecars {
+		sidecar.BlockRoot = blockRoot
+	}
+	return block, sidecars
+}
+
+func TestGetBlobSidecars(t *testing.T) {
+	store, _ := newTestForkchoice(t)
+	cfg := &clparams.MainnetBeaconConfig
+	netCfg := clparams.NetworkConfigs[clparams.MainnetNetwork]
+	blobStore, err := blob_storage.NewBlobStore(&freezer.InMemory{}, cfg, &netCfg)
+	require.NoError(t, err)
+	block, sidecars := newBlobBlock(t, 10, 2)
+	require.NoError(t, blobStore.OnBlock(block))
+	for _, sidecar := range sidecars {
+		require.NoError(t, blobStore.OnBlobSidecar(sidecar))
+	}
+	server := httptest.NewServer(NewApiHandler(nil, cfg, store, nil, nil, nil, nil, blobStore))
+	defer server.Close()
+	url := server.URL + "/eth/v1/beacon/blob_sidecars/" + sidecars[0].BlockRoot.Hex()
+
+	get := func(url string) (indices []uint64) {
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var out struct {
+			Data []struct {
+				BlockRoot     libcommon.Hash   `json:"block_root"`
+				Index         uint64           `json:"index,string"`
+				KzgCommitment hexutility.Bytes `json:"kzg_commitment"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		for _, sidecar := range out.Data {
+			require.Equal(t, sidecars[sidecar.Index].BlockRoot, sidecar.BlockRoot)
+			require.Equal(t, sidecars[sidecar.Index].KzgCommitment[:], []byte(sidecar.KzgCommitment))
+			indices = append(indices, sidecar.Index)
+		}
+		return indices
+	}
+	require.Equal(t, []uint64{0, 1}, get(url))
+	require.Equal(t, []uint64{1}, get(url+"?indices=1"))
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/octet-stream")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	encoded, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, encoded, 2*sidecars[0].EncodingSizeSSZ())
+
+	for url, status := range map[string]int{
+		url + "?indices=" + strconv.FormatUint(cfg.MaxBlobsPerBlock, 10):       http.StatusBadRequest,
+		server.URL + "/eth/v1/beacon/blob_sidecars/" + libcommon.Hash{1}.Hex(): http.StatusNotFound,
+	} {
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, status, resp.StatusCode, url)
+	}
+
+	// without a blob store, blob sidecars are not served
+	noBlobs := httptest.NewServer(NewApiHandler(nil, cfg, store, nil, nil, nil, nil, nil))
+	defer noBlobs.Close()
+	resp, err = http.Get(noBlobs.URL + "/eth/v1/beacon/blob_sidecars/head")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
+
+func TestGetBlockFromIndices(t *testing.T) {
+	store, _ := newTestForkchoice(t)
+	cfg := clparams.MainnetBeaconConfig
+	cfg.AltairForkEpoch, cfg.BellatrixForkEpoch, cfg.CapellaForkEpoch, cfg.DenebForkEpoch = 0, 0, math.MaxUint64, math.MaxUint64
+
+	// a finalized block forkchoice pruned
+	block := new(cltypes.SignedBeaconBlock)
+	require.NoError(t, block.DecodeSSZ(rawdb.SSZTestBeaconBlock, int(clparams.BellatrixVersion)))
+	root, err := block.Block.HashSSZ()
+	require.NoError(t, err)
+	db := memdb.NewTestDB(t)
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		if err := rawdb.WriteBeaconBlock(tx, block); err != nil {
+			return err
+		}
+		return rawdb.WriteFinalizedBlockRoot(tx, block.Block.Slot, root)
+	}))
+	server := httptest.NewServer(NewApiHandler(nil, &cfg, store, nil, db, nil, nil, nil))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/eth/v1/beacon/blocks/" + strconv.FormatUint(block.Block.Slot, 10) + "/root")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var rootOut struct {
+		Data rootResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rootOut))
+	require.Equal(t, root, rootOut.Data.Root)
+
+	resp, err = http.Get(server.URL + "/eth/v1/beacon/headers/" + libcommon.Hash(root).Hex())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var headerOut struct {
+		Data headerResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&headerOut))
+	require.True(t, headerOut.Data.Canonical)
+	require.Equal(t, block.Block.Slot, headerOut.Data.Header.Message.Slot)
+
+	// neither forkchoice nor the indices know of the slot after it
+	resp, err = http.Get(server.URL + "/eth/v2/beacon/blocks/" + strconv.FormatUint(block.Block.Slot+1, 10))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, err = http.Get(server.URL + "/eth/v2/beacon/blocks/" + libcommon.Hash{1}.Hex())
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+)
+
+type segmentTag int
+
+const (
+	segmentTagNone segmentTag = iota
+	segmentTagHead
+	segmentTagGenesis
+	segmentTagFinalized
+	segmentTagJustified
+)
+
+// segmentID is the parsed form of the {block_id} and {state_id} path parameters.
+type segmentID struct {
+	tag  segmentTag
+	slot *uint64
+	root *libcommon.Hash
+}
+
+func parseSegmentID(id string) (*segmentID, error) {
+	switch id {
+	case "head":
+		return &segmentID{tag: segmentTagHead}, nil
+	case "genesis":
+		return &segmentID{tag: segmentTagGenesis}, nil
+	case "finalized":
+		return &segmentID{tag: segmentTagFinalized}, nil
+	case "justified":
+		return &segmentID{tag: segmentTagJustified}, nil
+	}
+	if strings.HasPrefix(id, "0x") {
+		b, err := hex.DecodeString(id[2:])
+		if err != nil || len(b) != length.Hash {
+			return nil, newApiError(http.StatusBadRequest, "invalid root: %s", id)
+		}
+		root := libcommon.BytesToHash(b)
+		return &segmentID{root: &root}, nil
+	}
+	slot, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, newApiError(http.StatusBadRequest, "invalid id: %s", id)
+	}
+	return &segmentID{slot: &slot}, nil
+}
+
+func blockIdFromRequest(r *http.Request) (*segmentID, error) {
+	return parseSegmentID(chi.URLParam(r, "block_id"))
+}
+
+func stateIdFromRequest(r *http.Request) (*segmentID, error) {
+	return parseSegmentID(chi.URLParam(r, "state_id"))
+}
+
+func uint64FromURLParam(r *http.Request, name string) (uint64, error) {
+	str := chi.URLParam(r, name)
+	n, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return 0, newApiError(http.StatusBadRequest, "invalid %s: %s", name, str)
+	}
+	return n, nil
+}
+
+// uint64FromQueryParam returns nil if the query parameter is absent.
+func uint64FromQueryParam(r *http.Request, name string) (*uint64, error) {
+	str := r.URL.Query().Get(name)
+	if str == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return nil, newApiError(http.StatusBadRequest, "invalid %s: %s", name, str)
+	}
+	return &n, nil
+}
+
+// stringListFromQueryParam accepts both repeated and comma separated query parameters.
+func stringListFromQueryParam(r *http.Request, name string) []string {
+	var out []string
+	for _, value := range r.URL.Query()[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+)
+
+// versionedData is an element of the responses listing objects of possibly different forks.
+type versionedData struct {
+	Version string      `json:"version"`
+	Data    interface{} `json:"data"`
+}
+
+func (a *ApiHandler) versionAtSlot(slot uint64) clparams.StateVersion {
+	return a.beaconChainCfg.GetCurrentStateVersion(slot / a.beaconChainCfg.SlotsPerEpoch)
+}
+
+func (a *ApiHandler) getLightClientBootstrap(r *http.Request) (*beaconResponse, error) {
+	if a.lightClient == nil {
+		return nil, newApiError(http.StatusNotImplemented, "light client data is not served")
+	}
+	blockId, err := parseSegmentID(chi.URLParam(r, "block_root"))
+	if err != nil {
+		return nil, err
+	}
+	if blockId.root == nil {
+		return nil, newApiError(http.StatusBadRequest, "invalid block root: %s", chi.URLParam(r, "block_root"))
+	}
+	bootstrap, err := a.lightClient.Bootstrap(*blockId.root)
+	if err != nil {
+		return nil, err
+	}
+	if bootstrap == nil {
+		return nil, newApiError(http.StatusNotFound, "no bootstrap available for block %x", *blockId.root)
+	}
+	return newBeaconResponse(newLightClientBootstrapJSON(bootstrap)).
+		withVersion(a.versionAtSlot(bootstrap.Header.Beacon.Slot)).
+		withSSZ(bootstrap), nil
+}
+
+// getLightClientUpdates serves the best updates of a range of sync committee periods. Unlike the other endpoints it
+// answers with a bare list, each update carrying its own version.
+func (a *ApiHandler) getLightClientUpdates(w http.ResponseWriter, r *http.Request) {
+	if a.lightClient == nil {
+		notImplemented(w, r)
+		return
+	}
+	startPeriod, err := uint64FromQueryParam(r, "start_period")
+	if err != nil {
+		writeApiError(w, r, err)
+		return
+	}
+	count, err := uint64FromQueryParam(r, "count")
+	if err != nil {
+		writeApiError(w, r, err)
+		return
+	}
+	if startPeriod == nil || count == nil {
+		writeApiError(w, r, newApiError(http.StatusBadRequest, "start_period and count are required"))
+		return
+	}
+	n := *count
+	if n > maxLightClientUpdates {
+		n = maxLightClientUpdates
+	}
+	updates, err := a.lightClient.Updates(*startPeriod, n)
+	if err != nil {
+		writeApiError(w, r, err)
+		return
+	}
+	out := make([]versionedData, 0, len(updates))
+	for _, update := range updates {
+		out = append(out, versionedData{
+			Version: clparams.ClVersionToString(a.versionAtSlot(update.AttestedHeader.Beacon.Slot)),
+			Data:    newLightClientUpdateJSON(update),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		log.Debug("[Beacon API] failed to write response", "path", r.URL.Path, "err", err)
+	}
+}
+
+// maxLightClientUpdates is MAX_REQUEST_LIGHT_CLIENT_UPDATES.
+const maxLightClientUpdates = 128
+
+func (a *ApiHandler) getLightClientFinalityUpdate(r *http.Request) (*beaconResponse, error) {
+	if a.lightClient == nil {
+		return nil, newApiError(http.StatusNotImplemented, "light client data is not served")
+	}
+	update := a.lightClient.FinalityUpdate()
+	if update == nil {
+		return nil, newApiError(http.StatusNotFound, "no finality update available")
+	}
+	return newBeaconResponse(newLightClientFinalityUpdateJSON(update)).
+		withVersion(a.versionAtSlot(update.AttestedHeader.Beacon.Slot)).
+		withSSZ(update), nil
+}
+
+func (a *ApiHandler) getLightClientOptimisticUpdate(r *http.Request) (*beaconResponse, error) {
+	if a.lightClient == nil {
+		return nil, newApiError(http.StatusNotImplemented, "light client data is not served")
+	}
+	update := a.lightClient.OptimisticUpdate()
+	if update == nil {
+		return nil, newApiError(http.StatusNotFound, "no optimistic update available")
+	}
+	return newBeaconResponse(newLightClientOptimisticUpdateJSON(update)).
+		withVersion(a.versionAtSlot(update.AttestedHeader.Beacon.Slot)).
+		withSSZ(update), nil
+}
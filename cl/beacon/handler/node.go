@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+type syncingResponse struct {
+	HeadSlot     uint64 `json:"head_slot,string"`
+	SyncDistance uint64 `json:"sync_distance,string"`
+	IsSyncing    bool   `json:"is_syncing"`
+	IsOptimistic bool   `json:"is_optimistic"`
+	ElOffline    bool   `json:"el_offline"`
+}
+
+func (a *ApiHandler) getSyncing(r *http.Request) (*beaconResponse, error) {
+	_, headSlot, err := a.forkchoiceStore.GetHead()
+	if err != nil {
+		return nil, err
+	}
+	currentSlot := utils.GetCurrentSlot(a.genesisCfg.GenesisTime, a.beaconChainCfg.SecondsPerSlot)
+	var syncDistance uint64
+	if currentSlot > headSlot {
+		syncDistance = currentSlot - headSlot
+	}
+	return newBeaconResponse(syncingResponse{
+		HeadSlot:     headSlot,
+		SyncDistance: syncDistance,
+		// Allow the head to lag by one slot, the block for the current slot may still be in flight.
+		IsSyncing: syncDistance > 1,
+		ElOffline: a.forkchoiceStore.Engine() == nil,
+	}), nil
+}
+
+// getSpec serves all the fields of the beacon chain config tagged as part of the spec, keyed by their yaml name.
+func (a *ApiHandler) getSpec(r *http.Request) (*beaconResponse, error) {
+	spec := make(map[string]string)
+	cfgValue := reflect.ValueOf(a.beaconChainCfg).Elem()
+	cfgType := cfgValue.Type()
+	for i := 0; i < cfgType.NumField(); i++ {
+		field := cfgType.Field(i)
+		if field.Tag.Get("spec") != "true" {
+			continue
+		}
+		name := field.Tag.Get("yaml")
+		if name == "" {
+			continue
+		}
+		value := cfgValue.Field(i)
+		switch value.Kind() {
+		case reflect.Uint32:
+			// Fork versions are stored as integers but served as 4 bytes, like in the yaml config.
+			if strings.HasSuffix(name, "_VERSION") {
+				spec[name] = fmt.Sprintf("0x%08x", value.Uint())
+				continue
+			}
+			spec[name] = strconv.FormatUint(value.Uint(), 10)
+		case reflect.Uint8, reflect.Uint16, reflect.Uint64:
+			spec[name] = strconv.FormatUint(value.Uint(), 10)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			spec[name] = strconv.FormatInt(value.Int(), 10)
+		case reflect.String:
+			spec[name] = value.String()
+		case reflect.Array:
+			b := make([]byte, value.Len())
+			reflect.Copy(reflect.ValueOf(b), value)
+			spec[name] = "0x" + hex.EncodeToString(b)
+		default:
+			spec[name] = fmt.Sprintf("%v", value.Interface())
+		}
+	}
+	return newBeaconResponse(spec), nil
+}
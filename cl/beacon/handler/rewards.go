@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+)
+
+type idealAttestationRewardJSON struct {
+	EffectiveBalance uint64  `json:"effective_balance,string"`
+	Head             int64   `json:"head,string"`
+	Target           int64   `json:"target,string"`
+	Source           int64   `json:"source,string"`
+	InclusionDelay   *uint64 `json:"inclusion_delay,string,omitempty"`
+	Inactivity       int64   `json:"inactivity,string"`
+}
+
+type attestationRewardJSON struct {
+	ValidatorIndex uint64  `json:"validator_index,string"`
+	Head           int64   `json:"head,string"`
+	Target         int64   `json:"target,string"`
+	Source         int64   `json:"source,string"`
+	InclusionDelay *uint64 `json:"inclusion_delay,string,omitempty"`
+	Inactivity     int64   `json:"inactivity,string"`
+}
+
+type attestationRewardsResponse struct {
+	IdealRewards []idealAttestationRewardJSON `json:"ideal_rewards"`
+	TotalRewards []attestationRewardJSON      `json:"total_rewards"`
+}
+
+type blockRewardsResponse struct {
+	ProposerIndex     uint64 `json:"proposer_index,string"`
+	Total             uint64 `json:"total,string"`
+	Attestations      uint64 `json:"attestations,string"`
+	SyncAggregate     uint64 `json:"sync_aggregate,string"`
+	ProposerSlashings uint64 `json:"proposer_slashings,string"`
+	AttesterSlashings uint64 `json:"attester_slashings,string"`
+}
+
+type syncCommitteeRewardJSON struct {
+	ValidatorIndex uint64 `json:"validator_index,string"`
+	Reward         int64  `json:"reward,string"`
+}
+
+// validatorIdsFromBody decodes the optional list of validator ids, indices or public keys, posted to the rewards
+// endpoints. An empty body selects all the validators.
+func validatorIdsFromBody(r *http.Request) ([]string, error) {
+	var ids []string
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil && !errors.Is(err, io.EOF) {
+		return nil, newApiError(http.StatusBadRequest, "could not decode request body: %s", err)
+	}
+	return ids, nil
+}
+
+// validatorFilter resolves the requested validator ids in the given state, nil means all the validators.
+func validatorFilter(s *state.BeaconState, ids []string) (map[uint64]struct{}, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	filter := make(map[uint64]struct{}, len(ids))
+	for _, id := range ids {
+		idx, ok, err := validatorIndexFromId(s, id)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, newApiError(http.StatusBadRequest, "validator not found: %s", id)
+		}
+		filter[idx] = struct{}{}
+	}
+	return filter, nil
+}
+
+func filterIncludes(filter map[uint64]struct{}, idx uint64) bool {
+	if filter == nil {
+		return true
+	}
+	_, ok := filter[idx]
+	return ok
+}
+
+// blockPreState returns a copy of the state the block was applied to, advanced to the slot of the block.
+func (a *ApiHandler) blockPreState(ctx context.Context, block *cltypes.BeaconBlock) (*state.BeaconState, error) {
+	if block.Slot == a.beaconChainCfg.GenesisSlot {
+		return nil, newApiError(http.StatusBadRequest, "the genesis block has no rewards")
+	}
+	s, err := a.forkchoiceStore.GetFullState(block.ParentRoot)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		parentSlot := block.Slot - 1
+		if s, err = a.archivedState(ctx, &segmentID{slot: &parentSlot}); err != nil {
+			return nil, err
+		}
+	}
+	if s.Slot() < block.Slot {
+		if err := transition.ProcessSlots(s, block.Slot); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// blockFromRequest resolves the {block_id} of the request into a block and whether it is canonical.
+func (a *ApiHandler) blockFromRequest(r *http.Request) (*cltypes.SignedBeaconBlock, bool, error) {
+	blockId, err := blockIdFromRequest(r)
+	if err != nil {
+		return nil, false, err
+	}
+	root, err := a.rootFromBlockId(r.Context(), blockId)
+	if err != nil {
+		return nil, false, err
+	}
+	return a.blockByRoot(r.Context(), root)
+}
+
+// getAttestationRewards serves the rewards of the attestations of an epoch. They are applied, and thus available,
+// once the following epoch is over.
+func (a *ApiHandler) getAttestationRewards(r *http.Request) (*beaconResponse, error) {
+	epoch, err := uint64FromURLParam(r, "epoch")
+	if err != nil {
+		return nil, err
+	}
+	ids, err := validatorIdsFromBody(r)
+	if err != nil {
+		return nil, err
+	}
+	_, headSlot, err := a.forkchoiceStore.GetHead()
+	if err != nil {
+		return nil, err
+	}
+	// the state at the last slot of the following epoch, before its processing.
+	slot := (epoch+2)*a.beaconChainCfg.SlotsPerEpoch - 1
+	if slot > headSlot {
+		return nil, newApiError(http.StatusNotFound, "rewards of epoch %d are not available until epoch %d is over", epoch, epoch+1)
+	}
+	s, err := a.stateFromStateId(r.Context(), &segmentID{slot: &slot})
+	if err != nil {
+		return nil, err
+	}
+	filter, err := validatorFilter(s, ids)
+	if err != nil {
+		return nil, err
+	}
+	rewards, err := transition.AttestationRewards(s)
+	if err != nil {
+		return nil, err
+	}
+	ideal, err := transition.IdealAttestationRewards(s)
+	if err != nil {
+		return nil, err
+	}
+	// inclusion delays are only rewarded in phase0
+	phase0 := s.Version() == clparams.Phase0Version
+
+	resp := attestationRewardsResponse{
+		IdealRewards: make([]idealAttestationRewardJSON, 0, len(ideal)),
+		TotalRewards: make([]attestationRewardJSON, 0, len(rewards)),
+	}
+	for i := range ideal {
+		reward := idealAttestationRewardJSON{
+			EffectiveBalance: ideal[i].EffectiveBalance,
+			Head:             ideal[i].Head,
+			Target:           ideal[i].Target,
+			Source:           ideal[i].Source,
+			Inactivity:       ideal[i].Inactivity,
+		}
+		if phase0 {
+			reward.InclusionDelay = &ideal[i].InclusionDelay
+		}
+		resp.IdealRewards = append(resp.IdealRewards, reward)
+	}
+	for i := range rewards {
+		if !filterIncludes(filter, rewards[i].ValidatorIndex) {
+			continue
+		}
+		reward := attestationRewardJSON{
+			ValidatorIndex: rewards[i].ValidatorIndex,
+			Head:           rewards[i].Head,
+			Target:         rewards[i].Target,
+			Source:         rewards[i].Source,
+			Inactivity:     rewards[i].Inactivity,
+		}
+		if phase0 {
+			reward.InclusionDelay = &rewards[i].InclusionDelay
+		}
+		resp.TotalRewards = append(resp.TotalRewards, reward)
+	}
+	return newBeaconResponse(resp).
+		withFinalized(a.isFinalizedSlot(slot)).
+		withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getBlockRewards(r *http.Request) (*beaconResponse, error) {
+	block, canonical, err := a.blockFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	s, err := a.blockPreState(r.Context(), block.Block)
+	if err != nil {
+		return nil, err
+	}
+	rewards, err := transition.BlockRewards(s, block.Block)
+	if err != nil {
+		return nil, err
+	}
+	return newBeaconResponse(blockRewardsResponse{
+		ProposerIndex:     rewards.ProposerIndex,
+		Total:             rewards.Total(),
+		Attestations:      rewards.Attestations,
+		SyncAggregate:     rewards.SyncAggregate,
+		ProposerSlashings: rewards.ProposerSlashings,
+		AttesterSlashings: rewards.AttesterSlashings,
+	}).withFinalized(canonical && a.isFinalizedSlot(block.Block.Slot)).withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getSyncCommitteeRewards(r *http.Request) (*beaconResponse, error) {
+	block, canonical, err := a.blockFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	if block.Version() < clparams.AltairVersion {
+		return nil, newApiError(http.StatusBadRequest, "sync committees do not exist before altair")
+	}
+	ids, err := validatorIdsFromBody(r)
+	if err != nil {
+		return nil, err
+	}
+	s, err := a.blockPreState(r.Context(), block.Block)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := validatorFilter(s, ids)
+	if err != nil {
+		return nil, err
+	}
+	rewards, err := transition.SyncCommitteeRewards(s, block.Block.Body.SyncAggregate)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]syncCommitteeRewardJSON, 0, len(rewards))
+	for _, reward := range rewards {
+		if !filterIncludes(filter, reward.ValidatorIndex) {
+			continue
+		}
+		resp = append(resp, syncCommitteeRewardJSON{ValidatorIndex: reward.ValidatorIndex, Reward: reward.Reward})
+	}
+	return newBeaconResponse(resp).
+		withFinalized(canonical && a.isFinalizedSlot(block.Block.Slot)).
+		withExecutionOptimistic(false), nil
+}
@@ -0,0 +1,378 @@
+package handler
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cl/beacon/types"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+)
+
+type forkResponse struct {
+	PreviousVersion types.Bytes4 `json:"previous_version"`
+	CurrentVersion  types.Bytes4 `json:"current_version"`
+	Epoch           uint64       `json:"epoch,string"`
+}
+
+type finalityCheckpointsResponse struct {
+	PreviousJustified checkpointJSON `json:"previous_justified"`
+	CurrentJustified  checkpointJSON `json:"current_justified"`
+	Finalized         checkpointJSON `json:"finalized"`
+}
+
+type validatorResponse struct {
+	Index     uint64        `json:"index,string"`
+	Balance   uint64        `json:"balance,string"`
+	Status    string        `json:"status"`
+	Validator validatorJSON `json:"validator"`
+}
+
+type committeeResponse struct {
+	Index      uint64   `json:"index,string"`
+	Slot       uint64   `json:"slot,string"`
+	Validators []string `json:"validators"`
+}
+
+// stateFromStateId resolves a {state_id} into a copy of the matching beacon state, advanced to the requested slot if needed.
+func (a *ApiHandler) stateFromStateId(ctx context.Context, stateId *segmentID) (*state.BeaconState, error) {
+	var (
+		blockRoot  libcommon.Hash
+		targetSlot *uint64
+		err        error
+	)
+	switch {
+	case stateId.root != nil:
+		var ok bool
+		if blockRoot, ok = a.forkchoiceStore.GetBlockRootByStateRoot(*stateId.root); !ok {
+			return a.archivedState(ctx, stateId)
+		}
+	case stateId.slot != nil:
+		// Slots without a block are served by advancing the state of the closest canonical ancestor.
+		if currentSlot := a.forkchoiceStore.CurrentSlot(); *stateId.slot > currentSlot {
+			return nil, newApiError(http.StatusNotFound, "slot %d is in the future, current slot is %d", *stateId.slot, currentSlot)
+		}
+		targetSlot = stateId.slot
+		var found bool
+		if blockRoot, found, err = a.forkchoiceStore.GetCanonicalBlockRootAtOrBefore(*stateId.slot); err != nil {
+			return nil, err
+		}
+		if !found {
+			return a.archivedState(ctx, stateId)
+		}
+	default:
+		if blockRoot, err = a.rootFromBlockId(ctx, stateId); err != nil {
+			return nil, err
+		}
+	}
+	s, err := a.forkchoiceStore.GetFullState(blockRoot)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		if stateId.root != nil || stateId.slot != nil {
+			return a.archivedState(ctx, stateId)
+		}
+		return nil, newApiError(http.StatusNotFound, "state not available for block %x", blockRoot)
+	}
+	if targetSlot != nil && s.Slot() < *targetSlot {
+		if err := transition.ProcessSlots(s, *targetSlot); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// archivedState regenerates the state of a root or slot which forkchoice no longer holds from the state archive.
+// The state roots the archive does not know of are looked up among the finalized blocks of the indices database.
+func (a *ApiHandler) archivedState(ctx context.Context, stateId *segmentID) (*state.BeaconState, error) {
+	var (
+		s   *state.BeaconState
+		err error
+	)
+	if a.stateRegen != nil {
+		if stateId.root != nil {
+			s, err = a.stateRegen.StateByRoot(*stateId.root)
+			if err == nil && s == nil && a.indicesDB != nil {
+				var slot *uint64
+				if err = a.indicesDB.View(ctx, func(tx kv.Tx) error {
+					slot, _, err = rawdb.ReadBlockRootByStateRoot(tx, *stateId.root)
+					return err
+				}); err == nil && slot != nil {
+					s, err = a.stateRegen.State(*slot)
+				}
+			}
+		} else {
+			s, err = a.stateRegen.State(*stateId.slot)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s != nil {
+		return s, nil
+	}
+	if stateId.root != nil {
+		return nil, newApiError(http.StatusNotFound, "state not found %x", *stateId.root)
+	}
+	return nil, newApiError(http.StatusNotFound, "state not found for slot %d", *stateId.slot)
+}
+
+func (a *ApiHandler) stateFromRequest(r *http.Request) (*state.BeaconState, error) {
+	stateId, err := stateIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return a.stateFromStateId(r.Context(), stateId)
+}
+
+func (a *ApiHandler) getStateRoot(r *http.Request) (*beaconResponse, error) {
+	s, err := a.stateFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := s.HashSSZ()
+	if err != nil {
+		return nil, err
+	}
+	return newBeaconResponse(rootResponse{Root: root}).
+		withFinalized(a.isFinalizedSlot(s.Slot())).
+		withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getStateFork(r *http.Request) (*beaconResponse, error) {
+	s, err := a.stateFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	fork := s.Fork()
+	return newBeaconResponse(forkResponse{
+		PreviousVersion: fork.PreviousVersion,
+		CurrentVersion:  fork.CurrentVersion,
+		Epoch:           fork.Epoch,
+	}).withFinalized(a.isFinalizedSlot(s.Slot())).withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getStateFinalityCheckpoints(r *http.Request) (*beaconResponse, error) {
+	s, err := a.stateFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return newBeaconResponse(finalityCheckpointsResponse{
+		PreviousJustified: newCheckpointJSON(s.PreviousJustifiedCheckpoint()),
+		CurrentJustified:  newCheckpointJSON(s.CurrentJustifiedCheckpoint()),
+		Finalized:         newCheckpointJSON(s.FinalizedCheckpoint()),
+	}).withFinalized(a.isFinalizedSlot(s.Slot())).withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getState(r *http.Request) (*beaconResponse, error) {
+	s, err := a.stateFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	// The full state is only served as SSZ, the JSON view is limited to the header-like fields.
+	return newBeaconResponse(struct {
+		Slot                  uint64         `json:"slot,string"`
+		GenesisValidatorsRoot libcommon.Hash `json:"genesis_validators_root"`
+	}{
+		Slot:                  s.Slot(),
+		GenesisValidatorsRoot: s.GenesisValidatorsRoot(),
+	}).withSSZ(s).withVersion(s.Version()).withFinalized(a.isFinalizedSlot(s.Slot())).withExecutionOptimistic(false), nil
+}
+
+// validatorStatus computes the status of a validator as defined by the Beacon API.
+func validatorStatus(v solid.Validator, balance, epoch, farFutureEpoch uint64) string {
+	switch {
+	case v.ActivationEpoch() > epoch:
+		if v.ActivationEligibilityEpoch() == farFutureEpoch {
+			return "pending_initialized"
+		}
+		return "pending_queued"
+	case v.ExitEpoch() > epoch:
+		if v.ExitEpoch() == farFutureEpoch {
+			return "active_ongoing"
+		}
+		if v.Slashed() {
+			return "active_slashed"
+		}
+		return "active_exiting"
+	case v.WithdrawableEpoch() > epoch:
+		if v.Slashed() {
+			return "exited_slashed"
+		}
+		return "exited_unslashed"
+	case balance != 0:
+		return "withdrawal_possible"
+	default:
+		return "withdrawal_done"
+	}
+}
+
+// statusMatches checks a status against a filter, which may be either a precise status or a group (e.g. "active").
+func statusMatches(status string, filters map[string]struct{}) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	if _, ok := filters[status]; ok {
+		return true
+	}
+	_, ok := filters[status[:strings.Index(status, "_")]]
+	return ok
+}
+
+// validatorIndexFromId parses a validator id, which is either an index or a 0x prefixed public key.
+func validatorIndexFromId(s *state.BeaconState, id string) (uint64, bool, error) {
+	if strings.HasPrefix(id, "0x") {
+		b, err := hex.DecodeString(id[2:])
+		if err != nil || len(b) != 48 {
+			return 0, false, newApiError(http.StatusBadRequest, "invalid validator public key: %s", id)
+		}
+		var pk [48]byte
+		copy(pk[:], b)
+		idx, ok := s.ValidatorIndexByPubkey(pk)
+		return idx, ok, nil
+	}
+	idx, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, false, newApiError(http.StatusBadRequest, "invalid validator id: %s", id)
+	}
+	return idx, idx < uint64(s.ValidatorLength()), nil
+}
+
+func (a *ApiHandler) newValidatorResponse(s *state.BeaconState, idx uint64) (*validatorResponse, error) {
+	v, err := s.ValidatorForValidatorIndex(int(idx))
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.ValidatorBalance(int(idx))
+	if err != nil {
+		return nil, err
+	}
+	return &validatorResponse{
+		Index:     idx,
+		Balance:   balance,
+		Status:    validatorStatus(v, balance, state.Epoch(s.BeaconState), a.beaconChainCfg.FarFutureEpoch),
+		Validator: newValidatorJSON(v),
+	}, nil
+}
+
+func (a *ApiHandler) getStateValidators(r *http.Request) (*beaconResponse, error) {
+	s, err := a.stateFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	statusFilters := make(map[string]struct{})
+	for _, status := range stringListFromQueryParam(r, "status") {
+		statusFilters[status] = struct{}{}
+	}
+	out := []*validatorResponse{}
+	appendIfMatches := func(idx uint64) error {
+		validator, err := a.newValidatorResponse(s, idx)
+		if err != nil {
+			return err
+		}
+		if statusMatches(validator.Status, statusFilters) {
+			out = append(out, validator)
+		}
+		return nil
+	}
+	if ids := stringListFromQueryParam(r, "id"); len(ids) > 0 {
+		for _, id := range ids {
+			idx, ok, err := validatorIndexFromId(s, id)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if err := appendIfMatches(idx); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for idx := 0; idx < s.ValidatorLength(); idx++ {
+			if err := appendIfMatches(uint64(idx)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return newBeaconResponse(out).withFinalized(a.isFinalizedSlot(s.Slot())).withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getStateValidator(r *http.Request) (*beaconResponse, error) {
+	s, err := a.stateFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	idx, ok, err := validatorIndexFromId(s, chi.URLParam(r, "validator_id"))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, newApiError(http.StatusNotFound, "validator not found")
+	}
+	validator, err := a.newValidatorResponse(s, idx)
+	if err != nil {
+		return nil, err
+	}
+	return newBeaconResponse(validator).withFinalized(a.isFinalizedSlot(s.Slot())).withExecutionOptimistic(false), nil
+}
+
+func (a *ApiHandler) getStateCommittees(r *http.Request) (*beaconResponse, error) {
+	s, err := a.stateFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	stateEpoch := state.Epoch(s.BeaconState)
+	epoch := stateEpoch
+	epochFilter, err := uint64FromQueryParam(r, "epoch")
+	if err != nil {
+		return nil, err
+	}
+	if epochFilter != nil {
+		epoch = *epochFilter
+	}
+	// Committees can only be computed for the epochs whose randao mix is known to the state.
+	if epoch > stateEpoch+a.beaconChainCfg.MinSeedLookahead || epoch+a.beaconChainCfg.EpochsPerHistoricalVector-a.beaconChainCfg.MinSeedLookahead <= stateEpoch {
+		return nil, newApiError(http.StatusBadRequest, "epoch %d is out of range for state at epoch %d", epoch, stateEpoch)
+	}
+	indexFilter, err := uint64FromQueryParam(r, "index")
+	if err != nil {
+		return nil, err
+	}
+	slotFilter, err := uint64FromQueryParam(r, "slot")
+	if err != nil {
+		return nil, err
+	}
+	out := []committeeResponse{}
+	committeesPerSlot := s.CommitteeCount(epoch)
+	startSlot := epoch * a.beaconChainCfg.SlotsPerEpoch
+	for slot := startSlot; slot < startSlot+a.beaconChainCfg.SlotsPerEpoch; slot++ {
+		if slotFilter != nil && *slotFilter != slot {
+			continue
+		}
+		for committeeIndex := uint64(0); committeeIndex < committeesPerSlot; committeeIndex++ {
+			if indexFilter != nil && *indexFilter != committeeIndex {
+				continue
+			}
+			committee, err := s.GetBeaconCommitee(slot, committeeIndex)
+			if err != nil {
+				return nil, err
+			}
+			validators := make([]string, len(committee))
+			for i, idx := range committee {
+				validators[i] = strconv.FormatUint(idx, 10)
+			}
+			out = append(out, committeeResponse{Index: committeeIndex, Slot: slot, Validators: validators})
+		}
+	}
+	return newBeaconResponse(out).withFinalized(a.isFinalizedSlot(s.Slot())).withExecutionOptimistic(false), nil
+}
@@ -0,0 +1,380 @@
+package handler
+
+import (
+	"math/big"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// JSON views of the consensus objects, following the Beacon API conventions (quantities are decimal strings).
+
+type checkpointJSON struct {
+	Epoch uint64         `json:"epoch,string"`
+	Root  libcommon.Hash `json:"root"`
+}
+
+func newCheckpointJSON(c solid.Checkpoint) checkpointJSON {
+	return checkpointJSON{Epoch: c.Epoch(), Root: c.BlockRoot()}
+}
+
+type headerJSON struct {
+	Slot          uint64         `json:"slot,string"`
+	ProposerIndex uint64         `json:"proposer_index,string"`
+	ParentRoot    libcommon.Hash `json:"parent_root"`
+	StateRoot     libcommon.Hash `json:"state_root"`
+	BodyRoot      libcommon.Hash `json:"body_root"`
+}
+
+type signedHeaderJSON struct {
+	Message   headerJSON       `json:"message"`
+	Signature hexutility.Bytes `json:"signature"`
+}
+
+func newSignedHeaderJSON(signedBlock *cltypes.SignedBeaconBlock) (signedHeaderJSON, error) {
+	bodyRoot, err := signedBlock.Block.Body.HashSSZ()
+	if err != nil {
+		return signedHeaderJSON{}, err
+	}
+	return signedHeaderJSON{
+		Message: headerJSON{
+			Slot:          signedBlock.Block.Slot,
+			ProposerIndex: signedBlock.Block.ProposerIndex,
+			ParentRoot:    signedBlock.Block.ParentRoot,
+			StateRoot:     signedBlock.Block.StateRoot,
+			BodyRoot:      bodyRoot,
+		},
+		Signature: signedBlock.Signature[:],
+	}, nil
+}
+
+type attestationDataJSON struct {
+	Slot            uint64         `json:"slot,string"`
+	Index           uint64         `json:"index,string"`
+	BeaconBlockRoot libcommon.Hash `json:"beacon_block_root"`
+	Source          checkpointJSON `json:"source"`
+	Target          checkpointJSON `json:"target"`
+}
+
+func newAttestationDataJSON(data solid.AttestationData) attestationDataJSON {
+	return attestationDataJSON{
+		Slot:            data.Slot(),
+		Index:           data.ValidatorIndex(),
+		BeaconBlockRoot: data.BeaconBlockRoot(),
+		Source:          newCheckpointJSON(data.Source()),
+		Target:          newCheckpointJSON(data.Target()),
+	}
+}
+
+type attestationJSON struct {
+	AggregationBits hexutility.Bytes    `json:"aggregation_bits"`
+	Data            attestationDataJSON `json:"data"`
+	Signature       hexutility.Bytes    `json:"signature"`
+}
+
+func newAttestationJSON(a *solid.Attestation) attestationJSON {
+	signature := a.Signature()
+	return attestationJSON{
+		AggregationBits: common.CopyBytes(a.AggregationBits()),
+		Data:            newAttestationDataJSON(a.AttestantionData()),
+		Signature:       signature[:],
+	}
+}
+
+type voluntaryExitJSON struct {
+	Message struct {
+		Epoch          uint64 `json:"epoch,string"`
+		ValidatorIndex uint64 `json:"validator_index,string"`
+	} `json:"message"`
+	Signature hexutility.Bytes `json:"signature"`
+}
+
+func newVoluntaryExitJSON(e *cltypes.SignedVoluntaryExit) voluntaryExitJSON {
+	var out voluntaryExitJSON
+	out.Message.Epoch = e.VolunaryExit.Epoch
+	out.Message.ValidatorIndex = e.VolunaryExit.ValidatorIndex
+	out.Signature = common.CopyBytes(e.Signature[:])
+	return out
+}
+
+type contributionAndProofJSON struct {
+	Message struct {
+		AggregatorIndex uint64 `json:"aggregator_index,string"`
+		Contribution    struct {
+			Slot              uint64           `json:"slot,string"`
+			BeaconBlockRoot   libcommon.Hash   `json:"beacon_block_root"`
+			SubcommitteeIndex uint64           `json:"subcommittee_index,string"`
+			AggregationBits   hexutility.Bytes `json:"aggregation_bits"`
+			Signature         hexutility.Bytes `json:"signature"`
+		} `json:"contribution"`
+		SelectionProof hexutility.Bytes `json:"selection_proof"`
+	} `json:"message"`
+	Signature hexutility.Bytes `json:"signature"`
+}
+
+func newContributionAndProofJSON(c *cltypes.SignedContributionAndProof) contributionAndProofJSON {
+	var out contributionAndProofJSON
+	out.Message.AggregatorIndex = c.Message.AggregatorIndex
+	contribution := c.Message.Contribution
+	out.Message.Contribution.Slot = contribution.Slot
+	out.Message.Contribution.BeaconBlockRoot = contribution.BeaconBlockRoot
+	out.Message.Contribution.SubcommitteeIndex = contribution.SubcommitteeIndex
+	out.Message.Contribution.AggregationBits = common.CopyBytes(contribution.AggregationBits[:])
+	out.Message.Contribution.Signature = common.CopyBytes(contribution.Signature[:])
+	out.Message.SelectionProof = common.CopyBytes(c.Message.SelectionProof[:])
+	out.Signature = common.CopyBytes(c.Signature[:])
+	return out
+}
+
+type eth1DataJSON struct {
+	DepositRoot  libcommon.Hash `json:"deposit_root"`
+	DepositCount uint64         `json:"deposit_count,string"`
+	BlockHash    libcommon.Hash `json:"block_hash"`
+}
+
+type syncAggregateJSON struct {
+	SyncCommitteeBits      hexutility.Bytes `json:"sync_committee_bits"`
+	SyncCommitteeSignature hexutility.Bytes `json:"sync_committee_signature"`
+}
+
+// executionPayloadJSON omits the transactions and withdrawals lists, which are available through the SSZ encoding.
+type executionPayloadJSON struct {
+	ParentHash    libcommon.Hash    `json:"parent_hash"`
+	FeeRecipient  libcommon.Address `json:"fee_recipient"`
+	StateRoot     libcommon.Hash    `json:"state_root"`
+	ReceiptsRoot  libcommon.Hash    `json:"receipts_root"`
+	PrevRandao    libcommon.Hash    `json:"prev_randao"`
+	BlockNumber   uint64            `json:"block_number,string"`
+	GasLimit      uint64            `json:"gas_limit,string"`
+	GasUsed       uint64            `json:"gas_used,string"`
+	Timestamp     uint64            `json:"timestamp,string"`
+	BaseFeePerGas string            `json:"base_fee_per_gas"`
+	BlockHash     libcommon.Hash    `json:"block_hash"`
+}
+
+func newExecutionPayloadJSON(payload *cltypes.Eth1Block) *executionPayloadJSON {
+	// base fee is stored little endian.
+	baseFeeBytes := common.CopyBytes(payload.BaseFeePerGas[:])
+	for i, j := 0, len(baseFeeBytes)-1; i < j; i, j = i+1, j-1 {
+		baseFeeBytes[i], baseFeeBytes[j] = baseFeeBytes[j], baseFeeBytes[i]
+	}
+	baseFee := new(big.Int).SetBytes(baseFeeBytes)
+	return &executionPayloadJSON{
+		ParentHash:    payload.ParentHash,
+		FeeRecipient:  payload.FeeRecipient,
+		StateRoot:     payload.StateRoot,
+		ReceiptsRoot:  payload.ReceiptsRoot,
+		PrevRandao:    payload.PrevRandao,
+		BlockNumber:   payload.BlockNumber,
+		GasLimit:      payload.GasLimit,
+		GasUsed:       payload.GasUsed,
+		Timestamp:     payload.Time,
+		BaseFeePerGas: baseFee.String(),
+		BlockHash:     payload.BlockHash,
+	}
+}
+
+type blockBodyJSON struct {
+	RandaoReveal     hexutility.Bytes      `json:"randao_reveal"`
+	Eth1Data         eth1DataJSON          `json:"eth1_data"`
+	Graffiti         libcommon.Hash        `json:"graffiti"`
+	Attestations     []attestationJSON     `json:"attestations"`
+	VoluntaryExits   []voluntaryExitJSON   `json:"voluntary_exits"`
+	SyncAggregate    *syncAggregateJSON    `json:"sync_aggregate,omitempty"`
+	ExecutionPayload *executionPayloadJSON `json:"execution_payload,omitempty"`
+}
+
+type blockJSON struct {
+	Slot          uint64         `json:"slot,string"`
+	ProposerIndex uint64         `json:"proposer_index,string"`
+	ParentRoot    libcommon.Hash `json:"parent_root"`
+	StateRoot     libcommon.Hash `json:"state_root"`
+	Body          blockBodyJSON  `json:"body"`
+}
+
+type signedBlockJSON struct {
+	Message   blockJSON        `json:"message"`
+	Signature hexutility.Bytes `json:"signature"`
+}
+
+func newSignedBlockJSON(signedBlock *cltypes.SignedBeaconBlock) signedBlockJSON {
+	block := signedBlock.Block
+	body := block.Body
+	out := signedBlockJSON{
+		Message: blockJSON{
+			Slot:          block.Slot,
+			ProposerIndex: block.ProposerIndex,
+			ParentRoot:    block.ParentRoot,
+			StateRoot:     block.StateRoot,
+			Body: blockBodyJSON{
+				RandaoReveal:   common.CopyBytes(body.RandaoReveal[:]),
+				Graffiti:       body.Graffiti,
+				Attestations:   []attestationJSON{},
+				VoluntaryExits: []voluntaryExitJSON{},
+			},
+		},
+		Signature: common.CopyBytes(signedBlock.Signature[:]),
+	}
+	if body.Eth1Data != nil {
+		out.Message.Body.Eth1Data = eth1DataJSON{
+			DepositRoot:  body.Eth1Data.Root,
+			DepositCount: body.Eth1Data.DepositCount,
+			BlockHash:    body.Eth1Data.BlockHash,
+		}
+	}
+	if body.Attestations != nil {
+		body.Attestations.Range(func(_ int, a *solid.Attestation, _ int) bool {
+			out.Message.Body.Attestations = append(out.Message.Body.Attestations, newAttestationJSON(a))
+			return true
+		})
+	}
+	if body.VoluntaryExits != nil {
+		body.VoluntaryExits.Range(func(_ int, e *cltypes.SignedVoluntaryExit, _ int) bool {
+			out.Message.Body.VoluntaryExits = append(out.Message.Body.VoluntaryExits, newVoluntaryExitJSON(e))
+			return true
+		})
+	}
+	if body.SyncAggregate != nil && signedBlock.Version() >= clparams.AltairVersion {
+		out.Message.Body.SyncAggregate = &syncAggregateJSON{
+			SyncCommitteeBits:      common.CopyBytes(body.SyncAggregate.SyncCommiteeBits[:]),
+			SyncCommitteeSignature: common.CopyBytes(body.SyncAggregate.SyncCommiteeSignature[:]),
+		}
+	}
+	if body.ExecutionPayload != nil && signedBlock.Version() >= clparams.BellatrixVersion {
+		out.Message.Body.ExecutionPayload = newExecutionPayloadJSON(body.ExecutionPayload)
+	}
+	return out
+}
+
+type validatorJSON struct {
+	Pubkey                     hexutility.Bytes `json:"pubkey"`
+	WithdrawalCredentials      libcommon.Hash   `json:"withdrawal_credentials"`
+	EffectiveBalance           uint64           `json:"effective_balance,string"`
+	Slashed                    bool             `json:"slashed"`
+	ActivationEligibilityEpoch uint64           `json:"activation_eligibility_epoch,string"`
+	ActivationEpoch            uint64           `json:"activation_epoch,string"`
+	ExitEpoch                  uint64           `json:"exit_epoch,string"`
+	WithdrawableEpoch          uint64           `json:"withdrawable_epoch,string"`
+}
+
+func newValidatorJSON(v solid.Validator) validatorJSON {
+	return validatorJSON{
+		Pubkey:                     v.PublicKeyBytes(),
+		WithdrawalCredentials:      v.WithdrawalCredentials(),
+		EffectiveBalance:           v.EffectiveBalance(),
+		Slashed:                    v.Slashed(),
+		ActivationEligibilityEpoch: v.ActivationEligibilityEpoch(),
+		ActivationEpoch:            v.ActivationEpoch(),
+		ExitEpoch:                  v.ExitEpoch(),
+		WithdrawableEpoch:          v.WithdrawableEpoch(),
+	}
+}
+
+type lightClientHeaderJSON struct {
+	Beacon headerJSON `json:"beacon"`
+}
+
+func newLightClientHeaderJSON(h *cltypes.LightClientHeader) lightClientHeaderJSON {
+	return lightClientHeaderJSON{Beacon: headerJSON{
+		Slot:          h.Beacon.Slot,
+		ProposerIndex: h.Beacon.ProposerIndex,
+		ParentRoot:    h.Beacon.ParentRoot,
+		StateRoot:     h.Beacon.Root,
+		BodyRoot:      h.Beacon.BodyRoot,
+	}}
+}
+
+type syncCommitteeJSON struct {
+	Pubkeys         []hexutility.Bytes `json:"pubkeys"`
+	AggregatePubkey hexutility.Bytes   `json:"aggregate_pubkey"`
+}
+
+func newSyncCommitteeJSON(c *solid.SyncCommittee) syncCommitteeJSON {
+	committee := c.GetCommittee()
+	out := syncCommitteeJSON{Pubkeys: make([]hexutility.Bytes, len(committee))}
+	for i := range committee {
+		out.Pubkeys[i] = common.CopyBytes(committee[i][:])
+	}
+	aggregate := c.AggregatePublicKey()
+	out.AggregatePubkey = aggregate[:]
+	return out
+}
+
+func newSyncAggregateJSON(a *cltypes.SyncAggregate) syncAggregateJSON {
+	return syncAggregateJSON{
+		SyncCommitteeBits:      common.CopyBytes(a.SyncCommiteeBits[:]),
+		SyncCommitteeSignature: common.CopyBytes(a.SyncCommiteeSignature[:]),
+	}
+}
+
+type lightClientBootstrapJSON struct {
+	Header                     lightClientHeaderJSON `json:"header"`
+	CurrentSyncCommittee       syncCommitteeJSON     `json:"current_sync_committee"`
+	CurrentSyncCommitteeBranch []libcommon.Hash      `json:"current_sync_committee_branch"`
+}
+
+func newLightClientBootstrapJSON(b *cltypes.LightClientBootstrap) lightClientBootstrapJSON {
+	return lightClientBootstrapJSON{
+		Header:                     newLightClientHeaderJSON(b.Header),
+		CurrentSyncCommittee:       newSyncCommitteeJSON(b.CurrentSyncCommittee),
+		CurrentSyncCommitteeBranch: append([]libcommon.Hash{}, b.CurrentSyncCommitteeBranch[:]...),
+	}
+}
+
+type lightClientUpdateJSON struct {
+	AttestedHeader          lightClientHeaderJSON `json:"attested_header"`
+	NextSyncCommittee       syncCommitteeJSON     `json:"next_sync_committee"`
+	NextSyncCommitteeBranch []libcommon.Hash      `json:"next_sync_committee_branch"`
+	FinalizedHeader         lightClientHeaderJSON `json:"finalized_header"`
+	FinalityBranch          []libcommon.Hash      `json:"finality_branch"`
+	SyncAggregate           syncAggregateJSON     `json:"sync_aggregate"`
+	SignatureSlot           uint64                `json:"signature_slot,string"`
+}
+
+func newLightClientUpdateJSON(u *cltypes.LightClientUpdate) lightClientUpdateJSON {
+	return lightClientUpdateJSON{
+		AttestedHeader:          newLightClientHeaderJSON(u.AttestedHeader),
+		NextSyncCommittee:       newSyncCommitteeJSON(u.NextSyncCommittee),
+		NextSyncCommitteeBranch: append([]libcommon.Hash{}, u.NextSyncCommitteeBranch[:]...),
+		FinalizedHeader:         newLightClientHeaderJSON(u.FinalizedHeader),
+		FinalityBranch:          append([]libcommon.Hash{}, u.FinalityBranch[:]...),
+		SyncAggregate:           newSyncAggregateJSON(u.SyncAggregate),
+		SignatureSlot:           u.SignatureSlot,
+	}
+}
+
+type lightClientFinalityUpdateJSON struct {
+	AttestedHeader  lightClientHeaderJSON `json:"attested_header"`
+	FinalizedHeader lightClientHeaderJSON `json:"finalized_header"`
+	FinalityBranch  []libcommon.Hash      `json:"finality_branch"`
+	SyncAggregate   syncAggregateJSON     `json:"sync_aggregate"`
+	SignatureSlot   uint64                `json:"signature_slot,string"`
+}
+
+func newLightClientFinalityUpdateJSON(u *cltypes.LightClientFinalityUpdate) lightClientFinalityUpdateJSON {
+	return lightClientFinalityUpdateJSON{
+		AttestedHeader:  newLightClientHeaderJSON(u.AttestedHeader),
+		FinalizedHeader: newLightClientHeaderJSON(u.FinalizedHeader),
+		FinalityBranch:  append([]libcommon.Hash{}, u.FinalityBranch[:]...),
+		SyncAggregate:   newSyncAggregateJSON(u.SyncAggregate),
+		SignatureSlot:   u.SignatureSlot,
+	}
+}
+
+type lightClientOptimisticUpdateJSON struct {
+	AttestedHeader lightClientHeaderJSON `json:"attested_header"`
+	SyncAggregate  syncAggregateJSON     `json:"sync_aggregate"`
+	SignatureSlot  uint64                `json:"signature_slot,string"`
+}
+
+func newLightClientOptimisticUpdateJSON(u *cltypes.LightClientOptimisticUpdate) lightClientOptimisticUpdateJSON {
+	return lightClientOptimisticUpdateJSON{
+		AttestedHeader: newLightClientHeaderJSON(u.AttestedHeader),
+		SyncAggregate:  newSyncAggregateJSON(u.SyncAggregate),
+		SignatureSlot:  u.SignatureSlot,
+	}
+}
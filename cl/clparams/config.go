@@ -92,24 +92,28 @@ var (
 )
 
 type NetworkConfig struct {
-	GossipMaxSize                   uint64        `json:"gossip_max_size"`                    // The maximum allowed size of uncompressed gossip messages.
-	GossipMaxSizeBellatrix          uint64        `json:"gossip_max_size_bellatrix"`          // The maximum allowed size of bellatrix uncompressed gossip messages.
-	MaxRequestBlocks                uint64        `json:"max_request_blocks"`                 // Maximum number of blocks in a single request
-	MinEpochsForBlockRequests       uint64        `json:"min_epochs_for_block_requests"`      // The minimum epoch range over which a node must serve blocks
-	MaxChunkSize                    uint64        `json:"max_chunk_size"`                     // The maximum allowed size of uncompressed req/resp chunked responses.
-	AttestationSubnetCount          uint64        `json:"attestation_subnet_count"`           // The number of attestation subnets used in the gossipsub protocol.
-	TtfbTimeout                     time.Duration `json:"ttfbt_timeout"`                      // The maximum time to wait for first byte of request response (time-to-first-byte).
-	RespTimeout                     time.Duration `json:"resp_timeout"`                       // The maximum time for complete response transfer.
-	AttestationPropagationSlotRange uint64        `json:"attestation_propagation_slot_range"` // The maximum number of slots during which an attestation can be propagated.
-	MaximumGossipClockDisparity     time.Duration `json:"maximum_gossip_clock_disparity"`     // The maximum milliseconds of clock disparity assumed between honest nodes.
-	MessageDomainInvalidSnappy      [4]byte       `json:"message_domain_invalid_snappy"`      // 4-byte domain for gossip message-id isolation of invalid snappy messages
-	MessageDomainValidSnappy        [4]byte       `json:"message_domain_valid_snappy"`        // 4-byte domain for gossip message-id isolation of valid snappy messages
+	GossipMaxSize                   uint64        `json:"gossip_max_size"`                       // The maximum allowed size of uncompressed gossip messages.
+	GossipMaxSizeBellatrix          uint64        `json:"gossip_max_size_bellatrix"`             // The maximum allowed size of bellatrix uncompressed gossip messages.
+	MaxRequestBlocks                uint64        `json:"max_request_blocks"`                    // Maximum number of blocks in a single request
+	MinEpochsForBlockRequests       uint64        `json:"min_epochs_for_block_requests"`         // The minimum epoch range over which a node must serve blocks
+	MaxRequestBlobSidecars          uint64        `json:"max_request_blob_sidecars"`             // Maximum number of blob sidecars in a single request
+	MinEpochsForBlobSidecarsRequest uint64        `json:"min_epochs_for_blob_sidecars_requests"` // The minimum epoch range over which a node must serve blob sidecars
+	MaxChunkSize                    uint64        `json:"max_chunk_size"`                        // The maximum allowed size of uncompressed req/resp chunked responses.
+	AttestationSubnetCount          uint64        `json:"attestation_subnet_count"`              // The number of attestation subnets used in the gossipsub protocol.
+	TtfbTimeout                     time.Duration `json:"ttfbt_timeout"`                         // The maximum time to wait for first byte of request response (time-to-first-byte).
+	RespTimeout                     time.Duration `json:"resp_timeout"`                          // The maximum time for complete response transfer.
+	AttestationPropagationSlotRange uint64        `json:"attestation_propagation_slot_range"`    // The maximum number of slots during which an attestation can be propagated.
+	MaximumGossipClockDisparity     time.Duration `json:"maximum_gossip_clock_disparity"`        // The maximum milliseconds of clock disparity assumed between honest nodes.
+	MessageDomainInvalidSnappy      [4]byte       `json:"message_domain_invalid_snappy"`         // 4-byte domain for gossip message-id isolation of invalid snappy messages
+	MessageDomainValidSnappy        [4]byte       `json:"message_domain_valid_snappy"`           // 4-byte domain for gossip message-id isolation of valid snappy messages
 
 	// DiscoveryV5 Config
-	Eth2key                    string // ETH2Key is the ENR key of the Ethereum consensus object in an enr.
-	AttSubnetKey               string // AttSubnetKey is the ENR key of the subnet bitfield in the enr.
-	SyncCommsSubnetKey         string // SyncCommsSubnetKey is the ENR key of the sync committee subnet bitfield in the enr.
-	MinimumPeersInSubnetSearch uint64 // PeersInSubnetSearch is the required amount of peers that we need to be able to lookup in a subnet search.
+	Eth2key                     string // ETH2Key is the ENR key of the Ethereum consensus object in an enr.
+	AttSubnetKey                string // AttSubnetKey is the ENR key of the subnet bitfield in the enr.
+	SyncCommsSubnetKey          string // SyncCommsSubnetKey is the ENR key of the sync committee subnet bitfield in the enr.
+	MinimumPeersInSubnetSearch  uint64 // PeersInSubnetSearch is the required amount of peers that we need to be able to lookup in a subnet search.
+	SubnetsPerNode              uint64 // SubnetsPerNode is the number of long lived attestation subnets a node subscribes to.
+	EpochsPerSubnetSubscription uint64 // EpochsPerSubnetSubscription is how many epochs a node stays in a long lived subnet.
 
 	ContractDeploymentBlock uint64 // the eth1 block in which the deposit contract is deployed.
 	BootNodes               []string
@@ -129,6 +133,8 @@ var NetworkConfigs map[NetworkType]NetworkConfig = map[NetworkType]NetworkConfig
 		AttestationSubnetCount:          64,
 		AttestationPropagationSlotRange: 32,
 		MaxRequestBlocks:                1 << 10, // 1024
+		MaxRequestBlobSidecars:          768,
+		MinEpochsForBlobSidecarsRequest: 4096,
 		TtfbTimeout:                     ReqTimeout,
 		RespTimeout:                     RespTimeout,
 		MaximumGossipClockDisparity:     500 * time.Millisecond,
@@ -138,6 +144,8 @@ var NetworkConfigs map[NetworkType]NetworkConfig = map[NetworkType]NetworkConfig
 		AttSubnetKey:                    "attnets",
 		SyncCommsSubnetKey:              "syncnets",
 		MinimumPeersInSubnetSearch:      20,
+		SubnetsPerNode:                  2,
+		EpochsPerSubnetSubscription:     256,
 		ContractDeploymentBlock:         11184524,
 		BootNodes:                       MainnetBootstrapNodes,
 	},
@@ -149,6 +157,8 @@ var NetworkConfigs map[NetworkType]NetworkConfig = map[NetworkType]NetworkConfig
 		AttestationSubnetCount:          64,
 		AttestationPropagationSlotRange: 32,
 		MaxRequestBlocks:                1 << 10, // 1024
+		MaxRequestBlobSidecars:          768,
+		MinEpochsForBlobSidecarsRequest: 4096,
 		TtfbTimeout:                     ReqTimeout,
 		RespTimeout:                     RespTimeout,
 		MaximumGossipClockDisparity:     500 * time.Millisecond,
@@ -158,6 +168,8 @@ var NetworkConfigs map[NetworkType]NetworkConfig = map[NetworkType]NetworkConfig
 		AttSubnetKey:                    "attnets",
 		SyncCommsSubnetKey:              "syncnets",
 		MinimumPeersInSubnetSearch:      20,
+		SubnetsPerNode:                  2,
+		EpochsPerSubnetSubscription:     256,
 		ContractDeploymentBlock:         1273020,
 		BootNodes:                       MainnetBootstrapNodes,
 	},
@@ -169,6 +181,8 @@ var NetworkConfigs map[NetworkType]NetworkConfig = map[NetworkType]NetworkConfig
 		AttestationSubnetCount:          64,
 		AttestationPropagationSlotRange: 32,
 		MaxRequestBlocks:                1 << 10, // 1024
+		MaxRequestBlobSidecars:          768,
+		MinEpochsForBlobSidecarsRequest: 4096,
 		TtfbTimeout:                     ReqTimeout,
 		RespTimeout:                     RespTimeout,
 		MaximumGossipClockDisparity:     500 * time.Millisecond,
@@ -178,6 +192,8 @@ var NetworkConfigs map[NetworkType]NetworkConfig = map[NetworkType]NetworkConfig
 		AttSubnetKey:                    "attnets",
 		SyncCommsSubnetKey:              "syncnets",
 		MinimumPeersInSubnetSearch:      20,
+		SubnetsPerNode:                  2,
+		EpochsPerSubnetSubscription:     256,
 		ContractDeploymentBlock:         4367322,
 		BootNodes:                       MainnetBootstrapNodes,
 	},
@@ -189,6 +205,8 @@ var NetworkConfigs map[NetworkType]NetworkConfig = map[NetworkType]NetworkConfig
 		AttestationSubnetCount:          64,
 		AttestationPropagationSlotRange: 32,
 		MaxRequestBlocks:                1 << 10, // 1024
+		MaxRequestBlobSidecars:          768,
+		MinEpochsForBlobSidecarsRequest: 4096,
 		TtfbTimeout:                     ReqTimeout,
 		RespTimeout:                     RespTimeout,
 		MaximumGossipClockDisparity:     500 * time.Millisecond,
@@ -198,6 +216,8 @@ var NetworkConfigs map[NetworkType]NetworkConfig = map[NetworkType]NetworkConfig
 		AttSubnetKey:                    "attnets",
 		SyncCommsSubnetKey:              "syncnets",
 		MinimumPeersInSubnetSearch:      20,
+		SubnetsPerNode:                  2,
+		EpochsPerSubnetSubscription:     256,
 		ContractDeploymentBlock:         19475089,
 		BootNodes:                       GnosisBootstrapNodes,
 	},
@@ -209,6 +229,8 @@ var NetworkConfigs map[NetworkType]NetworkConfig = map[NetworkType]NetworkConfig
 		AttestationSubnetCount:          64,
 		AttestationPropagationSlotRange: 32,
 		MaxRequestBlocks:                1 << 10, // 1024
+		MaxRequestBlobSidecars:          768,
+		MinEpochsForBlobSidecarsRequest: 4096,
 		TtfbTimeout:                     ReqTimeout,
 		RespTimeout:                     RespTimeout,
 		MaximumGossipClockDisparity:     500 * time.Millisecond,
@@ -218,6 +240,8 @@ var NetworkConfigs map[NetworkType]NetworkConfig = map[NetworkType]NetworkConfig
 		AttSubnetKey:                    "attnets",
 		SyncCommsSubnetKey:              "syncnets",
 		MinimumPeersInSubnetSearch:      20,
+		SubnetsPerNode:                  2,
+		EpochsPerSubnetSubscription:     256,
 		ContractDeploymentBlock:         155530,
 		BootNodes:                       ChiadoBootstrapNodes,
 	},
@@ -366,6 +390,7 @@ type BeaconChainConfig struct {
 	MaxWithdrawalsPerPayload         uint64 `yaml:"MAX_WITHDRAWALS_PER_PAYLOAD" spec:"true"`          // MaxWithdrawalsPerPayload defines the maximum number of withdrawals in a block.
 	MaxBlsToExecutionChanges         uint64 `yaml:"MAX_BLS_TO_EXECUTION_CHANGES" spec:"true"`         // MaxBlsToExecutionChanges defines the maximum number of BLS-to-execution-change objects in a block.
 	MaxValidatorsPerWithdrawalsSweep uint64 `yaml:"MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP" spec:"true"` //MaxValidatorsPerWithdrawalsSweep bounds the size of the sweep searching for withdrawals per slot.
+	MaxBlobsPerBlock                 uint64 `yaml:"MAX_BLOBS_PER_BLOCK" spec:"true"`                  // MaxBlobsPerBlock defines the maximum number of blobs, and thus blob sidecars, of a block.
 
 	// BLS domain values.
 	DomainBeaconProposer              [4]byte `yaml:"DOMAIN_BEACON_PROPOSER" spec:"true"`                // DomainBeaconProposer defines the BLS signature domain for beacon proposal verification.
@@ -616,6 +641,7 @@ var MainnetBeaconConfig BeaconChainConfig = BeaconChainConfig{
 	MaxWithdrawalsPerPayload:         16,
 	MaxBlsToExecutionChanges:         16,
 	MaxValidatorsPerWithdrawalsSweep: 16384,
+	MaxBlobsPerBlock:                 6,
 
 	// BLS domain values.
 	DomainBeaconProposer:              utils.Uint32ToBytes4(0x00000000),
@@ -631,6 +657,7 @@ var MainnetBeaconConfig BeaconChainConfig = BeaconChainConfig{
 	DomainApplicationMask:             utils.Uint32ToBytes4(0x00000001),
 	DomainApplicationBuilder:          utils.Uint32ToBytes4(0x00000001),
 	DomainBLSToExecutionChange:        utils.Uint32ToBytes4(0x0A000000),
+	DomainBlobSideCar:                 utils.Uint32ToBytes4(0x0B000000),
 
 	// Prysm constants.
 	GweiPerEth:                     1000000000,
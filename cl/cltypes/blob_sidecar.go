@@ -0,0 +1,130 @@
+package cltypes
+
+import (
+	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	libkzg "github.com/ledgerwatch/erigon-lib/crypto/kzg"
+
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	ssz2 "github.com/ledgerwatch/erigon/cl/ssz"
+)
+
+const kzgProofLength = 48
+
+// BlobSidecar carries a blob of a Deneb block along with the proof that it matches one of the KZG commitments of
+// the block.
+type BlobSidecar struct {
+	BlockRoot       libcommon.Hash
+	Index           uint64
+	Slot            uint64
+	BlockParentRoot libcommon.Hash
+	ProposerIndex   uint64
+	Blob            Blob
+	KzgCommitment   KZGCommitment
+	KzgProof        KZGProof
+}
+
+func (b *BlobSidecar) EncodeSSZ(buf []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(buf, b.BlockRoot[:], b.Index, b.Slot, b.BlockParentRoot[:], b.ProposerIndex, b.Blob[:], b.KzgCommitment[:], b.KzgProof[:])
+}
+
+func (b *BlobSidecar) DecodeSSZ(buf []byte, version int) error {
+	return ssz2.UnmarshalSSZ(buf, version, b.BlockRoot[:], &b.Index, &b.Slot, b.BlockParentRoot[:], &b.ProposerIndex, b.Blob[:], b.KzgCommitment[:], b.KzgProof[:])
+}
+
+func (b *BlobSidecar) EncodingSizeSSZ() int {
+	return length.Hash*2 + length.BlockNum*3 + int(BYTES_PER_BLOB) + kzgProofLength*2
+}
+
+func (b *BlobSidecar) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(b.BlockRoot[:], b.Index, b.Slot, b.BlockParentRoot[:], b.ProposerIndex, b.Blob[:], b.KzgCommitment[:], b.KzgProof[:])
+}
+
+func (*BlobSidecar) Static() bool {
+	return true
+}
+
+// VerifyKZGProof checks that the blob of the sidecar matches its KZG commitment.
+func (b *BlobSidecar) VerifyKZGProof() error {
+	return libkzg.Ctx().VerifyBlobKZGProof(gokzg4844.Blob(b.Blob), gokzg4844.KZGCommitment(b.KzgCommitment), gokzg4844.KZGProof(b.KzgProof))
+}
+
+// SignedBlobSidecar is a blob sidecar signed by the proposer of its block, as gossiped.
+type SignedBlobSidecar struct {
+	Message   *BlobSidecar
+	Signature [96]byte
+}
+
+func (b *SignedBlobSidecar) EncodeSSZ(buf []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(buf, b.Message, b.Signature[:])
+}
+
+func (b *SignedBlobSidecar) DecodeSSZ(buf []byte, version int) error {
+	b.Message = new(BlobSidecar)
+	return ssz2.UnmarshalSSZ(buf, version, b.Message, b.Signature[:])
+}
+
+func (b *SignedBlobSidecar) EncodingSizeSSZ() int {
+	return b.Message.EncodingSizeSSZ() + 96
+}
+
+func (b *SignedBlobSidecar) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(b.Message, b.Signature[:])
+}
+
+func (*SignedBlobSidecar) Static() bool {
+	return true
+}
+
+// BlobIdentifier designates a blob sidecar in blob_sidecars_by_root requests.
+type BlobIdentifier struct {
+	BlockRoot libcommon.Hash
+	Index     uint64
+}
+
+func (b *BlobIdentifier) EncodeSSZ(buf []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(buf, b.BlockRoot[:], b.Index)
+}
+
+func (b *BlobIdentifier) DecodeSSZ(buf []byte, version int) error {
+	return ssz2.UnmarshalSSZ(buf, version, b.BlockRoot[:], &b.Index)
+}
+
+func (b *BlobIdentifier) EncodingSizeSSZ() int {
+	return length.Hash + length.BlockNum
+}
+
+func (b *BlobIdentifier) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(b.BlockRoot[:], b.Index)
+}
+
+func (*BlobIdentifier) Static() bool {
+	return true
+}
+
+// BlobSidecarsByRangeRequest is the request of blob_sidecars_by_range.
+type BlobSidecarsByRangeRequest struct {
+	StartSlot uint64
+	Count     uint64
+}
+
+func (b *BlobSidecarsByRangeRequest) EncodeSSZ(buf []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(buf, b.StartSlot, b.Count)
+}
+
+func (b *BlobSidecarsByRangeRequest) DecodeSSZ(buf []byte, version int) error {
+	return ssz2.UnmarshalSSZ(buf, version, &b.StartSlot, &b.Count)
+}
+
+func (b *BlobSidecarsByRangeRequest) EncodingSizeSSZ() int {
+	return 2 * length.BlockNum
+}
+
+func (b *BlobSidecarsByRangeRequest) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(b.StartSlot, b.Count)
+}
+
+func (*BlobSidecarsByRangeRequest) Static() bool {
+	return true
+}
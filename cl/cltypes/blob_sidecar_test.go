@@ -0,0 +1,66 @@
+package cltypes_test
+
+import (
+	"testing"
+
+	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
+	libkzg "github.com/ledgerwatch/erigon-lib/crypto/kzg"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+)
+
+func testBlobSidecar(t *testing.T) *cltypes.BlobSidecar {
+	sidecar := &cltypes.BlobSidecar{
+		Index:         1,
+		Slot:          42,
+		ProposerIndex: 7,
+	}
+	sidecar.BlockRoot[0] = 1
+	sidecar.BlockParentRoot[0] = 2
+	// the first byte of a field element must keep it below the modulus
+	sidecar.Blob[1] = 3
+	commitment, err := libkzg.Ctx().BlobToKZGCommitment(gokzg4844.Blob(sidecar.Blob), 1)
+	require.NoError(t, err)
+	proof, err := libkzg.Ctx().ComputeBlobKZGProof(gokzg4844.Blob(sidecar.Blob), commitment, 1)
+	require.NoError(t, err)
+	sidecar.KzgCommitment = cltypes.KZGCommitment(commitment)
+	sidecar.KzgProof = cltypes.KZGProof(proof)
+	return sidecar
+}
+
+func TestBlobSidecarSSZ(t *testing.T) {
+	signed := &cltypes.SignedBlobSidecar{Message: testBlobSidecar(t)}
+	signed.Signature[0] = 4
+
+	encoded, err := signed.EncodeSSZ(nil)
+	require.NoError(t, err)
+	require.Len(t, encoded, signed.EncodingSizeSSZ())
+
+	decoded := &cltypes.SignedBlobSidecar{}
+	require.NoError(t, decoded.DecodeSSZ(encoded, int(clparams.DenebVersion)))
+	require.Equal(t, signed, decoded)
+
+	root, err := signed.HashSSZ()
+	require.NoError(t, err)
+	decodedRoot, err := decoded.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, root, decodedRoot)
+
+	identifier := &cltypes.BlobIdentifier{BlockRoot: signed.Message.BlockRoot, Index: 3}
+	encoded, err = identifier.EncodeSSZ(nil)
+	require.NoError(t, err)
+	require.Len(t, encoded, 40)
+	decodedIdentifier := &cltypes.BlobIdentifier{}
+	require.NoError(t, decodedIdentifier.DecodeSSZ(encoded, int(clparams.DenebVersion)))
+	require.Equal(t, identifier, decodedIdentifier)
+}
+
+func TestBlobSidecarVerifyKZGProof(t *testing.T) {
+	sidecar := testBlobSidecar(t)
+	require.NoError(t, sidecar.VerifyKZGProof())
+
+	sidecar.Blob[33] = 1
+	require.Error(t, sidecar.VerifyKZGProof())
+}
@@ -0,0 +1,295 @@
+package cltypes
+
+import (
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/types/clonable"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	ssz2 "github.com/ledgerwatch/erigon/cl/ssz"
+)
+
+const (
+	// SyncCommitteeBranchLength is the depth of the sync committees in the beacon state tree.
+	SyncCommitteeBranchLength = 5
+	// FinalityBranchLength is the depth of the finalized checkpoint root in the beacon state tree.
+	FinalityBranchLength = 6
+
+	syncCommitteeSSZSize = 48 * 513
+)
+
+type (
+	SyncCommitteeBranch [SyncCommitteeBranchLength]libcommon.Hash
+	FinalityBranch      [FinalityBranchLength]libcommon.Hash
+)
+
+func flattenBranch(branch []libcommon.Hash) []byte {
+	out := make([]byte, 0, len(branch)*length.Hash)
+	for _, h := range branch {
+		out = append(out, h[:]...)
+	}
+	return out
+}
+
+func unflattenBranch(branch []libcommon.Hash, buf []byte) {
+	for i := range branch {
+		copy(branch[i][:], buf[i*length.Hash:])
+	}
+}
+
+// isEmptyBranch reports whether the branch is zeroed, which is how updates without the proven field are marked.
+func isEmptyBranch(branch []libcommon.Hash) bool {
+	for _, h := range branch {
+		if h != (libcommon.Hash{}) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ * LightClientHeader is the Altair header of a block as seen by light clients, it only wraps the beacon block header.
+ * The headers of Capella onwards also carry the execution payload header and its branch, they are not supported.
+ */
+type LightClientHeader struct {
+	Beacon *BeaconBlockHeader
+}
+
+func (h *LightClientHeader) EncodeSSZ(dst []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(dst, h.Beacon)
+}
+
+func (h *LightClientHeader) DecodeSSZ(buf []byte, version int) error {
+	h.Beacon = new(BeaconBlockHeader)
+	return ssz2.UnmarshalSSZ(buf, version, h.Beacon)
+}
+
+func (*LightClientHeader) Clone() clonable.Clonable {
+	return &LightClientHeader{}
+}
+
+func (*LightClientHeader) Static() bool {
+	return true
+}
+
+func (*LightClientHeader) EncodingSizeSSZ() int {
+	return 112
+}
+
+func (h *LightClientHeader) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(h.Beacon)
+}
+
+/*
+ * LightClientBootstrap lets a light client start syncing from a trusted block root
+ * by proving the current sync committee against the block's state root.
+ */
+type LightClientBootstrap struct {
+	Header                     *LightClientHeader
+	CurrentSyncCommittee       *solid.SyncCommittee
+	CurrentSyncCommitteeBranch SyncCommitteeBranch
+}
+
+func (b *LightClientBootstrap) EncodeSSZ(dst []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(dst, b.Header, b.CurrentSyncCommittee[:], flattenBranch(b.CurrentSyncCommitteeBranch[:]))
+}
+
+func (b *LightClientBootstrap) DecodeSSZ(buf []byte, version int) error {
+	b.Header = new(LightClientHeader)
+	b.CurrentSyncCommittee = new(solid.SyncCommittee)
+	branch := make([]byte, SyncCommitteeBranchLength*length.Hash)
+	if err := ssz2.UnmarshalSSZ(buf, version, b.Header, b.CurrentSyncCommittee[:], branch); err != nil {
+		return err
+	}
+	unflattenBranch(b.CurrentSyncCommitteeBranch[:], branch)
+	return nil
+}
+
+func (*LightClientBootstrap) Clone() clonable.Clonable {
+	return &LightClientBootstrap{}
+}
+
+func (*LightClientBootstrap) Static() bool {
+	return true
+}
+
+func (b *LightClientBootstrap) EncodingSizeSSZ() int {
+	return 112 + syncCommitteeSSZSize + SyncCommitteeBranchLength*length.Hash
+}
+
+func (b *LightClientBootstrap) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(b.Header, b.CurrentSyncCommittee, flattenBranch(b.CurrentSyncCommitteeBranch[:]))
+}
+
+/*
+ * LightClientUpdate proves the next sync committee and, optionally, the finalized header
+ * against the state of the attested header, which the sync aggregate signed at the signature slot.
+ */
+type LightClientUpdate struct {
+	AttestedHeader          *LightClientHeader
+	NextSyncCommittee       *solid.SyncCommittee
+	NextSyncCommitteeBranch SyncCommitteeBranch
+	FinalizedHeader         *LightClientHeader
+	FinalityBranch          FinalityBranch
+	SyncAggregate           *SyncAggregate
+	SignatureSlot           uint64
+}
+
+func (u *LightClientUpdate) EncodeSSZ(dst []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(dst, u.AttestedHeader, u.NextSyncCommittee[:], flattenBranch(u.NextSyncCommitteeBranch[:]),
+		u.FinalizedHeader, flattenBranch(u.FinalityBranch[:]), u.SyncAggregate, u.SignatureSlot)
+}
+
+func (u *LightClientUpdate) DecodeSSZ(buf []byte, version int) error {
+	u.AttestedHeader = new(LightClientHeader)
+	u.NextSyncCommittee = new(solid.SyncCommittee)
+	u.FinalizedHeader = new(LightClientHeader)
+	u.SyncAggregate = new(SyncAggregate)
+	nextSyncCommitteeBranch := make([]byte, SyncCommitteeBranchLength*length.Hash)
+	finalityBranch := make([]byte, FinalityBranchLength*length.Hash)
+	if err := ssz2.UnmarshalSSZ(buf, version, u.AttestedHeader, u.NextSyncCommittee[:], nextSyncCommitteeBranch,
+		u.FinalizedHeader, finalityBranch, u.SyncAggregate, &u.SignatureSlot); err != nil {
+		return err
+	}
+	unflattenBranch(u.NextSyncCommitteeBranch[:], nextSyncCommitteeBranch)
+	unflattenBranch(u.FinalityBranch[:], finalityBranch)
+	return nil
+}
+
+func (*LightClientUpdate) Clone() clonable.Clonable {
+	return &LightClientUpdate{}
+}
+
+func (*LightClientUpdate) Static() bool {
+	return true
+}
+
+func (*LightClientUpdate) EncodingSizeSSZ() int {
+	return 112*2 + syncCommitteeSSZSize + (SyncCommitteeBranchLength+FinalityBranchLength)*length.Hash + 160 + 8
+}
+
+func (u *LightClientUpdate) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(u.AttestedHeader, u.NextSyncCommittee, flattenBranch(u.NextSyncCommitteeBranch[:]),
+		u.FinalizedHeader, flattenBranch(u.FinalityBranch[:]), u.SyncAggregate, u.SignatureSlot)
+}
+
+// IsSyncCommitteeUpdate reports whether the update proves the next sync committee.
+func (u *LightClientUpdate) IsSyncCommitteeUpdate() bool {
+	return !isEmptyBranch(u.NextSyncCommitteeBranch[:])
+}
+
+// IsFinalityUpdate reports whether the update proves a finalized header.
+func (u *LightClientUpdate) IsFinalityUpdate() bool {
+	return !isEmptyBranch(u.FinalityBranch[:])
+}
+
+/*
+ * LightClientFinalityUpdate is the part of an update proving the latest finalized header.
+ */
+type LightClientFinalityUpdate struct {
+	AttestedHeader  *LightClientHeader
+	FinalizedHeader *LightClientHeader
+	FinalityBranch  FinalityBranch
+	SyncAggregate   *SyncAggregate
+	SignatureSlot   uint64
+}
+
+func (u *LightClientFinalityUpdate) EncodeSSZ(dst []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(dst, u.AttestedHeader, u.FinalizedHeader, flattenBranch(u.FinalityBranch[:]), u.SyncAggregate, u.SignatureSlot)
+}
+
+func (u *LightClientFinalityUpdate) DecodeSSZ(buf []byte, version int) error {
+	u.AttestedHeader = new(LightClientHeader)
+	u.FinalizedHeader = new(LightClientHeader)
+	u.SyncAggregate = new(SyncAggregate)
+	finalityBranch := make([]byte, FinalityBranchLength*length.Hash)
+	if err := ssz2.UnmarshalSSZ(buf, version, u.AttestedHeader, u.FinalizedHeader, finalityBranch, u.SyncAggregate, &u.SignatureSlot); err != nil {
+		return err
+	}
+	unflattenBranch(u.FinalityBranch[:], finalityBranch)
+	return nil
+}
+
+func (*LightClientFinalityUpdate) Clone() clonable.Clonable {
+	return &LightClientFinalityUpdate{}
+}
+
+func (*LightClientFinalityUpdate) Static() bool {
+	return true
+}
+
+func (*LightClientFinalityUpdate) EncodingSizeSSZ() int {
+	return 112*2 + FinalityBranchLength*length.Hash + 160 + 8
+}
+
+func (u *LightClientFinalityUpdate) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(u.AttestedHeader, u.FinalizedHeader, flattenBranch(u.FinalityBranch[:]), u.SyncAggregate, u.SignatureSlot)
+}
+
+/*
+ * LightClientOptimisticUpdate is the part of an update tracking the latest attested header.
+ */
+type LightClientOptimisticUpdate struct {
+	AttestedHeader *LightClientHeader
+	SyncAggregate  *SyncAggregate
+	SignatureSlot  uint64
+}
+
+func (u *LightClientOptimisticUpdate) EncodeSSZ(dst []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(dst, u.AttestedHeader, u.SyncAggregate, u.SignatureSlot)
+}
+
+func (u *LightClientOptimisticUpdate) DecodeSSZ(buf []byte, version int) error {
+	u.AttestedHeader = new(LightClientHeader)
+	u.SyncAggregate = new(SyncAggregate)
+	return ssz2.UnmarshalSSZ(buf, version, u.AttestedHeader, u.SyncAggregate, &u.SignatureSlot)
+}
+
+func (*LightClientOptimisticUpdate) Clone() clonable.Clonable {
+	return &LightClientOptimisticUpdate{}
+}
+
+func (*LightClientOptimisticUpdate) Static() bool {
+	return true
+}
+
+func (*LightClientOptimisticUpdate) EncodingSizeSSZ() int {
+	return 112 + 160 + 8
+}
+
+func (u *LightClientOptimisticUpdate) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(u.AttestedHeader, u.SyncAggregate, u.SignatureSlot)
+}
+
+/*
+ * LightClientUpdatesByRangeRequest asks for the best updates of count sync committee periods from the start period.
+ */
+type LightClientUpdatesByRangeRequest struct {
+	StartPeriod uint64
+	Count       uint64
+}
+
+func (r *LightClientUpdatesByRangeRequest) EncodeSSZ(dst []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(dst, r.StartPeriod, r.Count)
+}
+
+func (r *LightClientUpdatesByRangeRequest) DecodeSSZ(buf []byte, version int) error {
+	return ssz2.UnmarshalSSZ(buf, version, &r.StartPeriod, &r.Count)
+}
+
+func (*LightClientUpdatesByRangeRequest) Clone() clonable.Clonable {
+	return &LightClientUpdatesByRangeRequest{}
+}
+
+func (*LightClientUpdatesByRangeRequest) Static() bool {
+	return true
+}
+
+func (*LightClientUpdatesByRangeRequest) EncodingSizeSSZ() int {
+	return 16
+}
+
+func (r *LightClientUpdatesByRangeRequest) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(r.StartPeriod, r.Count)
+}
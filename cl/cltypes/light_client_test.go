@@ -0,0 +1,95 @@
+package cltypes_test
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/types/ssz"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+)
+
+type sszObject interface {
+	ssz.EncodableSSZ
+	ssz.HashableSSZ
+}
+
+func testLightClientHeader(slot uint64) *cltypes.LightClientHeader {
+	return &cltypes.LightClientHeader{Beacon: &cltypes.BeaconBlockHeader{
+		Slot:          slot,
+		ProposerIndex: 3,
+		ParentRoot:    libcommon.HexToHash("0x01"),
+		Root:          libcommon.HexToHash("0x02"),
+		BodyRoot:      libcommon.HexToHash("0x03"),
+	}}
+}
+
+func testSyncCommittee() *solid.SyncCommittee {
+	committee := &solid.SyncCommittee{}
+	for i := range committee {
+		committee[i] = byte(i)
+	}
+	return committee
+}
+
+func TestLightClientTypes(t *testing.T) {
+	update := &cltypes.LightClientUpdate{
+		AttestedHeader:          testLightClientHeader(64),
+		NextSyncCommittee:       testSyncCommittee(),
+		NextSyncCommitteeBranch: cltypes.SyncCommitteeBranch{libcommon.HexToHash("0x04")},
+		FinalizedHeader:         testLightClientHeader(32),
+		FinalityBranch:          cltypes.FinalityBranch{5: libcommon.HexToHash("0x05")},
+		SyncAggregate:           &cltypes.SyncAggregate{SyncCommiteeBits: [64]byte{0xff}, SyncCommiteeSignature: [96]byte{6}},
+		SignatureSlot:           65,
+	}
+	require.True(t, update.IsSyncCommitteeUpdate())
+	require.True(t, update.IsFinalityUpdate())
+	require.False(t, (&cltypes.LightClientUpdate{}).IsFinalityUpdate())
+
+	for _, test := range []struct {
+		obj, decoded sszObject
+	}{
+		{testLightClientHeader(1), &cltypes.LightClientHeader{}},
+		{&cltypes.LightClientBootstrap{
+			Header:                     testLightClientHeader(2),
+			CurrentSyncCommittee:       testSyncCommittee(),
+			CurrentSyncCommitteeBranch: cltypes.SyncCommitteeBranch{4: libcommon.HexToHash("0x07")},
+		}, &cltypes.LightClientBootstrap{}},
+		{update, &cltypes.LightClientUpdate{}},
+		{&cltypes.LightClientFinalityUpdate{
+			AttestedHeader:  update.AttestedHeader,
+			FinalizedHeader: update.FinalizedHeader,
+			FinalityBranch:  update.FinalityBranch,
+			SyncAggregate:   update.SyncAggregate,
+			SignatureSlot:   update.SignatureSlot,
+		}, &cltypes.LightClientFinalityUpdate{}},
+		{&cltypes.LightClientOptimisticUpdate{
+			AttestedHeader: update.AttestedHeader,
+			SyncAggregate:  update.SyncAggregate,
+			SignatureSlot:  update.SignatureSlot,
+		}, &cltypes.LightClientOptimisticUpdate{}},
+		{&cltypes.LightClientUpdatesByRangeRequest{StartPeriod: 5, Count: 10}, &cltypes.LightClientUpdatesByRangeRequest{}},
+	} {
+		enc, err := test.obj.EncodeSSZ(nil)
+		require.NoError(t, err)
+		require.Len(t, enc, test.obj.EncodingSizeSSZ())
+
+		require.NoError(t, test.decoded.DecodeSSZ(enc, 0))
+		require.Equal(t, test.obj, test.decoded)
+
+		root, err := test.obj.HashSSZ()
+		require.NoError(t, err)
+		decodedRoot, err := test.decoded.HashSSZ()
+		require.NoError(t, err)
+		require.Equal(t, root, decodedRoot)
+	}
+
+	// the header of a light client hashes to the root of its beacon block header
+	headerRoot, err := update.AttestedHeader.HashSSZ()
+	require.NoError(t, err)
+	beaconRoot, err := update.AttestedHeader.Beacon.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, beaconRoot, headerRoot)
+}
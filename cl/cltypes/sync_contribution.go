@@ -0,0 +1,148 @@
+package cltypes
+
+import (
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/types/clonable"
+
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	ssz2 "github.com/ledgerwatch/erigon/cl/ssz"
+)
+
+// SyncCommitteeAggregationBitsSize is the size in bytes of the aggregation bits of a contribution,
+// one bit per member of a sync subcommittee.
+const SyncCommitteeAggregationBitsSize = 16
+
+/*
+ * SyncCommitteeMessage is the vote of a single sync committee member
+ * for the block root at the given slot.
+ */
+type SyncCommitteeMessage struct {
+	Slot            uint64
+	BeaconBlockRoot libcommon.Hash
+	ValidatorIndex  uint64
+	Signature       [96]byte
+}
+
+func (m *SyncCommitteeMessage) EncodeSSZ(dst []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(dst, m.Slot, m.BeaconBlockRoot[:], m.ValidatorIndex, m.Signature[:])
+}
+
+func (m *SyncCommitteeMessage) DecodeSSZ(buf []byte, version int) error {
+	return ssz2.UnmarshalSSZ(buf, version, &m.Slot, m.BeaconBlockRoot[:], &m.ValidatorIndex, m.Signature[:])
+}
+
+func (*SyncCommitteeMessage) Clone() clonable.Clonable {
+	return &SyncCommitteeMessage{}
+}
+
+func (*SyncCommitteeMessage) Static() bool {
+	return true
+}
+
+func (*SyncCommitteeMessage) EncodingSizeSSZ() int {
+	return 144
+}
+
+func (m *SyncCommitteeMessage) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(m.Slot, m.BeaconBlockRoot[:], m.ValidatorIndex, m.Signature[:])
+}
+
+/*
+ * SyncCommitteeContribution aggregates the sync committee messages of
+ * a subcommittee for the block root at the given slot.
+ */
+type SyncCommitteeContribution struct {
+	Slot              uint64
+	BeaconBlockRoot   libcommon.Hash
+	SubcommitteeIndex uint64
+	AggregationBits   [SyncCommitteeAggregationBitsSize]byte
+	Signature         [96]byte
+}
+
+func (c *SyncCommitteeContribution) EncodeSSZ(dst []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(dst, c.Slot, c.BeaconBlockRoot[:], c.SubcommitteeIndex, c.AggregationBits[:], c.Signature[:])
+}
+
+func (c *SyncCommitteeContribution) DecodeSSZ(buf []byte, version int) error {
+	return ssz2.UnmarshalSSZ(buf, version, &c.Slot, c.BeaconBlockRoot[:], &c.SubcommitteeIndex, c.AggregationBits[:], c.Signature[:])
+}
+
+func (*SyncCommitteeContribution) Clone() clonable.Clonable {
+	return &SyncCommitteeContribution{}
+}
+
+func (*SyncCommitteeContribution) Static() bool {
+	return true
+}
+
+func (*SyncCommitteeContribution) EncodingSizeSSZ() int {
+	return 160
+}
+
+func (c *SyncCommitteeContribution) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(c.Slot, c.BeaconBlockRoot[:], c.SubcommitteeIndex, c.AggregationBits[:], c.Signature[:])
+}
+
+// ParticipantsCount returns how many subcommittee members took part in the contribution.
+func (c *SyncCommitteeContribution) ParticipantsCount() int {
+	count := 0
+	for _, b := range c.AggregationBits {
+		for ; b > 0; b &= b - 1 {
+			count++
+		}
+	}
+	return count
+}
+
+/*
+ * ContributionAndProof contains the index of the aggregator, the contribution
+ * and the selection proof of the aggregator for the contribution's slot.
+ */
+type ContributionAndProof struct {
+	AggregatorIndex uint64
+	Contribution    *SyncCommitteeContribution
+	SelectionProof  [96]byte
+}
+
+func (a *ContributionAndProof) EncodeSSZ(dst []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(dst, a.AggregatorIndex, a.Contribution, a.SelectionProof[:])
+}
+
+func (a *ContributionAndProof) DecodeSSZ(buf []byte, version int) error {
+	a.Contribution = new(SyncCommitteeContribution)
+	return ssz2.UnmarshalSSZ(buf, version, &a.AggregatorIndex, a.Contribution, a.SelectionProof[:])
+}
+
+func (*ContributionAndProof) Static() bool {
+	return true
+}
+
+func (*ContributionAndProof) EncodingSizeSSZ() int {
+	return 264
+}
+
+func (a *ContributionAndProof) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(a.AggregatorIndex, a.Contribution, a.SelectionProof[:])
+}
+
+type SignedContributionAndProof struct {
+	Message   *ContributionAndProof
+	Signature [96]byte
+}
+
+func (a *SignedContributionAndProof) EncodeSSZ(dst []byte) ([]byte, error) {
+	return ssz2.MarshalSSZ(dst, a.Message, a.Signature[:])
+}
+
+func (a *SignedContributionAndProof) DecodeSSZ(buf []byte, version int) error {
+	a.Message = new(ContributionAndProof)
+	return ssz2.UnmarshalSSZ(buf, version, a.Message, a.Signature[:])
+}
+
+func (*SignedContributionAndProof) EncodingSizeSSZ() int {
+	return 360
+}
+
+func (a *SignedContributionAndProof) HashSSZ() ([32]byte, error) {
+	return merkle_tree.HashTreeRoot(a.Message, a.Signature[:])
+}
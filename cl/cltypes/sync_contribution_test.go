@@ -0,0 +1,64 @@
+package cltypes_test
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+)
+
+func TestSyncCommitteeMessage(t *testing.T) {
+	msg := &cltypes.SyncCommitteeMessage{
+		Slot:            7,
+		BeaconBlockRoot: libcommon.HexToHash("0xaa"),
+		ValidatorIndex:  42,
+		Signature:       [96]byte{1, 2, 3},
+	}
+	enc, err := msg.EncodeSSZ(nil)
+	require.NoError(t, err)
+	require.Len(t, enc, msg.EncodingSizeSSZ())
+
+	decoded := &cltypes.SyncCommitteeMessage{}
+	require.NoError(t, decoded.DecodeSSZ(enc, 0))
+	require.Equal(t, msg, decoded)
+
+	root, err := msg.HashSSZ()
+	require.NoError(t, err)
+	decodedRoot, err := decoded.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, root, decodedRoot)
+}
+
+func TestSignedContributionAndProof(t *testing.T) {
+	signed := &cltypes.SignedContributionAndProof{
+		Message: &cltypes.ContributionAndProof{
+			AggregatorIndex: 3,
+			Contribution: &cltypes.SyncCommitteeContribution{
+				Slot:              9,
+				BeaconBlockRoot:   libcommon.HexToHash("0xbb"),
+				SubcommitteeIndex: 2,
+				AggregationBits:   [cltypes.SyncCommitteeAggregationBitsSize]byte{0b1011, 0, 0x80},
+				Signature:         [96]byte{4, 5},
+			},
+			SelectionProof: [96]byte{6},
+		},
+		Signature: [96]byte{7},
+	}
+	require.Equal(t, 4, signed.Message.Contribution.ParticipantsCount())
+
+	enc, err := signed.EncodeSSZ(nil)
+	require.NoError(t, err)
+	require.Len(t, enc, signed.EncodingSizeSSZ())
+
+	decoded := &cltypes.SignedContributionAndProof{}
+	require.NoError(t, decoded.DecodeSSZ(enc, 0))
+	require.Equal(t, signed, decoded)
+
+	root, err := signed.HashSSZ()
+	require.NoError(t, err)
+	decodedRoot, err := decoded.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, root, decodedRoot)
+}
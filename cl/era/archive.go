@@ -0,0 +1,347 @@
+package era
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/core"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// the objects Caplin records into its freezer
+const (
+	freezerNamespace   = "caplin_core"
+	freezerBlockObject = "signedBeaconBlock"
+	freezerStateObject = "beaconState"
+)
+
+// Export writes the era file of the given era into dir from the blocks and states recorded in the freezer, and
+// returns its path. The state at the end of the era is regenerated from the closest recorded state before it when
+// it was not recorded itself.
+func Export(ctx context.Context, f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig, era uint64, dir string) (string, error) {
+	endSlot := era * beaconConfig.SlotsPerHistoricalRoot
+	var startSlot uint64
+	if era > 0 {
+		startSlot = endSlot - beaconConfig.SlotsPerHistoricalRoot
+	}
+	s, err := regenerateState(ctx, f, beaconConfig, endSlot)
+	if err != nil {
+		return "", err
+	}
+	eraRoot, err := EraRoot(s, era)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, Filename(beaconConfig.ConfigName, era, eraRoot))
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	w, err := NewWriter(tmp, beaconConfig, era)
+	if err != nil {
+		return "", err
+	}
+	var blocks int
+	for slot := startSlot; era > 0 && slot < endSlot; slot++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		block, err := readBlock(f, beaconConfig, slot)
+		if err != nil {
+			return "", err
+		}
+		expected, err := s.GetBlockRootAtSlot(slot)
+		if err != nil {
+			return "", err
+		}
+		if block != nil {
+			blockRoot, err := block.Block.HashSSZ()
+			if err != nil {
+				return "", err
+			}
+			// blocks of other forks may have been recorded at empty slots
+			if blockRoot != expected {
+				block = nil
+			}
+		}
+		// the state knows which slots have a block, except for the first slot of the era, which is empty only if the
+		// block it points to was recorded before it
+		if block == nil && slot == startSlot && slot > 0 {
+			recorded, err := recordedBefore(ctx, f, beaconConfig, slot, expected)
+			if err != nil {
+				return "", err
+			}
+			if !recorded {
+				return "", fmt.Errorf("canonical block %x of slot %d or before is not recorded", expected, slot)
+			}
+		}
+		if block == nil && slot > startSlot {
+			previous, err := s.GetBlockRootAtSlot(slot - 1)
+			if err != nil {
+				return "", err
+			}
+			if previous != expected {
+				return "", fmt.Errorf("canonical block %x of slot %d is not recorded", expected, slot)
+			}
+		}
+		if block == nil {
+			continue
+		}
+		if err := w.AddBlock(block); err != nil {
+			return "", err
+		}
+		blocks++
+	}
+	if err := w.Finish(s); err != nil {
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	log.Info("[Era] Exported era", "era", era, "blocks", blocks, "file", path)
+	return path, nil
+}
+
+// Import verifies the era file at path against its own state and records its blocks and state into the freezer, for
+// Caplin to start from through LatestState.
+func Import(ctx context.Context, f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	r, err := NewReader(file, info.Size(), beaconConfig)
+	if err != nil {
+		return fmt.Errorf("invalid era file %s: %w", path, err)
+	}
+	s, err := r.State()
+	if err != nil {
+		return fmt.Errorf("invalid state in era file %s: %w", path, err)
+	}
+	eraRoot, err := EraRoot(s, r.Era())
+	if err != nil {
+		return err
+	}
+	if expected := Filename(beaconConfig.ConfigName, r.Era(), eraRoot); filepath.Base(path) != expected {
+		log.Warn("[Era] Unexpected era file name", "file", path, "expected", expected)
+	}
+
+	var blocks []*cltypes.SignedBeaconBlock
+	if err := r.ForEachBlock(func(block *cltypes.SignedBeaconBlock) (bool, error) {
+		blockRoot, err := block.Block.HashSSZ()
+		if err != nil {
+			return false, err
+		}
+		expected, err := s.GetBlockRootAtSlot(block.Block.Slot)
+		if err != nil {
+			return false, err
+		}
+		if blockRoot != expected {
+			return false, fmt.Errorf("block %x of slot %d is not the canonical block %x", blockRoot, block.Block.Slot, expected)
+		}
+		blocks = append(blocks, block)
+		return ctx.Err() == nil, ctx.Err()
+	}); err != nil {
+		return fmt.Errorf("invalid era file %s: %w", path, err)
+	}
+
+	for _, block := range blocks {
+		if err := freezer.PutObjectSSZIntoFreezer(freezerBlockObject, freezerNamespace, block.Block.Slot, block, f); err != nil {
+			return err
+		}
+	}
+	if err := freezer.PutObjectSSZIntoFreezer(freezerStateObject, freezerNamespace, s.Slot(), s, f); err != nil {
+		return err
+	}
+	log.Info("[Era] Imported era", "era", r.Era(), "blocks", len(blocks), "file", path)
+	return nil
+}
+
+// LatestState reads the recorded state of the highest slot, such as the one of the last imported era, along with the
+// recorded block it was built on, for Caplin to start from.
+func LatestState(f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig) (*state.BeaconState, *cltypes.SignedBeaconBlock, error) {
+	s, err := closestState(f, beaconConfig, math.MaxUint64)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := readBlock(f, beaconConfig, s.LatestBlockHeader().Slot)
+	if err != nil {
+		return nil, nil, err
+	}
+	if block == nil {
+		return nil, nil, fmt.Errorf("block of the state of slot %d is not recorded", s.Slot())
+	}
+	if err := core.VerifyCheckpointBlock(s, block); err != nil {
+		return nil, nil, err
+	}
+	return s, block, nil
+}
+
+// recordedBefore tells whether the block of the given root was recorded within the SLOTS_PER_HISTORICAL_ROOT slots
+// before the given one.
+func recordedBefore(ctx context.Context, f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig, slot uint64, blockRoot libcommon.Hash) (bool, error) {
+	for previous := slot; previous > 0 && slot-previous < beaconConfig.SlotsPerHistoricalRoot; {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		previous--
+		block, err := readBlock(f, beaconConfig, previous)
+		if err != nil {
+			return false, err
+		}
+		if block == nil {
+			continue
+		}
+		root, err := block.Block.HashSSZ()
+		if err != nil {
+			return false, err
+		}
+		// a block of another fork may have been recorded at an empty slot
+		if root == blockRoot {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// regenerateState returns the state at the given slot, replaying the recorded blocks on top of the closest
+// recorded state before it.
+func regenerateState(ctx context.Context, f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig, slot uint64) (*state.BeaconState, error) {
+	s, err := closestState(f, beaconConfig, slot)
+	if err != nil {
+		return nil, err
+	}
+	if s.Slot() == slot {
+		return s, nil
+	}
+	log.Info("[Era] Regenerating state", "from", s.Slot(), "to", slot)
+	for current := s.Slot() + 1; current < slot; current++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block, err := readBlock(f, beaconConfig, current)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			continue
+		}
+		// skip the recordings of blocks which are not built on our chain
+		parentRoot, err := core.CheckpointBlockRoot(s)
+		if err != nil {
+			return nil, err
+		}
+		if block.Block.ParentRoot != parentRoot {
+			continue
+		}
+		if err := transition.TransitionState(s, block, false); err != nil {
+			return nil, fmt.Errorf("cannot apply block of slot %d: %w", current, err)
+		}
+	}
+	if err := transition.ProcessSlots(s, slot); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// closestState reads the recorded state with the highest slot not after the given one.
+func closestState(f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig, slot uint64) (*state.BeaconState, error) {
+	if s, err := readState(f, beaconConfig, strconv.FormatUint(slot, 10)); err != nil || (s != nil && s.Slot() == slot) {
+		return s, err
+	}
+	lister, ok := f.(freezer.Lister)
+	if !ok {
+		return nil, fmt.Errorf("state of slot %d is not recorded", slot)
+	}
+	ids, err := lister.List(freezerNamespace, freezerStateObject)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []uint64
+	for _, id := range ids {
+		if n, err := strconv.ParseUint(id, 10, 64); err == nil && n <= slot {
+			candidates = append(candidates, n)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i] > candidates[j] })
+	for _, candidate := range candidates {
+		s, err := readState(f, beaconConfig, strconv.FormatUint(candidate, 10))
+		if err != nil {
+			return nil, err
+		}
+		// the initial state of a node is recorded as 0 whatever its slot
+		if s != nil && s.Slot() <= slot {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no state recorded before slot %d", slot)
+}
+
+func readState(f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig, id string) (*state.BeaconState, error) {
+	data, err := readObject(f, freezerStateObject, id)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	s, err := core.DecodeBeaconState(beaconConfig, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recorded state %s: %w", id, err)
+	}
+	return s, nil
+}
+
+func readBlock(f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig, slot uint64) (*cltypes.SignedBeaconBlock, error) {
+	data, err := readObject(f, freezerBlockObject, strconv.FormatUint(slot, 10))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	block, err := core.DecodeBeaconBlock(beaconConfig, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recorded block of slot %d: %w", slot, err)
+	}
+	return block, nil
+}
+
+// readObject reads a recorded object, nil if it was not recorded.
+func readObject(f freezer.Freezer, object, id string) ([]byte, error) {
+	reader, _, err := f.Get(freezerNamespace, object, id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	compressed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return utils.DecompressSnappy(compressed)
+}
@@ -0,0 +1,12 @@
+package era
+
+// e2store entry types used by era files.
+var (
+	typeCompressedSignedBeaconBlock = [2]byte{0x01, 0x00}
+	typeCompressedBeaconState       = [2]byte{0x02, 0x00}
+	typeSlotIndex                   = [2]byte{0x69, 0x32}
+)
+
+// maxEntrySize bounds the size of the blocks and states read from an era file, well above the one of a compressed
+// mainnet state, so that a corrupted header cannot make us allocate 4GB.
+const maxEntrySize = 1 << 30
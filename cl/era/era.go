@@ -0,0 +1,275 @@
+// Package era reads and writes era files, the e2store archives of SLOTS_PER_HISTORICAL_ROOT slots of blocks
+// followed by the state at the end of them.
+//
+// An era file is laid out as:
+//
+//	version | block* | state | block-index | state-index
+//
+// where blocks and state are SSZ encoded and snappy framed, and where era 0 only holds the genesis state and has no
+// block index.
+package era
+
+import (
+	"fmt"
+	"io"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/common/e2store"
+)
+
+// Filename is the name of the era file of the given era: <config>-<era>-<short era root>.era.
+func Filename(configName string, era uint64, eraRoot libcommon.Hash) string {
+	return fmt.Sprintf("%s-%05d-%x.era", configName, era, eraRoot[:4])
+}
+
+// EraRoot is the root identifying the given era in its state: the genesis validators root for era 0, then the
+// historical roots and finally the roots of the historical summaries.
+func EraRoot(s *state.BeaconState, era uint64) (libcommon.Hash, error) {
+	if era == 0 {
+		return s.GenesisValidatorsRoot(), nil
+	}
+	historicalRoots := uint64(s.HistoricalRootsLength())
+	if era <= historicalRoots {
+		return s.HistoricalRoot(int(era - 1)), nil
+	}
+	if s.Version() >= clparams.CapellaVersion && era <= historicalRoots+uint64(s.HistoricalSummariesLength()) {
+		return s.HistoricalSummary(int(era - 1 - historicalRoots)).HashSSZ()
+	}
+	return libcommon.Hash{}, fmt.Errorf("state at slot %d has no root for era %d", s.Slot(), era)
+}
+
+// Writer writes an era file. Its blocks are added in slot order, then Finish writes its state and indices.
+type Writer struct {
+	e2                     *e2store.Writer
+	era                    uint64
+	slotsPerHistoricalRoot uint64
+	startSlot              uint64
+	lastSlot               *uint64
+	blockOffsets           []int64
+}
+
+// NewWriter starts the era file of the given era.
+func NewWriter(w io.Writer, beaconConfig *clparams.BeaconChainConfig, era uint64) (*Writer, error) {
+	e := &Writer{
+		e2:                     e2store.NewWriter(w),
+		era:                    era,
+		slotsPerHistoricalRoot: beaconConfig.SlotsPerHistoricalRoot,
+	}
+	if era > 0 {
+		e.startSlot = (era - 1) * beaconConfig.SlotsPerHistoricalRoot
+		e.blockOffsets = make([]int64, beaconConfig.SlotsPerHistoricalRoot)
+	}
+	if _, err := e.e2.Write(e2store.TypeVersion, nil); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// AddBlock adds a block of the era, after the blocks of the previous slots.
+func (e *Writer) AddBlock(block *cltypes.SignedBeaconBlock) error {
+	slot := block.Block.Slot
+	if e.era == 0 || slot < e.startSlot || slot >= e.startSlot+e.slotsPerHistoricalRoot {
+		return fmt.Errorf("block at slot %d is not part of era %d", slot, e.era)
+	}
+	if e.lastSlot != nil && slot <= *e.lastSlot {
+		return fmt.Errorf("block at slot %d added after block at slot %d", slot, *e.lastSlot)
+	}
+	data, err := encodeFramed(block)
+	if err != nil {
+		return err
+	}
+	offset, err := e.e2.Write(typeCompressedSignedBeaconBlock, data)
+	if err != nil {
+		return err
+	}
+	e.blockOffsets[slot-e.startSlot] = offset
+	e.lastSlot = &slot
+	return nil
+}
+
+// Finish writes the state at the end of the era, which must be at its last slot, and the indices of the file.
+func (e *Writer) Finish(s *state.BeaconState) error {
+	if expected := e.era * e.slotsPerHistoricalRoot; s.Slot() != expected {
+		return fmt.Errorf("state of era %d must be at slot %d, not %d", e.era, expected, s.Slot())
+	}
+	data, err := encodeFramed(s)
+	if err != nil {
+		return err
+	}
+	stateOffset, err := e.e2.Write(typeCompressedBeaconState, data)
+	if err != nil {
+		return err
+	}
+	if e.era > 0 {
+		// offsets are relative to the index entry itself
+		indexOffset := e.e2.Offset()
+		offsets := make([]int64, len(e.blockOffsets))
+		for i, offset := range e.blockOffsets {
+			if offset != 0 {
+				offsets[i] = offset - indexOffset
+			}
+		}
+		if _, err := e.e2.Write(typeSlotIndex, e2store.EncodeIndex(e.startSlot, offsets)); err != nil {
+			return err
+		}
+	}
+	_, err = e.e2.Write(typeSlotIndex, e2store.EncodeIndex(s.Slot(), []int64{stateOffset - e.e2.Offset()}))
+	return err
+}
+
+// Reader reads an era file through its indices.
+type Reader struct {
+	r            io.ReaderAt
+	size         int64
+	beaconConfig *clparams.BeaconChainConfig
+	era          uint64
+	startSlot    uint64
+	blockOffsets []int64
+	stateOffset  int64
+}
+
+// NewReader reads the indices of the era file of the given size.
+func NewReader(r io.ReaderAt, size int64, beaconConfig *clparams.BeaconChainConfig) (*Reader, error) {
+	e := &Reader{r: r, size: size, beaconConfig: beaconConfig}
+	typ, _, err := e2store.ReadEntry(r, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if typ != e2store.TypeVersion {
+		return nil, fmt.Errorf("not an era file, missing version")
+	}
+
+	stateIndexOffset := size - e2store.IndexSize(1)
+	stateSlot, stateOffsets, err := readSlotIndex(r, stateIndexOffset, 1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state index: %w", err)
+	}
+	if len(stateOffsets) != 1 || stateSlot%beaconConfig.SlotsPerHistoricalRoot != 0 {
+		return nil, fmt.Errorf("invalid state index for slot %d", stateSlot)
+	}
+	e.era = stateSlot / beaconConfig.SlotsPerHistoricalRoot
+	e.stateOffset = stateIndexOffset + stateOffsets[0]
+	if e.era == 0 {
+		return e, nil
+	}
+
+	blockIndexOffset := stateIndexOffset - e2store.IndexSize(beaconConfig.SlotsPerHistoricalRoot)
+	startSlot, blockOffsets, err := readSlotIndex(r, blockIndexOffset, beaconConfig.SlotsPerHistoricalRoot)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block index: %w", err)
+	}
+	if startSlot != stateSlot-beaconConfig.SlotsPerHistoricalRoot || uint64(len(blockOffsets)) != beaconConfig.SlotsPerHistoricalRoot {
+		return nil, fmt.Errorf("block index of slot %d does not match the state of slot %d", startSlot, stateSlot)
+	}
+	e.startSlot = startSlot
+	e.blockOffsets = make([]int64, len(blockOffsets))
+	for i, offset := range blockOffsets {
+		if offset != 0 {
+			e.blockOffsets[i] = blockIndexOffset + offset
+		}
+	}
+	return e, nil
+}
+
+// Era is the era of the file.
+func (e *Reader) Era() uint64 {
+	return e.era
+}
+
+// State reads the state at the end of the era.
+func (e *Reader) State() (*state.BeaconState, error) {
+	data, err := e.readFramed(e.stateOffset, typeCompressedBeaconState)
+	if err != nil {
+		return nil, err
+	}
+	return core.DecodeBeaconState(e.beaconConfig, data)
+}
+
+// Block reads the block at the given slot of the era, nil if the slot is empty.
+func (e *Reader) Block(slot uint64) (*cltypes.SignedBeaconBlock, error) {
+	if e.era == 0 || slot < e.startSlot || slot >= e.startSlot+uint64(len(e.blockOffsets)) {
+		return nil, fmt.Errorf("slot %d is not part of era %d", slot, e.era)
+	}
+	offset := e.blockOffsets[slot-e.startSlot]
+	if offset == 0 {
+		return nil, nil
+	}
+	data, err := e.readFramed(offset, typeCompressedSignedBeaconBlock)
+	if err != nil {
+		return nil, err
+	}
+	block, err := core.DecodeBeaconBlock(e.beaconConfig, data)
+	if err != nil {
+		return nil, err
+	}
+	if block.Block.Slot != slot {
+		return nil, fmt.Errorf("block indexed at slot %d is at slot %d", slot, block.Block.Slot)
+	}
+	return block, nil
+}
+
+// ForEachBlock calls fn with the blocks of the era in slot order until it returns false or an error.
+func (e *Reader) ForEachBlock(fn func(block *cltypes.SignedBeaconBlock) (bool, error)) error {
+	for i, offset := range e.blockOffsets {
+		if offset == 0 {
+			continue
+		}
+		block, err := e.Block(e.startSlot + uint64(i))
+		if err != nil {
+			return err
+		}
+		if cont, err := fn(block); err != nil || !cont {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Reader) readFramed(offset int64, expected [2]byte) ([]byte, error) {
+	// the entry cannot run past the end of the file
+	maxSize := e.size - offset - e2store.HeaderSize
+	if maxSize > maxEntrySize {
+		maxSize = maxEntrySize
+	}
+	typ, data, err := e2store.ReadEntry(e.r, offset, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	if typ != expected {
+		return nil, fmt.Errorf("entry at %d has type %x, expected %x", offset, typ, expected)
+	}
+	return e2store.DecompressFramed(data)
+}
+
+// readSlotIndex reads the slot index entry at the given offset, of count offsets at most.
+func readSlotIndex(r io.ReaderAt, offset int64, count uint64) (uint64, []int64, error) {
+	if offset < e2store.HeaderSize {
+		return 0, nil, fmt.Errorf("file is too short")
+	}
+	typ, data, err := e2store.ReadEntry(r, offset, e2store.IndexSize(count)-e2store.HeaderSize)
+	if err != nil {
+		return 0, nil, err
+	}
+	if typ != typeSlotIndex {
+		return 0, nil, fmt.Errorf("entry at %d is not a slot index", offset)
+	}
+	return e2store.DecodeIndex(data)
+}
+
+type sszMarshaler interface {
+	EncodeSSZ(dst []byte) ([]byte, error)
+}
+
+// encodeFramed SSZ encodes the object and compresses it in the snappy framing format.
+func encodeFramed(object sszMarshaler) ([]byte, error) {
+	encoded, err := object.EncodeSSZ(nil)
+	if err != nil {
+		return nil, err
+	}
+	return e2store.CompressFramed(encoded)
+}
@@ -0,0 +1,181 @@
+package era_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/era"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// the test block is a bellatrix block of era 34, the test state a capella state
+const (
+	testEra       = 34
+	testBlockSlot = 274538
+	testStateSlot = testEra * 8192
+)
+
+func testConfig() *clparams.BeaconChainConfig {
+	cfg := clparams.MainnetBeaconConfig
+	cfg.AltairForkEpoch = 0
+	cfg.BellatrixForkEpoch = 0
+	cfg.CapellaForkEpoch = testStateSlot / cfg.SlotsPerEpoch
+	return &cfg
+}
+
+// testData returns a block, the canonical block before its era and the state at the end of its era, whose block
+// roots make both blocks canonical.
+func testData(t *testing.T, cfg *clparams.BeaconChainConfig) (*cltypes.SignedBeaconBlock, *cltypes.SignedBeaconBlock, *state.BeaconState) {
+	block := &cltypes.SignedBeaconBlock{}
+	require.NoError(t, block.DecodeSSZ(rawdb.SSZTestBeaconBlock, int(clparams.BellatrixVersion)))
+	require.Equal(t, uint64(testBlockSlot), block.Block.Slot)
+	blockRoot, err := block.Block.HashSSZ()
+	require.NoError(t, err)
+	previous := &cltypes.SignedBeaconBlock{}
+	require.NoError(t, previous.DecodeSSZ(rawdb.SSZTestBeaconBlock, int(clparams.BellatrixVersion)))
+	previous.Block.Slot = testStateSlot - cfg.SlotsPerHistoricalRoot - 1
+	previousRoot, err := previous.Block.HashSSZ()
+	require.NoError(t, err)
+
+	compressed, err := os.ReadFile("../phase1/core/state/tests/capella.ssz_snappy")
+	require.NoError(t, err)
+	decompressed, err := utils.DecompressSnappy(compressed)
+	require.NoError(t, err)
+	s := state.New(cfg)
+	require.NoError(t, s.DecodeSSZ(decompressed, int(clparams.CapellaVersion)))
+	s.SetSlot(testStateSlot)
+	for slot := uint64(testStateSlot - cfg.SlotsPerHistoricalRoot); slot < testStateSlot; slot++ {
+		root := previousRoot
+		if slot >= testBlockSlot {
+			root = blockRoot
+		}
+		s.SetBlockRootAt(int(slot%cfg.SlotsPerHistoricalRoot), root)
+	}
+	return block, previous, s
+}
+
+func TestWriterReader(t *testing.T) {
+	cfg := testConfig()
+	block, _, s := testData(t, cfg)
+
+	var buf bytes.Buffer
+	w, err := era.NewWriter(&buf, cfg, testEra)
+	require.NoError(t, err)
+	require.NoError(t, w.AddBlock(block))
+	require.Error(t, w.AddBlock(block))
+	require.NoError(t, w.Finish(s))
+
+	r, err := era.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), cfg)
+	require.NoError(t, err)
+	require.Equal(t, uint64(testEra), r.Era())
+
+	readState, err := r.State()
+	require.NoError(t, err)
+	expectedRoot, err := s.HashSSZ()
+	require.NoError(t, err)
+	root, err := readState.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, expectedRoot, root)
+
+	readBlock, err := r.Block(testBlockSlot)
+	require.NoError(t, err)
+	expectedRoot, err = block.HashSSZ()
+	require.NoError(t, err)
+	root, err = readBlock.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, expectedRoot, root)
+
+	empty, err := r.Block(testBlockSlot + 1)
+	require.NoError(t, err)
+	require.Nil(t, empty)
+	_, err = r.Block(testStateSlot)
+	require.Error(t, err)
+
+	var blocks int
+	require.NoError(t, r.ForEachBlock(func(*cltypes.SignedBeaconBlock) (bool, error) {
+		blocks++
+		return true, nil
+	}))
+	require.Equal(t, 1, blocks)
+
+	_, err = era.NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), int64(buf.Len()-1), cfg)
+	require.Error(t, err)
+	// the length of an entry is bounded before it is read
+	corrupted := append([]byte{}, buf.Bytes()...)
+	copy(corrupted[2:6], []byte{0xff, 0xff, 0xff, 0xff})
+	_, err = era.NewReader(bytes.NewReader(corrupted), int64(len(corrupted)), cfg)
+	require.Error(t, err)
+}
+
+func TestWriterRejectsBlocksOfOtherEras(t *testing.T) {
+	cfg := testConfig()
+	block, _, s := testData(t, cfg)
+
+	w, err := era.NewWriter(io.Discard, cfg, testEra+1)
+	require.NoError(t, err)
+	require.Error(t, w.AddBlock(block))
+	require.Error(t, w.Finish(s))
+}
+
+func TestExportImport(t *testing.T) {
+	cfg := testConfig()
+	block, previous, s := testData(t, cfg)
+	ctx := context.Background()
+
+	recorded := &freezer.InMemory{}
+	require.NoError(t, freezer.PutObjectSSZIntoFreezer("beaconState", "caplin_core", s.Slot(), s, recorded))
+	// missing block
+	_, err := era.Export(ctx, recorded, cfg, testEra, t.TempDir())
+	require.Error(t, err)
+	require.NoError(t, freezer.PutObjectSSZIntoFreezer("signedBeaconBlock", "caplin_core", block.Block.Slot, block, recorded))
+	// missing block at the first slot, unless the one before the era is recorded
+	_, err = era.Export(ctx, recorded, cfg, testEra, t.TempDir())
+	require.Error(t, err)
+	require.NoError(t, freezer.PutObjectSSZIntoFreezer("signedBeaconBlock", "caplin_core", previous.Block.Slot, previous, recorded))
+
+	dir := t.TempDir()
+	path, err := era.Export(ctx, recorded, cfg, testEra, dir)
+	require.NoError(t, err)
+	eraRoot, err := era.EraRoot(s, testEra)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, era.Filename("mainnet", testEra, eraRoot)), path)
+
+	imported := &freezer.InMemory{}
+	require.NoError(t, era.Import(ctx, imported, cfg, path))
+	for _, object := range []struct{ name, id string }{{"signedBeaconBlock", "274538"}, {"beaconState", "278528"}} {
+		expected, err := freezer.NewBlobStore(recorded).Get("caplin_core", object.name, object.id)
+		require.NoError(t, err)
+		got, err := freezer.NewBlobStore(imported).Get("caplin_core", object.name, object.id)
+		require.NoError(t, err)
+		require.Equal(t, expected, got, object.name)
+	}
+}
+
+func TestImportRejectsNonCanonicalBlocks(t *testing.T) {
+	cfg := testConfig()
+	block, _, s := testData(t, cfg)
+	s.SetBlockRootAt(testBlockSlot%int(cfg.SlotsPerHistoricalRoot), libcommon.Hash{1})
+
+	path := filepath.Join(t.TempDir(), "test.era")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := era.NewWriter(f, cfg, testEra)
+	require.NoError(t, err)
+	require.NoError(t, w.AddBlock(block))
+	require.NoError(t, w.Finish(s))
+	require.NoError(t, f.Close())
+
+	require.Error(t, era.Import(context.Background(), &freezer.InMemory{}, cfg, path))
+}
@@ -115,6 +115,24 @@ func ComputeForkDigest(
 	return ComputeForkDigestForVersion(currentForkVersion, genesisConfig.GenesisValidatorRoot)
 }
 
+// ComputeForkDigestAtEpoch returns the fork digest of the fork active at the given epoch, it is the context of
+// the req/resp responses whose type depends on the fork of the data they carry.
+func ComputeForkDigestAtEpoch(
+	beaconConfig *clparams.BeaconChainConfig,
+	genesisValidatorRoot libcommon.Hash,
+	epoch uint64,
+) ([4]byte, error) {
+	forkVersion := utils.Uint32ToBytes4(beaconConfig.GenesisForkVersion)
+	for _, fork := range forkList(beaconConfig.ForkVersionSchedule) {
+		if epoch >= fork.epoch {
+			forkVersion = fork.version
+			continue
+		}
+		break
+	}
+	return ComputeForkDigestForVersion(forkVersion, genesisValidatorRoot)
+}
+
 func ComputeNextForkDigest(
 	beaconConfig *clparams.BeaconChainConfig,
 	genesisConfig *clparams.GenesisConfig,
@@ -33,6 +33,20 @@ func TestMainnetForkDigest(t *testing.T) {
 	require.Equal(t, [4]byte{0xbb, 0xa4, 0xda, 0x96}, digest)
 }
 
+func TestMainnetForkDigestAtEpoch(t *testing.T) {
+	beaconCfg := clparams.BeaconConfigs[clparams.MainnetNetwork]
+	genesisCfg := clparams.GenesisConfigs[clparams.MainnetNetwork]
+	for epoch, expected := range map[uint64][4]byte{
+		0:      {0xb5, 0x30, 0x3f, 0x2a},
+		74240:  {0xaf, 0xca, 0xab, 0xa0},
+		194048: {0xbb, 0xa4, 0xda, 0x96},
+	} {
+		digest, err := ComputeForkDigestAtEpoch(&beaconCfg, genesisCfg.GenesisValidatorRoot, epoch)
+		require.NoError(t, err)
+		require.Equal(t, expected, digest)
+	}
+}
+
 func TestMainnetForkDigestWithNoGenesisTime(t *testing.T) {
 	beaconCfg := clparams.BeaconConfigs[clparams.MainnetNetwork]
 	genesisCfg := clparams.GenesisConfigs[clparams.MainnetNetwork]
@@ -0,0 +1,204 @@
+package freezer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// ContentAddressedObject is the object under which ContentAddressed stores the payloads of a namespace.
+const ContentAddressedObject = "content"
+
+const (
+	codecNone byte = iota
+	codecSnappy
+)
+
+// ContentAddressed is a Freezer storing every distinct payload of a namespace once, under its sha256 hash,
+// in the ContentAddressedObject object of the namespace. What is put under an id is then only a reference to
+// the payload, along with the sidecar. Payloads are snappy compressed when compress is set, the codec being
+// recorded with each of them so that the setting can be changed over time.
+type ContentAddressed struct {
+	f        Freezer
+	compress bool
+
+	// puts share the lock, a sweep must not see a payload without the reference being put along with it
+	mu sync.RWMutex
+}
+
+func NewContentAddressed(f Freezer, compress bool) *ContentAddressed {
+	return &ContentAddressed{f: f, compress: compress}
+}
+
+func (c *ContentAddressed) Get(namespace string, object string, id string, extra ...string) (data io.ReadCloser, sidecar []byte, err error) {
+	ref, sidecar, err := c.readAll(namespace, object, id, extra...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ref) != sha256.Size {
+		return nil, nil, fmt.Errorf("content addressed freezer: invalid reference of length %d for %s/%s/%s", len(ref), namespace, object, id)
+	}
+	blob, codec, err := c.readAll(namespace, ContentAddressedObject, hex.EncodeToString(ref))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(codec) != 1 {
+		return nil, nil, fmt.Errorf("content addressed freezer: missing codec of %x", ref)
+	}
+	switch codec[0] {
+	case codecNone:
+	case codecSnappy:
+		if blob, err = snappy.Decode(nil, blob); err != nil {
+			return nil, nil, fmt.Errorf("content addressed freezer: decompressing %x: %w", ref, err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("content addressed freezer: unknown codec %d of %x", codec[0], ref)
+	}
+	if hash := sha256.Sum256(blob); !bytes.Equal(hash[:], ref) {
+		return nil, nil, fmt.Errorf("content addressed freezer: payload %x is corrupted, its hash is %x", ref, hash)
+	}
+	return io.NopCloser(bytes.NewReader(blob)), sidecar, nil
+}
+
+func (c *ContentAddressed) Put(data io.Reader, sidecar []byte, namespace string, object string, id string, extra ...string) error {
+	blob, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(blob)
+	hashId := hex.EncodeToString(hash[:])
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	// the payload may already be stored, by this id or another one
+	exists, err := c.exists(namespace, ContentAddressedObject, hashId)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		codec := codecNone
+		if c.compress {
+			codec, blob = codecSnappy, snappy.Encode(nil, blob)
+		}
+		if err := c.f.Put(bytes.NewReader(blob), []byte{codec}, namespace, ContentAddressedObject, hashId); err != nil {
+			return err
+		}
+	}
+	return c.f.Put(bytes.NewReader(hash[:]), sidecar, namespace, object, id, extra...)
+}
+
+// exists stats the id when the underlying freezer is a Stater, and otherwise opens it.
+func (c *ContentAddressed) exists(namespace string, object string, id string) (bool, error) {
+	if stater, ok := c.f.(Stater); ok {
+		return stater.Stat(namespace, object, id)
+	}
+	data, _, err := c.f.Get(namespace, object, id)
+	switch {
+	case err == nil:
+		return true, data.Close()
+	case errors.Is(err, os.ErrNotExist):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// List enumerates the ids put for an object, the underlying freezer has to be a Lister.
+func (c *ContentAddressed) List(namespace string, object string) ([]string, error) {
+	lister, ok := c.f.(Lister)
+	if !ok {
+		return nil, fmt.Errorf("content addressed freezer: %T can't list objects", c.f)
+	}
+	return lister.List(namespace, object)
+}
+
+// Delete removes the reference put under id, the payload itself is left to Sweep as other ids may share it.
+// The underlying freezer has to be a Deleter.
+func (c *ContentAddressed) Delete(namespace string, object string, id string, extra ...string) error {
+	deleter, ok := c.f.(Deleter)
+	if !ok {
+		return fmt.Errorf("content addressed freezer: %T can't delete objects", c.f)
+	}
+	return deleter.Delete(namespace, object, id, extra...)
+}
+
+// Sweep deletes the payloads of namespace which none of the ids of objects refers to anymore, e.g. once they
+// were pruned, and returns how many were deleted. objects must cover every object of the namespace, payloads
+// are shared between them. Puts wait for the sweep to be done.
+func (c *ContentAddressed) Sweep(namespace string, objects ...string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	referenced := map[string]struct{}{}
+	for _, object := range objects {
+		ids, err := c.List(namespace, object)
+		if err != nil {
+			return 0, err
+		}
+		for _, id := range ids {
+			ref, _, err := c.readAll(namespace, object, id)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+			referenced[hex.EncodeToString(ref)] = struct{}{}
+		}
+	}
+	hashes, err := c.List(namespace, ContentAddressedObject)
+	if err != nil {
+		return 0, err
+	}
+	var deleted int
+	for _, hashId := range hashes {
+		if _, ok := referenced[hashId]; ok {
+			continue
+		}
+		if err := c.Delete(namespace, ContentAddressedObject, hashId); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// SweepLoop sweeps namespace every interval until ctx is done.
+func (c *ContentAddressed) SweepLoop(ctx context.Context, interval time.Duration, namespace string, objects ...string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := c.Sweep(namespace, objects...)
+			if err != nil {
+				log.Warn("[Freezer] Failed to sweep", "namespace", namespace, "deleted", deleted, "err", err)
+				continue
+			}
+			log.Debug("[Freezer] Swept unreferenced payloads", "namespace", namespace, "deleted", deleted)
+		}
+	}
+}
+
+func (c *ContentAddressed) readAll(namespace string, object string, id string, extra ...string) (data []byte, sidecar []byte, err error) {
+	r, sidecar, err := c.f.Get(namespace, object, id, extra...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+	data, err = io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, sidecar, nil
+}
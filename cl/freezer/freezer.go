@@ -14,3 +14,18 @@ type Getter interface {
 type Putter interface {
 	Put(data io.Reader, sidecar []byte, namespace, object, id string, extra ...string) error
 }
+
+// Lister is implemented by the freezers able to enumerate the ids stored for an object.
+type Lister interface {
+	List(namespace, object string) (ids []string, err error)
+}
+
+// Stater is implemented by the freezers able to tell whether an id was put without reading what was put.
+type Stater interface {
+	Stat(namespace, object, id string, extra ...string) (exists bool, err error)
+}
+
+// Deleter is implemented by the freezers able to remove what was put.
+type Deleter interface {
+	Delete(namespace, object, id string, extra ...string) error
+}
@@ -1,14 +1,17 @@
 package freezer_test
 
 import (
+	"io"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"sync/atomic"
 	"testing"
 
 	"github.com/ledgerwatch/erigon/cl/freezer"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func runBlobStoreTest(t *testing.T, b *freezer.BlobStore) {
@@ -82,6 +85,21 @@ func testFreezer(t *testing.T, fn func() (freezer.Freezer, func())) {
 		defer cn()
 		runSidecarBlobStoreTest(t, freezer.NewSidecarBlobStore(f))
 	})
+	t.Run("Stater", func(t *testing.T) {
+		f, cn := fn()
+		defer cn()
+		stater, ok := f.(freezer.Stater)
+		if !ok {
+			t.Skip("not a stater")
+		}
+		require.NoError(t, freezer.NewBlobStore(f).Put([]byte{1}, "test", "a", "b"))
+		exists, err := stater.Stat("test", "a", "b")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = stater.Stat("test", "b", "a")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
 }
 
 func TestMemoryStore(t *testing.T) {
@@ -98,3 +116,116 @@ func TestRootPathStore(t *testing.T) {
 	}
 	testFreezer(t, c)
 }
+
+func TestRootPathOsFsDelete(t *testing.T) {
+	f := &freezer.RootPathOsFs{Root: t.TempDir()}
+	store := freezer.NewBlobStore(f)
+	require.NoError(t, store.Put([]byte{1}, "test", "a", "1"))
+	for _, id := range []string{"", ".", "..", "../a", "1/.."} {
+		assert.ErrorIs(t, f.Delete("test", "a", id), os.ErrInvalid, id)
+	}
+	assert.ErrorIs(t, f.Delete("", "test", "a"), os.ErrInvalid)
+	ans, err := store.Get("test", "a", "1")
+	require.NoError(t, err)
+	assert.EqualValues(t, []byte{1}, ans)
+
+	require.NoError(t, f.Delete("test", "a", "1"))
+	_, err = store.Get("test", "a", "1")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestContentAddressedStore(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		testFreezer(t, func() (freezer.Freezer, func()) {
+			return freezer.NewContentAddressed(&freezer.InMemory{}, compress), func() {}
+		})
+	}
+}
+
+func TestContentAddressedSweep(t *testing.T) {
+	backend := &freezer.InMemory{}
+	f := freezer.NewContentAddressed(backend, true)
+	store := freezer.NewBlobStore(f)
+	// two ids sharing a payload
+	require.NoError(t, store.Put([]byte{1, 2, 3}, "test", "a", "1"))
+	require.NoError(t, store.Put([]byte{1, 2, 3}, "test", "a", "2"))
+	require.NoError(t, store.Put([]byte{4, 5, 6}, "test", "a", "3"))
+	hashes, err := backend.List("test", freezer.ContentAddressedObject)
+	require.NoError(t, err)
+	assert.Len(t, hashes, 2)
+
+	require.NoError(t, f.Delete("test", "a", "1"))
+	deleted, err := f.Sweep("test", "a")
+	require.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+
+	require.NoError(t, f.Delete("test", "a", "2"))
+	deleted, err = f.Sweep("test", "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	ans, err := store.Get("test", "a", "3")
+	require.NoError(t, err)
+	assert.EqualValues(t, []byte{4, 5, 6}, ans)
+}
+
+// getCounter counts the reads of the freezer it wraps.
+type getCounter struct {
+	*freezer.InMemory
+	gets int
+}
+
+func (g *getCounter) Get(namespace string, object string, id string, extra ...string) (io.ReadCloser, []byte, error) {
+	g.gets++
+	return g.InMemory.Get(namespace, object, id, extra...)
+}
+
+func TestContentAddressedPutStats(t *testing.T) {
+	backend := &getCounter{InMemory: &freezer.InMemory{}}
+	store := freezer.NewBlobStore(freezer.NewContentAddressed(backend, false))
+	require.NoError(t, store.Put([]byte{1, 2, 3}, "test", "a", "1"))
+	require.NoError(t, store.Put([]byte{1, 2, 3}, "test", "a", "2"))
+	// the stored payload is not read back to find out it is there
+	assert.Equal(t, 0, backend.gets)
+	hashes, err := backend.List("test", freezer.ContentAddressedObject)
+	require.NoError(t, err)
+	assert.Len(t, hashes, 1)
+}
+
+func TestRetentionStore(t *testing.T) {
+	f, err := freezer.NewRetention(&freezer.InMemory{}, 10, 1)
+	require.NoError(t, err)
+	testFreezer(t, func() (freezer.Freezer, func()) {
+		return f, func() {}
+	})
+
+	store := freezer.NewBlobStore(f)
+	for i := 0; i < 25; i++ {
+		require.NoError(t, store.Put([]byte{byte(i)}, "test", "slots", strconv.Itoa(i)))
+	}
+	ids, err := f.List("test", "slots")
+	require.NoError(t, err)
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+	assert.Equal(t, []string{"14", "15", "16", "17", "18", "19", "20", "21", "22", "23", "24"}, ids)
+	// ids which aren't numbers are kept
+	ans, err := store.Get("test", "a", "b")
+	require.NoError(t, err)
+	assert.NotNil(t, ans)
+
+	_, err = freezer.NewRetention(nopFreezer{}, 10, 1)
+	assert.Error(t, err)
+}
+
+type nopFreezer struct{}
+
+func (nopFreezer) Get(namespace, object, id string, extra ...string) (io.ReadCloser, []byte, error) {
+	return nil, nil, os.ErrNotExist
+}
+
+func (nopFreezer) Put(data io.Reader, sidecar []byte, namespace, object, id string, extra ...string) error {
+	return nil
+}
@@ -18,12 +18,17 @@ type RootPathOsFs struct {
 func (f *RootPathOsFs) resolveFileName(namespace string, object string, id string, extra ...string) (string, error) {
 	root := filepath.Clean(f.Root)
 	j := filepath.Join(root, namespace, object, id)
-	if !strings.HasPrefix(j, root) {
+	if j != root && !strings.HasPrefix(j, root+string(filepath.Separator)) {
 		return "", os.ErrInvalid
 	}
 	return j, nil
 }
 
+// isPathElement tells whether s names a single entry of a directory.
+func isPathElement(s string) bool {
+	return s != "" && s != "." && s != ".." && filepath.Base(s) == s
+}
+
 func (f *RootPathOsFs) Get(namespace string, object string, id string, extra ...string) (data io.ReadCloser, sidecar []byte, err error) {
 	infoPath, err := f.resolveFileName(namespace, object, id)
 	if err != nil {
@@ -63,3 +68,50 @@ func (f *RootPathOsFs) Put(data io.Reader, sidecar []byte, namespace string, obj
 	}
 	return nil
 }
+
+func (f *RootPathOsFs) Stat(namespace string, object string, id string, extra ...string) (bool, error) {
+	infoPath, err := f.resolveFileName(namespace, object, id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path.Join(infoPath, RootPathDataFile)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (f *RootPathOsFs) List(namespace string, object string) ([]string, error) {
+	objectPath, err := f.resolveFileName(namespace, object, "")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(objectPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
+	return ids, nil
+}
+
+func (f *RootPathOsFs) Delete(namespace string, object string, id string, extra ...string) error {
+	// anything else than a single element each would remove a whole object, namespace or what lies above
+	if !isPathElement(namespace) || !isPathElement(object) || !isPathElement(id) {
+		return os.ErrInvalid
+	}
+	infoPath, err := f.resolveFileName(namespace, object, id)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(infoPath)
+}
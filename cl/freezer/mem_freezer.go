@@ -56,7 +56,7 @@ func (f *InMemory) Get(namespace string, object string, id string, extra ...stri
 	if err == nil {
 		sidecar = blob.Bytes()
 	}
-	return io.NopCloser(fp), sidecar, nil
+	return io.NopCloser(bytes.NewReader(fp.Bytes())), sidecar, nil
 }
 
 func (f *InMemory) Put(data io.Reader, sidecar []byte, namespace string, object string, id string, extra ...string) error {
@@ -70,3 +70,44 @@ func (f *InMemory) Put(data io.Reader, sidecar []byte, namespace string, object
 	}
 	return nil
 }
+
+func (f *InMemory) Stat(namespace string, object string, id string, extra ...string) (bool, error) {
+	infoPath, err := f.resolveFileName(namespace, object, id)
+	if err != nil {
+		return false, err
+	}
+	_, ok := f.blob.Load(path.Join(infoPath, RootPathDataFile))
+	return ok, nil
+}
+
+func (f *InMemory) List(namespace string, object string) ([]string, error) {
+	objectPath, err := f.resolveFileName(namespace, object, "")
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]struct{}{}
+	var ids []string
+	f.blob.Range(func(key, _ any) bool {
+		name, ok := key.(string)
+		if !ok || !strings.HasPrefix(name, objectPath+"/") {
+			return true
+		}
+		id := path.Dir(strings.TrimPrefix(name, objectPath+"/"))
+		if _, ok := seen[id]; !ok && id != "." {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+		return true
+	})
+	return ids, nil
+}
+
+func (f *InMemory) Delete(namespace string, object string, id string, extra ...string) error {
+	infoPath, err := f.resolveFileName(namespace, object, id)
+	if err != nil {
+		return err
+	}
+	f.blob.Delete(path.Join(infoPath, RootPathDataFile))
+	f.blob.Delete(path.Join(infoPath, RootPathSidecarFile))
+	return nil
+}
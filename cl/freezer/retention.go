@@ -0,0 +1,94 @@
+package freezer
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"sync"
+
+	"github.com/ledgerwatch/log/v3"
+)
+
+// Retention is a Freezer keeping, for every object, only the ids within keep of the highest one put so far.
+// Ids are expected to be numerical, e.g. slots, the others are never pruned. Pruning happens every interval
+// ids, as listing what is stored may be costly on remote freezers.
+type Retention struct {
+	f        Freezer
+	keep     uint64
+	interval uint64
+
+	mu     sync.Mutex
+	pruned map[string]uint64 // highest id at the time of the last pruning, per namespace/object
+}
+
+// NewRetention wraps f, which has to be a Lister and a Deleter, with a retention policy.
+func NewRetention(f Freezer, keep, interval uint64) (*Retention, error) {
+	if _, ok := f.(Lister); !ok {
+		return nil, fmt.Errorf("retention freezer: %T can't list objects", f)
+	}
+	if _, ok := f.(Deleter); !ok {
+		return nil, fmt.Errorf("retention freezer: %T can't delete objects", f)
+	}
+	if interval == 0 {
+		interval = 1
+	}
+	return &Retention{f: f, keep: keep, interval: interval, pruned: map[string]uint64{}}, nil
+}
+
+func (r *Retention) Get(namespace string, object string, id string, extra ...string) (data io.ReadCloser, sidecar []byte, err error) {
+	return r.f.Get(namespace, object, id, extra...)
+}
+
+func (r *Retention) Put(data io.Reader, sidecar []byte, namespace string, object string, id string, extra ...string) error {
+	if err := r.f.Put(data, sidecar, namespace, object, id, extra...); err != nil {
+		return err
+	}
+	num, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || num < r.keep {
+		return nil
+	}
+	key := path.Join(namespace, object)
+	r.mu.Lock()
+	last, ok := r.pruned[key]
+	if ok && num < last+r.interval {
+		r.mu.Unlock()
+		return nil
+	}
+	r.pruned[key] = num
+	r.mu.Unlock()
+
+	// what was put is safe already, failing to prune only delays it to the next interval
+	if pruned, err := r.Prune(namespace, object, num-r.keep); err != nil {
+		log.Warn("[Freezer] Failed to prune", "namespace", namespace, "object", object, "pruned", pruned, "err", err)
+	}
+	return nil
+}
+
+// Prune deletes the numerical ids of an object below the given one and returns how many were deleted.
+func (r *Retention) Prune(namespace string, object string, below uint64) (int, error) {
+	ids, err := r.f.(Lister).List(namespace, object)
+	if err != nil {
+		return 0, err
+	}
+	var deleted int
+	for _, id := range ids {
+		num, err := strconv.ParseUint(id, 10, 64)
+		if err != nil || num >= below {
+			continue
+		}
+		if err := r.f.(Deleter).Delete(namespace, object, id); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (r *Retention) List(namespace string, object string) ([]string, error) {
+	return r.f.(Lister).List(namespace, object)
+}
+
+func (r *Retention) Delete(namespace string, object string, id string, extra ...string) error {
+	return r.f.(Deleter).Delete(namespace, object, id, extra...)
+}
@@ -0,0 +1,296 @@
+package freezer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3DefaultRegion = "us-east-1"
+	s3Service       = "s3"
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3TimeFormat    = "20060102T150405Z"
+	s3DateFormat    = "20060102"
+)
+
+// S3 is a Freezer storing objects in an S3 compatible object storage (AWS S3, MinIO, ...), laid out within
+// Bucket the same way RootPathOsFs lays them out on disk. Requests are path-style and signed with AWS
+// Signature Version 4, anonymous ones are sent when no AccessKey is set.
+type S3 struct {
+	Endpoint  string // e.g. https://s3.eu-central-1.amazonaws.com or http://127.0.0.1:9000
+	Region    string // defaults to us-east-1
+	Bucket    string
+	Prefix    string // prepended to every key, optional
+	AccessKey string
+	SecretKey string
+
+	Client *http.Client // defaults to http.DefaultClient
+}
+
+func (f *S3) resolveFileName(namespace string, object string, id string, extra ...string) (string, error) {
+	j := path.Join(namespace, object, id)
+	if j == ".." || strings.HasPrefix(j, "../") || strings.HasPrefix(j, "/") {
+		return "", os.ErrInvalid
+	}
+	if f.Prefix != "" {
+		j = path.Join(f.Prefix, j)
+	}
+	return j, nil
+}
+
+func (f *S3) Get(namespace string, object string, id string, extra ...string) (data io.ReadCloser, sidecar []byte, err error) {
+	infoPath, err := f.resolveFileName(namespace, object, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := f.do(http.MethodGet, path.Join(infoPath, RootPathDataFile), nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	blob, err := f.read(http.MethodGet, path.Join(infoPath, RootPathSidecarFile), nil)
+	if err == nil {
+		sidecar = blob
+	} else if !errors.Is(err, os.ErrNotExist) {
+		resp.Body.Close()
+		return nil, nil, err
+	}
+	return resp.Body, sidecar, nil
+}
+
+func (f *S3) Put(data io.Reader, sidecar []byte, namespace string, object string, id string, extra ...string) error {
+	infoPath, err := f.resolveFileName(namespace, object, id)
+	if err != nil {
+		return err
+	}
+	// the payload is hashed as part of the signature, so it has to be read upfront
+	blob, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	if _, err := f.read(http.MethodPut, path.Join(infoPath, RootPathDataFile), blob); err != nil {
+		return err
+	}
+	if sidecar != nil {
+		if _, err := f.read(http.MethodPut, path.Join(infoPath, RootPathSidecarFile), sidecar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stat sends a HEAD request, the payload is not transferred.
+func (f *S3) Stat(namespace string, object string, id string, extra ...string) (bool, error) {
+	infoPath, err := f.resolveFileName(namespace, object, id)
+	if err != nil {
+		return false, err
+	}
+	if _, err := f.read(http.MethodHead, path.Join(infoPath, RootPathDataFile), nil); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (f *S3) Delete(namespace string, object string, id string, extra ...string) error {
+	infoPath, err := f.resolveFileName(namespace, object, id)
+	if err != nil {
+		return err
+	}
+	for _, file := range []string{RootPathDataFile, RootPathSidecarFile} {
+		if _, err := f.read(http.MethodDelete, path.Join(infoPath, file), nil); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// s3ListBucketResult is the subset of the ListObjectsV2 response needed to enumerate "directories".
+type s3ListBucketResult struct {
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (f *S3) List(namespace string, object string) ([]string, error) {
+	objectPath, err := f.resolveFileName(namespace, object, "")
+	if err != nil {
+		return nil, err
+	}
+	prefix := objectPath + "/"
+	var ids []string
+	var token string
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		query.Set("delimiter", "/")
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := f.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result s3ListBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3 freezer: decoding list of %s: %w", prefix, err)
+		}
+		for _, p := range result.CommonPrefixes {
+			if id := strings.TrimSuffix(strings.TrimPrefix(p.Prefix, prefix), "/"); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return ids, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// read sends a request and reads the whole response body.
+func (f *S3) read(method, key string, body []byte) ([]byte, error) {
+	resp, err := f.do(method, key, nil, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// do sends a signed request for key, the bucket itself when key is empty. Missing keys are reported as
+// os.ErrNotExist, other unsuccessful responses as errors carrying the status and body returned.
+func (f *S3) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	endpoint, err := url.Parse(f.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3 freezer: invalid endpoint %q: %w", f.Endpoint, err)
+	}
+	canonicalURI := strings.TrimSuffix(endpoint.EscapedPath(), "/") + "/" + s3Escape(f.Bucket, true)
+	if key != "" {
+		canonicalURI += "/" + s3Escape(key, false)
+	}
+	canonicalQuery := s3CanonicalQuery(query)
+	target := endpoint.Scheme + "://" + endpoint.Host + canonicalURI
+	if canonicalQuery != "" {
+		target += "?" + canonicalQuery
+	}
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if f.AccessKey != "" {
+		f.sign(req, canonicalURI, canonicalQuery, body, time.Now().UTC())
+	}
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("s3 freezer: %s: %w", key, os.ErrNotExist)
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("s3 freezer: %s %s: %s: %s", method, key, resp.Status, bytes.TrimSpace(msg))
+}
+
+// sign adds the AWS Signature Version 4 headers to req.
+func (f *S3) sign(req *http.Request, canonicalURI, canonicalQuery string, body []byte, now time.Time) {
+	region := f.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	payloadHash := sha256.Sum256(body)
+	amzDate := now.Format(s3TimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + hex.EncodeToString(payloadHash[:]),
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := strings.Join([]string{now.Format(s3DateFormat), region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := []byte("AWS4" + f.SecretKey)
+	for _, part := range []string{now.Format(s3DateFormat), region, s3Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", s3Algorithm, f.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3CanonicalQuery encodes the query string the way it is signed: sorted by key, with every value escaped.
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes everything but the unreserved characters, as the signature requires. Slashes are
+// only encoded when encodeSlash is set, as they separate the segments of keys.
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
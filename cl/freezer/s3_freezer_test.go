@@ -0,0 +1,128 @@
+package freezer_test
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/freezer"
+)
+
+// fakeS3 is a minimal stand-in for an S3 compatible server, serving a single bucket.
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, "/"+s.bucket) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+s.bucket), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		s.list(w, r.URL.Query().Get("prefix"))
+	case r.Method == http.MethodGet:
+		data, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodHead:
+		if _, ok := s.objects[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		s.objects[key] = data
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *fakeS3) list(w http.ResponseWriter, prefix string) {
+	type commonPrefix struct {
+		Prefix string
+	}
+	var result struct {
+		XMLName        xml.Name `xml:"ListBucketResult"`
+		CommonPrefixes []commonPrefix
+		IsTruncated    bool
+	}
+	seen := map[string]struct{}{}
+	for key := range s.objects {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(key, prefix)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			seen[prefix+rest[:i+1]] = struct{}{}
+		}
+	}
+	for p := range seen {
+		result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: p})
+	}
+	sort.Slice(result.CommonPrefixes, func(i, j int) bool { return result.CommonPrefixes[i].Prefix < result.CommonPrefixes[j].Prefix })
+	xml.NewEncoder(w).Encode(result)
+}
+
+func newS3Freezer() (*freezer.S3, func()) {
+	backend := &fakeS3{bucket: "caplin", objects: map[string][]byte{}}
+	srv := httptest.NewServer(backend)
+	return &freezer.S3{
+		Endpoint:  srv.URL,
+		Bucket:    "caplin",
+		Prefix:    "mainnet",
+		AccessKey: "access",
+		SecretKey: "secret",
+	}, srv.Close
+}
+
+func TestS3Store(t *testing.T) {
+	testFreezer(t, func() (freezer.Freezer, func()) {
+		return newS3Freezer()
+	})
+}
+
+func TestS3ListDelete(t *testing.T) {
+	f, cn := newS3Freezer()
+	defer cn()
+	store := freezer.NewBlobStore(f)
+	for _, id := range []string{"1", "2", "3"} {
+		require.NoError(t, store.Put([]byte(id), "test", "a", id))
+	}
+	ids, err := f.List("test", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+
+	require.NoError(t, f.Delete("test", "a", "2"))
+	ids, err = f.List("test", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "3"}, ids)
+
+	ids, err = f.List("test", "b")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
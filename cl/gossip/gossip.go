@@ -0,0 +1,71 @@
+package gossip
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state/shuffling"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// Gossip types of the topics the sentinel protocol does not define yet. They are given values out of its range
+// and are internal to Caplin and its embedded sentinel: they never go over the gRPC protocol, where a remote
+// sentinel would not know them, and are exchanged in process instead. The subnet a message was received on
+// travels in the BlobIndex field of sentinel.GossipData.
+const (
+	AttestationGossipType sentinel.GossipType = 100 + iota
+	SyncCommitteeGossipType
+	SyncCommitteeContributionAndProofGossipType
+	LightClientFinalityUpdateGossipType
+	LightClientOptimisticUpdateGossipType
+)
+
+// IsInternal tells whether a gossip type is one of the types internal to Caplin.
+func IsInternal(t sentinel.GossipType) bool {
+	return t >= AttestationGossipType
+}
+
+// attestationSubnetPrefixBits is ceil(log2(ATTESTATION_SUBNET_COUNT)) + ATTESTATION_SUBNET_EXTRA_BITS.
+const attestationSubnetPrefixBits = 6
+
+// ComputeSubnetForAttestation returns the attestation subnet an attestation of the given committee is gossiped on.
+func ComputeSubnetForAttestation(committeesPerSlot, slot, committeeIndex, slotsPerEpoch, subnetCount uint64) uint64 {
+	committeesSinceEpochStart := committeesPerSlot * (slot % slotsPerEpoch)
+	return (committeesSinceEpochStart + committeeIndex) % subnetCount
+}
+
+// ComputeSubscribedSubnets returns the long lived attestation subnets a node has to subscribe to at the given epoch.
+func ComputeSubscribedSubnets(nodeID [32]byte, epoch uint64, beaconCfg *clparams.BeaconChainConfig, netCfg *clparams.NetworkConfig) ([]uint64, error) {
+	nodeOffset := new(big.Int).Mod(new(big.Int).SetBytes(nodeID[:]), new(big.Int).SetUint64(netCfg.EpochsPerSubnetSubscription)).Uint64()
+	var seedInput [8]byte
+	binary.LittleEndian.PutUint64(seedInput[:], (epoch+nodeOffset)/netCfg.EpochsPerSubnetSubscription)
+	seed := utils.Keccak256(seedInput[:])
+
+	nodeIDPrefix := uint64(nodeID[0] >> (8 - attestationSubnetPrefixBits))
+	permutatedPrefix, err := shuffling.ComputeShuffledIndex(beaconCfg, nodeIDPrefix, 1<<attestationSubnetPrefixBits, seed, nil, utils.Keccak256)
+	if err != nil {
+		return nil, err
+	}
+	subnets := make([]uint64, 0, netCfg.SubnetsPerNode)
+	for i := uint64(0); i < netCfg.SubnetsPerNode; i++ {
+		subnets = append(subnets, (permutatedPrefix+i)%netCfg.AttestationSubnetCount)
+	}
+	return subnets, nil
+}
+
+// SyncCommitteeSubnetSize is the number of sync committee members gossiping on the same subnet.
+func SyncCommitteeSubnetSize(beaconCfg *clparams.BeaconChainConfig) uint64 {
+	return beaconCfg.SyncCommitteeSize / beaconCfg.SyncCommitteeSubnetCount
+}
+
+// SubnetsBitfield packs subnets into the little endian bitfield used by the ENR and the metadata.
+func SubnetsBitfield(subnets []uint64) uint64 {
+	var bits uint64
+	for _, subnet := range subnets {
+		bits |= 1 << subnet
+	}
+	return bits
+}
@@ -0,0 +1,50 @@
+package gossip_test
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/gossip"
+)
+
+func TestComputeSubnetForAttestation(t *testing.T) {
+	require.Equal(t, uint64(0), gossip.ComputeSubnetForAttestation(4, 0, 0, 32, 64))
+	require.Equal(t, uint64(3), gossip.ComputeSubnetForAttestation(4, 32, 3, 32, 64))
+	require.Equal(t, uint64(10), gossip.ComputeSubnetForAttestation(4, 34, 2, 32, 64))
+	// wraps around the subnet count
+	require.Equal(t, uint64(1), gossip.ComputeSubnetForAttestation(4, 15, 5, 32, 64))
+	require.Equal(t, uint64(2), gossip.ComputeSubnetForAttestation(4, 16, 2, 32, 64))
+}
+
+func TestComputeSubscribedSubnets(t *testing.T) {
+	_, netCfg, beaconCfg := clparams.GetConfigsByNetwork(clparams.MainnetNetwork)
+	nodeID := [32]byte{0xab, 1, 2, 3}
+
+	subnets, err := gossip.ComputeSubscribedSubnets(nodeID, 100, beaconCfg, netCfg)
+	require.NoError(t, err)
+	require.Len(t, subnets, int(netCfg.SubnetsPerNode))
+	for i, subnet := range subnets {
+		require.Less(t, subnet, netCfg.AttestationSubnetCount)
+		require.Equal(t, (subnets[0]+uint64(i))%netCfg.AttestationSubnetCount, subnet)
+	}
+
+	// the node offset is 0, subscriptions only rotate every EpochsPerSubnetSubscription epochs
+	same, err := gossip.ComputeSubscribedSubnets(nodeID, netCfg.EpochsPerSubnetSubscription-1, beaconCfg, netCfg)
+	require.NoError(t, err)
+	require.Equal(t, subnets, same)
+}
+
+func TestSubnetsBitfield(t *testing.T) {
+	require.Equal(t, uint64(0), gossip.SubnetsBitfield(nil))
+	require.Equal(t, uint64(1<<63|1<<2|1), gossip.SubnetsBitfield([]uint64{0, 2, 63}))
+}
+
+func TestIsInternal(t *testing.T) {
+	require.False(t, gossip.IsInternal(sentinel.GossipType_BeaconBlockGossipType))
+	require.False(t, gossip.IsInternal(sentinel.GossipType_BlobSidecarType))
+	require.True(t, gossip.IsInternal(gossip.AttestationGossipType))
+	require.True(t, gossip.IsInternal(gossip.LightClientOptimisticUpdateGossipType))
+}
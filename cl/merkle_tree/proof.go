@@ -0,0 +1,37 @@
+package merkle_tree
+
+import (
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// MerkleProof returns the branch proving the leaf at the given index against the root of the flat leaves,
+// ordered from the sibling of the leaf up to the child of the root. The leaves are padded with zero hashes
+// up to the next power of two.
+func MerkleProof(leaves []byte, index uint64) ([]libcommon.Hash, error) {
+	count := uint64((len(leaves) + length.Hash - 1) / length.Hash)
+	if index >= count {
+		return nil, fmt.Errorf("leaf index %d is out of range of %d leaves", index, count)
+	}
+	depth := GetDepth(NextPowerOfTwo(count))
+	layer := make([]byte, NextPowerOfTwo(count)*length.Hash)
+	copy(layer, leaves)
+
+	branch := make([]libcommon.Hash, 0, depth)
+	for i := uint8(0); i < depth; i++ {
+		sibling := index ^ 1
+		branch = append(branch, libcommon.BytesToHash(layer[sibling*length.Hash:(sibling+1)*length.Hash]))
+		next := make([]byte, len(layer)/2)
+		for j := 0; j < len(next); j += length.Hash {
+			parent := utils.Keccak256(layer[2*j:2*j+length.Hash], layer[2*j+length.Hash:2*j+2*length.Hash])
+			copy(next[j:], parent[:])
+		}
+		layer = next
+		index /= 2
+	}
+	return branch, nil
+}
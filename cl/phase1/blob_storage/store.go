@@ -0,0 +1,421 @@
+// Package blob_storage keeps the verified blob sidecars of Deneb blocks for the retention window the network expects
+// them to be served.
+package blob_storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// the objects persisted by the store
+const (
+	freezerNamespace        = "caplin_blobs"
+	freezerSidecarsObject   = "blobSidecars" // the sidecars of a block, by block root
+	freezerSlotBlocksObject = "slotBlocks"   // the roots of the blocks of a slot with sidecars, by slot
+)
+
+// pendingSlots is how many slots sidecars wait for their block to be imported, and imported blocks for their
+// sidecars.
+const pendingSlots = 32
+
+// importedBlock is what the sidecars of an imported block are checked against.
+type importedBlock struct {
+	slot          uint64
+	proposerIndex uint64
+	parentRoot    libcommon.Hash
+	commitments   []cltypes.KZGCommitment
+}
+
+// verify checks that the sidecar belongs to the block of the given root.
+func (b *importedBlock) verify(blockRoot libcommon.Hash, sidecar *cltypes.BlobSidecar) error {
+	if sidecar.BlockRoot != blockRoot || sidecar.Slot != b.slot || sidecar.BlockParentRoot != b.parentRoot || sidecar.ProposerIndex != b.proposerIndex {
+		return fmt.Errorf("blob sidecar %d does not match the header of block %x", sidecar.Index, blockRoot)
+	}
+	if sidecar.Index >= uint64(len(b.commitments)) || sidecar.KzgCommitment != b.commitments[sidecar.Index] {
+		return fmt.Errorf("blob sidecar %d does not match the kzg commitments of block %x", sidecar.Index, blockRoot)
+	}
+	return nil
+}
+
+// CanonicalChain resolves the canonical block of a slot. known is false when the slot can't be resolved, the root is
+// empty when the canonical chain has no block at the slot.
+type CanonicalChain func(slot uint64) (root libcommon.Hash, known bool, err error)
+
+// BlobStore persists the blob sidecars of the imported blocks. A sidecar is only persisted once it is checked against
+// the header and the kzg commitments of its block: the sidecars received before their block wait for it in memory.
+// The sidecars older than MIN_EPOCHS_FOR_BLOB_SIDECARS_REQUESTS are pruned by Run.
+type BlobStore struct {
+	f            freezer.Freezer
+	beaconConfig *clparams.BeaconChainConfig
+	// retention is the number of slots the sidecars are kept for.
+	retention uint64
+
+	mu          sync.RWMutex
+	chain       CanonicalChain // nil until forkchoice is started
+	prunedEpoch uint64         // epoch of the highest slot at the time of the last pruning
+	pruneBelow  uint64         // slot below which the sidecars are pruned
+	wake        chan struct{}
+
+	pendingMu   sync.Mutex
+	blocks      map[libcommon.Hash]*importedBlock         // recently imported blocks with blobs
+	pending     map[libcommon.Hash][]*cltypes.BlobSidecar // sidecars waiting for their block, by block root
+	highestSlot uint64
+}
+
+// NewBlobStore creates a store on top of f, which has to be a Lister and a Deleter for the pruning.
+func NewBlobStore(f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig, netConfig *clparams.NetworkConfig) (*BlobStore, error) {
+	if _, ok := f.(freezer.Lister); !ok {
+		return nil, fmt.Errorf("blob store: %T can't list objects", f)
+	}
+	if _, ok := f.(freezer.Deleter); !ok {
+		return nil, fmt.Errorf("blob store: %T can't delete objects", f)
+	}
+	return &BlobStore{
+		f:            f,
+		beaconConfig: beaconConfig,
+		retention:    netConfig.MinEpochsForBlobSidecarsRequest * beaconConfig.SlotsPerEpoch,
+		wake:         make(chan struct{}, 1),
+		blocks:       map[libcommon.Hash]*importedBlock{},
+		pending:      map[libcommon.Hash][]*cltypes.BlobSidecar{},
+	}, nil
+}
+
+// Run prunes the sidecars out of the retention window once per epoch, until ctx is done.
+func (s *BlobStore) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			if pruned, err := s.prune(); err != nil {
+				// failing to prune only delays it to the next epoch
+				log.Warn("[Blob Store] Failed to prune", "pruned", pruned, "err", err)
+			}
+		}
+	}
+}
+
+// OnBlock records the commitments of an imported block and persists the sidecars of the block received before it.
+func (s *BlobStore) OnBlock(block *cltypes.BeaconBlock) error {
+	if block.Version() < clparams.DenebVersion || block.Body.BlobKzgCommitments == nil || block.Body.BlobKzgCommitments.Len() == 0 {
+		return nil
+	}
+	blockRoot, err := block.HashSSZ()
+	if err != nil {
+		return err
+	}
+	imported := &importedBlock{
+		slot:          block.Slot,
+		proposerIndex: block.ProposerIndex,
+		parentRoot:    block.ParentRoot,
+		commitments:   make([]cltypes.KZGCommitment, 0, block.Body.BlobKzgCommitments.Len()),
+	}
+	block.Body.BlobKzgCommitments.Range(func(_ int, commitment *cltypes.KZGCommitment, _ int) bool {
+		imported.commitments = append(imported.commitments, *commitment)
+		return true
+	})
+
+	s.pendingMu.Lock()
+	s.blocks[blockRoot] = imported
+	pending := s.pending[blockRoot]
+	delete(s.pending, blockRoot)
+	s.evict(block.Slot)
+	s.pendingMu.Unlock()
+
+	verified := make([]*cltypes.BlobSidecar, 0, len(pending))
+	for _, sidecar := range pending {
+		if err := imported.verify(blockRoot, sidecar); err != nil {
+			log.Debug("[Blob Store] Dropped blob sidecar", "slot", sidecar.Slot, "index", sidecar.Index, "err", err)
+			continue
+		}
+		verified = append(verified, sidecar)
+	}
+	return s.write(blockRoot, imported.slot, verified)
+}
+
+// OnBlobSidecar verifies the KZG proof of the sidecar and persists it if its block is imported already, it is kept
+// until its block is imported otherwise.
+func (s *BlobStore) OnBlobSidecar(sidecar *cltypes.BlobSidecar) error {
+	if sidecar.Index >= s.beaconConfig.MaxBlobsPerBlock {
+		return fmt.Errorf("blob sidecar index %d is out of range", sidecar.Index)
+	}
+	if err := sidecar.VerifyKZGProof(); err != nil {
+		return fmt.Errorf("invalid blob sidecar kzg proof: %w", err)
+	}
+
+	s.pendingMu.Lock()
+	imported, ok := s.blocks[sidecar.BlockRoot]
+	if !ok {
+		if sidecar.Slot+pendingSlots > s.highestSlot {
+			s.addPending(sidecar)
+		}
+		s.pendingMu.Unlock()
+		return nil
+	}
+	s.pendingMu.Unlock()
+
+	if err := imported.verify(sidecar.BlockRoot, sidecar); err != nil {
+		return err
+	}
+	return s.write(sidecar.BlockRoot, sidecar.Slot, []*cltypes.BlobSidecar{sidecar})
+}
+
+// addPending keeps a sidecar until its block is imported, once per index.
+func (s *BlobStore) addPending(sidecar *cltypes.BlobSidecar) {
+	for _, pending := range s.pending[sidecar.BlockRoot] {
+		if pending.Index == sidecar.Index {
+			return
+		}
+	}
+	s.pending[sidecar.BlockRoot] = append(s.pending[sidecar.BlockRoot], sidecar)
+}
+
+// evict forgets the imported blocks and the pending sidecars older than pendingSlots.
+func (s *BlobStore) evict(slot uint64) {
+	if slot <= s.highestSlot {
+		return
+	}
+	s.highestSlot = slot
+	if slot < pendingSlots {
+		return
+	}
+	for root, block := range s.blocks {
+		if block.slot+pendingSlots <= slot {
+			delete(s.blocks, root)
+		}
+	}
+	for root, sidecars := range s.pending {
+		if sidecars[0].Slot+pendingSlots <= slot {
+			delete(s.pending, root)
+		}
+	}
+}
+
+// write persists verified sidecars of a block along with the ones already stored.
+func (s *BlobStore) write(blockRoot libcommon.Hash, slot uint64, sidecars []*cltypes.BlobSidecar) error {
+	if len(sidecars) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := s.readBlock(blockRoot)
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 {
+		if err := s.addSlotBlock(slot, blockRoot); err != nil {
+			return err
+		}
+	}
+	for _, sidecar := range sidecars {
+		known := false
+		for _, other := range stored {
+			known = known || other.Index == sidecar.Index
+		}
+		if !known {
+			stored = append(stored, sidecar)
+		}
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].Index < stored[j].Index })
+
+	encoded := make([]byte, 0, len(stored)*sidecars[0].EncodingSizeSSZ())
+	for _, sidecar := range stored {
+		if encoded, err = sidecar.EncodeSSZ(encoded); err != nil {
+			return err
+		}
+	}
+	if err := freezer.NewBlobStore(s.f).Put(utils.CompressSnappy(encoded), freezerNamespace, freezerSidecarsObject, blockRoot.String()); err != nil {
+		return err
+	}
+
+	// prune once per epoch, listing what is stored may be costly so it is left to Run.
+	epoch := slot / s.beaconConfig.SlotsPerEpoch
+	if epoch > s.prunedEpoch && slot >= s.retention {
+		s.prunedEpoch = epoch
+		s.pruneBelow = slot - s.retention
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// addSlotBlock records that a block of the slot has sidecars.
+func (s *BlobStore) addSlotBlock(slot uint64, blockRoot libcommon.Hash) error {
+	roots, err := s.readSlotBlocks(slot)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, 0, (len(roots)+1)*length.Hash)
+	for _, root := range roots {
+		data = append(data, root[:]...)
+	}
+	data = append(data, blockRoot[:]...)
+	return freezer.NewBlobStore(s.f).Put(data, freezerNamespace, freezerSlotBlocksObject, strconv.FormatUint(slot, 10))
+}
+
+// readSlotBlocks returns the roots of the blocks of a slot with sidecars.
+func (s *BlobStore) readSlotBlocks(slot uint64) ([]libcommon.Hash, error) {
+	data, err := freezer.NewBlobStore(s.f).Get(freezerNamespace, freezerSlotBlocksObject, strconv.FormatUint(slot, 10))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%length.Hash != 0 {
+		return nil, fmt.Errorf("blocks of slot %d are corrupted", slot)
+	}
+	roots := make([]libcommon.Hash, len(data)/length.Hash)
+	for i := range roots {
+		copy(roots[i][:], data[i*length.Hash:])
+	}
+	return roots, nil
+}
+
+// readBlock returns the sidecars stored for a block, ordered by index.
+func (s *BlobStore) readBlock(blockRoot libcommon.Hash) ([]*cltypes.BlobSidecar, error) {
+	data, err := freezer.NewBlobStore(s.f).Get(freezerNamespace, freezerSidecarsObject, blockRoot.String())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if data, err = utils.DecompressSnappy(data); err != nil {
+		return nil, err
+	}
+	sidecarSize := (&cltypes.BlobSidecar{}).EncodingSizeSSZ()
+	if len(data)%sidecarSize != 0 {
+		return nil, fmt.Errorf("blob sidecars of block %x are corrupted", blockRoot)
+	}
+	sidecars := make([]*cltypes.BlobSidecar, 0, len(data)/sidecarSize)
+	for pos := 0; pos < len(data); pos += sidecarSize {
+		sidecar := &cltypes.BlobSidecar{}
+		if err := sidecar.DecodeSSZ(data[pos:pos+sidecarSize], int(clparams.DenebVersion)); err != nil {
+			return nil, err
+		}
+		sidecars = append(sidecars, sidecar)
+	}
+	return sidecars, nil
+}
+
+// BlobSidecarsByRoot returns the sidecars stored for a block, ordered by index.
+func (s *BlobStore) BlobSidecarsByRoot(blockRoot libcommon.Hash) ([]*cltypes.BlobSidecar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.readBlock(blockRoot)
+}
+
+// SetCanonicalChain sets how the canonical block of a slot is resolved for the range requests.
+func (s *BlobStore) SetCanonicalChain(chain CanonicalChain) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chain = chain
+}
+
+// BlobSidecarsByRange returns the sidecars stored for count slots from the start slot, ordered by slot and index. Only
+// the sidecars of the canonical block of a slot are returned.
+func (s *BlobStore) BlobSidecarsByRange(startSlot, count uint64) ([]*cltypes.BlobSidecar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var sidecars []*cltypes.BlobSidecar
+	for slot := startSlot; slot < startSlot+count; slot++ {
+		roots, err := s.readSlotBlocks(slot)
+		if err != nil {
+			return nil, err
+		}
+		if len(roots) == 0 {
+			continue
+		}
+		root, ok, err := s.canonicalRoot(slot, roots)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		blockSidecars, err := s.readBlock(root)
+		if err != nil {
+			return nil, err
+		}
+		sidecars = append(sidecars, blockSidecars...)
+	}
+	return sidecars, nil
+}
+
+// canonicalRoot picks the canonical block among the blocks of a slot with sidecars, false if none of them is. When the
+// canonical chain can't tell, the block is only picked if it is the single one of the slot.
+func (s *BlobStore) canonicalRoot(slot uint64, roots []libcommon.Hash) (libcommon.Hash, bool, error) {
+	if s.chain != nil {
+		canonical, known, err := s.chain(slot)
+		if err != nil {
+			return libcommon.Hash{}, false, err
+		}
+		if known {
+			for _, root := range roots {
+				if root == canonical {
+					return root, true, nil
+				}
+			}
+			return libcommon.Hash{}, false, nil
+		}
+	}
+	return roots[0], len(roots) == 1, nil
+}
+
+// prune deletes the sidecars of the slots below the last pruning target and returns how many slots were deleted. The
+// store is only locked while a slot is deleted, so that writes are not held by the pruning.
+func (s *BlobStore) prune() (int, error) {
+	s.mu.RLock()
+	below := s.pruneBelow
+	s.mu.RUnlock()
+	ids, err := s.f.(freezer.Lister).List(freezerNamespace, freezerSlotBlocksObject)
+	if err != nil {
+		return 0, err
+	}
+	var deleted int
+	for _, id := range ids {
+		slot, err := strconv.ParseUint(id, 10, 64)
+		if err != nil || slot >= below {
+			continue
+		}
+		if err := s.deleteSlot(slot, id); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// deleteSlot deletes the sidecars of the blocks of a slot.
+func (s *BlobStore) deleteSlot(slot uint64, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	roots, err := s.readSlotBlocks(slot)
+	if err != nil {
+		return err
+	}
+	deleter := s.f.(freezer.Deleter)
+	for _, root := range roots {
+		if err := deleter.Delete(freezerNamespace, freezerSidecarsObject, root.String()); err != nil {
+			return err
+		}
+	}
+	return deleter.Delete(freezerNamespace, freezerSlotBlocksObject, id)
+}
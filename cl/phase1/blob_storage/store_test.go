@@ -0,0 +1,161 @@
+package blob_storage
+
+import (
+	"testing"
+
+	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	libkzg "github.com/ledgerwatch/erigon-lib/crypto/kzg"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+)
+
+// newBlobSidecar returns a sidecar with a valid proof for a blob derived from seed, which is not tied to any block.
+func newBlobSidecar(t *testing.T, seed byte) *cltypes.BlobSidecar {
+	sidecar := &cltypes.BlobSidecar{}
+	sidecar.Blob[1] = seed
+	commitment, err := libkzg.Ctx().BlobToKZGCommitment(gokzg4844.Blob(sidecar.Blob), 1)
+	require.NoError(t, err)
+	proof, err := libkzg.Ctx().ComputeBlobKZGProof(gokzg4844.Blob(sidecar.Blob), commitment, 1)
+	require.NoError(t, err)
+	sidecar.KzgCommitment = cltypes.KZGCommitment(commitment)
+	sidecar.KzgProof = cltypes.KZGProof(proof)
+	return sidecar
+}
+
+// newBlock returns a Deneb block with one blob per seed, and the sidecars of its blobs.
+func newBlock(t *testing.T, slot uint64, parentRoot libcommon.Hash, seeds ...byte) (*cltypes.BeaconBlock, []*cltypes.BlobSidecar) {
+	version := clparams.DenebVersion
+	block := &cltypes.BeaconBlock{
+		Slot:          slot,
+		ProposerIndex: 3,
+		ParentRoot:    parentRoot,
+		Body: &cltypes.BeaconBody{
+			Eth1Data:           &cltypes.Eth1Data{},
+			ProposerSlashings:  solid.NewStaticListSSZ[*cltypes.ProposerSlashing](cltypes.MaxProposerSlashings, 416),
+			AttesterSlashings:  solid.NewDynamicListSSZ[*cltypes.AttesterSlashing](cltypes.MaxAttesterSlashings),
+			Attestations:       solid.NewDynamicListSSZ[*solid.Attestation](cltypes.MaxAttestations),
+			Deposits:           solid.NewStaticListSSZ[*cltypes.Deposit](cltypes.MaxDeposits, 1240),
+			VoluntaryExits:     solid.NewStaticListSSZ[*cltypes.SignedVoluntaryExit](cltypes.MaxVoluntaryExits, 112),
+			SyncAggregate:      &cltypes.SyncAggregate{},
+			ExecutionPayload:   cltypes.NewEth1Block(version),
+			ExecutionChanges:   solid.NewStaticListSSZ[*cltypes.SignedBLSToExecutionChange](cltypes.MaxExecutionChanges, 172),
+			BlobKzgCommitments: solid.NewStaticListSSZ[*cltypes.KZGCommitment](cltypes.MaxBlobsCommittmentsPerBlock, 48),
+			Version:            version,
+		},
+	}
+	sidecars := make([]*cltypes.BlobSidecar, len(seeds))
+	for i, seed := range seeds {
+		sidecars[i] = newBlobSidecar(t, seed)
+		block.Body.BlobKzgCommitments.Append(&sidecars[i].KzgCommitment)
+	}
+	blockRoot, err := block.HashSSZ()
+	require.NoError(t, err)
+	for i, sidecar := range sidecars {
+		sidecar.BlockRoot = blockRoot
+		sidecar.Index = uint64(i)
+		sidecar.Slot = slot
+		sidecar.BlockParentRoot = parentRoot
+		sidecar.ProposerIndex = block.ProposerIndex
+	}
+	return block, sidecars
+}
+
+func TestBlobStore(t *testing.T) {
+	netConfig := clparams.NetworkConfigs[clparams.MainnetNetwork]
+	netConfig.MinEpochsForBlobSidecarsRequest = 1
+	store, err := NewBlobStore(&freezer.InMemory{}, &clparams.MainnetBeaconConfig, &netConfig)
+	require.NoError(t, err)
+
+	block, sidecars := newBlock(t, 10, libcommon.Hash{9}, 1, 2)
+	first, second := sidecars[0], sidecars[1]
+	// received before its block, it is only stored once the block is imported
+	require.NoError(t, store.OnBlobSidecar(second))
+	stored, err := store.BlobSidecarsByRoot(second.BlockRoot)
+	require.NoError(t, err)
+	require.Empty(t, stored)
+	require.NoError(t, store.OnBlock(block))
+	require.NoError(t, store.OnBlobSidecar(first))
+	// received twice, stored once
+	require.NoError(t, store.OnBlobSidecar(first))
+	stored, err = store.BlobSidecarsByRoot(first.BlockRoot)
+	require.NoError(t, err)
+	require.Equal(t, []*cltypes.BlobSidecar{first, second}, stored)
+
+	// sidecars not matching the block are rejected
+	otherBlob := newBlobSidecar(t, 3)
+	otherBlob.BlockRoot, otherBlob.Slot, otherBlob.BlockParentRoot, otherBlob.ProposerIndex = first.BlockRoot, first.Slot, first.BlockParentRoot, first.ProposerIndex
+	require.Error(t, store.OnBlobSidecar(otherBlob))
+	otherBlob.Index = 2
+	require.Error(t, store.OnBlobSidecar(otherBlob))
+	otherProposer := *first
+	otherProposer.ProposerIndex++
+	require.Error(t, store.OnBlobSidecar(&otherProposer))
+	invalidProof := *first
+	invalidProof.Blob[1]++
+	require.Error(t, store.OnBlobSidecar(&invalidProof))
+	outOfRange := *first
+	outOfRange.Index = clparams.MainnetBeaconConfig.MaxBlobsPerBlock
+	require.Error(t, store.OnBlobSidecar(&outOfRange))
+
+	// a sidecar claiming a block it does not match is dropped when the block is imported
+	equivocating, equivocatingSidecars := newBlock(t, 10, libcommon.Hash{8}, 4)
+	forged := *equivocatingSidecars[0]
+	forged.ProposerIndex++
+	require.NoError(t, store.OnBlobSidecar(&forged))
+	require.NoError(t, store.OnBlock(equivocating))
+	stored, err = store.BlobSidecarsByRoot(forged.BlockRoot)
+	require.NoError(t, err)
+	require.Empty(t, stored)
+	// the sidecars of both blocks of the slot are kept
+	require.NoError(t, store.OnBlobSidecar(equivocatingSidecars[0]))
+	stored, err = store.BlobSidecarsByRoot(equivocatingSidecars[0].BlockRoot)
+	require.NoError(t, err)
+	require.Equal(t, equivocatingSidecars, stored)
+
+	later, laterSidecars := newBlock(t, 40, libcommon.Hash{39}, 5)
+	require.NoError(t, store.OnBlock(later))
+	require.NoError(t, store.OnBlobSidecar(laterSidecars[0]))
+	// only the canonical block of a slot is served by range, a slot with several blocks is skipped while it is unknown
+	stored, err = store.BlobSidecarsByRange(0, 64)
+	require.NoError(t, err)
+	require.Equal(t, []*cltypes.BlobSidecar{laterSidecars[0]}, stored)
+	canonical := map[uint64]libcommon.Hash{10: equivocatingSidecars[0].BlockRoot}
+	store.SetCanonicalChain(func(slot uint64) (libcommon.Hash, bool, error) {
+		root, ok := canonical[slot]
+		return root, ok, nil
+	})
+	stored, err = store.BlobSidecarsByRange(0, 64)
+	require.NoError(t, err)
+	require.Equal(t, []*cltypes.BlobSidecar{equivocatingSidecars[0], laterSidecars[0]}, stored)
+	// nor is a block which is not canonical
+	canonical[10], canonical[40] = first.BlockRoot, libcommon.Hash{}
+	stored, err = store.BlobSidecarsByRange(0, 64)
+	require.NoError(t, err)
+	require.Equal(t, []*cltypes.BlobSidecar{first, second}, stored)
+	delete(canonical, 40)
+
+	// two epochs later, the sidecars out of the retention window of one epoch are pruned
+	latest, latestSidecars := newBlock(t, 64, libcommon.Hash{63}, 6)
+	require.NoError(t, store.OnBlock(latest))
+	require.NoError(t, store.OnBlobSidecar(latestSidecars[0]))
+	pruned, err := store.prune()
+	require.NoError(t, err)
+	require.Equal(t, 1, pruned)
+	for _, blockRoot := range []libcommon.Hash{first.BlockRoot, equivocatingSidecars[0].BlockRoot} {
+		stored, err = store.BlobSidecarsByRoot(blockRoot)
+		require.NoError(t, err)
+		require.Empty(t, stored)
+	}
+	stored, err = store.BlobSidecarsByRange(0, 65)
+	require.NoError(t, err)
+	require.Equal(t, []*cltypes.BlobSidecar{laterSidecars[0], latestSidecars[0]}, stored)
+
+	// the sidecars of blocks imported too long ago are not kept waiting
+	require.NoError(t, store.OnBlobSidecar(newBlobSidecar(t, 7)))
+	require.Empty(t, store.pending)
+}
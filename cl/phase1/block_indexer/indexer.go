@@ -0,0 +1,136 @@
+package block_indexer
+
+import (
+	"context"
+	"encoding/binary"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+)
+
+// subscriptionBufferSize is large enough for the blocks imported at once while catching up with the chain.
+const subscriptionBufferSize = 8192
+
+var eventTopics = []string{beaconevents.TopicBlock, beaconevents.TopicFinalizedCheckpoint}
+
+// Indexer stores the blocks finalized by forkchoice into the indices database, together with the canonical root of
+// each slot, so that they can be served once forkchoice pruned them.
+type Indexer struct {
+	db           kv.RwDB
+	beaconConfig *clparams.BeaconChainConfig
+	// the last finalized slot stored, the blocks at or before it are not stored again.
+	lastSlot uint64
+}
+
+func NewIndexer(db kv.RwDB, beaconConfig *clparams.BeaconChainConfig) *Indexer {
+	return &Indexer{db: db, beaconConfig: beaconConfig}
+}
+
+// Start resumes from the last finalized slot stored and indexes the blocks finalized by forkchoice in the background
+// until the context is cancelled.
+func (i *Indexer) Start(ctx context.Context, forkChoice *forkchoice.ForkChoiceStore, emitter *beaconevents.Emitter) error {
+	if err := i.db.View(ctx, func(tx kv.Tx) error {
+		c, err := tx.Cursor(kv.FinalizedBlockRoots)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		k, _, err := c.Last()
+		if err != nil || len(k) != 4 {
+			return err
+		}
+		i.lastSlot = uint64(binary.BigEndian.Uint32(k))
+		return nil
+	}); err != nil {
+		return err
+	}
+	// subscribe before forkchoice imports any block so that none of them is missed
+	sub, unsubscribe := emitter.Subscribe(eventTopics, subscriptionBufferSize)
+	go i.run(ctx, sub, unsubscribe, forkChoice, emitter)
+	return nil
+}
+
+func (i *Indexer) run(ctx context.Context, sub *beaconevents.Subscription, unsubscribe func(), forkChoice *forkchoice.ForkChoiceStore, emitter *beaconevents.Emitter) {
+	// the blocks imported by forkchoice which are not finalized yet, forkchoice may prune them before they are
+	pending := make(map[libcommon.Hash]*cltypes.SignedBeaconBlock)
+	for {
+		select {
+		case <-ctx.Done():
+			unsubscribe()
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// the blocks missed meanwhile are left out of the index
+				log.Warn("[Block Indexer] Fell behind the chain events, resubscribing")
+				sub, unsubscribe = emitter.Subscribe(eventTopics, subscriptionBufferSize)
+				continue
+			}
+			switch data := event.Data.(type) {
+			case *beaconevents.BlockData:
+				if block, ok := forkChoice.GetBlock(data.Block); ok {
+					pending[data.Block] = block
+				}
+			case *beaconevents.FinalizedCheckpointData:
+				if err := i.onFinalized(ctx, forkChoice, data, pending); err != nil {
+					log.Warn("[Block Indexer] Could not index finalized blocks", "epoch", data.Epoch, "err", err)
+				}
+			}
+		}
+	}
+}
+
+// onFinalized stores the chain of the finalized checkpoint down to the last finalized slot stored, or to the first
+// block neither pending nor held by forkchoice.
+func (i *Indexer) onFinalized(ctx context.Context, forkChoice *forkchoice.ForkChoiceStore, data *beaconevents.FinalizedCheckpointData, pending map[libcommon.Hash]*cltypes.SignedBeaconBlock) error {
+	epochStart := data.Epoch * i.beaconConfig.SlotsPerEpoch
+	defer func() {
+		for root, block := range pending {
+			if block.Block.Slot <= epochStart {
+				delete(pending, root)
+			}
+		}
+	}()
+
+	var chain []*cltypes.SignedBeaconBlock
+	for root := data.Block; ; {
+		block, ok := pending[root]
+		if !ok {
+			block, ok = forkChoice.GetBlock(root)
+		}
+		if !ok || block.Block.Slot <= i.lastSlot {
+			break
+		}
+		chain = append(chain, block)
+		root = block.Block.ParentRoot
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+	if err := i.db.Update(ctx, func(tx kv.RwTx) error {
+		for _, block := range chain {
+			root, err := block.Block.HashSSZ()
+			if err != nil {
+				return err
+			}
+			if err := rawdb.WriteBeaconBlock(tx, block); err != nil {
+				return err
+			}
+			if err := rawdb.WriteFinalizedBlockRoot(tx, block.Block.Slot, root); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	i.lastSlot = chain[0].Block.Slot
+	log.Debug("[Block Indexer] Indexed finalized blocks", "from", chain[len(chain)-1].Block.Slot, "to", i.lastSlot)
+	return nil
+}
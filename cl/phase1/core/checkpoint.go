@@ -2,10 +2,21 @@ package core
 
 import (
 	"context"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
@@ -48,3 +59,136 @@ func RetrieveBeaconState(ctx context.Context, beaconConfig *clparams.BeaconChain
 	}
 	return beaconState, nil
 }
+
+// ReadBeaconStateFromFile reads a beacon state from a local file, SSZ encoded, or SSZ encoded and snappy compressed
+// when its extension is .ssz_snappy.
+func ReadBeaconStateFromFile(beaconConfig *clparams.BeaconChainConfig, path string) (*state.BeaconState, error) {
+	log.Info("[Checkpoint Sync] Reading beacon state", "file", path)
+	marshaled, err := readSSZFile(path)
+	if err != nil {
+		return nil, err
+	}
+	beaconState, err := DecodeBeaconState(beaconConfig, marshaled)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint sync failed, invalid beacon state %s: %s", path, err)
+	}
+	return beaconState, nil
+}
+
+// ReadBeaconBlockFromFile reads a signed beacon block from a local file, encoded like for ReadBeaconStateFromFile.
+func ReadBeaconBlockFromFile(beaconConfig *clparams.BeaconChainConfig, path string) (*cltypes.SignedBeaconBlock, error) {
+	marshaled, err := readSSZFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, err := DecodeBeaconBlock(beaconConfig, marshaled)
+	if err != nil {
+		return nil, fmt.Errorf("invalid beacon block %s: %s", path, err)
+	}
+	return block, nil
+}
+
+// DecodeBeaconState decodes an SSZ encoded beacon state, whose fork is deduced from its slot.
+func DecodeBeaconState(beaconConfig *clparams.BeaconChainConfig, marshaled []byte) (*state.BeaconState, error) {
+	// the slot comes right after genesis_time and genesis_validators_root
+	if len(marshaled) < 48 {
+		return nil, fmt.Errorf("too short to be a beacon state")
+	}
+	slot := binary.LittleEndian.Uint64(marshaled[40:48])
+
+	beaconState := state.New(beaconConfig)
+	if err := beaconState.DecodeSSZ(marshaled, int(beaconConfig.GetCurrentStateVersion(slot/beaconConfig.SlotsPerEpoch))); err != nil {
+		return nil, err
+	}
+	return beaconState, nil
+}
+
+// DecodeBeaconBlock decodes an SSZ encoded signed beacon block, whose fork is deduced from its slot.
+func DecodeBeaconBlock(beaconConfig *clparams.BeaconChainConfig, marshaled []byte) (*cltypes.SignedBeaconBlock, error) {
+	// the slot comes right after the offset of the block and the signature
+	if len(marshaled) < 108 {
+		return nil, fmt.Errorf("too short to be a beacon block")
+	}
+	slot := binary.LittleEndian.Uint64(marshaled[100:108])
+
+	block := &cltypes.SignedBeaconBlock{}
+	if err := block.DecodeSSZ(marshaled, int(beaconConfig.GetCurrentStateVersion(slot/beaconConfig.SlotsPerEpoch))); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func readSSZFile(path string) ([]byte, error) {
+	marshaled, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) == ".ssz_snappy" {
+		return utils.DecompressSnappy(marshaled)
+	}
+	return marshaled, nil
+}
+
+// ParseWeakSubjectivityCheckpoint parses a weak subjectivity checkpoint given as block_root:epoch.
+func ParseWeakSubjectivityCheckpoint(s string) (solid.Checkpoint, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid weak subjectivity checkpoint %q, expected block_root:epoch", s)
+	}
+	root, err := hex.DecodeString(strings.TrimPrefix(parts[0], "0x"))
+	if err != nil || len(root) != length.Hash {
+		return nil, fmt.Errorf("invalid weak subjectivity checkpoint root %q", parts[0])
+	}
+	epoch, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid weak subjectivity checkpoint epoch %q: %s", parts[1], err)
+	}
+	return solid.NewCheckpointFromParameters(libcommon.BytesToHash(root), epoch), nil
+}
+
+// CheckpointBlockRoot is the root of the latest block applied to the state.
+func CheckpointBlockRoot(s *state.BeaconState) (libcommon.Hash, error) {
+	header := s.LatestBlockHeader()
+	// the state root of the header is only filled by the slot processing following the block
+	if header.Root == (libcommon.Hash{}) {
+		stateRoot, err := s.HashSSZ()
+		if err != nil {
+			return libcommon.Hash{}, err
+		}
+		header.Root = stateRoot
+	}
+	return header.HashSSZ()
+}
+
+// VerifyWeakSubjectivityCheckpoint checks that the state is the state of the given checkpoint: its latest block is
+// the checkpoint block, and the state is either at that block or at the start of the checkpoint epoch.
+func VerifyWeakSubjectivityCheckpoint(s *state.BeaconState, checkpoint solid.Checkpoint) error {
+	blockRoot, err := CheckpointBlockRoot(s)
+	if err != nil {
+		return err
+	}
+	if blockRoot != checkpoint.BlockRoot() {
+		return fmt.Errorf("weak subjectivity check failed, the state is at block %x, expected %x", blockRoot, checkpoint.BlockRoot())
+	}
+	epochStart := checkpoint.Epoch() * s.BeaconConfig().SlotsPerEpoch
+	if s.Slot() > epochStart || s.Slot()+s.BeaconConfig().SlotsPerEpoch <= epochStart {
+		return fmt.Errorf("weak subjectivity check failed, the state is at slot %d, expected the start of epoch %d", s.Slot(), checkpoint.Epoch())
+	}
+	return nil
+}
+
+// VerifyCheckpointBlock checks that the block is the latest block applied to the state.
+func VerifyCheckpointBlock(s *state.BeaconState, block *cltypes.SignedBeaconBlock) error {
+	expected, err := CheckpointBlockRoot(s)
+	if err != nil {
+		return err
+	}
+	blockRoot, err := block.Block.HashSSZ()
+	if err != nil {
+		return err
+	}
+	if blockRoot != expected {
+		return fmt.Errorf("checkpoint block %x at slot %d does not match the block %x of the state", blockRoot, block.Block.Slot, expected)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package core_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/phase1/core"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+func TestReadBeaconStateFromFile(t *testing.T) {
+	compressed, err := os.ReadFile("state/tests/capella.ssz_snappy")
+	require.NoError(t, err)
+	decompressed, err := utils.DecompressSnappy(compressed)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "state.ssz_snappy"), compressed, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "state.ssz"), decompressed, 0644))
+	for _, name := range []string{"state.ssz_snappy", "state.ssz"} {
+		s, err := core.ReadBeaconStateFromFile(&clparams.MainnetBeaconConfig, filepath.Join(dir, name))
+		require.NoError(t, err)
+		require.Equal(t, clparams.CapellaVersion, s.Version())
+		root, err := s.HashSSZ()
+		require.NoError(t, err)
+		require.Equal(t, libcommon.HexToHash("0xb3012b73c02ab66b2779d996f9d33d36e58bf71ffc8f3e12e07024606617a9c0"), libcommon.Hash(root))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "short.ssz"), decompressed[:40], 0644))
+	_, err = core.ReadBeaconStateFromFile(&clparams.MainnetBeaconConfig, filepath.Join(dir, "short.ssz"))
+	require.Error(t, err)
+}
+
+func TestParseWeakSubjectivityCheckpoint(t *testing.T) {
+	root := libcommon.HexToHash("0x0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")
+	checkpoint, err := core.ParseWeakSubjectivityCheckpoint(root.Hex() + ":1234")
+	require.NoError(t, err)
+	require.Equal(t, root, checkpoint.BlockRoot())
+	require.Equal(t, uint64(1234), checkpoint.Epoch())
+
+	for _, invalid := range []string{"", root.Hex(), "0x01:1234", root.Hex() + ":", root.Hex() + ":-1", root.Hex() + ":1:2"} {
+		_, err := core.ParseWeakSubjectivityCheckpoint(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestVerifyWeakSubjectivityCheckpoint(t *testing.T) {
+	compressed, err := os.ReadFile("state/tests/capella.ssz_snappy")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "state.ssz_snappy")
+	require.NoError(t, os.WriteFile(path, compressed, 0644))
+	s, err := core.ReadBeaconStateFromFile(&clparams.MainnetBeaconConfig, path)
+	require.NoError(t, err)
+
+	blockRoot, err := core.CheckpointBlockRoot(s)
+	require.NoError(t, err)
+	// the checkpoint epoch is the one starting at or right after the state
+	epoch := (s.Slot() + s.BeaconConfig().SlotsPerEpoch - 1) / s.BeaconConfig().SlotsPerEpoch
+
+	require.NoError(t, core.VerifyWeakSubjectivityCheckpoint(s, solid.NewCheckpointFromParameters(blockRoot, epoch)))
+	require.Error(t, core.VerifyWeakSubjectivityCheckpoint(s, solid.NewCheckpointFromParameters(libcommon.Hash{1}, epoch)))
+	require.Error(t, core.VerifyWeakSubjectivityCheckpoint(s, solid.NewCheckpointFromParameters(blockRoot, epoch+1)))
+	checkpoint, err := core.ParseWeakSubjectivityCheckpoint(fmt.Sprintf("%x:%d", blockRoot, epoch))
+	require.NoError(t, err)
+	require.NoError(t, core.VerifyWeakSubjectivityCheckpoint(s, checkpoint))
+}
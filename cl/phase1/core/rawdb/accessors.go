@@ -58,7 +58,7 @@ func WriteBeaconBlock(tx kv.RwTx, signedBlock *cltypes.SignedBeaconBlock) error
 	return tx.Put(kv.BeaconBlocks, key, utils.CompressSnappy(value))
 }
 
-func ReadBeaconBlock(tx kv.RwTx, blockRoot libcommon.Hash, slot uint64, version clparams.StateVersion) (*cltypes.SignedBeaconBlock, uint64, libcommon.Hash, error) {
+func ReadBeaconBlock(tx kv.Getter, blockRoot libcommon.Hash, slot uint64, version clparams.StateVersion) (*cltypes.SignedBeaconBlock, uint64, libcommon.Hash, error) {
 	encodedBeaconBlock, err := tx.GetOne(kv.BeaconBlocks, append(EncodeNumber(slot), blockRoot[:]...))
 	if err != nil {
 		return nil, 0, libcommon.Hash{}, err
@@ -99,3 +99,30 @@ func ReadFinalizedBlockRoot(tx kv.Getter, slot uint64) (libcommon.Hash, error) {
 	}
 	return libcommon.BytesToHash(root), nil
 }
+
+// ReadBlockSlotByBlockRoot returns the slot of a stored block given its root, nil if the root is not indexed.
+func ReadBlockSlotByBlockRoot(tx kv.Getter, blockRoot libcommon.Hash) (*uint64, error) {
+	slotBytes, err := tx.GetOne(kv.RootSlotIndex, blockRoot[:])
+	if err != nil {
+		return nil, err
+	}
+	// Block roots map to a 4 bytes slot, state roots map to slot + block root.
+	if len(slotBytes) != 4 {
+		return nil, nil
+	}
+	slot := uint64(binary.BigEndian.Uint32(slotBytes))
+	return &slot, nil
+}
+
+// ReadBlockRootByStateRoot returns the slot and the root of the block which produced the given state root.
+func ReadBlockRootByStateRoot(tx kv.Getter, stateRoot libcommon.Hash) (*uint64, libcommon.Hash, error) {
+	key, err := tx.GetOne(kv.RootSlotIndex, stateRoot[:])
+	if err != nil {
+		return nil, libcommon.Hash{}, err
+	}
+	if len(key) != 4+length.Hash {
+		return nil, libcommon.Hash{}, nil
+	}
+	slot := uint64(binary.BigEndian.Uint32(key[:4]))
+	return &slot, libcommon.BytesToHash(key[4:]), nil
+}
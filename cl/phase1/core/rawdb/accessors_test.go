@@ -44,3 +44,24 @@ func TestFinalizedBlockRoot(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, libcommon.BytesToHash(root[:]), newRoot)
 }
+
+func TestBlockRootIndices(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	signedBeaconBlock := new(cltypes.SignedBeaconBlock)
+	require.NoError(t, signedBeaconBlock.DecodeSSZ(rawdb.SSZTestBeaconBlock, int(clparams.BellatrixVersion)))
+
+	root, err := signedBeaconBlock.Block.HashSSZ()
+	require.NoError(t, err)
+
+	require.NoError(t, rawdb.WriteBeaconBlock(tx, signedBeaconBlock))
+	slot, err := rawdb.ReadBlockSlotByBlockRoot(tx, root)
+	require.NoError(t, err)
+	require.NotNil(t, slot)
+	require.Equal(t, signedBeaconBlock.Block.Slot, *slot)
+
+	slot, blockRoot, err := rawdb.ReadBlockRootByStateRoot(tx, signedBeaconBlock.Block.StateRoot)
+	require.NoError(t, err)
+	require.NotNil(t, slot)
+	require.Equal(t, signedBeaconBlock.Block.Slot, *slot)
+	require.Equal(t, libcommon.Hash(root), blockRoot)
+}
@@ -57,17 +57,24 @@ func (b *BeaconState) SetPreviousStateRoot(root libcommon.Hash) {
 }
 
 func (b *BeaconState) _updateProposerIndex() (err error) {
-	epoch := Epoch(b.BeaconState)
+	b.proposerIndex = new(uint64)
+	*b.proposerIndex, err = b.computeProposerIndexForSlot(b.Slot())
+	return
+}
 
-	hash := sha256.New()
+// computeProposerIndexForSlot computes the proposer for the given slot using the shuffling of the slot's epoch.
+func (b *BeaconState) computeProposerIndexForSlot(slot uint64) (uint64, error) {
 	beaconConfig := b.BeaconConfig()
+	epoch := GetEpochAtSlot(beaconConfig, slot)
+
+	hash := sha256.New()
 	mixPosition := (epoch + beaconConfig.EpochsPerHistoricalVector - beaconConfig.MinSeedLookahead - 1) %
 		beaconConfig.EpochsPerHistoricalVector
 	// Input for the seed hash.
 	mix := b.GetRandaoMix(int(mixPosition))
-	input := shuffling2.GetSeed(b.BeaconConfig(), mix, epoch, b.BeaconConfig().DomainBeaconProposer)
+	input := shuffling2.GetSeed(beaconConfig, mix, epoch, beaconConfig.DomainBeaconProposer)
 	slotByteArray := make([]byte, 8)
-	binary.LittleEndian.PutUint64(slotByteArray, b.Slot())
+	binary.LittleEndian.PutUint64(slotByteArray, slot)
 
 	// Add slot to the end of the input.
 	inputWithSlot := append(input[:], slotByteArray...)
@@ -81,9 +88,7 @@ func (b *BeaconState) _updateProposerIndex() (err error) {
 	// Write the seed to an array.
 	seedArray := [32]byte{}
 	copy(seedArray[:], seed)
-	b.proposerIndex = new(uint64)
-	*b.proposerIndex, err = shuffling2.ComputeProposerIndex(b.BeaconState, indices, seedArray)
-	return
+	return shuffling2.ComputeProposerIndex(b.BeaconState, indices, seedArray)
 }
 
 // _initializeValidatorsPhase0 initializes the validators matching flags based on previous/current attestations
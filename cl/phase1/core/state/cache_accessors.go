@@ -74,6 +74,18 @@ func (b *BeaconState) GetBeaconProposerIndex() (uint64, error) {
 	return *b.proposerIndex, nil
 }
 
+// GetBeaconProposerIndexForSlot computes the proposer index for an arbitrary slot of the current or next epoch.
+func (b *BeaconState) GetBeaconProposerIndexForSlot(slot uint64) (uint64, error) {
+	if slot == b.Slot() {
+		return b.GetBeaconProposerIndex()
+	}
+	epoch := GetEpochAtSlot(b.BeaconConfig(), slot)
+	if currentEpoch := Epoch(b.BeaconState); epoch < currentEpoch || epoch > currentEpoch+b.BeaconConfig().MinSeedLookahead {
+		return 0, fmt.Errorf("GetBeaconProposerIndexForSlot: slot %d is outside of the lookahead of epoch %d", slot, currentEpoch)
+	}
+	return b.computeProposerIndexForSlot(slot)
+}
+
 // BaseRewardPerIncrement return base rewards for processing sync committee and duties.
 func (b *BeaconState) BaseRewardPerIncrement() uint64 {
 	if b.totalActiveBalanceCache == nil {
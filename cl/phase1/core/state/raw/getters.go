@@ -276,6 +276,22 @@ func (b *BeaconState) NextWithdrawalValidatorIndex() uint64 {
 	return b.nextWithdrawalValidatorIndex
 }
 
+func (b *BeaconState) HistoricalRootsLength() int {
+	return b.historicalRoots.Length()
+}
+
+func (b *BeaconState) HistoricalRoot(index int) libcommon.Hash {
+	return b.historicalRoots.Get(index)
+}
+
+func (b *BeaconState) HistoricalSummariesLength() int {
+	return b.historicalSummaries.Len()
+}
+
+func (b *BeaconState) HistoricalSummary(index int) *cltypes.HistoricalSummary {
+	return b.historicalSummaries.Get(index)
+}
+
 // more compluicated ones
 
 // GetBlockRootAtSlot returns the block root at a given slot
@@ -301,3 +301,32 @@ func (b *BeaconState) markLeaf(idxs ...StateLeafIndex) {
 		b.touchedLeaves[idx] = true
 	}
 }
+
+// CurrentSyncCommitteeBranch returns the proof of the current sync committee against the state root.
+func (b *BeaconState) CurrentSyncCommitteeBranch() ([]libcommon.Hash, error) {
+	if err := b.computeDirtyLeaves(); err != nil {
+		return nil, err
+	}
+	return merkle_tree.MerkleProof(b.leaves, uint64(CurrentSyncCommitteeLeafIndex))
+}
+
+// NextSyncCommitteeBranch returns the proof of the next sync committee against the state root.
+func (b *BeaconState) NextSyncCommitteeBranch() ([]libcommon.Hash, error) {
+	if err := b.computeDirtyLeaves(); err != nil {
+		return nil, err
+	}
+	return merkle_tree.MerkleProof(b.leaves, uint64(NextSyncCommitteeLeafIndex))
+}
+
+// FinalityRootBranch returns the proof of the block root of the finalized checkpoint against the state root,
+// starting with the epoch of the checkpoint which is the sibling of the root.
+func (b *BeaconState) FinalityRootBranch() ([]libcommon.Hash, error) {
+	if err := b.computeDirtyLeaves(); err != nil {
+		return nil, err
+	}
+	branch, err := merkle_tree.MerkleProof(b.leaves, uint64(FinalizedCheckpointLeafIndex))
+	if err != nil {
+		return nil, err
+	}
+	return append([]libcommon.Hash{merkle_tree.Uint64Root(b.finalizedCheckpoint.Epoch())}, branch...), nil
+}
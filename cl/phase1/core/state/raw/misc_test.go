@@ -6,6 +6,8 @@ import (
 
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/utils"
 )
 
 func TestGetters(t *testing.T) {
@@ -29,3 +31,27 @@ func TestGetters(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, common.Hash(root), common.HexToHash("0x9f1620db18ee06b9cbdf1b7fa9658701063d2bd05d54b09780f6c0a074b4ce5f"))
 }
+
+func TestBranches(t *testing.T) {
+	state := GetTestState()
+	root, err := state.HashSSZ()
+	require.NoError(t, err)
+
+	currentRoot, err := state.CurrentSyncCommittee().HashSSZ()
+	require.NoError(t, err)
+	branch, err := state.CurrentSyncCommitteeBranch()
+	require.NoError(t, err)
+	require.True(t, utils.IsValidMerkleBranch(currentRoot, branch, 5, uint64(CurrentSyncCommitteeLeafIndex), root))
+
+	nextRoot, err := state.NextSyncCommittee().HashSSZ()
+	require.NoError(t, err)
+	branch, err = state.NextSyncCommitteeBranch()
+	require.NoError(t, err)
+	require.True(t, utils.IsValidMerkleBranch(nextRoot, branch, 5, uint64(NextSyncCommitteeLeafIndex), root))
+	require.False(t, utils.IsValidMerkleBranch(currentRoot, branch, 5, uint64(NextSyncCommitteeLeafIndex), root))
+
+	branch, err = state.FinalityRootBranch()
+	require.NoError(t, err)
+	require.Len(t, branch, 6)
+	require.True(t, utils.IsValidMerkleBranch(state.FinalizedCheckpoint().BlockRoot(), branch, 6, uint64(FinalizedCheckpointLeafIndex)*2+1, root))
+}
@@ -50,6 +50,12 @@ func (b *BeaconState) EncodeSSZ(buf []byte) ([]byte, error) {
 	return ssz2.MarshalSSZ(buf, b.getSchema()...)
 }
 
+// DynamicOffsetsSSZ returns the offsets of the variable size fields in the SSZ encoding of a state of the same
+// version, which is not decoded.
+func (b *BeaconState) DynamicOffsetsSSZ(buf []byte) ([]int, error) {
+	return ssz2.DynamicOffsets(buf, b.getSchema()...)
+}
+
 // getSchema gives the schema for the current beacon state version according to ETH 2.0 specs.
 func (b *BeaconState) getSchema() []interface{} {
 	s := []interface{}{&b.genesisTime, b.genesisValidatorsRoot[:], &b.slot, b.fork, b.latestBlockHeader, b.blockRoots, b.stateRoots, b.historicalRoots,
@@ -186,6 +186,15 @@ func ProcessDeposit(s *state2.BeaconState, deposit *cltypes.Deposit, fullValidat
 
 // ProcessVoluntaryExit takes a voluntary exit and applies state transition.
 func ProcessVoluntaryExit(s *state2.BeaconState, signedVoluntaryExit *cltypes.SignedVoluntaryExit, fullValidation bool) error {
+	if err := ValidateVoluntaryExit(s, signedVoluntaryExit, fullValidation); err != nil {
+		return err
+	}
+	// Do the exit (same process in slashing).
+	return s.InitiateValidatorExit(signedVoluntaryExit.VolunaryExit.ValidatorIndex)
+}
+
+// ValidateVoluntaryExit checks that a voluntary exit can be applied to the state, without modifying it.
+func ValidateVoluntaryExit(s *state2.BeaconState, signedVoluntaryExit *cltypes.SignedVoluntaryExit, fullValidation bool) error {
 	// Sanity checks so that we know it is good.
 	voluntaryExit := signedVoluntaryExit.VolunaryExit
 	currentEpoch := state2.Epoch(s.BeaconState)
@@ -225,8 +234,7 @@ func ProcessVoluntaryExit(s *state2.BeaconState, signedVoluntaryExit *cltypes.Si
 			return errors.New("ProcessVoluntaryExit: BLS verification failed")
 		}
 	}
-	// Do the exit (same process in slashing).
-	return s.InitiateValidatorExit(voluntaryExit.ValidatorIndex)
+	return nil
 }
 
 // ProcessWithdrawals processes withdrawals by decreasing the balance of each validator
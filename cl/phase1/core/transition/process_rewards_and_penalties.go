@@ -6,15 +6,44 @@ import (
 	state2 "github.com/ledgerwatch/erigon/cl/phase1/core/state"
 )
 
-func processRewardsAndPenaltiesPostAltair(s *state2.BeaconState) (err error) {
+// AttestationReward is the breakdown of what a validator earns (positive) or loses (negative) for its attestations of
+// the previous epoch, applied when the current epoch is processed.
+type AttestationReward struct {
+	ValidatorIndex uint64
+	Source         int64
+	Target         int64
+	Head           int64
+	// InclusionDelay rewards the inclusion speed of the attestation, phase0 only.
+	InclusionDelay uint64
+	Inactivity     int64
+
+	// the proposer which included the attestation, it gets a share of the phase0 inclusion reward.
+	inclusionProposerIndex  uint64
+	inclusionProposerReward uint64
+}
+
+// flagComponent returns which of the source, target and head components a participation flag rewards.
+func flagComponent(beaconConfig *clparams.BeaconChainConfig, flagIdx int, source, target, head *int64) *int64 {
+	switch flagIdx {
+	case int(beaconConfig.TimelySourceFlagIndex):
+		return source
+	case int(beaconConfig.TimelyTargetFlagIndex):
+		return target
+	}
+	return head
+}
+
+// flagReward returns the component of the reward matching a participation flag.
+func (r *AttestationReward) flagReward(beaconConfig *clparams.BeaconChainConfig, flagIdx int) *int64 {
+	return flagComponent(beaconConfig, flagIdx, &r.Source, &r.Target, &r.Head)
+}
+
+// flagRewardMultipliers precomputes the participation of each flag, so that the reward of a participating validator
+// is baseReward * multipliers[flag] / denominator.
+func flagRewardMultipliers(s *state2.BeaconState) (multipliers []uint64, denominator uint64) {
 	beaconConfig := s.BeaconConfig()
 	weights := beaconConfig.ParticipationWeights()
-	eligibleValidators := state2.EligibleValidatorsIndicies(s.BeaconState)
-	// Initialize variables
-	totalActiveBalance := s.GetTotalActiveBalance()
 	previousEpoch := state2.PreviousEpoch(s.BeaconState)
-	// Inactivity penalties denominator.
-	inactivityPenaltyDenominator := beaconConfig.InactivityScoreBias * beaconConfig.GetPenaltyQuotient(s.Version())
 	// Make buffer for flag indexes total balances.
 	flagsTotalBalances := make([]uint64, len(weights))
 	// Compute all total balances for each enable unslashed validator indicies with all flags on.
@@ -26,61 +55,59 @@ func processRewardsAndPenaltiesPostAltair(s *state2.BeaconState) (err error) {
 		}
 		return true
 	})
-	// precomputed multiplier for reward.
-	rewardMultipliers := make([]uint64, len(weights))
+	multipliers = make([]uint64, len(weights))
 	for i := range weights {
-		rewardMultipliers[i] = weights[i] * (flagsTotalBalances[i] / beaconConfig.EffectiveBalanceIncrement)
+		multipliers[i] = weights[i] * (flagsTotalBalances[i] / beaconConfig.EffectiveBalanceIncrement)
 	}
-	rewardDenominator := (totalActiveBalance / beaconConfig.EffectiveBalanceIncrement) * beaconConfig.WeightDenominator
-	var baseReward uint64
-	// Now process deltas and whats nots.
-	for _, index := range eligibleValidators {
-		baseReward, err = s.BaseReward(index)
+	denominator = (s.GetTotalActiveBalance() / beaconConfig.EffectiveBalanceIncrement) * beaconConfig.WeightDenominator
+	return
+}
+
+func attestationRewardsPostAltair(s *state2.BeaconState) ([]AttestationReward, error) {
+	beaconConfig := s.BeaconConfig()
+	weights := beaconConfig.ParticipationWeights()
+	eligibleValidators := state2.EligibleValidatorsIndicies(s.BeaconState)
+	previousEpoch := state2.PreviousEpoch(s.BeaconState)
+	leaking := state2.InactivityLeaking(s.BeaconState)
+	// Inactivity penalties denominator.
+	inactivityPenaltyDenominator := beaconConfig.InactivityScoreBias * beaconConfig.GetPenaltyQuotient(s.Version())
+	rewardMultipliers, rewardDenominator := flagRewardMultipliers(s)
+
+	rewards := make([]AttestationReward, len(eligibleValidators))
+	for i, index := range eligibleValidators {
+		reward := &rewards[i]
+		reward.ValidatorIndex = index
+		baseReward, err := s.BaseReward(index)
 		if err != nil {
-			return
+			return nil, err
 		}
 		for flagIdx := range weights {
 			if state2.IsUnslashedParticipatingIndex(s.BeaconState, previousEpoch, index, flagIdx) {
-				if !state2.InactivityLeaking(s.BeaconState) {
-					rewardNumerator := baseReward * rewardMultipliers[flagIdx]
-					if err := state2.IncreaseBalance(s.BeaconState, index, rewardNumerator/rewardDenominator); err != nil {
-						return err
-					}
+				if !leaking {
+					*reward.flagReward(beaconConfig, flagIdx) = int64(baseReward * rewardMultipliers[flagIdx] / rewardDenominator)
 				}
 			} else if flagIdx != int(beaconConfig.TimelyHeadFlagIndex) {
-				if err := state2.DecreaseBalance(s.BeaconState, index, baseReward*weights[flagIdx]/beaconConfig.WeightDenominator); err != nil {
-					return err
-				}
+				*reward.flagReward(beaconConfig, flagIdx) = -int64(baseReward * weights[flagIdx] / beaconConfig.WeightDenominator)
 			}
 		}
 		if !state2.IsUnslashedParticipatingIndex(s.BeaconState, previousEpoch, index, int(beaconConfig.TimelyTargetFlagIndex)) {
 			inactivityScore, err := s.ValidatorInactivityScore(int(index))
 			if err != nil {
-				return err
+				return nil, err
 			}
-			// Process inactivity penalties.
 			effectiveBalance, err := s.ValidatorEffectiveBalance(int(index))
 			if err != nil {
-				return err
+				return nil, err
 			}
-			state2.DecreaseBalance(s.BeaconState, index, (effectiveBalance*inactivityScore)/inactivityPenaltyDenominator)
+			reward.Inactivity = -int64((effectiveBalance * inactivityScore) / inactivityPenaltyDenominator)
 		}
 	}
-	return
+	return rewards, nil
 }
 
-// processRewardsAndPenaltiesPhase0 process rewards and penalties for phase0 state.
-func processRewardsAndPenaltiesPhase0(s *state2.BeaconState) (err error) {
-	beaconConfig := s.BeaconConfig()
-	if state2.Epoch(s.BeaconState) == beaconConfig.GenesisEpoch {
-		return nil
-	}
-	eligibleValidators := state2.EligibleValidatorsIndicies(s.BeaconState)
-	// Initialize variables
-	rewardDenominator := s.GetTotalActiveBalance() / beaconConfig.EffectiveBalanceIncrement
-	// Make buffer for flag indexes totTargetal balances.
-	var unslashedMatchingSourceBalanceIncrements, unslashedMatchingTargetBalanceIncrements, unslashedMatchingHeadBalanceIncrements uint64
-	// Compute all total balances for each enable unslashed validator indicies with all flags on.
+// matchingBalanceIncrements returns the unslashed balance increments which attested the source, target and head of
+// the previous epoch.
+func matchingBalanceIncrements(s *state2.BeaconState) (source, target, head uint64, err error) {
 	s.ForEachValidator(func(validator solid.Validator, idx, total int) bool {
 		if validator.Slashed() {
 			return true
@@ -97,148 +124,169 @@ func processRewardsAndPenaltiesPhase0(s *state2.BeaconState) (err error) {
 			return false
 		}
 		if previousMatchingSourceAttester {
-			unslashedMatchingSourceBalanceIncrements += validator.EffectiveBalance()
+			source += validator.EffectiveBalance()
 		}
 		if previousMatchingTargetAttester {
-			unslashedMatchingTargetBalanceIncrements += validator.EffectiveBalance()
+			target += validator.EffectiveBalance()
 		}
 		if previousMatchingHeadAttester {
-			unslashedMatchingHeadBalanceIncrements += validator.EffectiveBalance()
+			head += validator.EffectiveBalance()
 		}
 		return true
 	})
-	// Then compute their total increment.
-	unslashedMatchingSourceBalanceIncrements /= beaconConfig.EffectiveBalanceIncrement
-	unslashedMatchingTargetBalanceIncrements /= beaconConfig.EffectiveBalanceIncrement
-	unslashedMatchingHeadBalanceIncrements /= beaconConfig.EffectiveBalanceIncrement
-	// Now process deltas and whats nots.
-	for _, index := range eligibleValidators {
+	increment := s.BeaconConfig().EffectiveBalanceIncrement
+	return source / increment, target / increment, head / increment, err
+}
+
+func attestationRewardsPhase0(s *state2.BeaconState) ([]AttestationReward, error) {
+	beaconConfig := s.BeaconConfig()
+	eligibleValidators := state2.EligibleValidatorsIndicies(s.BeaconState)
+	leaking := state2.InactivityLeaking(s.BeaconState)
+	// Initialize variables
+	rewardDenominator := s.GetTotalActiveBalance() / beaconConfig.EffectiveBalanceIncrement
+	unslashedMatchingSourceBalanceIncrements, unslashedMatchingTargetBalanceIncrements, unslashedMatchingHeadBalanceIncrements, err := matchingBalanceIncrements(s)
+	if err != nil {
+		return nil, err
+	}
+
+	rewards := make([]AttestationReward, len(eligibleValidators))
+	for i, index := range eligibleValidators {
+		reward := &rewards[i]
+		reward.ValidatorIndex = index
 		baseReward, err := s.BaseReward(index)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		currentValidator, err := s.ValidatorForValidatorIndex(int(index))
 		if err != nil {
-			return err
+			return nil, err
 		}
 		var previousMatchingSourceAttester, previousMatchingTargetAttester, previousMatchingHeadAttester bool
 
 		if previousMatchingSourceAttester, err = s.ValidatorIsPreviousMatchingSourceAttester(int(index)); err != nil {
-			return err
+			return nil, err
 		}
 		if previousMatchingTargetAttester, err = s.ValidatorIsPreviousMatchingTargetAttester(int(index)); err != nil {
-			return err
+			return nil, err
 		}
 		if previousMatchingHeadAttester, err = s.ValidatorIsPreviousMatchingHeadAttester(int(index)); err != nil {
-			return err
+			return nil, err
 		}
 
-		// we can use a multiplier to account for all attesting
-		var attested, missed uint64
-		if currentValidator.Slashed() {
-			attested, missed = 0, 3
-		} else {
-			if previousMatchingSourceAttester {
-				attested++
-			}
-			if previousMatchingTargetAttester {
-				attested++
-			}
-			if previousMatchingHeadAttester {
-				attested++
-			}
-			missed = 3 - attested
-		}
-
-		// If we attested then we reward the validator.
-		if state2.InactivityLeaking(s.BeaconState) {
-			if err := state2.IncreaseBalance(s.BeaconState, index, baseReward*attested); err != nil {
-				return err
-			}
-		} else {
-			if !currentValidator.Slashed() && previousMatchingSourceAttester {
-				rewardNumerator := baseReward * unslashedMatchingSourceBalanceIncrements
-				if err := state2.IncreaseBalance(s.BeaconState, index, rewardNumerator/rewardDenominator); err != nil {
-					return err
-				}
-			}
-			if !currentValidator.Slashed() && previousMatchingTargetAttester {
-				rewardNumerator := baseReward * unslashedMatchingTargetBalanceIncrements
-				if err := state2.IncreaseBalance(s.BeaconState, index, rewardNumerator/rewardDenominator); err != nil {
-					return err
-				}
-			}
-			if !currentValidator.Slashed() && previousMatchingHeadAttester {
-				rewardNumerator := baseReward * unslashedMatchingHeadBalanceIncrements
-				if err := state2.IncreaseBalance(s.BeaconState, index, rewardNumerator/rewardDenominator); err != nil {
-					return err
-				}
+		// Each attested duty is rewarded, each missed one is penalized, slashed validators miss all of them.
+		for _, component := range []struct {
+			attested   bool
+			increments uint64
+			reward     *int64
+		}{
+			{previousMatchingSourceAttester, unslashedMatchingSourceBalanceIncrements, &reward.Source},
+			{previousMatchingTargetAttester, unslashedMatchingTargetBalanceIncrements, &reward.Target},
+			{previousMatchingHeadAttester, unslashedMatchingHeadBalanceIncrements, &reward.Head},
+		} {
+			switch {
+			case currentValidator.Slashed() || !component.attested:
+				*component.reward = -int64(baseReward)
+			case leaking:
+				*component.reward = int64(baseReward)
+			default:
+				*component.reward = int64(baseReward * component.increments / rewardDenominator)
 			}
 		}
+		proposerReward := baseReward / beaconConfig.ProposerRewardQuotient
 		// Process inactivity of the network as a whole finalities.
-		if state2.InactivityLeaking(s.BeaconState) {
-			proposerReward := baseReward / beaconConfig.ProposerRewardQuotient
+		if leaking {
 			// Neutralize rewards.
-			if state2.DecreaseBalance(s.BeaconState, index, beaconConfig.BaseRewardsPerEpoch*baseReward-proposerReward); err != nil {
-				return err
-			}
+			reward.Inactivity = -int64(beaconConfig.BaseRewardsPerEpoch*baseReward - proposerReward)
 			if currentValidator.Slashed() || !previousMatchingTargetAttester {
 				// Increase penalities linearly if network is leaking.
-				if state2.DecreaseBalance(s.BeaconState, index, currentValidator.EffectiveBalance()*state2.FinalityDelay(s.BeaconState)/beaconConfig.InactivityPenaltyQuotient); err != nil {
-					return err
-				}
+				reward.Inactivity -= int64(currentValidator.EffectiveBalance() * state2.FinalityDelay(s.BeaconState) / beaconConfig.InactivityPenaltyQuotient)
 			}
 		}
-
-		// For each missed duty we penalize the validator.
-		if state2.DecreaseBalance(s.BeaconState, index, baseReward*missed); err != nil {
-			return err
+		// Lastly reward the speed of inclusion, sharing the reward with the including proposer.
+		if currentValidator.Slashed() || !previousMatchingSourceAttester {
+			continue
+		}
+		attestation, err := s.ValidatorMinPreviousInclusionDelayAttestation(int(index))
+		if err != nil {
+			return nil, err
 		}
+		reward.InclusionDelay = (baseReward - proposerReward) / attestation.InclusionDelay()
+		reward.inclusionProposerIndex = attestation.ProposerIndex()
+		reward.inclusionProposerReward = proposerReward
+	}
+	return rewards, nil
+}
 
+// AttestationRewards computes the rewards and penalties that ProcessRewardsAndPenalties applies for the attestations
+// of the previous epoch, one for each eligible validator.
+func AttestationRewards(s *state2.BeaconState) ([]AttestationReward, error) {
+	if state2.Epoch(s.BeaconState) == s.BeaconConfig().GenesisEpoch {
+		return nil, nil
 	}
-	// Lastly process late attestations
+	if s.Version() == clparams.Phase0Version {
+		return attestationRewardsPhase0(s)
+	}
+	return attestationRewardsPostAltair(s)
+}
 
-	s.ForEachValidator(func(validator solid.Validator, index, total int) bool {
-		var previousMatchingSourceAttester bool
-		var attestation *solid.PendingAttestation
-		if previousMatchingSourceAttester, err = s.ValidatorIsPreviousMatchingSourceAttester(index); err != nil {
-			return false
-		}
-		if validator.Slashed() || !previousMatchingSourceAttester {
-			return true
-		}
-		if attestation, err = s.ValidatorMinPreviousInclusionDelayAttestation(index); err != nil {
-			return false
-		}
-		var baseReward uint64
-		baseReward, err = s.BaseReward(uint64(index))
-		if err != nil {
-			return false
-		}
-		// Compute proposer reward.
-		proposerReward := (baseReward / beaconConfig.ProposerRewardQuotient)
-		if err = state2.IncreaseBalance(s.BeaconState, attestation.ProposerIndex(), proposerReward); err != nil {
-			return false
-		}
-		maxAttesterReward := baseReward - proposerReward
-		if err = state2.IncreaseBalance(s.BeaconState, uint64(index), maxAttesterReward/attestation.InclusionDelay()); err != nil {
-			return false
-		}
-		return true
-	})
-	if err != nil {
-		return err
+// applyDelta increases or decreases the balance of a validator.
+func applyDelta(s *state2.BeaconState, index uint64, delta int64) error {
+	if delta >= 0 {
+		return state2.IncreaseBalance(s.BeaconState, index, uint64(delta))
 	}
-	return
+	return state2.DecreaseBalance(s.BeaconState, index, uint64(-delta))
 }
 
 // ProcessRewardsAndPenalties applies rewards/penalties accumulated during previous epoch.
 func ProcessRewardsAndPenalties(s *state2.BeaconState) error {
-	if state2.Epoch(s.BeaconState) == s.BeaconConfig().GenesisEpoch {
+	rewards, err := AttestationRewards(s)
+	if err != nil {
+		return err
+	}
+	if s.Version() != clparams.Phase0Version {
+		// Post altair the flags are applied one after the other, as balances can not go below zero the order matters.
+		beaconConfig := s.BeaconConfig()
+		for i := range rewards {
+			reward := &rewards[i]
+			for flagIdx := range beaconConfig.ParticipationWeights() {
+				if err := applyDelta(s, reward.ValidatorIndex, *reward.flagReward(beaconConfig, flagIdx)); err != nil {
+					return err
+				}
+			}
+			if err := applyDelta(s, reward.ValidatorIndex, reward.Inactivity); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
-	if s.Version() == clparams.Phase0Version {
-		return processRewardsAndPenaltiesPhase0(s)
+	// In phase0 all the rewards are applied before the penalties.
+	for _, reward := range rewards {
+		var increase, decrease uint64
+		for _, delta := range []int64{reward.Source, reward.Target, reward.Head, reward.Inactivity} {
+			if delta >= 0 {
+				increase += uint64(delta)
+			} else {
+				decrease += uint64(-delta)
+			}
+		}
+		if err := state2.IncreaseBalance(s.BeaconState, reward.ValidatorIndex, increase); err != nil {
+			return err
+		}
+		if err := state2.DecreaseBalance(s.BeaconState, reward.ValidatorIndex, decrease); err != nil {
+			return err
+		}
+	}
+	// Then the late attestations.
+	for _, reward := range rewards {
+		if reward.inclusionProposerReward == 0 && reward.InclusionDelay == 0 {
+			continue
+		}
+		if err := state2.IncreaseBalance(s.BeaconState, reward.inclusionProposerIndex, reward.inclusionProposerReward); err != nil {
+			return err
+		}
+		if err := state2.IncreaseBalance(s.BeaconState, reward.ValidatorIndex, reward.InclusionDelay); err != nil {
+			return err
+		}
 	}
-	return processRewardsAndPenaltiesPostAltair(s)
+	return nil
 }
@@ -0,0 +1,185 @@
+package transition
+
+import (
+	"errors"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	state2 "github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// IdealAttestationReward is the reward of a validator of the given effective balance which attested perfectly.
+type IdealAttestationReward struct {
+	EffectiveBalance uint64
+	Source           int64
+	Target           int64
+	Head             int64
+	// InclusionDelay rewards the inclusion speed of the attestation, phase0 only.
+	InclusionDelay uint64
+	Inactivity     int64
+}
+
+// IdealAttestationRewards computes the rewards of perfect attestations of the previous epoch for each effective balance,
+// to compare with the ones returned by AttestationRewards.
+func IdealAttestationRewards(s *state2.BeaconState) ([]IdealAttestationReward, error) {
+	beaconConfig := s.BeaconConfig()
+	if state2.Epoch(s.BeaconState) == beaconConfig.GenesisEpoch {
+		return nil, nil
+	}
+	leaking := state2.InactivityLeaking(s.BeaconState)
+	totalActiveBalance := s.GetTotalActiveBalance()
+
+	var (
+		source, target, head uint64 // phase0 matching balance increments
+		rewardMultipliers    []uint64
+		rewardDenominator    uint64
+		err                  error
+	)
+	if s.Version() == clparams.Phase0Version {
+		if source, target, head, err = matchingBalanceIncrements(s); err != nil {
+			return nil, err
+		}
+		rewardDenominator = totalActiveBalance / beaconConfig.EffectiveBalanceIncrement
+	} else {
+		rewardMultipliers, rewardDenominator = flagRewardMultipliers(s)
+	}
+
+	rewards := make([]IdealAttestationReward, 0, beaconConfig.MaxEffectiveBalance/beaconConfig.EffectiveBalanceIncrement)
+	for effectiveBalance := beaconConfig.EffectiveBalanceIncrement; effectiveBalance <= beaconConfig.MaxEffectiveBalance; effectiveBalance += beaconConfig.EffectiveBalanceIncrement {
+		reward := IdealAttestationReward{EffectiveBalance: effectiveBalance}
+		if s.Version() == clparams.Phase0Version {
+			baseReward := effectiveBalance * beaconConfig.BaseRewardFactor / utils.IntegerSquareRoot(totalActiveBalance) / beaconConfig.BaseRewardsPerEpoch
+			for _, component := range []struct {
+				increments uint64
+				reward     *int64
+			}{{source, &reward.Source}, {target, &reward.Target}, {head, &reward.Head}} {
+				if leaking {
+					*component.reward = int64(baseReward)
+				} else {
+					*component.reward = int64(baseReward * component.increments / rewardDenominator)
+				}
+			}
+			reward.InclusionDelay = baseReward - baseReward/beaconConfig.ProposerRewardQuotient
+		} else if !leaking {
+			baseReward := effectiveBalance / beaconConfig.EffectiveBalanceIncrement * s.BaseRewardPerIncrement()
+			for flagIdx := range rewardMultipliers {
+				*flagComponent(beaconConfig, flagIdx, &reward.Source, &reward.Target, &reward.Head) = int64(baseReward * rewardMultipliers[flagIdx] / rewardDenominator)
+			}
+		}
+		rewards = append(rewards, reward)
+	}
+	return rewards, nil
+}
+
+// BlockReward is what the proposer of a block earns for the operations it includes.
+type BlockReward struct {
+	ProposerIndex     uint64
+	Attestations      uint64
+	SyncAggregate     uint64
+	ProposerSlashings uint64
+	AttesterSlashings uint64
+}
+
+// Total is the sum of all the rewards of the block.
+func (r *BlockReward) Total() uint64 {
+	return r.Attestations + r.SyncAggregate + r.ProposerSlashings + r.AttesterSlashings
+}
+
+// BlockRewards computes the rewards of the proposer of a block. s is the pre-state of the block advanced to its slot,
+// it is modified by the call. Phase0 attestations are rewarded at the end of the epoch and are not accounted for.
+func BlockRewards(s *state2.BeaconState, block *cltypes.BeaconBlock) (*BlockReward, error) {
+	rewards := &BlockReward{ProposerIndex: block.ProposerIndex}
+	// balanceIncrease measures the increase of the proposer balance while processing a part of the block.
+	balanceIncrease := func(process func() error) (uint64, error) {
+		before, err := s.ValidatorBalance(int(block.ProposerIndex))
+		if err != nil {
+			return 0, err
+		}
+		if err := process(); err != nil {
+			return 0, err
+		}
+		after, err := s.ValidatorBalance(int(block.ProposerIndex))
+		if err != nil {
+			return 0, err
+		}
+		if after < before {
+			return 0, nil
+		}
+		return after - before, nil
+	}
+
+	var err error
+	if rewards.ProposerSlashings, err = balanceIncrease(func() error {
+		for i := 0; i < block.Body.ProposerSlashings.Len(); i++ {
+			if err := ProcessProposerSlashing(s, block.Body.ProposerSlashings.Get(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if rewards.AttesterSlashings, err = balanceIncrease(func() error {
+		for i := 0; i < block.Body.AttesterSlashings.Len(); i++ {
+			if err := ProcessAttesterSlashing(s, block.Body.AttesterSlashings.Get(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if rewards.Attestations, err = balanceIncrease(func() error {
+		return ProcessAttestations(s, block.Body.Attestations, false)
+	}); err != nil {
+		return nil, err
+	}
+	if s.Version() >= clparams.AltairVersion && block.Body.SyncAggregate != nil {
+		proposerReward, _, err := s.SyncRewards()
+		if err != nil {
+			return nil, err
+		}
+		rewards.SyncAggregate = proposerReward * uint64(block.Body.SyncAggregate.Sum())
+	}
+	return rewards, nil
+}
+
+// SyncCommitteeReward is the reward (positive) or penalty (negative) of a sync committee member for a block.
+type SyncCommitteeReward struct {
+	ValidatorIndex uint64
+	Reward         int64
+}
+
+// SyncCommitteeRewards computes the rewards of the members of the current sync committee for the aggregate included
+// in a block, s being the pre-state of the block advanced to its slot. Members appearing more than once in the
+// committee get the sum of their rewards.
+func SyncCommitteeRewards(s *state2.BeaconState, aggregate *cltypes.SyncAggregate) ([]SyncCommitteeReward, error) {
+	currentSyncCommittee := s.CurrentSyncCommittee()
+	if currentSyncCommittee == nil {
+		return nil, errors.New("nil current sync committee in s")
+	}
+	_, participantReward, err := s.SyncRewards()
+	if err != nil {
+		return nil, err
+	}
+	positions := make(map[uint64]int)
+	var rewards []SyncCommitteeReward
+	for i, key := range currentSyncCommittee.GetCommittee() {
+		vIdx, exists := s.ValidatorIndexByPubkey(key)
+		if !exists {
+			return nil, errors.New("validator public key does not exist in state")
+		}
+		reward := -int64(participantReward)
+		if aggregate.SyncCommiteeBits[i/8]&(1<<(i%8)) > 0 {
+			reward = int64(participantReward)
+		}
+		if position, ok := positions[vIdx]; ok {
+			rewards[position].Reward += reward
+			continue
+		}
+		positions[vIdx] = len(rewards)
+		rewards = append(rewards, SyncCommitteeReward{ValidatorIndex: vIdx, Reward: reward})
+	}
+	return rewards, nil
+}
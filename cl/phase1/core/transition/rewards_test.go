@@ -0,0 +1,73 @@
+package transition
+
+import (
+	_ "embed"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+//go:embed test_data/epoch_processing/rewards_penalty_test_state.ssz_snappy
+var rewardsPenaltyState []byte
+
+func TestAttestationRewards(t *testing.T) {
+	s := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(s, rewardsPenaltyState, int(clparams.BellatrixVersion)))
+	rewards, err := AttestationRewards(s)
+	require.NoError(t, err)
+	require.NotEmpty(t, rewards)
+
+	processed, err := s.Copy()
+	require.NoError(t, err)
+	require.NoError(t, ProcessRewardsAndPenalties(processed))
+	for _, reward := range rewards {
+		before, err := s.ValidatorBalance(int(reward.ValidatorIndex))
+		require.NoError(t, err)
+		after, err := processed.ValidatorBalance(int(reward.ValidatorIndex))
+		require.NoError(t, err)
+		expected := int64(before) + reward.Source + reward.Target + reward.Head + reward.Inactivity
+		if expected < 0 {
+			expected = 0
+		}
+		require.Equal(t, uint64(expected), after, "validator %d", reward.ValidatorIndex)
+	}
+
+	ideal, err := IdealAttestationRewards(s)
+	require.NoError(t, err)
+	require.Len(t, ideal, int(clparams.MainnetBeaconConfig.MaxEffectiveBalance/clparams.MainnetBeaconConfig.EffectiveBalanceIncrement))
+	for i := 1; i < len(ideal); i++ {
+		require.GreaterOrEqual(t, ideal[i].Source+ideal[i].Target+ideal[i].Head, ideal[i-1].Source+ideal[i-1].Target+ideal[i-1].Head)
+	}
+}
+
+func TestBlockRewards(t *testing.T) {
+	s := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(s, capellaState, int(clparams.CapellaVersion)))
+	block := &cltypes.SignedBeaconBlock{}
+	require.NoError(t, utils.DecodeSSZSnappy(block, capellaBlock, int(clparams.CapellaVersion)))
+	require.NoError(t, ProcessSlots(s, block.Block.Slot))
+
+	syncRewards, err := SyncCommitteeRewards(s, block.Block.Body.SyncAggregate)
+	require.NoError(t, err)
+	require.NotEmpty(t, syncRewards)
+	_, participantReward, err := s.SyncRewards()
+	require.NoError(t, err)
+	var total int64
+	for _, reward := range syncRewards {
+		total += reward.Reward
+	}
+	participants := int64(block.Block.Body.SyncAggregate.Sum())
+	require.Equal(t, (2*participants-int64(s.BeaconConfig().SyncCommitteeSize))*int64(participantReward), total)
+
+	rewards, err := BlockRewards(s, block.Block)
+	require.NoError(t, err)
+	require.Equal(t, block.Block.ProposerIndex, rewards.ProposerIndex)
+	require.NotZero(t, rewards.Attestations)
+	require.NotZero(t, rewards.SyncAggregate)
+	require.Equal(t, rewards.Attestations+rewards.SyncAggregate+rewards.ProposerSlashings+rewards.AttesterSlashings, rewards.Total())
+}
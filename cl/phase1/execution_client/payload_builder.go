@@ -0,0 +1,182 @@
+package execution_client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	types2 "github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+)
+
+// PayloadAttributes are the attributes of the execution payload the execution layer is asked to build.
+type PayloadAttributes struct {
+	Timestamp             uint64
+	PrevRandao            libcommon.Hash
+	SuggestedFeeRecipient libcommon.Address
+	// Withdrawals are only sent from capella onwards.
+	Withdrawals []*types2.Withdrawal
+}
+
+// BlobsBundle are the blobs of the transactions of a payload, along with their KZG commitments and proofs.
+type BlobsBundle struct {
+	Commitments []cltypes.KZGCommitment
+	Proofs      []cltypes.KZGProof
+	Blobs       []cltypes.Blob
+}
+
+// PayloadBuilder is implemented by the execution engines able to build execution payloads for block proposals.
+type PayloadBuilder interface {
+	// StartPayload updates the fork choice of the execution layer and starts building a payload on top of head.
+	StartPayload(finalized libcommon.Hash, head libcommon.Hash, attributes *PayloadAttributes, version clparams.StateVersion) (uint64, error)
+	// GetPayload stops the building of the given payload and returns it, with its blobs from deneb onwards.
+	GetPayload(payloadId uint64, version clparams.StateVersion) (*cltypes.Eth1Block, *BlobsBundle, error)
+}
+
+func (e *ExecutionEnginePhase1) StartPayload(finalized libcommon.Hash, head libcommon.Hash, attributes *PayloadAttributes, version clparams.StateVersion) (uint64, error) {
+	grpcAttributes := &remote.EnginePayloadAttributes{
+		Version:               1,
+		Timestamp:             attributes.Timestamp,
+		PrevRandao:            gointerfaces.ConvertHashToH256(attributes.PrevRandao),
+		SuggestedFeeRecipient: gointerfaces.ConvertAddressToH160(attributes.SuggestedFeeRecipient),
+	}
+	if version >= clparams.CapellaVersion {
+		grpcAttributes.Version = 2
+		grpcAttributes.Withdrawals = privateapi.ConvertWithdrawalsToRpc(attributes.Withdrawals)
+	}
+	grpcMessage := &remote.EngineForkChoiceUpdatedRequest{
+		ForkchoiceState: &remote.EngineForkChoiceState{
+			HeadBlockHash:      gointerfaces.ConvertHashToH256(head),
+			SafeBlockHash:      gointerfaces.ConvertHashToH256(head),
+			FinalizedBlockHash: gointerfaces.ConvertHashToH256(finalized),
+		},
+		PayloadAttributes: grpcAttributes,
+	}
+	ctx, cancel := context.WithTimeout(e.ctx, 3*time.Second)
+	defer cancel()
+
+	var (
+		resp *remote.EngineForkChoiceUpdatedResponse
+		err  error
+	)
+	if e.executionClient != nil {
+		resp, err = e.executionClient.EngineForkChoiceUpdated(ctx, grpcMessage)
+	} else if e.executionServer != nil {
+		resp, err = e.executionServer.EngineForkChoiceUpdated(ctx, grpcMessage)
+	} else {
+		return 0, fmt.Errorf("no execution layer to build payloads")
+	}
+	if err != nil {
+		return 0, err
+	}
+	if resp.PayloadStatus != nil && resp.PayloadStatus.Status != remote.EngineStatus_VALID {
+		return 0, fmt.Errorf("cannot build payload on top of %x, head status is %s", head, resp.PayloadStatus.Status)
+	}
+	if resp.PayloadId == 0 {
+		return 0, fmt.Errorf("execution layer did not start building a payload on top of %x", head)
+	}
+	return resp.PayloadId, nil
+}
+
+func (e *ExecutionEnginePhase1) GetPayload(payloadId uint64, version clparams.StateVersion) (*cltypes.Eth1Block, *BlobsBundle, error) {
+	ctx, cancel := context.WithTimeout(e.ctx, 3*time.Second)
+	defer cancel()
+
+	var (
+		resp *remote.EngineGetPayloadResponse
+		err  error
+	)
+	request := &remote.EngineGetPayloadRequest{PayloadId: payloadId}
+	if e.executionClient != nil {
+		resp, err = e.executionClient.EngineGetPayload(ctx, request)
+	} else if e.executionServer != nil {
+		resp, err = e.executionServer.EngineGetPayload(ctx, request)
+	} else {
+		return nil, nil, fmt.Errorf("no execution layer to build payloads")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, err := convertPayloadFromGrpc(resp.ExecutionPayload, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version < clparams.DenebVersion {
+		return payload, nil, nil
+	}
+	bundle, err := convertBlobsBundleFromGrpc(resp.BlobsBundle)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, bundle, nil
+}
+
+func convertBlobsBundleFromGrpc(bundle *types.BlobsBundleV1) (*BlobsBundle, error) {
+	commitments, proofs, blobs := bundle.GetCommitments(), bundle.GetProofs(), bundle.GetBlobs()
+	if len(commitments) != len(proofs) || len(proofs) != len(blobs) {
+		return nil, fmt.Errorf("should have same number of commitments/proofs/blobs, got %v vs %v vs %v", len(commitments), len(proofs), len(blobs))
+	}
+	out := &BlobsBundle{
+		Commitments: make([]cltypes.KZGCommitment, len(commitments)),
+		Proofs:      make([]cltypes.KZGProof, len(proofs)),
+		Blobs:       make([]cltypes.Blob, len(blobs)),
+	}
+	for i := range commitments {
+		if len(commitments[i]) != len(out.Commitments[i]) || len(proofs[i]) != len(out.Proofs[i]) || len(blobs[i]) != len(out.Blobs[i]) {
+			return nil, fmt.Errorf("invalid size of blob %d", i)
+		}
+		copy(out.Commitments[i][:], commitments[i])
+		copy(out.Proofs[i][:], proofs[i])
+		copy(out.Blobs[i][:], blobs[i])
+	}
+	return out, nil
+}
+
+func convertPayloadFromGrpc(payload *types.ExecutionPayload, version clparams.StateVersion) (*cltypes.Eth1Block, error) {
+	if payload == nil {
+		return nil, fmt.Errorf("execution layer returned no payload")
+	}
+	// the base fee is little endian CL-side
+	baseFee := gointerfaces.ConvertH256ToUint256Int(payload.BaseFeePerGas).Bytes32()
+	for i, j := 0, len(baseFee)-1; i < j; i, j = i+1, j-1 {
+		baseFee[i], baseFee[j] = baseFee[j], baseFee[i]
+	}
+	extra := solid.NewExtraData()
+	extra.SetBytes(payload.ExtraData)
+
+	block := cltypes.NewEth1Block(version)
+	block.ParentHash = gointerfaces.ConvertH256ToHash(payload.ParentHash)
+	block.FeeRecipient = gointerfaces.ConvertH160toAddress(payload.Coinbase)
+	block.StateRoot = gointerfaces.ConvertH256ToHash(payload.StateRoot)
+	block.ReceiptsRoot = gointerfaces.ConvertH256ToHash(payload.ReceiptRoot)
+	block.LogsBloom = gointerfaces.ConvertH2048ToBloom(payload.LogsBloom)
+	block.PrevRandao = gointerfaces.ConvertH256ToHash(payload.PrevRandao)
+	block.BlockNumber = payload.BlockNumber
+	block.GasLimit = payload.GasLimit
+	block.GasUsed = payload.GasUsed
+	block.Time = payload.Timestamp
+	block.Extra = extra
+	block.BaseFeePerGas = baseFee
+	block.BlockHash = gointerfaces.ConvertH256ToHash(payload.BlockHash)
+	block.Transactions = solid.NewTransactionsSSZFromTransactions(payload.Transactions)
+	block.Withdrawals = solid.NewStaticListSSZFromList(privateapi.ConvertWithdrawalsFromRpc(payload.Withdrawals), 16, 44)
+	if payload.DataGasUsed != nil {
+		block.DataGasUsed = *payload.DataGasUsed
+	}
+	if payload.ExcessDataGas != nil {
+		block.ExcessDataGas = *payload.ExcessDataGas
+	}
+	// make sure what we got hashes to the block hash the execution layer gave us
+	if _, err := block.RlpHeader(); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
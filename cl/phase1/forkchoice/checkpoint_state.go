@@ -140,6 +140,14 @@ func (c *checkpointState) committeeCount(epoch, lenIndicies uint64) uint64 {
 	return committeCount
 }
 
+// committeesPerSlot returns the number of beacon committees of every slot of the given epoch.
+func (c *checkpointState) committeesPerSlot(epoch uint64) uint64 {
+	if shuffledIndicesCached, ok := c.shuffledSetsCache[epoch]; ok {
+		return c.committeeCount(epoch, shuffledIndicesCached.lenActive)
+	}
+	return c.committeeCount(epoch, uint64(len(c.getActiveIndicies(epoch))))
+}
+
 func (c *checkpointState) getDomain(domainType [4]byte, epoch uint64) ([]byte, error) {
 	if epoch < c.fork.Epoch {
 		return fork.ComputeDomain(domainType[:], c.fork.PreviousVersion, c.genesisValidatorsRoot)
@@ -183,3 +191,28 @@ func (c *checkpointState) isValidIndexedAttestation(att *cltypes.IndexedAttestat
 func (c *checkpointState) epochAtSlot(slot uint64) uint64 {
 	return slot / c.beaconConfig.SlotsPerEpoch
 }
+
+// isValidBlobSidecarSignature verifies the signature of a blob sidecar by its proposer.
+func (c *checkpointState) isValidBlobSidecarSignature(signed *cltypes.SignedBlobSidecar) error {
+	sidecar := signed.Message
+	if sidecar.ProposerIndex >= uint64(len(c.validators)) {
+		return fmt.Errorf("unknown proposer index %d", sidecar.ProposerIndex)
+	}
+	domain, err := c.getDomain(c.beaconConfig.DomainBlobSideCar, c.epochAtSlot(sidecar.Slot))
+	if err != nil {
+		return fmt.Errorf("unable to get the domain: %v", err)
+	}
+	signingRoot, err := fork.ComputeSigningRoot(sidecar, domain)
+	if err != nil {
+		return fmt.Errorf("unable to get signing root: %v", err)
+	}
+	publicKey := c.validators[sidecar.ProposerIndex].publicKey
+	valid, err := bls.Verify(signed.Signature[:], signingRoot[:], publicKey[:])
+	if err != nil {
+		return fmt.Errorf("error while validating signature: %v", err)
+	}
+	if !valid {
+		return fmt.Errorf("invalid blob sidecar signature")
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package forkchoice
+
+import (
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	state2 "github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// stateRootOf returns the post-state root of a block held in the fork graph.
+func (f *ForkChoiceStore) stateRootOf(blockRoot libcommon.Hash) libcommon.Hash {
+	header, has := f.forkGraph.GetHeader(blockRoot)
+	if !has {
+		return libcommon.Hash{}
+	}
+	return header.Root
+}
+
+// dutyDependentRoot is the root of the last block of the epoch preceding the given one, as seen from head.
+func (f *ForkChoiceStore) dutyDependentRoot(head libcommon.Hash, epoch uint64) libcommon.Hash {
+	startSlot := f.computeStartSlotAtEpoch(epoch)
+	if startSlot == 0 {
+		return f.Ancestor(head, 0)
+	}
+	return f.Ancestor(head, startSlot-1)
+}
+
+// notifyHead emits head and chain_reorg events when the computed head differs from the last one notified.
+func (f *ForkChoiceStore) notifyHead(head libcommon.Hash, slot uint64) {
+	if head == f.headRoot {
+		return
+	}
+	oldHead, oldSlot := f.headRoot, f.headSlot
+	f.headRoot, f.headSlot = head, slot
+	if f.lightClient != nil {
+		f.onLightClientHead(head)
+	}
+	if f.emitter == nil {
+		return
+	}
+	epoch := f.computeEpochAtSlot(slot)
+	var previousEpoch uint64
+	if epoch > 0 {
+		previousEpoch = epoch - 1
+	}
+	newHeadState := f.stateRootOf(head)
+	f.emitter.Publish(beaconevents.TopicHead, &beaconevents.HeadData{
+		Slot:                      slot,
+		Block:                     head,
+		State:                     newHeadState,
+		EpochTransition:           epoch > f.computeEpochAtSlot(oldSlot),
+		PreviousDutyDependentRoot: f.dutyDependentRoot(head, previousEpoch),
+		CurrentDutyDependentRoot:  f.dutyDependentRoot(head, epoch),
+	})
+	// No reorg if the new head builds on top of the old one.
+	if f.Ancestor(head, oldSlot) == oldHead {
+		return
+	}
+	// Walk the old chain back to the common ancestor to compute the depth.
+	commonSlot := oldSlot
+	for root := oldHead; ; {
+		header, has := f.forkGraph.GetHeader(root)
+		if !has {
+			break
+		}
+		commonSlot = header.Slot
+		if f.Ancestor(head, header.Slot) == root {
+			break
+		}
+		root = header.ParentRoot
+	}
+	f.emitter.Publish(beaconevents.TopicChainReorg, &beaconevents.ChainReorgData{
+		Slot:         slot,
+		Depth:        oldSlot - commonSlot,
+		OldHeadBlock: oldHead,
+		NewHeadBlock: head,
+		OldHeadState: f.stateRootOf(oldHead),
+		NewHeadState: newHeadState,
+		Epoch:        epoch,
+	})
+}
+
+func (f *ForkChoiceStore) notifyFinalizedCheckpoint(checkpoint solid.Checkpoint) {
+	if f.lightClient != nil {
+		if err := f.lightClient.OnFinalized(checkpoint.BlockRoot()); err != nil {
+			log.Warn("[Light Client] Could not store bootstrap", "epoch", checkpoint.Epoch(), "err", err)
+		}
+	}
+	if f.emitter == nil {
+		return
+	}
+	f.emitter.Publish(beaconevents.TopicFinalizedCheckpoint, &beaconevents.FinalizedCheckpointData{
+		Block: checkpoint.BlockRoot(),
+		State: f.stateRootOf(checkpoint.BlockRoot()),
+		Epoch: checkpoint.Epoch(),
+	})
+}
+
+// onLightClientBlock feeds the light client server with an imported block. Failing to do so must not prevent the
+// block from being imported.
+func (f *ForkChoiceStore) onLightClientBlock(block *cltypes.SignedBeaconBlock, blockRoot libcommon.Hash, postState *state2.BeaconState) {
+	if err := f.lightClient.OnBlock(block, blockRoot, postState); err != nil {
+		log.Warn("[Light Client] Could not process block", "slot", block.Block.Slot, "err", err)
+	}
+}
+
+// onLightClientHead publishes the light client updates signed by the new head, only canonical blocks are
+// served to light clients.
+func (f *ForkChoiceStore) onLightClientHead(head libcommon.Hash) {
+	finalityUpdate, optimisticUpdate := f.lightClient.OnHead(head)
+	if f.emitter == nil {
+		return
+	}
+	if finalityUpdate != nil {
+		f.emitter.Publish(beaconevents.TopicLightClientFinalityUpdate, finalityUpdate)
+	}
+	if optimisticUpdate != nil {
+		f.emitter.Publish(beaconevents.TopicLightClientOptimisticUpdate, optimisticUpdate)
+	}
+}
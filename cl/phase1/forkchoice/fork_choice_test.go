@@ -4,9 +4,11 @@ import (
 	_ "embed"
 	"testing"
 
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
 	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice/fuzz"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
@@ -46,7 +48,7 @@ func TestForkChoiceBasic(t *testing.T) {
 	// Initialize forkchoice store
 	anchorState := state.New(&clparams.MainnetBeaconConfig)
 	require.NoError(t, utils.DecodeSSZSnappy(anchorState, anchorStateEncoded, int(clparams.AltairVersion)))
-	store, err := forkchoice.NewForkChoiceStore(anchorState, nil, nil, false)
+	store, err := forkchoice.NewForkChoiceStore(anchorState, nil, nil, nil, nil, false)
 	require.NoError(t, err)
 	// first steps
 	store.OnTick(0)
@@ -86,3 +88,77 @@ func TestForkChoiceBasic(t *testing.T) {
 	// lastly do attestation
 	require.NoError(t, store.OnAttestation(testAttestation, false))
 }
+
+func TestForkChoiceFuzz(t *testing.T) {
+	block0x3a, block0xc2, block0xd4 := &cltypes.SignedBeaconBlock{}, &cltypes.SignedBeaconBlock{}, &cltypes.SignedBeaconBlock{}
+	require.NoError(t, utils.DecodeSSZSnappy(block0x3a, block3aEncoded, int(clparams.AltairVersion)))
+	require.NoError(t, utils.DecodeSSZSnappy(block0xc2, blockc2Encoded, int(clparams.AltairVersion)))
+	require.NoError(t, utils.DecodeSSZSnappy(block0xd4, blockd4Encoded, int(clparams.AltairVersion)))
+	testAttestation := &solid.Attestation{}
+	require.NoError(t, utils.DecodeSSZSnappy(testAttestation, attestationEncoded, int(clparams.AltairVersion)))
+	anchorState := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(anchorState, anchorStateEncoded, int(clparams.AltairVersion)))
+
+	scenario := &fuzz.Scenario{
+		AnchorState: anchorState,
+		Events: []fuzz.Event{
+			{Kind: fuzz.EventTick, Time: 12},
+			{Kind: fuzz.EventBlock, Block: block0x3a},
+			{Kind: fuzz.EventTick, Time: 36},
+			{Kind: fuzz.EventBlock, Block: block0xc2},
+			{Kind: fuzz.EventBlock, Block: block0xd4},
+			{Kind: fuzz.EventAttestation, Attestation: testAttestation},
+		},
+		FullValidation: true,
+	}
+	failure, err := fuzz.NewHarness(scenario, 3).Fuzz(0, 16)
+	require.NoError(t, err)
+	require.Nil(t, failure, "%s", failure)
+}
+
+func TestForkChoiceVoluntaryExit(t *testing.T) {
+	anchorState := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(anchorState, anchorStateEncoded, int(clparams.AltairVersion)))
+	store, err := forkchoice.NewForkChoiceStore(anchorState, nil, nil, nil, nil, false)
+	require.NoError(t, err)
+	// validators have not been active long enough to exit at genesis
+	require.Error(t, store.OnVoluntaryExit(&cltypes.SignedVoluntaryExit{
+		VolunaryExit: &cltypes.VoluntaryExit{Epoch: 0, ValidatorIndex: 0},
+	}))
+	// unknown validators cannot exit
+	require.Error(t, store.OnVoluntaryExit(&cltypes.SignedVoluntaryExit{
+		VolunaryExit: &cltypes.VoluntaryExit{Epoch: 0, ValidatorIndex: uint64(anchorState.ValidatorLength())},
+	}))
+}
+
+func TestForkChoiceUpdateHead(t *testing.T) {
+	block0x3a := &cltypes.SignedBeaconBlock{}
+	require.NoError(t, utils.DecodeSSZSnappy(block0x3a, block3aEncoded, int(clparams.AltairVersion)))
+	anchorState := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(anchorState, anchorStateEncoded, int(clparams.AltairVersion)))
+	emitter := beaconevents.NewEmitter()
+	sub, unsubscribe := emitter.Subscribe([]string{beaconevents.TopicHead}, 4)
+	defer unsubscribe()
+	store, err := forkchoice.NewForkChoiceStore(anchorState, nil, nil, emitter, nil, false)
+	require.NoError(t, err)
+	store.OnTick(12)
+	require.NoError(t, store.OnBlock(block0x3a, false, true))
+
+	// reading the head does not notify it
+	_, _, err = store.GetHead()
+	require.NoError(t, err)
+	require.Len(t, sub.Events(), 0)
+
+	headRoot, headSlot, err := store.UpdateHead()
+	require.NoError(t, err)
+	require.Len(t, sub.Events(), 1)
+	event := <-sub.Events()
+	head := event.Data.(*beaconevents.HeadData)
+	require.Equal(t, headSlot, head.Slot)
+	require.Equal(t, headRoot, head.Block)
+
+	// an unchanged head is notified once
+	_, _, err = store.UpdateHead()
+	require.NoError(t, err)
+	require.Len(t, sub.Events(), 0)
+}
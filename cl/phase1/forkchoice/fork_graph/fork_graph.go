@@ -204,11 +204,21 @@ func (f *ForkGraph) GetHeader(blockRoot libcommon.Hash) (*cltypes.BeaconBlockHea
 	return obj, has
 }
 
-func (f *ForkGraph) getBlock(blockRoot libcommon.Hash) (*cltypes.SignedBeaconBlock, bool) {
+func (f *ForkGraph) GetBlock(blockRoot libcommon.Hash) (*cltypes.SignedBeaconBlock, bool) {
 	obj, has := f.blocks[blockRoot]
 	return obj, has
 }
 
+// GetBlockRootByStateRoot looks up the block root whose post-state has the given state root.
+func (f *ForkGraph) GetBlockRootByStateRoot(stateRoot libcommon.Hash) (libcommon.Hash, bool) {
+	for blockRoot, header := range f.headers {
+		if header.Root == stateRoot {
+			return blockRoot, true
+		}
+	}
+	return libcommon.Hash{}, false
+}
+
 func (f *ForkGraph) GetState(blockRoot libcommon.Hash, alwaysCopy bool) (*state.BeaconState, bool, error) {
 	// collect all blocks beetwen greatest extending node path and block.
 	blocksInTheWay := []*cltypes.SignedBeaconBlock{}
@@ -225,7 +235,7 @@ func (f *ForkGraph) GetState(blockRoot libcommon.Hash, alwaysCopy bool) (*state.
 	}
 	// try and find the point of recconection
 	for currentIteratorRoot != reconnectionRootLong && currentIteratorRoot != reconnectionRootShort {
-		block, isSegmentPresent := f.getBlock(currentIteratorRoot)
+		block, isSegmentPresent := f.GetBlock(currentIteratorRoot)
 		if !isSegmentPresent {
 			log.Debug("Could not retrieve state: Missing header", "missing", currentIteratorRoot,
 				"longRecconection", libcommon.Hash(reconnectionRootLong), "shortRecconection", libcommon.Hash(reconnectionRootShort))
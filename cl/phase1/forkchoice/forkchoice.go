@@ -3,11 +3,14 @@ package forkchoice
 import (
 	"sync"
 
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/freezer"
 	state2 "github.com/ledgerwatch/erigon/cl/phase1/core/state"
 	"github.com/ledgerwatch/erigon/cl/phase1/execution_client"
 	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice/fork_graph"
+	"github.com/ledgerwatch/erigon/cl/phase1/light_client"
 
 	lru "github.com/hashicorp/golang-lru/v2"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
@@ -30,7 +33,9 @@ type ForkChoiceStore struct {
 	proposerBoostRoot             libcommon.Hash
 	// Use go map because this is actually an unordered set
 	equivocatingIndicies map[uint64]struct{}
-	forkGraph            *fork_graph.ForkGraph
+	// validators whose voluntary exit was already accepted from gossip
+	seenVoluntaryExits map[uint64]struct{}
+	forkGraph          *fork_graph.ForkGraph
 	// I use the cache due to the convenient auto-cleanup feauture.
 	checkpointStates *lru.Cache[checkpointComparable, *checkpointState] // We keep ssz snappy of it as the full beacon state is full of rendundant data.
 	latestMessages   map[uint64]*LatestMessage
@@ -41,6 +46,13 @@ type ForkChoiceStore struct {
 	engine execution_client.ExecutionEngine
 	// freezer
 	recorder freezer.Freezer
+	// events
+	emitter *beaconevents.Emitter
+	// light client data, nil when it is not served
+	lightClient *light_client.Store
+	// last head we notified about, used to detect head changes and reorgs.
+	headRoot libcommon.Hash
+	headSlot uint64
 }
 
 type LatestMessage struct {
@@ -49,11 +61,16 @@ type LatestMessage struct {
 }
 
 // NewForkChoiceStore initialize a new store from the given anchor state, either genesis or checkpoint sync state.
-func NewForkChoiceStore(anchorState *state2.BeaconState, engine execution_client.ExecutionEngine, recorder freezer.Freezer, enabledPruning bool) (*ForkChoiceStore, error) {
+func NewForkChoiceStore(anchorState *state2.BeaconState, engine execution_client.ExecutionEngine, recorder freezer.Freezer, emitter *beaconevents.Emitter, lightClient *light_client.Store, enabledPruning bool) (*ForkChoiceStore, error) {
 	anchorRoot, err := anchorState.BlockRoot()
 	if err != nil {
 		return nil, err
 	}
+	if lightClient != nil {
+		if err := lightClient.OnAnchor(anchorState); err != nil {
+			return nil, err
+		}
+	}
 	anchorCheckpoint := solid.NewCheckpointFromParameters(
 		anchorRoot,
 		state2.Epoch(anchorState.BeaconState),
@@ -75,14 +92,26 @@ func NewForkChoiceStore(anchorState *state2.BeaconState, engine execution_client
 		unrealizedFinalizedCheckpoint: anchorCheckpoint.Copy(),
 		forkGraph:                     fork_graph.New(anchorState, enabledPruning),
 		equivocatingIndicies:          map[uint64]struct{}{},
+		seenVoluntaryExits:            map[uint64]struct{}{},
 		latestMessages:                map[uint64]*LatestMessage{},
 		checkpointStates:              checkpointStates,
 		eth2Roots:                     eth2Roots,
 		engine:                        engine,
 		recorder:                      recorder,
+		emitter:                       emitter,
+		lightClient:                   lightClient,
+		headRoot:                      anchorRoot,
+		headSlot:                      anchorState.Slot(),
 	}, nil
 }
 
+// CurrentSlot returns the slot of the current time.
+func (f *ForkChoiceStore) CurrentSlot() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Slot()
+}
+
 // Highest seen returns highest seen slot
 func (f *ForkChoiceStore) HighestSeen() uint64 {
 	f.mu.Lock()
@@ -146,3 +175,80 @@ func (f *ForkChoiceStore) AnchorSlot() uint64 {
 	defer f.mu.Unlock()
 	return f.forkGraph.AnchorSlot()
 }
+
+// GetHeader returns the header of a block still held in the fork graph.
+func (f *ForkChoiceStore) GetHeader(blockRoot libcommon.Hash) (*cltypes.BeaconBlockHeader, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.forkGraph.GetHeader(blockRoot)
+}
+
+// GetBlock returns a block still held in the fork graph.
+func (f *ForkChoiceStore) GetBlock(blockRoot libcommon.Hash) (*cltypes.SignedBeaconBlock, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.forkGraph.GetBlock(blockRoot)
+}
+
+// GetBlockRootByStateRoot returns the block root matching the given post-state root.
+func (f *ForkChoiceStore) GetBlockRootByStateRoot(stateRoot libcommon.Hash) (libcommon.Hash, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.forkGraph.GetBlockRootByStateRoot(stateRoot)
+}
+
+// GetFullState returns a copy of the post-state of the given block root, nil if it cannot be reconstructed.
+func (f *ForkChoiceStore) GetFullState(blockRoot libcommon.Hash) (*state2.BeaconState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, _, err := f.forkGraph.GetState(blockRoot, true)
+	return s, err
+}
+
+// GetCanonicalBlockRoot returns the root of the canonical block at the given slot. false is returned if the slot
+// is empty or no longer part of the fork graph.
+func (f *ForkChoiceStore) GetCanonicalBlockRoot(slot uint64) (libcommon.Hash, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	headRoot, headSlot, err := f.getHead()
+	if err != nil {
+		return libcommon.Hash{}, false, err
+	}
+	if slot > headSlot {
+		return libcommon.Hash{}, false, nil
+	}
+	root := f.Ancestor(headRoot, slot)
+	header, has := f.forkGraph.GetHeader(root)
+	if !has || header.Slot != slot {
+		return libcommon.Hash{}, false, nil
+	}
+	return root, true, nil
+}
+
+// GetCanonicalBlockRootAtOrBefore returns the root of the latest canonical block at or before the given slot,
+// walking back from the head only once. false is returned if that block is no longer part of the fork graph.
+func (f *ForkChoiceStore) GetCanonicalBlockRootAtOrBefore(slot uint64) (libcommon.Hash, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	headRoot, _, err := f.getHead()
+	if err != nil {
+		return libcommon.Hash{}, false, err
+	}
+	root := f.Ancestor(headRoot, slot)
+	return root, root != (libcommon.Hash{}), nil
+}
+
+// IsCanonical returns whether the given block root is part of the chain leading to the current head.
+func (f *ForkChoiceStore) IsCanonical(blockRoot libcommon.Hash) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	header, has := f.forkGraph.GetHeader(blockRoot)
+	if !has {
+		return false, nil
+	}
+	headRoot, _, err := f.getHead()
+	if err != nil {
+		return false, err
+	}
+	return f.Ancestor(headRoot, header.Slot) == blockRoot, nil
+}
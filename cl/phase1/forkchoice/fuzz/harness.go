@@ -0,0 +1,300 @@
+// Package fuzz replays the events of a fork choice scenario in adversarial orders derived from a seed, checking
+// the invariants of the store after every event and against an in order replay of the same events.
+package fuzz
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+)
+
+type EventKind int
+
+const (
+	EventTick EventKind = iota
+	EventBlock
+	EventAttestation
+	EventAttesterSlashing
+)
+
+// Event is something the fork choice store is fed with. Ticks are the wall clock and are never reordered.
+type Event struct {
+	Kind             EventKind
+	Time             uint64
+	Block            *cltypes.SignedBeaconBlock
+	Attestation      *solid.Attestation
+	FromBlock        bool // the attestation was included in a block rather than gossiped
+	AttesterSlashing *cltypes.AttesterSlashing
+}
+
+func (e Event) String() string {
+	switch e.Kind {
+	case EventTick:
+		return fmt.Sprintf("tick %d", e.Time)
+	case EventBlock:
+		root, _ := e.Block.Block.HashSSZ()
+		return fmt.Sprintf("block slot=%d root=%x", e.Block.Block.Slot, root)
+	case EventAttestation:
+		data := e.Attestation.AttestantionData()
+		root := data.BeaconBlockRoot()
+		return fmt.Sprintf("attestation slot=%d index=%d root=%x fromBlock=%t", data.Slot(), data.ValidatorIndex(), root, e.FromBlock)
+	case EventAttesterSlashing:
+		return "attester slashing"
+	default:
+		return "unknown"
+	}
+}
+
+// Scenario is the anchor state of a fork choice store and the events it is fed with, in order.
+type Scenario struct {
+	AnchorState    *state.BeaconState
+	Events         []Event
+	FullValidation bool
+}
+
+// Violation is an invariant broken by the store after the event at Step of a sequence.
+type Violation struct {
+	Step   int
+	Reason string
+	// differential violations are found comparing the replay to the in order one.
+	differential bool
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("step %d: %s", v.Step, v.Reason)
+}
+
+// result is the outcome of a replay.
+type result struct {
+	accepted  map[libcommon.Hash]struct{}
+	violation *Violation
+}
+
+// replay feeds a new store with the events of the sequence, given as indices in the scenario events. Blocks whose
+// parent is not known yet are retried after every accepted block, as a node would once it has fetched the parent.
+func (s *Scenario) replay(sequence []int) (*result, error) {
+	anchorState, err := s.AnchorState.Copy()
+	if err != nil {
+		return nil, err
+	}
+	store, err := forkchoice.NewForkChoiceStore(anchorState, nil, nil, nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	r := &result{accepted: map[libcommon.Hash]struct{}{}}
+	prev := checkpoints{justified: store.JustifiedCheckpoint().Epoch(), finalized: store.FinalizedCheckpoint().Epoch()}
+	var pending []*cltypes.SignedBeaconBlock
+	for step, idx := range sequence {
+		event := s.Events[idx]
+		switch event.Kind {
+		case EventTick:
+			store.OnTick(event.Time)
+		case EventBlock:
+			if !s.onBlock(store, event.Block, r) {
+				pending = append(pending, event.Block)
+				break
+			}
+			for retried := true; retried; {
+				retried = false
+				for i, block := range pending {
+					if s.onBlock(store, block, r) {
+						pending = append(pending[:i], pending[i+1:]...)
+						retried = true
+						break
+					}
+				}
+			}
+		case EventAttestation:
+			// late or unknown attestations are expected to be rejected
+			_ = store.OnAttestation(event.Attestation, event.FromBlock)
+		case EventAttesterSlashing:
+			_ = store.OnAttesterSlashing(event.AttesterSlashing)
+		}
+		if reason := prev.check(store); reason != "" {
+			r.violation = &Violation{Step: step, Reason: reason}
+			return r, nil
+		}
+	}
+	return r, nil
+}
+
+func (s *Scenario) onBlock(store *forkchoice.ForkChoiceStore, block *cltypes.SignedBeaconBlock, r *result) bool {
+	if err := store.OnBlock(block, false, s.FullValidation); err != nil {
+		return false
+	}
+	root, err := block.Block.HashSSZ()
+	if err != nil {
+		return false
+	}
+	r.accepted[root] = struct{}{}
+	return true
+}
+
+// checkpoints are the epochs of the checkpoints seen after the previous event.
+type checkpoints struct {
+	justified, finalized uint64
+}
+
+// check returns why the store breaks the invariants, if it does, and records its checkpoints.
+func (c *checkpoints) check(store *forkchoice.ForkChoiceStore) string {
+	justified, finalized := store.JustifiedCheckpoint(), store.FinalizedCheckpoint()
+	if justified.Epoch() < c.justified {
+		return fmt.Sprintf("justified checkpoint regressed from epoch %d to %d", c.justified, justified.Epoch())
+	}
+	if finalized.Epoch() < c.finalized {
+		return fmt.Sprintf("finalized checkpoint regressed from epoch %d to %d", c.finalized, finalized.Epoch())
+	}
+	if finalized.Epoch() > justified.Epoch() {
+		return fmt.Sprintf("finalized epoch %d is ahead of justified epoch %d", finalized.Epoch(), justified.Epoch())
+	}
+	c.justified, c.finalized = justified.Epoch(), finalized.Epoch()
+
+	headRoot, _, err := store.GetHead()
+	if err != nil {
+		return fmt.Sprintf("head is unavailable: %v", err)
+	}
+	if ancestor := store.Ancestor(headRoot, store.FinalizedSlot()); ancestor != finalized.BlockRoot() {
+		return fmt.Sprintf("head %x does not descend from finalized checkpoint %x", headRoot, finalized.BlockRoot())
+	}
+	return ""
+}
+
+// Failure is a minimal sequence of events, reordered from a seed, breaking an invariant.
+type Failure struct {
+	Seed      int64
+	Events    []Event
+	Violation *Violation
+}
+
+func (f *Failure) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "seed %d: %s\n", f.Seed, f.Violation)
+	for i, event := range f.Events {
+		fmt.Fprintf(&b, "%4d: %s\n", i, event)
+	}
+	return b.String()
+}
+
+// Harness explores the orderings of a scenario with deliveries delayed by up to maxDelay events.
+type Harness struct {
+	scenario *Scenario
+	maxDelay int
+	// in order replays of the sets of events checked, by sorted sequence.
+	baselines map[string]*result
+}
+
+func NewHarness(scenario *Scenario, maxDelay int) *Harness {
+	return &Harness{scenario: scenario, maxDelay: maxDelay, baselines: map[string]*result{}}
+}
+
+// NewSpectestHarness explores the orderings of the events of a fork choice consensus spec test case.
+func NewSpectestHarness(root fs.FS, version clparams.StateVersion, maxDelay int) (*Harness, error) {
+	scenario, err := LoadSpectest(root, version)
+	if err != nil {
+		return nil, err
+	}
+	return NewHarness(scenario, maxDelay), nil
+}
+
+// Check replays the sequence and returns the first invariant it breaks. The blocks accepted in order have to be
+// accepted whatever the order they are delivered in.
+func (h *Harness) Check(sequence []int) (*Violation, error) {
+	return h.check(sequence, true)
+}
+
+// check replays the sequence, comparing it to the in order replay of the same events if differential is set.
+func (h *Harness) check(sequence []int, differential bool) (*Violation, error) {
+	r, err := h.scenario.replay(sequence)
+	if err != nil {
+		return nil, err
+	}
+	if r.violation != nil || !differential || sort.IntsAreSorted(sequence) {
+		return r.violation, nil
+	}
+	baseline, err := h.baseline(sequence)
+	if err != nil {
+		return nil, err
+	}
+	if baseline.violation != nil {
+		return &Violation{Step: baseline.violation.Step, Reason: "in order: " + baseline.violation.Reason, differential: true}, nil
+	}
+	for root := range baseline.accepted {
+		if _, ok := r.accepted[root]; !ok {
+			return &Violation{Step: len(sequence), Reason: fmt.Sprintf("block %x is accepted in order but not reordered", root), differential: true}, nil
+		}
+	}
+	return nil, nil
+}
+
+// baseline returns the in order replay of the events of the sequence, which is computed once per set of events: the
+// orderings of a seed share it.
+func (h *Harness) baseline(sequence []int) (*result, error) {
+	inOrder := append([]int{}, sequence...)
+	sort.Ints(inOrder)
+	var key strings.Builder
+	for _, idx := range inOrder {
+		key.WriteString(strconv.Itoa(idx))
+		key.WriteByte(',')
+	}
+	if baseline, ok := h.baselines[key.String()]; ok {
+		return baseline, nil
+	}
+	baseline, err := h.scenario.replay(inOrder)
+	if err != nil {
+		return nil, err
+	}
+	h.baselines[key.String()] = baseline
+	return baseline, nil
+}
+
+// Run replays the ordering derived from the seed, returning the minimized reproducing sequence if it breaks an
+// invariant.
+func (h *Harness) Run(seed int64) (*Failure, error) {
+	sequence := Permute(h.scenario.Events, seed, h.maxDelay)
+	violation, err := h.Check(sequence)
+	if err != nil || violation == nil {
+		return nil, err
+	}
+	// an invariant broken by the replay alone is minimized without the in order replays.
+	differential := violation.differential
+	var checkErr error
+	sequence = Minimize(sequence, func(candidate []int) bool {
+		v, err := h.check(candidate, differential)
+		if err != nil {
+			checkErr = err
+			return false
+		}
+		if v != nil {
+			violation = v
+		}
+		return v != nil
+	})
+	if checkErr != nil {
+		return nil, checkErr
+	}
+	failure := &Failure{Seed: seed, Violation: violation}
+	for _, idx := range sequence {
+		failure.Events = append(failure.Events, h.scenario.Events[idx])
+	}
+	return failure, nil
+}
+
+// Fuzz runs the seeds from fromSeed on, stopping at the first failure.
+func (h *Harness) Fuzz(fromSeed int64, seeds int) (*Failure, error) {
+	for seed := fromSeed; seed < fromSeed+int64(seeds); seed++ {
+		if failure, err := h.Run(seed); failure != nil || err != nil {
+			return failure, err
+		}
+	}
+	return nil, nil
+}
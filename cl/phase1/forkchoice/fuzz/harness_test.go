@@ -0,0 +1,34 @@
+package fuzz
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+)
+
+func TestSpectestHarness(t *testing.T) {
+	root := os.DirFS("../test_data")
+	harness, err := NewSpectestHarness(root, clparams.AltairVersion, 3)
+	require.NoError(t, err)
+	// the checks of the case are left out
+	require.Len(t, harness.scenario.Events, 6)
+	require.Equal(t, EventAttestation, harness.scenario.Events[5].Kind)
+
+	failure, err := harness.Fuzz(0, 16)
+	require.NoError(t, err)
+	require.Nil(t, failure, "%s", failure)
+	// the orderings of all the seeds are compared to the same in order replay
+	require.Len(t, harness.baselines, 1)
+
+	anchorState, err := os.ReadFile("../test_data/anchor_state.ssz_snappy")
+	require.NoError(t, err)
+	_, err = NewSpectestHarness(fstest.MapFS{
+		"anchor_state.ssz_snappy": {Data: anchorState},
+		"steps.yaml":              {Data: []byte("- tick: 12\n- pow_block: pow_block_0x01\n")},
+	}, clparams.AltairVersion, 3)
+	require.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package fuzz
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Permute returns the order, as indices in events, in which the events are delivered when each of them but the
+// ticks is delayed by up to maxDelay events. The same seed always gives the same order.
+func Permute(events []Event, seed int64, maxDelay int) []int {
+	rng := rand.New(rand.NewSource(seed))
+	positions := make([]int, len(events))
+	sequence := make([]int, len(events))
+	for i, event := range events {
+		sequence[i] = i
+		positions[i] = i
+		if event.Kind != EventTick {
+			positions[i] += rng.Intn(maxDelay + 1)
+		}
+	}
+	sort.SliceStable(sequence, func(i, j int) bool {
+		return positions[sequence[i]] < positions[sequence[j]]
+	})
+	return sequence
+}
+
+// Minimize drops chunks of the sequence, halving their size down to single events, for as long as it still fails.
+// Single events are dropped until none of them can be.
+func Minimize(sequence []int, fails func([]int) bool) []int {
+	for chunk := len(sequence) / 2; chunk > 0; {
+		removed := false
+		for start := 0; start < len(sequence); {
+			end := start + chunk
+			if end > len(sequence) {
+				end = len(sequence)
+			}
+			candidate := append(append([]int{}, sequence[:start]...), sequence[end:]...)
+			if fails(candidate) {
+				sequence, removed = candidate, true
+				continue
+			}
+			start = end
+		}
+		if chunk > 1 || !removed {
+			chunk /= 2
+		}
+	}
+	return sequence
+}
@@ -0,0 +1,57 @@
+package fuzz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermute(t *testing.T) {
+	events := make([]Event, 32)
+	for i := range events {
+		events[i].Kind = EventBlock
+		if i%4 == 0 {
+			events[i] = Event{Kind: EventTick, Time: uint64(i)}
+		}
+	}
+	sequence := Permute(events, 7, 5)
+	require.Equal(t, sequence, Permute(events, 7, 5))
+	require.NotEqual(t, sequence, Permute(events, 8, 5))
+	require.ElementsMatch(t, Permute(events, 0, 0), sequence)
+
+	var lastTick, seenTicks int
+	for position, idx := range sequence {
+		if events[idx].Kind == EventTick {
+			// ticks keep their order and are never delayed
+			require.LessOrEqual(t, lastTick, idx)
+			require.LessOrEqual(t, position, idx)
+			lastTick = idx
+			seenTicks++
+			continue
+		}
+		// deliveries are never ahead of the ticks before them
+		require.GreaterOrEqual(t, seenTicks, idx/4+1)
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	sequence := []int{42}
+	for i := 0; i < 100; i++ {
+		sequence = append(sequence, i)
+	}
+	// fails when 17 is delivered after 42
+	minimized := Minimize(sequence, func(candidate []int) bool {
+		for i, idx := range candidate {
+			if idx != 17 {
+				continue
+			}
+			for _, before := range candidate[:i] {
+				if before == 42 {
+					return true
+				}
+			}
+		}
+		return false
+	})
+	require.Equal(t, []int{42, 17}, minimized)
+}
@@ -0,0 +1,59 @@
+package fuzz
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/spectest"
+)
+
+type spectestStep struct {
+	Tick             *uint64 `yaml:"tick,omitempty"`
+	Block            *string `yaml:"block,omitempty"`
+	Attestation      *string `yaml:"attestation,omitempty"`
+	AttesterSlashing *string `yaml:"attester_slashing,omitempty"`
+	PowBlock         *string `yaml:"pow_block,omitempty"`
+	PayloadStatus    any     `yaml:"payload_status,omitempty"`
+}
+
+// LoadSpectest reads the scenario of a fork choice consensus spec test case. The checks of the case are left out,
+// they only hold for the order of the fixture.
+func LoadSpectest(root fs.FS, version clparams.StateVersion) (*Scenario, error) {
+	anchorState, err := spectest.ReadBeaconState(root, version, "anchor_state.ssz_snappy")
+	if err != nil {
+		return nil, err
+	}
+	var steps []spectestStep
+	if err := spectest.ReadYml(root, "steps.yaml", &steps); err != nil {
+		return nil, err
+	}
+	scenario := &Scenario{AnchorState: anchorState, FullValidation: true}
+	for i, step := range steps {
+		var event Event
+		switch {
+		case step.PowBlock != nil || step.PayloadStatus != nil:
+			return nil, fmt.Errorf("step %d: execution layer steps are not supported", i)
+		case step.Tick != nil:
+			event = Event{Kind: EventTick, Time: *step.Tick}
+		case step.Block != nil:
+			event = Event{Kind: EventBlock, Block: &cltypes.SignedBeaconBlock{}}
+			err = spectest.ReadSsz(root, version, *step.Block+".ssz_snappy", event.Block)
+		case step.Attestation != nil:
+			event = Event{Kind: EventAttestation, Attestation: &solid.Attestation{}}
+			err = spectest.ReadSsz(root, version, *step.Attestation+".ssz_snappy", event.Attestation)
+		case step.AttesterSlashing != nil:
+			event = Event{Kind: EventAttesterSlashing, AttesterSlashing: &cltypes.AttesterSlashing{}}
+			err = spectest.ReadSsz(root, version, *step.AttesterSlashing+".ssz_snappy", event.AttesterSlashing)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		scenario.Events = append(scenario.Events, event)
+	}
+	return scenario, nil
+}
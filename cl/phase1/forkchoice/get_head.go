@@ -16,6 +16,18 @@ func (f *ForkChoiceStore) GetHead() (libcommon.Hash, uint64, error) {
 	return f.getHead()
 }
 
+// UpdateHead recomputes the head after the store changed and notifies the new head, if any, to the subscribers.
+func (f *ForkChoiceStore) UpdateHead() (libcommon.Hash, uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	head, slot, err := f.getHead()
+	if err != nil {
+		return libcommon.Hash{}, 0, err
+	}
+	f.notifyHead(head, slot)
+	return head, slot, nil
+}
+
 func (f *ForkChoiceStore) getHead() (libcommon.Hash, uint64, error) {
 	// Retrieve att
 	head := f.justifiedCheckpoint.BlockRoot()
@@ -3,6 +3,7 @@ package forkchoice
 import (
 	"fmt"
 
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/phase1/cache"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
@@ -21,6 +22,9 @@ func (f *ForkChoiceStore) OnAttestation(attestation *solid.Attestation, fromBloc
 	target := data.Target()
 	if cachedIndicies, ok := cache.LoadAttestatingIndicies(&data, attestation.AggregationBits()); ok {
 		f.processAttestingIndicies(attestation, cachedIndicies)
+		if !fromBlock {
+			f.emitter.Publish(beaconevents.TopicAttestation, attestation)
+		}
 		return nil
 	}
 	targetState, err := f.getCheckpointState(target)
@@ -52,9 +56,23 @@ func (f *ForkChoiceStore) OnAttestation(attestation *solid.Attestation, fromBloc
 	}
 	// Lastly update latest messages.
 	f.processAttestingIndicies(attestation, attestationIndicies)
+	if !fromBlock {
+		f.emitter.Publish(beaconevents.TopicAttestation, attestation)
+	}
 	return nil
 }
 
+// CommitteesPerSlot returns the number of beacon committees of every slot of the target's epoch.
+func (f *ForkChoiceStore) CommitteesPerSlot(target solid.Checkpoint) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	targetState, err := f.getCheckpointState(target)
+	if err != nil {
+		return 0, err
+	}
+	return targetState.committeesPerSlot(target.Epoch()), nil
+}
+
 func (f *ForkChoiceStore) processAttestingIndicies(attestation *solid.Attestation, indicies []uint64) {
 	beaconBlockRoot := attestation.AttestantionData().BeaconBlockRoot()
 	target := attestation.AttestantionData().Target()
@@ -0,0 +1,67 @@
+package forkchoice
+
+import (
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+)
+
+// ValidateBlobSidecar checks a gossiped blob sidecar against fork choice: it has to be newer than the finalized
+// checkpoint and than its parent block, which has to be known, and be signed by the expected proposer of its slot.
+// The sidecar is checked against its block, which may not be known yet, when it is stored.
+func (f *ForkChoiceStore) ValidateBlobSidecar(signed *cltypes.SignedBlobSidecar) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sidecar := signed.Message
+	if sidecar.Slot > f.Slot() {
+		return fmt.Errorf("blob sidecar is too early compared to current_slot")
+	}
+	if sidecar.Slot <= f.computeStartSlotAtEpoch(f.finalizedCheckpoint.Epoch()) {
+		return fmt.Errorf("blob sidecar is not newer than the finalized checkpoint")
+	}
+	parent, ok := f.forkGraph.GetHeader(sidecar.BlockParentRoot)
+	if !ok {
+		return fmt.Errorf("blob sidecar parent block %x is unknown", sidecar.BlockParentRoot)
+	}
+	if sidecar.Slot <= parent.Slot {
+		return fmt.Errorf("blob sidecar is not newer than its parent block")
+	}
+	proposerIndex, err := f.expectedProposer(sidecar.BlockParentRoot, sidecar.Slot)
+	if err != nil {
+		return err
+	}
+	if sidecar.ProposerIndex != proposerIndex {
+		return fmt.Errorf("blob sidecar proposer %d is not the expected proposer %d", sidecar.ProposerIndex, proposerIndex)
+	}
+	justifiedState, err := f.getCheckpointState(f.justifiedCheckpoint)
+	if err != nil {
+		return err
+	}
+	return justifiedState.isValidBlobSidecarSignature(signed)
+}
+
+// expectedProposer computes the proposer of a block of the given slot on top of the given parent, advancing the state
+// of the parent to the slot when it is of an earlier epoch.
+func (f *ForkChoiceStore) expectedProposer(parentRoot libcommon.Hash, slot uint64) (uint64, error) {
+	parentState, _, err := f.forkGraph.GetState(parentRoot, false)
+	if err != nil {
+		return 0, err
+	}
+	if parentState == nil {
+		return 0, fmt.Errorf("state of block %x is not available", parentRoot)
+	}
+	if f.computeEpochAtSlot(slot) == state.Epoch(parentState.BeaconState) {
+		return parentState.GetBeaconProposerIndexForSlot(slot)
+	}
+	if parentState, err = parentState.Copy(); err != nil {
+		return 0, err
+	}
+	if err := transition.ProcessSlots(parentState, slot); err != nil {
+		return 0, err
+	}
+	return parentState.GetBeaconProposerIndex()
+}
@@ -0,0 +1,36 @@
+package forkchoice
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+)
+
+// OnVoluntaryExit validates a gossiped voluntary exit: it has to be the first one seen for its validator and be
+// applicable to the head state.
+func (f *ForkChoiceStore) OnVoluntaryExit(signed *cltypes.SignedVoluntaryExit) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	validatorIndex := signed.VolunaryExit.ValidatorIndex
+	if _, ok := f.seenVoluntaryExits[validatorIndex]; ok {
+		return fmt.Errorf("voluntary exit of validator %d was already seen", validatorIndex)
+	}
+	headRoot, _, err := f.getHead()
+	if err != nil {
+		return err
+	}
+	// the head state is only read, it is not copied unless it has to be rebuilt
+	s, _, err := f.forkGraph.GetState(headRoot, false)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return fmt.Errorf("head state not accessible")
+	}
+	if err := transition.ValidateVoluntaryExit(s, signed, true); err != nil {
+		return err
+	}
+	f.seenVoluntaryExits[validatorIndex] = struct{}{}
+	return nil
+}
@@ -23,6 +23,7 @@ func (f *ForkChoiceStore) updateCheckpoints(justifiedCheckpoint, finalizedCheckp
 	}
 	if finalizedCheckpoint.Epoch() > f.finalizedCheckpoint.Epoch() {
 		f.finalizedCheckpoint = finalizedCheckpoint
+		f.notifyFinalizedCheckpoint(finalizedCheckpoint)
 	}
 }
 
@@ -0,0 +1,49 @@
+package light_client
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
+	"github.com/ledgerwatch/erigon-lib/types/ssz"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
+	"github.com/ledgerwatch/erigon/cl/gossip"
+)
+
+var gossipTopics = []string{beaconevents.TopicLightClientFinalityUpdate, beaconevents.TopicLightClientOptimisticUpdate}
+
+// PublishGossip forwards the finality and optimistic updates produced by forkchoice to the light_client_* gossip
+// topics until the context is cancelled.
+func PublishGossip(ctx context.Context, sentinelClient sentinel.SentinelClient, emitter *beaconevents.Emitter) {
+	sub, unsubscribe := emitter.Subscribe(gossipTopics, beaconevents.DefaultSubscriptionBufferSize)
+	for {
+		select {
+		case <-ctx.Done():
+			unsubscribe()
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				log.Warn("[Light Client] Fell behind the updates, resubscribing")
+				sub, unsubscribe = emitter.Subscribe(gossipTopics, beaconevents.DefaultSubscriptionBufferSize)
+				continue
+			}
+			gossipType := gossip.LightClientFinalityUpdateGossipType
+			if event.Topic == beaconevents.TopicLightClientOptimisticUpdate {
+				gossipType = gossip.LightClientOptimisticUpdateGossipType
+			}
+			update, ok := event.Data.(ssz.Marshaler)
+			if !ok {
+				continue
+			}
+			encoded, err := update.EncodeSSZ(nil)
+			if err != nil {
+				log.Warn("[Light Client] Could not encode update", "topic", event.Topic, "err", err)
+				continue
+			}
+			if _, err := sentinelClient.PublishGossip(ctx, &sentinel.GossipData{Data: encoded, Type: gossipType}); err != nil {
+				log.Debug("[Light Client] Could not publish update", "topic", event.Topic, "err", err)
+			}
+		}
+	}
+}
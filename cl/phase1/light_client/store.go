@@ -0,0 +1,422 @@
+// Package light_client serves the Altair light client protocol: it derives bootstraps and updates from the canonical
+// blocks imported by forkchoice and keeps the best update of every sync committee period on disk. Since Capella the
+// light client headers carry the execution payload header, which is not supported yet, so no data is derived from the
+// blocks of Capella onwards.
+package light_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// the objects persisted by the store
+const (
+	freezerNamespace       = "caplin_light_client"
+	freezerUpdateObject    = "bestUpdate"
+	freezerBootstrapObject = "bootstrap"
+)
+
+// attestedCacheSize is the number of recent blocks whose state proofs are kept, enough to reach back to the
+// finalized checkpoint of the attested blocks.
+const attestedCacheSize = 256
+
+// attestedData is what the post-state of a block proves to light clients, along with the sync aggregate of the block.
+type attestedData struct {
+	syncAggregate              *cltypes.SyncAggregate
+	header                     *cltypes.LightClientHeader
+	currentSyncCommittee       *solid.SyncCommittee
+	currentSyncCommitteeBranch cltypes.SyncCommitteeBranch
+	nextSyncCommittee          *solid.SyncCommittee
+	nextSyncCommitteeBranch    cltypes.SyncCommitteeBranch
+	finalizedRoot              libcommon.Hash
+	finalityBranch             cltypes.FinalityBranch
+}
+
+// Store builds the light client data of the canonical chain imported by forkchoice. It keeps the best update of
+// every sync committee period and the bootstraps of the finalized blocks in the freezer, the latest finality and
+// optimistic updates are only kept in memory. Forkchoice feeds it under its lock, so the freezer is only written
+// to by Run.
+type Store struct {
+	f            freezer.Freezer
+	beaconConfig *clparams.BeaconChainConfig
+
+	writesMu sync.Mutex
+	writes   []func() error
+	wake     chan struct{}
+
+	mu               sync.RWMutex
+	attested         *lru.Cache[libcommon.Hash, *attestedData]
+	bestUpdates      map[uint64]*cltypes.LightClientUpdate
+	finalityUpdate   *cltypes.LightClientFinalityUpdate
+	optimisticUpdate *cltypes.LightClientOptimisticUpdate
+}
+
+func NewStore(f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig) (*Store, error) {
+	attested, err := lru.New[libcommon.Hash, *attestedData](attestedCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		f:            f,
+		beaconConfig: beaconConfig,
+		attested:     attested,
+		bestUpdates:  make(map[uint64]*cltypes.LightClientUpdate),
+		wake:         make(chan struct{}, 1),
+	}, nil
+}
+
+// Run writes the best updates and the bootstraps to the freezer until the context is cancelled.
+func (s *Store) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			s.flush()
+		}
+	}
+}
+
+// enqueue schedules a write to the freezer, it is run by Run.
+func (s *Store) enqueue(write func() error) {
+	s.writesMu.Lock()
+	s.writes = append(s.writes, write)
+	s.writesMu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush runs the scheduled writes in order.
+func (s *Store) flush() {
+	s.writesMu.Lock()
+	writes := s.writes
+	s.writes = nil
+	s.writesMu.Unlock()
+	for _, write := range writes {
+		if err := write(); err != nil {
+			log.Warn("[Light Client] Could not persist light client data", "err", err)
+		}
+	}
+}
+
+func (s *Store) syncCommitteePeriod(slot uint64) uint64 {
+	return slot / s.beaconConfig.SlotsPerEpoch / s.beaconConfig.EpochsPerSyncCommitteePeriod
+}
+
+// OnAnchor records the proofs of the state forkchoice starts from.
+func (s *Store) OnAnchor(anchorState *state.BeaconState) error {
+	header := anchorState.LatestBlockHeader()
+	if header.Root == (libcommon.Hash{}) {
+		stateRoot, err := anchorState.HashSSZ()
+		if err != nil {
+			return err
+		}
+		header.Root = stateRoot
+	}
+	root, err := header.HashSSZ()
+	if err != nil {
+		return err
+	}
+	return s.addState(root, &header, nil, anchorState)
+}
+
+// OnBlock records the proofs of the post-state of a block imported by forkchoice, whichever fork it is part of, and
+// the sync aggregate it carries. Updates are only created by OnHead, once the block is canonical.
+func (s *Store) OnBlock(block *cltypes.SignedBeaconBlock, blockRoot libcommon.Hash, postState *state.BeaconState) error {
+	bodyRoot, err := block.Block.Body.HashSSZ()
+	if err != nil {
+		return err
+	}
+	header := &cltypes.BeaconBlockHeader{
+		Slot:          block.Block.Slot,
+		ProposerIndex: block.Block.ProposerIndex,
+		ParentRoot:    block.Block.ParentRoot,
+		Root:          block.Block.StateRoot,
+		BodyRoot:      bodyRoot,
+	}
+	var syncAggregate *cltypes.SyncAggregate
+	if block.Version() >= clparams.AltairVersion && block.Block.Body.SyncAggregate != nil {
+		aggregate := *block.Block.Body.SyncAggregate
+		syncAggregate = &aggregate
+	}
+	return s.addState(blockRoot, header, syncAggregate, postState)
+}
+
+// OnHead creates the update signed by the sync aggregate of the new head of the chain. It returns the finality and
+// optimistic updates to forward to the network, nil when the head does not improve on the previous ones.
+func (s *Store) OnHead(headRoot libcommon.Hash) (*cltypes.LightClientFinalityUpdate, *cltypes.LightClientOptimisticUpdate) {
+	signing, ok := s.attested.Peek(headRoot)
+	if !ok {
+		return nil, nil
+	}
+	update, ok := s.createUpdate(signing)
+	if !ok {
+		return nil, nil
+	}
+	return s.processUpdate(update)
+}
+
+// addState records what the post-state of the given block proves. States before Altair have no sync committees, and
+// the headers of the blocks of Capella onwards cannot be served.
+func (s *Store) addState(blockRoot libcommon.Hash, header *cltypes.BeaconBlockHeader, syncAggregate *cltypes.SyncAggregate, postState *state.BeaconState) error {
+	version := s.beaconConfig.GetCurrentStateVersion(header.Slot / s.beaconConfig.SlotsPerEpoch)
+	if version < clparams.AltairVersion {
+		return nil
+	}
+	if version >= clparams.CapellaVersion {
+		// the latest updates would never be superseded, stop serving them as well
+		s.mu.Lock()
+		s.finalityUpdate, s.optimisticUpdate = nil, nil
+		s.mu.Unlock()
+		return nil
+	}
+	data := &attestedData{
+		syncAggregate: syncAggregate,
+		header:        &cltypes.LightClientHeader{Beacon: header},
+		finalizedRoot: postState.FinalizedCheckpoint().BlockRoot(),
+	}
+	currentBranch, err := postState.CurrentSyncCommitteeBranch()
+	if err != nil {
+		return err
+	}
+	nextBranch, err := postState.NextSyncCommitteeBranch()
+	if err != nil {
+		return err
+	}
+	finalityBranch, err := postState.FinalityRootBranch()
+	if err != nil {
+		return err
+	}
+	copy(data.currentSyncCommitteeBranch[:], currentBranch)
+	copy(data.nextSyncCommitteeBranch[:], nextBranch)
+	copy(data.finalityBranch[:], finalityBranch)
+
+	// the committees only change once per period, share them with the parent to save memory
+	data.currentSyncCommittee = postState.CurrentSyncCommittee().Copy()
+	data.nextSyncCommittee = postState.NextSyncCommittee().Copy()
+	if parent, ok := s.attested.Peek(header.ParentRoot); ok {
+		if parent.currentSyncCommittee.Equal(data.currentSyncCommittee) {
+			data.currentSyncCommittee = parent.currentSyncCommittee
+		}
+		if parent.nextSyncCommittee.Equal(data.nextSyncCommittee) {
+			data.nextSyncCommittee = parent.nextSyncCommittee
+		}
+	}
+	s.attested.Add(blockRoot, data)
+	return nil
+}
+
+// createUpdate builds the update for the parent of the signing block, which its sync aggregate attests to.
+func (s *Store) createUpdate(signing *attestedData) (*cltypes.LightClientUpdate, bool) {
+	syncAggregate := signing.syncAggregate
+	if syncAggregate == nil || uint64(syncAggregate.Sum()) < s.beaconConfig.MinSyncCommitteeParticipants {
+		return nil, false
+	}
+	signatureSlot := signing.header.Beacon.Slot
+	attested, ok := s.attested.Get(signing.header.Beacon.ParentRoot)
+	if !ok {
+		return nil, false
+	}
+	update := &cltypes.LightClientUpdate{
+		AttestedHeader:    attested.header,
+		NextSyncCommittee: &solid.SyncCommittee{},
+		FinalizedHeader:   &cltypes.LightClientHeader{Beacon: &cltypes.BeaconBlockHeader{}},
+		SyncAggregate:     syncAggregate,
+		SignatureSlot:     signatureSlot,
+	}
+	// the next sync committee is only useful if the update is signed by the current one
+	if s.syncCommitteePeriod(attested.header.Beacon.Slot) == s.syncCommitteePeriod(signatureSlot) {
+		update.NextSyncCommittee = attested.nextSyncCommittee
+		update.NextSyncCommitteeBranch = attested.nextSyncCommitteeBranch
+	}
+	// the finalized header is left empty while the genesis block is finalized
+	if attested.finalizedRoot == (libcommon.Hash{}) {
+		update.FinalityBranch = attested.finalityBranch
+	} else if finalized, ok := s.attested.Peek(attested.finalizedRoot); ok {
+		update.FinalizedHeader = finalized.header
+		update.FinalityBranch = attested.finalityBranch
+	}
+	return update, true
+}
+
+// processUpdate schedules the update to be kept if it is the best of its period and tracks the latest finality and
+// optimistic updates.
+func (s *Store) processUpdate(update *cltypes.LightClientUpdate) (*cltypes.LightClientFinalityUpdate, *cltypes.LightClientOptimisticUpdate) {
+	s.enqueue(func() error { return s.storeIfBest(update) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var (
+		finalityUpdate   *cltypes.LightClientFinalityUpdate
+		optimisticUpdate *cltypes.LightClientOptimisticUpdate
+	)
+	if update.IsFinalityUpdate() && isNewerFinality(s.beaconConfig.SyncCommitteeSize, update, s.finalityUpdate) {
+		finalityUpdate = &cltypes.LightClientFinalityUpdate{
+			AttestedHeader:  update.AttestedHeader,
+			FinalizedHeader: update.FinalizedHeader,
+			FinalityBranch:  update.FinalityBranch,
+			SyncAggregate:   update.SyncAggregate,
+			SignatureSlot:   update.SignatureSlot,
+		}
+		s.finalityUpdate = finalityUpdate
+	}
+	if s.optimisticUpdate == nil || update.AttestedHeader.Beacon.Slot > s.optimisticUpdate.AttestedHeader.Beacon.Slot {
+		optimisticUpdate = &cltypes.LightClientOptimisticUpdate{
+			AttestedHeader: update.AttestedHeader,
+			SyncAggregate:  update.SyncAggregate,
+			SignatureSlot:  update.SignatureSlot,
+		}
+		s.optimisticUpdate = optimisticUpdate
+	}
+	return finalityUpdate, optimisticUpdate
+}
+
+// storeIfBest persists the update if it is better than the best one of its period.
+func (s *Store) storeIfBest(update *cltypes.LightClientUpdate) error {
+	period := s.syncCommitteePeriod(update.AttestedHeader.Beacon.Slot)
+	best, err := s.BestUpdate(period)
+	if err != nil {
+		return err
+	}
+	if best != nil && !isBetterUpdate(s.syncCommitteePeriod, s.beaconConfig.SyncCommitteeSize, update, best) {
+		return nil
+	}
+	if s.f != nil {
+		if err := freezer.PutObjectSSZIntoFreezer(freezerUpdateObject, freezerNamespace, period, update, s.f); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bestUpdates[period] = update
+	return nil
+}
+
+// OnFinalized schedules the bootstrap of the finalized block to be persisted, if its post-state was recorded.
+func (s *Store) OnFinalized(blockRoot libcommon.Hash) error {
+	data, ok := s.attested.Peek(blockRoot)
+	if !ok || s.f == nil {
+		return nil
+	}
+	encoded, err := newBootstrap(data).EncodeSSZ(nil)
+	if err != nil {
+		return err
+	}
+	s.enqueue(func() error {
+		return freezer.NewBlobStore(s.f).Put(utils.CompressSnappy(encoded), freezerNamespace, freezerBootstrapObject, blockRoot.String())
+	})
+	return nil
+}
+
+func newBootstrap(data *attestedData) *cltypes.LightClientBootstrap {
+	return &cltypes.LightClientBootstrap{
+		Header:                     data.header,
+		CurrentSyncCommittee:       data.currentSyncCommittee,
+		CurrentSyncCommitteeBranch: data.currentSyncCommitteeBranch,
+	}
+}
+
+// Bootstrap returns the bootstrap of a recent or finalized block, nil if it is not known.
+func (s *Store) Bootstrap(blockRoot libcommon.Hash) (*cltypes.LightClientBootstrap, error) {
+	if data, ok := s.attested.Peek(blockRoot); ok {
+		return newBootstrap(data), nil
+	}
+	bootstrap := &cltypes.LightClientBootstrap{}
+	ok, err := s.readObject(freezerBootstrapObject, blockRoot.String(), bootstrap)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return bootstrap, nil
+}
+
+// BestUpdate returns the best update of the sync committee period, nil if there is none.
+func (s *Store) BestUpdate(period uint64) (*cltypes.LightClientUpdate, error) {
+	s.mu.RLock()
+	update, ok := s.bestUpdates[period]
+	s.mu.RUnlock()
+	if ok {
+		return update, nil
+	}
+	update = &cltypes.LightClientUpdate{}
+	ok, err := s.readObject(freezerUpdateObject, fmt.Sprintf("%d", period), update)
+	if err != nil || !ok {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.bestUpdates[period]; !ok {
+		s.bestUpdates[period] = update
+	}
+	return s.bestUpdates[period], nil
+}
+
+// Updates returns the best updates of count consecutive periods from the start period, stopping at the first
+// period without one.
+func (s *Store) Updates(startPeriod, count uint64) ([]*cltypes.LightClientUpdate, error) {
+	var updates []*cltypes.LightClientUpdate
+	for period := startPeriod; period < startPeriod+count; period++ {
+		update, err := s.BestUpdate(period)
+		if err != nil {
+			return nil, err
+		}
+		if update == nil {
+			break
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// FinalityUpdate returns the latest finality update, nil if there is none yet.
+func (s *Store) FinalityUpdate() *cltypes.LightClientFinalityUpdate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.finalityUpdate
+}
+
+// OptimisticUpdate returns the latest optimistic update, nil if there is none yet.
+func (s *Store) OptimisticUpdate() *cltypes.LightClientOptimisticUpdate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.optimisticUpdate
+}
+
+// readObject decodes a snappy compressed object, false if it was not persisted.
+func (s *Store) readObject(object, id string, obj interface {
+	DecodeSSZ(buf []byte, version int) error
+}) (bool, error) {
+	if s.f == nil {
+		return false, nil
+	}
+	data, err := freezer.NewBlobStore(s.f).Get(freezerNamespace, object, id)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	encoded, err := utils.DecompressSnappy(data)
+	if err != nil {
+		return false, err
+	}
+	if err := obj.DecodeSSZ(encoded, int(clparams.AltairVersion)); err != nil {
+		return false, fmt.Errorf("invalid light client %s %s: %w", object, id, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,154 @@
+package light_client
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state/raw"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+const testData = "../core/transition/test_data/block_processing/"
+
+// bellatrixConfig returns the mainnet config without the forks from Capella onwards, so that the capella test data is
+// served as bellatrix data: the proofs of the sync committees and of the finalized checkpoint are the same.
+func bellatrixConfig() *clparams.BeaconChainConfig {
+	cfg := clparams.MainnetBeaconConfig
+	cfg.CapellaForkEpoch, cfg.DenebForkEpoch = math.MaxUint64, math.MaxUint64
+	return &cfg
+}
+
+// testChain returns a capella state and the block built on top of it.
+func testChain(t *testing.T) (*state.BeaconState, *cltypes.SignedBeaconBlock) {
+	read := func(name string) []byte {
+		data, err := os.ReadFile(testData + name)
+		require.NoError(t, err)
+		return data
+	}
+	anchor := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(anchor, read("capella_state.ssz_snappy"), int(clparams.CapellaVersion)))
+	block := &cltypes.SignedBeaconBlock{}
+	require.NoError(t, utils.DecodeSSZSnappy(block, read("capella_block.ssz_snappy"), int(clparams.CapellaVersion)))
+	return anchor, block
+}
+
+func TestStore(t *testing.T) {
+	anchor, block := testChain(t)
+	cfg := bellatrixConfig()
+	f := &freezer.InMemory{}
+
+	store, err := NewStore(f, cfg)
+	require.NoError(t, err)
+	require.NoError(t, store.OnAnchor(anchor))
+
+	postState, err := anchor.Copy()
+	require.NoError(t, err)
+	require.NoError(t, transition.TransitionState(postState, block, false))
+	blockRoot, err := block.Block.HashSSZ()
+	require.NoError(t, err)
+
+	// blocks of other forks are not served until they become the head
+	require.NoError(t, store.OnBlock(block, blockRoot, postState))
+	require.Nil(t, store.OptimisticUpdate())
+	finalityUpdate, optimisticUpdate := store.OnHead(blockRoot)
+	// the finalized block of the anchor is unknown to the store
+	require.Nil(t, finalityUpdate)
+	require.NotNil(t, optimisticUpdate)
+	require.Equal(t, block.Block.ParentRoot, libcommon.Hash(mustHash(t, optimisticUpdate.AttestedHeader.Beacon)))
+	require.Equal(t, block.Block.Slot, optimisticUpdate.SignatureSlot)
+	require.Equal(t, optimisticUpdate, store.OptimisticUpdate())
+
+	// the attested header is the anchor, whose bootstrap proves the current sync committee
+	bootstrap, err := store.Bootstrap(block.Block.ParentRoot)
+	require.NoError(t, err)
+	require.NotNil(t, bootstrap)
+	committeeRoot, err := anchor.CurrentSyncCommittee().HashSSZ()
+	require.NoError(t, err)
+	require.True(t, utils.IsValidMerkleBranch(committeeRoot, bootstrap.CurrentSyncCommitteeBranch[:], cltypes.SyncCommitteeBranchLength, uint64(raw.CurrentSyncCommitteeLeafIndex), bootstrap.Header.Beacon.Root))
+	require.NoError(t, store.OnFinalized(block.Block.ParentRoot))
+	store.flush()
+
+	// the best update and the finalized bootstrap survive a restart
+	period := store.syncCommitteePeriod(optimisticUpdate.AttestedHeader.Beacon.Slot)
+	expected, err := store.BestUpdate(period)
+	require.NoError(t, err)
+	require.NotNil(t, expected)
+
+	restarted, err := NewStore(f, cfg)
+	require.NoError(t, err)
+	updates, err := restarted.Updates(period, 2)
+	require.NoError(t, err)
+	require.Len(t, updates, 1)
+	require.Equal(t, mustHash(t, expected), mustHash(t, updates[0]))
+	persisted, err := restarted.Bootstrap(block.Block.ParentRoot)
+	require.NoError(t, err)
+	require.NotNil(t, persisted)
+	require.Equal(t, mustHash(t, bootstrap), mustHash(t, persisted))
+
+	missing, err := restarted.Bootstrap(blockRoot)
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
+func TestStoreCapella(t *testing.T) {
+	anchor, block := testChain(t)
+	store, err := NewStore(&freezer.InMemory{}, &clparams.MainnetBeaconConfig)
+	require.NoError(t, err)
+	// the latest updates of the previous fork are not served anymore either
+	store.optimisticUpdate = &cltypes.LightClientOptimisticUpdate{}
+	require.NoError(t, store.OnAnchor(anchor))
+	require.Nil(t, store.OptimisticUpdate())
+
+	postState, err := anchor.Copy()
+	require.NoError(t, err)
+	require.NoError(t, transition.TransitionState(postState, block, false))
+	blockRoot, err := block.Block.HashSSZ()
+	require.NoError(t, err)
+	require.NoError(t, store.OnBlock(block, blockRoot, postState))
+	finalityUpdate, optimisticUpdate := store.OnHead(blockRoot)
+	require.Nil(t, finalityUpdate)
+	require.Nil(t, optimisticUpdate)
+	bootstrap, err := store.Bootstrap(block.Block.ParentRoot)
+	require.NoError(t, err)
+	require.Nil(t, bootstrap)
+}
+
+func TestIsBetterUpdate(t *testing.T) {
+	periodAtSlot := func(slot uint64) uint64 { return slot / 8192 }
+	newUpdate := func(attestedSlot uint64, participants int) *cltypes.LightClientUpdate {
+		aggregate := &cltypes.SyncAggregate{}
+		for i := 0; i < participants; i++ {
+			aggregate.SyncCommiteeBits[i/8] |= 1 << (i % 8)
+		}
+		return &cltypes.LightClientUpdate{
+			AttestedHeader:  &cltypes.LightClientHeader{Beacon: &cltypes.BeaconBlockHeader{Slot: attestedSlot}},
+			FinalizedHeader: &cltypes.LightClientHeader{Beacon: &cltypes.BeaconBlockHeader{}},
+			SyncAggregate:   aggregate,
+			SignatureSlot:   attestedSlot + 1,
+		}
+	}
+	// supermajority wins
+	require.True(t, isBetterUpdate(periodAtSlot, 512, newUpdate(10, 400), newUpdate(10, 300)))
+	require.False(t, isBetterUpdate(periodAtSlot, 512, newUpdate(10, 300), newUpdate(10, 400)))
+	// then participation
+	require.True(t, isBetterUpdate(periodAtSlot, 512, newUpdate(10, 200), newUpdate(10, 100)))
+	require.True(t, isBetterUpdate(periodAtSlot, 512, newUpdate(10, 500), newUpdate(10, 400)))
+	// then older data
+	require.True(t, isBetterUpdate(periodAtSlot, 512, newUpdate(5, 400), newUpdate(10, 400)))
+	require.False(t, isBetterUpdate(periodAtSlot, 512, newUpdate(10, 400), newUpdate(10, 400)))
+}
+
+func mustHash(t *testing.T, obj interface{ HashSSZ() ([32]byte, error) }) [32]byte {
+	root, err := obj.HashSSZ()
+	require.NoError(t, err)
+	return root
+}
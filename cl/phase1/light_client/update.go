@@ -0,0 +1,73 @@
+package light_client
+
+import (
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+)
+
+// hasSupermajority reports whether two thirds of the sync committee of the given size participated.
+func hasSupermajority(syncCommitteeSize uint64, participants int) bool {
+	return uint64(participants)*3 >= syncCommitteeSize*2
+}
+
+// isBetterUpdate reports whether newUpdate should replace oldUpdate as the best update of their period, as
+// specified by is_better_update of the light client sync protocol.
+func isBetterUpdate(periodAtSlot func(uint64) uint64, syncCommitteeSize uint64, newUpdate, oldUpdate *cltypes.LightClientUpdate) bool {
+	// compare supermajority sync committee participation
+	newParticipants, oldParticipants := newUpdate.SyncAggregate.Sum(), oldUpdate.SyncAggregate.Sum()
+	newSupermajority, oldSupermajority := hasSupermajority(syncCommitteeSize, newParticipants), hasSupermajority(syncCommitteeSize, oldParticipants)
+	if newSupermajority != oldSupermajority {
+		return newSupermajority
+	}
+	if !newSupermajority && newParticipants != oldParticipants {
+		return newParticipants > oldParticipants
+	}
+
+	// compare presence of the relevant sync committee
+	hasRelevantSyncCommittee := func(u *cltypes.LightClientUpdate) bool {
+		return u.IsSyncCommitteeUpdate() && periodAtSlot(u.AttestedHeader.Beacon.Slot) == periodAtSlot(u.SignatureSlot)
+	}
+	newRelevant, oldRelevant := hasRelevantSyncCommittee(newUpdate), hasRelevantSyncCommittee(oldUpdate)
+	if newRelevant != oldRelevant {
+		return newRelevant
+	}
+
+	// compare indication of any finality
+	newFinality, oldFinality := newUpdate.IsFinalityUpdate(), oldUpdate.IsFinalityUpdate()
+	if newFinality != oldFinality {
+		return newFinality
+	}
+
+	// compare sync committee finality
+	if newFinality {
+		hasSyncCommitteeFinality := func(u *cltypes.LightClientUpdate) bool {
+			return periodAtSlot(u.FinalizedHeader.Beacon.Slot) == periodAtSlot(u.AttestedHeader.Beacon.Slot)
+		}
+		newSyncCommitteeFinality, oldSyncCommitteeFinality := hasSyncCommitteeFinality(newUpdate), hasSyncCommitteeFinality(oldUpdate)
+		if newSyncCommitteeFinality != oldSyncCommitteeFinality {
+			return newSyncCommitteeFinality
+		}
+	}
+
+	// tiebreaker 1: sync committee participation beyond supermajority
+	if newParticipants != oldParticipants {
+		return newParticipants > oldParticipants
+	}
+	// tiebreaker 2: prefer older data, fewer changes to best
+	if newUpdate.AttestedHeader.Beacon.Slot != oldUpdate.AttestedHeader.Beacon.Slot {
+		return newUpdate.AttestedHeader.Beacon.Slot < oldUpdate.AttestedHeader.Beacon.Slot
+	}
+	return newUpdate.SignatureSlot < oldUpdate.SignatureSlot
+}
+
+// isNewerFinality reports whether the finality update carried by the update should be forwarded after the previous
+// one: it finalizes a later header, or the same header with a supermajority the previous one did not have.
+func isNewerFinality(syncCommitteeSize uint64, update *cltypes.LightClientUpdate, previous *cltypes.LightClientFinalityUpdate) bool {
+	if previous == nil {
+		return true
+	}
+	newSlot, oldSlot := update.FinalizedHeader.Beacon.Slot, previous.FinalizedHeader.Beacon.Slot
+	if newSlot != oldSlot {
+		return newSlot > oldSlot
+	}
+	return hasSupermajority(syncCommitteeSize, update.SyncAggregate.Sum()) && !hasSupermajority(syncCommitteeSize, previous.SyncAggregate.Sum())
+}
@@ -0,0 +1,58 @@
+package network
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// onBlobSidecar validates a blob sidecar received on its subnet and stores it once its block is imported.
+func (g *GossipManager) onBlobSidecar(data *sentinel.GossipData, version clparams.StateVersion, l log.Ctx) error {
+	if g.blobStore == nil {
+		return nil
+	}
+	signed := &cltypes.SignedBlobSidecar{}
+	if err := signed.DecodeSSZ(common.CopyBytes(data.Data), int(version)); err != nil {
+		g.sentinel.BanPeer(g.ctx, data.Peer)
+		l["at"] = "decoding blob sidecar"
+		return err
+	}
+	sidecar := signed.Message
+	l["slot"] = sidecar.Slot
+	l["index"] = sidecar.Index
+	if sidecar.Index >= g.beaconConfig.MaxBlobsPerBlock {
+		g.sentinel.BanPeer(g.ctx, data.Peer)
+		l["at"] = "blob sidecar index"
+		return fmt.Errorf("blob sidecar index %d is out of range", sidecar.Index)
+	}
+	if data.BlobIndex == nil || uint64(*data.BlobIndex) != sidecar.Index {
+		l["at"] = "blob sidecar subnet"
+		return fmt.Errorf("blob sidecar %d received on the wrong subnet", sidecar.Index)
+	}
+	if err := g.forkChoice.ValidateBlobSidecar(signed); err != nil {
+		l["at"] = "blob sidecar validation"
+		return err
+	}
+	// the sidecar is signed by its proposer at this point, an invalid proof is on the peer.
+	if err := g.blobStore.OnBlobSidecar(sidecar); err != nil {
+		g.sentinel.BanPeer(g.ctx, data.Peer)
+		l["at"] = "blob sidecar store"
+		return err
+	}
+	return nil
+}
+
+// OnImportedBlock stores the sidecars of a block imported by fork choice which were received before it.
+func (g *GossipManager) OnImportedBlock(block *cltypes.SignedBeaconBlock) {
+	if g.blobStore == nil {
+		return
+	}
+	if err := g.blobStore.OnBlock(block.Block); err != nil {
+		log.Warn("[Blob Store] Could not store the blob sidecars of a block", "slot", block.Block.Slot, "err", err)
+	}
+}
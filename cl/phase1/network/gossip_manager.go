@@ -3,10 +3,14 @@ package network
 import (
 	"context"
 	"runtime"
+	"sync"
 
 	"github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/gossip"
+	"github.com/ledgerwatch/erigon/cl/phase1/blob_storage"
 	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
@@ -27,13 +31,19 @@ type GossipManager struct {
 	recorder   freezer.Freezer
 	forkChoice *forkchoice.ForkChoiceStore
 	sentinel   sentinel.SentinelClient
+	emitter    *beaconevents.Emitter
+	blobStore  *blob_storage.BlobStore // nil when blob sidecars are not stored
 	// configs
 	beaconConfig  *clparams.BeaconChainConfig
 	genesisConfig *clparams.GenesisConfig
+
+	// attestations of the current slot, which only count for fork choice from the next one
+	pendingAttestations   []*solid.Attestation
+	pendingAttestationsMu sync.Mutex
 }
 
 func NewGossipReceiver(ctx context.Context, s sentinel.SentinelClient, forkChoice *forkchoice.ForkChoiceStore,
-	beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig, recorder freezer.Freezer) *GossipManager {
+	beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig, recorder freezer.Freezer, emitter *beaconevents.Emitter, blobStore *blob_storage.BlobStore) *GossipManager {
 	return &GossipManager{
 		sentinel:      s,
 		forkChoice:    forkChoice,
@@ -41,6 +51,8 @@ func NewGossipReceiver(ctx context.Context, s sentinel.SentinelClient, forkChoic
 		beaconConfig:  beaconConfig,
 		genesisConfig: genesisConfig,
 		recorder:      recorder,
+		emitter:       emitter,
+		blobStore:     blobStore,
 	}
 }
 
@@ -109,6 +121,7 @@ func (g *GossipManager) onRecv(data *sentinel.GossipData, l log.Ctx) error {
 			l["at"] = "block process"
 			return err
 		}
+		g.OnImportedBlock(block)
 		block.Block.Body.Attestations.Range(func(idx int, a *solid.Attestation, total int) bool {
 			if err = g.forkChoice.OnAttestation(a, true); err != nil {
 				return false
@@ -120,7 +133,7 @@ func (g *GossipManager) onRecv(data *sentinel.GossipData, l log.Ctx) error {
 			return err
 		}
 		// Now check the head
-		headRoot, headSlot, err := g.forkChoice.GetHead()
+		headRoot, headSlot, err := g.forkChoice.UpdateHead()
 		if err != nil {
 			l["slot"] = block.Block.Slot
 			l["at"] = "fetch head data"
@@ -151,6 +164,11 @@ func (g *GossipManager) onRecv(data *sentinel.GossipData, l log.Ctx) error {
 			l["at"] = "decode exit"
 			return err
 		}
+		if err := g.forkChoice.OnVoluntaryExit(object.(*cltypes.SignedVoluntaryExit)); err != nil {
+			l["at"] = "on voluntary exit"
+			return err
+		}
+		g.emitter.Publish(beaconevents.TopicVoluntaryExit, object.(*cltypes.SignedVoluntaryExit))
 	case sentinel.GossipType_ProposerSlashingGossipType:
 		object = &cltypes.ProposerSlashing{}
 		if err := object.DecodeSSZ(data.Data, int(version)); err != nil {
@@ -169,13 +187,16 @@ func (g *GossipManager) onRecv(data *sentinel.GossipData, l log.Ctx) error {
 			l["at"] = "on attester slash"
 			return err
 		}
+	case sentinel.GossipType_BlobSidecarType:
+		return g.onBlobSidecar(data, version, l)
 	case sentinel.GossipType_AggregateAndProofGossipType:
-		object = &cltypes.SignedAggregateAndProof{}
-		if err := object.DecodeSSZ(data.Data, int(version)); err != nil {
-			l["at"] = "decoding proof"
-			g.sentinel.BanPeer(g.ctx, data.Peer)
-			return err
-		}
+		return g.onAggregateAndProof(data, version, l)
+	case gossip.AttestationGossipType:
+		return g.onAttestation(data, version, l)
+	case gossip.SyncCommitteeGossipType:
+		return g.onSyncCommitteeMessage(data, version, l)
+	case gossip.SyncCommitteeContributionAndProofGossipType:
+		return g.onContributionAndProof(data, version, l)
 	}
 	return nil
 }
@@ -186,6 +207,8 @@ func (g *GossipManager) Start() {
 		return
 	}
 
+	go g.pendingAttestationsLoop()
+
 	l := log.Ctx{}
 	for {
 		data, err := subscription.Recv()
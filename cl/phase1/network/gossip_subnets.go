@@ -0,0 +1,236 @@
+package network
+
+import (
+	"fmt"
+	"math/bits"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/gossip"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+const (
+	// attestationPropagationSlotRange and attestationSubnetCount are ATTESTATION_PROPAGATION_SLOT_RANGE and
+	// ATTESTATION_SUBNET_COUNT, which are the same on every network.
+	attestationPropagationSlotRange = 32
+	attestationSubnetCount          = 64
+	// maxPendingAttestations bounds the attestations of the current slot waiting for the next one.
+	maxPendingAttestations = 1 << 14
+)
+
+// bitlistParticipants counts the bits set in an SSZ bitlist, leaving out its length delimiter.
+func bitlistParticipants(bitlist []byte) int {
+	count := 0
+	for _, b := range bitlist {
+		count += bits.OnesCount8(b)
+	}
+	if count > 0 {
+		count--
+	}
+	return count
+}
+
+// validateAttestationSlot ignores attestations which are from the future or too old to be propagated.
+func (g *GossipManager) validateAttestationSlot(slot uint64) error {
+	currentSlot := utils.GetCurrentSlot(g.genesisConfig.GenesisTime, g.beaconConfig.SecondsPerSlot)
+	if slot > currentSlot || slot+attestationPropagationSlotRange < currentSlot {
+		return fmt.Errorf("attestation slot %d is out of the propagation range at slot %d", slot, currentSlot)
+	}
+	return nil
+}
+
+// onAttestation validates an attestation received on an attestation subnet and feeds it to fork choice.
+func (g *GossipManager) onAttestation(data *sentinel.GossipData, version clparams.StateVersion, l log.Ctx) error {
+	attestation := &solid.Attestation{}
+	if err := attestation.DecodeSSZ(common.CopyBytes(data.Data), int(version)); err != nil {
+		g.sentinel.BanPeer(g.ctx, data.Peer)
+		l["at"] = "decoding attestation"
+		return err
+	}
+	attestationData := attestation.AttestantionData()
+	l["slot"] = attestationData.Slot()
+	if data.BlobIndex == nil {
+		l["at"] = "attestation subnet"
+		return fmt.Errorf("attestation received on no subnet")
+	}
+	if err := g.validateAttestationSlot(attestationData.Slot()); err != nil {
+		l["at"] = "attestation slot"
+		return err
+	}
+	if participants := bitlistParticipants(attestation.AggregationBits()); participants != 1 {
+		g.sentinel.BanPeer(g.ctx, data.Peer)
+		l["at"] = "attestation participants"
+		return fmt.Errorf("unaggregated attestation has %d participants", participants)
+	}
+	target := attestationData.Target()
+	if target.Epoch() != attestationData.Slot()/g.beaconConfig.SlotsPerEpoch {
+		l["at"] = "attestation target"
+		return fmt.Errorf("attestation target epoch %d does not match its slot", target.Epoch())
+	}
+	committeesPerSlot, err := g.forkChoice.CommitteesPerSlot(target)
+	if err != nil {
+		l["at"] = "attestation committees"
+		return err
+	}
+	// the committee index of the attestation data is named ValidatorIndex
+	committeeIndex := attestationData.ValidatorIndex()
+	if committeeIndex >= committeesPerSlot {
+		l["at"] = "attestation committee"
+		return fmt.Errorf("committee index %d is out of range, there are %d committees per slot", committeeIndex, committeesPerSlot)
+	}
+	subnet := gossip.ComputeSubnetForAttestation(committeesPerSlot, attestationData.Slot(), committeeIndex, g.beaconConfig.SlotsPerEpoch, attestationSubnetCount)
+	if subnet != uint64(*data.BlobIndex) {
+		l["at"] = "attestation subnet"
+		return fmt.Errorf("attestation belongs to subnet %d, received on %d", subnet, *data.BlobIndex)
+	}
+	// attestations only count for fork choice from the slot after theirs
+	if currentSlot := utils.GetCurrentSlot(g.genesisConfig.GenesisTime, g.beaconConfig.SecondsPerSlot); attestationData.Slot() >= currentSlot {
+		g.addPendingAttestation(attestation)
+		return nil
+	}
+	if err := g.forkChoice.OnAttestation(attestation, false); err != nil {
+		l["at"] = "attestation process"
+		return err
+	}
+	return nil
+}
+
+func (g *GossipManager) addPendingAttestation(attestation *solid.Attestation) {
+	g.pendingAttestationsMu.Lock()
+	defer g.pendingAttestationsMu.Unlock()
+	if len(g.pendingAttestations) >= maxPendingAttestations {
+		return
+	}
+	g.pendingAttestations = append(g.pendingAttestations, attestation)
+}
+
+// processPendingAttestations feeds to fork choice the attestations which were waiting for their slot to be over.
+func (g *GossipManager) processPendingAttestations() {
+	currentSlot := utils.GetCurrentSlot(g.genesisConfig.GenesisTime, g.beaconConfig.SecondsPerSlot)
+	g.pendingAttestationsMu.Lock()
+	var ready []*solid.Attestation
+	pending := g.pendingAttestations[:0]
+	for _, attestation := range g.pendingAttestations {
+		if attestation.AttestantionData().Slot() < currentSlot {
+			ready = append(ready, attestation)
+		} else {
+			pending = append(pending, attestation)
+		}
+	}
+	g.pendingAttestations = pending
+	g.pendingAttestationsMu.Unlock()
+
+	for _, attestation := range ready {
+		if err := g.forkChoice.OnAttestation(attestation, false); err != nil {
+			log.Trace("[Beacon Gossip] Pending attestation rejected", "slot", attestation.AttestantionData().Slot(), "err", err)
+		}
+	}
+}
+
+// pendingAttestationsLoop processes the pending attestations once their slot is over.
+func (g *GossipManager) pendingAttestationsLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	lastSlot := utils.GetCurrentSlot(g.genesisConfig.GenesisTime, g.beaconConfig.SecondsPerSlot)
+	for {
+		select {
+		case <-ticker.C:
+			if currentSlot := utils.GetCurrentSlot(g.genesisConfig.GenesisTime, g.beaconConfig.SecondsPerSlot); currentSlot != lastSlot {
+				lastSlot = currentSlot
+				g.processPendingAttestations()
+			}
+		case <-g.ctx.Done():
+			return
+		}
+	}
+}
+
+// onAggregateAndProof feeds the aggregate of an aggregate and proof to fork choice.
+func (g *GossipManager) onAggregateAndProof(data *sentinel.GossipData, version clparams.StateVersion, l log.Ctx) error {
+	aggregateAndProof := &cltypes.SignedAggregateAndProof{}
+	if err := aggregateAndProof.DecodeSSZ(common.CopyBytes(data.Data), int(version)); err != nil {
+		l["at"] = "decoding proof"
+		g.sentinel.BanPeer(g.ctx, data.Peer)
+		return err
+	}
+	aggregate := aggregateAndProof.Message.Aggregate
+	l["slot"] = aggregate.AttestantionData().Slot()
+	if err := g.validateAttestationSlot(aggregate.AttestantionData().Slot()); err != nil {
+		l["at"] = "aggregate slot"
+		return err
+	}
+	if aggregate.AttestantionData().Slot() >= utils.GetCurrentSlot(g.genesisConfig.GenesisTime, g.beaconConfig.SecondsPerSlot) {
+		g.addPendingAttestation(aggregate)
+		return nil
+	}
+	if err := g.forkChoice.OnAttestation(aggregate, false); err != nil {
+		l["at"] = "aggregate process"
+		return err
+	}
+	return nil
+}
+
+// validateSyncCommitteeSlot ignores sync committee messages and contributions which are not for the current slot.
+func (g *GossipManager) validateSyncCommitteeSlot(slot uint64) error {
+	if currentSlot := utils.GetCurrentSlot(g.genesisConfig.GenesisTime, g.beaconConfig.SecondsPerSlot); slot != currentSlot {
+		return fmt.Errorf("sync committee slot %d is not the current slot %d", slot, currentSlot)
+	}
+	return nil
+}
+
+// onSyncCommitteeMessage validates a sync committee message. Caplin does not produce blocks, so it has no use for
+// them yet, they are only checked so that peers sending malformed ones get banned.
+func (g *GossipManager) onSyncCommitteeMessage(data *sentinel.GossipData, version clparams.StateVersion, l log.Ctx) error {
+	msg := &cltypes.SyncCommitteeMessage{}
+	if err := msg.DecodeSSZ(common.CopyBytes(data.Data), int(version)); err != nil {
+		g.sentinel.BanPeer(g.ctx, data.Peer)
+		l["at"] = "decoding sync committee message"
+		return err
+	}
+	l["slot"] = msg.Slot
+	if data.BlobIndex == nil || uint64(*data.BlobIndex) >= g.beaconConfig.SyncCommitteeSubnetCount {
+		l["at"] = "sync committee subnet"
+		return fmt.Errorf("sync committee message received on an unknown subnet")
+	}
+	if err := g.validateSyncCommitteeSlot(msg.Slot); err != nil {
+		l["at"] = "sync committee slot"
+		return err
+	}
+	return nil
+}
+
+// onContributionAndProof validates a sync committee contribution and notifies the event subscribers of it.
+func (g *GossipManager) onContributionAndProof(data *sentinel.GossipData, version clparams.StateVersion, l log.Ctx) error {
+	signed := &cltypes.SignedContributionAndProof{}
+	if err := signed.DecodeSSZ(common.CopyBytes(data.Data), int(version)); err != nil {
+		g.sentinel.BanPeer(g.ctx, data.Peer)
+		l["at"] = "decoding contribution"
+		return err
+	}
+	contribution := signed.Message.Contribution
+	l["slot"] = contribution.Slot
+	if err := g.validateSyncCommitteeSlot(contribution.Slot); err != nil {
+		l["at"] = "contribution slot"
+		return err
+	}
+	if contribution.SubcommitteeIndex >= g.beaconConfig.SyncCommitteeSubnetCount {
+		g.sentinel.BanPeer(g.ctx, data.Peer)
+		l["at"] = "contribution subcommittee"
+		return fmt.Errorf("subcommittee index %d is out of range", contribution.SubcommitteeIndex)
+	}
+	if contribution.ParticipantsCount() == 0 {
+		g.sentinel.BanPeer(g.ctx, data.Peer)
+		l["at"] = "contribution participants"
+		return fmt.Errorf("contribution has no participants")
+	}
+	g.emitter.Publish(beaconevents.TopicContributionAndProof, signed)
+	return nil
+}
@@ -115,12 +115,13 @@ func startDownloadService(s *stagedsync.StageState, cfg StageForkChoiceCfg) {
 				log.Warn("Could not download block", "reason", err, "slot", block.Block.Slot)
 				return highestSlotProcessed, libcommon.Hash{}, err
 			}
+			cfg.gossipManager.OnImportedBlock(block)
 			highestSlotProcessed = utils.Max64(block.Block.Slot, highestSlotProcessed)
 			if sendForckchoice {
 				var m runtime.MemStats
 				dbg.ReadMemStats(&m)
 				// Import the head
-				headRoot, headSlot, err := cfg.forkChoice.GetHead()
+				headRoot, headSlot, err := cfg.forkChoice.UpdateHead()
 
 				log.Debug("New block imported",
 					"slot", block.Block.Slot,
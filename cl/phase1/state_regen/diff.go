@@ -0,0 +1,102 @@
+package state_regen
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// fieldDiff diffs the SSZ encodings of two states field by field, given the offsets of their variable size fields.
+// A list growing, such as the eth1 data votes, shifts the encoding of the fields after it, which a diff of the whole
+// encodings would not survive: diffing each field against the same field of base keeps the validators and balances
+// aligned, so the result is mostly zeros and compresses well. The offsets of both encodings are written first, then
+// the xorDiff of each field.
+func fieldDiff(base []byte, baseOffsets []int, target []byte, targetOffsets []int) []byte {
+	out := make([]byte, 0, 8+4*(len(baseOffsets)+len(targetOffsets))+len(target))
+	for _, offsets := range [][]int{baseOffsets, targetOffsets} {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(offsets)))
+		for _, offset := range offsets {
+			out = binary.BigEndian.AppendUint32(out, uint32(offset))
+		}
+	}
+	baseFields, targetFields := splitFields(base, baseOffsets), splitFields(target, targetOffsets)
+	for i, field := range targetFields {
+		var baseField []byte
+		if i < len(baseFields) {
+			baseField = baseFields[i]
+		}
+		out = append(out, xorDiff(baseField, field)...)
+	}
+	return out
+}
+
+// applyFieldDiff rebuilds the target encoding of a fieldDiff against base.
+func applyFieldDiff(base, diff []byte) ([]byte, error) {
+	baseOffsets, diff, err := readOffsets(diff, len(base))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base offsets: %w", err)
+	}
+	targetOffsets, diff, err := readOffsets(diff, len(diff))
+	if err == nil && len(targetOffsets) > 0 && targetOffsets[len(targetOffsets)-1] > len(diff) {
+		err = fmt.Errorf("offset %d past the end", targetOffsets[len(targetOffsets)-1])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid target offsets: %w", err)
+	}
+	baseFields := splitFields(base, baseOffsets)
+	target := make([]byte, 0, len(diff))
+	for i, field := range splitFields(diff, targetOffsets) {
+		var baseField []byte
+		if i < len(baseFields) {
+			baseField = baseFields[i]
+		}
+		target = append(target, xorDiff(baseField, field)...)
+	}
+	return target, nil
+}
+
+// readOffsets reads the offsets written by fieldDiff for an encoding of the given length.
+func readOffsets(diff []byte, length int) ([]int, []byte, error) {
+	if len(diff) < 4 {
+		return nil, nil, fmt.Errorf("diff is too short")
+	}
+	count := binary.BigEndian.Uint32(diff)
+	diff = diff[4:]
+	if uint64(len(diff)) < 4*uint64(count) {
+		return nil, nil, fmt.Errorf("diff is too short for %d offsets", count)
+	}
+	offsets := make([]int, count)
+	for i := range offsets {
+		offsets[i] = int(binary.BigEndian.Uint32(diff[4*i:]))
+		if (i > 0 && offsets[i] < offsets[i-1]) || offsets[i] > length {
+			return nil, nil, fmt.Errorf("offset %d out of order", offsets[i])
+		}
+	}
+	return offsets, diff[4*count:], nil
+}
+
+// splitFields splits an encoding at the given offsets: its static part, then each of its variable size fields.
+func splitFields(encoded []byte, offsets []int) [][]byte {
+	fields := make([][]byte, 0, len(offsets)+1)
+	start := 0
+	for _, offset := range offsets {
+		fields = append(fields, encoded[start:offset])
+		start = offset
+	}
+	return append(fields, encoded[start:])
+}
+
+// xorDiff returns a buffer of the length of target holding the XOR of target and base over their common length,
+// followed by the remainder of target. Applying it to base again yields target:
+// xorDiff(base, xorDiff(base, target)) == target.
+func xorDiff(base, target []byte) []byte {
+	out := make([]byte, len(target))
+	n := len(base)
+	if len(target) < n {
+		n = len(target)
+	}
+	for i := 0; i < n; i++ {
+		out[i] = base[i] ^ target[i]
+	}
+	copy(out[n:], target[n:])
+	return out
+}
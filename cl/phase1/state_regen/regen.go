@@ -0,0 +1,493 @@
+// Package state_regen archives the finalized chain on disk and regenerates the beacon state of any archived slot.
+//
+// The state at the last slot of every epoch is stored, as a full snapshot every snapshotInterval slots and as a
+// field by field diff against the previous snapshot otherwise, along with the finalized blocks. The state of a slot is rebuilt
+// from the closest stored state before it by replaying the blocks in between.
+package state_regen
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/core"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// the objects the archive is made of
+const (
+	freezerNamespace       = "caplin_regen"
+	freezerStateObject     = "beaconState"
+	freezerBlockObject     = "signedBeaconBlock"
+	freezerStateRootObject = "stateRoot"
+	freezerMetadataObject  = "metadata"
+	metadataId             = "archive"
+)
+
+// a stored state starts with its kind, diffs are followed by the slot of the snapshot they apply to.
+const (
+	kindSnapshot byte = iota
+	kindDiff
+)
+
+// segment is a range of slots whose states can be regenerated: a state is stored at From and every block up to To
+// was archived. Blocks missed by the archive, after a restart far behind the chain for example, start a new segment.
+type segment struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+type metadata struct {
+	Segments []segment      `json:"segments"`
+	HeadRoot libcommon.Hash `json:"headRoot"`
+}
+
+type snapshot struct {
+	slot    uint64
+	encoded []byte
+}
+
+// Regenerator archives the finalized blocks and states and regenerates the states of the archived slots.
+type Regenerator struct {
+	f                freezer.Freezer
+	beaconConfig     *clparams.BeaconChainConfig
+	snapshotInterval uint64
+
+	mu          sync.RWMutex
+	meta        metadata
+	checkpoints []uint64 // sorted slots of the stored states
+
+	// snapshot diffs were last applied to, shared by the readers
+	cacheMu sync.Mutex
+	cache   *snapshot
+
+	// archiving state, only used by the archiving goroutine
+	head         *state.BeaconState
+	headRoot     libcommon.Hash
+	lastSnapshot *snapshot
+}
+
+// NewRegenerator creates a regenerator archiving into f, with a full snapshot at most every snapshotInterval slots.
+func NewRegenerator(f freezer.Freezer, beaconConfig *clparams.BeaconChainConfig, snapshotInterval uint64) *Regenerator {
+	if snapshotInterval == 0 {
+		snapshotInterval = beaconConfig.SlotsPerEpoch
+	}
+	return &Regenerator{f: f, beaconConfig: beaconConfig, snapshotInterval: snapshotInterval}
+}
+
+// Init loads the archive and resumes it after its last archived block, or starts a new segment from the anchor
+// state if the archive does not reach it.
+func (r *Regenerator) Init(anchor *state.BeaconState) error {
+	lister, ok := r.f.(freezer.Lister)
+	if !ok {
+		return fmt.Errorf("state regeneration needs a freezer able to list its objects")
+	}
+	data, err := r.readRaw(freezerMetadataObject, metadataId)
+	if err != nil {
+		return err
+	}
+	var meta metadata
+	if data != nil {
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return fmt.Errorf("invalid archive metadata: %w", err)
+		}
+	}
+	ids, err := lister.List(freezerNamespace, freezerStateObject)
+	if err != nil {
+		return err
+	}
+	checkpoints := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if slot, err := strconv.ParseUint(id, 10, 64); err == nil {
+			checkpoints = append(checkpoints, slot)
+		}
+	}
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i] < checkpoints[j] })
+
+	r.mu.Lock()
+	r.meta, r.checkpoints = meta, checkpoints
+	r.mu.Unlock()
+
+	if len(meta.Segments) == 0 || meta.Segments[len(meta.Segments)-1].To < anchor.Slot() {
+		s, err := anchor.Copy()
+		if err != nil {
+			return err
+		}
+		return r.rebase(s)
+	}
+	return r.resume()
+}
+
+// resume restores the archiving state at the end of the last segment.
+func (r *Regenerator) resume() error {
+	r.mu.RLock()
+	last := r.meta.Segments[len(r.meta.Segments)-1]
+	checkpoint, found := r.closestCheckpoint(last, last.To)
+	headRoot := r.meta.HeadRoot
+	r.mu.RUnlock()
+	if !found {
+		return fmt.Errorf("no state archived between slots %d and %d", last.From, last.To)
+	}
+	s, err := r.State(last.To)
+	if err != nil {
+		return err
+	}
+	kind, data, err := r.readCheckpoint(checkpoint)
+	if err != nil {
+		return err
+	}
+	if kind == kindSnapshot {
+		r.lastSnapshot = &snapshot{slot: checkpoint, encoded: data}
+	} else {
+		snapshotSlot := binary.BigEndian.Uint64(data)
+		encoded, err := r.readSnapshot(snapshotSlot)
+		if err != nil {
+			return err
+		}
+		r.lastSnapshot = &snapshot{slot: snapshotSlot, encoded: encoded}
+	}
+	r.head, r.headRoot = s, headRoot
+	return nil
+}
+
+// rebase starts a new segment from the given state, which the regenerator takes ownership of.
+func (r *Regenerator) rebase(s *state.BeaconState) error {
+	root, err := core.CheckpointBlockRoot(s)
+	if err != nil {
+		return err
+	}
+	stateRoot, err := s.HashSSZ()
+	if err != nil {
+		return err
+	}
+	r.lastSnapshot = nil
+	if err := r.storeCheckpoint(s); err != nil {
+		return err
+	}
+	if err := r.putStateRoot(stateRoot, s.Slot()); err != nil {
+		return err
+	}
+	r.head, r.headRoot = s, root
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// segments are in slot order, the new one supersedes what the previous ones archived after its start
+	segments := r.meta.Segments[:0]
+	for _, seg := range r.meta.Segments {
+		if seg.From >= s.Slot() {
+			continue
+		}
+		if seg.To >= s.Slot() {
+			seg.To = s.Slot() - 1
+		}
+		segments = append(segments, seg)
+	}
+	r.meta.Segments = append(segments, segment{From: s.Slot(), To: s.Slot()})
+	r.meta.HeadRoot = root
+	return r.writeMetadata()
+}
+
+// archiveBlock applies the next finalized block to the archiving state, storing the states of the epochs ending
+// before it.
+func (r *Regenerator) archiveBlock(block *cltypes.SignedBeaconBlock) error {
+	if block.Block.ParentRoot != r.headRoot {
+		return fmt.Errorf("block of slot %d does not extend the archived chain", block.Block.Slot)
+	}
+	for next := r.nextCheckpointSlot(r.head.Slot()); next < block.Block.Slot; next = r.nextCheckpointSlot(next) {
+		if err := transition.ProcessSlots(r.head, next); err != nil {
+			return err
+		}
+		if err := r.storeCheckpoint(r.head); err != nil {
+			return err
+		}
+	}
+	if err := transition.TransitionState(r.head, block, false); err != nil {
+		return fmt.Errorf("cannot apply block of slot %d: %w", block.Block.Slot, err)
+	}
+	if err := freezer.PutObjectSSZIntoFreezer(freezerBlockObject, freezerNamespace, block.Block.Slot, block, r.f); err != nil {
+		return err
+	}
+	if err := r.putStateRoot(block.Block.StateRoot, block.Block.Slot); err != nil {
+		return err
+	}
+	if (block.Block.Slot+1)%r.beaconConfig.SlotsPerEpoch == 0 {
+		if err := r.storeCheckpoint(r.head); err != nil {
+			return err
+		}
+	}
+	root, err := block.Block.HashSSZ()
+	if err != nil {
+		return err
+	}
+	r.headRoot = root
+	return nil
+}
+
+// commit makes the archived blocks up to the given slot available, the states of the slots following the last
+// block are the states of the empty slots leading to it.
+func (r *Regenerator) commit(slot uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := &r.meta.Segments[len(r.meta.Segments)-1]
+	if slot > last.To {
+		last.To = slot
+	}
+	r.meta.HeadRoot = r.headRoot
+	return r.writeMetadata()
+}
+
+// nextCheckpointSlot is the first slot after the given one which ends an epoch.
+func (r *Regenerator) nextCheckpointSlot(slot uint64) uint64 {
+	next := (slot/r.beaconConfig.SlotsPerEpoch+1)*r.beaconConfig.SlotsPerEpoch - 1
+	if next == slot {
+		next += r.beaconConfig.SlotsPerEpoch
+	}
+	return next
+}
+
+func (r *Regenerator) storeCheckpoint(s *state.BeaconState) error {
+	encoded, err := s.EncodeSSZ(nil)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if diff, ok := r.diffAgainstSnapshot(s, encoded); ok {
+		data = make([]byte, 9)
+		data[0] = kindDiff
+		binary.BigEndian.PutUint64(data[1:], r.lastSnapshot.slot)
+		data = append(data, utils.CompressSnappy(diff)...)
+	} else {
+		data = append([]byte{kindSnapshot}, utils.CompressSnappy(encoded)...)
+		r.lastSnapshot = &snapshot{slot: s.Slot(), encoded: encoded}
+	}
+	if err := freezer.NewBlobStore(r.f).Put(data, freezerNamespace, freezerStateObject, strconv.FormatUint(s.Slot(), 10)); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := sort.Search(len(r.checkpoints), func(i int) bool { return r.checkpoints[i] >= s.Slot() })
+	if i == len(r.checkpoints) || r.checkpoints[i] != s.Slot() {
+		r.checkpoints = append(r.checkpoints, 0)
+		copy(r.checkpoints[i+1:], r.checkpoints[i:])
+		r.checkpoints[i] = s.Slot()
+	}
+	return nil
+}
+
+// diffAgainstSnapshot diffs the encoding of the state against the last snapshot, unless a new snapshot is due or
+// the snapshot is of another fork, whose fields the schema of the state cannot tell.
+func (r *Regenerator) diffAgainstSnapshot(s *state.BeaconState, encoded []byte) ([]byte, bool) {
+	if r.lastSnapshot == nil || s.Slot()-r.lastSnapshot.slot >= r.snapshotInterval {
+		return nil, false
+	}
+	baseOffsets, err := s.DynamicOffsetsSSZ(r.lastSnapshot.encoded)
+	if err != nil {
+		return nil, false
+	}
+	offsets, err := s.DynamicOffsetsSSZ(encoded)
+	if err != nil {
+		return nil, false
+	}
+	return fieldDiff(r.lastSnapshot.encoded, baseOffsets, encoded, offsets), true
+}
+
+func (r *Regenerator) putStateRoot(stateRoot libcommon.Hash, slot uint64) error {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, slot)
+	return freezer.NewBlobStore(r.f).Put(data, freezerNamespace, freezerStateRootObject, stateRoot.String())
+}
+
+// writeMetadata must be called with mu held.
+func (r *Regenerator) writeMetadata() error {
+	data, err := json.Marshal(r.meta)
+	if err != nil {
+		return err
+	}
+	return freezer.NewBlobStore(r.f).Put(data, freezerNamespace, freezerMetadataObject, metadataId)
+}
+
+// State regenerates the state at the given slot, nil if the slot was not archived.
+func (r *Regenerator) State(slot uint64) (*state.BeaconState, error) {
+	r.mu.RLock()
+	seg, ok := r.segmentOf(slot)
+	checkpoint, found := r.closestCheckpoint(seg, slot)
+	r.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	if !found {
+		return nil, fmt.Errorf("no state archived between slots %d and %d", seg.From, slot)
+	}
+	encoded, err := r.readState(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	s, err := core.DecodeBeaconState(r.beaconConfig, encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archived state of slot %d: %w", checkpoint, err)
+	}
+	for current := checkpoint + 1; current <= slot; current++ {
+		block, err := r.readBlock(current)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			continue
+		}
+		parentRoot, err := core.CheckpointBlockRoot(s)
+		if err != nil {
+			return nil, err
+		}
+		if block.Block.ParentRoot != parentRoot {
+			return nil, fmt.Errorf("archived block of slot %d does not extend the archived chain", current)
+		}
+		if err := transition.TransitionState(s, block, false); err != nil {
+			return nil, fmt.Errorf("cannot apply archived block of slot %d: %w", current, err)
+		}
+	}
+	if s.Slot() < slot {
+		if err := transition.ProcessSlots(s, slot); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// StateByRoot regenerates the state with the given root, nil if it is not the post-state of an archived block.
+func (r *Regenerator) StateByRoot(stateRoot libcommon.Hash) (*state.BeaconState, error) {
+	data, err := r.readRaw(freezerStateRootObject, stateRoot.String())
+	if err != nil || data == nil {
+		return nil, err
+	}
+	if len(data) != 8 {
+		return nil, fmt.Errorf("invalid archived slot of state %x", stateRoot)
+	}
+	return r.State(binary.BigEndian.Uint64(data))
+}
+
+// segmentOf must be called with mu held.
+func (r *Regenerator) segmentOf(slot uint64) (segment, bool) {
+	for _, seg := range r.meta.Segments {
+		if seg.From <= slot && slot <= seg.To {
+			return seg, true
+		}
+	}
+	return segment{}, false
+}
+
+// closestCheckpoint returns the highest slot of a state stored within the segment and not after the given slot.
+// It must be called with mu held.
+func (r *Regenerator) closestCheckpoint(seg segment, slot uint64) (uint64, bool) {
+	i := sort.Search(len(r.checkpoints), func(i int) bool { return r.checkpoints[i] > slot })
+	if i == 0 || r.checkpoints[i-1] < seg.From {
+		return 0, false
+	}
+	return r.checkpoints[i-1], true
+}
+
+// readState reads the SSZ encoding of the state stored at the given slot.
+func (r *Regenerator) readState(slot uint64) ([]byte, error) {
+	kind, data, err := r.readCheckpoint(slot)
+	if err != nil || kind == kindSnapshot {
+		return data, err
+	}
+	snapshotSlot := binary.BigEndian.Uint64(data)
+	base, err := r.readSnapshot(snapshotSlot)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := applyFieldDiff(base, data[8:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid archived diff of slot %d: %w", slot, err)
+	}
+	return encoded, nil
+}
+
+// readSnapshot reads the encoding of the snapshot at the given slot through the cache, it must not be modified.
+func (r *Regenerator) readSnapshot(slot uint64) ([]byte, error) {
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	if r.cache != nil && r.cache.slot == slot {
+		return r.cache.encoded, nil
+	}
+	kind, data, err := r.readCheckpoint(slot)
+	if err != nil {
+		return nil, err
+	}
+	if kind != kindSnapshot {
+		return nil, fmt.Errorf("archived state of slot %d is not a snapshot", slot)
+	}
+	r.cache = &snapshot{slot: slot, encoded: data}
+	return data, nil
+}
+
+// readCheckpoint reads the state stored at the given slot: the decompressed encoding of a snapshot, or the slot of
+// its snapshot followed by the decompressed diff.
+func (r *Regenerator) readCheckpoint(slot uint64) (byte, []byte, error) {
+	data, err := r.readRaw(freezerStateObject, strconv.FormatUint(slot, 10))
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) == 0 {
+		return 0, nil, fmt.Errorf("archived state of slot %d is missing", slot)
+	}
+	switch data[0] {
+	case kindSnapshot:
+		encoded, err := utils.DecompressSnappy(data[1:])
+		return kindSnapshot, encoded, err
+	case kindDiff:
+		if len(data) < 9 {
+			return 0, nil, fmt.Errorf("invalid archived diff of slot %d", slot)
+		}
+		diff, err := utils.DecompressSnappy(data[9:])
+		if err != nil {
+			return 0, nil, err
+		}
+		return kindDiff, append(data[1:9:9], diff...), nil
+	default:
+		return 0, nil, fmt.Errorf("archived state of slot %d has unknown kind %d", slot, data[0])
+	}
+}
+
+func (r *Regenerator) readBlock(slot uint64) (*cltypes.SignedBeaconBlock, error) {
+	data, err := r.readObject(freezerBlockObject, strconv.FormatUint(slot, 10))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	block, err := core.DecodeBeaconBlock(r.beaconConfig, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archived block of slot %d: %w", slot, err)
+	}
+	return block, nil
+}
+
+// readObject reads a snappy compressed object, nil if it was not archived.
+func (r *Regenerator) readObject(object, id string) ([]byte, error) {
+	data, err := r.readRaw(object, id)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return utils.DecompressSnappy(data)
+}
+
+// readRaw reads an object as it was put, nil if it was not archived.
+func (r *Regenerator) readRaw(object, id string) ([]byte, error) {
+	data, err := freezer.NewBlobStore(r.f).Get(freezerNamespace, object, id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
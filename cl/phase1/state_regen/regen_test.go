@@ -0,0 +1,169 @@
+package state_regen
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+const testData = "../forkchoice/fork_graph/test_data/"
+
+// testChain returns the genesis state and its blocks of slots 1 and 33.
+func testChain(t *testing.T) (*state.BeaconState, []*cltypes.SignedBeaconBlock) {
+	read := func(name string) []byte {
+		data, err := os.ReadFile(testData + name)
+		require.NoError(t, err)
+		return data
+	}
+	anchor := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(anchor, read("anchor_state.ssz_snappy"), int(clparams.Phase0Version)))
+	var blocks []*cltypes.SignedBeaconBlock
+	for _, name := range []string{
+		"block_0xe2a37a22d208ebe969c50e9d44bb3f1f63c5404787b9c214a5f2f28fb9835feb.ssz_snappy",
+		"block_0xbf1a9ba2d349f6b5a5095bff40bd103ae39177e36018fb1f589953b9eeb0ca9d.ssz_snappy",
+	} {
+		block := &cltypes.SignedBeaconBlock{}
+		require.NoError(t, utils.DecodeSSZSnappy(block, read(name), int(clparams.Phase0Version)))
+		blocks = append(blocks, block)
+	}
+	return anchor, blocks
+}
+
+func TestXorDiff(t *testing.T) {
+	for _, test := range []struct{ base, target []byte }{
+		{[]byte{1, 2, 3}, []byte{1, 2, 4}},
+		{[]byte{1, 2, 3}, []byte{1, 2, 3, 4, 5}},
+		{[]byte{1, 2, 3, 4, 5}, []byte{1, 2}},
+		{nil, []byte{1}},
+		{[]byte{1}, nil},
+	} {
+		diff := xorDiff(test.base, test.target)
+		require.Len(t, diff, len(test.target))
+		require.Equal(t, test.target, append([]byte{}, xorDiff(test.base, diff)...))
+	}
+	require.Equal(t, []byte{0, 0, 7}, xorDiff([]byte{1, 2, 3}, []byte{1, 2, 4}))
+}
+
+func TestFieldDiff(t *testing.T) {
+	// a static part of 3 bytes followed by two fields, the first of which grows
+	base, baseOffsets := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}, []int{3, 5}
+	target, targetOffsets := []byte{1, 2, 4, 4, 5, 0, 6, 7, 8, 9, 10}, []int{3, 6}
+	diff := fieldDiff(base, baseOffsets, target, targetOffsets)
+	// the second field is still aligned with its base
+	require.Equal(t, []byte{0, 0, 7, 0, 0, 0, 0, 0, 0, 0, 10}, diff[len(diff)-len(target):])
+	encoded, err := applyFieldDiff(base, diff)
+	require.NoError(t, err)
+	require.Equal(t, target, encoded)
+
+	// fields missing from the base are stored as they are
+	diff = fieldDiff(base[:3], nil, target, targetOffsets)
+	encoded, err = applyFieldDiff(base[:3], diff)
+	require.NoError(t, err)
+	require.Equal(t, target, encoded)
+
+	_, err = applyFieldDiff(base[:4], fieldDiff(base, baseOffsets, target, targetOffsets))
+	require.Error(t, err)
+	_, err = applyFieldDiff(base, []byte{0, 0, 0, 1})
+	require.Error(t, err)
+}
+
+func TestRegenerate(t *testing.T) {
+	anchor, blocks := testChain(t)
+	f := &freezer.InMemory{}
+	// the state of slot 31 is stored as a diff against the genesis snapshot
+	r := NewRegenerator(f, &clparams.MainnetBeaconConfig, 64)
+	require.NoError(t, r.Init(anchor))
+	for _, block := range blocks {
+		require.NoError(t, r.archiveBlock(block))
+	}
+	require.Error(t, r.archiveBlock(blocks[0]))
+	require.NoError(t, r.commit(64))
+	require.Equal(t, []uint64{0, 31}, r.checkpoints)
+	kind, _, err := r.readCheckpoint(31)
+	require.NoError(t, err)
+	require.Equal(t, kindDiff, kind)
+
+	check := func(r *Regenerator) {
+		s, err := r.State(0)
+		require.NoError(t, err)
+		expected, err := anchor.HashSSZ()
+		require.NoError(t, err)
+		root, err := s.HashSSZ()
+		require.NoError(t, err)
+		require.Equal(t, expected, root)
+
+		for _, block := range blocks {
+			s, err := r.State(block.Block.Slot)
+			require.NoError(t, err)
+			root, err := s.HashSSZ()
+			require.NoError(t, err)
+			require.Equal(t, block.Block.StateRoot, root)
+
+			s, err = r.StateByRoot(block.Block.StateRoot)
+			require.NoError(t, err)
+			require.Equal(t, block.Block.Slot, s.Slot())
+		}
+
+		for _, slot := range []uint64{2, 31, 32, 64} {
+			s, err := r.State(slot)
+			require.NoError(t, err)
+			require.Equal(t, slot, s.Slot())
+		}
+
+		s, err = r.State(65)
+		require.NoError(t, err)
+		require.Nil(t, s)
+		s, err = r.StateByRoot(blocks[0].Block.ParentRoot)
+		require.NoError(t, err)
+		require.Nil(t, s)
+	}
+	check(r)
+
+	// a restarted archive resumes after its last block
+	r = NewRegenerator(f, &clparams.MainnetBeaconConfig, 64)
+	require.NoError(t, r.Init(anchor))
+	require.Equal(t, uint64(64), r.head.Slot())
+	require.Equal(t, uint64(0), r.lastSnapshot.slot)
+	check(r)
+}
+
+func TestRebase(t *testing.T) {
+	anchor, blocks := testChain(t)
+	r := NewRegenerator(&freezer.InMemory{}, &clparams.MainnetBeaconConfig, 64)
+	require.NoError(t, r.Init(anchor))
+
+	// the archive missed the block of slot 1 and restarts from its post-state
+	s, err := anchor.Copy()
+	require.NoError(t, err)
+	require.NoError(t, transition.TransitionState(s, blocks[0], false))
+	require.NoError(t, r.rebase(s))
+	require.NoError(t, r.archiveBlock(blocks[1]))
+	require.NoError(t, r.commit(33))
+	require.Equal(t, []segment{{From: 0, To: 0}, {From: 1, To: 33}}, r.meta.Segments)
+
+	s, err = r.State(0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), s.Slot())
+	s, err = r.State(33)
+	require.NoError(t, err)
+	root, err := s.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, blocks[1].Block.StateRoot, root)
+
+	// restarting from an earlier state supersedes the later segments
+	s, err = anchor.Copy()
+	require.NoError(t, err)
+	require.NoError(t, r.rebase(s))
+	require.Equal(t, []segment{{From: 0, To: 0}}, r.meta.Segments)
+	s, err = r.State(33)
+	require.NoError(t, err)
+	require.Nil(t, s)
+}
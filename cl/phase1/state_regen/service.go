@@ -0,0 +1,118 @@
+package state_regen
+
+import (
+	"context"
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+)
+
+// subscriptionBufferSize is large enough for the blocks imported at once while catching up with the chain.
+const subscriptionBufferSize = 8192
+
+// Start initializes the archive from the anchor state and archives the blocks finalized by forkchoice in the
+// background until the context is cancelled.
+func (r *Regenerator) Start(ctx context.Context, anchor *state.BeaconState, forkChoice *forkchoice.ForkChoiceStore, emitter *beaconevents.Emitter) error {
+	if err := r.Init(anchor); err != nil {
+		return err
+	}
+	// subscribe before forkchoice imports any block so that none of them is missed
+	sub, unsubscribe := emitter.Subscribe(eventTopics, subscriptionBufferSize)
+	go r.run(ctx, sub, unsubscribe, forkChoice, emitter)
+	return nil
+}
+
+var eventTopics = []string{beaconevents.TopicBlock, beaconevents.TopicFinalizedCheckpoint}
+
+func (r *Regenerator) run(ctx context.Context, sub *beaconevents.Subscription, unsubscribe func(), forkChoice *forkchoice.ForkChoiceStore, emitter *beaconevents.Emitter) {
+	// the blocks imported by forkchoice which are not finalized yet, forkchoice prunes them before they are
+	pending := make(map[libcommon.Hash]*cltypes.SignedBeaconBlock)
+	for {
+		select {
+		case <-ctx.Done():
+			unsubscribe()
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// too slow to keep up with forkchoice, the missed blocks are made up for by a rebase
+				log.Warn("[State Regen] Fell behind the chain events, resubscribing")
+				sub, unsubscribe = emitter.Subscribe(eventTopics, subscriptionBufferSize)
+				continue
+			}
+			switch data := event.Data.(type) {
+			case *beaconevents.BlockData:
+				if block, ok := forkChoice.GetBlock(data.Block); ok {
+					pending[data.Block] = block
+				}
+			case *beaconevents.FinalizedCheckpointData:
+				if err := r.onFinalized(forkChoice, data, pending); err != nil {
+					log.Warn("[State Regen] Could not archive finalized blocks", "epoch", data.Epoch, "err", err)
+				}
+			}
+		}
+	}
+}
+
+// onFinalized archives the blocks up to the finalized checkpoint. The archive starts a new segment from the
+// finalized state when it misses blocks or cannot apply them.
+func (r *Regenerator) onFinalized(forkChoice *forkchoice.ForkChoiceStore, data *beaconevents.FinalizedCheckpointData, pending map[libcommon.Hash]*cltypes.SignedBeaconBlock) error {
+	epochStart := data.Epoch * r.beaconConfig.SlotsPerEpoch
+	defer func() {
+		for root, block := range pending {
+			if block.Block.Slot <= epochStart {
+				delete(pending, root)
+			}
+		}
+	}()
+
+	r.mu.RLock()
+	archived := r.meta.Segments[len(r.meta.Segments)-1].To
+	r.mu.RUnlock()
+	if epochStart <= archived {
+		return nil
+	}
+
+	var chain []*cltypes.SignedBeaconBlock
+	complete := true
+	for root := data.Block; root != r.headRoot; {
+		block, ok := pending[root]
+		if !ok {
+			block, ok = forkChoice.GetBlock(root)
+		}
+		if !ok || block.Block.Slot <= archived {
+			complete = false
+			break
+		}
+		chain = append(chain, block)
+		root = block.Block.ParentRoot
+	}
+	if complete {
+		var err error
+		for i := len(chain) - 1; i >= 0 && err == nil; i-- {
+			err = r.archiveBlock(chain[i])
+		}
+		if err == nil {
+			return r.commit(epochStart)
+		}
+		log.Warn("[State Regen] Could not archive finalized block", "err", err)
+	}
+
+	s, err := forkChoice.GetFullState(data.Block)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return fmt.Errorf("finalized state %x is not available", data.Block)
+	}
+	log.Info("[State Regen] Starting a new archive segment", "slot", s.Slot())
+	if err := r.rebase(s); err != nil {
+		return err
+	}
+	return r.commit(epochStart)
+}
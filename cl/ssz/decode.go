@@ -109,3 +109,43 @@ func UnmarshalSSZ(buf []byte, version int, schema ...interface{}) (err error) {
 
 	return
 }
+
+// DynamicOffsets returns the offsets of the dynamic components of the schema encoded in buf, without decoding them.
+// The static part of the encoding ends at the first one, and each component ends where the next one starts.
+func DynamicOffsets(buf []byte, schema ...interface{}) (offsets []int, err error) {
+	defer func() {
+		if err2 := recover(); err2 != nil {
+			err = fmt.Errorf("panic while decoding: %v", err2)
+		}
+	}()
+
+	position := 0
+	for i, element := range schema {
+		switch obj := element.(type) {
+		case *uint64:
+			position += 8
+		case []byte:
+			position += len(obj)
+		case SizedObjectSSZ:
+			if obj.Static() {
+				position += obj.EncodingSizeSSZ()
+				continue
+			}
+			if len(buf) < position+4 {
+				return nil, ssz.ErrLowBufferSize
+			}
+			offset := int(binary.LittleEndian.Uint32(buf[position:]))
+			if (len(offsets) == 0 && offset < position+4) || (len(offsets) > 0 && offset < offsets[len(offsets)-1]) || offset > len(buf) {
+				return nil, ssz.ErrBadOffset
+			}
+			offsets = append(offsets, offset)
+			position += 4
+		default:
+			panic(fmt.Errorf("RTFM, bad schema component %d", i))
+		}
+	}
+	if len(buf) < position || (len(offsets) > 0 && offsets[0] != position) {
+		return nil, ssz.ErrBadOffset
+	}
+	return offsets, nil
+}
@@ -25,3 +25,22 @@ func TestEncodeDecode(t *testing.T) {
 	dec, _ := utils.DecompressSnappy(beaconState)
 	require.Equal(t, dec, d)
 }
+
+func TestDynamicOffsets(t *testing.T) {
+	bs := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(bs, beaconState, int(clparams.CapellaVersion)))
+	encoded, err := bs.EncodeSSZ(nil)
+	require.NoError(t, err)
+	offsets, err := bs.DynamicOffsetsSSZ(encoded)
+	require.NoError(t, err)
+	// historical roots, eth1 data votes, validators, balances, participations, inactivity scores, payload header
+	// and historical summaries
+	require.Len(t, offsets, 9)
+	require.Equal(t, 2736653, offsets[0])
+	// the validators follow the votes and are followed by the balances
+	require.Equal(t, bs.ValidatorLength()*121, offsets[3]-offsets[2])
+	require.Equal(t, bs.ValidatorLength()*8, offsets[4]-offsets[3])
+
+	_, err = bs.DynamicOffsetsSSZ(encoded[:offsets[0]-1])
+	require.Error(t, err)
+}
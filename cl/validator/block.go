@@ -0,0 +1,320 @@
+package validator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/fork"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+	"github.com/ledgerwatch/erigon/cl/phase1/execution_client"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// payloadBuildingTime is how long the execution layer is given to fill the payload of our blocks.
+const payloadBuildingTime = time.Second
+
+// produceBlock builds and signs the block of the given duty on top of the current head, along with the sidecars of
+// its blobs from deneb onwards.
+func (v *Service) produceBlock(duty ProposerDuty) (*cltypes.SignedBeaconBlock, []*cltypes.SignedBlobSidecar, error) {
+	headRoot, _, err := v.forkChoice.GetHead()
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := v.forkChoice.GetFullState(headRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s == nil {
+		return nil, nil, fmt.Errorf("cannot reconstruct the state of head %x", headRoot)
+	}
+	if s.Slot() >= duty.Slot {
+		return nil, nil, fmt.Errorf("head %x is already at slot %d", headRoot, s.Slot())
+	}
+	// s is kept at the head to run the whole transition of our block on it, pre is the state our block is built on.
+	pre, err := s.Copy()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := transition.ProcessSlots(pre, duty.Slot); err != nil {
+		return nil, nil, err
+	}
+	proposerIndex, err := pre.GetBeaconProposerIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+	if proposerIndex != duty.ValidatorIndex {
+		return nil, nil, fmt.Errorf("validator %d is no longer the proposer of slot %d, %d is", duty.ValidatorIndex, duty.Slot, proposerIndex)
+	}
+	beaconConfig := pre.BeaconConfig()
+	epoch := state.Epoch(pre.BeaconState)
+
+	body := newBeaconBody(pre.Version())
+	randaoDomain, err := pre.GetDomain(beaconConfig.DomainRandao, epoch)
+	if err != nil {
+		return nil, nil, err
+	}
+	body.RandaoReveal = duty.Key.Sign(computeSigningRootEpoch(epoch, randaoDomain))
+	// we vote for what is already there, so the deposits to include are the ones of the current vote
+	body.Eth1Data = pre.Eth1Data().Copy()
+	body.Graffiti = v.cfg.Graffiti
+	deposits, err := v.includableDeposits(pre)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, deposit := range deposits {
+		body.Deposits.Append(deposit)
+	}
+	for _, attestation := range v.includableAttestations(pre) {
+		body.Attestations.Append(attestation)
+	}
+	if pre.Version() >= clparams.AltairVersion {
+		if body.SyncAggregate, err = v.syncAggregate(pre); err != nil {
+			return nil, nil, err
+		}
+	}
+	var blobs *execution_client.BlobsBundle
+	if pre.Version() >= clparams.BellatrixVersion {
+		if body.ExecutionPayload, blobs, err = v.buildPayload(pre); err != nil {
+			return nil, nil, err
+		}
+	}
+	if blobs != nil {
+		if uint64(len(blobs.Commitments)) > beaconConfig.MaxBlobsPerBlock {
+			return nil, nil, fmt.Errorf("payload has %d blobs, at most %d fit in a block", len(blobs.Commitments), beaconConfig.MaxBlobsPerBlock)
+		}
+		for i := range blobs.Commitments {
+			body.BlobKzgCommitments.Append(&blobs.Commitments[i])
+		}
+	}
+
+	block := &cltypes.BeaconBlock{
+		Slot:          duty.Slot,
+		ProposerIndex: duty.ValidatorIndex,
+		ParentRoot:    headRoot,
+		Body:          body,
+	}
+	if err := transition.TransitionState(s, &cltypes.SignedBeaconBlock{Block: block}, false); err != nil {
+		return nil, nil, err
+	}
+	if block.StateRoot, err = s.HashSSZ(); err != nil {
+		return nil, nil, err
+	}
+
+	proposerDomain, err := pre.GetDomain(beaconConfig.DomainBeaconProposer, epoch)
+	if err != nil {
+		return nil, nil, err
+	}
+	signingRoot, err := fork.ComputeSigningRoot(block, proposerDomain)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := v.cfg.SlashingProtection.CheckAndRecordBlock(duty.Key.PublicKey, block.Slot, signingRoot); err != nil {
+		return nil, nil, err
+	}
+	sidecars, err := signBlobSidecars(pre, block, blobs, duty.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &cltypes.SignedBeaconBlock{Signature: duty.Key.Sign(signingRoot), Block: block}, sidecars, nil
+}
+
+// includableDeposits returns the pending deposits a block built on the given state has to include.
+func (v *Service) includableDeposits(pre *state.BeaconState) ([]*cltypes.Deposit, error) {
+	eth1Data := pre.Eth1Data()
+	from, to := pre.Eth1DepositIndex(), eth1Data.DepositCount
+	if to <= from {
+		return nil, nil
+	}
+	if maxDeposits := pre.BeaconConfig().MaxDeposits; to-from > maxDeposits {
+		to = from + maxDeposits
+	}
+	if v.cfg.Deposits == nil {
+		return nil, fmt.Errorf("cannot include the %d pending deposits without following the deposit contract", to-from)
+	}
+	return v.cfg.Deposits.Deposits(eth1Data, from, to)
+}
+
+// signBlobSidecars signs the sidecars of the blobs of a block.
+func signBlobSidecars(pre *state.BeaconState, block *cltypes.BeaconBlock, blobs *execution_client.BlobsBundle, key *Key) ([]*cltypes.SignedBlobSidecar, error) {
+	if blobs == nil || len(blobs.Blobs) == 0 {
+		return nil, nil
+	}
+	blockRoot, err := block.HashSSZ()
+	if err != nil {
+		return nil, err
+	}
+	domain, err := pre.GetDomain(pre.BeaconConfig().DomainBlobSideCar, state.Epoch(pre.BeaconState))
+	if err != nil {
+		return nil, err
+	}
+	sidecars := make([]*cltypes.SignedBlobSidecar, 0, len(blobs.Blobs))
+	for i := range blobs.Blobs {
+		sidecar := &cltypes.BlobSidecar{
+			BlockRoot:       blockRoot,
+			Index:           uint64(i),
+			Slot:            block.Slot,
+			BlockParentRoot: block.ParentRoot,
+			ProposerIndex:   block.ProposerIndex,
+			Blob:            blobs.Blobs[i],
+			KzgCommitment:   blobs.Commitments[i],
+			KzgProof:        blobs.Proofs[i],
+		}
+		signingRoot, err := fork.ComputeSigningRoot(sidecar, domain)
+		if err != nil {
+			return nil, err
+		}
+		sidecars = append(sidecars, &cltypes.SignedBlobSidecar{Message: sidecar, Signature: key.Sign(signingRoot)})
+	}
+	return sidecars, nil
+}
+
+// buildPayload asks the execution layer for the payload of a block built on the given state.
+func (v *Service) buildPayload(pre *state.BeaconState) (*cltypes.Eth1Block, *execution_client.BlobsBundle, error) {
+	if v.builder == nil {
+		return nil, nil, fmt.Errorf("no execution layer to build payloads")
+	}
+	if !state.IsMergeTransitionComplete(pre.BeaconState) {
+		return nil, nil, fmt.Errorf("cannot build payloads before the merge")
+	}
+	attributes := &execution_client.PayloadAttributes{
+		Timestamp:             state.ComputeTimestampAtSlot(pre.BeaconState, pre.Slot()),
+		PrevRandao:            pre.GetRandaoMixes(state.Epoch(pre.BeaconState)),
+		SuggestedFeeRecipient: v.cfg.FeeRecipient,
+	}
+	if pre.Version() >= clparams.CapellaVersion {
+		attributes.Withdrawals = state.ExpectedWithdrawals(pre.BeaconState)
+	}
+	finalized := v.forkChoice.GetEth1Hash(v.forkChoice.FinalizedCheckpoint().BlockRoot())
+	payloadId, err := v.builder.StartPayload(finalized, pre.LatestExecutionPayloadHeader().BlockHash, attributes, pre.Version())
+	if err != nil {
+		return nil, nil, err
+	}
+	select {
+	case <-time.After(payloadBuildingTime):
+	case <-v.ctx.Done():
+		return nil, nil, v.ctx.Err()
+	}
+	return v.builder.GetPayload(payloadId, pre.Version())
+}
+
+// includableAttestations aggregates the attestations of our validators which can be included in a block built on
+// the given state.
+func (v *Service) includableAttestations(pre *state.BeaconState) []*solid.Attestation {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	beaconConfig := pre.BeaconConfig()
+	epoch := state.Epoch(pre.BeaconState)
+
+	var aggregates []*solid.Attestation
+	var signatures [][][96]byte
+	for _, attestation := range v.attestations {
+		data := attestation.AttestantionData()
+		if data.Slot()+beaconConfig.MinAttestationInclusionDelay > pre.Slot() || pre.Slot() > data.Slot()+beaconConfig.SlotsPerEpoch {
+			continue
+		}
+		// attestations voting for another source than the one of the state are invalid
+		switch data.Target().Epoch() {
+		case epoch:
+			if !data.Source().Equal(pre.CurrentJustifiedCheckpoint()) {
+				continue
+			}
+		case epoch - 1:
+			if !data.Source().Equal(pre.PreviousJustifiedCheckpoint()) {
+				continue
+			}
+		default:
+			continue
+		}
+		merged := false
+		for i, aggregate := range aggregates {
+			if !aggregate.AttestantionData().Equal(data) {
+				continue
+			}
+			bits := aggregate.AggregationBits()
+			for j, b := range attestation.AggregationBits() {
+				bits[j] |= b
+			}
+			signatures[i] = append(signatures[i], attestation.Signature())
+			merged = true
+			break
+		}
+		if merged {
+			continue
+		}
+		if len(aggregates) == cltypes.MaxAttestations {
+			break
+		}
+		bits := make([]byte, len(attestation.AggregationBits()))
+		copy(bits, attestation.AggregationBits())
+		aggregates = append(aggregates, solid.NewAttestionFromParameters(bits, data, attestation.Signature()))
+		signatures = append(signatures, [][96]byte{attestation.Signature()})
+	}
+	includable := make([]*solid.Attestation, 0, len(aggregates))
+	for i, aggregate := range aggregates {
+		signature, err := aggregateSignatures(signatures[i])
+		if err != nil {
+			continue
+		}
+		aggregate.SetSignature(signature)
+		includable = append(includable, aggregate)
+	}
+	return includable
+}
+
+// syncAggregate aggregates the sync committee messages of our validators for the parent of a block built on the
+// given state.
+func (v *Service) syncAggregate(pre *state.BeaconState) (*cltypes.SyncAggregate, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	aggregate := &cltypes.SyncAggregate{}
+	previousSlot := pre.Slot() - 1
+	previousRoot, err := pre.GetBlockRootAtSlot(previousSlot)
+	if err != nil {
+		return nil, err
+	}
+	var signatures [][96]byte
+	for _, msg := range v.syncMessages {
+		if msg.slot != previousSlot || msg.blockRoot != previousRoot {
+			continue
+		}
+		for _, position := range msg.positions {
+			aggregate.SyncCommiteeBits[position/8] |= 1 << (position % 8)
+			signatures = append(signatures, msg.signature)
+		}
+	}
+	if aggregate.SyncCommiteeSignature, err = aggregateSignatures(signatures); err != nil {
+		return nil, err
+	}
+	return aggregate, nil
+}
+
+// newBeaconBody returns an empty body of the given version.
+func newBeaconBody(version clparams.StateVersion) *cltypes.BeaconBody {
+	return &cltypes.BeaconBody{
+		Eth1Data:           &cltypes.Eth1Data{},
+		ProposerSlashings:  solid.NewStaticListSSZ[*cltypes.ProposerSlashing](cltypes.MaxProposerSlashings, 416),
+		AttesterSlashings:  solid.NewDynamicListSSZ[*cltypes.AttesterSlashing](cltypes.MaxAttesterSlashings),
+		Attestations:       solid.NewDynamicListSSZ[*solid.Attestation](cltypes.MaxAttestations),
+		Deposits:           solid.NewStaticListSSZ[*cltypes.Deposit](cltypes.MaxDeposits, 1240),
+		VoluntaryExits:     solid.NewStaticListSSZ[*cltypes.SignedVoluntaryExit](cltypes.MaxVoluntaryExits, 112),
+		SyncAggregate:      &cltypes.SyncAggregate{},
+		ExecutionPayload:   cltypes.NewEth1Block(version),
+		ExecutionChanges:   solid.NewStaticListSSZ[*cltypes.SignedBLSToExecutionChange](cltypes.MaxExecutionChanges, 172),
+		BlobKzgCommitments: solid.NewStaticListSSZ[*cltypes.KZGCommitment](cltypes.MaxBlobsCommittmentsPerBlock, 48),
+		Version:            version,
+	}
+}
+
+// computeSigningRootEpoch is the signing root of an epoch, which is what the randao reveal signs.
+func computeSigningRootEpoch(epoch uint64, domain []byte) libcommon.Hash {
+	b := make([]byte, 32)
+	binary.LittleEndian.PutUint64(b, epoch)
+	return utils.Keccak256(b, domain)
+}
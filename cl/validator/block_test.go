@@ -0,0 +1,132 @@
+package validator
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/fork"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+func newTestService(t *testing.T, anchorState *state.BeaconState, deposits *DepositTree) (*Service, *forkchoice.ForkChoiceStore) {
+	store, err := forkchoice.NewForkChoiceStore(anchorState, nil, nil, nil, nil, false)
+	require.NoError(t, err)
+	store.OnTick(anchorState.GenesisTime() + (anchorState.Slot()+1)*anchorState.BeaconConfig().SecondsPerSlot)
+	slashingProtection, err := OpenSlashingProtection(filepath.Join(t.TempDir(), "slashing_protection.json"), anchorState.GenesisValidatorsRoot())
+	require.NoError(t, err)
+	t.Cleanup(func() { slashingProtection.Close() })
+	cfg := &Config{SlashingProtection: slashingProtection, Deposits: deposits}
+	return NewService(context.Background(), cfg, nil, store, nil, nil, anchorState.BeaconConfig(), &clparams.GenesisConfig{}), store
+}
+
+// nextProposerDuty is the duty of the proposer of the slot after the one of the state.
+func nextProposerDuty(t *testing.T, s *state.BeaconState) ProposerDuty {
+	next, err := s.Copy()
+	require.NoError(t, err)
+	require.NoError(t, transition.ProcessSlots(next, s.Slot()+1))
+	proposerIndex, err := next.GetBeaconProposerIndex()
+	require.NoError(t, err)
+	return ProposerDuty{Slot: next.Slot(), ValidatorIndex: proposerIndex, Key: testKey(t, proposerIndex)}
+}
+
+func TestProduceBlock(t *testing.T) {
+	anchorState := newTestState(t)
+	v, store := newTestService(t, anchorState, nil)
+	duty := nextProposerDuty(t, anchorState)
+
+	block, sidecars, err := v.produceBlock(duty)
+	require.NoError(t, err)
+	require.Empty(t, sidecars)
+	headRoot, _, err := store.GetHead()
+	require.NoError(t, err)
+	require.Equal(t, duty.Slot, block.Block.Slot)
+	require.Equal(t, duty.ValidatorIndex, block.Block.ProposerIndex)
+	require.Equal(t, headRoot, block.Block.ParentRoot)
+
+	// the block passes the full validation of fork choice, signatures and state root included
+	require.NoError(t, store.OnBlock(block, true, true))
+	blockRoot, err := block.Block.HashSSZ()
+	require.NoError(t, err)
+	headRoot, _, err = store.GetHead()
+	require.NoError(t, err)
+	require.Equal(t, libcommon.Hash(blockRoot), headRoot)
+
+	// the head is at the slot of the duty now
+	_, _, err = v.produceBlock(duty)
+	require.Error(t, err)
+	// and another block at the same slot is slashable
+	require.ErrorIs(t, v.cfg.SlashingProtection.CheckAndRecordBlock(duty.Key.PublicKey, duty.Slot, [32]byte{1}), ErrSlashableBlock)
+}
+
+func TestProduceBlockWrongProposer(t *testing.T) {
+	anchorState := newTestState(t)
+	v, _ := newTestService(t, anchorState, nil)
+	duty := nextProposerDuty(t, anchorState)
+	duty.ValidatorIndex++
+	duty.Key = testKey(t, duty.ValidatorIndex)
+	_, _, err := v.produceBlock(duty)
+	require.Error(t, err)
+}
+
+func TestIncludableDeposits(t *testing.T) {
+	anchorState := newTestState(t)
+	beaconConfig := anchorState.BeaconConfig()
+	pre, err := anchorState.Copy()
+	require.NoError(t, err)
+
+	// the deposits already processed by the state are not checked, only the pending ones are proven
+	tree := NewDepositTree(beaconConfig)
+	for i := uint64(0); i < pre.Eth1DepositIndex(); i++ {
+		require.NoError(t, tree.Add(&cltypes.DepositData{PubKey: testKey(t, i).PublicKey, Amount: beaconConfig.MaxEffectiveBalance}))
+	}
+	pending := beaconConfig.MaxDeposits + 2
+	for i := uint64(0); i < pending; i++ {
+		key := testKey(t, uint64(pre.ValidatorLength())+i)
+		data := &cltypes.DepositData{PubKey: key.PublicKey, Amount: beaconConfig.MaxEffectiveBalance}
+		domain, err := fork.ComputeDomain(beaconConfig.DomainDeposit[:], utils.Uint32ToBytes4(beaconConfig.GenesisForkVersion), [32]byte{})
+		require.NoError(t, err)
+		messageRoot, err := data.MessageHash()
+		require.NoError(t, err)
+		data.Signature = key.Sign(utils.Keccak256(messageRoot[:], domain))
+		require.NoError(t, tree.Add(data))
+	}
+	depositRoot, err := tree.Root(tree.Len())
+	require.NoError(t, err)
+	eth1Data := pre.Eth1Data().Copy()
+	eth1Data.Root, eth1Data.DepositCount = depositRoot, tree.Len()
+	pre.SetEth1Data(eth1Data)
+
+	v, _ := newTestService(t, anchorState, nil)
+	_, err = v.includableDeposits(pre)
+	require.Error(t, err)
+
+	v.cfg.Deposits = tree
+	deposits, err := v.includableDeposits(pre)
+	require.NoError(t, err)
+	require.Len(t, deposits, int(beaconConfig.MaxDeposits))
+	validators := pre.ValidatorLength()
+	for _, deposit := range deposits {
+		require.NoError(t, transition.ProcessDeposit(pre, deposit, true))
+	}
+	require.Equal(t, validators+int(beaconConfig.MaxDeposits), pre.ValidatorLength())
+
+	// the rest is included by the next block
+	deposits, err = v.includableDeposits(pre)
+	require.NoError(t, err)
+	require.Len(t, deposits, 2)
+
+	// a vote for deposits we do not know about cannot be proven
+	eth1Data.DepositCount++
+	pre.SetEth1Data(eth1Data)
+	_, err = v.includableDeposits(pre)
+	require.Error(t, err)
+}
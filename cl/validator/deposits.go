@@ -0,0 +1,216 @@
+package validator
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// depositEventTopic is the topic of DepositEvent(bytes,bytes,bytes,bytes,bytes), logged by the deposit contract.
+var depositEventTopic = libcommon.HexToHash("0x649bbc62d0e31342afea4e5cd82d4049e7e1ee912fc0889aa790803be39038c5")
+
+const (
+	// depositLogsRange is the amount of execution blocks whose deposit logs are requested at once.
+	depositLogsRange    = 10_000
+	depositPollInterval = time.Minute
+)
+
+// DepositTree is the merkle tree of the deposit contract. It proves the deposits included in our blocks against
+// the deposit root of any deposit count it holds.
+type DepositTree struct {
+	mu       sync.RWMutex
+	depth    int
+	deposits []*cltypes.DepositData
+	// layers[l][i] is the root of the i-th complete subtree of height l.
+	layers [][][32]byte
+}
+
+// NewDepositTree creates an empty deposit tree of the depth of the deposit contract.
+func NewDepositTree(beaconConfig *clparams.BeaconChainConfig) *DepositTree {
+	depth := int(beaconConfig.DepositContractTreeDepth)
+	return &DepositTree{depth: depth, layers: make([][][32]byte, depth+1)}
+}
+
+// Len is the amount of deposits in the tree.
+func (t *DepositTree) Len() uint64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return uint64(len(t.deposits))
+}
+
+// Add appends the next deposit of the contract to the tree.
+func (t *DepositTree) Add(data *cltypes.DepositData) error {
+	leaf, err := data.HashSSZ()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.deposits = append(t.deposits, data)
+	t.layers[0] = append(t.layers[0], leaf)
+	for level := 1; level <= t.depth; level++ {
+		children := t.layers[level-1]
+		if len(children)%2 != 0 || len(children)/2 == len(t.layers[level]) {
+			break
+		}
+		t.layers[level] = append(t.layers[level], utils.Keccak256(children[len(children)-2][:], children[len(children)-1][:]))
+	}
+	return nil
+}
+
+// Deposits returns the deposits [from, to) along with their proofs against the deposit root of eth1Data.
+func (t *DepositTree) Deposits(eth1Data *cltypes.Eth1Data, from, to uint64) ([]*cltypes.Deposit, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	count := eth1Data.DepositCount
+	root, err := t.root(count)
+	if err != nil {
+		return nil, err
+	}
+	if root != eth1Data.Root {
+		return nil, fmt.Errorf("deposit root of %d deposits mismatches the voted one %x", count, eth1Data.Root)
+	}
+	if from > to || to > count {
+		return nil, fmt.Errorf("invalid deposits range [%d, %d) of %d deposits", from, to, count)
+	}
+	var length libcommon.Hash
+	binary.LittleEndian.PutUint64(length[:], count)
+	deposits := make([]*cltypes.Deposit, 0, to-from)
+	for index := from; index < to; index++ {
+		proof := solid.NewHashVector(t.depth + 1)
+		for level := 0; level < t.depth; level++ {
+			proof.Set(level, t.node(level, (index>>level)^1, count))
+		}
+		proof.Set(t.depth, length)
+		deposits = append(deposits, &cltypes.Deposit{Proof: proof, Data: t.deposits[index]})
+	}
+	return deposits, nil
+}
+
+// Root is the deposit root of the contract once it held the first count deposits.
+func (t *DepositTree) Root(count uint64) (libcommon.Hash, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.root(count)
+}
+
+func (t *DepositTree) root(count uint64) (libcommon.Hash, error) {
+	if count > uint64(len(t.deposits)) {
+		return libcommon.Hash{}, fmt.Errorf("only %d deposits are known, %d are voted for", len(t.deposits), count)
+	}
+	var length [32]byte
+	binary.LittleEndian.PutUint64(length[:], count)
+	root := t.node(t.depth, 0, count)
+	return utils.Keccak256(root[:], length[:]), nil
+}
+
+// node is the root of the index-th subtree of the given height of the tree holding the first count deposits.
+func (t *DepositTree) node(level int, index, count uint64) [32]byte {
+	start := index << level
+	if start >= count {
+		return merkle_tree.ZeroHashes[level]
+	}
+	if start+(1<<level) <= count {
+		return t.layers[level][index]
+	}
+	left, right := t.node(level-1, 2*index, count), t.node(level-1, 2*index+1, count)
+	return utils.Keccak256(left[:], right[:])
+}
+
+// FollowDeposits fills the tree with the deposit logs of the execution layer behind the JSON-RPC client, starting
+// from the given block, until the context is done. Only the blocks at the eth1 follow distance are read, as they
+// are the only ones deposits can be voted for from.
+func FollowDeposits(ctx context.Context, client *rpc.Client, tree *DepositTree, beaconConfig *clparams.BeaconChainConfig, fromBlock uint64) {
+	contract := libcommon.HexToAddress(beaconConfig.DepositContractAddress)
+	next := fromBlock
+	for {
+		var head hexutil.Uint64
+		if err := client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+			log.Warn("[Validator] Could not read the execution head", "err", err)
+		} else if target := uint64(head); target > beaconConfig.Eth1FollowDistance {
+			target -= beaconConfig.Eth1FollowDistance
+			for next <= target && ctx.Err() == nil {
+				to := next + depositLogsRange - 1
+				if to > target {
+					to = target
+				}
+				if err := readDepositLogs(ctx, client, tree, contract, next, to); err != nil {
+					log.Warn("[Validator] Could not read deposit logs", "from", next, "to", to, "err", err)
+					break
+				}
+				next = to + 1
+			}
+			log.Debug("[Validator] Followed deposits", "block", next-1, "deposits", tree.Len())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(depositPollInterval):
+		}
+	}
+}
+
+func readDepositLogs(ctx context.Context, client *rpc.Client, tree *DepositTree, contract libcommon.Address, from, to uint64) error {
+	var logs []*types.Log
+	filter := map[string]interface{}{
+		"fromBlock": hexutil.Uint64(from),
+		"toBlock":   hexutil.Uint64(to),
+		"address":   contract,
+		"topics":    []libcommon.Hash{depositEventTopic},
+	}
+	if err := client.CallContext(ctx, &logs, "eth_getLogs", filter); err != nil {
+		return err
+	}
+	for _, l := range logs {
+		index, data, err := decodeDepositEvent(l.Data)
+		if err != nil {
+			return fmt.Errorf("invalid deposit log of tx %x: %w", l.TxHash, err)
+		}
+		if known := tree.Len(); index < known {
+			// already read, before a failure in the middle of the range
+			continue
+		} else if index > known {
+			return fmt.Errorf("missing deposits %d to %d", known, index)
+		}
+		if err := tree.Add(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeDepositEvent decodes the ABI encoded pubkey, withdrawal credentials, amount, signature and index of a
+// DepositEvent log.
+func decodeDepositEvent(data []byte) (uint64, *cltypes.DepositData, error) {
+	lengths := []int{48, 32, 8, 96, 8}
+	fields := make([][]byte, len(lengths))
+	for i, length := range lengths {
+		if len(data) < 32*(i+1) {
+			return 0, nil, fmt.Errorf("log is too short")
+		}
+		offset := binary.BigEndian.Uint64(data[32*i+24 : 32*(i+1)])
+		if offset+32 > uint64(len(data)) || binary.BigEndian.Uint64(data[offset+24:offset+32]) != uint64(length) || offset+32+uint64(length) > uint64(len(data)) {
+			return 0, nil, fmt.Errorf("invalid field %d", i)
+		}
+		fields[i] = data[offset+32 : offset+32+uint64(length)]
+	}
+	deposit := &cltypes.DepositData{Amount: binary.LittleEndian.Uint64(fields[2])}
+	copy(deposit.PubKey[:], fields[0])
+	copy(deposit.WithdrawalCredentials[:], fields[1])
+	copy(deposit.Signature[:], fields[3])
+	return binary.LittleEndian.Uint64(fields[4]), deposit, nil
+}
@@ -0,0 +1,154 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+)
+
+// ProposerDuty is a slot one of our validators has to propose a block at.
+type ProposerDuty struct {
+	Slot           uint64
+	ValidatorIndex uint64
+	Key            *Key
+}
+
+// AttesterDuty is the committee one of our validators has to attest with during an epoch.
+type AttesterDuty struct {
+	Slot                    uint64
+	ValidatorIndex          uint64
+	CommitteeIndex          uint64
+	CommitteeLength         uint64
+	CommitteesAtSlot        uint64
+	ValidatorCommitteeIndex uint64
+	Key                     *Key
+}
+
+// SyncCommitteeDuty are the positions of one of our validators in a sync committee.
+type SyncCommitteeDuty struct {
+	ValidatorIndex uint64
+	Positions      []uint64
+	Key            *Key
+}
+
+// ValidatorIndices maps the keys which are part of the validator set of the state to their validator index.
+func ValidatorIndices(s *state.BeaconState, keys []*Key) map[uint64]*Key {
+	indices := make(map[uint64]*Key, len(keys))
+	for _, key := range keys {
+		if index, ok := s.ValidatorIndexByPubkey(key.PublicKey); ok {
+			indices[index] = key
+		}
+	}
+	return indices
+}
+
+// ProposerDuties returns the slots of the current epoch of the state our validators have to propose at.
+func ProposerDuties(s *state.BeaconState, validators map[uint64]*Key) ([]ProposerDuty, error) {
+	beaconConfig := s.BeaconConfig()
+	epoch := state.Epoch(s.BeaconState)
+	var duties []ProposerDuty
+	for slot := epoch * beaconConfig.SlotsPerEpoch; slot < (epoch+1)*beaconConfig.SlotsPerEpoch; slot++ {
+		// the state cannot have missed a slot of its own epoch
+		if slot < s.Slot() {
+			continue
+		}
+		proposerIndex, err := s.GetBeaconProposerIndexForSlot(slot)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := validators[proposerIndex]; ok {
+			duties = append(duties, ProposerDuty{Slot: slot, ValidatorIndex: proposerIndex, Key: key})
+		}
+	}
+	return duties, nil
+}
+
+// AttesterDuties returns the committees our validators attest with during the given epoch, which is either the
+// current or the next epoch of the state.
+func AttesterDuties(s *state.BeaconState, epoch uint64, validators map[uint64]*Key) ([]AttesterDuty, error) {
+	beaconConfig := s.BeaconConfig()
+	if currentEpoch := state.Epoch(s.BeaconState); epoch < currentEpoch || epoch > currentEpoch+1 {
+		return nil, fmt.Errorf("cannot compute the attester duties of epoch %d at epoch %d", epoch, currentEpoch)
+	}
+	committeesPerSlot := s.CommitteeCount(epoch)
+	var duties []AttesterDuty
+	for slot := epoch * beaconConfig.SlotsPerEpoch; slot < (epoch+1)*beaconConfig.SlotsPerEpoch; slot++ {
+		for committeeIndex := uint64(0); committeeIndex < committeesPerSlot; committeeIndex++ {
+			committee, err := s.GetBeaconCommitee(slot, committeeIndex)
+			if err != nil {
+				return nil, err
+			}
+			for position, validatorIndex := range committee {
+				key, ok := validators[validatorIndex]
+				if !ok {
+					continue
+				}
+				duties = append(duties, AttesterDuty{
+					Slot:                    slot,
+					ValidatorIndex:          validatorIndex,
+					CommitteeIndex:          committeeIndex,
+					CommitteeLength:         uint64(len(committee)),
+					CommitteesAtSlot:        committeesPerSlot,
+					ValidatorCommitteeIndex: uint64(position),
+					Key:                     key,
+				})
+			}
+		}
+	}
+	return duties, nil
+}
+
+// SyncCommitteeDuties returns the positions of our validators in the sync committee signing at the given slot.
+func SyncCommitteeDuties(s *state.BeaconState, slot uint64, validators map[uint64]*Key) ([]SyncCommitteeDuty, error) {
+	syncCommittee, err := syncCommitteeAtSlot(s, slot)
+	if err != nil {
+		return nil, err
+	}
+	if syncCommittee == nil {
+		return nil, nil
+	}
+	byIndex := map[uint64]*SyncCommitteeDuty{}
+	var duties []*SyncCommitteeDuty
+	for position, pubkey := range syncCommittee.GetCommittee() {
+		validatorIndex, ok := s.ValidatorIndexByPubkey(pubkey)
+		if !ok {
+			continue
+		}
+		key, ok := validators[validatorIndex]
+		if !ok {
+			continue
+		}
+		duty, ok := byIndex[validatorIndex]
+		if !ok {
+			duty = &SyncCommitteeDuty{ValidatorIndex: validatorIndex, Key: key}
+			byIndex[validatorIndex] = duty
+			duties = append(duties, duty)
+		}
+		duty.Positions = append(duty.Positions, uint64(position))
+	}
+	out := make([]SyncCommitteeDuty, 0, len(duties))
+	for _, duty := range duties {
+		out = append(out, *duty)
+	}
+	return out, nil
+}
+
+// syncCommitteeAtSlot returns the sync committee whose messages of the given slot are included in the block of the
+// next slot, nil before altair.
+func syncCommitteeAtSlot(s *state.BeaconState, slot uint64) (*solid.SyncCommittee, error) {
+	if s.Version() < clparams.AltairVersion {
+		return nil, nil
+	}
+	beaconConfig := s.BeaconConfig()
+	period := state.GetEpochAtSlot(beaconConfig, slot+1) / beaconConfig.EpochsPerSyncCommitteePeriod
+	statePeriod := state.Epoch(s.BeaconState) / beaconConfig.EpochsPerSyncCommitteePeriod
+	switch period {
+	case statePeriod:
+		return s.CurrentSyncCommittee(), nil
+	case statePeriod + 1:
+		return s.NextSyncCommittee(), nil
+	}
+	return nil, fmt.Errorf("cannot compute the sync committee of slot %d at slot %d", slot, s.Slot())
+}
@@ -0,0 +1,97 @@
+package validator
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// newTestState decodes the anchor state of the fork choice spec tests.
+func newTestState(t *testing.T) *state.BeaconState {
+	encoded, err := os.ReadFile("../phase1/forkchoice/test_data/anchor_state.ssz_snappy")
+	require.NoError(t, err)
+	s := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, utils.DecodeSSZSnappy(s, encoded, int(clparams.AltairVersion)))
+	return s
+}
+
+// testKey is the key of a validator of the spec tests, whose secret key is its index plus one.
+func testKey(t *testing.T, index uint64) *Key {
+	secret := make([]byte, 32)
+	binary.BigEndian.PutUint64(secret[24:], index+1)
+	key, err := NewKey(secret)
+	require.NoError(t, err)
+	return key
+}
+
+func TestDuties(t *testing.T) {
+	s := newTestState(t)
+	var keys []*Key
+	for i := uint64(0); i < 64; i++ {
+		keys = append(keys, testKey(t, i))
+	}
+	// not part of the validator set
+	validators := ValidatorIndices(s, append(keys, testKey(t, uint64(s.ValidatorLength()))))
+	require.Len(t, validators, len(keys))
+	for index, key := range validators {
+		require.Equal(t, keys[index], key)
+	}
+
+	slotsPerEpoch := s.BeaconConfig().SlotsPerEpoch
+	epoch := state.Epoch(s.BeaconState)
+	proposerDuties, err := ProposerDuties(s, validators)
+	require.NoError(t, err)
+	for _, duty := range proposerDuties {
+		require.Equal(t, epoch, duty.Slot/slotsPerEpoch)
+		proposerIndex, err := s.GetBeaconProposerIndexForSlot(duty.Slot)
+		require.NoError(t, err)
+		require.Equal(t, proposerIndex, duty.ValidatorIndex)
+		require.Equal(t, validators[duty.ValidatorIndex], duty.Key)
+	}
+
+	for _, attestationEpoch := range []uint64{epoch, epoch + 1} {
+		attesterDuties, err := AttesterDuties(s, attestationEpoch, validators)
+		require.NoError(t, err)
+		// every validator attests once per epoch
+		require.Len(t, attesterDuties, len(validators))
+		seen := map[uint64]struct{}{}
+		for _, duty := range attesterDuties {
+			require.Equal(t, attestationEpoch, duty.Slot/slotsPerEpoch)
+			committee, err := s.GetBeaconCommitee(duty.Slot, duty.CommitteeIndex)
+			require.NoError(t, err)
+			require.Equal(t, uint64(len(committee)), duty.CommitteeLength)
+			require.Equal(t, duty.ValidatorIndex, committee[duty.ValidatorCommitteeIndex])
+			require.Equal(t, s.CommitteeCount(attestationEpoch), duty.CommitteesAtSlot)
+			seen[duty.ValidatorIndex] = struct{}{}
+		}
+		require.Len(t, seen, len(validators))
+	}
+	_, err = AttesterDuties(s, epoch+2, validators)
+	require.Error(t, err)
+
+	syncDuties, err := SyncCommitteeDuties(s, s.Slot(), validators)
+	require.NoError(t, err)
+	committee := s.CurrentSyncCommittee().GetCommittee()
+	positions := 0
+	for _, duty := range syncDuties {
+		for _, position := range duty.Positions {
+			require.Equal(t, validators[duty.ValidatorIndex].PublicKey, committee[position])
+		}
+		positions += len(duty.Positions)
+	}
+	expected := 0
+	for _, pubkey := range committee {
+		for _, key := range keys {
+			if key.PublicKey == pubkey {
+				expected++
+			}
+		}
+	}
+	require.Equal(t, expected, positions)
+}
@@ -0,0 +1,197 @@
+package validator
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Keystore is an EIP-2335 BLS keystore.
+type Keystore struct {
+	Crypto struct {
+		Kdf      keystoreModule `json:"kdf"`
+		Checksum keystoreModule `json:"checksum"`
+		Cipher   keystoreModule `json:"cipher"`
+	} `json:"crypto"`
+	Description string `json:"description"`
+	Pubkey      string `json:"pubkey"`
+	Path        string `json:"path"`
+	UUID        string `json:"uuid"`
+	Version     int    `json:"version"`
+}
+
+type keystoreModule struct {
+	Function string          `json:"function"`
+	Params   json.RawMessage `json:"params"`
+	Message  string          `json:"message"`
+}
+
+type kdfParams struct {
+	Dklen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+	// scrypt
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+	// pbkdf2
+	C   int    `json:"c"`
+	Prf string `json:"prf"`
+}
+
+type cipherParams struct {
+	IV string `json:"iv"`
+}
+
+// ReadKeystore parses the keystore at the given path.
+func ReadKeystore(path string) (*Keystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ks := &Keystore{}
+	if err := json.Unmarshal(data, ks); err != nil {
+		return nil, fmt.Errorf("invalid keystore %s: %w", path, err)
+	}
+	if ks.Version != 4 {
+		return nil, fmt.Errorf("keystore %s has unsupported version %d", path, ks.Version)
+	}
+	return ks, nil
+}
+
+// Decrypt returns the secret key held by the keystore.
+func (ks *Keystore) Decrypt(password string) ([]byte, error) {
+	var params kdfParams
+	if err := json.Unmarshal(ks.Crypto.Kdf.Params, &params); err != nil {
+		return nil, fmt.Errorf("invalid kdf params: %w", err)
+	}
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kdf salt: %w", err)
+	}
+	if params.Dklen < 32 {
+		return nil, fmt.Errorf("kdf key length %d is too short", params.Dklen)
+	}
+	normalizedPassword := normalizePassword(password)
+
+	var decryptionKey []byte
+	switch ks.Crypto.Kdf.Function {
+	case "scrypt":
+		if decryptionKey, err = scrypt.Key(normalizedPassword, salt, params.N, params.R, params.P, params.Dklen); err != nil {
+			return nil, err
+		}
+	case "pbkdf2":
+		if params.Prf != "hmac-sha256" {
+			return nil, fmt.Errorf("unsupported pbkdf2 prf %s", params.Prf)
+		}
+		decryptionKey = pbkdf2.Key(normalizedPassword, salt, params.C, params.Dklen, sha256.New)
+	default:
+		return nil, fmt.Errorf("unsupported kdf %s", ks.Crypto.Kdf.Function)
+	}
+
+	cipherMessage, err := hex.DecodeString(ks.Crypto.Cipher.Message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cipher message: %w", err)
+	}
+	if ks.Crypto.Checksum.Function != "sha256" {
+		return nil, fmt.Errorf("unsupported checksum %s", ks.Crypto.Checksum.Function)
+	}
+	expectedChecksum, err := hex.DecodeString(ks.Crypto.Checksum.Message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum: %w", err)
+	}
+	checksum := sha256.Sum256(append(append([]byte{}, decryptionKey[16:32]...), cipherMessage...))
+	if !bytes.Equal(checksum[:], expectedChecksum) {
+		return nil, fmt.Errorf("invalid password")
+	}
+
+	if ks.Crypto.Cipher.Function != "aes-128-ctr" {
+		return nil, fmt.Errorf("unsupported cipher %s", ks.Crypto.Cipher.Function)
+	}
+	var cParams cipherParams
+	if err := json.Unmarshal(ks.Crypto.Cipher.Params, &cParams); err != nil {
+		return nil, fmt.Errorf("invalid cipher params: %w", err)
+	}
+	iv, err := hex.DecodeString(cParams.IV)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cipher iv: %w", err)
+	}
+	block, err := aes.NewCipher(decryptionKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, fmt.Errorf("cipher iv must be %d bytes long", block.BlockSize())
+	}
+	secret := make([]byte, len(cipherMessage))
+	cipher.NewCTR(block, iv).XORKeyStream(secret, cipherMessage)
+	return secret, nil
+}
+
+// normalizePassword applies the NFKD normalization to the password and strips the control codes out of it.
+func normalizePassword(password string) []byte {
+	var out strings.Builder
+	for _, r := range norm.NFKD.String(password) {
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return []byte(out.String())
+}
+
+// LoadKeystore decrypts the keystore at the given path and checks that its key matches its public key.
+func LoadKeystore(path, password string) (*Key, error) {
+	ks, err := ReadKeystore(path)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := ks.Decrypt(password)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt keystore %s: %w", path, err)
+	}
+	key, err := NewKey(secret)
+	if err != nil {
+		return nil, fmt.Errorf("keystore %s holds an invalid key: %w", path, err)
+	}
+	if pubkey := hex.EncodeToString(key.PublicKey[:]); pubkey != strings.TrimPrefix(strings.ToLower(ks.Pubkey), "0x") {
+		return nil, fmt.Errorf("keystore %s holds the key of %s, not of %s", path, pubkey, ks.Pubkey)
+	}
+	return key, nil
+}
+
+// LoadKeystores decrypts all the keystores found in dir with the same password.
+func LoadKeystores(dir, password string) ([]*Key, error) {
+	var keys []*Key
+	seen := map[[48]byte]struct{}{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		key, err := LoadKeystore(path, password)
+		if err != nil {
+			return err
+		}
+		if _, ok := seen[key.PublicKey]; ok {
+			return fmt.Errorf("keystore %s duplicates the key %s", path, key)
+		}
+		seen[key.PublicKey] = struct{}{}
+		keys = append(keys, key)
+		return nil
+	})
+	return keys, err
+}
@@ -0,0 +1,52 @@
+package validator_test
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/validator"
+)
+
+// EIP-2335 test vectors
+const (
+	testKeystorePassword = "𝔱𝔢𝔰𝔱𝔭𝔞𝔰𝔰𝔴𝔬𝔯𝔡🔑"
+	testKeystoreSecret   = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+	testKeystorePubkey   = "9612d7a727c9d0a22e185a1c768478dfe919cada9266988cb32359c11f2b7b27f4ae4040902382ae2910c15e2b420d07"
+)
+
+func TestKeystoreDecrypt(t *testing.T) {
+	for _, name := range []string{"keystore_scrypt.json", "keystore_pbkdf2.json"} {
+		t.Run(name, func(t *testing.T) {
+			ks, err := validator.ReadKeystore(filepath.Join("testdata", name))
+			require.NoError(t, err)
+			secret, err := ks.Decrypt(testKeystorePassword)
+			require.NoError(t, err)
+			require.Equal(t, testKeystoreSecret, hex.EncodeToString(secret))
+
+			_, err = ks.Decrypt("testpassword")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadKeystores(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile(filepath.Join("testdata", "keystore_pbkdf2.json"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keystore-0.json"), data, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "password.txt"), []byte(testKeystorePassword), 0600))
+
+	keys, err := validator.LoadKeystores(dir, testKeystorePassword)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, testKeystorePubkey, hex.EncodeToString(keys[0].PublicKey[:]))
+
+	// the same key twice
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keystore-1.json"), data, 0600))
+	_, err = validator.LoadKeystores(dir, testKeystorePassword)
+	require.Error(t, err)
+}
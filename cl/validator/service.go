@@ -0,0 +1,397 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/fork"
+	"github.com/ledgerwatch/erigon/cl/gossip"
+	"github.com/ledgerwatch/erigon/cl/phase1/blob_storage"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/transition"
+	"github.com/ledgerwatch/erigon/cl/phase1/execution_client"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// attestationSubnetCount is ATTESTATION_SUBNET_COUNT, which is the same on every network.
+const attestationSubnetCount = 64
+
+// Config is the configuration of the validators run by Caplin.
+type Config struct {
+	Keys               []*Key
+	SlashingProtection *SlashingProtection
+	// Deposits proves the pending deposits our blocks have to include, nil if the deposit contract is not followed.
+	Deposits     *DepositTree
+	FeeRecipient libcommon.Address
+	Graffiti     [32]byte
+}
+
+// syncCommitteeSignature is the signature of the sync committee message of one of our validators.
+type syncCommitteeSignature struct {
+	slot      uint64
+	blockRoot libcommon.Hash
+	positions []uint64
+	signature [96]byte
+}
+
+// Service performs the duties of our validators: it proposes blocks, attests and signs sync committee messages.
+// Aggregation duties are not performed, the attestations and sync committee messages of our validators are only
+// aggregated into the blocks we propose.
+type Service struct {
+	ctx        context.Context
+	cfg        *Config
+	sentinel   sentinel.SentinelClient
+	forkChoice *forkchoice.ForkChoiceStore
+	builder    execution_client.PayloadBuilder
+	blobStore  *blob_storage.BlobStore
+	// configs
+	beaconConfig  *clparams.BeaconChainConfig
+	genesisConfig *clparams.GenesisConfig
+
+	// duties of dutiesEpoch
+	hasDuties      bool
+	dutiesEpoch    uint64
+	proposerDuties []ProposerDuty
+	attesterDuties []AttesterDuty
+	// sync committee duties by sync committee period
+	syncDuties map[uint64][]SyncCommitteeDuty
+
+	// what our validators signed, waiting to be included in our blocks
+	mu           sync.Mutex
+	attestations []*solid.Attestation
+	syncMessages []*syncCommitteeSignature
+}
+
+// NewService creates the validator service. Blocks cannot be proposed without a payload builder, the sidecars of
+// their blobs are stored in blobStore if it is not nil.
+func NewService(ctx context.Context, cfg *Config, s sentinel.SentinelClient, forkChoice *forkchoice.ForkChoiceStore, builder execution_client.PayloadBuilder,
+	blobStore *blob_storage.BlobStore, beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig) *Service {
+	return &Service{
+		ctx:           ctx,
+		cfg:           cfg,
+		sentinel:      s,
+		forkChoice:    forkChoice,
+		builder:       builder,
+		blobStore:     blobStore,
+		beaconConfig:  beaconConfig,
+		genesisConfig: genesisConfig,
+		syncDuties:    map[uint64][]SyncCommitteeDuty{},
+	}
+}
+
+// Run performs the duties of our validators until the context is done. Blocks are proposed at the start of their
+// slot, attestations and sync committee messages are signed a third of the way through it.
+func (v *Service) Run() {
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	lastProposalSlot := utils.GetCurrentSlot(v.genesisConfig.GenesisTime, v.beaconConfig.SecondsPerSlot)
+	lastAttestationSlot := lastProposalSlot
+	for {
+		select {
+		case <-ticker.C:
+		case <-v.ctx.Done():
+			return
+		}
+		slot := utils.GetCurrentSlot(v.genesisConfig.GenesisTime, v.beaconConfig.SecondsPerSlot)
+		if slot > lastProposalSlot {
+			lastProposalSlot = slot
+			if err := v.updateDuties(slot / v.beaconConfig.SlotsPerEpoch); err != nil {
+				log.Warn("[Validator] Could not compute duties", "slot", slot, "err", err)
+				continue
+			}
+			v.pruneOperations(slot)
+			v.propose(slot)
+		}
+		timeIntoSlot := utils.GetCurrentSlotOverTime(v.genesisConfig.GenesisTime, v.beaconConfig.SecondsPerSlot)
+		if slot > lastAttestationSlot && timeIntoSlot >= v.beaconConfig.SecondsPerSlot/3 && v.hasDuties && v.dutiesEpoch == slot/v.beaconConfig.SlotsPerEpoch {
+			lastAttestationSlot = slot
+			if err := v.attest(slot); err != nil {
+				log.Warn("[Validator] Could not attest", "slot", slot, "err", err)
+			}
+		}
+	}
+}
+
+// updateDuties computes the duties of our validators for the given epoch, unless they already were.
+func (v *Service) updateDuties(epoch uint64) error {
+	if v.hasDuties && v.dutiesEpoch == epoch {
+		return nil
+	}
+	_, s, err := v.headStateAtEpoch(epoch)
+	if err != nil {
+		return err
+	}
+	validators := ValidatorIndices(s, v.cfg.Keys)
+	proposerDuties, err := ProposerDuties(s, validators)
+	if err != nil {
+		return err
+	}
+	attesterDuties, err := AttesterDuties(s, epoch, validators)
+	if err != nil {
+		return err
+	}
+	syncDuties := map[uint64][]SyncCommitteeDuty{}
+	for slot := epoch * v.beaconConfig.SlotsPerEpoch; slot < (epoch+1)*v.beaconConfig.SlotsPerEpoch; slot++ {
+		period := v.syncCommitteePeriod(slot)
+		if _, ok := syncDuties[period]; ok {
+			continue
+		}
+		if syncDuties[period], err = SyncCommitteeDuties(s, slot, validators); err != nil {
+			return err
+		}
+	}
+	v.hasDuties, v.dutiesEpoch = true, epoch
+	v.proposerDuties, v.attesterDuties, v.syncDuties = proposerDuties, attesterDuties, syncDuties
+	log.Debug("[Validator] Computed duties", "epoch", epoch, "validators", len(validators), "proposals", len(proposerDuties), "attestations", len(attesterDuties))
+	return nil
+}
+
+// syncCommitteePeriod is the period of the sync committee signing at the given slot.
+func (v *Service) syncCommitteePeriod(slot uint64) uint64 {
+	return (slot + 1) / v.beaconConfig.SlotsPerEpoch / v.beaconConfig.EpochsPerSyncCommitteePeriod
+}
+
+// headStateAtEpoch returns the head root and a copy of its state, advanced to the given epoch if it is behind.
+func (v *Service) headStateAtEpoch(epoch uint64) (libcommon.Hash, *state.BeaconState, error) {
+	headRoot, _, err := v.forkChoice.GetHead()
+	if err != nil {
+		return libcommon.Hash{}, nil, err
+	}
+	s, err := v.forkChoice.GetFullState(headRoot)
+	if err != nil {
+		return libcommon.Hash{}, nil, err
+	}
+	if s == nil {
+		return libcommon.Hash{}, nil, fmt.Errorf("cannot reconstruct the state of head %x", headRoot)
+	}
+	if epochStart := epoch * v.beaconConfig.SlotsPerEpoch; s.Slot() < epochStart {
+		if err := transition.ProcessSlots(s, epochStart); err != nil {
+			return libcommon.Hash{}, nil, err
+		}
+	}
+	return headRoot, s, nil
+}
+
+func (v *Service) propose(slot uint64) {
+	for _, duty := range v.proposerDuties {
+		if duty.Slot != slot {
+			continue
+		}
+		block, sidecars, err := v.produceBlock(duty)
+		if err != nil {
+			log.Warn("[Validator] Could not produce block", "slot", slot, "validator", duty.ValidatorIndex, "err", err)
+			return
+		}
+		if err := v.forkChoice.OnBlock(block, true, true); err != nil {
+			log.Warn("[Validator] Produced an invalid block", "slot", slot, "validator", duty.ValidatorIndex, "err", err)
+			return
+		}
+		if v.blobStore != nil {
+			if err := v.blobStore.OnBlock(block.Block); err != nil {
+				log.Warn("[Validator] Could not store the blob sidecars of our block", "slot", slot, "err", err)
+			}
+		}
+		block.Block.Body.Attestations.Range(func(_ int, attestation *solid.Attestation, _ int) bool {
+			if err := v.forkChoice.OnAttestation(attestation, true); err != nil {
+				log.Debug("[Validator] Attestation of our block rejected by fork choice", "err", err)
+			}
+			return true
+		})
+		encoded, err := block.EncodeSSZ(nil)
+		if err != nil {
+			log.Warn("[Validator] Could not encode block", "slot", slot, "err", err)
+			return
+		}
+		if _, err := v.sentinel.PublishGossip(v.ctx, &sentinel.GossipData{
+			Data: encoded,
+			Type: sentinel.GossipType_BeaconBlockGossipType,
+		}); err != nil {
+			log.Warn("[Validator] Could not publish block", "slot", slot, "err", err)
+			return
+		}
+		v.publishBlobSidecars(sidecars)
+		blockRoot, _ := block.Block.HashSSZ()
+		log.Info("[Validator] Proposed block", "slot", slot, "validator", duty.ValidatorIndex, "root", libcommon.Hash(blockRoot),
+			"attestations", block.Block.Body.Attestations.Len(), "deposits", block.Block.Body.Deposits.Len(), "blobs", len(sidecars))
+		return
+	}
+}
+
+// publishBlobSidecars stores and publishes the sidecars of the blobs of our block, each on its subnet.
+func (v *Service) publishBlobSidecars(sidecars []*cltypes.SignedBlobSidecar) {
+	for _, sidecar := range sidecars {
+		if v.blobStore != nil {
+			if err := v.blobStore.OnBlobSidecar(sidecar.Message); err != nil {
+				log.Warn("[Validator] Could not store blob sidecar", "slot", sidecar.Message.Slot, "index", sidecar.Message.Index, "err", err)
+			}
+		}
+		encoded, err := sidecar.EncodeSSZ(nil)
+		if err != nil {
+			log.Warn("[Validator] Could not encode blob sidecar", "slot", sidecar.Message.Slot, "err", err)
+			return
+		}
+		index := uint32(sidecar.Message.Index)
+		if _, err := v.sentinel.PublishGossip(v.ctx, &sentinel.GossipData{
+			Data:      encoded,
+			Type:      sentinel.GossipType_BlobSidecarType,
+			BlobIndex: &index,
+		}); err != nil {
+			log.Warn("[Validator] Could not publish blob sidecar", "slot", sidecar.Message.Slot, "index", index, "err", err)
+		}
+	}
+}
+
+// attest signs and publishes the attestations and sync committee messages of our validators for the given slot.
+func (v *Service) attest(slot uint64) error {
+	var duties []AttesterDuty
+	for _, duty := range v.attesterDuties {
+		if duty.Slot == slot {
+			duties = append(duties, duty)
+		}
+	}
+	syncDuties := v.syncDuties[v.syncCommitteePeriod(slot)]
+	if len(duties) == 0 && len(syncDuties) == 0 {
+		return nil
+	}
+	epoch := slot / v.beaconConfig.SlotsPerEpoch
+	headRoot, s, err := v.headStateAtEpoch(epoch)
+	if err != nil {
+		return err
+	}
+	if len(duties) > 0 {
+		if err := v.publishAttestations(s, headRoot, epoch, duties); err != nil {
+			return err
+		}
+	}
+	if len(syncDuties) > 0 {
+		return v.publishSyncCommitteeMessages(s, headRoot, slot, syncDuties)
+	}
+	return nil
+}
+
+func (v *Service) publishAttestations(s *state.BeaconState, headRoot libcommon.Hash, epoch uint64, duties []AttesterDuty) error {
+	targetRoot := headRoot
+	if epochStart := epoch * v.beaconConfig.SlotsPerEpoch; epochStart < s.Slot() {
+		var err error
+		if targetRoot, err = s.GetBlockRootAtSlot(epochStart); err != nil {
+			return err
+		}
+	}
+	target := solid.NewCheckpointFromParameters(targetRoot, epoch)
+	domain, err := s.GetDomain(v.beaconConfig.DomainBeaconAttester, epoch)
+	if err != nil {
+		return err
+	}
+	for _, duty := range duties {
+		data := solid.NewAttestionDataFromParameters(duty.Slot, duty.CommitteeIndex, headRoot, s.CurrentJustifiedCheckpoint().Copy(), target)
+		signingRoot, err := fork.ComputeSigningRoot(data, domain)
+		if err != nil {
+			return err
+		}
+		if err := v.cfg.SlashingProtection.CheckAndRecordAttestation(duty.Key.PublicKey, data.Source().Epoch(), epoch, signingRoot); err != nil {
+			log.Warn("[Validator] Refused to attest", "slot", duty.Slot, "validator", duty.ValidatorIndex, "err", err)
+			continue
+		}
+		// the aggregation bits are a bitlist with our single bit set, followed by its length delimiter
+		aggregationBits := make([]byte, duty.CommitteeLength/8+1)
+		aggregationBits[duty.ValidatorCommitteeIndex/8] |= 1 << (duty.ValidatorCommitteeIndex % 8)
+		aggregationBits[duty.CommitteeLength/8] |= 1 << (duty.CommitteeLength % 8)
+		attestation := solid.NewAttestionFromParameters(aggregationBits, data, duty.Key.Sign(signingRoot))
+
+		encoded, err := attestation.EncodeSSZ(nil)
+		if err != nil {
+			return err
+		}
+		subnet := uint32(gossip.ComputeSubnetForAttestation(duty.CommitteesAtSlot, duty.Slot, duty.CommitteeIndex, v.beaconConfig.SlotsPerEpoch, attestationSubnetCount))
+		if _, err := v.sentinel.PublishGossip(v.ctx, &sentinel.GossipData{
+			Data:      encoded,
+			Type:      gossip.AttestationGossipType,
+			BlobIndex: &subnet,
+		}); err != nil {
+			log.Warn("[Validator] Could not publish attestation", "slot", duty.Slot, "validator", duty.ValidatorIndex, "err", err)
+		}
+		v.mu.Lock()
+		v.attestations = append(v.attestations, attestation)
+		v.mu.Unlock()
+	}
+	log.Debug("[Validator] Attested", "slot", duties[0].Slot, "attestations", len(duties), "head", headRoot)
+	return nil
+}
+
+func (v *Service) publishSyncCommitteeMessages(s *state.BeaconState, headRoot libcommon.Hash, slot uint64, duties []SyncCommitteeDuty) error {
+	domain, err := s.GetDomain(v.beaconConfig.DomainSyncCommittee, slot/v.beaconConfig.SlotsPerEpoch)
+	if err != nil {
+		return err
+	}
+	// the signing root of a block root is the block root itself mixed with the domain
+	signingRoot := utils.Keccak256(headRoot[:], domain)
+	subnetSize := gossip.SyncCommitteeSubnetSize(v.beaconConfig)
+	for _, duty := range duties {
+		signature := duty.Key.Sign(signingRoot)
+		msg := &cltypes.SyncCommitteeMessage{
+			Slot:            slot,
+			BeaconBlockRoot: headRoot,
+			ValidatorIndex:  duty.ValidatorIndex,
+			Signature:       signature,
+		}
+		encoded, err := msg.EncodeSSZ(nil)
+		if err != nil {
+			return err
+		}
+		published := map[uint64]struct{}{}
+		for _, position := range duty.Positions {
+			subnet := position / subnetSize
+			if _, ok := published[subnet]; ok {
+				continue
+			}
+			published[subnet] = struct{}{}
+			index := uint32(subnet)
+			if _, err := v.sentinel.PublishGossip(v.ctx, &sentinel.GossipData{
+				Data:      encoded,
+				Type:      gossip.SyncCommitteeGossipType,
+				BlobIndex: &index,
+			}); err != nil {
+				log.Warn("[Validator] Could not publish sync committee message", "slot", slot, "validator", duty.ValidatorIndex, "err", err)
+			}
+		}
+		v.mu.Lock()
+		v.syncMessages = append(v.syncMessages, &syncCommitteeSignature{
+			slot:      slot,
+			blockRoot: headRoot,
+			positions: duty.Positions,
+			signature: signature,
+		})
+		v.mu.Unlock()
+	}
+	return nil
+}
+
+// pruneOperations forgets what our validators signed and can no longer be included in a block.
+func (v *Service) pruneOperations(slot uint64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	attestations := v.attestations[:0]
+	for _, attestation := range v.attestations {
+		if attestation.AttestantionData().Slot()+v.beaconConfig.SlotsPerEpoch >= slot {
+			attestations = append(attestations, attestation)
+		}
+	}
+	v.attestations = attestations
+	syncMessages := v.syncMessages[:0]
+	for _, msg := range v.syncMessages {
+		if msg.slot+1 >= slot {
+			syncMessages = append(syncMessages, msg)
+		}
+	}
+	v.syncMessages = syncMessages
+}
@@ -0,0 +1,52 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/Giulio2002/bls"
+)
+
+// Key is the BLS secret key of a validator.
+type Key struct {
+	privateKey *bls.PrivateKey
+	PublicKey  [48]byte
+}
+
+// NewKey parses a 32 bytes big endian BLS secret key.
+func NewKey(secret []byte) (*Key, error) {
+	privateKey, err := bls.NewPrivateKeyFromBytes(secret)
+	if err != nil {
+		return nil, err
+	}
+	key := &Key{privateKey: privateKey}
+	copy(key.PublicKey[:], bls.CompressPublicKey(privateKey.PublicKey()))
+	return key, nil
+}
+
+// Sign signs a signing root.
+func (k *Key) Sign(signingRoot [32]byte) (signature [96]byte) {
+	copy(signature[:], k.privateKey.Sign(signingRoot[:]).Bytes())
+	return
+}
+
+// aggregateSignatures aggregates the signatures of the same message by different keys.
+func aggregateSignatures(signatures [][96]byte) ([96]byte, error) {
+	if len(signatures) == 0 {
+		return bls.InfiniteSignature, nil
+	}
+	compressed := make([][]byte, len(signatures))
+	for i := range signatures {
+		compressed[i] = signatures[i][:]
+	}
+	aggregate, err := bls.AggregateSignatures(compressed)
+	if err != nil {
+		return [96]byte{}, fmt.Errorf("cannot aggregate signatures: %w", err)
+	}
+	var signature [96]byte
+	copy(signature[:], aggregate)
+	return signature, nil
+}
+
+func (k *Key) String() string {
+	return fmt.Sprintf("%x", k.PublicKey)
+}
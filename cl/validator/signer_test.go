@@ -0,0 +1,42 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/Giulio2002/bls"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndAggregate(t *testing.T) {
+	var keys []*Key
+	for i := byte(1); i <= 3; i++ {
+		key, err := NewKey(append(make([]byte, 31), i))
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	root := [32]byte{0xde, 0xad}
+
+	signatures := make([][96]byte, 0, len(keys))
+	publicKeys := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		signature := key.Sign(root)
+		valid, err := bls.Verify(signature[:], root[:], key.PublicKey[:])
+		require.NoError(t, err)
+		require.True(t, valid)
+		signatures = append(signatures, signature)
+		publicKeys = append(publicKeys, key.PublicKey[:])
+	}
+
+	aggregate, err := aggregateSignatures(signatures)
+	require.NoError(t, err)
+	valid, err := bls.VerifyAggregate(aggregate[:], root[:], publicKeys)
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	empty, err := aggregateSignatures(nil)
+	require.NoError(t, err)
+	require.Equal(t, bls.InfiniteSignature, empty)
+
+	_, err = NewKey(make([]byte, 32))
+	require.Error(t, err)
+}
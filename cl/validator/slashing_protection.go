@@ -0,0 +1,414 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// interchangeFormatVersion is the version of the EIP-3076 interchange format we read and write.
+const interchangeFormatVersion = "5"
+
+const (
+	journalBlockRecord       byte = 1
+	journalAttestationRecord byte = 2
+	// journalRecordSize is the size of a journal record: its type, the pubkey, the slot or the source and target
+	// epochs, and the signing root.
+	journalRecordSize = 1 + 48 + 8 + 8 + 32
+	// maxJournalRecords is the amount of records after which the journal is compacted into the interchange file.
+	maxJournalRecords = 4096
+)
+
+var (
+	ErrSlashableBlock       = errors.New("refusing to sign a slashable block")
+	ErrSlashableAttestation = errors.New("refusing to sign a slashable attestation")
+)
+
+// Interchange is an EIP-3076 slashing protection interchange file.
+type Interchange struct {
+	Metadata struct {
+		InterchangeFormatVersion string `json:"interchange_format_version"`
+		GenesisValidatorsRoot    string `json:"genesis_validators_root"`
+	} `json:"metadata"`
+	Data []*InterchangeRecord `json:"data"`
+}
+
+// InterchangeRecord is the signing history of one validator.
+type InterchangeRecord struct {
+	Pubkey             string                          `json:"pubkey"`
+	SignedBlocks       []*InterchangeSignedBlock       `json:"signed_blocks"`
+	SignedAttestations []*InterchangeSignedAttestation `json:"signed_attestations"`
+}
+
+type InterchangeSignedBlock struct {
+	Slot        uint64 `json:"slot,string"`
+	SigningRoot string `json:"signing_root,omitempty"`
+}
+
+type InterchangeSignedAttestation struct {
+	SourceEpoch uint64 `json:"source_epoch,string"`
+	TargetEpoch uint64 `json:"target_epoch,string"`
+	SigningRoot string `json:"signing_root,omitempty"`
+}
+
+// signingHistory keeps the watermarks of a validator: nothing at or below them is ever signed again, except for
+// the very same message.
+type signingHistory struct {
+	hasBlock        bool
+	blockSlot       uint64
+	blockRoot       [32]byte
+	hasAttestation  bool
+	sourceEpoch     uint64
+	targetEpoch     uint64
+	attestationRoot [32]byte
+}
+
+// SlashingProtection refuses to sign blocks and attestations which could get our validators slashed. It only
+// keeps the highest block slot and attestation epochs signed by each validator, the minimal strategy of EIP-3076.
+// The watermarks are persisted as an interchange file, and every signature is appended to a journal next to it,
+// which is folded back into the interchange file when it grows too large and when the database is opened.
+type SlashingProtection struct {
+	mu                    sync.Mutex
+	path                  string
+	genesisValidatorsRoot [32]byte
+	history               map[[48]byte]*signingHistory
+	journal               *os.File
+	journalRecords        int
+}
+
+// OpenSlashingProtection loads the slashing protection database at path, creating it if it does not exist.
+func OpenSlashingProtection(path string, genesisValidatorsRoot [32]byte) (*SlashingProtection, error) {
+	s := &SlashingProtection{
+		path:                  path,
+		genesisValidatorsRoot: genesisValidatorsRoot,
+		history:               map[[48]byte]*signingHistory{},
+	}
+	f, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		defer f.Close()
+		if err := s.merge(f); err != nil {
+			return nil, fmt.Errorf("invalid slashing protection database %s: %w", path, err)
+		}
+	}
+	if err := s.replayJournal(); err != nil {
+		return nil, fmt.Errorf("invalid slashing protection journal %s: %w", s.journalPath(), err)
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the journal of the database.
+func (s *SlashingProtection) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.journal == nil {
+		return nil
+	}
+	err := s.journal.Close()
+	s.journal = nil
+	return err
+}
+
+// CheckAndRecordBlock records the signing of a block, or refuses it if it is slashable.
+func (s *SlashingProtection) CheckAndRecordBlock(pubkey [48]byte, slot uint64, signingRoot [32]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := s.getHistory(pubkey)
+	if h.hasBlock && (slot < h.blockSlot || (slot == h.blockSlot && (signingRoot != h.blockRoot || signingRoot == [32]byte{}))) {
+		return fmt.Errorf("%w: slot %d, already signed slot %d", ErrSlashableBlock, slot, h.blockSlot)
+	}
+	if err := s.appendJournal(journalBlockRecord, pubkey, slot, 0, signingRoot); err != nil {
+		return err
+	}
+	h.hasBlock, h.blockSlot, h.blockRoot = true, slot, signingRoot
+	return s.maybeCompact()
+}
+
+// CheckAndRecordAttestation records the signing of an attestation, or refuses it if it is slashable.
+func (s *SlashingProtection) CheckAndRecordAttestation(pubkey [48]byte, sourceEpoch, targetEpoch uint64, signingRoot [32]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sourceEpoch > targetEpoch {
+		return fmt.Errorf("%w: source epoch %d is after target epoch %d", ErrSlashableAttestation, sourceEpoch, targetEpoch)
+	}
+	h := s.getHistory(pubkey)
+	if h.hasAttestation {
+		if sourceEpoch < h.sourceEpoch {
+			return fmt.Errorf("%w: source epoch %d, already signed source epoch %d", ErrSlashableAttestation, sourceEpoch, h.sourceEpoch)
+		}
+		if targetEpoch < h.targetEpoch || (targetEpoch == h.targetEpoch && (signingRoot != h.attestationRoot || signingRoot == [32]byte{})) {
+			return fmt.Errorf("%w: target epoch %d, already signed target epoch %d", ErrSlashableAttestation, targetEpoch, h.targetEpoch)
+		}
+	}
+	if err := s.appendJournal(journalAttestationRecord, pubkey, sourceEpoch, targetEpoch, signingRoot); err != nil {
+		return err
+	}
+	h.hasAttestation, h.sourceEpoch, h.targetEpoch, h.attestationRoot = true, sourceEpoch, targetEpoch, signingRoot
+	return s.maybeCompact()
+}
+
+// Import merges an interchange file into the database, keeping the highest watermarks of each validator.
+func (s *SlashingProtection) Import(r io.Reader) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.merge(r); err != nil {
+		return err
+	}
+	return s.compact()
+}
+
+// Export writes the database as an interchange file.
+func (s *SlashingProtection) Export(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.export(w)
+}
+
+func (s *SlashingProtection) getHistory(pubkey [48]byte) *signingHistory {
+	h, ok := s.history[pubkey]
+	if !ok {
+		h = &signingHistory{}
+		s.history[pubkey] = h
+	}
+	return h
+}
+
+func (s *SlashingProtection) merge(r io.Reader) error {
+	interchange := &Interchange{}
+	if err := json.NewDecoder(r).Decode(interchange); err != nil {
+		return err
+	}
+	if interchange.Metadata.InterchangeFormatVersion != interchangeFormatVersion {
+		return fmt.Errorf("unsupported interchange format version %q", interchange.Metadata.InterchangeFormatVersion)
+	}
+	genesisValidatorsRoot, err := decodeHex(interchange.Metadata.GenesisValidatorsRoot, 32)
+	if err != nil {
+		return fmt.Errorf("invalid genesis validators root: %w", err)
+	}
+	if !bytes.Equal(genesisValidatorsRoot, s.genesisValidatorsRoot[:]) {
+		return fmt.Errorf("interchange is for genesis validators root %s, expected %x", interchange.Metadata.GenesisValidatorsRoot, s.genesisValidatorsRoot)
+	}
+
+	for _, record := range interchange.Data {
+		rawPubkey, err := decodeHex(record.Pubkey, 48)
+		if err != nil {
+			return fmt.Errorf("invalid pubkey %s: %w", record.Pubkey, err)
+		}
+		var pubkey [48]byte
+		copy(pubkey[:], rawPubkey)
+		h := s.getHistory(pubkey)
+		for _, block := range record.SignedBlocks {
+			root, err := decodeOptionalRoot(block.SigningRoot)
+			if err != nil {
+				return err
+			}
+			if !h.hasBlock || block.Slot > h.blockSlot {
+				h.hasBlock, h.blockSlot, h.blockRoot = true, block.Slot, root
+			} else if block.Slot == h.blockSlot && root != h.blockRoot {
+				// two different blocks at the same slot, none of them can be signed again
+				h.blockRoot = [32]byte{}
+			}
+		}
+		for _, attestation := range record.SignedAttestations {
+			root, err := decodeOptionalRoot(attestation.SigningRoot)
+			if err != nil {
+				return err
+			}
+			if attestation.SourceEpoch > attestation.TargetEpoch {
+				return fmt.Errorf("attestation of %s has source epoch %d after target epoch %d", record.Pubkey, attestation.SourceEpoch, attestation.TargetEpoch)
+			}
+			if !h.hasAttestation || attestation.SourceEpoch > h.sourceEpoch {
+				h.sourceEpoch = attestation.SourceEpoch
+			}
+			if !h.hasAttestation || attestation.TargetEpoch > h.targetEpoch {
+				h.targetEpoch, h.attestationRoot = attestation.TargetEpoch, root
+			} else if attestation.TargetEpoch == h.targetEpoch && root != h.attestationRoot {
+				h.attestationRoot = [32]byte{}
+			}
+			h.hasAttestation = true
+		}
+	}
+	return nil
+}
+
+func (s *SlashingProtection) export(w io.Writer) error {
+	interchange := &Interchange{Data: []*InterchangeRecord{}}
+	interchange.Metadata.InterchangeFormatVersion = interchangeFormatVersion
+	interchange.Metadata.GenesisValidatorsRoot = encodeHex(s.genesisValidatorsRoot[:])
+	for pubkey, h := range s.history {
+		record := &InterchangeRecord{
+			Pubkey:             encodeHex(pubkey[:]),
+			SignedBlocks:       []*InterchangeSignedBlock{},
+			SignedAttestations: []*InterchangeSignedAttestation{},
+		}
+		if h.hasBlock {
+			record.SignedBlocks = append(record.SignedBlocks, &InterchangeSignedBlock{
+				Slot:        h.blockSlot,
+				SigningRoot: encodeOptionalRoot(h.blockRoot),
+			})
+		}
+		if h.hasAttestation {
+			record.SignedAttestations = append(record.SignedAttestations, &InterchangeSignedAttestation{
+				SourceEpoch: h.sourceEpoch,
+				TargetEpoch: h.targetEpoch,
+				SigningRoot: encodeOptionalRoot(h.attestationRoot),
+			})
+		}
+		interchange.Data = append(interchange.Data, record)
+	}
+	sort.Slice(interchange.Data, func(i, j int) bool {
+		return interchange.Data[i].Pubkey < interchange.Data[j].Pubkey
+	})
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(interchange)
+}
+
+func (s *SlashingProtection) journalPath() string {
+	return s.path + ".journal"
+}
+
+// appendJournal durably records a signature before it is released.
+func (s *SlashingProtection) appendJournal(recordType byte, pubkey [48]byte, a, b uint64, signingRoot [32]byte) error {
+	if s.journal == nil {
+		return errors.New("slashing protection database is closed")
+	}
+	var record [journalRecordSize]byte
+	record[0] = recordType
+	copy(record[1:49], pubkey[:])
+	binary.BigEndian.PutUint64(record[49:57], a)
+	binary.BigEndian.PutUint64(record[57:65], b)
+	copy(record[65:], signingRoot[:])
+	if _, err := s.journal.Write(record[:]); err != nil {
+		return err
+	}
+	if err := s.journal.Sync(); err != nil {
+		return err
+	}
+	s.journalRecords++
+	return nil
+}
+
+// replayJournal applies the signatures recorded since the last compaction. A truncated trailing record, left by a
+// crash in the middle of a write, was never released and is ignored.
+func (s *SlashingProtection) replayJournal() error {
+	data, err := os.ReadFile(s.journalPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for ; len(data) >= journalRecordSize; data = data[journalRecordSize:] {
+		var pubkey [48]byte
+		var signingRoot [32]byte
+		copy(pubkey[:], data[1:49])
+		copy(signingRoot[:], data[65:journalRecordSize])
+		a, b := binary.BigEndian.Uint64(data[49:57]), binary.BigEndian.Uint64(data[57:65])
+		h := s.getHistory(pubkey)
+		switch data[0] {
+		case journalBlockRecord:
+			if !h.hasBlock || a >= h.blockSlot {
+				h.hasBlock, h.blockSlot, h.blockRoot = true, a, signingRoot
+			}
+		case journalAttestationRecord:
+			if !h.hasAttestation || b >= h.targetEpoch {
+				h.hasAttestation, h.sourceEpoch, h.targetEpoch, h.attestationRoot = true, a, b, signingRoot
+			}
+		default:
+			return fmt.Errorf("unknown record type %d", data[0])
+		}
+	}
+	return nil
+}
+
+func (s *SlashingProtection) maybeCompact() error {
+	if s.journalRecords < maxJournalRecords {
+		return nil
+	}
+	return s.compact()
+}
+
+// compact atomically replaces the interchange file with the current watermarks, and starts a new journal.
+func (s *SlashingProtection) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := s.export(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	if s.journal != nil {
+		if err := s.journal.Close(); err != nil {
+			return err
+		}
+		s.journal = nil
+	}
+	journal, err := os.OpenFile(s.journalPath(), os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	s.journal, s.journalRecords = journal, 0
+	return nil
+}
+
+func decodeHex(s string, length int) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != length {
+		return nil, fmt.Errorf("expected %d bytes, got %d", length, len(b))
+	}
+	return b, nil
+}
+
+func encodeHex(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// decodeOptionalRoot decodes a signing root, which is all zeroes when unknown.
+func decodeOptionalRoot(s string) (root [32]byte, err error) {
+	if s == "" {
+		return
+	}
+	b, err := decodeHex(s, 32)
+	if err != nil {
+		return root, fmt.Errorf("invalid signing root %s: %w", s, err)
+	}
+	copy(root[:], b)
+	return
+}
+
+func encodeOptionalRoot(root [32]byte) string {
+	if root == ([32]byte{}) {
+		return ""
+	}
+	return encodeHex(root[:])
+}
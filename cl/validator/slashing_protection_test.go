@@ -0,0 +1,113 @@
+package validator_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/validator"
+)
+
+var (
+	testGenesisValidatorsRoot = [32]byte{0x04, 0x70}
+	testPubkey                = [48]byte{0xa9}
+)
+
+func TestSlashingProtectionBlocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slashing_protection.json")
+	s, err := validator.OpenSlashingProtection(path, testGenesisValidatorsRoot)
+	require.NoError(t, err)
+
+	require.NoError(t, s.CheckAndRecordBlock(testPubkey, 10, [32]byte{1}))
+	// the very same block can be signed again
+	require.NoError(t, s.CheckAndRecordBlock(testPubkey, 10, [32]byte{1}))
+	require.True(t, errors.Is(s.CheckAndRecordBlock(testPubkey, 10, [32]byte{2}), validator.ErrSlashableBlock))
+	require.True(t, errors.Is(s.CheckAndRecordBlock(testPubkey, 9, [32]byte{3}), validator.ErrSlashableBlock))
+	require.NoError(t, s.CheckAndRecordBlock(testPubkey, 11, [32]byte{4}))
+	// other validators are not affected
+	require.NoError(t, s.CheckAndRecordBlock([48]byte{0xb0}, 1, [32]byte{5}))
+
+	// the history survives restarts
+	s, err = validator.OpenSlashingProtection(path, testGenesisValidatorsRoot)
+	require.NoError(t, err)
+	require.True(t, errors.Is(s.CheckAndRecordBlock(testPubkey, 11, [32]byte{6}), validator.ErrSlashableBlock))
+	require.NoError(t, s.CheckAndRecordBlock(testPubkey, 11, [32]byte{4}))
+	require.NoError(t, s.CheckAndRecordBlock(testPubkey, 12, [32]byte{7}))
+	require.NoError(t, s.Close())
+
+	// a record torn by a crash is ignored
+	journal, err := os.OpenFile(path+".journal", os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = journal.Write([]byte{1, 0xa9, 0, 0})
+	require.NoError(t, err)
+	require.NoError(t, journal.Close())
+	s, err = validator.OpenSlashingProtection(path, testGenesisValidatorsRoot)
+	require.NoError(t, err)
+	require.True(t, errors.Is(s.CheckAndRecordBlock(testPubkey, 12, [32]byte{8}), validator.ErrSlashableBlock))
+	require.NoError(t, s.Close())
+
+	_, err = validator.OpenSlashingProtection(path, [32]byte{0xff})
+	require.Error(t, err)
+}
+
+func TestSlashingProtectionAttestations(t *testing.T) {
+	s, err := validator.OpenSlashingProtection(filepath.Join(t.TempDir(), "slashing_protection.json"), testGenesisValidatorsRoot)
+	require.NoError(t, err)
+
+	require.NoError(t, s.CheckAndRecordAttestation(testPubkey, 2, 3, [32]byte{1}))
+	require.NoError(t, s.CheckAndRecordAttestation(testPubkey, 2, 3, [32]byte{1}))
+	// double vote
+	require.True(t, errors.Is(s.CheckAndRecordAttestation(testPubkey, 2, 3, [32]byte{2}), validator.ErrSlashableAttestation))
+	// surrounded and surrounding votes
+	require.True(t, errors.Is(s.CheckAndRecordAttestation(testPubkey, 1, 4, [32]byte{3}), validator.ErrSlashableAttestation))
+	require.True(t, errors.Is(s.CheckAndRecordAttestation(testPubkey, 3, 2, [32]byte{4}), validator.ErrSlashableAttestation))
+	require.True(t, errors.Is(s.CheckAndRecordAttestation(testPubkey, 2, 2, [32]byte{5}), validator.ErrSlashableAttestation))
+	require.NoError(t, s.CheckAndRecordAttestation(testPubkey, 3, 4, [32]byte{6}))
+}
+
+func TestSlashingProtectionInterchange(t *testing.T) {
+	interchange := `{
+  "metadata": {
+    "interchange_format_version": "5",
+    "genesis_validators_root": "0x0470000000000000000000000000000000000000000000000000000000000000"
+  },
+  "data": [
+    {
+      "pubkey": "0xa90000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+      "signed_blocks": [
+        {"slot": "81952", "signing_root": "0x4ff6f743a43f3b4f95350831aeaf0a122a1a392922c45d804280284a69eb850b"},
+        {"slot": "81951"}
+      ],
+      "signed_attestations": [
+        {"source_epoch": "2290", "target_epoch": "3007", "signing_root": "0x587d6a4f59a58fe24f406e0502413e77fe1babddee641fda30034ed37ecc884d"},
+        {"source_epoch": "2291", "target_epoch": "3001"}
+      ]
+    }
+  ]
+}`
+	s, err := validator.OpenSlashingProtection(filepath.Join(t.TempDir(), "slashing_protection.json"), testGenesisValidatorsRoot)
+	require.NoError(t, err)
+	require.NoError(t, s.Import(strings.NewReader(interchange)))
+
+	require.True(t, errors.Is(s.CheckAndRecordBlock(testPubkey, 81951, [32]byte{1}), validator.ErrSlashableBlock))
+	require.True(t, errors.Is(s.CheckAndRecordAttestation(testPubkey, 2290, 3008, [32]byte{1}), validator.ErrSlashableAttestation))
+	require.True(t, errors.Is(s.CheckAndRecordAttestation(testPubkey, 2291, 3006, [32]byte{1}), validator.ErrSlashableAttestation))
+	require.NoError(t, s.CheckAndRecordAttestation(testPubkey, 2291, 3008, [32]byte{1}))
+
+	var exported bytes.Buffer
+	require.NoError(t, s.Export(&exported))
+	other, err := validator.OpenSlashingProtection(filepath.Join(t.TempDir(), "slashing_protection.json"), testGenesisValidatorsRoot)
+	require.NoError(t, err)
+	require.NoError(t, other.Import(&exported))
+	require.True(t, errors.Is(other.CheckAndRecordBlock(testPubkey, 81952, [32]byte{2}), validator.ErrSlashableBlock))
+	require.NoError(t, other.CheckAndRecordBlock(testPubkey, 81953, [32]byte{2}))
+	require.True(t, errors.Is(other.CheckAndRecordAttestation(testPubkey, 2291, 3008, [32]byte{2}), validator.ErrSlashableAttestation))
+
+	wrongGenesis := strings.Replace(interchange, "0x0470", "0x0471", 1)
+	require.Error(t, other.Import(strings.NewReader(wrongGenesis)))
+}
@@ -3,16 +3,27 @@ package caplin1
 import (
 	"context"
 
+	"github.com/ledgerwatch/erigon/cl/beacon"
+	"github.com/ledgerwatch/erigon/cl/beacon/beaconevents"
+	"github.com/ledgerwatch/erigon/cl/beacon/handler"
 	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
 	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/blob_storage"
+	"github.com/ledgerwatch/erigon/cl/phase1/block_indexer"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
 	"github.com/ledgerwatch/erigon/cl/phase1/execution_client"
 	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice"
+	"github.com/ledgerwatch/erigon/cl/phase1/light_client"
 	network2 "github.com/ledgerwatch/erigon/cl/phase1/network"
 	"github.com/ledgerwatch/erigon/cl/phase1/stages"
+	"github.com/ledgerwatch/erigon/cl/phase1/state_regen"
+	"github.com/ledgerwatch/erigon/cl/validator"
 
 	"github.com/Giulio2002/bls"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/rpc"
 	"github.com/ledgerwatch/log/v3"
@@ -21,7 +32,8 @@ import (
 )
 
 func RunCaplinPhase1(ctx context.Context, sentinel sentinel.SentinelClient, beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig,
-	engine execution_client.ExecutionEngine, state *state.BeaconState, caplinFreezer freezer.Freezer) error {
+	engine execution_client.ExecutionEngine, state *state.BeaconState, caplinFreezer freezer.Freezer, beaconApiCfg *beacon.RouterConfiguration, validatorCfg *validator.Config, stateRegen *state_regen.Regenerator,
+	lightClient *light_client.Store, blobStore *blob_storage.BlobStore, indicesDB kv.RwDB) error {
 	beaconRpc := rpc.NewBeaconRpcP2P(ctx, sentinel, beaconConfig, genesisConfig)
 	downloader := network2.NewForwardBeaconDownloader(ctx, beaconRpc)
 
@@ -30,7 +42,8 @@ func RunCaplinPhase1(ctx context.Context, sentinel sentinel.SentinelClient, beac
 			return err
 		}
 	}
-	forkChoice, err := forkchoice.NewForkChoiceStore(state, engine, caplinFreezer, true)
+	emitter := beaconevents.NewEmitter()
+	forkChoice, err := forkchoice.NewForkChoiceStore(state, engine, caplinFreezer, emitter, lightClient, true)
 	if err != nil {
 		log.Error("Could not create forkchoice", "err", err)
 		return err
@@ -43,6 +56,62 @@ func RunCaplinPhase1(ctx context.Context, sentinel sentinel.SentinelClient, beac
 		}
 		return true
 	})
-	gossipManager := network2.NewGossipReceiver(ctx, sentinel, forkChoice, beaconConfig, genesisConfig, caplinFreezer)
+	if stateRegen != nil {
+		if err := stateRegen.Start(ctx, state, forkChoice, emitter); err != nil {
+			return err
+		}
+		log.Info("State regeneration started")
+	}
+	if indicesDB != nil {
+		if err := block_indexer.NewIndexer(indicesDB, beaconConfig).Start(ctx, forkChoice, emitter); err != nil {
+			return err
+		}
+		log.Info("Block indexing started")
+	}
+	if lightClient != nil {
+		go lightClient.Run(ctx)
+		go light_client.PublishGossip(ctx, sentinel, emitter)
+		log.Info("Light client server started")
+	}
+	if beaconApiCfg != nil {
+		apiHandler := handler.NewApiHandler(genesisConfig, beaconConfig, forkChoice, emitter, indicesDB, stateRegen, lightClient, blobStore)
+		go beacon.ListenAndServe(apiHandler, beaconApiCfg)
+		log.Info("Beacon API started", "addr", beaconApiCfg.Address)
+	}
+	if validatorCfg != nil && len(validatorCfg.Keys) > 0 {
+		builder, _ := engine.(execution_client.PayloadBuilder)
+		go validator.NewService(ctx, validatorCfg, sentinel, forkChoice, builder, blobStore, beaconConfig, genesisConfig).Run()
+		log.Info("Validators started", "keys", len(validatorCfg.Keys))
+	}
+	if blobStore != nil {
+		blobStore.SetCanonicalChain(canonicalChain(ctx, forkChoice, indicesDB))
+		go blobStore.Run(ctx)
+	}
+	gossipManager := network2.NewGossipReceiver(ctx, sentinel, forkChoice, beaconConfig, genesisConfig, caplinFreezer, emitter, blobStore)
 	return stages.SpawnStageForkChoice(stages.StageForkChoice(nil, downloader, genesisConfig, beaconConfig, state, nil, gossipManager, forkChoice, caplinFreezer), &stagedsync.StageState{ID: "Caplin"}, nil, ctx)
 }
+
+// canonicalChain resolves the canonical block of a slot from forkchoice, or from the indices database once forkchoice
+// pruned the slot.
+func canonicalChain(ctx context.Context, forkChoice *forkchoice.ForkChoiceStore, indicesDB kv.RoDB) blob_storage.CanonicalChain {
+	return func(slot uint64) (libcommon.Hash, bool, error) {
+		if root, ok, err := forkChoice.GetCanonicalBlockRoot(slot); err != nil || ok {
+			return root, ok, err
+		}
+		// the slot is empty if forkchoice still holds the chain down to it
+		if _, ok, err := forkChoice.GetCanonicalBlockRootAtOrBefore(slot); err != nil || ok {
+			return libcommon.Hash{}, ok, err
+		}
+		if indicesDB == nil {
+			return libcommon.Hash{}, false, nil
+		}
+		var root libcommon.Hash
+		if err := indicesDB.View(ctx, func(tx kv.Tx) (err error) {
+			root, err = rawdb.ReadFinalizedBlockRoot(tx, slot)
+			return err
+		}); err != nil {
+			return libcommon.Hash{}, false, err
+		}
+		return root, root != (libcommon.Hash{}), nil
+	}
+}
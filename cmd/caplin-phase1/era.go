@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cl/era"
+	lcCli "github.com/ledgerwatch/erigon/cmd/sentinel/cli"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/cli/flags"
+	lightclientapp "github.com/ledgerwatch/erigon/turbo/app"
+	"github.com/ledgerwatch/erigon/turbo/debug"
+)
+
+var (
+	eraDirFlag = cli.StringFlag{
+		Name:  "era.dir",
+		Usage: "directory of the era files",
+		Value: "era",
+	}
+	eraFromFlag = cli.Uint64Flag{
+		Name:  "era.from",
+		Usage: "first era to export",
+		Value: 0,
+	}
+	eraToFlag = cli.Uint64Flag{
+		Name:  "era.to",
+		Usage: "last era to export",
+		Value: 0,
+	}
+)
+
+var eraCommand = cli.Command{
+	Name:  "era",
+	Usage: "Export and import the blocks and states recorded by Caplin as era files",
+	Description: `
+Era files hold the blocks of SLOTS_PER_HISTORICAL_ROOT slots followed by the state at the end of them, which is
+regenerated from the closest recorded state when it was not recorded itself. They are read from and written to the
+recordings selected by the record-dir and record-s3 flags, which caplin-phase1 starts from with the
+checkpoint-sync-records flag.`,
+	Subcommands: []*cli.Command{
+		{
+			Name:   "export",
+			Usage:  "Export the eras from era.from to era.to into era.dir",
+			Action: lightclientapp.MigrateFlags(exportEras),
+			Flags:  append([]cli.Flag{&eraDirFlag, &eraFromFlag, &eraToFlag}, flags.CLDefaultFlags...),
+		},
+		{
+			Name:      "import",
+			Usage:     "Verify and import era files, all those of era.dir if none is given",
+			ArgsUsage: "(<filename> ... <filename N>)",
+			Action:    lightclientapp.MigrateFlags(importEras),
+			Flags:     append([]cli.Flag{&eraDirFlag}, flags.CLDefaultFlags...),
+		},
+	},
+}
+
+func exportEras(cliCtx *cli.Context) error {
+	cfg, err := setupEraCommand(cliCtx)
+	if err != nil {
+		return err
+	}
+	from, to := cliCtx.Uint64(eraFromFlag.Name), cliCtx.Uint64(eraToFlag.Name)
+	if to < from {
+		return fmt.Errorf("era.to %d is before era.from %d", to, from)
+	}
+	dir := cliCtx.String(eraDirFlag.Name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	caplinFreezer := openFreezer(cfg)
+	for e := from; e <= to; e++ {
+		if _, err := era.Export(cliCtx.Context, caplinFreezer, cfg.BeaconCfg, e, dir); err != nil {
+			return fmt.Errorf("could not export era %d: %w", e, err)
+		}
+	}
+	return nil
+}
+
+func importEras(cliCtx *cli.Context) error {
+	cfg, err := setupEraCommand(cliCtx)
+	if err != nil {
+		return err
+	}
+	files := cliCtx.Args().Slice()
+	if len(files) == 0 {
+		if files, err = filepath.Glob(filepath.Join(cliCtx.String(eraDirFlag.Name), "*.era")); err != nil {
+			return err
+		}
+		// era numbers are zero padded, so the names sort in era order
+		sort.Strings(files)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no era file to import")
+	}
+	caplinFreezer := openFreezer(cfg)
+	for _, file := range files {
+		if err := era.Import(cliCtx.Context, caplinFreezer, cfg.BeaconCfg, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setupEraCommand(cliCtx *cli.Context) (*lcCli.ConsensusClientCliCfg, error) {
+	if cliCtx.Context == nil {
+		cliCtx.Context = context.Background()
+	}
+	cfg, err := lcCli.SetupConsensusClientCfg(cliCtx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := debug.Setup(cliCtx, true /* root logger */); err != nil {
+		return nil, err
+	}
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StderrHandler))
+	return cfg, nil
+}
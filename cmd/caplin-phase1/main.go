@@ -17,32 +17,43 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/ledgerwatch/erigon/cl/beacon"
-	"github.com/ledgerwatch/erigon/cl/beacon/handler"
+	"github.com/ledgerwatch/erigon/cl/era"
 	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/blob_storage"
 	"github.com/ledgerwatch/erigon/cl/phase1/core"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
 	"github.com/ledgerwatch/erigon/cl/phase1/execution_client"
+	"github.com/ledgerwatch/erigon/cl/phase1/light_client"
+	"github.com/ledgerwatch/erigon/cl/phase1/state_regen"
+	"github.com/ledgerwatch/erigon/cl/validator"
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/urfave/cli/v2"
 	"google.golang.org/grpc"
 
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/fork"
+	"github.com/ledgerwatch/erigon/cl/rpc"
 	"github.com/ledgerwatch/erigon/cmd/caplin-phase1/caplin1"
 	lcCli "github.com/ledgerwatch/erigon/cmd/sentinel/cli"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/cli/flags"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/service"
+	rpc2 "github.com/ledgerwatch/erigon/rpc"
 	lightclientapp "github.com/ledgerwatch/erigon/turbo/app"
 	"github.com/ledgerwatch/erigon/turbo/debug"
 )
 
 func main() {
 	app := lightclientapp.MakeApp("caplin-phase1", runCaplinNode, flags.CLDefaultFlags)
+	app.Commands = append(app.Commands, &eraCommand)
 	if err := app.Run(os.Args); err != nil {
 		_, printErr := fmt.Fprintln(os.Stderr, err)
 		if printErr != nil {
@@ -57,6 +68,7 @@ func runCaplinNode(cliCtx *cli.Context) error {
 	cfg, err := lcCli.SetupConsensusClientCfg(cliCtx)
 	if err != nil {
 		log.Error("[Phase1] Could not initialize caplin", "err", err)
+		return err
 	}
 	if _, err := debug.Setup(cliCtx, true /* root logger */); err != nil {
 		return err
@@ -64,10 +76,29 @@ func runCaplinNode(cliCtx *cli.Context) error {
 	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(cfg.LogLvl), log.StderrHandler))
 	log.Info("[Phase1]", "chain", cliCtx.String(flags.Chain.Name))
 	log.Info("[Phase1] Running Caplin", "cfg", cfg)
+	if cfg.InitialSync && (cfg.CheckpointState != "" || cfg.CheckpointBlock != "" || cfg.CheckpointRecords) {
+		return fmt.Errorf("--%s cannot be used along with --%s, --%s or --%s", flags.InitSyncFlag.Name, flags.CheckpointSyncStateFlag.Name, flags.CheckpointSyncBlockFlag.Name, flags.CheckpointSyncRecordsFlag.Name)
+	}
+	if cfg.CheckpointRecords && (cfg.CheckpointState != "" || cfg.CheckpointBlock != "") {
+		return fmt.Errorf("--%s cannot be used along with --%s or --%s", flags.CheckpointSyncRecordsFlag.Name, flags.CheckpointSyncStateFlag.Name, flags.CheckpointSyncBlockFlag.Name)
+	}
 	// Either start from genesis or a checkpoint
-	var state *state.BeaconState
+	var (
+		state           *state.BeaconState
+		checkpointBlock *cltypes.SignedBeaconBlock
+	)
 	if cfg.InitialSync {
 		state = cfg.InitalState
+	} else if cfg.CheckpointRecords {
+		// the recordings are seeded by the era files imported with the era command
+		if state, checkpointBlock, err = era.LatestState(openFreezer(cfg), cfg.BeaconCfg); err != nil {
+			return err
+		}
+		log.Info("[Checkpoint Sync] Starting from the recorded state", "slot", state.Slot())
+	} else if cfg.CheckpointState != "" {
+		if state, err = core.ReadBeaconStateFromFile(cfg.BeaconCfg, cfg.CheckpointState); err != nil {
+			return err
+		}
 	} else {
 		state, err = core.RetrieveBeaconState(ctx, cfg.BeaconCfg, cfg.GenesisCfg, cfg.CheckpointUri)
 		if err != nil {
@@ -75,12 +106,43 @@ func runCaplinNode(cliCtx *cli.Context) error {
 		}
 	}
 
+	if cfg.WeakSubjectivity != nil {
+		if err := core.VerifyWeakSubjectivityCheckpoint(state, cfg.WeakSubjectivity); err != nil {
+			return err
+		}
+		log.Info("[Checkpoint Sync] Weak subjectivity checkpoint verified", "root", cfg.WeakSubjectivity.BlockRoot(), "epoch", cfg.WeakSubjectivity.Epoch())
+	}
+	if cfg.CheckpointBlock != "" {
+		if checkpointBlock, err = core.ReadBeaconBlockFromFile(cfg.BeaconCfg, cfg.CheckpointBlock); err != nil {
+			return err
+		}
+		if err := core.VerifyCheckpointBlock(state, checkpointBlock); err != nil {
+			return err
+		}
+	}
+
+	validatorCfg, err := setupValidators(ctx, cfg, state)
+	if err != nil {
+		return err
+	}
+	if cfg.ValidatorSlashingProtectionExport != "" {
+		return nil
+	}
+	if validatorCfg != nil {
+		// our attestations and sync committee messages can only be published on subnets we are subscribed to
+		cfg.AllSubnets = true
+	}
+
 	forkDigest, err := fork.ComputeForkDigest(cfg.BeaconCfg, cfg.GenesisCfg)
 	if err != nil {
 		return err
 	}
 
-	sentinel, err := service.StartSentinelService(&sentinel.SentinelConfig{
+	var (
+		lightClient *light_client.Store
+		blobStore   *blob_storage.BlobStore
+	)
+	sentinelCfg := &sentinel.SentinelConfig{
 		IpAddr:        cfg.Addr,
 		Port:          int(cfg.Port),
 		TCPPort:       cfg.ServerTcpPort,
@@ -88,7 +150,25 @@ func runCaplinNode(cliCtx *cli.Context) error {
 		NetworkConfig: cfg.NetworkCfg,
 		BeaconConfig:  cfg.BeaconCfg,
 		NoDiscovery:   cfg.NoDiscovery,
-	}, nil, &service.ServerConfig{Network: cfg.ServerProtocol, Addr: cfg.ServerAddr}, nil, &cltypes.Status{
+
+		SubscribeAllSubnets: cfg.AllSubnets,
+		ReputationFile:      cfg.ReputationFile,
+		RateLimits:          cfg.RateLimits,
+	}
+	if cfg.LightClientDir != "" {
+		if lightClient, err = light_client.NewStore(&freezer.RootPathOsFs{Root: cfg.LightClientDir}, cfg.BeaconCfg); err != nil {
+			return err
+		}
+		sentinelCfg.LightClient = lightClient
+	}
+	if cfg.BlobsDir != "" {
+		if blobStore, err = blob_storage.NewBlobStore(&freezer.RootPathOsFs{Root: cfg.BlobsDir}, cfg.BeaconCfg, cfg.NetworkCfg); err != nil {
+			return err
+		}
+		sentinelCfg.BlobSidecars = blobStore
+	}
+
+	sentinel, err := service.StartSentinelService(sentinelCfg, nil, &service.ServerConfig{Network: cfg.ServerProtocol, Addr: cfg.ServerAddr}, nil, &cltypes.Status{
 		ForkDigest:     forkDigest,
 		FinalizedRoot:  state.FinalizedCheckpoint().BlockRoot(),
 		FinalizedEpoch: state.FinalizedCheckpoint().Epoch(),
@@ -115,20 +195,189 @@ func runCaplinNode(cliCtx *cli.Context) error {
 		engine = execution_client.NewExecutionEnginePhase1FromClient(ctx, remote.NewETHBACKENDClient(cc))
 	}
 
-	apiHandler := handler.NewApiHandler(cfg.GenesisCfg, cfg.BeaconCfg)
-	go beacon.ListenAndServe(apiHandler, &beacon.RouterConfiguration{
+	var caplinFreezer freezer.Freezer
+	if cfg.RecordMode {
+		caplinFreezer = openFreezer(cfg)
+		if cfg.RecordRetention > 0 {
+			// the payloads no longer referenced by the pruned ids are swept once per epoch too
+			if contentAddressed, ok := caplinFreezer.(*freezer.ContentAddressed); ok {
+				epochDuration := time.Duration(cfg.BeaconCfg.SlotsPerEpoch*cfg.BeaconCfg.SecondsPerSlot) * time.Second
+				go contentAddressed.SweepLoop(ctx, epochDuration, "caplin_core", "beaconState", "signedBeaconBlock")
+			}
+			// prune once per epoch
+			if caplinFreezer, err = freezer.NewRetention(caplinFreezer, cfg.RecordRetention, cfg.BeaconCfg.SlotsPerEpoch); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the block of a state read from a file is backfilled, from the peers if it was not given
+	if checkpointBlock == nil && cfg.CheckpointState != "" {
+		if checkpointBlock, err = fetchCheckpointBlock(ctx, rpc.NewBeaconRpcP2P(ctx, sentinel, cfg.BeaconCfg, cfg.GenesisCfg), state); err != nil {
+			return err
+		}
+	}
+	if checkpointBlock != nil {
+		log.Info("[Checkpoint Sync] Checkpoint block verified", "slot", checkpointBlock.Block.Slot)
+		if caplinFreezer != nil {
+			if err := freezer.PutObjectSSZIntoFreezer("signedBeaconBlock", "caplin_core", checkpointBlock.Block.Slot, checkpointBlock, caplinFreezer); err != nil {
+				return err
+			}
+		}
+	}
+
+	var stateRegen *state_regen.Regenerator
+	if cfg.StateRegenDir != "" {
+		stateRegen = state_regen.NewRegenerator(&freezer.RootPathOsFs{Root: cfg.StateRegenDir}, cfg.BeaconCfg, cfg.StateRegenSnapshotInterval)
+	}
+
+	var indicesDB kv.RwDB
+	if cfg.IndicesDir != "" {
+		if indicesDB, err = mdbx.NewMDBX(log.Root()).Path(cfg.IndicesDir).Open(); err != nil {
+			return err
+		}
+		defer indicesDB.Close()
+	}
+
+	return caplin1.RunCaplinPhase1(ctx, sentinel, cfg.BeaconCfg, cfg.GenesisCfg, engine, state, caplinFreezer, &beacon.RouterConfiguration{
 		Protocol: cfg.BeaconProtocol,
 		Address:  cfg.BeaconAddr,
 		// TODO(enriavil1): Make timeouts configurable via flags
-	})
-	log.Info("Beacon API started", "addr", cfg.BeaconAddr)
+	}, validatorCfg, stateRegen, lightClient, blobStore, indicesDB)
+}
 
-	var caplinFreezer freezer.Freezer
-	if cfg.RecordMode {
-		caplinFreezer = &freezer.RootPathOsFs{
-			Root: cfg.RecordDir,
+// openFreezer opens the freezer Caplin records its blocks and states into.
+func openFreezer(cfg *lcCli.ConsensusClientCliCfg) freezer.Freezer {
+	var caplinFreezer freezer.Freezer = &freezer.RootPathOsFs{
+		Root: cfg.RecordDir,
+	}
+	if cfg.RecordS3 != nil {
+		caplinFreezer = cfg.RecordS3
+	}
+	if cfg.RecordDedup {
+		caplinFreezer = freezer.NewContentAddressed(caplinFreezer, true)
+	}
+	return caplinFreezer
+}
+
+const (
+	// checkpointBlockAttempts is the amount of peers asked for the checkpoint block before giving up.
+	checkpointBlockAttempts   = 10
+	checkpointBlockMinBackoff = time.Second
+	checkpointBlockMaxBackoff = 30 * time.Second
+)
+
+// fetchCheckpointBlock requests the latest block applied to the state from the peers, backing off between the
+// attempts, until one of them serves it.
+func fetchCheckpointBlock(ctx context.Context, beaconRpc *rpc.BeaconRpcP2P, state *state.BeaconState) (*cltypes.SignedBeaconBlock, error) {
+	blockRoot, err := core.CheckpointBlockRoot(state)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("[Checkpoint Sync] Requesting checkpoint block", "root", blockRoot)
+	backoff := checkpointBlockMinBackoff
+	for attempt := 1; ; attempt++ {
+		blocks, pid, err := beaconRpc.SendBeaconBlocksByRootReq(ctx, [][32]byte{blockRoot})
+		if err == nil && len(blocks) == 0 {
+			err = fmt.Errorf("block not served")
+		}
+		if err == nil {
+			if err = core.VerifyCheckpointBlock(state, blocks[0]); err == nil {
+				return blocks[0], nil
+			}
+			beaconRpc.BanPeer(pid)
+		}
+		if attempt == checkpointBlockAttempts {
+			return nil, fmt.Errorf("could not fetch checkpoint block %x after %d attempts, use --%s: %w", blockRoot, attempt, flags.CheckpointSyncBlockFlag.Name, err)
 		}
+		log.Debug("[Checkpoint Sync] Could not fetch checkpoint block", "attempt", attempt, "err", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > checkpointBlockMaxBackoff {
+			backoff = checkpointBlockMaxBackoff
+		}
+	}
+}
+
+// setupValidators loads the validator keys and their slashing protection database, and handles the import and
+// export of the latter. It returns nil when no validator is configured.
+func setupValidators(ctx context.Context, cfg *lcCli.ConsensusClientCliCfg, state *state.BeaconState) (*validator.Config, error) {
+	if cfg.ValidatorKeystores == "" && cfg.ValidatorSlashingProtectionImport == "" && cfg.ValidatorSlashingProtectionExport == "" {
+		return nil, nil
 	}
+	slashingProtection, err := validator.OpenSlashingProtection(cfg.ValidatorSlashingProtection, state.GenesisValidatorsRoot())
+	if err != nil {
+		return nil, err
+	}
+	validatorCfg, err := loadValidators(ctx, cfg, slashingProtection)
+	if validatorCfg == nil {
+		slashingProtection.Close()
+	}
+	return validatorCfg, err
+}
 
-	return caplin1.RunCaplinPhase1(ctx, sentinel, cfg.BeaconCfg, cfg.GenesisCfg, engine, state, caplinFreezer)
+// loadValidators imports or exports the slashing protection database, then loads the keystores and starts following
+// the deposits if an execution layer endpoint is configured.
+func loadValidators(ctx context.Context, cfg *lcCli.ConsensusClientCliCfg, slashingProtection *validator.SlashingProtection) (*validator.Config, error) {
+	if cfg.ValidatorSlashingProtectionImport != "" {
+		f, err := os.Open(cfg.ValidatorSlashingProtectionImport)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := slashingProtection.Import(f); err != nil {
+			return nil, fmt.Errorf("could not import %s: %w", cfg.ValidatorSlashingProtectionImport, err)
+		}
+		log.Info("[Validator] Imported slashing protection", "file", cfg.ValidatorSlashingProtectionImport)
+	}
+	if cfg.ValidatorSlashingProtectionExport != "" {
+		f, err := os.Create(cfg.ValidatorSlashingProtectionExport)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := slashingProtection.Export(f); err != nil {
+			return nil, err
+		}
+		log.Info("[Validator] Exported slashing protection", "file", cfg.ValidatorSlashingProtectionExport)
+		return nil, nil
+	}
+	if cfg.ValidatorKeystores == "" {
+		return nil, nil
+	}
+	var password string
+	if cfg.ValidatorPasswordFile != "" {
+		b, err := os.ReadFile(cfg.ValidatorPasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		password = strings.TrimRight(string(b), "\r\n")
+	}
+	keys, err := validator.LoadKeystores(cfg.ValidatorKeystores, password)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keystore found in %s", cfg.ValidatorKeystores)
+	}
+	log.Info("[Validator] Loaded keystores", "keys", len(keys))
+	var deposits *validator.DepositTree
+	if cfg.ValidatorEth1Endpoint != "" {
+		client, err := rpc2.DialContext(ctx, cfg.ValidatorEth1Endpoint, log.Root())
+		if err != nil {
+			return nil, err
+		}
+		deposits = validator.NewDepositTree(cfg.BeaconCfg)
+		go validator.FollowDeposits(ctx, client, deposits, cfg.BeaconCfg, cfg.ValidatorDepositContractBlock)
+	}
+	return &validator.Config{
+		Keys:               keys,
+		SlashingProtection: slashingProtection,
+		Deposits:           deposits,
+		FeeRecipient:       cfg.ValidatorFeeRecipient,
+		Graffiti:           cfg.ValidatorGraffiti,
+	}, nil
 }
@@ -22,6 +22,9 @@ func main() {
 	test := flag.String("test", "TestRegressionWithValidation", "select test to run. can be TestRegressionWithValidation, TestRegressionWithoutValidation and TestRegressionBadBlocks")
 	step := flag.Int("step", 1, "how often to log performance")
 	pprof := flag.Bool("pprof", true, "turn on profiling")
+	fuzzSeeds := flag.Int("fuzz", 0, "number of seeds to reorder the recorded blocks and attestations with, checking the fork choice invariants, instead of running a test")
+	fuzzFrom := flag.Int64("fuzz-from", 0, "first seed to fuzz with")
+	fuzzDelay := flag.Int("fuzz-delay", 8, "maximum number of events a block or attestation is delayed by when fuzzing")
 	flag.Parse()
 	if _, ok := nameTestsMap[*test]; !ok {
 		log.Error("Could not start regression tests", "err", "test not found")
@@ -46,6 +49,13 @@ func main() {
 		return
 	}
 
+	if *fuzzSeeds > 0 {
+		if err := r.Fuzz(*fuzzFrom, *fuzzSeeds, *fuzzDelay); err != nil {
+			log.Error("Could not fuzz fork choice", "err", err)
+		}
+		return
+	}
+
 	if err := r.Run(*test, nameTestsMap[*test], *step); err != nil {
 		log.Error("Could not do regression tests", "err", err)
 	}
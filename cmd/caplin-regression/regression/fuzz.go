@@ -0,0 +1,51 @@
+package regression
+
+import (
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/phase1/forkchoice/fuzz"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// ForkChoiceScenario returns the recorded blocks, the attestations they include and a tick at the slot of each of
+// them as a fork choice fuzzing scenario.
+func (r *RegressionTester) ForkChoiceScenario() (*fuzz.Scenario, error) {
+	anchorState, err := r.readStartingState()
+	if err != nil {
+		return nil, err
+	}
+	scenario := &fuzz.Scenario{AnchorState: anchorState, FullValidation: true}
+	for _, block := range r.blockList {
+		scenario.Events = append(scenario.Events,
+			fuzz.Event{Kind: fuzz.EventTick, Time: anchorState.GenesisTime() + block.Block.Slot*anchorState.BeaconConfig().SecondsPerSlot},
+			fuzz.Event{Kind: fuzz.EventBlock, Block: block},
+		)
+		block.Block.Body.Attestations.Range(func(_ int, attestation *solid.Attestation, _ int) bool {
+			scenario.Events = append(scenario.Events, fuzz.Event{Kind: fuzz.EventAttestation, Attestation: attestation, FromBlock: true})
+			return true
+		})
+	}
+	return scenario, nil
+}
+
+// Fuzz replays the recording in the orders derived from the seeds, logging the minimal sequence reproducing the
+// first invariant broken.
+func (r *RegressionTester) Fuzz(fromSeed int64, seeds, maxDelay int) error {
+	scenario, err := r.ForkChoiceScenario()
+	if err != nil {
+		return err
+	}
+	harness := fuzz.NewHarness(scenario, maxDelay)
+	for seed := fromSeed; seed < fromSeed+int64(seeds); seed++ {
+		failure, err := harness.Run(seed)
+		if err != nil {
+			return err
+		}
+		if failure != nil {
+			log.Error("Fork choice invariant broken", "seed", seed, "violation", failure.Violation, "events", len(failure.Events))
+			log.Error(failure.String())
+			return failure.Violation
+		}
+		log.Info("Fuzzed", "seed", seed)
+	}
+	return nil
+}
@@ -32,6 +32,7 @@ type HttpCfg struct {
 	MaxTraces                uint64
 	WebsocketEnabled         bool
 	WebsocketCompression     bool
+	WebsocketOrigins         []string
 	RpcAllowListFilePath     string
 	RpcBatchConcurrency      uint
 	RpcStreamingDisable      bool
@@ -64,4 +65,6 @@ type HttpCfg struct {
 
 	BatchLimit      int // Maximum number of requests in a batch
 	ReturnDataLimit int // Maximum number of bytes returned from calls (like eth_call)
+
+	MaxGetProofRewindBlockCount int // Maximum number of blocks eth_getProof can go back from the head
 }
@@ -5,7 +5,11 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
@@ -17,17 +21,39 @@ type AdminAPI interface {
 	// Peers returns information about the connected remote nodes.
 	// https://geth.ethereum.org/docs/rpc/ns-admin#admin_peers
 	Peers(ctx context.Context) ([]*p2p.PeerInfo, error)
+
+	// AddPeer requests connecting to a remote node, and maintaining the connection.
+	// https://geth.ethereum.org/docs/rpc/ns-admin#admin_addpeer
+	AddPeer(ctx context.Context, url string) (bool, error)
+
+	// RemovePeer disconnects from a remote node, and stops reconnecting to it.
+	RemovePeer(ctx context.Context, url string) (bool, error)
+
+	// AddTrustedPeer allows a remote node to always connect, even if the peer slots are full.
+	AddTrustedPeer(ctx context.Context, url string) (bool, error)
+
+	// RemoveTrustedPeer removes a remote node from the trusted peers, without disconnecting from it.
+	RemoveTrustedPeer(ctx context.Context, url string) (bool, error)
+
+	// PeerEvents sends a notification each time a peer is added or dropped, or a message is sent or received.
+	PeerEvents(ctx context.Context) (*rpc.Subscription, error)
 }
 
+// errPeerManagementUnsupported is returned when the sentries do not run in process, the sentry gRPC interface
+// does not expose the peers to add or remove.
+var errPeerManagementUnsupported = errors.New("peer management is only available with the sentries running in process")
+
 // AdminAPIImpl data structure to store things needed for admin_* commands.
 type AdminAPIImpl struct {
 	ethBackend rpchelper.ApiBackend
+	peers      rpchelper.PeerManager
 }
 
 // NewAdminAPI returns AdminAPIImpl instance.
-func NewAdminAPI(eth rpchelper.ApiBackend) *AdminAPIImpl {
+func NewAdminAPI(eth rpchelper.ApiBackend, peers rpchelper.PeerManager) *AdminAPIImpl {
 	return &AdminAPIImpl{
 		ethBackend: eth,
+		peers:      peers,
 	}
 }
 
@@ -47,3 +73,68 @@ func (api *AdminAPIImpl) NodeInfo(ctx context.Context) (*p2p.NodeInfo, error) {
 func (api *AdminAPIImpl) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
 	return api.ethBackend.Peers(ctx)
 }
+
+func (api *AdminAPIImpl) AddPeer(_ context.Context, url string) (bool, error) {
+	if api.peers == nil {
+		return false, errPeerManagementUnsupported
+	}
+	return api.peers.AddPeer(url)
+}
+
+func (api *AdminAPIImpl) RemovePeer(_ context.Context, url string) (bool, error) {
+	if api.peers == nil {
+		return false, errPeerManagementUnsupported
+	}
+	return api.peers.RemovePeer(url)
+}
+
+func (api *AdminAPIImpl) AddTrustedPeer(_ context.Context, url string) (bool, error) {
+	if api.peers == nil {
+		return false, errPeerManagementUnsupported
+	}
+	return api.peers.AddTrustedPeer(url)
+}
+
+func (api *AdminAPIImpl) RemoveTrustedPeer(_ context.Context, url string) (bool, error) {
+	if api.peers == nil {
+		return false, errPeerManagementUnsupported
+	}
+	return api.peers.RemoveTrustedPeer(url)
+}
+
+func (api *AdminAPIImpl) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
+	if api.peers == nil {
+		return &rpc.Subscription{}, errPeerManagementUnsupported
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	events := make(chan *p2p.PeerEvent, 32)
+	sub, err := api.peers.SubscribePeerEvents(events)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case e := <-events:
+				if err := notifier.Notify(rpcSub.ID, e); err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+					return
+				}
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/eth/integrity"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+const (
+	// VerifyBlocksMaxRange is the maximum number of blocks verified per call, each of them being re-executed: larger
+	// ranges are verified by the verify command
+	VerifyBlocksMaxRange = 1_000
+	verifyBlocksWorkers  = 2
+)
+
+// VerifyBlocks implements debug_verifyBlocks. Re-checks the headers, bodies, senders, receipts, snapshot indices
+// and transaction lookups of the blocks of the range, or only the given checks, and returns the failed ones.
+func (api *PrivateDebugAPIImpl) VerifyBlocks(ctx context.Context, fromBlock, toBlock rpc.BlockNumber, checks []string) (*integrity.BlocksReport, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	from, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(fromBlock), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	to, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(toBlock), tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("fromBlock %d is after toBlock %d", from, to)
+	}
+	if to-from >= VerifyBlocksMaxRange {
+		return nil, fmt.Errorf("at most %d blocks can be verified at once, use the verify command for larger ranges", VerifyBlocksMaxRange)
+	}
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	tx.Rollback()
+
+	return integrity.VerifyBlocks(ctx, api.db, api._blockReader, integrity.BlocksCfg{
+		From:        from,
+		To:          to,
+		Checks:      checks,
+		Workers:     verifyBlocksWorkers,
+		ChainConfig: chainConfig,
+		Engine:      api.engine(),
+	}, log.Root())
+}
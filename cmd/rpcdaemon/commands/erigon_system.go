@@ -7,6 +7,7 @@ import (
 
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/forkid"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
@@ -35,6 +36,25 @@ func (api *ErigonImpl) Forks(ctx context.Context) (Forks, error) {
 	return Forks{genesis.Hash(), heightForks, timeForks}, nil
 }
 
+// StagesProgress implements erigon_stagesProgress. Returns the block number reached by every stage of the staged sync
+func (api *ErigonImpl) StagesProgress(ctx context.Context) (map[string]hexutil.Uint64, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	progress := make(map[string]hexutil.Uint64, len(stages.AllStages))
+	for _, stage := range stages.AllStages {
+		block, err := stages.GetStageProgress(tx, stage)
+		if err != nil {
+			return nil, err
+		}
+		progress[string(stage)] = hexutil.Uint64(block)
+	}
+	return progress, nil
+}
+
 // Post the merge eth_blockNumber will return latest forkChoiceHead block number
 // erigon_blockNumber will return latest executed block number or any block number requested
 func (api *ErigonImpl) BlockNumber(ctx context.Context, rpcBlockNumPtr *rpc.BlockNumber) (hexutil.Uint64, error) {
@@ -324,11 +324,13 @@ type APIImpl struct {
 	db              kv.RoDB
 	GasCap          uint64
 	ReturnDataLimit int
-	logger          log.Logger
+	// MaxGetProofRewindBlockCount limits how far back from the head eth_getProof serves proofs
+	MaxGetProofRewindBlockCount int
+	logger                      log.Logger
 }
 
 // NewEthAPI returns APIImpl instance
-func NewEthAPI(base *BaseAPI, db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient, gascap uint64, returnDataLimit int, logger log.Logger) *APIImpl {
+func NewEthAPI(base *BaseAPI, db kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient, gascap uint64, returnDataLimit int, maxGetProofRewindBlockCount int, logger log.Logger) *APIImpl {
 	if gascap == 0 {
 		gascap = uint64(math.MaxUint64 / 2)
 	}
@@ -343,6 +345,8 @@ func NewEthAPI(base *BaseAPI, db kv.RoDB, eth rpchelper.ApiBackend, txPool txpoo
 		GasCap:          gascap,
 		ReturnDataLimit: returnDataLimit,
 		logger:          logger,
+
+		MaxGetProofRewindBlockCount: maxGetProofRewindBlockCount,
 	}
 }
 
@@ -25,7 +25,6 @@ import (
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/crypto"
-	"github.com/ledgerwatch/erigon/eth/stagedsync"
 	"github.com/ledgerwatch/erigon/eth/tracers/logger"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
@@ -306,19 +305,10 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 	return hexutil.Uint64(hi), nil
 }
 
-// maxGetProofRewindBlockCount limits the number of blocks into the past that
-// GetProof will allow computing proofs.  Because we must rewind the hash state
-// and re-compute the state trie, the further back in time the request, the more
-// computationally intensive the operation becomes.  The staged sync code
-// assumes that if more than 100_000 blocks are skipped, that the entire trie
-// should be re-computed. Re-computing the entire trie will currently take ~15
-// minutes on mainnet.  The current limit has been chosen arbitrarily as
-// 'useful' without likely being overly computationally intense.  This parameter
-// could possibly be made configurable in the future if needed.
-var maxGetProofRewindBlockCount uint64 = 1_000
-
-// GetProof is partially implemented; no Storage proofs, and proofs must be for
-// blocks within maxGetProofRewindBlockCount blocks of the head.
+// GetProof is partially implemented; no Storage proofs. Proofs for historical blocks are computed by overlaying the
+// hashed state of the requested block, rebuilt from history, on top of the latest one; the further back in time the
+// request, the more state has to be rebuilt and the more computationally intensive the operation becomes, which is
+// why proofs must be for blocks within MaxGetProofRewindBlockCount blocks of the head.
 func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, storageKeys []libcommon.Hash, blockNrOrHash rpc.BlockNumberOrHash) (*accounts.AccProofResult, error) {
 
 	tx, err := api.db.BeginRo(ctx)
@@ -326,9 +316,7 @@ func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, sto
 		return nil, err
 	}
 	defer tx.Rollback()
-	if api.historyV3(tx) {
-		return nil, fmt.Errorf("not supported by Erigon3")
-	}
+	historyV3 := api.historyV3(tx)
 
 	blockNr, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
 	if err != nil {
@@ -350,34 +338,7 @@ func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, sto
 		return nil, fmt.Errorf("block number is in the future latest=%d requested=%d", latestBlock, blockNr)
 	}
 
-	rl := trie.NewRetainList(0)
-	var loader *trie.FlatDBTrieLoader
-	if blockNr < latestBlock {
-		if latestBlock-blockNr > maxGetProofRewindBlockCount {
-			return nil, fmt.Errorf("requested block is too old, block must be within %d blocks of the head block number (currently %d)", maxGetProofRewindBlockCount, latestBlock)
-		}
-		batch := memdb.NewMemoryBatch(tx, api.dirs.Tmp)
-		defer batch.Rollback()
-
-		unwindState := &stagedsync.UnwindState{UnwindPoint: blockNr}
-		stageState := &stagedsync.StageState{BlockNumber: latestBlock}
-
-		hashStageCfg := stagedsync.StageHashStateCfg(nil, api.dirs, api.historyV3(batch))
-		if err := stagedsync.UnwindHashStateStage(unwindState, stageState, batch, hashStageCfg, ctx, api.logger); err != nil {
-			return nil, err
-		}
-
-		interHashStageCfg := stagedsync.StageTrieCfg(nil, false, false, false, api.dirs.Tmp, api._blockReader, nil, api.historyV3(batch), api._agg)
-		loader, err = stagedsync.UnwindIntermediateHashesForTrieLoader("eth_getProof", rl, unwindState, stageState, batch, interHashStageCfg, nil, nil, ctx.Done(), api.logger)
-		if err != nil {
-			return nil, err
-		}
-		tx = batch
-	} else {
-		loader = trie.NewFlatDBTrieLoader("eth_getProof", rl, nil, nil, false)
-	}
-
-	reader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, 0, api.filters, api.stateCache, api.historyV3(tx), "")
+	reader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, 0, api.filters, api.stateCache, historyV3, "")
 	if err != nil {
 		return nil, err
 	}
@@ -388,13 +349,28 @@ func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, sto
 	if a == nil {
 		a = &accounts.Account{}
 	}
+
+	rl := trie.NewRetainList(0)
+	var trieTx kv.Tx = tx
+	if blockNr < latestBlock {
+		if latestBlock-blockNr > uint64(api.MaxGetProofRewindBlockCount) {
+			return nil, fmt.Errorf("requested block is too old, block must be within %d blocks of the head block number (currently %d)", api.MaxGetProofRewindBlockCount, latestBlock)
+		}
+		batch := memdb.NewMemoryBatch(tx, api.dirs.Tmp)
+		defer batch.Rollback()
+		if err := historicalHashedState(ctx, tx, batch, blockNr, historyV3, rl); err != nil {
+			return nil, err
+		}
+		trieTx = batch
+	}
+	loader := trie.NewFlatDBTrieLoader("eth_getProof", rl, nil, nil, false)
 	pr, err := trie.NewProofRetainer(address, a, storageKeys, rl)
 	if err != nil {
 		return nil, err
 	}
 
 	loader.SetProofRetainer(pr)
-	root, err := loader.CalcTrieRoot(tx, nil)
+	root, err := loader.CalcTrieRoot(trieTx, nil)
 	if err != nil {
 		return nil, err
 	}
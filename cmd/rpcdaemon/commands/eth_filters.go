@@ -163,7 +163,8 @@ func (api *APIImpl) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 }
 
 // NewPendingTransactions send a notification each time when a transaction had added into mempool.
-func (api *APIImpl) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+// Only the transactions matching crit are notified when it is given.
+func (api *APIImpl) NewPendingTransactions(ctx context.Context, crit *rpchelper.PendingTxsCriteria) (*rpc.Subscription, error) {
 	if api.filters == nil {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
@@ -171,12 +172,15 @@ func (api *APIImpl) NewPendingTransactions(ctx context.Context) (*rpc.Subscripti
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	txsCh, id, err := api.subscribePendingTxs(ctx, 256, crit)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
 
 	rpcSub := notifier.CreateSubscription()
 
 	go func() {
 		defer debug.LogPanic()
-		txsCh, id := api.filters.SubscribePendingTxs(256)
 		defer api.filters.UnsubscribePendingTxs(id)
 
 		for {
@@ -205,7 +209,8 @@ func (api *APIImpl) NewPendingTransactions(ctx context.Context) (*rpc.Subscripti
 }
 
 // NewPendingTransactionsWithBody send a notification each time when a transaction had added into mempool.
-func (api *APIImpl) NewPendingTransactionsWithBody(ctx context.Context) (*rpc.Subscription, error) {
+// Only the transactions matching crit are notified when it is given.
+func (api *APIImpl) NewPendingTransactionsWithBody(ctx context.Context, crit *rpchelper.PendingTxsCriteria) (*rpc.Subscription, error) {
 	if api.filters == nil {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
@@ -213,12 +218,15 @@ func (api *APIImpl) NewPendingTransactionsWithBody(ctx context.Context) (*rpc.Su
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	txsCh, id, err := api.subscribePendingTxs(ctx, 512, crit)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
 
 	rpcSub := notifier.CreateSubscription()
 
 	go func() {
 		defer debug.LogPanic()
-		txsCh, id := api.filters.SubscribePendingTxs(512)
 		defer api.filters.UnsubscribePendingTxs(id)
 
 		for {
@@ -246,6 +254,28 @@ func (api *APIImpl) NewPendingTransactionsWithBody(ctx context.Context) (*rpc.Su
 	return rpcSub, nil
 }
 
+// subscribePendingTxs subscribes to the pending transactions matching crit, all of them when it is nil.
+func (api *APIImpl) subscribePendingTxs(ctx context.Context, size int, crit *rpchelper.PendingTxsCriteria) (<-chan []types.Transaction, rpchelper.PendingTxsSubID, error) {
+	if crit == nil {
+		txsCh, id := api.filters.SubscribePendingTxs(size)
+		return txsCh, id, nil
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, "", err
+	}
+	signer := types.LatestSigner(chainConfig)
+	txsCh, id := api.filters.SubscribeFilteredPendingTxs(size, func(txn types.Transaction) bool {
+		return crit.Match(txn, signer)
+	})
+	return txsCh, id, nil
+}
+
 // Logs send a notification each time a new log appears.
 func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc.Subscription, error) {
 	if api.filters == nil {
@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/order"
+	"github.com/ledgerwatch/erigon-lib/kv/rawdbv3"
+	"github.com/ledgerwatch/erigon-lib/kv/temporal/historyv2"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/state/temporal"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+// historicalHashedState overlays the hashed state as of the end of blockNr on top of the latest hashed state held
+// in batch. Only the keys changed after blockNr are visited: they come from the changesets, or from the temporal
+// history when historyV3 is enabled. Every visited key is added to the retain list, so the trie loader ignores the
+// (newer) IntermediateHashes along its path and IntermediateHashes never have to be unwound.
+func historicalHashedState(ctx context.Context, tx kv.Tx, batch kv.RwTx, blockNr uint64, historyV3 bool, rl *trie.RetainList) error {
+	accountKeys, storageKeys, err := changedKeysAfter(tx, blockNr, historyV3)
+	if err != nil {
+		return err
+	}
+	historicalReader, err := rpchelper.CreateHistoryStateReader(tx, blockNr+1, 0, historyV3, "")
+	if err != nil {
+		return err
+	}
+	latestReader := state.NewPlainStateReader(tx)
+
+	historicalAccounts := make(map[libcommon.Address]*accounts.Account, len(accountKeys))
+	for _, address := range accountKeys {
+		if err := libcommon.Stopped(ctx.Done()); err != nil {
+			return err
+		}
+		acc, err := historicalReader.ReadAccountData(address)
+		if err != nil {
+			return err
+		}
+		historicalAccounts[address] = acc
+		latestAcc, err := latestReader.ReadAccountData(address)
+		if err != nil {
+			return err
+		}
+
+		addrHash, err := common.HashData(address[:])
+		if err != nil {
+			return err
+		}
+		if acc == nil {
+			if err := batch.Delete(kv.HashedAccounts, addrHash[:]); err != nil {
+				return err
+			}
+		} else {
+			value := make([]byte, acc.EncodingLengthForStorage())
+			acc.EncodeForStorage(value)
+			if err := batch.Put(kv.HashedAccounts, addrHash[:], value); err != nil {
+				return err
+			}
+		}
+		// Storage trie hashes of a contract which was re-created (or did not exist yet) at blockNr are of no use
+		if latestAcc != nil && latestAcc.Incarnation > 0 && (acc == nil || acc.Incarnation < latestAcc.Incarnation) {
+			if err := batch.ForPrefix(kv.TrieOfStorage, addrHash[:], func(k, _ []byte) error {
+				return batch.Delete(kv.TrieOfStorage, k)
+			}); err != nil {
+				return err
+			}
+		}
+		rl.AddKeyWithMarker(addrHash[:], latestAcc == nil)
+	}
+
+	for _, key := range storageKeys {
+		if err := libcommon.Stopped(ctx.Done()); err != nil {
+			return err
+		}
+		address := libcommon.BytesToAddress(key[:length.Addr])
+		var incarnation uint64
+		var location libcommon.Hash
+		if len(key) == length.Addr+length.Incarnation+length.Hash {
+			incarnation = binary.BigEndian.Uint64(key[length.Addr:])
+			location.SetBytes(key[length.Addr+length.Incarnation:])
+		} else {
+			// history v3 keys carry no incarnation, the one of the account as of blockNr applies
+			acc, ok := historicalAccounts[address]
+			if !ok {
+				if acc, err = historicalReader.ReadAccountData(address); err != nil {
+					return err
+				}
+				historicalAccounts[address] = acc
+			}
+			if acc == nil {
+				continue
+			}
+			incarnation = acc.Incarnation
+			location.SetBytes(key[length.Addr:])
+		}
+
+		value, err := historicalReader.ReadAccountStorage(address, incarnation, &location)
+		if err != nil {
+			return err
+		}
+		latestValue, err := latestReader.ReadAccountStorage(address, incarnation, &location)
+		if err != nil {
+			return err
+		}
+
+		addrHash, err := common.HashData(address[:])
+		if err != nil {
+			return err
+		}
+		locHash, err := common.HashData(location[:])
+		if err != nil {
+			return err
+		}
+		hashedKey := dbutils.GenerateCompositeStorageKey(addrHash, incarnation, locHash)
+		if len(value) == 0 {
+			if err := batch.Delete(kv.HashedStorage, hashedKey); err != nil {
+				return err
+			}
+		} else if err := batch.Put(kv.HashedStorage, hashedKey, value); err != nil {
+			return err
+		}
+		rl.AddKeyWithMarker(hashedKey, len(latestValue) == 0)
+	}
+	return nil
+}
+
+// changedKeysAfter returns the deduplicated account addresses and plain storage keys modified after blockNr. Storage
+// keys are address+incarnation+location for the changesets and address+location for history v3.
+func changedKeysAfter(tx kv.Tx, blockNr uint64, historyV3 bool) (accountKeys []libcommon.Address, storageKeys [][]byte, err error) {
+	seenAccounts := map[libcommon.Address]struct{}{}
+	seenStorage := map[string]struct{}{}
+	addAccount := func(k []byte) {
+		address := libcommon.BytesToAddress(k)
+		if _, ok := seenAccounts[address]; !ok {
+			seenAccounts[address] = struct{}{}
+			accountKeys = append(accountKeys, address)
+		}
+	}
+	addStorage := func(k []byte) {
+		if _, ok := seenStorage[string(k)]; !ok {
+			seenStorage[string(k)] = struct{}{}
+			storageKeys = append(storageKeys, libcommon.Copy(k))
+		}
+	}
+
+	if !historyV3 {
+		startKey := hexutility.EncodeTs(blockNr + 1)
+		forChangeSet := func(changeSetBucket string, add func([]byte)) error {
+			decode := historyv2.Mapper[changeSetBucket].Decode
+			return tx.ForEach(changeSetBucket, startKey, func(dbKey, dbValue []byte) error {
+				_, k, _, err := decode(dbKey, dbValue)
+				if err != nil {
+					return err
+				}
+				add(k)
+				return nil
+			})
+		}
+		if err := forChangeSet(kv.AccountChangeSet, addAccount); err != nil {
+			return nil, nil, err
+		}
+		if err := forChangeSet(kv.StorageChangeSet, addStorage); err != nil {
+			return nil, nil, err
+		}
+		return accountKeys, storageKeys, nil
+	}
+
+	ttx, ok := tx.(kv.TemporalTx)
+	if !ok {
+		return nil, nil, fmt.Errorf("historical proofs on history v3 require a temporal transaction")
+	}
+	fromTxNum, err := rawdbv3.TxNums.Min(tx, blockNr+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	forHistory := func(name kv.History, add func([]byte)) error {
+		it, err := ttx.HistoryRange(name, int(fromTxNum), -1, order.Asc, kv.Unlim)
+		if err != nil {
+			return err
+		}
+		for it.HasNext() {
+			k, _, err := it.Next()
+			if err != nil {
+				return err
+			}
+			add(k)
+		}
+		return nil
+	}
+	if err := forHistory(temporal.AccountsHistory, addAccount); err != nil {
+		return nil, nil, err
+	}
+	if err := forHistory(temporal.StorageHistory, addStorage); err != nil {
+		return nil, nil, err
+	}
+	return accountKeys, storageKeys, nil
+}
@@ -28,7 +28,7 @@ func TestPendingBlock(t *testing.T) {
 	engine := ethash.NewFaker()
 	br, _ := m.NewBlocksIO()
 	api := NewEthAPI(NewBaseApi(ff, stateCache, br, nil, false, rpccfg.DefaultEvmCallTimeout, engine,
-		m.Dirs), nil, nil, nil, mining, 5000000, 100_000, log.New())
+		m.Dirs), nil, nil, nil, mining, 5000000, 100_000, 100_000, log.New())
 	expect := uint64(12345)
 	b, err := rlp.EncodeToBytes(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(expect))}))
 	require.NoError(t, err)
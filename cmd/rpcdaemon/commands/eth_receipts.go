@@ -42,6 +42,21 @@ func (api *BaseAPI) getReceipts(ctx context.Context, tx kv.Tx, chainConfig *chai
 	if cached := rawdb.ReadReceipts(tx, block, senders); cached != nil {
 		return cached, nil
 	}
+	frozen, err := api._blockReader.RawReceipts(ctx, block.NumberU64())
+	if err != nil {
+		return nil, err
+	}
+	if frozen != nil {
+		if len(senders) > 0 {
+			block.SendersToTxs(senders)
+		} else {
+			senders = block.Body().SendersFromTxs()
+		}
+		if err := frozen.DeriveFields(block.Hash(), block.NumberU64(), block.Transactions(), senders); err != nil {
+			return nil, err
+		}
+		return frozen, nil
+	}
 	engine := api.engine()
 
 	_, _, _, ibs, _, err := transactions.ComputeTxEnv(ctx, engine, block, chainConfig, api._blockReader, tx, 0, api.historyV3(tx))
@@ -141,6 +156,34 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 		return api.getLogsV3(ctx, tx.(kv.TemporalTx), begin, end, crit)
 	}
 
+	addrMap := make(map[common.Address]struct{}, len(crit.Addresses))
+	for _, v := range crit.Addresses {
+		addrMap[v] = struct{}{}
+	}
+	receiptsFrom, err := rawdb.ReceiptsAvailableFrom(tx)
+	if err != nil {
+		return nil, err
+	}
+	if begin < receiptsFrom {
+		// the receipts and log indices of these blocks are pruned, their logs can only be in the receipts snapshots
+		frozenEnd := end
+		if frozenEnd >= receiptsFrom {
+			frozenEnd = receiptsFrom - 1
+		}
+		if frozenEnd-begin >= maxFrozenLogsRange {
+			return nil, fmt.Errorf("the logs of blocks before %d are read block by block, query at most %d of them at once", receiptsFrom, maxFrozenLogsRange)
+		}
+		frozenLogs, err := api.getFrozenLogs(ctx, tx, begin, frozenEnd, addrMap, crit)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, frozenLogs...)
+		if end < receiptsFrom {
+			return logs, nil
+		}
+		begin = receiptsFrom
+	}
+
 	blockNumbers := bitmapdb.NewBitmap()
 	defer bitmapdb.ReturnToPool(blockNumbers)
 	if err := applyFilters(blockNumbers, tx, begin, end, crit); err != nil {
@@ -149,10 +192,6 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 	if blockNumbers.IsEmpty() {
 		return logs, nil
 	}
-	addrMap := make(map[common.Address]struct{}, len(crit.Addresses))
-	for _, v := range crit.Addresses {
-		addrMap[v] = struct{}{}
-	}
 	iter := blockNumbers.Iterator()
 	for iter.HasNext() {
 		if err := ctx.Err(); err != nil {
@@ -195,35 +234,82 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) (t
 		if len(blockLogs) == 0 {
 			continue
 		}
-
-		blockHash, err := api._blockReader.CanonicalHash(ctx, tx, blockNumber)
-		if err != nil {
+		if err := api.setLogsBlockFields(ctx, tx, blockNumber, blockLogs); err != nil {
 			return nil, err
 		}
+		logs = append(logs, blockLogs...)
+	}
 
-		body, err := api._blockReader.BodyWithTransactions(ctx, tx, blockHash, blockNumber)
-		if err != nil {
+	return logs, nil
+}
+
+// maxFrozenLogsRange limits the blocks eth_getLogs reads from the receipts snapshots, which have no log index to
+// select the blocks to read.
+const maxFrozenLogsRange = 10_000
+
+// getFrozenLogs filters the logs of the blocks of [begin, end] from the receipts snapshots, skipping the blocks they
+// don't cover.
+func (api *APIImpl) getFrozenLogs(ctx context.Context, tx kv.Tx, begin, end uint64, addrMap map[common.Address]struct{}, crit filters.FilterCriteria) (types.Logs, error) {
+	logs := types.Logs{}
+	for blockNumber := begin; blockNumber <= end; blockNumber++ {
+		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
-		if body == nil {
-			return nil, fmt.Errorf("block not found %d", blockNumber)
+		receipts, err := api._blockReader.RawReceipts(ctx, blockNumber)
+		if err != nil {
+			return nil, err
 		}
-		for _, log := range blockLogs {
-			log.BlockNumber = blockNumber
-			log.BlockHash = blockHash
-			// bor transactions are at the end of the bodies transactions (added manually but not actually part of the block)
-			if log.TxIndex == uint(len(body.Transactions)) {
-				log.TxHash = types.ComputeBorTxHash(blockNumber, blockHash)
-			} else {
-				log.TxHash = body.Transactions[log.TxIndex].Hash()
+		var logIndex uint
+		var blockLogs []*types.Log
+		for txIndex, receipt := range receipts {
+			for _, log := range receipt.Logs {
+				log.Index = logIndex
+				logIndex++
+			}
+			filtered := types.Logs(receipt.Logs).Filter(addrMap, crit.Topics)
+			for _, log := range filtered {
+				log.TxIndex = uint(txIndex)
 			}
+			blockLogs = append(blockLogs, filtered...)
+		}
+		if len(blockLogs) == 0 {
+			continue
+		}
+		if err := api.setLogsBlockFields(ctx, tx, blockNumber, blockLogs); err != nil {
+			return nil, err
 		}
 		logs = append(logs, blockLogs...)
 	}
-
 	return logs, nil
 }
 
+// setLogsBlockFields sets the fields of the logs of a block that are not stored with them.
+func (api *APIImpl) setLogsBlockFields(ctx context.Context, tx kv.Tx, blockNumber uint64, blockLogs []*types.Log) error {
+	blockHash, err := api._blockReader.CanonicalHash(ctx, tx, blockNumber)
+	if err != nil {
+		return err
+	}
+
+	body, err := api._blockReader.BodyWithTransactions(ctx, tx, blockHash, blockNumber)
+	if err != nil {
+		return err
+	}
+	if body == nil {
+		return fmt.Errorf("block not found %d", blockNumber)
+	}
+	for _, log := range blockLogs {
+		log.BlockNumber = blockNumber
+		log.BlockHash = blockHash
+		// bor transactions are at the end of the bodies transactions (added manually but not actually part of the block)
+		if log.TxIndex == uint(len(body.Transactions)) {
+			log.TxHash = types.ComputeBorTxHash(blockNumber, blockHash)
+		} else {
+			log.TxHash = body.Transactions[log.TxIndex].Hash()
+		}
+	}
+	return nil
+}
+
 // The Topic list restricts matches to particular event topics. Each event has a list
 // of topics. Topics matches a prefix of that list. An empty element slice matches any
 // topic. Non-empty elements represent an alternative that matches any of the
@@ -0,0 +1,303 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks a single eth_simulateV1 request may simulate
+	maxSimulateBlocks = 256
+	// simulateBlockTime is the number of seconds added to the parent timestamp when a simulated block doesn't override it
+	simulateBlockTime = 12
+)
+
+// SimulatedBlock is a block of calls executed by eth_simulateV1. Overrides apply before the first call of the block.
+type SimulatedBlock struct {
+	BlockOverrides *BlockOverrides        `json:"blockOverrides"`
+	StateOverrides *ethapi.StateOverrides `json:"stateOverrides"`
+	Calls          []ethapi.CallArgs      `json:"calls"`
+}
+
+// SimulationOpts are the parameters of eth_simulateV1.
+type SimulationOpts struct {
+	BlockStateCalls []SimulatedBlock `json:"blockStateCalls"`
+	// Validation makes calls subject to the checks of real execution: nonces, balances, base fee and block gas limit
+	Validation bool `json:"validation"`
+}
+
+// SimulateV1 implements eth_simulateV1. Executes consecutive blocks of calls on top of the given block. Each block sees the
+// state left by the previous ones, may override its header fields and the state, and reports the logs and gas of every call.
+func (api *APIImpl) SimulateV1(ctx context.Context, opts SimulationOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, fmt.Errorf("empty input")
+	}
+	if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, fmt.Errorf("too many blocks: %d, max %d", len(opts.BlockStateCalls), maxSimulateBlocks)
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(start time.Time) { log.Trace("Executing EVM simulateV1 finished", "runtime", time.Since(start)) }(time.Now())
+
+	nrOrHash := latestNumOrHash
+	if blockNrOrHash != nil {
+		nrOrHash = *blockNrOrHash
+	}
+	blockNum, hash, _, err := rpchelper.GetCanonicalBlockNumber(nrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.blockWithSenders(ctx, tx, hash, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d(%x) not found", blockNum, hash)
+	}
+	stateReader, err := rpchelper.CreateStateReader(ctx, tx, nrOrHash, 0, api.filters, api.stateCache, api.historyV3(tx), chainConfig.ChainName)
+	if err != nil {
+		return nil, err
+	}
+	ibs := state.New(stateReader)
+
+	overrideBlockHash := make(map[uint64]libcommon.Hash)
+	simulatedHashes := make(map[uint64]libcommon.Hash)
+	getHash := func(i uint64) libcommon.Hash {
+		if hash, ok := overrideBlockHash[i]; ok {
+			return hash
+		}
+		if hash, ok := simulatedHashes[i]; ok {
+			return hash
+		}
+		hash, err := api._blockReader.CanonicalHash(ctx, tx, i)
+		if err != nil {
+			log.Debug("Can't get block hash by number", "number", i, "only-canonical", true)
+		}
+		return hash
+	}
+
+	// Setup context so it may be cancelled the call has completed
+	// or, in case of unmetered gas, setup a context with a timeout.
+	var cancel context.CancelFunc
+	if api.evmCallTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, api.evmCallTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	// Make sure the context is cancelled when the call has completed
+	// this makes sure resources are cleaned up.
+	defer cancel()
+
+	// A single EVM runs all the calls, reset in between, so that it can be cancelled from another goroutine.
+	vmConfig := vm.Config{NoBaseFee: !opts.Validation}
+	evm := vm.NewEVM(evmtypes.BlockContext{}, evmtypes.TxContext{}, ibs, chainConfig, vmConfig)
+	// Wait for the context to be done and cancel the evm. Even if the
+	// EVM has finished, cancelling may be done (repeatedly)
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+
+	parent := block.Header()
+	ret := make([]map[string]interface{}, 0, len(opts.BlockStateCalls))
+	for blockIdx, simBlock := range opts.BlockStateCalls {
+		header, err := simulatedHeader(chainConfig, parent, simBlock.BlockOverrides, opts.Validation)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", blockIdx, err)
+		}
+		if simBlock.BlockOverrides != nil && simBlock.BlockOverrides.BlockHash != nil {
+			for blockNum, hash := range *simBlock.BlockOverrides.BlockHash {
+				overrideBlockHash[blockNum] = hash
+			}
+		}
+		if simBlock.StateOverrides != nil {
+			if err := simBlock.StateOverrides.Override(ibs); err != nil {
+				return nil, fmt.Errorf("block %d: %w", blockIdx, err)
+			}
+		}
+
+		blockCtx := core.NewEVMBlockContext(header, getHash, api.engine(), &header.Coinbase)
+		rules := chainConfig.Rules(header.Number.Uint64(), header.Time)
+		evm.ResetBetweenBlocks(blockCtx, evmtypes.TxContext{}, ibs, vmConfig, rules)
+		gasLimit := uint64(math.MaxUint64)
+		if opts.Validation {
+			gasLimit = header.GasLimit
+		}
+		gp := new(core.GasPool).AddGas(gasLimit).AddDataGas(math.MaxUint64)
+
+		var blockLogs []*types.Log
+		calls := make([]map[string]interface{}, 0, len(simBlock.Calls))
+		for callIdx, args := range simBlock.Calls {
+			if args.Gas == nil || *args.Gas == 0 {
+				gas := api.GasCap
+				if opts.Validation && gp.Gas() < gas {
+					gas = gp.Gas()
+				}
+				args.Gas = (*hexutil.Uint64)(&gas)
+			}
+			msg, err := args.ToMessage(api.GasCap, blockCtx.BaseFee)
+			if err != nil {
+				return nil, fmt.Errorf("block %d, call %d: %w", blockIdx, callIdx, err)
+			}
+			if opts.Validation {
+				nonce := ibs.GetNonce(msg.From())
+				if args.Nonce != nil {
+					nonce = uint64(*args.Nonce)
+				}
+				msg = types.NewMessage(msg.From(), msg.To(), nonce, msg.Value(), msg.Gas(), msg.GasPrice(), msg.FeeCap(), msg.Tip(), msg.Data(), msg.AccessList(), true /* checkNonce */, false /* isFree */, msg.MaxFeePerDataGas())
+			}
+
+			txHash := simulatedTxHash(header.Number.Uint64(), callIdx)
+			ibs.SetTxContext(txHash, libcommon.Hash{}, callIdx)
+			evm.Reset(core.NewEVMTxContext(msg), ibs)
+			// resetting clears a cancellation which happened in between calls
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("execution aborted (timeout = %v)", api.evmCallTimeout)
+			}
+			result, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */)
+			if err != nil {
+				return nil, fmt.Errorf("block %d, call %d: %w", blockIdx, callIdx, err)
+			}
+			if err = ibs.FinalizeTx(rules, state.NewNoopWriter()); err != nil {
+				return nil, err
+			}
+			// If the timer caused an abort, return an appropriate error message
+			if evm.Cancelled() {
+				return nil, fmt.Errorf("execution aborted (timeout = %v)", api.evmCallTimeout)
+			}
+			if len(result.ReturnData) > api.ReturnDataLimit {
+				return nil, fmt.Errorf("call returned result on length %d exceeding --rpc.returndata.limit %d", len(result.ReturnData), api.ReturnDataLimit)
+			}
+
+			logs := ibs.GetLogs(txHash)
+			if logs == nil {
+				logs = []*types.Log{}
+			}
+			for _, l := range logs {
+				l.BlockNumber = header.Number.Uint64()
+			}
+			blockLogs = append(blockLogs, logs...)
+			header.GasUsed += result.UsedGas
+
+			callResult := map[string]interface{}{
+				"returnData": hexutility.Bytes(result.ReturnData),
+				"logs":       logs,
+				"gasUsed":    hexutil.Uint64(result.UsedGas),
+				"status":     hexutil.Uint64(types.ReceiptStatusSuccessful),
+			}
+			if result.Err != nil {
+				callResult["status"] = hexutil.Uint64(types.ReceiptStatusFailed)
+				if len(result.Revert()) > 0 {
+					callResult["error"] = ethapi.NewRevertError(result)
+				} else {
+					callResult["error"] = result.Err.Error()
+				}
+			}
+			calls = append(calls, callResult)
+		}
+
+		blockHash := header.Hash()
+		for _, l := range blockLogs {
+			l.BlockHash = blockHash
+		}
+		simulatedHashes[header.Number.Uint64()] = blockHash
+
+		fields := map[string]interface{}{
+			"number":     (*hexutil.Big)(header.Number),
+			"hash":       blockHash,
+			"parentHash": header.ParentHash,
+			"timestamp":  hexutil.Uint64(header.Time),
+			"gasLimit":   hexutil.Uint64(header.GasLimit),
+			"gasUsed":    hexutil.Uint64(header.GasUsed),
+			"miner":      header.Coinbase,
+			"calls":      calls,
+		}
+		if header.BaseFee != nil {
+			fields["baseFeePerGas"] = (*hexutil.Big)(header.BaseFee)
+		}
+		ret = append(ret, fields)
+		parent = header
+	}
+	return ret, nil
+}
+
+// simulatedHeader builds the header of a simulated block on top of parent, applying the block overrides. Without
+// validation the base fee defaults to zero, so that calls don't have to pay for gas.
+func simulatedHeader(chainConfig *chain.Config, parent *types.Header, overrides *BlockOverrides, validation bool) (*types.Header, error) {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   parent.Coinbase,
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + simulateBlockTime,
+		MixDigest:  parent.MixDigest,
+	}
+	if overrides == nil {
+		overrides = &BlockOverrides{}
+	}
+	if overrides.BlockNumber != nil {
+		if uint64(*overrides.BlockNumber) <= parent.Number.Uint64() {
+			return nil, fmt.Errorf("block number %d is not greater than the parent one %d", uint64(*overrides.BlockNumber), parent.Number.Uint64())
+		}
+		header.Number.SetUint64(uint64(*overrides.BlockNumber))
+	}
+	if overrides.Timestamp != nil {
+		if uint64(*overrides.Timestamp) <= parent.Time {
+			return nil, fmt.Errorf("block timestamp %d is not greater than the parent one %d", uint64(*overrides.Timestamp), parent.Time)
+		}
+		header.Time = uint64(*overrides.Timestamp)
+	}
+	if overrides.Coinbase != nil {
+		header.Coinbase = *overrides.Coinbase
+	}
+	if overrides.GasLimit != nil {
+		header.GasLimit = uint64(*overrides.GasLimit)
+	}
+	if overrides.Difficulty != nil {
+		header.Difficulty = big.NewInt(int64(*overrides.Difficulty))
+	}
+	switch {
+	case overrides.BaseFee != nil:
+		header.BaseFee = overrides.BaseFee.ToBig()
+	case chainConfig.IsLondon(header.Number.Uint64()):
+		header.BaseFee = new(big.Int)
+		if validation {
+			header.BaseFee = misc.CalcBaseFee(chainConfig, parent)
+		}
+	}
+	return header, nil
+}
+
+// simulatedTxHash identifies a simulated call, which has no transaction, by its position in the simulated chain.
+func simulatedTxHash(blockNum uint64, callIdx int) libcommon.Hash {
+	return crypto.Keccak256Hash(hexutility.EncodeTs(blockNum), hexutility.EncodeTs(uint64(callIdx)))
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+)
+
+func TestSimulateV1(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	agg := m.HistoryV3Components()
+	br, _ := m.NewBlocksIO()
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, br, agg, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs), m.DB, nil, nil, nil, 5000000, 100_000, 100_000, log.New())
+	ctx := context.Background()
+
+	latest, err := api.GetBlockByNumber(ctx, -1 /* latest */, false)
+	require.NoError(t, err)
+	latestNumber := latest["number"].(*hexutil.Big).ToInt().Uint64()
+	latestTime := uint64(latest["timestamp"].(hexutil.Uint64))
+
+	var (
+		from     = libcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+		contract = libcommon.HexToAddress("0x2000000000000000000000000000000000000002")
+		coinbase = libcommon.HexToAddress("0x3000000000000000000000000000000000000003")
+		// emits an empty log and returns the block timestamp
+		code = hexutility.Bytes{
+			byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.LOG0),
+			byte(vm.TIMESTAMP), byte(vm.PUSH1), 0x0, byte(vm.MSTORE),
+			byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x0, byte(vm.RETURN),
+		}
+		balance   = (*hexutil.Big)(big.NewInt(1e18))
+		timestamp = hexutil.Uint64(latestTime + 100)
+	)
+	call := ethapi.CallArgs{From: &from, To: &contract}
+	opts := SimulationOpts{BlockStateCalls: []SimulatedBlock{
+		{
+			BlockOverrides: &BlockOverrides{Timestamp: &timestamp, Coinbase: &coinbase},
+			StateOverrides: &ethapi.StateOverrides{contract: {Code: &code}},
+			Calls:          []ethapi.CallArgs{call},
+		},
+		{
+			Calls: []ethapi.CallArgs{call, call},
+		},
+	}}
+	res, err := api.SimulateV1(ctx, opts, nil)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	require.Equal(t, new(big.Int).SetUint64(latestNumber+1), res[0]["number"].(*hexutil.Big).ToInt())
+	require.Equal(t, timestamp, res[0]["timestamp"])
+	require.Equal(t, coinbase, res[0]["miner"])
+	calls := res[0]["calls"].([]map[string]interface{})
+	require.Len(t, calls, 1)
+	require.Equal(t, hexutil.Uint64(types.ReceiptStatusSuccessful), calls[0]["status"])
+	require.Equal(t, hexutility.Bytes(uint256.NewInt(uint64(timestamp)).PaddedBytes(32)), calls[0]["returnData"])
+	logs := calls[0]["logs"].([]*types.Log)
+	require.Len(t, logs, 1)
+	require.Equal(t, contract, logs[0].Address)
+	require.Equal(t, res[0]["hash"], logs[0].BlockHash)
+	require.Equal(t, calls[0]["gasUsed"], res[0]["gasUsed"])
+
+	// The second block builds on top of the first one
+	require.Equal(t, new(big.Int).SetUint64(latestNumber+2), res[1]["number"].(*hexutil.Big).ToInt())
+	require.Equal(t, res[0]["hash"], res[1]["parentHash"])
+	require.Equal(t, timestamp+simulateBlockTime, res[1]["timestamp"])
+	calls = res[1]["calls"].([]map[string]interface{})
+	require.Len(t, calls, 2)
+	require.Equal(t, hexutility.Bytes(uint256.NewInt(uint64(timestamp)+simulateBlockTime).PaddedBytes(32)), calls[1]["returnData"])
+
+	// Block numbers must increase
+	number := hexutil.Uint64(latestNumber)
+	_, err = api.SimulateV1(ctx, SimulationOpts{BlockStateCalls: []SimulatedBlock{{BlockOverrides: &BlockOverrides{BlockNumber: &number}}}}, nil)
+	require.Error(t, err)
+
+	// Validation enforces nonces
+	nonce := hexutil.Uint64(1)
+	validated := ethapi.CallArgs{From: &from, To: &contract, Nonce: &nonce, MaxFeePerGas: (*hexutil.Big)(big.NewInt(1e10))}
+	opts = SimulationOpts{Validation: true, BlockStateCalls: []SimulatedBlock{{
+		StateOverrides: &ethapi.StateOverrides{contract: {Code: &code}, from: {Balance: &balance}},
+		Calls:          []ethapi.CallArgs{validated},
+	}}}
+	_, err = api.SimulateV1(ctx, opts, nil)
+	require.ErrorContains(t, err, "nonce too high")
+
+	nonce = 0
+	res, err = api.SimulateV1(ctx, opts, nil)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Uint64(types.ReceiptStatusSuccessful), res[0]["calls"].([]map[string]interface{})[0]["status"])
+}
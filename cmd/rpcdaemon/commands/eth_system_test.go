@@ -45,7 +45,7 @@ func TestGasPrice(t *testing.T) {
 			stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
 			br, _ := m.NewBlocksIO()
 			base := NewBaseApi(nil, stateCache, br, nil, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs)
-			eth := NewEthAPI(base, m.DB, nil, nil, nil, 5000000, 100_000, log.New())
+			eth := NewEthAPI(base, m.DB, nil, nil, nil, 5000000, 100_000, 100_000, log.New())
 
 			ctx := context.Background()
 			result, err := eth.GasPrice(ctx)
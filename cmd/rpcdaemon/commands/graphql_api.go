@@ -18,6 +18,8 @@ import (
 type GraphQLAPI interface {
 	GetBlockDetails(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error)
 	GetChainID(ctx context.Context) (*big.Int, error)
+	// Filters is the subscription machinery shared with eth_subscribe, nil if notifications are not supported.
+	Filters() *rpchelper.Filters
 }
 
 type GraphQLAPIImpl struct {
@@ -32,6 +34,10 @@ func NewGraphQLAPI(base *BaseAPI, db kv.RoDB) *GraphQLAPIImpl {
 	}
 }
 
+func (api *GraphQLAPIImpl) Filters() *rpchelper.Filters {
+	return api.filters
+}
+
 func (api *GraphQLAPIImpl) GetChainID(ctx context.Context) (*big.Int, error) {
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
@@ -1,8 +1,10 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"sort"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
@@ -17,6 +19,9 @@ import (
 // NetAPI the interface for the net_ RPC commands
 type TxPoolAPI interface {
 	Content(ctx context.Context) (map[string]map[string]map[string]*RPCTransaction, error)
+	ContentFrom(ctx context.Context, addr libcommon.Address) (map[string]map[string]*RPCTransaction, error)
+	ContentPaginated(ctx context.Context, cursor *libcommon.Address, pageSize *hexutil.Uint) (*TxPoolContentPage, error)
+	Status(ctx context.Context) (map[string]hexutil.Uint, error)
 }
 
 // TxPoolAPIImpl data structure to store things needed for net_ commands
@@ -40,7 +45,98 @@ func (api *TxPoolAPIImpl) Content(ctx context.Context) (map[string]map[string]ma
 	if err != nil {
 		return nil, err
 	}
+	return api.content(ctx, reply, nil)
+}
 
+// ContentFrom returns the transactions of the pool sent by addr, by sub-pool and nonce.
+func (api *TxPoolAPIImpl) ContentFrom(ctx context.Context, addr libcommon.Address) (map[string]map[string]*RPCTransaction, error) {
+	reply, err := api.pool.All(ctx, &proto_txpool.AllRequest{})
+	if err != nil {
+		return nil, err
+	}
+	content, err := api.content(ctx, reply, func(sender libcommon.Address) bool { return sender == addr })
+	if err != nil || content == nil {
+		return nil, err
+	}
+	account := addr.Hex()
+	result := make(map[string]map[string]*RPCTransaction, len(content))
+	for subPool, senders := range content {
+		if dump, ok := senders[account]; ok {
+			result[subPool] = dump
+		} else {
+			result[subPool] = make(map[string]*RPCTransaction)
+		}
+	}
+	return result, nil
+}
+
+const (
+	defaultTxPoolPageSize = 100
+	maxTxPoolPageSize     = 1000
+)
+
+// TxPoolContentPage is a page of the pool content, see ContentPaginated.
+type TxPoolContentPage struct {
+	Pending map[string]map[string]*RPCTransaction `json:"pending"`
+	BaseFee map[string]map[string]*RPCTransaction `json:"baseFee"`
+	Queued  map[string]map[string]*RPCTransaction `json:"queued"`
+	// Next is the cursor of the following page, nil on the last one
+	Next *libcommon.Address `json:"next"`
+}
+
+// ContentPaginated returns the content of the pool for up to pageSize senders, those ordered after cursor or
+// from the first one when it is nil. The transactions of a sender are never split over pages.
+func (api *TxPoolAPIImpl) ContentPaginated(ctx context.Context, cursor *libcommon.Address, pageSize *hexutil.Uint) (*TxPoolContentPage, error) {
+	size := defaultTxPoolPageSize
+	if pageSize != nil {
+		size = int(*pageSize)
+	}
+	if size <= 0 || size > maxTxPoolPageSize {
+		return nil, fmt.Errorf("page size must be between 1 and %d", maxTxPoolPageSize)
+	}
+	reply, err := api.pool.All(ctx, &proto_txpool.AllRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	senders := make([]libcommon.Address, 0, len(reply.Txs))
+	seen := make(map[libcommon.Address]struct{}, len(reply.Txs))
+	for i := range reply.Txs {
+		addr := gointerfaces.ConvertH160toAddress(reply.Txs[i].Sender)
+		if _, ok := seen[addr]; ok {
+			continue
+		}
+		seen[addr] = struct{}{}
+		if cursor == nil || bytes.Compare(addr[:], cursor[:]) > 0 {
+			senders = append(senders, addr)
+		}
+	}
+	sort.Slice(senders, func(i, j int) bool { return bytes.Compare(senders[i][:], senders[j][:]) < 0 })
+
+	page := &TxPoolContentPage{}
+	if len(senders) > size {
+		senders = senders[:size]
+		next := senders[size-1]
+		page.Next = &next
+	}
+	inPage := make(map[libcommon.Address]struct{}, len(senders))
+	for _, addr := range senders {
+		inPage[addr] = struct{}{}
+	}
+	content, err := api.content(ctx, reply, func(sender libcommon.Address) bool {
+		_, ok := inPage[sender]
+		return ok
+	})
+	if err != nil || content == nil {
+		return nil, err
+	}
+	page.Pending, page.BaseFee, page.Queued = content["pending"], content["baseFee"], content["queued"]
+	return page, nil
+}
+
+// content groups the transactions of reply by sub-pool, sender and nonce, keeping only those of the senders
+// include accepts, all of them when it is nil.
+func (api *TxPoolAPIImpl) content(ctx context.Context, reply *proto_txpool.AllReply, include func(libcommon.Address) bool) (map[string]map[string]map[string]*RPCTransaction, error) {
 	content := map[string]map[string]map[string]*RPCTransaction{
 		"pending": make(map[string]map[string]*RPCTransaction),
 		"baseFee": make(map[string]map[string]*RPCTransaction),
@@ -51,11 +147,14 @@ func (api *TxPoolAPIImpl) Content(ctx context.Context) (map[string]map[string]ma
 	baseFee := make(map[libcommon.Address][]types.Transaction, 8)
 	queued := make(map[libcommon.Address][]types.Transaction, 8)
 	for i := range reply.Txs {
+		addr := gointerfaces.ConvertH160toAddress(reply.Txs[i].Sender)
+		if include != nil && !include(addr) {
+			continue
+		}
 		txn, err := types.DecodeWrappedTransaction(reply.Txs[i].RlpTx)
 		if err != nil {
 			return nil, fmt.Errorf("decoding transaction from: %x: %w", reply.Txs[i].RlpTx, err)
 		}
-		addr := gointerfaces.ConvertH160toAddress(reply.Txs[i].Sender)
 		switch reply.Txs[i].TxnType {
 		case proto_txpool.AllReply_PENDING:
 			if _, ok := pending[addr]; !ok {
@@ -59,6 +59,27 @@ func TestTxPoolContent(t *testing.T) {
 	require.Equal(1, len(content["pending"][sender]))
 	require.Equal(expectValue, content["pending"][sender]["0"].Value.ToInt().Uint64())
 
+	contentFrom, err := api.ContentFrom(ctx, m.Address)
+	require.NoError(err)
+	require.Equal(1, len(contentFrom["pending"]))
+	require.Equal(expectValue, contentFrom["pending"]["0"].Value.ToInt().Uint64())
+	require.Empty(contentFrom["queued"])
+	contentFrom, err = api.ContentFrom(ctx, libcommon.Address{1})
+	require.NoError(err)
+	require.Empty(contentFrom["pending"])
+
+	pageSize := hexutil.Uint(1)
+	page, err := api.ContentPaginated(ctx, nil, &pageSize)
+	require.NoError(err)
+	require.Equal(1, len(page.Pending[sender]))
+	require.Nil(page.Next)
+	page, err = api.ContentPaginated(ctx, &m.Address, &pageSize)
+	require.NoError(err)
+	require.Empty(page.Pending)
+	pageSize = 0
+	_, err = api.ContentPaginated(ctx, nil, &pageSize)
+	require.Error(err)
+
 	status, err := api.Status(ctx)
 	require.NoError(err)
 	require.Len(status, 3)
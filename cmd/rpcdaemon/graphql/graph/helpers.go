@@ -5,16 +5,24 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/holiman/uint256"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/common/length"
 
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/graphql/graph/model"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
 )
 
+// subscriptionBufferSize is the number of notifications buffered for each subscription, rpchelper.Filters drops
+// the notifications of a subscriber lagging further behind.
+const subscriptionBufferSize = 128
+
 func convertDataToStringP(abstractMap map[string]interface{}, field string) *string {
 	var result string
 
@@ -134,3 +142,66 @@ func convertStrHexToDec(hexString *string) *string {
 
 	return &result
 }
+
+func convertBlockFilterCriteria(filter model.BlockFilterCriteria) (filters.FilterCriteria, error) {
+	var crit filters.FilterCriteria
+	for _, address := range filter.Addresses {
+		if !libcommon.IsHexAddress(address) {
+			return crit, fmt.Errorf("invalid address: %s", address)
+		}
+		crit.Addresses = append(crit.Addresses, libcommon.HexToAddress(address))
+	}
+	for _, alternatives := range filter.Topics {
+		topics := make([]libcommon.Hash, 0, len(alternatives))
+		for _, topic := range alternatives {
+			b, err := hexutil.Decode(topic)
+			if err != nil || len(b) != length.Hash {
+				return crit, fmt.Errorf("invalid topic: %s", topic)
+			}
+			topics = append(topics, libcommon.BytesToHash(b))
+		}
+		crit.Topics = append(crit.Topics, topics)
+	}
+	return crit, nil
+}
+
+func convertLog(l *types.Log) *model.Log {
+	out := &model.Log{
+		Index:       int(l.Index),
+		Account:     &model.Account{Address: strings.ToLower(l.Address.String())},
+		Data:        "0x" + hex.EncodeToString(l.Data),
+		Topics:      make([]string, 0, len(l.Topics)),
+		Transaction: &model.Transaction{Hash: l.TxHash.String()},
+	}
+	for _, topic := range l.Topics {
+		out.Topics = append(out.Topics, topic.String())
+	}
+	return out
+}
+
+func convertPendingTransaction(txn types.Transaction, signer *types.Signer) *model.Transaction {
+	out := &model.Transaction{
+		Hash:      txn.Hash().String(),
+		Nonce:     "0x" + strconv.FormatUint(txn.GetNonce(), 16),
+		Value:     txn.GetValue().Hex(),
+		GasPrice:  txn.GetPrice().Hex(),
+		Gas:       txn.GetGas(),
+		InputData: "0x" + hex.EncodeToString(txn.GetData()),
+		From:      &model.Account{},
+	}
+	if txn.Type() != types.LegacyTxType && txn.Type() != types.AccessListTxType {
+		maxFeePerGas, maxPriorityFeePerGas := txn.GetFeeCap().Hex(), txn.GetTip().Hex()
+		out.MaxFeePerGas, out.MaxPriorityFeePerGas = &maxFeePerGas, &maxPriorityFeePerGas
+	}
+	txType := int(txn.Type())
+	out.Type = &txType
+	if from, err := txn.Sender(*signer); err == nil {
+		out.From.Address = strings.ToLower(from.String())
+	}
+	if to := txn.GetTo(); to != nil {
+		out.To = &model.Account{Address: strings.ToLower(to.String())}
+	}
+	v, r, s := txn.RawSignatureValues()
+	out.V, out.R, out.S = v.Hex(), r.Hex(), s.Hex()
+	return out
+}
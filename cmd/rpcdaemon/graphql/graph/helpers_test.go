@@ -0,0 +1,26 @@
+package graph
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/graphql/graph/model"
+)
+
+func TestConvertBlockFilterCriteria(t *testing.T) {
+	topic := "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	crit, err := convertBlockFilterCriteria(model.BlockFilterCriteria{
+		Addresses: []string{"0xdac17f958d2ee523a2206206994597c13d831ec7"},
+		Topics:    [][]string{{topic}, {}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []libcommon.Address{libcommon.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7")}, crit.Addresses)
+	require.Equal(t, [][]libcommon.Hash{{libcommon.HexToHash(topic)}, {}}, crit.Topics)
+
+	_, err = convertBlockFilterCriteria(model.BlockFilterCriteria{Addresses: []string{"0x1234"}})
+	require.Error(t, err)
+	_, err = convertBlockFilterCriteria(model.BlockFilterCriteria{Topics: [][]string{{"0x1234"}}})
+	require.Error(t, err)
+}
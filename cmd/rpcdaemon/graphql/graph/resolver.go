@@ -17,3 +17,11 @@ type Resolver struct {
 	filters     *rpchelper.Filters
 	blockReader services.FullBlockReader
 }
+
+func NewResolver(graphqlAPI commands.GraphQLAPI) *Resolver {
+	r := &Resolver{GraphQLAPI: graphqlAPI}
+	if graphqlAPI != nil {
+		r.filters = graphqlAPI.Filters()
+	}
+	return r
+}
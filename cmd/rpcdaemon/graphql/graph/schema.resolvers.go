@@ -12,9 +12,11 @@ import (
 	"strings"
 
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/graphql/graph/model"
+	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/log/v3"
 )
 
 // SendRawTransaction is the resolver for the sendRawTransaction field.
@@ -201,11 +203,131 @@ func (r *queryResolver) ChainID(ctx context.Context) (string, error) {
 	return "0x" + strconv.FormatUint(chainID.Uint64(), 16), err
 }
 
+// NewBlock is the resolver for the newBlock field.
+func (r *subscriptionResolver) NewBlock(ctx context.Context) (<-chan *model.Block, error) {
+	if r.filters == nil {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	out := make(chan *model.Block, subscriptionBufferSize)
+	go func() {
+		defer debug.LogPanic()
+		defer close(out)
+		headers, id := r.filters.SubscribeNewHeads(subscriptionBufferSize)
+		defer r.filters.UnsubscribeHeads(id)
+		for {
+			select {
+			case h, ok := <-headers:
+				if !ok {
+					return
+				}
+				if h == nil {
+					continue
+				}
+				number := h.Number.String()
+				block, err := (&queryResolver{r.Resolver}).Block(ctx, &number, nil)
+				if err != nil {
+					log.Warn("[graphql] could not resolve new block", "number", number, "err", err)
+					continue
+				}
+				select {
+				case out <- block:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Logs is the resolver for the logs field.
+func (r *subscriptionResolver) Logs(ctx context.Context, filter model.BlockFilterCriteria) (<-chan *model.Log, error) {
+	if r.filters == nil {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	crit, err := convertBlockFilterCriteria(filter)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan *model.Log, subscriptionBufferSize)
+	go func() {
+		defer debug.LogPanic()
+		defer close(out)
+		logs, id := r.filters.SubscribeLogs(subscriptionBufferSize, crit)
+		defer r.filters.UnsubscribeLogs(id)
+		for {
+			select {
+			case l, ok := <-logs:
+				if !ok {
+					return
+				}
+				if l == nil {
+					continue
+				}
+				select {
+				case out <- convertLog(l):
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// PendingTransactions is the resolver for the pendingTransactions field.
+func (r *subscriptionResolver) PendingTransactions(ctx context.Context) (<-chan *model.Transaction, error) {
+	if r.filters == nil {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	chainID, err := r.GraphQLAPI.GetChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	out := make(chan *model.Transaction, subscriptionBufferSize)
+	go func() {
+		defer debug.LogPanic()
+		defer close(out)
+		txsCh, id := r.filters.SubscribePendingTxs(subscriptionBufferSize)
+		defer r.filters.UnsubscribePendingTxs(id)
+		for {
+			select {
+			case txs, ok := <-txsCh:
+				if !ok {
+					return
+				}
+				for _, txn := range txs {
+					if txn == nil {
+						continue
+					}
+					select {
+					case out <- convertPendingTransaction(txn, signer):
+					case <-ctx.Done():
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
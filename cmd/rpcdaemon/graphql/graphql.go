@@ -3,9 +3,15 @@ package graphql
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gorilla/websocket"
+	"github.com/ledgerwatch/log/v3"
 
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/graphql/graph"
@@ -16,7 +22,9 @@ const (
 	urlPath = "/graphql"
 )
 
-func CreateHandler(api []rpc.API) *handler.Server {
+// CreateHandler serves the GraphQL API, its subscriptions are upgraded to WebSocket connections from the allowed
+// origins only, see rpc.WebsocketOriginValidator.
+func CreateHandler(api []rpc.API, allowedOrigins []string, logger log.Logger) *handler.Server {
 
 	var graphqlAPI commands.GraphQLAPI
 
@@ -30,10 +38,28 @@ func CreateHandler(api []rpc.API) *handler.Server {
 		}
 	}
 
-	resolver := graph.Resolver{}
-	resolver.GraphQLAPI = graphqlAPI
+	resolver := graph.NewResolver(graphqlAPI)
 
-	return handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver})) // TODO : init resolver.DB here !!!
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: resolver})) // TODO : init resolver.DB here !!!
+	// Subscriptions are served over graphql-ws on the same endpoint.
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: rpc.WebsocketOriginValidator(allowedOrigins, logger),
+		},
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	srv.SetQueryCache(lru.New(1000))
+
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New(100),
+	})
+	return srv
 }
 
 func ProcessGraphQLcheckIfNeeded(
@@ -3,11 +3,31 @@ package graphql
 import (
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
 )
 
+func TestGraphQLWebsocketOrigins(t *testing.T) {
+	server := httptest.NewServer(CreateHandler(nil, []string{"http://allowed.example"}, log.New()))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-ws"}}
+
+	_, resp, err := dialer.Dial(url, http.Header{"Origin": {"http://evil.example"}})
+	require.Error(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	conn, _, err := dialer.Dial(url, http.Header{"Origin": {"http://allowed.example"}})
+	require.NoError(t, err)
+	conn.Close()
+}
+
 func TestGraphQLQueryBlock(t *testing.T) {
 	t.Skip("Not a unit test")
 
@@ -0,0 +1,253 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+const (
+	detailedURLPath = "/health/detailed"
+
+	probeParam       = "probe"
+	probeReadiness   = "readiness"
+	probeLiveness    = "liveness"
+	maxStageLagParam = "max_stage_lag"
+
+	// defaultMaxStageLag is how many blocks the last stage may be behind the headers for the node to be ready
+	defaultMaxStageLag = 10
+
+	statusReady    = "ready"
+	statusStarting = "starting"
+	statusBroken   = "broken"
+
+	// stateCacheLabel is the metrics label of the rpcdaemon state cache, see cli.RootCommand
+	stateCacheLabel = "rpc"
+)
+
+// detailedReport is the body of the /health/detailed response.
+//
+// A node is "broken" when one of its components can't be queried, "starting" while it is downloading snapshots or
+// its last stage lags behind the headers, and "ready" otherwise. Readiness probes only succeed on a ready node, while
+// liveness probes succeed on a starting one as well, so that a syncing node isn't restarted.
+type detailedReport struct {
+	Status       string          `json:"status"`
+	Errors       []string        `json:"errors,omitempty"`
+	HighestBlock uint64          `json:"highest_block"`
+	Stages       []stageReport   `json:"stages,omitempty"`
+	Snapshots    snapshotsReport `json:"snapshots"`
+	Head         headReport      `json:"head"`
+	Peers        string          `json:"peers"`
+	PeerCount    uint64          `json:"peer_count"`
+	TxPool       string          `json:"txpool"`
+	StateCache   cacheReport     `json:"state_cache"`
+}
+
+type stageReport struct {
+	Name  string `json:"name"`
+	Block uint64 `json:"block"`
+	Lag   uint64 `json:"lag"`
+}
+
+type snapshotsReport struct {
+	Blocks     uint64 `json:"blocks"`
+	Downloaded bool   `json:"downloaded"`
+}
+
+type headReport struct {
+	Number        uint64 `json:"number"`
+	Timestamp     uint64 `json:"timestamp"`
+	SecondsBehind int64  `json:"seconds_behind"`
+}
+
+type cacheReport struct {
+	Hits        uint64  `json:"hits"`
+	Misses      uint64  `json:"misses"`
+	HitRate     float64 `json:"hit_rate"`
+	CodeHits    uint64  `json:"code_hits"`
+	CodeMisses  uint64  `json:"code_misses"`
+	CodeHitRate float64 `json:"code_hit_rate"`
+}
+
+func processDetailed(w http.ResponseWriter, r *http.Request, rpcAPI []rpc.API) {
+	query := r.URL.Query()
+	probe := probeReadiness
+	if p := query.Get(probeParam); p != "" {
+		probe = p
+	}
+	if probe != probeReadiness && probe != probeLiveness {
+		writeDetailedError(w, fmt.Errorf("%w: %s=%s", errBadHeaderValue, probeParam, probe))
+		return
+	}
+	maxStageLag := uint64(defaultMaxStageLag)
+	if v := query.Get(maxStageLagParam); v != "" {
+		lag, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeDetailedError(w, fmt.Errorf("%w: %s=%s", errBadHeaderValue, maxStageLagParam, v))
+			return
+		}
+		maxStageLag = lag
+	}
+	maxSecondsLate := -1
+	if v := query.Get(maxSecondsBehind); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			writeDetailedError(w, fmt.Errorf("%w: %s=%s", errBadHeaderValue, maxSecondsBehind, v))
+			return
+		}
+		maxSecondsLate = seconds
+	}
+
+	netAPI, ethAPI, erigonAPI, txPoolAPI := parseDetailedAPI(rpcAPI)
+	report := buildDetailedReport(r, netAPI, ethAPI, erigonAPI, txPoolAPI, maxStageLag, maxSecondsLate)
+
+	statusCode := http.StatusOK
+	switch {
+	case report.Status == statusBroken:
+		statusCode = http.StatusInternalServerError
+	case report.Status == statusStarting && probe == probeReadiness:
+		statusCode = http.StatusServiceUnavailable
+	}
+	if err := writeJSON(w, report, statusCode); err != nil {
+		log.Root().Warn("unable to process healthcheck request", "err", err)
+	}
+}
+
+func buildDetailedReport(r *http.Request, netAPI NetAPI, ethAPI EthAPI, erigonAPI ErigonAPI, txPoolAPI TxPoolAPI, maxStageLag uint64, maxSecondsLate int) *detailedReport {
+	report := &detailedReport{Status: statusReady}
+	broken := func(err error) {
+		report.Status = statusBroken
+		report.Errors = append(report.Errors, err.Error())
+	}
+	starting := func(err error) {
+		if report.Status == statusReady {
+			report.Status = statusStarting
+		}
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	// Stages progress, the last stage must follow the headers
+	if erigonAPI == nil {
+		broken(fmt.Errorf("no connection to the Erigon server or `erigon` namespace isn't enabled"))
+	} else if progress, err := erigonAPI.StagesProgress(r.Context()); err != nil {
+		broken(fmt.Errorf("stages progress: %w", err))
+	} else {
+		report.HighestBlock = uint64(progress[string(stages.Headers)])
+		for _, stage := range stages.AllStages {
+			block := uint64(progress[string(stage)])
+			var lag uint64
+			if report.HighestBlock > block {
+				lag = report.HighestBlock - block
+			}
+			report.Stages = append(report.Stages, stageReport{Name: string(stage), Block: block, Lag: lag})
+		}
+		// Headers are only downloaded once the snapshots are
+		report.Snapshots.Blocks = uint64(progress[string(stages.Snapshots)])
+		report.Snapshots.Downloaded = report.HighestBlock > 0
+		finish := uint64(progress[string(stages.Finish)])
+		switch {
+		case !report.Snapshots.Downloaded:
+			starting(fmt.Errorf("snapshots are being downloaded"))
+		case finish == 0:
+			starting(fmt.Errorf("no block has been synced yet"))
+		case report.HighestBlock > finish && report.HighestBlock-finish > maxStageLag:
+			starting(fmt.Errorf("%w: stage %s is %d blocks behind the headers (maximum %d)", errNotSynced, stages.Finish, report.HighestBlock-finish, maxStageLag))
+		}
+	}
+
+	// Freshness of the data served
+	if ethAPI == nil {
+		broken(fmt.Errorf("no connection to the Erigon server or `eth` namespace isn't enabled"))
+	} else if head, err := ethAPI.GetBlockByNumber(r.Context(), rpc.LatestBlockNumber, false); err != nil {
+		broken(fmt.Errorf("latest block: %w", err))
+	} else {
+		report.Head.Number = uint64Field(head, "number")
+		report.Head.Timestamp = uint64Field(head, "timestamp")
+		if report.Head.Timestamp > 0 {
+			report.Head.SecondsBehind = time.Now().Unix() - int64(report.Head.Timestamp)
+		}
+		if maxSecondsLate >= 0 && report.Head.SecondsBehind > int64(maxSecondsLate) {
+			starting(fmt.Errorf("%w: latest block is %d seconds old (maximum %d)", errTimestampTooOld, report.Head.SecondsBehind, maxSecondsLate))
+		}
+	}
+
+	// Components the node relies on, they are only reported when their namespace is enabled
+	report.Peers = errorStringOrOK(errCheckDisabled)
+	if netAPI != nil {
+		peerCount, err := netAPI.PeerCount(r.Context())
+		report.Peers = errorStringOrOK(err)
+		report.PeerCount = uint64(peerCount)
+		if err != nil {
+			broken(fmt.Errorf("peers: %w", err))
+		}
+	}
+	report.TxPool = errorStringOrOK(errCheckDisabled)
+	if txPoolAPI != nil {
+		_, err := txPoolAPI.Status(r.Context())
+		report.TxPool = errorStringOrOK(err)
+		if err != nil {
+			broken(fmt.Errorf("txpool: %w", err))
+		}
+	}
+
+	report.StateCache = stateCacheReport(stateCacheLabel)
+	return report
+}
+
+// stateCacheReport reads the hit and miss counters the state cache exposes as metrics.
+func stateCacheReport(label string) cacheReport {
+	counter := func(name, result string) uint64 {
+		return metrics.GetOrCreateCounter(fmt.Sprintf(`%s{result="%s",name="%s"}`, name, result, label)).Get()
+	}
+	hitRate := func(hits, misses uint64) float64 {
+		if hits+misses == 0 {
+			return 0
+		}
+		return float64(hits) / float64(hits+misses)
+	}
+	report := cacheReport{
+		Hits:       counter("cache_total", "hit"),
+		Misses:     counter("cache_total", "miss"),
+		CodeHits:   counter("cache_code_total", "hit"),
+		CodeMisses: counter("cache_code_total", "miss"),
+	}
+	report.HitRate = hitRate(report.Hits, report.Misses)
+	report.CodeHitRate = hitRate(report.CodeHits, report.CodeMisses)
+	return report
+}
+
+func uint64Field(fields map[string]interface{}, name string) uint64 {
+	switch v := fields[name].(type) {
+	case uint64:
+		return v
+	case hexutil.Uint64:
+		return uint64(v)
+	case *hexutil.Big:
+		if v != nil {
+			return v.ToInt().Uint64()
+		}
+	}
+	return 0
+}
+
+func writeDetailedError(w http.ResponseWriter, err error) {
+	report := &detailedReport{Status: statusBroken, Errors: []string{err.Error()}}
+	if err := writeJSON(w, report, http.StatusBadRequest); err != nil {
+		log.Root().Warn("unable to process healthcheck request", "err", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}, statusCode int) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	return json.NewEncoder(w).Encode(body)
+}
@@ -39,6 +39,10 @@ func ProcessHealthcheckIfNeeded(
 	r *http.Request,
 	rpcAPI []rpc.API,
 ) bool {
+	if strings.EqualFold(r.URL.Path, detailedURLPath) {
+		processDetailed(w, r, rpcAPI)
+		return true
+	}
 	if !strings.EqualFold(r.URL.Path, urlPath) {
 		return false
 	}
@@ -39,6 +39,23 @@ func (e *ethApiStub) Syncing(_ context.Context) (interface{}, error) {
 	return e.syncingResult, e.syncingError
 }
 
+type erigonApiStub struct {
+	progress map[string]hexutil.Uint64
+	error    error
+}
+
+func (e *erigonApiStub) StagesProgress(_ context.Context) (map[string]hexutil.Uint64, error) {
+	return e.progress, e.error
+}
+
+type txPoolApiStub struct {
+	error error
+}
+
+func (tp *txPoolApiStub) Status(_ context.Context) (map[string]hexutil.Uint, error) {
+	return map[string]hexutil.Uint{}, tp.error
+}
+
 func TestProcessHealthcheckIfNeeded_HeadersTests(t *testing.T) {
 	cases := []struct {
 		headers             []string
@@ -560,3 +577,113 @@ func TestProcessHealthcheckIfNeeded_RequestBody(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessHealthcheckIfNeeded_Detailed(t *testing.T) {
+	synced := map[string]hexutil.Uint64{"Snapshots": 100, "Headers": 200, "Execution": 195, "Finish": 195}
+	cases := []struct {
+		query              string
+		stagesProgress     map[string]hexutil.Uint64
+		stagesError        error
+		txPoolError        error
+		blockResult        map[string]interface{}
+		expectedStatusCode int
+		expectedStatus     string
+	}{
+		// 0 - synced
+		{
+			stagesProgress:     synced,
+			blockResult:        map[string]interface{}{"number": hexutil.Uint64(195), "timestamp": hexutil.Uint64(time.Now().Unix())},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     statusReady,
+		},
+		// 1 - downloading snapshots
+		{
+			stagesProgress:     map[string]hexutil.Uint64{},
+			blockResult:        map[string]interface{}{},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     statusStarting,
+		},
+		// 2 - downloading snapshots - liveness
+		{
+			query:              "?probe=liveness",
+			stagesProgress:     map[string]hexutil.Uint64{},
+			blockResult:        map[string]interface{}{},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     statusStarting,
+		},
+		// 3 - stages lagging
+		{
+			query:              "?max_stage_lag=2",
+			stagesProgress:     synced,
+			blockResult:        map[string]interface{}{"number": hexutil.Uint64(195), "timestamp": hexutil.Uint64(time.Now().Unix())},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     statusStarting,
+		},
+		// 4 - latest block too old
+		{
+			query:              "?max_seconds_behind=60",
+			stagesProgress:     synced,
+			blockResult:        map[string]interface{}{"number": hexutil.Uint64(195), "timestamp": hexutil.Uint64(time.Now().Add(-time.Hour).Unix())},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     statusStarting,
+		},
+		// 5 - db error
+		{
+			query:              "?probe=liveness",
+			stagesError:        errors.New("db closed"),
+			blockResult:        map[string]interface{}{},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedStatus:     statusBroken,
+		},
+		// 6 - txpool disconnected
+		{
+			stagesProgress:     synced,
+			txPoolError:        errors.New("connection refused"),
+			blockResult:        map[string]interface{}{"number": hexutil.Uint64(195), "timestamp": hexutil.Uint64(time.Now().Unix())},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedStatus:     statusBroken,
+		},
+		// 7 - bad probe
+		{
+			query:              "?probe=startup",
+			stagesProgress:     synced,
+			blockResult:        map[string]interface{}{},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedStatus:     statusBroken,
+		},
+	}
+
+	for idx, c := range cases {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest(http.MethodGet, "http://localhost:9090/health/detailed"+c.query, nil)
+		if err != nil {
+			t.Errorf("%v: creating request: %v", idx, err)
+		}
+
+		apis := []rpc.API{
+			{Service: &netApiStub{response: hexutil.Uint(3)}},
+			{Service: &ethApiStub{blockResult: c.blockResult}},
+			{Service: &erigonApiStub{progress: c.stagesProgress, error: c.stagesError}},
+			{Service: &txPoolApiStub{error: c.txPoolError}},
+		}
+
+		if !ProcessHealthcheckIfNeeded(w, r, apis) {
+			t.Errorf("%v: detailed healthcheck not processed", idx)
+		}
+
+		result := w.Result()
+		if result.StatusCode != c.expectedStatusCode {
+			t.Errorf("%v: expected status code: %v, but got: %v", idx, c.expectedStatusCode, result.StatusCode)
+		}
+
+		var report detailedReport
+		if err := json.NewDecoder(result.Body).Decode(&report); err != nil {
+			t.Errorf("%v: unmarshalling the response body: %s", idx, err)
+		}
+		result.Body.Close()
+
+		if report.Status != c.expectedStatus {
+			t.Errorf("%v: expected status: %s, but got: %s (errors: %v)", idx, c.expectedStatus, report.Status, report.Errors)
+		}
+	}
+}
@@ -15,3 +15,11 @@ type EthAPI interface {
 	GetBlockByNumber(_ context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error)
 	Syncing(ctx context.Context) (interface{}, error)
 }
+
+type ErigonAPI interface {
+	StagesProgress(ctx context.Context) (map[string]hexutil.Uint64, error)
+}
+
+type TxPoolAPI interface {
+	Status(ctx context.Context) (map[string]hexutil.Uint, error)
+}
@@ -20,3 +20,21 @@ func parseAPI(api []rpc.API) (netAPI NetAPI, ethAPI EthAPI) {
 	}
 	return netAPI, ethAPI
 }
+
+func parseDetailedAPI(api []rpc.API) (netAPI NetAPI, ethAPI EthAPI, erigonAPI ErigonAPI, txPoolAPI TxPoolAPI) {
+	netAPI, ethAPI = parseAPI(api)
+	for _, rpc := range api {
+		if rpc.Service == nil {
+			continue
+		}
+
+		if erigonCandidate, ok := rpc.Service.(ErigonAPI); ok {
+			erigonAPI = erigonCandidate
+		}
+
+		if txPoolCandidate, ok := rpc.Service.(TxPoolAPI); ok {
+			txPoolAPI = txPoolCandidate
+		}
+	}
+	return netAPI, ethAPI, erigonAPI, txPoolAPI
+}
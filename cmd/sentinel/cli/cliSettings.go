@@ -3,6 +3,11 @@ package cli
 import (
 	"fmt"
 
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cl/freezer"
+	"github.com/ledgerwatch/erigon/cl/phase1/core"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/rawdb"
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state"
 
@@ -11,33 +16,61 @@ import (
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/cli/flags"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/handlers"
 	"github.com/ledgerwatch/erigon/turbo/logging"
 
 	"github.com/ledgerwatch/log/v3"
 )
 
 type ConsensusClientCliCfg struct {
-	GenesisCfg       *clparams.GenesisConfig     `json:"genesisCfg"`
-	BeaconCfg        *clparams.BeaconChainConfig `json:"beaconCfg"`
-	NetworkCfg       *clparams.NetworkConfig     `json:"networkCfg"`
-	BeaconDataCfg    *rawdb.BeaconDataConfig     `json:"beaconDataConfig"`
-	Port             uint                        `json:"port"`
-	Addr             string                      `json:"address"`
-	ServerAddr       string                      `json:"serverAddr"`
-	ServerProtocol   string                      `json:"serverProtocol"`
-	ServerTcpPort    uint                        `json:"serverTcpPort"`
-	LogLvl           uint                        `json:"logLevel"`
-	NoDiscovery      bool                        `json:"noDiscovery"`
-	CheckpointUri    string                      `json:"checkpointUri"`
-	Chaindata        string                      `json:"chaindata"`
-	ErigonPrivateApi string                      `json:"erigonPrivateApi"`
-	TransitionChain  bool                        `json:"transitionChain"`
-	NetworkType      clparams.NetworkType        `json:"networkType"`
-	InitialSync      bool                        `json:"initialSync"`
-	BeaconAddr       string                      `json:"beaconAddr"`
-	BeaconProtocol   string                      `json:"beaconProtocol"`
-	RecordMode       bool                        `json:"recordMode"`
-	RecordDir        string                      `json:"recordDir"`
+	GenesisCfg        *clparams.GenesisConfig     `json:"genesisCfg"`
+	BeaconCfg         *clparams.BeaconChainConfig `json:"beaconCfg"`
+	NetworkCfg        *clparams.NetworkConfig     `json:"networkCfg"`
+	BeaconDataCfg     *rawdb.BeaconDataConfig     `json:"beaconDataConfig"`
+	Port              uint                        `json:"port"`
+	Addr              string                      `json:"address"`
+	ServerAddr        string                      `json:"serverAddr"`
+	ServerProtocol    string                      `json:"serverProtocol"`
+	ServerTcpPort     uint                        `json:"serverTcpPort"`
+	LogLvl            uint                        `json:"logLevel"`
+	NoDiscovery       bool                        `json:"noDiscovery"`
+	AllSubnets        bool                        `json:"subscribeAllSubnets"`
+	CheckpointUri     string                      `json:"checkpointUri"`
+	CheckpointState   string                      `json:"checkpointState"`
+	CheckpointBlock   string                      `json:"checkpointBlock"`
+	CheckpointRecords bool                        `json:"checkpointRecords"`
+	WeakSubjectivity  solid.Checkpoint            `json:"-"`
+	Chaindata         string                      `json:"chaindata"`
+	ErigonPrivateApi  string                      `json:"erigonPrivateApi"`
+	TransitionChain   bool                        `json:"transitionChain"`
+	NetworkType       clparams.NetworkType        `json:"networkType"`
+	InitialSync       bool                        `json:"initialSync"`
+	BeaconAddr        string                      `json:"beaconAddr"`
+	BeaconProtocol    string                      `json:"beaconProtocol"`
+	RecordMode        bool                        `json:"recordMode"`
+	RecordDir         string                      `json:"recordDir"`
+	RecordS3          *freezer.S3                 `json:"-"`
+	RecordDedup       bool                        `json:"recordContentAddressed"`
+	RecordRetention   uint64                      `json:"recordRetention"`
+
+	StateRegenDir              string `json:"stateRegenDir"`
+	StateRegenSnapshotInterval uint64 `json:"stateRegenSnapshotInterval"`
+
+	LightClientDir string                    `json:"lightClientDir"`
+	ReputationFile string                    `json:"reputationFile"`
+	RateLimits     map[string]handlers.Quota `json:"rateLimits"`
+	BlobsDir       string                    `json:"blobsDir"`
+	IndicesDir     string                    `json:"indicesDir"`
+
+	ValidatorKeystores                string            `json:"validatorKeystores"`
+	ValidatorPasswordFile             string            `json:"validatorPasswordFile"`
+	ValidatorSlashingProtection       string            `json:"validatorSlashingProtection"`
+	ValidatorSlashingProtectionImport string            `json:"validatorSlashingProtectionImport"`
+	ValidatorSlashingProtectionExport string            `json:"validatorSlashingProtectionExport"`
+	ValidatorFeeRecipient             libcommon.Address `json:"validatorFeeRecipient"`
+	ValidatorGraffiti                 [32]byte          `json:"-"`
+	ValidatorEth1Endpoint             string            `json:"validatorEth1Endpoint"`
+	ValidatorDepositContractBlock     uint64            `json:"validatorDepositContractBlock"`
 
 	InitalState *state.BeaconState
 }
@@ -79,6 +112,46 @@ func SetupConsensusClientCfg(ctx *cli.Context) (*ConsensusClientCliCfg, error) {
 	cfg.BeaconProtocol = "tcp"
 	cfg.RecordMode = ctx.Bool(flags.RecordModeFlag.Name)
 	cfg.RecordDir = ctx.String(flags.RecordModeDir.Name)
+	if endpoint := ctx.String(flags.RecordS3EndpointFlag.Name); endpoint != "" {
+		cfg.RecordS3 = &freezer.S3{
+			Endpoint:  endpoint,
+			Region:    ctx.String(flags.RecordS3RegionFlag.Name),
+			Bucket:    ctx.String(flags.RecordS3BucketFlag.Name),
+			Prefix:    ctx.String(flags.RecordS3PrefixFlag.Name),
+			AccessKey: ctx.String(flags.RecordS3AccessKeyFlag.Name),
+			SecretKey: ctx.String(flags.RecordS3SecretKeyFlag.Name),
+		}
+	}
+	cfg.RecordDedup = ctx.Bool(flags.RecordContentAddressedFlag.Name)
+	cfg.RecordRetention = ctx.Uint64(flags.RecordRetentionFlag.Name)
+	cfg.StateRegenDir = ctx.String(flags.StateRegenDirFlag.Name)
+	cfg.StateRegenSnapshotInterval = ctx.Uint64(flags.StateRegenSnapshotIntervalFlag.Name)
+	cfg.LightClientDir = ctx.String(flags.LightClientDirFlag.Name)
+	cfg.ReputationFile = ctx.String(flags.ReputationFileFlag.Name)
+	if cfg.RateLimits, err = handlers.ParseRateLimits(ctx.String(flags.RateLimitsFlag.Name)); err != nil {
+		return nil, err
+	}
+	cfg.BlobsDir = ctx.String(flags.BlobsDirFlag.Name)
+	cfg.IndicesDir = ctx.String(flags.IndicesDirFlag.Name)
+
+	cfg.ValidatorKeystores = ctx.String(flags.ValidatorKeystoresFlag.Name)
+	cfg.ValidatorPasswordFile = ctx.String(flags.ValidatorPasswordFileFlag.Name)
+	cfg.ValidatorSlashingProtection = ctx.String(flags.ValidatorSlashingProtectionFlag.Name)
+	cfg.ValidatorSlashingProtectionImport = ctx.String(flags.ValidatorSlashingProtectionImportFlag.Name)
+	cfg.ValidatorSlashingProtectionExport = ctx.String(flags.ValidatorSlashingProtectionExportFlag.Name)
+	if feeRecipient := ctx.String(flags.ValidatorFeeRecipientFlag.Name); feeRecipient != "" {
+		if !libcommon.IsHexAddress(feeRecipient) {
+			return nil, fmt.Errorf("invalid fee recipient %s", feeRecipient)
+		}
+		cfg.ValidatorFeeRecipient = libcommon.HexToAddress(feeRecipient)
+	}
+	graffiti := ctx.String(flags.ValidatorGraffitiFlag.Name)
+	if len(graffiti) > len(cfg.ValidatorGraffiti) {
+		return nil, fmt.Errorf("graffiti is longer than %d bytes", len(cfg.ValidatorGraffiti))
+	}
+	copy(cfg.ValidatorGraffiti[:], graffiti)
+	cfg.ValidatorEth1Endpoint = ctx.String(flags.ValidatorEth1EndpointFlag.Name)
+	cfg.ValidatorDepositContractBlock = ctx.Uint64(flags.ValidatorDepositContractBlockFlag.Name)
 
 	cfg.Port = uint(ctx.Int(flags.SentinelDiscoveryPort.Name))
 	cfg.Addr = ctx.String(flags.SentinelDiscoveryAddr.Name)
@@ -89,12 +162,21 @@ func SetupConsensusClientCfg(ctx *cli.Context) (*ConsensusClientCliCfg, error) {
 		cfg.LogLvl = uint(log.LvlDebug)
 	}
 	cfg.NoDiscovery = ctx.Bool(flags.NoDiscovery.Name)
+	cfg.AllSubnets = ctx.Bool(flags.SubscribeAllSubnetsFlag.Name)
 	if ctx.String(flags.CheckpointSyncUrlFlag.Name) != "" {
 		cfg.CheckpointUri = ctx.String(flags.CheckpointSyncUrlFlag.Name)
 	} else {
 		cfg.CheckpointUri = clparams.GetCheckpointSyncEndpoint(cfg.NetworkType)
 		fmt.Println(cfg.CheckpointUri)
 	}
+	cfg.CheckpointState = ctx.String(flags.CheckpointSyncStateFlag.Name)
+	cfg.CheckpointBlock = ctx.String(flags.CheckpointSyncBlockFlag.Name)
+	cfg.CheckpointRecords = ctx.Bool(flags.CheckpointSyncRecordsFlag.Name)
+	if checkpoint := ctx.String(flags.WeakSubjectivityCheckpointFlag.Name); checkpoint != "" {
+		if cfg.WeakSubjectivity, err = core.ParseWeakSubjectivityCheckpoint(checkpoint); err != nil {
+			return nil, err
+		}
+	}
 	cfg.Chaindata = ctx.String(flags.ChaindataFlag.Name)
 	cfg.BeaconDataCfg = rawdb.BeaconDataConfigurations[ctx.String(flags.BeaconDBModeFlag.Name)]
 	// Process bootnodes
@@ -12,15 +12,44 @@ var CLDefaultFlags = []cli.Flag{
 	&Chain,
 	&SentinelTcpPort,
 	&NoDiscovery,
+	&SubscribeAllSubnetsFlag,
 	&ChaindataFlag,
 	&BeaconDBModeFlag,
 	&BootnodesFlag,
 	&BeaconConfigFlag,
 	&GenesisSSZFlag,
 	&CheckpointSyncUrlFlag,
+	&CheckpointSyncStateFlag,
+	&CheckpointSyncBlockFlag,
+	&CheckpointSyncRecordsFlag,
+	&WeakSubjectivityCheckpointFlag,
 	&SentinelStaticPeersFlag,
 	&TransitionChainFlag,
 	&InitSyncFlag,
 	&RecordModeDir,
 	&RecordModeFlag,
+	&RecordS3EndpointFlag,
+	&RecordS3RegionFlag,
+	&RecordS3BucketFlag,
+	&RecordS3PrefixFlag,
+	&RecordS3AccessKeyFlag,
+	&RecordS3SecretKeyFlag,
+	&RecordContentAddressedFlag,
+	&RecordRetentionFlag,
+	&StateRegenDirFlag,
+	&StateRegenSnapshotIntervalFlag,
+	&LightClientDirFlag,
+	&ReputationFileFlag,
+	&RateLimitsFlag,
+	&BlobsDirFlag,
+	&IndicesDirFlag,
+	&ValidatorKeystoresFlag,
+	&ValidatorPasswordFileFlag,
+	&ValidatorSlashingProtectionFlag,
+	&ValidatorSlashingProtectionImportFlag,
+	&ValidatorSlashingProtectionExportFlag,
+	&ValidatorFeeRecipientFlag,
+	&ValidatorGraffitiFlag,
+	&ValidatorEth1EndpointFlag,
+	&ValidatorDepositContractBlockFlag,
 }
@@ -63,6 +63,11 @@ var (
 		Usage: "turn off or on the lightclient finding peers",
 		Value: false,
 	}
+	SubscribeAllSubnetsFlag = cli.BoolFlag{
+		Name:  "subscribe-all-subnets",
+		Usage: "subscribe to every attestation and sync committee subnet instead of the long lived ones",
+		Value: false,
+	}
 	ChaindataFlag = cli.StringFlag{
 		Name:  "chaindata",
 		Usage: "chaindata of database",
@@ -78,6 +83,26 @@ var (
 		Usage: "checkpoint sync endpoint",
 		Value: "",
 	}
+	CheckpointSyncStateFlag = cli.StringFlag{
+		Name:  "checkpoint-sync-state",
+		Usage: "checkpoint sync from a local .ssz or .ssz_snappy beacon state instead of checkpoint-sync-url",
+		Value: "",
+	}
+	CheckpointSyncBlockFlag = cli.StringFlag{
+		Name:  "checkpoint-sync-block",
+		Usage: "local .ssz or .ssz_snappy file of the block of the checkpoint sync state, fetched from peers if not set",
+		Value: "",
+	}
+	CheckpointSyncRecordsFlag = cli.BoolFlag{
+		Name:  "checkpoint-sync-records",
+		Usage: "checkpoint sync from the latest state recorded by record-dir or record-s3, such as the one of the last imported era file",
+		Value: false,
+	}
+	WeakSubjectivityCheckpointFlag = cli.StringFlag{
+		Name:  "weak-subjectivity-checkpoint",
+		Usage: "refuse to start from a checkpoint sync state which is not at this checkpoint, given as block_root:epoch",
+		Value: "",
+	}
 	ErigonPrivateApiFlag = cli.StringFlag{
 		Name:  "private.api.addr",
 		Usage: "connect to existing erigon instance",
@@ -107,4 +132,124 @@ var (
 		Name:  "record-dir",
 		Usage: "directory for states and block recordings",
 	}
+	RecordS3EndpointFlag = cli.StringFlag{
+		Name:  "record-s3.endpoint",
+		Usage: "record to an S3 compatible object storage at this endpoint instead of record-dir",
+		Value: "",
+	}
+	RecordS3RegionFlag = cli.StringFlag{
+		Name:  "record-s3.region",
+		Usage: "region of the S3 bucket",
+		Value: "us-east-1",
+	}
+	RecordS3BucketFlag = cli.StringFlag{
+		Name:  "record-s3.bucket",
+		Usage: "S3 bucket for states and block recordings",
+		Value: "caplin-recordings",
+	}
+	RecordS3PrefixFlag = cli.StringFlag{
+		Name:  "record-s3.prefix",
+		Usage: "prefix of the recordings keys within the S3 bucket",
+		Value: "",
+	}
+	RecordS3AccessKeyFlag = cli.StringFlag{
+		Name:    "record-s3.access-key",
+		Usage:   "S3 access key",
+		EnvVars: []string{"CAPLIN_RECORD_S3_ACCESS_KEY"},
+	}
+	RecordS3SecretKeyFlag = cli.StringFlag{
+		Name:    "record-s3.secret-key",
+		Usage:   "S3 secret key",
+		EnvVars: []string{"CAPLIN_RECORD_S3_SECRET_KEY"},
+	}
+	RecordContentAddressedFlag = cli.BoolFlag{
+		Value: false,
+		Name:  "record-content-addressed",
+		Usage: "store every distinct recording once, snappy compressed, under its hash",
+	}
+	RecordRetentionFlag = cli.Uint64Flag{
+		Value: 0,
+		Name:  "record-retention",
+		Usage: "keep the recordings of the last N slots only, 0 keeps them all",
+	}
+	StateRegenDirFlag = cli.StringFlag{
+		Name:  "state-regen.dir",
+		Usage: "archive the finalized states into this directory to serve the states of any past slot through the beacon API, disabled if empty",
+		Value: "",
+	}
+	StateRegenSnapshotIntervalFlag = cli.Uint64Flag{
+		Name:  "state-regen.snapshot-interval",
+		Usage: "slots between the full states of the archive, the states of the epochs in between are stored as diffs",
+		Value: 8192,
+	}
+	BlobsDirFlag = cli.StringFlag{
+		Name:  "blobs.dir",
+		Usage: "store the verified blob sidecars in this directory and serve them over the beacon API and the p2p network, disabled if empty",
+		Value: "",
+	}
+	IndicesDirFlag = cli.StringFlag{
+		Name:  "indices.dir",
+		Usage: "store the finalized blocks in a database in this directory to serve them through the beacon API once forkchoice pruned them, disabled if empty",
+		Value: "",
+	}
+	ReputationFileFlag = cli.StringFlag{
+		Name:  "sentinel.reputation-file",
+		Usage: "file persisting the penalties and bans of peers across restarts, kept in memory if empty",
+		Value: "",
+	}
+	RateLimitsFlag = cli.StringFlag{
+		Name:  "sentinel.rate-limits",
+		Usage: "comma separated <protocol>:<amount>/<period> quotas of the req/resp protocols per peer overriding the default ones, e.g. beacon_blocks_by_range:1024/10s. The protocols serving lists count the items requested",
+		Value: "",
+	}
+	LightClientDirFlag = cli.StringFlag{
+		Name:  "light-client.dir",
+		Usage: "serve light clients over the beacon API and the p2p network, keeping the best updates in this directory, disabled if empty",
+		Value: "",
+	}
+	ValidatorKeystoresFlag = cli.StringFlag{
+		Name:  "validator.keystores",
+		Usage: "directory of the EIP-2335 keystores of the validators to run",
+		Value: "",
+	}
+	ValidatorPasswordFileFlag = cli.StringFlag{
+		Name:  "validator.password-file",
+		Usage: "file holding the password of the validator keystores",
+		Value: "",
+	}
+	ValidatorSlashingProtectionFlag = cli.StringFlag{
+		Name:  "validator.slashing-protection",
+		Usage: "slashing protection database of the validators, an EIP-3076 interchange file",
+		Value: "slashing-protection.json",
+	}
+	ValidatorSlashingProtectionImportFlag = cli.StringFlag{
+		Name:  "validator.slashing-protection.import",
+		Usage: "import an EIP-3076 interchange file into the slashing protection database before running the validators",
+		Value: "",
+	}
+	ValidatorSlashingProtectionExportFlag = cli.StringFlag{
+		Name:  "validator.slashing-protection.export",
+		Usage: "export the slashing protection database as an EIP-3076 interchange file and exit",
+		Value: "",
+	}
+	ValidatorFeeRecipientFlag = cli.StringFlag{
+		Name:  "validator.fee-recipient",
+		Usage: "address receiving the fees of the blocks proposed by the validators",
+		Value: "",
+	}
+	ValidatorGraffitiFlag = cli.StringFlag{
+		Name:  "validator.graffiti",
+		Usage: "graffiti of the blocks proposed by the validators, at most 32 bytes",
+		Value: "",
+	}
+	ValidatorEth1EndpointFlag = cli.StringFlag{
+		Name:  "validator.eth1-endpoint",
+		Usage: "JSON-RPC endpoint of the execution layer the deposits included in the blocks proposed by the validators are read from",
+		Value: "",
+	}
+	ValidatorDepositContractBlockFlag = cli.Uint64Flag{
+		Name:  "validator.deposit-contract-block",
+		Usage: "execution block the deposit contract was deployed at, where reading deposits starts",
+		Value: 0,
+	}
 )
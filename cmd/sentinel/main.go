@@ -52,6 +52,10 @@ func runSentinelNode(cliCtx *cli.Context) error {
 		NetworkConfig: cfg.NetworkCfg,
 		BeaconConfig:  cfg.BeaconCfg,
 		NoDiscovery:   cfg.NoDiscovery,
+
+		SubscribeAllSubnets: cfg.AllSubnets,
+		ReputationFile:      cfg.ReputationFile,
+		RateLimits:          cfg.RateLimits,
 	}, nil, &service.ServerConfig{Network: cfg.ServerProtocol, Addr: cfg.ServerAddr}, nil, nil, log.Root())
 	if err != nil {
 		log.Error("[Sentinel] Could not start sentinel", "err", err)
@@ -19,6 +19,7 @@ import (
 	"net"
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/handlers"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/crypto"
@@ -43,6 +44,16 @@ type SentinelConfig struct {
 	HostDNS       string
 	NoDiscovery   bool
 	TmpDir        string
+	// SubscribeAllSubnets subscribes to every attestation and sync committee subnet instead of the long lived ones.
+	SubscribeAllSubnets bool
+	// LightClient serves the light client protocols and topics, nil disables them.
+	LightClient handlers.LightClientServer
+	// BlobSidecars serves the blob sidecars protocols and subscribes to their topics, nil disables them.
+	BlobSidecars handlers.BlobSidecarServer
+	// ReputationFile persists the penalties and bans of peers across restarts, empty keeps them in memory only.
+	ReputationFile string
+	// RateLimits are the quotas of the req/resp protocols per peer, nil applies the default ones.
+	RateLimits map[string]handlers.Quota
 }
 
 func convertToCryptoPrivkey(privkey *ecdsa.PrivateKey) (crypto.PrivKey, error) {
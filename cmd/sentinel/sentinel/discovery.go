@@ -161,6 +161,12 @@ func (s *Sentinel) setupENR(
 func (s *Sentinel) onConnection(net network.Network, conn network.Conn) {
 	go func() {
 		peerId := conn.RemotePeer()
+		if s.peers.IsBanned(peerId) {
+			s.peers.WithPeer(peerId, func(peer *peers.Peer) {
+				peer.Disconnect("banned peer")
+			})
+			return
+		}
 		invalid := !s.handshaker.ValidatePeer(peerId)
 		if invalid {
 			log.Trace("Handshake was unsuccessful")
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication/ssz_snappy"
+)
+
+// BlobSidecarServer provides the blob sidecars served to peers, ordered by slot and index.
+type BlobSidecarServer interface {
+	BlobSidecarsByRoot(blockRoot libcommon.Hash) ([]*cltypes.BlobSidecar, error)
+	BlobSidecarsByRange(startSlot, count uint64) ([]*cltypes.BlobSidecar, error)
+}
+
+// writeBlobSidecars writes a response chunk per sidecar, whose context is the fork digest of its slot, stopping after
+// limit sidecars.
+func (c *ConsensusHandlers) writeBlobSidecars(stream network.Stream, sidecars []*cltypes.BlobSidecar, limit uint64) error {
+	for i, sidecar := range sidecars {
+		if uint64(i) >= limit {
+			break
+		}
+		if err := c.writeChunkAtSlot(stream, sidecar.Slot, sidecar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ConsensusHandlers) blobSidecarsByRangeHandler(stream network.Stream) error {
+	req := &cltypes.BlobSidecarsByRangeRequest{}
+	if err := ssz_snappy.DecodeAndReadNoForkDigest(stream, req, clparams.DenebVersion); err != nil {
+		return err
+	}
+	count := req.Count
+	if maxSlots := c.netConfig.MaxRequestBlobSidecars / c.beaconConfig.MaxBlobsPerBlock; count > maxSlots {
+		count = maxSlots
+	}
+	if ok, err := c.chargeItems(stream, communication.BlobSidecarByRangeProtocolV1, count*c.beaconConfig.MaxBlobsPerBlock); !ok {
+		return err
+	}
+	sidecars, err := c.blobSidecars.BlobSidecarsByRange(req.StartSlot, count)
+	if err != nil {
+		return err
+	}
+	return c.writeBlobSidecars(stream, sidecars, c.netConfig.MaxRequestBlobSidecars)
+}
+
+func (c *ConsensusHandlers) blobSidecarsByRootHandler(stream network.Stream) error {
+	req := solid.NewStaticListSSZ[*cltypes.BlobIdentifier](int(c.netConfig.MaxRequestBlobSidecars), 40)
+	if err := ssz_snappy.DecodeAndReadNoForkDigest(stream, req, clparams.DenebVersion); err != nil {
+		return err
+	}
+	if ok, err := c.chargeItems(stream, communication.BlobSidecarByRootProtocolV1, uint64(req.Len())); !ok {
+		return err
+	}
+	// the sidecars of a block are read once, whatever the number of its indices requested.
+	byRoot := map[libcommon.Hash][]*cltypes.BlobSidecar{}
+	var sidecars []*cltypes.BlobSidecar
+	var err error
+	req.Range(func(_ int, identifier *cltypes.BlobIdentifier, _ int) bool {
+		blockSidecars, ok := byRoot[identifier.BlockRoot]
+		if !ok {
+			if blockSidecars, err = c.blobSidecars.BlobSidecarsByRoot(identifier.BlockRoot); err != nil {
+				return false
+			}
+			byRoot[identifier.BlockRoot] = blockSidecars
+		}
+		for _, sidecar := range blockSidecars {
+			if sidecar.Index == identifier.Index {
+				sidecars = append(sidecars, sidecar)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return c.writeBlobSidecars(stream, sidecars, c.netConfig.MaxRequestBlobSidecars)
+}
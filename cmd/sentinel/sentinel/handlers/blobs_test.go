@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/types/ssz"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication/ssz_snappy"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/peers"
+)
+
+// testBlobSidecars serves the sidecars of a slice, ordered by slot and index.
+type testBlobSidecars []*cltypes.BlobSidecar
+
+func (s testBlobSidecars) BlobSidecarsByRoot(blockRoot libcommon.Hash) ([]*cltypes.BlobSidecar, error) {
+	var sidecars []*cltypes.BlobSidecar
+	for _, sidecar := range s {
+		if sidecar.BlockRoot == blockRoot {
+			sidecars = append(sidecars, sidecar)
+		}
+	}
+	return sidecars, nil
+}
+
+func (s testBlobSidecars) BlobSidecarsByRange(startSlot, count uint64) ([]*cltypes.BlobSidecar, error) {
+	var sidecars []*cltypes.BlobSidecar
+	for _, sidecar := range s {
+		if sidecar.Slot >= startSlot && sidecar.Slot < startSlot+count {
+			sidecars = append(sidecars, sidecar)
+		}
+	}
+	return sidecars, nil
+}
+
+// requestBlobSidecars sends a request and decodes the sidecars of the response chunks.
+func requestBlobSidecars(t *testing.T, send func([]byte) ([]byte, byte, error), req ssz.Marshaler) []*cltypes.BlobSidecar {
+	var buf bytes.Buffer
+	require.NoError(t, ssz_snappy.EncodeAndWrite(&buf, req))
+	data, code, err := send(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, byte(SuccessfulResponsePrefix), code)
+
+	var sidecars []*cltypes.BlobSidecar
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		var digest [4]byte
+		_, err := io.ReadFull(r, digest[:])
+		require.NoError(t, err)
+		sidecar := &cltypes.BlobSidecar{}
+		require.NoError(t, ssz_snappy.DecodeAndReadNoForkDigest(r, sidecar, clparams.DenebVersion))
+		sidecars = append(sidecars, sidecar)
+		// the code of the next chunk
+		if code, err := r.ReadByte(); err == nil {
+			require.Equal(t, byte(SuccessfulResponsePrefix), code)
+		}
+	}
+	return sidecars
+}
+
+func TestBlobSidecarsHandlers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sidecars := testBlobSidecars{
+		{BlockRoot: libcommon.Hash{1}, Slot: 10, Index: 0},
+		{BlockRoot: libcommon.Hash{1}, Slot: 10, Index: 1},
+		{BlockRoot: libcommon.Hash{2}, Slot: 11, Index: 0},
+		{BlockRoot: libcommon.Hash{3}, Slot: 12, Index: 0},
+	}
+	server, client := newTestHost(t), newTestHost(t)
+	manager := peers.NewManager(ctx, server, nil)
+	genesisCfg, netCfg, beaconCfg := clparams.GetConfigsByNetwork(clparams.MainnetNetwork)
+	NewConsensusHandlers(ctx, nil, server, manager, beaconCfg, netCfg, genesisCfg, newMetadata(), nil, sidecars, nil).Start()
+	require.NoError(t, client.Connect(ctx, peer.AddrInfo{ID: server.ID(), Addrs: server.Addrs()}))
+	sendTo := func(protocol string) func([]byte) ([]byte, byte, error) {
+		return func(data []byte) ([]byte, byte, error) {
+			return communication.SendRequestRawToPeer(ctx, client, data, protocol, server.ID())
+		}
+	}
+
+	got := requestBlobSidecars(t, sendTo(communication.BlobSidecarByRangeProtocolV1),
+		&cltypes.BlobSidecarsByRangeRequest{StartSlot: 10, Count: 2})
+	require.Equal(t, []*cltypes.BlobSidecar(sidecars[:3]), got)
+
+	identifiers := solid.NewStaticListSSZ[*cltypes.BlobIdentifier](int(netCfg.MaxRequestBlobSidecars), 40)
+	identifiers.Append(&cltypes.BlobIdentifier{BlockRoot: libcommon.Hash{1}, Index: 1})
+	identifiers.Append(&cltypes.BlobIdentifier{BlockRoot: libcommon.Hash{3}, Index: 0})
+	identifiers.Append(&cltypes.BlobIdentifier{BlockRoot: libcommon.Hash{3}, Index: 1})
+	identifiers.Append(&cltypes.BlobIdentifier{BlockRoot: libcommon.Hash{4}, Index: 0})
+	got = requestBlobSidecars(t, sendTo(communication.BlobSidecarByRootProtocolV1), identifiers)
+	require.Equal(t, []*cltypes.BlobSidecar{sidecars[1], sidecars[3]}, got)
+}
@@ -14,6 +14,10 @@
 package handlers
 
 import (
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/solid"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication/ssz_snappy"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/libp2p/go-libp2p/core/network"
@@ -21,11 +25,29 @@ import (
 
 func (c *ConsensusHandlers) blocksByRangeHandler(stream network.Stream) error {
 	log.Trace("Got block by range handler call")
+	req := &cltypes.BeaconBlocksByRangeRequest{}
+	if err := ssz_snappy.DecodeAndReadNoForkDigest(stream, req, clparams.Phase0Version); err != nil {
+		return err
+	}
+	count := req.Count
+	if count > c.netConfig.MaxRequestBlocks {
+		count = c.netConfig.MaxRequestBlocks
+	}
+	if ok, err := c.chargeItems(stream, communication.BeaconBlocksByRangeProtocolV1, count); !ok {
+		return err
+	}
 	return ssz_snappy.EncodeAndWrite(stream, &emptyString{}, ResourceUnavaiablePrefix)
 }
 
 func (c *ConsensusHandlers) beaconBlocksByRootHandler(stream network.Stream) error {
 	log.Trace("Got beacon block by root handler call")
+	req := solid.NewHashList(int(c.netConfig.MaxRequestBlocks))
+	if err := ssz_snappy.DecodeAndReadNoForkDigest(stream, req, clparams.Phase0Version); err != nil {
+		return err
+	}
+	if ok, err := c.chargeItems(stream, communication.BeaconBlocksByRootProtocolV1, uint64(req.Length())); !ok {
+		return err
+	}
 	return ssz_snappy.EncodeAndWrite(stream, &emptyString{}, ResourceUnavaiablePrefix)
 }
 
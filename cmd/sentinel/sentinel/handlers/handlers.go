@@ -15,12 +15,15 @@ package handlers
 
 import (
 	"context"
+	"math"
 	"strings"
+	"sync/atomic"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication/ssz_snappy"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/peers"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/libp2p/go-libp2p/core/host"
@@ -32,21 +35,32 @@ type ConsensusHandlers struct {
 	handlers      map[protocol.ID]network.StreamHandler
 	host          host.Host
 	peers         *peers.Manager
-	metadata      *cltypes.Metadata
+	metadata      *atomic.Pointer[cltypes.Metadata]
 	beaconConfig  *clparams.BeaconChainConfig
+	netConfig     *clparams.NetworkConfig
 	genesisConfig *clparams.GenesisConfig
 	ctx           context.Context
+	limiter       *rateLimiter
+	served        *peerServedBytes
 
-	db kv.RoDB // Read stuff from database to answer
+	db           kv.RoDB           // Read stuff from database to answer
+	lightClient  LightClientServer // nil when light clients are not served
+	blobSidecars BlobSidecarServer // nil when blob sidecars are not served
 }
 
 const (
 	SuccessfulResponsePrefix = 0x00
 	ResourceUnavaiablePrefix = 0x03
+	// RateLimitedPrefix answers the requests exceeding the quota of the peer for a protocol.
+	RateLimitedPrefix = 139
 )
 
 func NewConsensusHandlers(ctx context.Context, db kv.RoDB, host host.Host,
-	peers *peers.Manager, beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig, metadata *cltypes.Metadata) *ConsensusHandlers {
+	peers *peers.Manager, beaconConfig *clparams.BeaconChainConfig, netConfig *clparams.NetworkConfig, genesisConfig *clparams.GenesisConfig, metadata *atomic.Pointer[cltypes.Metadata],
+	lightClient LightClientServer, blobSidecars BlobSidecarServer, quotas map[string]Quota) *ConsensusHandlers {
+	if quotas == nil {
+		quotas = protocolQuotas
+	}
 	c := &ConsensusHandlers{
 		peers:         peers,
 		host:          host,
@@ -54,7 +68,12 @@ func NewConsensusHandlers(ctx context.Context, db kv.RoDB, host host.Host,
 		db:            db,
 		genesisConfig: genesisConfig,
 		beaconConfig:  beaconConfig,
+		netConfig:     netConfig,
 		ctx:           ctx,
+		lightClient:   lightClient,
+		blobSidecars:  blobSidecars,
+		limiter:       newRateLimiter(quotas),
+		served:        newPeerServedBytes(),
 	}
 
 	hm := map[string]func(s network.Stream) error{
@@ -66,6 +85,16 @@ func NewConsensusHandlers(ctx context.Context, db kv.RoDB, host host.Host,
 		communication.BeaconBlocksByRangeProtocolV1: c.blocksByRangeHandler,
 		communication.BeaconBlocksByRootProtocolV1:  c.beaconBlocksByRootHandler,
 	}
+	if lightClient != nil {
+		hm[communication.LightClientBootstrapProtocolV1] = c.lightClientBootstrapHandler
+		hm[communication.LightClientUpdatesByRangeProtocolV1] = c.lightClientUpdatesByRangeHandler
+		hm[communication.LightClientFinalityUpdateProtocolV1] = c.lightClientFinalityUpdateHandler
+		hm[communication.LightClientOptimisticUpdateProtocolV1] = c.lightClientOptimisticUpdateHandler
+	}
+	if blobSidecars != nil {
+		hm[communication.BlobSidecarByRangeProtocolV1] = c.blobSidecarsByRangeHandler
+		hm[communication.BlobSidecarByRootProtocolV1] = c.blobSidecarsByRootHandler
+	}
 
 	c.handlers = map[protocol.ID]network.StreamHandler{}
 	for k, v := range hm {
@@ -78,20 +107,50 @@ func (c *ConsensusHandlers) Start() {
 	for id, handler := range c.handlers {
 		c.host.SetStreamHandler(id, handler)
 	}
+	c.host.Network().Notify(&network.NotifyBundle{DisconnectedF: c.served.disconnected})
+}
+
+// chargeItems consumes count items of the quota of the peer for the protocol, answering the request as rate limited
+// when it is exhausted.
+func (c *ConsensusHandlers) chargeItems(stream network.Stream, protocol string, count uint64) (bool, error) {
+	n := int(count)
+	if count > math.MaxInt32 {
+		n = math.MaxInt32
+	}
+	if c.limiter.allowN(stream.Conn().RemotePeer(), protocol, n) {
+		return true, nil
+	}
+	rateLimitedRequest(protocol)
+	return false, ssz_snappy.EncodeAndWrite(stream, &emptyString{}, RateLimitedPrefix)
 }
 
 func (c *ConsensusHandlers) wrapStreamHandler(name string, fn func(s network.Stream) error) func(s network.Stream) {
 	return func(s network.Stream) {
+		pid := s.Conn().RemotePeer()
+		if c.peers.IsBanned(pid) {
+			_ = s.Reset()
+			return
+		}
 		l := log.Ctx{
 			"name": name,
 		}
-		rawVer, err := c.host.Peerstore().Get(s.Conn().RemotePeer(), "AgentVersion")
+		rawVer, err := c.host.Peerstore().Get(pid, "AgentVersion")
 		if err == nil {
 			if str, ok := rawVer.(string); ok {
 				l["agent"] = str
 			}
 		}
-		err = fn(s)
+		stream := &meteredStream{Stream: s}
+		// the protocols serving lists are charged per item by their handlers. Throttled peers are not penalized,
+		// honest peers syncing from us exceed their quota too.
+		if _, ok := itemProtocols[name]; ok || c.limiter.allow(pid, name) {
+			err = fn(stream)
+		} else {
+			rateLimitedRequest(name)
+			err = ssz_snappy.EncodeAndWrite(stream, &emptyString{}, RateLimitedPrefix)
+		}
+		servedBytes(name, stream.written)
+		c.served.add(c.host.Network(), pid, stream.written)
 		if err != nil {
 			l["err"] = err
 			log.Error("[pubsubhandler] stream handler", l)
@@ -25,7 +25,7 @@ import (
 
 func (c *ConsensusHandlers) pingHandler(s network.Stream) error {
 	return ssz_snappy.EncodeAndWrite(s, &cltypes.Ping{
-		Id: c.metadata.SeqNumber,
+		Id: c.metadata.Load().SeqNumber,
 	}, SuccessfulResponsePrefix)
 }
 
@@ -36,14 +36,15 @@ func (c *ConsensusHandlers) goodbyeHandler(s network.Stream) error {
 }
 
 func (c *ConsensusHandlers) metadataV1Handler(s network.Stream) error {
+	metadata := c.metadata.Load()
 	return ssz_snappy.EncodeAndWrite(s, &cltypes.Metadata{
-		SeqNumber: c.metadata.SeqNumber,
-		Attnets:   c.metadata.Attnets,
+		SeqNumber: metadata.SeqNumber,
+		Attnets:   metadata.Attnets,
 	}, SuccessfulResponsePrefix)
 }
 
 func (c *ConsensusHandlers) metadataV2Handler(s network.Stream) error {
-	return ssz_snappy.EncodeAndWrite(s, c.metadata, SuccessfulResponsePrefix)
+	return ssz_snappy.EncodeAndWrite(s, c.metadata.Load(), SuccessfulResponsePrefix)
 }
 
 // TODO: Actually respond with proper status
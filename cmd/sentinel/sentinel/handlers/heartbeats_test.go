@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication/ssz_snappy"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/peers"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetadata() *atomic.Pointer[cltypes.Metadata] {
+	metadata := new(atomic.Pointer[cltypes.Metadata])
+	metadata.Store(&cltypes.Metadata{Syncnets: new(uint64)})
+	return metadata
+}
+
+func TestPingSwappedMetadata(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, client := newTestHost(t), newTestHost(t)
+	manager := peers.NewManager(ctx, server, nil)
+	genesisCfg, netCfg, beaconCfg := clparams.GetConfigsByNetwork(clparams.MainnetNetwork)
+	metadata := newMetadata()
+	NewConsensusHandlers(ctx, nil, server, manager, beaconCfg, netCfg, genesisCfg, metadata, nil, nil, nil).Start()
+	require.NoError(t, client.Connect(ctx, peer.AddrInfo{ID: server.ID(), Addrs: server.Addrs()}))
+
+	// the sequence number of the metadata swapped in is answered
+	metadata.Store(&cltypes.Metadata{SeqNumber: 5, Syncnets: new(uint64)})
+	data, code, err := communication.SendRequestRawToPeer(ctx, client, nil, communication.PingProtocolV1, server.ID())
+	require.NoError(t, err)
+	require.Equal(t, byte(SuccessfulResponsePrefix), code)
+	ping := &cltypes.Ping{}
+	require.NoError(t, ssz_snappy.DecodeAndReadNoForkDigest(bytes.NewReader(data), ping, clparams.Phase0Version))
+	require.Equal(t, uint64(5), ping.Id)
+}
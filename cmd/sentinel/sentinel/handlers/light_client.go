@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/types/ssz"
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/fork"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication/ssz_snappy"
+)
+
+// LightClientServer provides the light client data served to peers.
+type LightClientServer interface {
+	Bootstrap(blockRoot libcommon.Hash) (*cltypes.LightClientBootstrap, error)
+	Updates(startPeriod, count uint64) ([]*cltypes.LightClientUpdate, error)
+	FinalityUpdate() *cltypes.LightClientFinalityUpdate
+	OptimisticUpdate() *cltypes.LightClientOptimisticUpdate
+}
+
+// writeChunkAtSlot writes a successful response chunk, whose context is the fork digest of the slot the served
+// data belongs to.
+func (c *ConsensusHandlers) writeChunkAtSlot(stream network.Stream, slot uint64, val ssz.Marshaler) error {
+	digest, err := fork.ComputeForkDigestAtEpoch(c.beaconConfig, c.genesisConfig.GenesisValidatorRoot, slot/c.beaconConfig.SlotsPerEpoch)
+	if err != nil {
+		return err
+	}
+	return ssz_snappy.EncodeAndWrite(stream, val, SuccessfulResponsePrefix, digest[0], digest[1], digest[2], digest[3])
+}
+
+func (c *ConsensusHandlers) lightClientBootstrapHandler(stream network.Stream) error {
+	var root blockRootRequest
+	if err := ssz_snappy.DecodeAndReadNoForkDigest(stream, &root, clparams.Phase0Version); err != nil {
+		return err
+	}
+	bootstrap, err := c.lightClient.Bootstrap(libcommon.Hash(root))
+	if err != nil {
+		return err
+	}
+	if bootstrap == nil {
+		return ssz_snappy.EncodeAndWrite(stream, &emptyString{}, ResourceUnavaiablePrefix)
+	}
+	return c.writeChunkAtSlot(stream, bootstrap.Header.Beacon.Slot, bootstrap)
+}
+
+func (c *ConsensusHandlers) lightClientUpdatesByRangeHandler(stream network.Stream) error {
+	req := &cltypes.LightClientUpdatesByRangeRequest{}
+	if err := ssz_snappy.DecodeAndReadNoForkDigest(stream, req, clparams.Phase0Version); err != nil {
+		return err
+	}
+	count := req.Count
+	if count > communication.MaximumRequestClientUpdates {
+		count = communication.MaximumRequestClientUpdates
+	}
+	if ok, err := c.chargeItems(stream, communication.LightClientUpdatesByRangeProtocolV1, count); !ok {
+		return err
+	}
+	updates, err := c.lightClient.Updates(req.StartPeriod, count)
+	if err != nil {
+		return err
+	}
+	for _, update := range updates {
+		if err := c.writeChunkAtSlot(stream, update.AttestedHeader.Beacon.Slot, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ConsensusHandlers) lightClientFinalityUpdateHandler(stream network.Stream) error {
+	update := c.lightClient.FinalityUpdate()
+	if update == nil {
+		return ssz_snappy.EncodeAndWrite(stream, &emptyString{}, ResourceUnavaiablePrefix)
+	}
+	return c.writeChunkAtSlot(stream, update.AttestedHeader.Beacon.Slot, update)
+}
+
+func (c *ConsensusHandlers) lightClientOptimisticUpdateHandler(stream network.Stream) error {
+	update := c.lightClient.OptimisticUpdate()
+	if update == nil {
+		return ssz_snappy.EncodeAndWrite(stream, &emptyString{}, ResourceUnavaiablePrefix)
+	}
+	return c.writeChunkAtSlot(stream, update.AttestedHeader.Beacon.Slot, update)
+}
+
+// blockRootRequest is the block root a light client bootstraps from.
+type blockRootRequest libcommon.Hash
+
+func (r *blockRootRequest) EncodeSSZ(buf []byte) ([]byte, error) {
+	return append(buf, r[:]...), nil
+}
+
+func (r *blockRootRequest) EncodingSizeSSZ() int {
+	return len(r)
+}
+
+func (r *blockRootRequest) DecodeSSZ(buf []byte, _ int) error {
+	if len(buf) < len(r) {
+		return ssz.ErrLowBufferSize
+	}
+	copy(r[:], buf)
+	return nil
+}
@@ -0,0 +1,232 @@
+/*
+   Copyright 2022 Erigon-Lightclient contributors
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+       http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/erigon/cl/phase1/core/state/lru"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"golang.org/x/time/rate"
+)
+
+// Quota is a token bucket of Amount requests refilled over Period. The quotas of the protocols serving lists count
+// the items served instead, so that a request for a single block does not cost as much as one for a thousand.
+type Quota struct {
+	Amount int
+	Period time.Duration
+}
+
+func (q Quota) limiter() *rate.Limiter {
+	return rate.NewLimiter(rate.Every(q.Period/time.Duration(q.Amount)), q.Amount)
+}
+
+// defaultQuota applies to the protocols without a quota of their own.
+var defaultQuota = Quota{Amount: 16, Period: 10 * time.Second}
+
+// itemProtocols are the protocols whose quota counts the items requested, charged by their handlers.
+var itemProtocols = map[string]struct{}{
+	communication.BeaconBlocksByRangeProtocolV1:       {},
+	communication.BeaconBlocksByRootProtocolV1:        {},
+	communication.BlobSidecarByRangeProtocolV1:        {},
+	communication.BlobSidecarByRootProtocolV1:         {},
+	communication.LightClientUpdatesByRangeProtocolV1: {},
+}
+
+// protocolQuotas are the requests, or items, a peer may ask per protocol.
+var protocolQuotas = map[string]Quota{
+	communication.PingProtocolV1:                        {Amount: 2, Period: 10 * time.Second},
+	communication.GoodbyeProtocolV1:                     {Amount: 1, Period: 10 * time.Second},
+	communication.StatusProtocolV1:                      {Amount: 5, Period: 15 * time.Second},
+	communication.MetadataProtocolV1:                    {Amount: 2, Period: 5 * time.Second},
+	communication.MetadataProtocolV2:                    {Amount: 2, Period: 5 * time.Second},
+	communication.BeaconBlocksByRangeProtocolV1:         {Amount: 1024, Period: 10 * time.Second},
+	communication.BeaconBlocksByRootProtocolV1:          {Amount: 128, Period: 10 * time.Second},
+	communication.BlobSidecarByRangeProtocolV1:          {Amount: 768, Period: 10 * time.Second},
+	communication.BlobSidecarByRootProtocolV1:           {Amount: 128, Period: 10 * time.Second},
+	communication.LightClientBootstrapProtocolV1:        {Amount: 1, Period: 10 * time.Second},
+	communication.LightClientUpdatesByRangeProtocolV1:   {Amount: 128, Period: 10 * time.Second},
+	communication.LightClientFinalityUpdateProtocolV1:   {Amount: 2, Period: 10 * time.Second},
+	communication.LightClientOptimisticUpdateProtocolV1: {Amount: 2, Period: 10 * time.Second},
+}
+
+// ParseRateLimits parses comma separated <protocol>:<amount>/<period> quotas, such as
+// beacon_blocks_by_range:1024/10s, and returns the default quotas overridden by them. A protocol is named after its
+// topic and the quota applies to all of its versions.
+func ParseRateLimits(limits string) (map[string]Quota, error) {
+	quotas := make(map[string]Quota, len(protocolQuotas))
+	for protocol, q := range protocolQuotas {
+		quotas[protocol] = q
+	}
+	for _, limit := range strings.Split(limits, ",") {
+		if limit = strings.TrimSpace(limit); limit == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(limit, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q, expected <protocol>:<amount>/<period>", limit)
+		}
+		amount, period, ok := strings.Cut(value, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q, expected <protocol>:<amount>/<period>", limit)
+		}
+		var (
+			q   Quota
+			err error
+		)
+		if q.Amount, err = strconv.Atoi(amount); err != nil || q.Amount <= 0 {
+			return nil, fmt.Errorf("invalid amount in rate limit %q", limit)
+		}
+		if q.Period, err = time.ParseDuration(period); err != nil || q.Period <= 0 {
+			return nil, fmt.Errorf("invalid period in rate limit %q", limit)
+		}
+		var found bool
+		for protocol := range protocolQuotas {
+			if strings.HasPrefix(protocol, communication.ProtocolPrefix+"/"+name+"/") {
+				quotas[protocol], found = q, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown protocol %q in rate limit %q", name, limit)
+		}
+	}
+	return quotas, nil
+}
+
+type rateLimiterKey struct {
+	pid      peer.ID
+	protocol string
+}
+
+// rateLimiter keeps a token bucket per peer and protocol, the least recently used ones are dropped.
+type rateLimiter struct {
+	quotas map[string]Quota
+
+	mu      sync.Mutex
+	buckets *lru.Cache[rateLimiterKey, *rate.Limiter]
+}
+
+func newRateLimiter(quotas map[string]Quota) *rateLimiter {
+	buckets, err := lru.New[rateLimiterKey, *rate.Limiter]("sentinel_rate_limiter", 4096)
+	if err != nil {
+		panic(err)
+	}
+	return &rateLimiter{quotas: quotas, buckets: buckets}
+}
+
+// allow consumes a request of the peer over the protocol, returning false if its quota is exhausted.
+func (r *rateLimiter) allow(pid peer.ID, protocol string) bool {
+	return r.allowN(pid, protocol, 1)
+}
+
+// allowN consumes n requests or items of the peer over the protocol, returning false if its quota is exhausted. A
+// request larger than the whole quota consumes all of it.
+func (r *rateLimiter) allowN(pid peer.ID, protocol string, n int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := rateLimiterKey{pid: pid, protocol: protocol}
+	bucket, ok := r.buckets.Get(key)
+	if !ok {
+		q, ok := r.quotas[protocol]
+		if !ok {
+			q = defaultQuota
+		}
+		bucket = q.limiter()
+		r.buckets.Add(key, bucket)
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > bucket.Burst() {
+		n = bucket.Burst()
+	}
+	return bucket.AllowN(time.Now(), n)
+}
+
+// meteredStream counts the bytes served to the remote peer.
+type meteredStream struct {
+	network.Stream
+	written int
+}
+
+func (s *meteredStream) Write(p []byte) (int, error) {
+	n, err := s.Stream.Write(p)
+	s.written += n
+	return n, err
+}
+
+// servedBytes counts the bytes served per protocol.
+func servedBytes(protocol string, n int) {
+	if n == 0 {
+		return
+	}
+	metrics.GetOrCreateCounter(fmt.Sprintf(`sentinel_served_bytes{protocol="%s"}`, protocol)).Add(n)
+}
+
+// peerServedBytes counts the bytes served to each connected peer. The counter of a peer is dropped once it
+// disconnects, so that the series do not grow with every peer ever connected.
+type peerServedBytes struct {
+	mu       sync.Mutex
+	counters map[peer.ID]string
+}
+
+func newPeerServedBytes() *peerServedBytes {
+	return &peerServedBytes{counters: make(map[peer.ID]string)}
+}
+
+// add counts the bytes served to the peer, nothing is counted for the peers which are not connected anymore.
+func (p *peerServedBytes) add(net network.Network, pid peer.ID, n int) {
+	if n == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	name, ok := p.counters[pid]
+	if !ok {
+		if net.Connectedness(pid) != network.Connected {
+			return
+		}
+		name = fmt.Sprintf(`sentinel_served_peer_bytes{peer="%s"}`, pid)
+		p.counters[pid] = name
+	}
+	metrics.GetOrCreateCounter(name).Add(n)
+}
+
+// drop unregisters the counter of the peer.
+func (p *peerServedBytes) drop(pid peer.ID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if name, ok := p.counters[pid]; ok {
+		metrics.UnregisterMetric(name)
+		delete(p.counters, pid)
+	}
+}
+
+// disconnected drops the counter of the peer once its last connection is closed.
+func (p *peerServedBytes) disconnected(net network.Network, conn network.Conn) {
+	if net.Connectedness(conn.RemotePeer()) != network.Connected {
+		p.drop(conn.RemotePeer())
+	}
+}
+
+func rateLimitedRequest(protocol string) {
+	metrics.GetOrCreateCounter(fmt.Sprintf(`sentinel_rate_limited_requests{protocol="%s"}`, protocol)).Inc()
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication/ssz_snappy"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/peers"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHost(t *testing.T) host.Host {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func TestRateLimitedPing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, client := newTestHost(t), newTestHost(t)
+	manager := peers.NewManager(ctx, server, nil)
+	genesisCfg, netCfg, beaconCfg := clparams.GetConfigsByNetwork(clparams.MainnetNetwork)
+	NewConsensusHandlers(ctx, nil, server, manager, beaconCfg, netCfg, genesisCfg, newMetadata(), nil, nil, nil).Start()
+	require.NoError(t, client.Connect(ctx, peer.AddrInfo{ID: server.ID(), Addrs: server.Addrs()}))
+
+	ping := func() byte {
+		_, code, err := communication.SendRequestRawToPeer(ctx, client, nil, communication.PingProtocolV1, server.ID())
+		require.NoError(t, err)
+		return code
+	}
+	quota := protocolQuotas[communication.PingProtocolV1]
+	for i := 0; i < quota.Amount; i++ {
+		require.Equal(t, byte(SuccessfulResponsePrefix), ping())
+	}
+	require.Equal(t, byte(RateLimitedPrefix), ping())
+	// throttled peers are not penalized
+	require.False(t, manager.IsBanned(client.ID()))
+
+	// other protocols have their own quota.
+	_, code, err := communication.SendRequestRawToPeer(ctx, client, nil, communication.StatusProtocolV1, server.ID())
+	require.NoError(t, err)
+	require.NotEqual(t, byte(RateLimitedPrefix), code)
+
+	// banned peers are not served at all.
+	manager.WithPeer(client.ID(), func(p *peers.Peer) { p.Ban("test") })
+	require.NoError(t, client.Connect(ctx, peer.AddrInfo{ID: server.ID(), Addrs: server.Addrs()}))
+	_, _, err = communication.SendRequestRawToPeer(ctx, client, nil, communication.PingProtocolV1, server.ID())
+	require.Error(t, err)
+}
+
+func TestRateLimitedBlocksByRange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, client := newTestHost(t), newTestHost(t)
+	manager := peers.NewManager(ctx, server, nil)
+	genesisCfg, netCfg, beaconCfg := clparams.GetConfigsByNetwork(clparams.MainnetNetwork)
+	quotas, err := ParseRateLimits("beacon_blocks_by_range:100/1h")
+	require.NoError(t, err)
+	NewConsensusHandlers(ctx, nil, server, manager, beaconCfg, netCfg, genesisCfg, newMetadata(), nil, nil, quotas).Start()
+	require.NoError(t, client.Connect(ctx, peer.AddrInfo{ID: server.ID(), Addrs: server.Addrs()}))
+
+	blocksByRange := func(count uint64) byte {
+		var buf bytes.Buffer
+		require.NoError(t, ssz_snappy.EncodeAndWrite(&buf, &cltypes.BeaconBlocksByRangeRequest{StartSlot: 1, Count: count, Step: 1}))
+		_, code, err := communication.SendRequestRawToPeer(ctx, client, buf.Bytes(), communication.BeaconBlocksByRangeProtocolV1, server.ID())
+		require.NoError(t, err)
+		return code
+	}
+	// the quota is charged per block requested, not per request
+	require.Equal(t, byte(ResourceUnavaiablePrefix), blocksByRange(60))
+	require.Equal(t, byte(ResourceUnavaiablePrefix), blocksByRange(40))
+	require.Equal(t, byte(RateLimitedPrefix), blocksByRange(1))
+}
+
+func TestPeerServedBytes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, client := newTestHost(t), newTestHost(t)
+	manager := peers.NewManager(ctx, server, nil)
+	genesisCfg, netCfg, beaconCfg := clparams.GetConfigsByNetwork(clparams.MainnetNetwork)
+	c := NewConsensusHandlers(ctx, nil, server, manager, beaconCfg, netCfg, genesisCfg, newMetadata(), nil, nil, nil)
+	c.Start()
+	require.NoError(t, client.Connect(ctx, peer.AddrInfo{ID: server.ID(), Addrs: server.Addrs()}))
+
+	_, code, err := communication.SendRequestRawToPeer(ctx, client, nil, communication.PingProtocolV1, server.ID())
+	require.NoError(t, err)
+	require.Equal(t, byte(SuccessfulResponsePrefix), code)
+	c.served.mu.Lock()
+	name, ok := c.served.counters[client.ID()]
+	c.served.mu.Unlock()
+	require.True(t, ok)
+	require.NotZero(t, metrics.GetOrCreateCounter(name).Get())
+
+	// the counter is dropped with the peer
+	require.NoError(t, client.Network().ClosePeer(server.ID()))
+	require.Eventually(t, func() bool {
+		c.served.mu.Lock()
+		defer c.served.mu.Unlock()
+		return len(c.served.counters) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	// nor is anything counted for it afterwards
+	c.served.add(server.Network(), client.ID(), 1)
+	require.Empty(t, c.served.counters)
+}
+
+func TestParseRateLimits(t *testing.T) {
+	quotas, err := ParseRateLimits("")
+	require.NoError(t, err)
+	require.Equal(t, protocolQuotas, quotas)
+
+	quotas, err = ParseRateLimits("ping:5/1m, blob_sidecars_by_range:10/1s")
+	require.NoError(t, err)
+	require.Equal(t, Quota{Amount: 5, Period: time.Minute}, quotas[communication.PingProtocolV1])
+	require.Equal(t, Quota{Amount: 10, Period: time.Second}, quotas[communication.BlobSidecarByRangeProtocolV1])
+	require.Equal(t, protocolQuotas[communication.StatusProtocolV1], quotas[communication.StatusProtocolV1])
+	// the defaults are left untouched
+	require.Equal(t, 2, protocolQuotas[communication.PingProtocolV1].Amount)
+
+	// metadata has two versions
+	quotas, err = ParseRateLimits("metadata:1/1s")
+	require.NoError(t, err)
+	require.Equal(t, 1, quotas[communication.MetadataProtocolV1].Amount)
+	require.Equal(t, 1, quotas[communication.MetadataProtocolV2].Amount)
+
+	for _, invalid := range []string{"ping", "ping:5", "ping:0/1s", "ping:5/0s", "ping:5/x", "unknown:5/1s"} {
+		_, err := ParseRateLimits(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	limiter := newRateLimiter(map[string]Quota{"test": {Amount: 1, Period: time.Millisecond}})
+	require.True(t, limiter.allow("a", "test"))
+	require.False(t, limiter.allow("a", "test"))
+	require.True(t, limiter.allow("b", "test"))
+	time.Sleep(5 * time.Millisecond)
+	require.True(t, limiter.allow("a", "test"))
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ledgerwatch/erigon/cl/phase1/core/state/lru"
 	"github.com/ledgerwatch/erigon/metrics/methelp"
+	"github.com/ledgerwatch/log/v3"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
@@ -29,34 +30,57 @@ type Manager struct {
 	host        host.Host
 	peers       *lru.Cache[peer.ID, *Peer]
 	peerTimeout time.Duration
+	// penalties and bans outlive the peers records, and restarts if persisted.
+	reputation *ReputationStore
 
 	mu sync.Mutex
 }
 
-func NewManager(ctx context.Context, host host.Host) *Manager {
+// NewManager creates the peer manager, reputation may be nil to keep penalties and bans in memory only.
+func NewManager(ctx context.Context, host host.Host, reputation *ReputationStore) *Manager {
 	c, err := lru.New[peer.ID, *Peer]("beacon_peer_manager", 500)
 	if err != nil {
 		panic(err)
 	}
+	if reputation == nil {
+		if reputation, err = NewReputationStore("", DefaultBanDuration); err != nil {
+			panic(err)
+		}
+	}
 	m := &Manager{
 		peerTimeout: 8 * time.Hour,
 		peers:       c,
 		host:        host,
+		reputation:  reputation,
 	}
 	go m.run(ctx)
 	return m
 }
 
+// IsBanned reports whether the peer is banned.
+func (m *Manager) IsBanned(id peer.ID) bool {
+	return m.reputation.IsBanned(id)
+}
+
+// Penalize records a penalty for the peer without waiting for it to be available, and disconnects it once it gets
+// banned.
+func (m *Manager) Penalize(id peer.ID) {
+	log.Debug("[Sentinel Peers] peer penalized", "peer-id", id)
+	if m.reputation.Penalize(id, 1) {
+		log.Debug("[Sentinel Peers] peer banned after too many penalties", "peer-id", id)
+		m.host.Peerstore().RemovePeer(id)
+		m.host.Network().ClosePeer(id)
+	}
+}
+
 func (m *Manager) getPeer(id peer.ID) (peer *Peer) {
 	m.mu.Lock()
 	p, ok := m.peers.Get(id)
 	if !ok {
 		p = &Peer{
-			pid:       id,
-			working:   make(chan struct{}, 1),
-			m:         m,
-			Penalties: 0,
-			Banned:    false,
+			pid:     id,
+			working: make(chan struct{}, 1),
+			m:       m,
 		}
 		m.peers.Add(id, p)
 	}
@@ -104,12 +128,21 @@ func (m *Manager) WithPeer(id peer.ID, fn func(peer *Peer)) {
 
 func (m *Manager) run(ctx context.Context) {
 	m1 := time.NewTicker(1 * time.Hour)
+	flush := time.NewTicker(time.Minute)
 	for {
 		select {
 		case <-m1.C:
 			m.gc()
+		case <-flush.C:
+			if err := m.reputation.Flush(); err != nil {
+				log.Warn("[Sentinel Peers] could not persist peers reputation", "err", err)
+			}
 		case <-ctx.Done():
 			m1.Stop()
+			flush.Stop()
+			if err := m.reputation.Flush(); err != nil {
+				log.Warn("[Sentinel Peers] could not persist peers reputation", "err", err)
+			}
 			return
 		}
 	}
@@ -10,8 +10,6 @@ import (
 
 // Record Peer data.
 type Peer struct {
-	Penalties int
-	Banned    bool
 	InRequest bool
 
 	// request info
@@ -32,15 +30,12 @@ func (p *Peer) ID() peer.ID {
 	return p.pid
 }
 func (p *Peer) Penalize() {
-	log.Debug("[Sentinel Peers] peer penalized", "peer-id", p.pid)
-	p.Penalties++
+	p.m.Penalize(p.pid)
 }
 
 func (p *Peer) Forgive() {
 	log.Debug("[Sentinel Peers] peer forgiven", "peer-id", p.pid)
-	if p.Penalties > 0 {
-		p.Penalties--
-	}
+	p.m.reputation.Forgive(p.pid)
 }
 
 func (p *Peer) MarkUsed() {
@@ -55,10 +50,7 @@ func (p *Peer) MarkReplied() {
 }
 
 func (p *Peer) IsAvailable() (available bool) {
-	if p.Banned {
-		return false
-	}
-	if p.Penalties > MaxBadResponses {
+	if p.IsBad() {
 		return false
 	}
 	if time.Now().Sub(p.lastRequest) > 0*time.Second {
@@ -68,12 +60,7 @@ func (p *Peer) IsAvailable() (available bool) {
 }
 
 func (p *Peer) IsBad() (bad bool) {
-	if p.Banned {
-		bad = true
-		return
-	}
-	bad = p.Penalties > MaxBadResponses
-	return
+	return p.m.IsBanned(p.pid)
 }
 
 var skipReasons = []string{
@@ -100,11 +87,10 @@ func (p *Peer) Disconnect(reason ...string) {
 	}
 	p.m.host.Peerstore().RemovePeer(p.pid)
 	p.m.host.Network().ClosePeer(p.pid)
-	p.Penalties = 0
 }
 func (p *Peer) Ban(reason ...string) {
 	log.Debug("[Sentinel Peers] bad peers has been banned", "peer-id", p.pid, "reason", strings.Join(reason, " "))
-	p.Banned = true
+	p.m.reputation.Ban(p.pid)
 	p.Disconnect(reason...)
 	return
 }
@@ -0,0 +1,205 @@
+package peers
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	// DefaultBanDuration is how long a peer stays banned.
+	DefaultBanDuration = 24 * time.Hour
+	// penaltyDecayInterval is the time after which a penalty is forgotten.
+	penaltyDecayInterval = 5 * time.Minute
+)
+
+// reputation is what we remember of a peer.
+type reputation struct {
+	Penalties   int       `json:"penalties,omitempty"`
+	BannedUntil time.Time `json:"banned_until,omitempty"`
+	// LastPenalty is used to decay the penalties.
+	LastPenalty time.Time `json:"last_penalty,omitempty"`
+}
+
+// decay forgets the penalties older than the decay interval.
+func (r *reputation) decay(now time.Time) {
+	if r.Penalties == 0 {
+		return
+	}
+	decayed := int(now.Sub(r.LastPenalty) / penaltyDecayInterval)
+	if decayed <= 0 {
+		return
+	}
+	r.Penalties -= decayed
+	if r.Penalties < 0 {
+		r.Penalties = 0
+	}
+	r.LastPenalty = r.LastPenalty.Add(time.Duration(decayed) * penaltyDecayInterval)
+}
+
+func (r *reputation) banned(now time.Time) bool {
+	return now.Before(r.BannedUntil)
+}
+
+// ReputationStore keeps the penalties and the bans of peers. It is persisted to a file so that bans survive restarts,
+// an empty path keeps it in memory only.
+type ReputationStore struct {
+	path        string
+	banDuration time.Duration
+
+	mu          sync.Mutex
+	reputations map[peer.ID]*reputation
+	dirty       bool
+}
+
+// NewReputationStore loads the reputations persisted at the given path, if any.
+func NewReputationStore(path string, banDuration time.Duration) (*ReputationStore, error) {
+	r := &ReputationStore{
+		path:        path,
+		banDuration: banDuration,
+		reputations: make(map[peer.ID]*reputation),
+	}
+	if path == "" {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var persisted map[string]*reputation
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return nil, err
+	}
+	for id, rep := range persisted {
+		pid, err := peer.Decode(id)
+		if err != nil {
+			return nil, err
+		}
+		r.reputations[pid] = rep
+	}
+	r.prune(time.Now())
+	return r, nil
+}
+
+func (r *ReputationStore) get(pid peer.ID, now time.Time) *reputation {
+	rep, ok := r.reputations[pid]
+	if !ok {
+		rep = &reputation{}
+		r.reputations[pid] = rep
+	}
+	rep.decay(now)
+	return rep
+}
+
+// Penalize records a penalty of the given weight, banning the peer once it has more than MaxBadResponses. It returns
+// whether the peer is banned.
+func (r *ReputationStore) Penalize(pid peer.ID, weight int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	rep := r.get(pid, now)
+	rep.Penalties += weight
+	rep.LastPenalty = now
+	if rep.Penalties > MaxBadResponses && !rep.banned(now) {
+		rep.BannedUntil = now.Add(r.banDuration)
+	}
+	r.dirty = true
+	return rep.banned(now)
+}
+
+// Forgive removes a penalty.
+func (r *ReputationStore) Forgive(pid peer.ID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep := r.get(pid, time.Now())
+	if rep.Penalties > 0 {
+		rep.Penalties--
+		r.dirty = true
+	}
+}
+
+// Ban bans the peer for the ban duration.
+func (r *ReputationStore) Ban(pid peer.ID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.get(pid, time.Now()).BannedUntil = time.Now().Add(r.banDuration)
+	r.dirty = true
+}
+
+// Unban lifts the ban of the peer and forgets its penalties.
+func (r *ReputationStore) Unban(pid peer.ID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.reputations, pid)
+	r.dirty = true
+}
+
+// IsBanned reports whether the peer is currently banned.
+func (r *ReputationStore) IsBanned(pid peer.ID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep, ok := r.reputations[pid]
+	return ok && rep.banned(time.Now())
+}
+
+// Penalties returns the current penalties of the peer.
+func (r *ReputationStore) Penalties(pid peer.ID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep, ok := r.reputations[pid]
+	if !ok {
+		return 0
+	}
+	rep.decay(time.Now())
+	return rep.Penalties
+}
+
+// prune forgets the peers which are neither banned nor penalized anymore.
+func (r *ReputationStore) prune(now time.Time) {
+	for pid, rep := range r.reputations {
+		rep.decay(now)
+		if rep.Penalties == 0 && !rep.banned(now) {
+			delete(r.reputations, pid)
+			r.dirty = true
+		}
+	}
+}
+
+// Flush prunes the store and persists it if it changed.
+func (r *ReputationStore) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(time.Now())
+	if r.path == "" || !r.dirty {
+		return nil
+	}
+	persisted := make(map[string]*reputation, len(r.reputations))
+	for pid, rep := range r.reputations {
+		persisted[pid.String()] = rep
+	}
+	data, err := json.Marshal(persisted)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	// write then rename so that a crash never leaves a truncated file behind.
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
@@ -0,0 +1,54 @@
+package peers
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+const testPeer = peer.ID("test-peer")
+
+func TestReputationBanSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reputation.json")
+	store, err := NewReputationStore(path, time.Hour)
+	require.NoError(t, err)
+	for i := 0; i < MaxBadResponses; i++ {
+		require.False(t, store.Penalize(testPeer, 1))
+	}
+	require.True(t, store.Penalize(testPeer, 1))
+	require.True(t, store.IsBanned(testPeer))
+	require.NoError(t, store.Flush())
+
+	store, err = NewReputationStore(path, time.Hour)
+	require.NoError(t, err)
+	require.True(t, store.IsBanned(testPeer))
+	require.Equal(t, MaxBadResponses+1, store.Penalties(testPeer))
+
+	store.Unban(testPeer)
+	require.NoError(t, store.Flush())
+	store, err = NewReputationStore(path, time.Hour)
+	require.NoError(t, err)
+	require.False(t, store.IsBanned(testPeer))
+}
+
+func TestReputationDecay(t *testing.T) {
+	now := time.Now()
+	rep := &reputation{Penalties: 3, LastPenalty: now.Add(-2*penaltyDecayInterval - time.Second)}
+	rep.decay(now)
+	require.Equal(t, 1, rep.Penalties)
+	rep.decay(now.Add(penaltyDecayInterval))
+	require.Zero(t, rep.Penalties)
+
+	store, err := NewReputationStore("", time.Hour)
+	require.NoError(t, err)
+	store.Penalize(testPeer, 2)
+	store.Forgive(testPeer)
+	require.Equal(t, 1, store.Penalties(testPeer))
+	store.Ban(testPeer)
+	require.True(t, store.IsBanned(testPeer))
+	// an in memory store is never persisted.
+	require.NoError(t, store.Flush())
+}
@@ -44,6 +44,13 @@ const (
 	ProposerSlashingTopic        TopicName = "proposer_slashing"
 	AttesterSlashingTopic        TopicName = "attester_slashing"
 	BlobSidecarTopic             TopicName = "blob_sidecar_%d" // This topic needs an index
+
+	BeaconAttestationTopic                 TopicName = "beacon_attestation_%d" // This topic needs a subnet
+	SyncCommitteeTopic                     TopicName = "sync_committee_%d"     // This topic needs a subnet
+	SyncCommitteeContributionAndProofTopic TopicName = "sync_committee_contribution_and_proof"
+
+	LightClientFinalityUpdateTopic   TopicName = "light_client_finality_update"
+	LightClientOptimisticUpdateTopic TopicName = "light_client_optimistic_update"
 )
 
 type GossipTopic struct {
@@ -71,6 +78,34 @@ var AttesterSlashingSsz = GossipTopic{
 	Name:     AttesterSlashingTopic,
 	CodecStr: SSZSnappyCodec,
 }
+var SyncCommitteeContributionAndProofSsz = GossipTopic{
+	Name:     SyncCommitteeContributionAndProofTopic,
+	CodecStr: SSZSnappyCodec,
+}
+var LightClientFinalityUpdateSsz = GossipTopic{
+	Name:     LightClientFinalityUpdateTopic,
+	CodecStr: SSZSnappyCodec,
+}
+var LightClientOptimisticUpdateSsz = GossipTopic{
+	Name:     LightClientOptimisticUpdateTopic,
+	CodecStr: SSZSnappyCodec,
+}
+
+// AttestationSubnetTopic returns the topic of the given attestation subnet.
+func AttestationSubnetTopic(subnet uint64) GossipTopic {
+	return GossipTopic{
+		Name:     TopicName(fmt.Sprintf(string(BeaconAttestationTopic), subnet)),
+		CodecStr: SSZSnappyCodec,
+	}
+}
+
+// SyncCommitteeSubnetTopic returns the topic of the given sync committee subnet.
+func SyncCommitteeSubnetTopic(subnet uint64) GossipTopic {
+	return GossipTopic{
+		Name:     TopicName(fmt.Sprintf(string(SyncCommitteeTopic), subnet)),
+		CodecStr: SSZSnappyCodec,
+	}
+}
 
 type GossipManager struct {
 	ch            chan *pubsub.Message
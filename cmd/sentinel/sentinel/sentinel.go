@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
@@ -65,7 +67,7 @@ type Sentinel struct {
 	host       host.Host
 	cfg        *SentinelConfig
 	peers      *peers.Manager
-	metadataV2 *cltypes.Metadata
+	metadataV2 atomic.Pointer[cltypes.Metadata] // replaced as a whole, the metadata served is never modified
 	handshaker *handshake.HandShaker
 
 	db kv.RoDB
@@ -76,6 +78,10 @@ type Sentinel struct {
 	metrics              bool
 	listenForPeersDoneCh chan struct{}
 	logger               log.Logger
+
+	subnetsMu sync.Mutex
+	attnets   uint64 // attestation subnets we are subscribed to
+	syncnets  uint64 // sync committee subnets we are subscribed to
 }
 
 func (s *Sentinel) createLocalNode(
@@ -153,14 +159,14 @@ func (s *Sentinel) createListener() (*discover.UDPv5, error) {
 	}
 
 	// TODO: Set up proper attestation number
-	s.metadataV2 = &cltypes.Metadata{
+	s.metadataV2.Store(&cltypes.Metadata{
 		SeqNumber: localNode.Seq(),
 		Attnets:   0,
 		Syncnets:  new(uint64),
-	}
+	})
 
 	// Start stream handlers
-	handlers.NewConsensusHandlers(s.ctx, s.db, s.host, s.peers, s.cfg.BeaconConfig, s.cfg.GenesisConfig, s.metadataV2).Start()
+	handlers.NewConsensusHandlers(s.ctx, s.db, s.host, s.peers, s.cfg.BeaconConfig, s.cfg.NetworkConfig, s.cfg.GenesisConfig, &s.metadataV2, s.cfg.LightClient, s.cfg.BlobSidecars, s.cfg.RateLimits).Start()
 
 	net, err := discover.ListenV5(s.ctx, conn, localNode, discCfg)
 	if err != nil {
@@ -284,7 +290,11 @@ func New(
 	s.handshaker = handshake.New(ctx, cfg.GenesisConfig, cfg.BeaconConfig, host)
 
 	s.host = host
-	s.peers = peers.NewManager(ctx, s.host)
+	reputation, err := peers.NewReputationStore(cfg.ReputationFile, peers.DefaultBanDuration)
+	if err != nil {
+		return nil, fmt.Errorf("[Sentinel] failed to load peers reputation err=%w", err)
+	}
+	s.peers = peers.NewManager(ctx, s.host, reputation)
 
 	pubsub.TimeCacheDuration = 550 * gossipSubHeartbeatInterval
 	s.pubsub, err = pubsub.NewGossipSub(s.ctx, s.host, s.pubsubOptions()...)
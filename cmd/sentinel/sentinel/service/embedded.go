@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+
+	sentinelrpc "github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
+	"google.golang.org/grpc"
+
+	"github.com/ledgerwatch/erigon/cl/gossip"
+)
+
+// embeddedClient is the client of a sentinel running in process. The gossip types internal to Caplin, which the
+// gRPC protocol does not define, are published and received in process, everything else goes over gRPC.
+type embeddedClient struct {
+	sentinelrpc.SentinelClient
+	server *SentinelServer
+}
+
+func (c *embeddedClient) PublishGossip(ctx context.Context, in *sentinelrpc.GossipData, opts ...grpc.CallOption) (*sentinelrpc.EmptyMessage, error) {
+	if gossip.IsInternal(in.Type) {
+		return c.server.PublishGossip(ctx, in)
+	}
+	return c.SentinelClient.PublishGossip(ctx, in, opts...)
+}
+
+func (c *embeddedClient) SubscribeGossip(ctx context.Context, in *sentinelrpc.EmptyMessage, opts ...grpc.CallOption) (sentinelrpc.Sentinel_SubscribeGossipClient, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	remote, err := c.SentinelClient.SubscribeGossip(ctx, in, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	ch, subId, err := c.server.gossipNotifier.addSubscriber()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	stream := &embeddedGossipStream{
+		Sentinel_SubscribeGossipClient: remote,
+		ctx:                            ctx,
+		packets:                        make(chan embeddedGossipPacket),
+	}
+	go func() {
+		<-ctx.Done()
+		c.server.gossipNotifier.removeSubscriber(subId)
+	}()
+	// the notifier waits for every subscriber to take a packet, so this one is drained until it is removed
+	go func() {
+		for packet := range ch {
+			if !gossip.IsInternal(packet.t) {
+				continue
+			}
+			stream.deliver(&sentinelrpc.GossipData{
+				Data:      packet.data,
+				Type:      packet.t,
+				Peer:      &sentinelrpc.Peer{Pid: packet.pid},
+				BlobIndex: packet.blobIndex,
+			}, nil)
+		}
+	}()
+	go func() {
+		// the subscription is over once the gRPC stream fails
+		defer cancel()
+		for {
+			data, err := remote.Recv()
+			stream.deliver(data, err)
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return stream, nil
+}
+
+type embeddedGossipPacket struct {
+	data *sentinelrpc.GossipData
+	err  error
+}
+
+// embeddedGossipStream merges the gossip received over gRPC with the internal one received in process.
+type embeddedGossipStream struct {
+	sentinelrpc.Sentinel_SubscribeGossipClient
+	ctx     context.Context
+	packets chan embeddedGossipPacket
+}
+
+func (s *embeddedGossipStream) deliver(data *sentinelrpc.GossipData, err error) {
+	select {
+	case s.packets <- embeddedGossipPacket{data: data, err: err}:
+	case <-s.ctx.Done():
+	}
+}
+
+func (s *embeddedGossipStream) Recv() (*sentinelrpc.GossipData, error) {
+	select {
+	case packet := <-s.packets:
+		return packet.data, packet.err
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
@@ -12,9 +12,11 @@ import (
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	sentinelrpc "github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/gossip"
 	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication"
+	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/handlers"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/peers"
 	"github.com/ledgerwatch/log/v3"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
@@ -53,6 +55,21 @@ func extractBlobSideCarIndex(topic string) int {
 	return blobIndex
 }
 
+// extractSubnetIndex takes the topic of a subnet, e.g. beacon_attestation_{subnet}, and extracts the subnet
+func extractSubnetIndex(topic string, name sentinel.TopicName) (int, error) {
+	prefix := strings.TrimSuffix(string(name), "%d")
+	startIndex := strings.Index(topic, prefix)
+	if startIndex < 0 {
+		return 0, fmt.Errorf("topic %s is not a %s topic", topic, name)
+	}
+	startIndex += len(prefix)
+	endIndex := strings.Index(topic[startIndex:], "/")
+	if endIndex < 0 {
+		endIndex = len(topic) - startIndex
+	}
+	return strconv.Atoi(topic[startIndex : startIndex+endIndex])
+}
+
 //BanPeer(context.Context, *Peer) (*EmptyMessage, error)
 
 func (s *SentinelServer) BanPeer(_ context.Context, p *sentinelrpc.Peer) (*sentinelrpc.EmptyMessage, error) {
@@ -92,6 +109,22 @@ func (s *SentinelServer) PublishGossip(_ context.Context, msg *sentinelrpc.Gossi
 			return &sentinelrpc.EmptyMessage{}, errors.New("cannot publish sidecar blob with no index")
 		}
 		subscription = manager.GetMatchingSubscription(fmt.Sprintf(string(sentinel.BlobSidecarTopic), *msg.BlobIndex))
+	case gossip.AttestationGossipType:
+		if msg.BlobIndex == nil {
+			return &sentinelrpc.EmptyMessage{}, errors.New("cannot publish attestation with no subnet")
+		}
+		subscription = manager.GetMatchingSubscription(fmt.Sprintf("/%s/", sentinel.AttestationSubnetTopic(uint64(*msg.BlobIndex)).Name))
+	case gossip.SyncCommitteeGossipType:
+		if msg.BlobIndex == nil {
+			return &sentinelrpc.EmptyMessage{}, errors.New("cannot publish sync committee message with no subnet")
+		}
+		subscription = manager.GetMatchingSubscription(fmt.Sprintf("/%s/", sentinel.SyncCommitteeSubnetTopic(uint64(*msg.BlobIndex)).Name))
+	case gossip.SyncCommitteeContributionAndProofGossipType:
+		subscription = manager.GetMatchingSubscription(string(sentinel.SyncCommitteeContributionAndProofTopic))
+	case gossip.LightClientFinalityUpdateGossipType:
+		subscription = manager.GetMatchingSubscription(string(sentinel.LightClientFinalityUpdateTopic))
+	case gossip.LightClientOptimisticUpdateGossipType:
+		subscription = manager.GetMatchingSubscription(string(sentinel.LightClientOptimisticUpdateTopic))
 	default:
 		return &sentinelrpc.EmptyMessage{}, nil
 	}
@@ -115,6 +148,10 @@ func (s *SentinelServer) SubscribeGossip(_ *sentinelrpc.EmptyMessage, stream sen
 		case <-stream.Context().Done():
 			return nil
 		case packet := <-ch:
+			// the gossip types internal to Caplin are only relayed in process, see embeddedClient
+			if gossip.IsInternal(packet.t) {
+				continue
+			}
 			if err := stream.Send(&sentinelrpc.GossipData{
 				Data: packet.data,
 				Type: packet.t,
@@ -162,7 +199,8 @@ func (s *SentinelServer) SendRequest(pctx context.Context, req *sentinelrpc.Requ
 		if err != nil {
 			return
 		}
-		if isError > 3 {
+		// being rate limited is our fault, the peer is still honest.
+		if isError > 3 && isError != handlers.RateLimitedPrefix {
 			peer.Disconnect(fmt.Sprintf("invalid response, starting byte %d", isError))
 			peer.Penalize()
 		}
@@ -282,6 +320,35 @@ func (s *SentinelServer) startServerBackgroundLoop() {
 	}
 }
 
+// rotateSubnetsLoop keeps us subscribed to our long lived attestation subnets, which change every few hundred epochs.
+func (s *SentinelServer) rotateSubnetsLoop() {
+	s.mu.RLock()
+	cfg := s.sentinel.Config()
+	s.mu.RUnlock()
+	ticker := time.NewTicker(time.Duration(cfg.BeaconConfig.SecondsPerSlot*cfg.BeaconConfig.SlotsPerEpoch) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			epoch := utils.GetCurrentEpoch(cfg.GenesisConfig.GenesisTime, cfg.BeaconConfig.SecondsPerSlot, cfg.BeaconConfig.SlotsPerEpoch)
+			// subscribing may take a while, the lock only guards the sentinel being replaced
+			s.mu.RLock()
+			sent := s.sentinel
+			s.mu.RUnlock()
+			attnets, _, err := sent.LongLivedSubnets(epoch)
+			if err == nil {
+				_, syncnets := sent.Subnets()
+				err = sent.SetSubnets(attnets, syncnets)
+			}
+			if err != nil {
+				s.logger.Warn("[Sentinel] Could not rotate subnets", "err", err)
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
 func (s *SentinelServer) handleGossipPacket(pkt *pubsub.Message) error {
 	var err error
 	s.logger.Trace("[Sentinel Gossip] Received Packet", "topic", pkt.Topic)
@@ -309,6 +376,21 @@ func (s *SentinelServer) handleGossipPacket(pkt *pubsub.Message) error {
 		s.gossipNotifier.notify(sentinelrpc.GossipType_ProposerSlashingGossipType, data, string(textPid))
 	} else if strings.Contains(*pkt.Topic, string(sentinel.AttesterSlashingTopic)) {
 		s.gossipNotifier.notify(sentinelrpc.GossipType_AttesterSlashingGossipType, data, string(textPid))
+	} else if strings.Contains(*pkt.Topic, string(sentinel.SyncCommitteeContributionAndProofTopic)) {
+		// needs to come before the sync committee subnets, whose topics share its prefix
+		s.gossipNotifier.notify(gossip.SyncCommitteeContributionAndProofGossipType, data, string(textPid))
+	} else if strings.Contains(*pkt.Topic, strings.TrimSuffix(string(sentinel.SyncCommitteeTopic), "%d")) {
+		subnet, err := extractSubnetIndex(*pkt.Topic, sentinel.SyncCommitteeTopic)
+		if err != nil {
+			return err
+		}
+		s.gossipNotifier.notifyBlob(gossip.SyncCommitteeGossipType, data, string(textPid), subnet)
+	} else if strings.Contains(*pkt.Topic, strings.TrimSuffix(string(sentinel.BeaconAttestationTopic), "%d")) {
+		subnet, err := extractSubnetIndex(*pkt.Topic, sentinel.BeaconAttestationTopic)
+		if err != nil {
+			return err
+		}
+		s.gossipNotifier.notifyBlob(gossip.AttestationGossipType, data, string(textPid), subnet)
 	} else if strings.Contains(*pkt.Topic, string(sentinel.BlobSidecarTopic)) {
 		// extract the index
 
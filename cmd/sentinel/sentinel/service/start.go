@@ -9,6 +9,7 @@ import (
 	sentinelrpc "github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel"
 	"github.com/ledgerwatch/log/v3"
 	rcmgrObs "github.com/libp2p/go-libp2p/p2p/host/resource-manager/obs"
@@ -35,12 +36,18 @@ func createSentinel(cfg *sentinel.SentinelConfig, db kv.RoDB, logger log.Logger)
 	}
 	gossipTopics := []sentinel.GossipTopic{
 		sentinel.BeaconBlockSsz,
-		//sentinel.BeaconAggregateAndProofSsz,
+		sentinel.BeaconAggregateAndProofSsz,
+		sentinel.SyncCommitteeContributionAndProofSsz,
 		//sentinel.VoluntaryExitSsz,
 		//sentinel.ProposerSlashingSsz,
 		//sentinel.AttesterSlashingSsz,
 	}
-	// gossipTopics = append(gossipTopics, sentinel.GossipSidecarTopics(params.MaxBlobsPerBlock)...)
+	if cfg.BlobSidecars != nil {
+		gossipTopics = append(gossipTopics, sentinel.GossipSidecarTopics(cfg.BeaconConfig.MaxBlobsPerBlock)...)
+	}
+	if cfg.LightClient != nil {
+		gossipTopics = append(gossipTopics, sentinel.LightClientFinalityUpdateSsz, sentinel.LightClientOptimisticUpdateSsz)
+	}
 
 	for _, v := range gossipTopics {
 		if err := sent.Unsubscribe(v); err != nil {
@@ -58,6 +65,15 @@ func createSentinel(cfg *sentinel.SentinelConfig, db kv.RoDB, logger log.Logger)
 			logger.Error("[Sentinel] failed to start sentinel", "err", err)
 		}
 	}
+	// the attestation and sync committee subnets are subscribed to separately, as they change over time
+	epoch := utils.GetCurrentEpoch(cfg.GenesisConfig.GenesisTime, cfg.BeaconConfig.SecondsPerSlot, cfg.BeaconConfig.SlotsPerEpoch)
+	attnets, syncnets, err := sent.LongLivedSubnets(epoch)
+	if err == nil {
+		err = sent.SetSubnets(attnets, syncnets)
+	}
+	if err != nil {
+		logger.Error("[Sentinel] failed to subscribe to subnets", "err", err)
+	}
 	return sent, nil
 }
 
@@ -100,7 +116,7 @@ WaitingLoop:
 		return nil, err
 	}
 
-	return sentinelrpc.NewSentinelClient(conn), nil
+	return &embeddedClient{SentinelClient: sentinelrpc.NewSentinelClient(conn), server: server}, nil
 }
 
 func StartServe(server *SentinelServer, srvCfg *ServerConfig, creds credentials.TransportCredentials) {
@@ -112,6 +128,7 @@ func StartServe(server *SentinelServer, srvCfg *ServerConfig, creds credentials.
 	gRPCserver := grpc.NewServer(grpc.Creds(creds))
 	go server.ListenToGossip()
 	go server.startServerBackgroundLoop()
+	go server.rotateSubnetsLoop()
 	// Regiser our server as a gRPC server
 	sentinelrpc.RegisterSentinelServer(gRPCserver, server)
 	if err := gRPCserver.Serve(lis); err != nil {
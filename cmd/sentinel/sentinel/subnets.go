@@ -0,0 +1,89 @@
+package sentinel
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/gossip"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+)
+
+// LongLivedSubnets returns the attestation and sync committee subnets to subscribe to at the given epoch, as bitfields.
+// Unless every subnet is requested, these are the long lived attestation subnets derived from our node id and no sync
+// committee subnet, as those are only needed by the members of the sync committee.
+func (s *Sentinel) LongLivedSubnets(epoch uint64) (attnets uint64, syncnets uint64, err error) {
+	if s.cfg.SubscribeAllSubnets {
+		return allSubnets(s.cfg.NetworkConfig.AttestationSubnetCount), allSubnets(s.cfg.BeaconConfig.SyncCommitteeSubnetCount), nil
+	}
+	subnets, err := gossip.ComputeSubscribedSubnets(s.listener.LocalNode().ID(), epoch, s.cfg.BeaconConfig, s.cfg.NetworkConfig)
+	if err != nil {
+		return 0, 0, err
+	}
+	return gossip.SubnetsBitfield(subnets), 0, nil
+}
+
+func allSubnets(count uint64) uint64 {
+	if count >= 64 {
+		return ^uint64(0)
+	}
+	return 1<<count - 1
+}
+
+// Subnets returns the bitfields of the attestation and sync committee subnets we are subscribed to.
+func (s *Sentinel) Subnets() (attnets uint64, syncnets uint64) {
+	s.subnetsMu.Lock()
+	defer s.subnetsMu.Unlock()
+	return s.attnets, s.syncnets
+}
+
+// SetSubnets subscribes to the given attestation and sync committee subnets, leaves the others and advertises
+// them in our ENR and metadata.
+func (s *Sentinel) SetSubnets(attnets, syncnets uint64) error {
+	s.subnetsMu.Lock()
+	defer s.subnetsMu.Unlock()
+	if attnets == s.attnets && syncnets == s.syncnets {
+		return nil
+	}
+	for subnet := uint64(0); subnet < s.cfg.NetworkConfig.AttestationSubnetCount; subnet++ {
+		if err := s.updateSubnet(AttestationSubnetTopic(subnet), s.attnets&(1<<subnet) != 0, attnets&(1<<subnet) != 0); err != nil {
+			return err
+		}
+	}
+	for subnet := uint64(0); subnet < s.cfg.BeaconConfig.SyncCommitteeSubnetCount; subnet++ {
+		if err := s.updateSubnet(SyncCommitteeSubnetTopic(subnet), s.syncnets&(1<<subnet) != 0, syncnets&(1<<subnet) != 0); err != nil {
+			return err
+		}
+	}
+	s.attnets, s.syncnets = attnets, syncnets
+
+	var attnetsBits [8]byte
+	binary.LittleEndian.PutUint64(attnetsBits[:], attnets)
+	localNode := s.listener.LocalNode()
+	localNode.Set(enr.WithEntry(s.cfg.NetworkConfig.AttSubnetKey, attnetsBits[:]))
+	localNode.Set(enr.WithEntry(s.cfg.NetworkConfig.SyncCommsSubnetKey, []byte{byte(syncnets)}))
+
+	// the handlers may be serving the current metadata, a new one is swapped in
+	s.metadataV2.Store(&cltypes.Metadata{
+		SeqNumber: s.metadataV2.Load().SeqNumber + 1,
+		Attnets:   attnets,
+		Syncnets:  &syncnets,
+	})
+	return nil
+}
+
+func (s *Sentinel) updateSubnet(topic GossipTopic, subscribed, subscribe bool) error {
+	switch {
+	case subscribe && !subscribed:
+		sub, err := s.SubscribeGossip(topic)
+		if err != nil {
+			return err
+		}
+		if err := sub.Listen(); err != nil {
+			return fmt.Errorf("failed to listen to %s: %w", topic.Name, err)
+		}
+	case !subscribe && subscribed:
+		return s.Unsubscribe(topic)
+	}
+	return nil
+}
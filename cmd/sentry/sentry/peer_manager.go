@@ -0,0 +1,98 @@
+package sentry
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/event"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+// PeerManager adds and removes the peers of the sentries running in process, which the sentry gRPC
+// interface does not expose. Each call applies to every sentry.
+type PeerManager struct {
+	servers []*GrpcServer
+}
+
+func NewPeerManager(servers []*GrpcServer) *PeerManager {
+	return &PeerManager{servers: servers}
+}
+
+// p2pServer returns the p2p server of the sentry, which is started by the first status it receives.
+func (ss *GrpcServer) p2pServer() (*p2p.Server, error) {
+	ss.lock.RLock()
+	defer ss.lock.RUnlock()
+	if ss.P2pServer == nil {
+		return nil, errors.New("p2p server was not started")
+	}
+	return ss.P2pServer, nil
+}
+
+func (m *PeerManager) p2pServers() ([]*p2p.Server, error) {
+	if len(m.servers) == 0 {
+		return nil, errors.New("no sentry running in process")
+	}
+	servers := make([]*p2p.Server, len(m.servers))
+	for i, ss := range m.servers {
+		srv, err := ss.p2pServer()
+		if err != nil {
+			return nil, err
+		}
+		servers[i] = srv
+	}
+	return servers, nil
+}
+
+// apply parses the enode URL and passes it to the p2p server of every sentry.
+func (m *PeerManager) apply(url string, f func(srv *p2p.Server, node *enode.Node)) (bool, error) {
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %w", err)
+	}
+	servers, err := m.p2pServers()
+	if err != nil {
+		return false, err
+	}
+	for _, srv := range servers {
+		f(srv, node)
+	}
+	return true, nil
+}
+
+// AddPeer connects to the node and keeps reconnecting to it when the connection drops.
+func (m *PeerManager) AddPeer(url string) (bool, error) {
+	return m.apply(url, (*p2p.Server).AddPeer)
+}
+
+// RemovePeer disconnects from the node and stops reconnecting to it.
+func (m *PeerManager) RemovePeer(url string) (bool, error) {
+	return m.apply(url, (*p2p.Server).RemovePeer)
+}
+
+// AddTrustedPeer lets the node connect even when the peer slots are full.
+func (m *PeerManager) AddTrustedPeer(url string) (bool, error) {
+	return m.apply(url, (*p2p.Server).AddTrustedPeer)
+}
+
+// RemoveTrustedPeer removes the node from the trusted peers, without disconnecting from it.
+func (m *PeerManager) RemoveTrustedPeer(url string) (bool, error) {
+	return m.apply(url, (*p2p.Server).RemoveTrustedPeer)
+}
+
+// SubscribePeerEvents sends the peer events of every sentry to ch, until the subscription is unsubscribed.
+func (m *PeerManager) SubscribePeerEvents(ch chan *p2p.PeerEvent) (event.Subscription, error) {
+	servers, err := m.p2pServers()
+	if err != nil {
+		return nil, err
+	}
+	var scope event.SubscriptionScope
+	for _, srv := range servers {
+		scope.Track(srv.SubscribeEvents(ch))
+	}
+	return event.NewSubscription(func(unsub <-chan struct{}) error {
+		defer scope.Close()
+		<-unsub
+		return nil
+	}), nil
+}
@@ -0,0 +1,55 @@
+package sentry
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+func TestPeerManager(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	url := enode.NewV4(&key.PublicKey, net.IP{127, 0, 0, 1}, 30303, 30303).URLv4()
+
+	_, err = NewPeerManager(nil).AddPeer(url)
+	require.Error(t, err)
+	notStarted := NewPeerManager([]*GrpcServer{{}})
+	_, err = notStarted.AddPeer(url)
+	require.Error(t, err)
+	_, err = notStarted.SubscribePeerEvents(make(chan *p2p.PeerEvent))
+	require.Error(t, err)
+
+	serverKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	srv := &p2p.Server{Config: p2p.Config{
+		Name:            "test",
+		MaxPeers:        10,
+		MaxPendingPeers: 10,
+		NoDiscovery:     true,
+		PrivateKey:      serverKey,
+	}}
+	require.NoError(t, srv.Start(context.Background(), log.New()))
+	defer srv.Stop()
+	m := NewPeerManager([]*GrpcServer{{P2pServer: srv}})
+
+	_, err = m.AddPeer("enode://invalid")
+	require.Error(t, err)
+	for _, f := range []func(string) (bool, error){m.AddTrustedPeer, m.RemoveTrustedPeer, m.AddPeer, m.RemovePeer} {
+		ok, err := f(url)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	sub, err := m.SubscribePeerEvents(make(chan *p2p.PeerEvent))
+	require.NoError(t, err)
+	sub.Unsubscribe()
+	_, open := <-sub.Err()
+	require.False(t, open)
+}
@@ -367,6 +367,11 @@ var (
 		Usage: "Maximum number of bytes returned from eth_call or similar invocations",
 		Value: 100_000,
 	}
+	RpcMaxGetProofRewindBlockCount = cli.IntFlag{
+		Name:  "rpc.maxgetproofrewindblockcount.limit",
+		Usage: "Maximum number of blocks back from the head eth_getProof serves proofs for, the state changed since then is rebuilt in memory",
+		Value: 100_000,
+	}
 	HTTPTraceFlag = cli.BoolFlag{
 		Name:  "http.trace",
 		Usage: "Trace HTTP requests with INFO level",
@@ -447,7 +452,7 @@ var (
 	}
 	WSAllowedOriginsFlag = cli.StringFlag{
 		Name:  "ws.origins",
-		Usage: "Origins from which to accept websockets requests",
+		Usage: "Comma separated list of origins from which to accept GraphQL websocket subscriptions, localhost only if empty. Accepts '*' wildcard.",
 		Value: "",
 	}
 	WSPathPrefixFlag = cli.StringFlag{
@@ -0,0 +1,129 @@
+// Package e2store reads and writes the entries of e2store files, the container format of the era and era1 archives.
+//
+// An entry is laid out as:
+//
+//	type | length | reserved | data
+//
+// where the type takes 2 bytes, the length of the data 4 little endian bytes and the reserved bytes are zero.
+package e2store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+)
+
+// TypeVersion is the type of the entry starting every e2store file.
+var TypeVersion = [2]byte{0x65, 0x32}
+
+// HeaderSize is the size of the header of an entry.
+const HeaderSize = 8
+
+// Writer writes entries, keeping track of where they start.
+type Writer struct {
+	w      io.Writer
+	offset int64
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Offset is the offset the next entry is written at.
+func (e *Writer) Offset() int64 {
+	return e.offset
+}
+
+// Write writes an entry and returns the offset of its header.
+func (e *Writer) Write(typ [2]byte, data []byte) (int64, error) {
+	if uint64(len(data)) > 0xffffffff {
+		return 0, fmt.Errorf("entry of %d bytes is too large", len(data))
+	}
+	header := make([]byte, HeaderSize)
+	copy(header, typ[:])
+	binary.LittleEndian.PutUint32(header[2:], uint32(len(data)))
+	offset := e.offset
+	if _, err := e.w.Write(header); err != nil {
+		return 0, err
+	}
+	if _, err := e.w.Write(data); err != nil {
+		return 0, err
+	}
+	e.offset += int64(HeaderSize + len(data))
+	return offset, nil
+}
+
+// ReadEntry reads the entry whose header is at the given offset, failing if its data is larger than maxSize.
+func ReadEntry(r io.ReaderAt, offset int64, maxSize int64) (typ [2]byte, data []byte, err error) {
+	header := make([]byte, HeaderSize)
+	if _, err = r.ReadAt(header, offset); err != nil {
+		return typ, nil, fmt.Errorf("cannot read entry header at %d: %w", offset, err)
+	}
+	copy(typ[:], header)
+	if header[6] != 0 || header[7] != 0 {
+		return typ, nil, fmt.Errorf("entry at %d has non zero reserved bytes", offset)
+	}
+	length := int64(binary.LittleEndian.Uint32(header[2:]))
+	if length > maxSize {
+		return typ, nil, fmt.Errorf("entry at %d of %d bytes exceeds %d bytes", offset, length, maxSize)
+	}
+	data = make([]byte, length)
+	if _, err = r.ReadAt(data, offset+HeaderSize); err != nil {
+		return typ, nil, fmt.Errorf("cannot read entry at %d: %w", offset, err)
+	}
+	return typ, data, nil
+}
+
+// EncodeIndex encodes an index entry: the starting number, the offsets of the entries of each number relative to the
+// index entry, 0 for the numbers without one, and their count.
+func EncodeIndex(start uint64, offsets []int64) []byte {
+	data := make([]byte, 16+8*len(offsets))
+	binary.LittleEndian.PutUint64(data, start)
+	for i, offset := range offsets {
+		binary.LittleEndian.PutUint64(data[8+8*i:], uint64(offset))
+	}
+	binary.LittleEndian.PutUint64(data[len(data)-8:], uint64(len(offsets)))
+	return data
+}
+
+func DecodeIndex(data []byte) (start uint64, offsets []int64, err error) {
+	if len(data) < 16 || len(data)%8 != 0 {
+		return 0, nil, fmt.Errorf("invalid index of %d bytes", len(data))
+	}
+	count := binary.LittleEndian.Uint64(data[len(data)-8:])
+	if count != uint64(len(data)-16)/8 {
+		return 0, nil, fmt.Errorf("index of %d bytes cannot hold %d offsets", len(data), count)
+	}
+	start = binary.LittleEndian.Uint64(data)
+	offsets = make([]int64, count)
+	for i := range offsets {
+		offsets[i] = int64(binary.LittleEndian.Uint64(data[8+8*i:]))
+	}
+	return start, offsets, nil
+}
+
+// IndexSize is the size of an index entry with the given number of offsets, header included.
+func IndexSize(count uint64) int64 {
+	return int64(HeaderSize + 16 + 8*count)
+}
+
+// CompressFramed compresses the data in the snappy framing format.
+func CompressFramed(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := snappy.NewBufferedWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressFramed decompresses the data compressed in the snappy framing format.
+func DecompressFramed(data []byte) ([]byte, error) {
+	return io.ReadAll(snappy.NewReader(bytes.NewReader(data)))
+}
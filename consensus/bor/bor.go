@@ -1275,8 +1275,7 @@ func (c *Bor) CommitStates(
 }
 
 func validateEventRecord(eventRecord *clerk.EventRecordWithTime, number uint64, to time.Time, lastStateID uint64, chainID string) error {
-	// event id should be sequential and event.Time should lie in the range [from, to)
-	if lastStateID+1 != eventRecord.ID || eventRecord.ChainID != chainID || !eventRecord.Time.Before(to) {
+	if !eventRecord.Valid(lastStateID, to, chainID) {
 		return &InvalidStateReceivedError{number, lastStateID, &to, eventRecord}
 	}
 
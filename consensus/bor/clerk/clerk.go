@@ -47,3 +47,9 @@ func (e *EventRecordWithTime) BuildEventRecord() *EventRecord {
 		ChainID:  e.ChainID,
 	}
 }
+
+// Valid reports whether the event can be committed after the event lastStateID, by a block committing the events
+// recorded before to: event ids are sequential and event times lie in the range [from, to).
+func (e *EventRecordWithTime) Valid(lastStateID uint64, to time.Time, chainID string) bool {
+	return lastStateID+1 == e.ID && e.ChainID == chainID && e.Time.Before(to)
+}
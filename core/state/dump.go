@@ -119,6 +119,12 @@ func (d iterativeDump) OnAccount(addr libcommon.Address, account DumpAccount) {
 	d.Encode(dumpAccount)
 }
 
+// NewIterativeDumpCollector returns a collector writing the root and then each account as a json-object on its own
+// line of the output.
+func NewIterativeDumpCollector(output *json.Encoder) DumpCollector {
+	return iterativeDump{output}
+}
+
 // OnRoot implements DumpCollector interface
 func (d iterativeDump) OnRoot(root libcommon.Hash) {
 	//nolint:errcheck
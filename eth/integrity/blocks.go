@@ -0,0 +1,389 @@
+package integrity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcfg"
+	"github.com/ledgerwatch/log/v3"
+	"golang.org/x/exp/slices"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
+)
+
+// Checks run by VerifyBlocks on each block of the range
+const (
+	CheckHeaders   = "headers"   // header hash is the canonical one and links to the parent
+	CheckBodies    = "bodies"    // transactions, uncles and withdrawals roots match the header
+	CheckSenders   = "senders"   // stored senders match the ones recovered from the signatures
+	CheckReceipts  = "receipts"  // receipts root of the re-executed block matches the header
+	CheckSnapshots = "snapshots" // snapshot indices resolve the frozen blocks
+	CheckTxLookup  = "txlookup"  // transactions are looked up to their block
+)
+
+var AllBlockChecks = []string{CheckHeaders, CheckBodies, CheckSenders, CheckReceipts, CheckSnapshots, CheckTxLookup}
+
+type BlocksCfg struct {
+	From, To     uint64 // inclusive range of blocks to verify
+	Checks       []string
+	Workers      int
+	ChunkSize    uint64 // amount of blocks verified by a worker at once, and between checkpoints
+	ProgressFile string // if set, progress is saved there after each chunk and resumed from there
+	Restart      bool   // ignore the progress saved by an interrupted verification
+	ChainConfig  *chain.Config
+	Engine       consensus.EngineReader // needed by CheckReceipts
+}
+
+// BlockFailure is a failed check of a block.
+type BlockFailure struct {
+	Block uint64 `json:"block"`
+	Check string `json:"check"`
+	Err   string `json:"error"`
+}
+
+// BlocksReport is the outcome of VerifyBlocks, and its checkpoint when it is saved in the progress file.
+type BlocksReport struct {
+	From     uint64         `json:"from"`
+	To       uint64         `json:"to"`
+	Checks   []string       `json:"checks"`
+	Next     uint64         `json:"next"` // all blocks before it are verified
+	Failures []BlockFailure `json:"failures"`
+}
+
+func (r *BlocksReport) Done() bool { return r.Next > r.To }
+
+// VerifyBlocks runs the checks over the range of blocks in parallel chunks. Failed checks are collected in the
+// report, while errors reading the data abort the verification. With a progress file, an interrupted verification of
+// the same range and checks resumes after the last checkpoint, a completed one starts over.
+func VerifyBlocks(ctx context.Context, db kv.RoDB, blockReader services.FullBlockReader, cfg BlocksCfg, logger log.Logger) (*BlocksReport, error) {
+	if cfg.From > cfg.To {
+		return nil, fmt.Errorf("empty range of blocks: %d-%d", cfg.From, cfg.To)
+	}
+	if len(cfg.Checks) == 0 {
+		cfg.Checks = AllBlockChecks
+	}
+	for _, check := range cfg.Checks {
+		if !slices.Contains(AllBlockChecks, check) {
+			return nil, fmt.Errorf("unknown check %q, expected one of %v", check, AllBlockChecks)
+		}
+	}
+	if slices.Contains(cfg.Checks, CheckReceipts) && cfg.Engine == nil {
+		return nil, fmt.Errorf("%s check needs a consensus engine", CheckReceipts)
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = 1_000
+	}
+
+	report := &BlocksReport{From: cfg.From, To: cfg.To, Checks: cfg.Checks, Next: cfg.From}
+	if cfg.ProgressFile != "" && !cfg.Restart {
+		saved, err := loadBlocksReport(cfg.ProgressFile)
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil && !saved.Done() && saved.From == report.From && saved.To == report.To && slices.Equal(saved.Checks, report.Checks) {
+			report = saved
+			logger.Info("[verify] resuming", "from", report.Next, "to", report.To, "failures", len(report.Failures))
+		}
+	}
+
+	v := &blockVerifier{cfg: cfg, blockReader: blockReader}
+	if err := db.View(ctx, func(tx kv.Tx) (err error) {
+		if v.historyV3, err = kvcfg.HistoryV3.Enabled(tx); err != nil {
+			return err
+		}
+		pm, err := prune.Get(tx)
+		if err != nil {
+			return err
+		}
+		executed, err := stages.GetStageProgress(tx, stages.Execution)
+		if err != nil {
+			return err
+		}
+		v.historyFrom = pm.History.PruneTo(executed)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if slices.Contains(cfg.Checks, CheckReceipts) && v.historyFrom > cfg.From {
+		logger.Info("[verify] receipts of blocks with pruned history are not checked", "before", v.historyFrom)
+	}
+
+	// chunks complete in any order, the report only advances over contiguous ones
+	var mu sync.Mutex
+	completed := map[uint64][]BlockFailure{}
+	complete := func(from uint64, failures []BlockFailure) error {
+		mu.Lock()
+		defer mu.Unlock()
+		completed[from] = failures
+		advanced := false
+		for {
+			failures, ok := completed[report.Next]
+			if !ok {
+				break
+			}
+			delete(completed, report.Next)
+			report.Failures = append(report.Failures, failures...)
+			report.Next += cfg.ChunkSize
+			if report.Next > report.To {
+				report.Next = report.To + 1
+			}
+			advanced = true
+		}
+		if !advanced || cfg.ProgressFile == "" {
+			return nil
+		}
+		return saveBlocksReport(cfg.ProgressFile, report)
+	}
+
+	logEvery := time.NewTicker(30 * time.Second)
+	defer logEvery.Stop()
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(cfg.Workers)
+	for from := report.Next; from <= cfg.To; from += cfg.ChunkSize {
+		from, to := from, from+cfg.ChunkSize-1
+		if to > cfg.To {
+			to = cfg.To
+		}
+		select {
+		case <-logEvery.C:
+			mu.Lock()
+			logger.Info("[verify] progress", "verified", report.Next, "to", report.To, "failures", len(report.Failures))
+			mu.Unlock()
+		default:
+		}
+		g.Go(func() error {
+			var failures []BlockFailure
+			if err := db.View(gCtx, func(tx kv.Tx) error {
+				for blockNum := from; blockNum <= to; blockNum++ {
+					select {
+					case <-gCtx.Done():
+						return gCtx.Err()
+					default:
+					}
+					blockFailures, err := v.verify(gCtx, tx, blockNum)
+					if err != nil {
+						return fmt.Errorf("verifying block %d: %w", blockNum, err)
+					}
+					failures = append(failures, blockFailures...)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			for _, failure := range failures {
+				logger.Warn("[verify] check failed", "block", failure.Block, "check", failure.Check, "err", failure.Err)
+			}
+			return complete(from, failures)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+type blockVerifier struct {
+	cfg         BlocksCfg
+	blockReader services.FullBlockReader
+	historyV3   bool
+	historyFrom uint64 // first block whose history is not pruned, the ones before cannot be re-executed
+}
+
+func (v *blockVerifier) enabled(check string) bool { return slices.Contains(v.cfg.Checks, check) }
+
+// verify runs the checks of the block, the ones depending on a failed one are skipped.
+func (v *blockVerifier) verify(ctx context.Context, tx kv.Tx, blockNum uint64) (failures []BlockFailure, err error) {
+	fail := func(check string, format string, args ...interface{}) {
+		failures = append(failures, BlockFailure{Block: blockNum, Check: check, Err: fmt.Sprintf(format, args...)})
+	}
+
+	hash, err := v.blockReader.CanonicalHash(ctx, tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if hash == (libcommon.Hash{}) {
+		fail(CheckHeaders, "no canonical hash")
+		return failures, nil
+	}
+	header, err := v.blockReader.Header(ctx, tx, hash, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		fail(CheckHeaders, "header %x not found", hash)
+		return failures, nil
+	}
+	if v.enabled(CheckHeaders) {
+		if header.Hash() != hash {
+			fail(CheckHeaders, "header hash %x, canonical hash %x", header.Hash(), hash)
+		}
+		if header.Number.Uint64() != blockNum {
+			fail(CheckHeaders, "header number %d", header.Number.Uint64())
+		}
+		if blockNum > 0 {
+			parentHash, err := v.blockReader.CanonicalHash(ctx, tx, blockNum-1)
+			if err != nil {
+				return nil, err
+			}
+			if header.ParentHash != parentHash {
+				fail(CheckHeaders, "parent hash %x, canonical parent hash %x", header.ParentHash, parentHash)
+			}
+		}
+	}
+
+	// remote block readers have no snapshots to check
+	frozenReader, ok := v.blockReader.(interface {
+		VerifyFrozenBlock(blockNum uint64, hash libcommon.Hash) (bool, error)
+	})
+	// no block is frozen while the snapshots are empty, block 0 included
+	if v.enabled(CheckSnapshots) && ok && v.blockReader.Snapshots().BlocksAvailable() > 0 && blockNum <= v.blockReader.Snapshots().BlocksAvailable() {
+		if frozen, err := frozenReader.VerifyFrozenBlock(blockNum, hash); err != nil {
+			fail(CheckSnapshots, "%v", err)
+		} else if !frozen {
+			fail(CheckSnapshots, "block is not in the snapshots")
+		}
+	}
+
+	block, senders, err := v.blockReader.BlockWithSenders(ctx, tx, hash, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		fail(CheckBodies, "body not found")
+		return failures, nil
+	}
+	txs := block.Transactions()
+	bodyOk := true
+	if v.enabled(CheckBodies) {
+		if root := types.DeriveSha(txs); root != header.TxHash {
+			fail(CheckBodies, "transactions root %x, header %x", root, header.TxHash)
+			bodyOk = false
+		}
+		if uncleHash := types.CalcUncleHash(block.Uncles()); uncleHash != header.UncleHash {
+			fail(CheckBodies, "uncles hash %x, header %x", uncleHash, header.UncleHash)
+			bodyOk = false
+		}
+		if header.WithdrawalsHash != nil {
+			if root := types.DeriveSha(types.Withdrawals(block.Withdrawals())); root != *header.WithdrawalsHash {
+				fail(CheckBodies, "withdrawals root %x, header %x", root, *header.WithdrawalsHash)
+				bodyOk = false
+			}
+		}
+	}
+
+	if v.enabled(CheckSenders) {
+		if len(senders) != len(txs) {
+			fail(CheckSenders, "%d senders for %d transactions", len(senders), len(txs))
+		} else {
+			signer := types.MakeSigner(v.cfg.ChainConfig, blockNum)
+			for i, txn := range txs {
+				sender, err := signer.Sender(txn)
+				if err != nil {
+					fail(CheckSenders, "transaction %d: %v", i, err)
+				} else if sender != senders[i] {
+					fail(CheckSenders, "transaction %d: stored sender %x, recovered %x", i, senders[i], sender)
+				}
+			}
+		}
+	}
+
+	if v.enabled(CheckTxLookup) {
+		for i, txn := range txs {
+			txnBlockNum, ok, err := v.blockReader.TxnLookup(ctx, tx, txn.Hash())
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				fail(CheckTxLookup, "transaction %d %x not found", i, txn.Hash())
+			} else if txnBlockNum != blockNum {
+				fail(CheckTxLookup, "transaction %d %x looked up to block %d", i, txn.Hash(), txnBlockNum)
+			}
+		}
+	}
+
+	// receipts are not part of the pre-Byzantium roots without the intermediate state roots
+	if v.enabled(CheckReceipts) && bodyOk && v.cfg.ChainConfig.IsByzantium(blockNum) && blockNum >= v.historyFrom {
+		receipts, err := v.execute(ctx, tx, block)
+		if err != nil {
+			fail(CheckReceipts, "re-execution: %v", err)
+		} else if root := types.DeriveSha(receipts); root != header.ReceiptHash {
+			fail(CheckReceipts, "receipts root %x, header %x", root, header.ReceiptHash)
+		}
+	}
+	return failures, nil
+}
+
+// execute re-executes the block on the historical state and returns its receipts.
+func (v *blockVerifier) execute(ctx context.Context, tx kv.Tx, block *types.Block) (types.Receipts, error) {
+	_, _, _, ibs, _, err := transactions.ComputeTxEnv(ctx, v.cfg.Engine, block, v.cfg.ChainConfig, v.blockReader, tx, 0, v.historyV3)
+	if err != nil {
+		return nil, err
+	}
+	header := block.Header()
+	getHeader := func(hash libcommon.Hash, number uint64) *types.Header {
+		h, _ := v.blockReader.Header(ctx, tx, hash, number)
+		return h
+	}
+	usedGas := new(uint64)
+	gp := new(core.GasPool).AddGas(block.GasLimit()).AddDataGas(params.MaxDataGasPerBlock)
+	noopWriter := state.NewNoopWriter()
+	receipts := make(types.Receipts, len(block.Transactions()))
+	for i, txn := range block.Transactions() {
+		ibs.SetTxContext(txn.Hash(), block.Hash(), i)
+		receipt, _, err := core.ApplyTransaction(v.cfg.ChainConfig, core.GetHashFn(header, getHeader), v.cfg.Engine, nil, gp, ibs, noopWriter, header, txn, usedGas, vm.Config{})
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		receipts[i] = receipt
+	}
+	return receipts, nil
+}
+
+func loadBlocksReport(fileName string) (*BlocksReport, error) {
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	report := &BlocksReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("invalid progress file %s: %w", fileName, err)
+	}
+	return report, nil
+}
+
+// saveBlocksReport replaces the progress file at once, so that it is never partially written.
+func saveBlocksReport(fileName string, report *BlocksReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpName := fileName + ".tmp"
+	if err := os.WriteFile(tmpName, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, fileName)
+}
@@ -0,0 +1,138 @@
+package integrity_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/integrity"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+// createChain inserts a chain of blocks with a transaction each.
+func createChain(t *testing.T, chainSize int) *stages.MockSentry {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &types.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{addr: {Balance: big.NewInt(math.MaxInt64)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	m := stages.MockWithGenesis(t, gspec, key, false)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, chainSize, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), libcommon.HexToAddress("deadbeef"), uint256.NewInt(100), 21000, uint256.NewInt(params.GWei), nil), *signer, key)
+		require.NoError(t, err)
+		b.AddTx(tx)
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+	return m
+}
+
+// deleteTxLookup deletes the lookup of the transaction of the block.
+func deleteTxLookup(t *testing.T, m *stages.MockSentry, blockNum uint64) {
+	require.NoError(t, m.DB.Update(m.Ctx, func(tx kv.RwTx) error {
+		hash, err := rawdb.ReadCanonicalHash(tx, blockNum)
+		if err != nil {
+			return err
+		}
+		body, err := rawdb.ReadBodyWithTransactions(tx, hash, blockNum)
+		if err != nil {
+			return err
+		}
+		require.Len(t, body.Transactions, 1)
+		return rawdb.DeleteTxLookupEntry(tx, body.Transactions[0].Hash())
+	}))
+}
+
+func TestVerifyBlocks(t *testing.T) {
+	m := createChain(t, 10)
+	require := require.New(t)
+	br, _ := m.NewBlocksIO()
+	cfg := integrity.BlocksCfg{From: 0, To: 10, Workers: 2, ChunkSize: 3, ChainConfig: m.ChainConfig, Engine: m.Engine}
+
+	report, err := integrity.VerifyBlocks(m.Ctx, m.DB, br, cfg, log.New())
+	require.NoError(err)
+	require.True(report.Done())
+	require.Equal(integrity.AllBlockChecks, report.Checks)
+	require.Empty(report.Failures)
+
+	// the transaction of the block 3 is not looked up anymore
+	deleteTxLookup(t, m, 3)
+	report, err = integrity.VerifyBlocks(m.Ctx, m.DB, br, cfg, log.New())
+	require.NoError(err)
+	require.Len(report.Failures, 1)
+	require.Equal(uint64(3), report.Failures[0].Block)
+	require.Equal(integrity.CheckTxLookup, report.Failures[0].Check)
+
+	cfg.Checks = []string{"unknown"}
+	_, err = integrity.VerifyBlocks(m.Ctx, m.DB, br, cfg, log.New())
+	require.Error(err)
+	cfg.Checks, cfg.Engine = []string{integrity.CheckReceipts}, nil
+	_, err = integrity.VerifyBlocks(m.Ctx, m.DB, br, cfg, log.New())
+	require.Error(err)
+}
+
+func TestVerifyBlocksResume(t *testing.T) {
+	m := createChain(t, 10)
+	require := require.New(t)
+	br, _ := m.NewBlocksIO()
+	deleteTxLookup(t, m, 3)
+
+	// the blocks before 6 were verified by an interrupted run, which found a failure the chain does not have
+	progressFile := filepath.Join(t.TempDir(), "progress.json")
+	checks := []string{integrity.CheckHeaders, integrity.CheckTxLookup}
+	saved := integrity.BlocksReport{From: 0, To: 10, Checks: checks, Next: 6, Failures: []integrity.BlockFailure{{Block: 1, Check: integrity.CheckHeaders, Err: "saved"}}}
+	data, err := json.Marshal(saved)
+	require.NoError(err)
+	require.NoError(os.WriteFile(progressFile, data, 0644))
+
+	cfg := integrity.BlocksCfg{From: 0, To: 10, Checks: checks, ChunkSize: 2, ProgressFile: progressFile, ChainConfig: m.ChainConfig}
+	report, err := integrity.VerifyBlocks(m.Ctx, m.DB, br, cfg, log.New())
+	require.NoError(err)
+	require.True(report.Done())
+	require.Equal(saved.Failures, report.Failures)
+
+	// the progress file keeps the outcome of the completed run
+	data, err = os.ReadFile(progressFile)
+	require.NoError(err)
+	var resumed integrity.BlocksReport
+	require.NoError(json.Unmarshal(data, &resumed))
+	require.Equal(uint64(11), resumed.Next)
+	require.Equal(saved.Failures, resumed.Failures)
+
+	// a completed run is not resumed, it starts over and finds the missing lookup
+	report, err = integrity.VerifyBlocks(m.Ctx, m.DB, br, cfg, log.New())
+	require.NoError(err)
+	require.Len(report.Failures, 1)
+	require.Equal(uint64(3), report.Failures[0].Block)
+	require.Equal(integrity.CheckTxLookup, report.Failures[0].Check)
+
+	// so does an interrupted run of another range, or a restarted one
+	interrupted, err := json.Marshal(saved)
+	require.NoError(err)
+	for _, restarted := range []integrity.BlocksCfg{{To: 9}, {To: 10, Restart: true}} {
+		require.NoError(os.WriteFile(progressFile, interrupted, 0644))
+		cfg.To, cfg.Restart = restarted.To, restarted.Restart
+		report, err = integrity.VerifyBlocks(m.Ctx, m.DB, br, cfg, log.New())
+		require.NoError(err)
+		require.Len(report.Failures, 1)
+		require.Equal(uint64(3), report.Failures[0].Block)
+	}
+}
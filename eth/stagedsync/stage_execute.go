@@ -854,14 +854,21 @@ func PruneExecutionStage(s *PruneState, tx kv.RwTx, cfg ExecuteBlockCfg, ctx con
 		}
 
 		if cfg.prune.Receipts.Enabled() {
-			if err = rawdb.PruneTable(tx, kv.Receipts, cfg.prune.Receipts.PruneTo(s.ForwardProgress), ctx, math.MaxInt32); err != nil {
+			receiptsPruneTo := cfg.prune.Receipts.PruneTo(s.ForwardProgress)
+			if cfg.blockReader != nil {
+				// the receipts are frozen with their blocks, keep them until then
+				if snapshots := cfg.blockReader.Snapshots(); snapshots != nil && snapshots.Cfg().Enabled && snapshots.Cfg().Produce {
+					receiptsPruneTo = cmp.Min(receiptsPruneTo, snapshots.BlocksAvailable()+1)
+				}
+			}
+			if err = rawdb.PruneTable(tx, kv.Receipts, receiptsPruneTo, ctx, math.MaxInt32); err != nil {
 				return err
 			}
-			if err = rawdb.PruneTable(tx, kv.BorReceipts, cfg.prune.Receipts.PruneTo(s.ForwardProgress), ctx, math.MaxUint32); err != nil {
+			if err = rawdb.PruneTable(tx, kv.BorReceipts, receiptsPruneTo, ctx, math.MaxUint32); err != nil {
 				return err
 			}
 			// LogIndex.Prune will read everything what not pruned here
-			if err = rawdb.PruneTable(tx, kv.Log, cfg.prune.Receipts.PruneTo(s.ForwardProgress), ctx, math.MaxInt32); err != nil {
+			if err = rawdb.PruneTable(tx, kv.Log, receiptsPruneTo, ctx, math.MaxInt32); err != nil {
 				return err
 			}
 		}
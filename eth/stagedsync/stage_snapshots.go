@@ -343,6 +343,15 @@ func WaitForDownloader(s *StageState, ctx context.Context, cfg SnapshotsCfg, tx
 		_, fname := filepath.Split(existingFile.Path)
 		existingFilesMap[fname] = struct{}{}
 	}
+	if !dbEmpty {
+		extraFiles, err := snapshotsync.ExtraSegmentFiles(snapshots.Dir())
+		if err != nil {
+			return err
+		}
+		for _, fname := range extraFiles {
+			existingFilesMap[fname] = struct{}{}
+		}
+	}
 	if len(missingSnapshots) > 0 {
 		log.Warn(fmt.Sprintf("[%s] downloading missing snapshots", s.LogPrefix()))
 	}
@@ -25,6 +25,7 @@ import (
 	"testing"
 
 	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon/turbo/stages"
 	"github.com/stretchr/testify/require"
@@ -34,6 +35,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/tracers"
 	"github.com/ledgerwatch/erigon/tests"
 )
@@ -163,3 +165,118 @@ func testPrestateDiffTracer(tracerName string, dirPath string, t *testing.T) {
 		})
 	}
 }
+
+// TestPrestateTracerFrameChanges tests the frameChanges mode of the prestate tracer on the following:
+// Tx to A. A writes storage and transient storage, calls B which writes storage and reverts, then
+// sends value to C which selfdestructs to D.
+// Expected: every change is attributed to the frame which made it and the changes of B are flagged as reverted
+func TestPrestateTracerFrameChanges(t *testing.T) {
+	var (
+		a           = libcommon.HexToAddress("0x00000000000000000000000000000000000000aa")
+		b           = libcommon.HexToAddress("0x00000000000000000000000000000000000000bb")
+		c           = libcommon.HexToAddress("0x00000000000000000000000000000000000000cc")
+		beneficiary = libcommon.HexToAddress("0x00000000000000000000000000000000000000dd")
+		config      = &chain.Config{
+			ChainID:               big.NewInt(1),
+			HomesteadBlock:        new(big.Int),
+			TangerineWhistleBlock: new(big.Int),
+			SpuriousDragonBlock:   new(big.Int),
+			ByzantiumBlock:        new(big.Int),
+			ConstantinopleBlock:   new(big.Int),
+			PetersburgBlock:       new(big.Int),
+			IstanbulBlock:         new(big.Int),
+			BerlinBlock:           new(big.Int),
+			LondonBlock:           new(big.Int),
+			ShanghaiTime:          new(big.Int),
+			CancunTime:            new(big.Int),
+		}
+	)
+	privkey, err := crypto.HexToECDSA("0000000000000000deadbeef00000000000000000000000000000000deadbeef")
+	require.NoError(t, err)
+	signer := types.LatestSigner(config)
+	tx, err := types.SignNewTx(privkey, *signer, &types.LegacyTx{
+		GasPrice: uint256.NewInt(0),
+		CommonTx: types.CommonTx{
+			Gas: 1000000,
+			To:  &a,
+		},
+	})
+	require.NoError(t, err)
+	origin, _ := signer.Sender(tx)
+	txContext := evmtypes.TxContext{
+		Origin:   origin,
+		GasPrice: uint256.NewInt(0),
+	}
+	context := evmtypes.BlockContext{
+		CanTransfer:   core.CanTransfer,
+		Transfer:      core.Transfer,
+		BlockNumber:   1,
+		Time:          1,
+		Difficulty:    big.NewInt(0),
+		GasLimit:      uint64(6000000),
+		BaseFee:       uint256.NewInt(0),
+		ExcessDataGas: new(uint64),
+	}
+	codeA := []byte{
+		byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x0, byte(vm.SSTORE), // slot 0 = 1
+		byte(vm.PUSH1), 0x2, byte(vm.PUSH1), 0x1, byte(vm.TSTORE), // transient slot 1 = 2
+		byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), // in and outs zero, value=0
+		byte(vm.PUSH20)}
+	codeA = append(codeA, b.Bytes()...)
+	codeA = append(codeA, byte(vm.GAS), byte(vm.CALL), byte(vm.POP),
+		byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), // in and outs zero
+		byte(vm.PUSH1), 0x5, byte(vm.PUSH20)) // value=5
+	codeA = append(codeA, c.Bytes()...)
+	codeA = append(codeA, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP))
+	codeB := []byte{
+		byte(vm.PUSH1), 0x7, byte(vm.PUSH1), 0x0, byte(vm.SSTORE), // slot 0 = 7
+		byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.REVERT),
+	}
+	codeC := append([]byte{byte(vm.PUSH20)}, beneficiary.Bytes()...)
+	codeC = append(codeC, byte(vm.SELFDESTRUCT))
+	alloc := types.GenesisAlloc{
+		a:      types.GenesisAccount{Nonce: 1, Code: codeA, Balance: big.NewInt(1000)},
+		b:      types.GenesisAccount{Nonce: 1, Code: codeB, Balance: big.NewInt(0)},
+		c:      types.GenesisAccount{Nonce: 1, Code: codeC, Balance: big.NewInt(100)},
+		origin: types.GenesisAccount{Balance: big.NewInt(500000000000000)},
+	}
+	rules := config.Rules(context.BlockNumber, context.Time)
+	m := stages.Mock(t)
+	dbTx, err := m.DB.BeginRw(m.Ctx)
+	require.NoError(t, err)
+	defer dbTx.Rollback()
+
+	statedb, _ := tests.MakePreState(rules, dbTx, alloc, context.BlockNumber)
+	tracer, err := tracers.New("prestateTracer", new(tracers.Context), json.RawMessage(`{"diffMode": true, "frameChanges": true}`))
+	require.NoError(t, err)
+	evm := vm.NewEVM(context, txContext, statedb, config, vm.Config{Debug: true, Tracer: tracer})
+	msg, err := tx.AsMessage(*signer, nil, rules)
+	require.NoError(t, err)
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.GetGas()).AddDataGas(tx.GetDataGas()))
+	_, err = st.TransitionDb(true /* refunds */, false /* gasBailout */)
+	require.NoError(t, err)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	var result struct {
+		Changes json.RawMessage `json:"changes"`
+	}
+	require.NoError(t, json.Unmarshal(res, &result))
+	want := `[
+		{"frame":{"index":0,"depth":0,"type":"CALL","address":"0x00000000000000000000000000000000000000aa"},"kind":"storage","address":"0x00000000000000000000000000000000000000aa",
+			"slot":"0x0000000000000000000000000000000000000000000000000000000000000000",
+			"pre":"0x0000000000000000000000000000000000000000000000000000000000000000","post":"0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{"frame":{"index":0,"depth":0,"type":"CALL","address":"0x00000000000000000000000000000000000000aa"},"kind":"transientStorage","address":"0x00000000000000000000000000000000000000aa",
+			"slot":"0x0000000000000000000000000000000000000000000000000000000000000001",
+			"pre":"0x0000000000000000000000000000000000000000000000000000000000000000","post":"0x0000000000000000000000000000000000000000000000000000000000000002"},
+		{"frame":{"index":1,"depth":1,"type":"CALL","address":"0x00000000000000000000000000000000000000bb"},"kind":"storage","address":"0x00000000000000000000000000000000000000bb",
+			"slot":"0x0000000000000000000000000000000000000000000000000000000000000000",
+			"pre":"0x0000000000000000000000000000000000000000000000000000000000000000","post":"0x0000000000000000000000000000000000000000000000000000000000000007","reverted":true},
+		{"frame":{"index":2,"depth":1,"type":"CALL","address":"0x00000000000000000000000000000000000000cc"},"kind":"balance","address":"0x00000000000000000000000000000000000000aa","pre":"0x3e8","post":"0x3e3"},
+		{"frame":{"index":2,"depth":1,"type":"CALL","address":"0x00000000000000000000000000000000000000cc"},"kind":"balance","address":"0x00000000000000000000000000000000000000cc","pre":"0x64","post":"0x69"},
+		{"frame":{"index":2,"depth":1,"type":"CALL","address":"0x00000000000000000000000000000000000000cc"},"kind":"selfdestruct","address":"0x00000000000000000000000000000000000000cc","pre":"0x69","post":"0x0",
+			"beneficiary":"0x00000000000000000000000000000000000000dd"},
+		{"frame":{"index":2,"depth":1,"type":"CALL","address":"0x00000000000000000000000000000000000000cc"},"kind":"balance","address":"0x00000000000000000000000000000000000000dd","pre":"0x0","post":"0x69"}
+	]`
+	require.JSONEq(t, want, string(result.Changes))
+}
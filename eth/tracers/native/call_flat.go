@@ -0,0 +1,353 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync/atomic"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/tracers"
+)
+
+func init() {
+	register("flatCallTracer", newFlatCallTracer)
+}
+
+var parityErrorMapping = map[string]string{
+	"contract creation code storage out of gas": "Out of gas",
+	"out of gas":                      "Out of gas",
+	"gas uint64 overflow":             "Out of gas",
+	"max code size exceeded":          "Out of gas",
+	"invalid jump destination":        "Bad jump destination",
+	"return data out of bounds":       "Out of bounds",
+	"stack limit reached 1024 (1023)": "Out of stack",
+	"precompiled failed":              "Built-in failed",
+	"invalid input length":            "Built-in failed",
+}
+
+var parityErrorMappingStartingWith = map[string]string{
+	"invalid opcode:": "Bad instruction",
+	"stack underflow": "Stack underflow",
+}
+
+// flatCallFrame is a standalone call frame in the format of `trace_transaction`.
+// Do not change the ordering of the fields, it mirrors the Parity output.
+type flatCallFrame struct {
+	Action              interface{}     `json:"action"`
+	BlockHash           *libcommon.Hash `json:"blockHash,omitempty"`
+	BlockNumber         *uint64         `json:"blockNumber,omitempty"`
+	Error               string          `json:"error,omitempty"`
+	Result              interface{}     `json:"result"`
+	Subtraces           int             `json:"subtraces"`
+	TraceAddress        []int           `json:"traceAddress"`
+	TransactionHash     *libcommon.Hash `json:"transactionHash,omitempty"`
+	TransactionPosition *uint64         `json:"transactionPosition,omitempty"`
+	Type                string          `json:"type"`
+}
+
+type flatCallAction struct {
+	From     libcommon.Address `json:"from"`
+	CallType string            `json:"callType"`
+	Gas      hexutil.Uint64    `json:"gas"`
+	Input    hexutility.Bytes  `json:"input"`
+	To       libcommon.Address `json:"to"`
+	Value    *hexutil.Big      `json:"value"`
+}
+
+type flatCreateAction struct {
+	From  libcommon.Address `json:"from"`
+	Gas   hexutil.Uint64    `json:"gas"`
+	Init  hexutility.Bytes  `json:"init"`
+	Value *hexutil.Big      `json:"value"`
+}
+
+type flatSuicideAction struct {
+	Address       libcommon.Address `json:"address"`
+	RefundAddress libcommon.Address `json:"refundAddress"`
+	Balance       *hexutil.Big      `json:"balance"`
+}
+
+type flatCallResult struct {
+	GasUsed hexutil.Uint64   `json:"gasUsed"`
+	Output  hexutility.Bytes `json:"output"`
+}
+
+type flatCreateResult struct {
+	Address *libcommon.Address `json:"address,omitempty"`
+	Code    hexutility.Bytes   `json:"code"`
+	GasUsed hexutil.Uint64     `json:"gasUsed"`
+}
+
+// flatCallScope keeps what the nested call frame loses or does not record about a scope.
+type flatCallScope struct {
+	precompile bool
+	create     bool
+	to         libcommon.Address
+}
+
+// flatCallTracer reports call frame information of a tx in a flat format, i.e.
+// as opposed to the nested format of `callTracer`. The output is the one of
+// `trace_transaction`.
+type flatCallTracer struct {
+	tracer     *callTracer
+	config     flatCallTracerConfig
+	ctx        *tracers.Context // Holds tracer context data
+	scopes     []flatCallScope
+	topGasUsed uint64 // Gas used by the top call, without the intrinsic gas
+}
+
+type flatCallTracerConfig struct {
+	ConvertParityErrors bool `json:"convertParityErrors"` // If true, call tracer converts errors to parity format
+	IncludePrecompiles  bool `json:"includePrecompiles"`  // If true, call tracer includes calls to precompiled contracts
+}
+
+// newFlatCallTracer returns a new flatCallTracer.
+func newFlatCallTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config flatCallTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	tracer, err := newCallTracer(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := tracer.(*callTracer)
+	if !ok {
+		return nil, errors.New("internal error: embedded tracer has wrong type")
+	}
+	return &flatCallTracer{tracer: t, ctx: ctx, config: config}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *flatCallTracer) CaptureStart(env vm.VMInterface, from libcommon.Address, to libcommon.Address, precompile, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	t.tracer.CaptureStart(env, from, to, precompile, create, input, gas, value, code)
+	t.scopes = append(t.scopes[:0], flatCallScope{create: create, to: to})
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *flatCallTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.tracer.CaptureEnd(output, gasUsed, err)
+	t.topGasUsed = gasUsed
+	t.restoreCreateAddress(&t.tracer.callstack[0], t.scopes[0], err)
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *flatCallTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	t.tracer.CaptureState(pc, op, gas, cost, scope, rData, depth, err)
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *flatCallTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	t.tracer.CaptureFault(pc, op, gas, cost, scope, depth, err)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *flatCallTracer) CaptureEnter(typ vm.OpCode, from libcommon.Address, to libcommon.Address, precompile, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	// Skip if tracing was interrupted
+	if atomic.LoadUint32(&t.tracer.interrupt) > 0 {
+		return
+	}
+	t.tracer.CaptureEnter(typ, from, to, precompile, create, input, gas, value, code)
+	// Parity traces don't include calls to precompiles which don't transfer value
+	skip := precompile && !t.config.IncludePrecompiles && (value == nil || value.IsZero())
+	t.scopes = append(t.scopes, flatCallScope{precompile: skip, create: create, to: to})
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *flatCallTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.tracer.CaptureExit(output, gasUsed, err)
+	if len(t.scopes) <= 1 || atomic.LoadUint32(&t.tracer.interrupt) > 0 {
+		return
+	}
+	scope := t.scopes[len(t.scopes)-1]
+	t.scopes = t.scopes[:len(t.scopes)-1]
+
+	// The call has been nested into its parent by the call tracer
+	parent := &t.tracer.callstack[len(t.tracer.callstack)-1]
+	if scope.precompile {
+		parent.Calls = parent.Calls[:len(parent.Calls)-1]
+		return
+	}
+	t.restoreCreateAddress(&parent.Calls[len(parent.Calls)-1], scope, err)
+}
+
+// restoreCreateAddress keeps the address of a reverted contract creation, as `trace_transaction` reports it.
+func (t *flatCallTracer) restoreCreateAddress(call *callFrame, scope flatCallScope, err error) {
+	if scope.create && errors.Is(err, vm.ErrExecutionReverted) {
+		call.To = scope.to
+	}
+}
+
+func (t *flatCallTracer) CaptureTxStart(gasLimit uint64) {
+	t.tracer.CaptureTxStart(gasLimit)
+}
+
+func (t *flatCallTracer) CaptureTxEnd(restGas uint64) {
+	t.tracer.CaptureTxEnd(restGas)
+}
+
+// GetResult returns the json-encoded list of flat call traces, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *flatCallTracer) GetResult() (json.RawMessage, error) {
+	if len(t.tracer.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	// Parity reports the gas used by the execution only
+	top := t.tracer.callstack[0]
+	top.GasUsed = t.topGasUsed
+
+	flat, err := t.flatFromNested(&top, []int{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := json.Marshal(flat)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.tracer.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *flatCallTracer) Stop(err error) {
+	t.tracer.Stop(err)
+}
+
+// flatFromNested flattens the call frame and its sub-calls in depth-first order.
+// parentValue is the value of the parent frame, inherited by delegate calls.
+func (t *flatCallTracer) flatFromNested(input *callFrame, traceAddress []int, parentValue *big.Int) (output []flatCallFrame, err error) {
+	frame := flatCallFrame{
+		TraceAddress: traceAddress,
+		Subtraces:    len(input.Calls),
+	}
+	value := input.Value
+	switch input.Type {
+	case vm.CREATE, vm.CREATE2:
+		frame.Type = "create"
+		frame.Action = &flatCreateAction{
+			From:  input.From,
+			Gas:   hexutil.Uint64(input.Gas),
+			Init:  input.Input,
+			Value: bigOrZero(value),
+		}
+		result := &flatCreateResult{Code: input.Output, GasUsed: hexutil.Uint64(input.GasUsed)}
+		if input.To != (libcommon.Address{}) {
+			to := input.To
+			result.Address = &to
+		}
+		frame.Result = result
+	case vm.SELFDESTRUCT:
+		frame.Type = "suicide"
+		frame.Action = &flatSuicideAction{
+			Address:       input.From,
+			RefundAddress: input.To,
+			Balance:       bigOrZero(value),
+		}
+	case vm.CALL, vm.STATICCALL, vm.CALLCODE, vm.DELEGATECALL:
+		switch input.Type {
+		case vm.DELEGATECALL:
+			value = parentValue
+		case vm.STATICCALL:
+			value = nil
+		}
+		frame.Type = "call"
+		frame.Action = &flatCallAction{
+			From:     input.From,
+			CallType: strings.ToLower(input.Type.String()),
+			Gas:      hexutil.Uint64(input.Gas),
+			Input:    input.Input,
+			To:       input.To,
+			Value:    bigOrZero(value),
+		}
+		frame.Result = &flatCallResult{GasUsed: hexutil.Uint64(input.GasUsed), Output: input.Output}
+	default:
+		return nil, fmt.Errorf("unrecognized call frame type: %s", input.Type)
+	}
+
+	// Revert output contains useful information (revert reason).
+	// Otherwise discard result.
+	if input.Error != "" {
+		if input.Error == vm.ErrExecutionReverted.Error() {
+			frame.Error = "Reverted"
+		} else {
+			frame.Error = input.Error
+			frame.Result = nil
+			if t.config.ConvertParityErrors {
+				frame.Error = convertErrorToParity(input.Error)
+			}
+		}
+	}
+	t.fillCallFrameFromContext(&frame)
+	output = append(output, frame)
+
+	// Recursively form flattened call frames.
+	for i := range input.Calls {
+		childAddr := make([]int, 0, len(traceAddress)+1)
+		childAddr = append(childAddr, traceAddress...)
+		childAddr = append(childAddr, i)
+		flat, err := t.flatFromNested(&input.Calls[i], childAddr, value)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, flat...)
+	}
+	return output, nil
+}
+
+// fillCallFrameFromContext sets the block and transaction of mined transactions.
+func (t *flatCallTracer) fillCallFrameFromContext(frame *flatCallFrame) {
+	if t.ctx == nil || t.ctx.BlockHash == (libcommon.Hash{}) {
+		return
+	}
+	blockHash, blockNumber, txHash, txIndex := t.ctx.BlockHash, t.ctx.BlockNumber, t.ctx.TxHash, uint64(t.ctx.TxIndex)
+	frame.BlockHash = &blockHash
+	frame.BlockNumber = &blockNumber
+	frame.TransactionHash = &txHash
+	frame.TransactionPosition = &txIndex
+}
+
+func convertErrorToParity(err string) string {
+	if parityError, ok := parityErrorMapping[err]; ok {
+		return parityError
+	}
+	for gethError, parityError := range parityErrorMappingStartingWith {
+		if strings.HasPrefix(err, gethError) {
+			return parityError
+		}
+	}
+	return err
+}
+
+func bigOrZero(v *big.Int) *hexutil.Big {
+	if v == nil {
+		return (*hexutil.Big)(new(big.Int))
+	}
+	return (*hexutil.Big)(v)
+}
@@ -19,6 +19,7 @@ package native
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"math/big"
 	"sync/atomic"
 
@@ -70,10 +71,16 @@ type prestateTracer struct {
 	reason    error  // Textual reason for the interruption
 	created   map[libcommon.Address]bool
 	deleted   map[libcommon.Address]bool
+	frames    []*changeFrame // Call frames currently executing, used in frameChanges mode
+	changes   []*stateChange // State changes in execution order, used in frameChanges mode
+	calls     int            // Number of call frames entered so far
+	// selfdestructing is set between the CaptureEnter and CaptureExit pair reported for SELFDESTRUCT
+	selfdestructing bool
 }
 
 type prestateTracerConfig struct {
-	DiffMode bool `json:"diffMode"` // If true, this tracer will return state modifications
+	DiffMode     bool `json:"diffMode"`     // If true, this tracer will return state modifications
+	FrameChanges bool `json:"frameChanges"` // If true, every modification is also attributed to the call frame which made it
 }
 
 func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
@@ -83,12 +90,16 @@ func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Trace
 			return nil, err
 		}
 	}
+	if config.FrameChanges && !config.DiffMode {
+		return nil, errors.New("prestateTracer: frameChanges requires diffMode")
+	}
 	return &prestateTracer{
 		pre:     state{},
 		post:    state{},
 		config:  config,
 		created: make(map[libcommon.Address]bool),
 		deleted: make(map[libcommon.Address]bool),
+		changes: []*stateChange{},
 	}, nil
 }
 
@@ -118,10 +129,20 @@ func (t *prestateTracer) CaptureStart(env vm.VMInterface, from libcommon.Address
 	if create && t.config.DiffMode {
 		t.created[to] = true
 	}
+	if t.config.FrameChanges {
+		typ := vm.CALL
+		if create {
+			typ = vm.CREATE
+		}
+		t.enterFrame(typ, from, to, create, value)
+	}
 }
 
 // CaptureEnd is called after the call finishes to finalize the tracing.
 func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if t.config.FrameChanges {
+		t.exitFrame(err)
+	}
 	if t.config.DiffMode {
 		return
 	}
@@ -169,6 +190,35 @@ func (t *prestateTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64,
 		t.lookupAccount(addr)
 		t.created[addr] = true
 	}
+	if t.config.FrameChanges {
+		t.captureWrite(op, scope)
+	}
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *prestateTracer) CaptureEnter(typ vm.OpCode, from libcommon.Address, to libcommon.Address, precompile, create bool, input []byte, gas uint64, value *uint256.Int, code []byte) {
+	if !t.config.FrameChanges {
+		return
+	}
+	if typ == vm.SELFDESTRUCT {
+		t.selfdestructing = true
+		t.captureSelfdestruct(from, to, value)
+		return
+	}
+	t.enterFrame(typ, from, to, create, value)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *prestateTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if !t.config.FrameChanges {
+		return
+	}
+	if t.selfdestructing {
+		t.selfdestructing = false
+		return
+	}
+	t.exitFrame(err)
 }
 
 func (t *prestateTracer) CaptureTxStart(gasLimit uint64) {
@@ -244,7 +294,13 @@ func (t *prestateTracer) CaptureTxEnd(restGas uint64) {
 func (t *prestateTracer) GetResult() (json.RawMessage, error) {
 	var res []byte
 	var err error
-	if t.config.DiffMode {
+	if t.config.FrameChanges {
+		res, err = json.Marshal(struct {
+			Post    state          `json:"post"`
+			Pre     state          `json:"pre"`
+			Changes []*stateChange `json:"changes"`
+		}{t.post, t.pre, t.changes})
+	} else if t.config.DiffMode {
 		res, err = json.Marshal(struct {
 			Post state `json:"post"`
 			Pre  state `json:"pre"`
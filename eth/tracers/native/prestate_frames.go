@@ -0,0 +1,210 @@
+package native
+
+import (
+	"bytes"
+
+	"github.com/holiman/uint256"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/vm"
+)
+
+// Kinds of the state changes reported in frameChanges mode.
+const (
+	changeBalance          = "balance"
+	changeNonce            = "nonce"
+	changeCode             = "code"
+	changeStorage          = "storage"
+	changeTransientStorage = "transientStorage"
+	changeSelfdestruct     = "selfdestruct"
+)
+
+// frameRef identifies the call frame a state change is attributed to. Index is the
+// position of the frame in execution order, the top-level call being 0.
+type frameRef struct {
+	Index   int               `json:"index"`
+	Depth   int               `json:"depth"`
+	Type    string            `json:"type"`
+	Address libcommon.Address `json:"address"`
+}
+
+// stateChange is a single modification of the state made by a call frame. Pre and Post
+// hold the values right before and after the modification. Changes made by a frame
+// which was reverted, directly or through one of its callers, are kept and flagged.
+type stateChange struct {
+	Frame       frameRef           `json:"frame"`
+	Kind        string             `json:"kind"`
+	Address     libcommon.Address  `json:"address"`
+	Slot        *libcommon.Hash    `json:"slot,omitempty"`
+	Pre         interface{}        `json:"pre"`
+	Post        interface{}        `json:"post"`
+	Beneficiary *libcommon.Address `json:"beneficiary,omitempty"`
+	Reverted    bool               `json:"reverted,omitempty"`
+}
+
+// changeFrame is the bookkeeping of an executing call frame.
+type changeFrame struct {
+	ref   frameRef
+	first int // Index of the first change recorded within the frame
+	// Creations transfer value, bump nonces and deploy code only after CaptureEnter,
+	// so the affected accounts are sampled on entry and compared on exit.
+	create      bool
+	from        libcommon.Address
+	value       *uint256.Int
+	fromNonce   uint64
+	fromBalance *uint256.Int
+	toNonce     uint64
+	toBalance   *uint256.Int
+	toCode      []byte
+}
+
+// enterFrame pushes a new call frame. Value transfers of calls have already been
+// applied at this point and are recorded right away.
+func (t *prestateTracer) enterFrame(typ vm.OpCode, from, to libcommon.Address, create bool, value *uint256.Int) {
+	ibs := t.env.IntraBlockState()
+	frame := &changeFrame{
+		ref: frameRef{
+			Index:   t.calls,
+			Depth:   len(t.frames),
+			Type:    typ.String(),
+			Address: to,
+		},
+		first:  len(t.changes),
+		create: create,
+		from:   from,
+		value:  value,
+	}
+	t.calls++
+	t.frames = append(t.frames, frame)
+
+	if create {
+		frame.fromNonce = ibs.GetNonce(from)
+		frame.fromBalance = ibs.GetBalance(from).Clone()
+		frame.toNonce = ibs.GetNonce(to)
+		frame.toBalance = ibs.GetBalance(to).Clone()
+		frame.toCode = ibs.GetCode(to)
+		return
+	}
+	if typ != vm.CALL || value == nil || value.IsZero() || from == to {
+		return
+	}
+	fromBalance := ibs.GetBalance(from)
+	toBalance := ibs.GetBalance(to)
+	t.recordBalance(from, new(uint256.Int).Add(fromBalance, value), fromBalance)
+	t.recordBalance(to, new(uint256.Int).Sub(toBalance, value), toBalance)
+}
+
+// exitFrame pops the current call frame, flagging everything it changed as reverted
+// if it failed.
+func (t *prestateTracer) exitFrame(err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.create {
+		t.exitCreate(frame, err)
+	}
+	if err != nil {
+		for _, c := range t.changes[frame.first:] {
+			c.Reverted = true
+		}
+	}
+	t.frames = t.frames[:len(t.frames)-1]
+	// The nonce of the creator is bumped before the creation snapshot is taken,
+	// so it survives a failed creation.
+	if frame.create {
+		if nonce := t.env.IntraBlockState().GetNonce(frame.from); nonce != frame.fromNonce {
+			t.record(frame.ref, changeNonce, frame.from, nil, hexutil.Uint64(frame.fromNonce), hexutil.Uint64(nonce))
+		}
+	}
+}
+
+// exitCreate records the account set up by a successful creation. The effects of a
+// failed one are rolled back and not reported.
+func (t *prestateTracer) exitCreate(frame *changeFrame, err error) {
+	if err != nil {
+		return
+	}
+	ibs := t.env.IntraBlockState()
+	to := frame.ref.Address
+	if nonce := ibs.GetNonce(to); nonce != frame.toNonce {
+		t.record(frame.ref, changeNonce, to, nil, hexutil.Uint64(frame.toNonce), hexutil.Uint64(nonce))
+	}
+	if frame.value != nil && !frame.value.IsZero() && frame.from != to {
+		t.recordBalance(frame.from, frame.fromBalance, new(uint256.Int).Sub(frame.fromBalance, frame.value))
+		t.recordBalance(to, frame.toBalance, new(uint256.Int).Add(frame.toBalance, frame.value))
+	}
+	if code := ibs.GetCode(to); !bytes.Equal(code, frame.toCode) {
+		t.record(frame.ref, changeCode, to, nil, hexutility.Bytes(frame.toCode), hexutility.Bytes(code))
+	}
+}
+
+// captureWrite records the storage and transient storage writes of the current frame.
+func (t *prestateTracer) captureWrite(op vm.OpCode, scope *vm.ScopeContext) {
+	if op != vm.SSTORE && op != vm.TSTORE {
+		return
+	}
+	stackData := scope.Stack.Data
+	stackLen := len(stackData)
+	if stackLen < 2 || len(t.frames) == 0 {
+		return
+	}
+	addr := scope.Contract.Address()
+	slot := libcommon.Hash(stackData[stackLen-1].Bytes32())
+	post := libcommon.Hash(stackData[stackLen-2].Bytes32())
+	var pre libcommon.Hash
+	kind := changeStorage
+	if op == vm.SSTORE {
+		var val uint256.Int
+		t.env.IntraBlockState().GetState(addr, &slot, &val)
+		pre = val.Bytes32()
+	} else {
+		kind = changeTransientStorage
+		val := t.env.IntraBlockState().GetTransientState(addr, slot)
+		pre = val.Bytes32()
+	}
+	if pre == post {
+		return
+	}
+	t.record(t.frames[len(t.frames)-1].ref, kind, addr, &slot, pre, post)
+}
+
+// captureSelfdestruct records the destruction of addr by the current frame, together
+// with the balance it hands over to the beneficiary.
+func (t *prestateTracer) captureSelfdestruct(addr, beneficiary libcommon.Address, balance *uint256.Int) {
+	if len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1].ref
+	t.changes = append(t.changes, &stateChange{
+		Frame:       frame,
+		Kind:        changeSelfdestruct,
+		Address:     addr,
+		Pre:         (*hexutil.Big)(balance.ToBig()),
+		Post:        (*hexutil.Big)(new(uint256.Int).ToBig()),
+		Beneficiary: &beneficiary,
+	})
+	if beneficiary != addr && !balance.IsZero() {
+		pre := t.env.IntraBlockState().GetBalance(beneficiary)
+		t.record(frame, changeBalance, beneficiary, nil, (*hexutil.Big)(pre.ToBig()), (*hexutil.Big)(new(uint256.Int).Add(pre, balance).ToBig()))
+	}
+}
+
+// recordBalance attributes a balance change to the current frame.
+func (t *prestateTracer) recordBalance(addr libcommon.Address, pre, post *uint256.Int) {
+	t.record(t.frames[len(t.frames)-1].ref, changeBalance, addr, nil, (*hexutil.Big)(pre.ToBig()), (*hexutil.Big)(post.ToBig()))
+}
+
+func (t *prestateTracer) record(frame frameRef, kind string, addr libcommon.Address, slot *libcommon.Hash, pre, post interface{}) {
+	t.changes = append(t.changes, &stateChange{
+		Frame:   frame,
+		Kind:    kind,
+		Address: addr,
+		Slot:    slot,
+		Pre:     pre,
+		Post:    post,
+	})
+}
@@ -68,6 +68,12 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, jwtSecret []byte, com
 	})
 }
 
+// WebsocketOriginValidator returns the origin check of WebsocketHandler, for the other endpoints upgrading to
+// WebSocket connections.
+func WebsocketOriginValidator(allowedOrigins []string, logger log.Logger) func(*http.Request) bool {
+	return wsHandshakeValidator(allowedOrigins, logger)
+}
+
 // wsHandshakeValidator returns a handler that verifies the origin during the
 // websocket upgrade process. When a '*' is specified as an allowed origins all
 // connections are accepted.
@@ -0,0 +1,549 @@
+package app
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/datadir"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	libstate "github.com/ledgerwatch/erigon-lib/state"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/hack/tool/fromdb"
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/state/temporal"
+	"github.com/ledgerwatch/erigon/core/systemcontracts"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/debug"
+	"github.com/ledgerwatch/erigon/turbo/era1"
+	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+)
+
+var exportCommand = cli.Command{
+	Action:    MigrateFlags(exportChain),
+	Name:      "export",
+	Usage:     "Export blocks to RLP or era1 files",
+	ArgsUsage: "<filename|dirname>",
+	Flags: []cli.Flag{
+		&utils.DataDirFlag,
+		&ExportFromFlag,
+		&ExportToFlag,
+		&ExportFormatFlag,
+	},
+	Subcommands: []*cli.Command{
+		{
+			Action:    MigrateFlags(dumpState),
+			Name:      "dump-state",
+			Usage:     "Dump the accounts and storage at a block as JSON",
+			ArgsUsage: "[<filename>]",
+			Flags: []cli.Flag{
+				&utils.DataDirFlag,
+				&DumpStateBlockFlag,
+				&DumpStateJSONLFlag,
+				&DumpStateNoCodeFlag,
+				&DumpStateNoStorageFlag,
+			},
+			Description: `
+The dump-state command writes the state at the end of a block, by default the last executed one,
+to the given file or to stdout. The dump is a single JSON object, or with --jsonl a line with the
+root followed by a line per account. The file is gzipped if its name ends with .gz.`,
+		},
+	},
+	Description: `
+The export command reads blocks from the database and the snapshots of the node, which may be
+running. With the rlp format, the blocks are RLP encoded one after the other in a single file,
+gzipped if its name ends with .gz, which can be read back with the import command.
+
+With the era1 format, the given directory receives a file per 8192 blocks holding their headers,
+bodies, receipts and total difficulties, with the accumulator of the file in its name. The export
+must start at a multiple of 8192, only covers the blocks before the merge and the receipts of the
+blocks must not be pruned.`,
+}
+
+var (
+	ExportFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block to export",
+		Value: 0,
+	}
+	ExportToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block to export. Zero - means the last block of the node.",
+		Value: 0,
+	}
+	ExportFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Format of the export: rlp or era1",
+		Value: "rlp",
+	}
+	DumpStateBlockFlag = cli.Uint64Flag{
+		Name:  "block",
+		Usage: "Block whose resulting state is dumped. Defaults to the last executed block.",
+	}
+	DumpStateJSONLFlag = cli.BoolFlag{
+		Name:  "jsonl",
+		Usage: "Write an account per line instead of a single JSON object",
+	}
+	DumpStateNoCodeFlag = cli.BoolFlag{
+		Name:  "nocode",
+		Usage: "Exclude contract code",
+	}
+	DumpStateNoStorageFlag = cli.BoolFlag{
+		Name:  "nostorage",
+		Usage: "Exclude storage entries",
+	}
+)
+
+func exportChain(cliCtx *cli.Context) error {
+	if cliCtx.NArg() < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+
+	var logger log.Logger
+	var err error
+	if logger, err = debug.Setup(cliCtx, true /* rootLogger */); err != nil {
+		return err
+	}
+	ctx, cancel := libcommon.RootContext()
+	defer cancel()
+
+	db, snapshots, err := openReadonlyDB(cliCtx, logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer snapshots.Close()
+	blockReader := snapshotsync.NewBlockReader(snapshots)
+	chainConfig := fromdb.ChainConfig(db)
+
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	format := cliCtx.String(ExportFormatFlag.Name)
+	from, to := cliCtx.Uint64(ExportFromFlag.Name), cliCtx.Uint64(ExportToFlag.Name)
+	if to == 0 {
+		// era1 files need the receipts of their blocks
+		stage := stages.Bodies
+		if format == "era1" {
+			stage = stages.Execution
+		}
+		if to, err = stages.GetStageProgress(tx, stage); err != nil {
+			return err
+		}
+	}
+	if from > to {
+		return fmt.Errorf("nothing to export from block %d to block %d", from, to)
+	}
+
+	switch format {
+	case "rlp":
+		return exportRLP(ctx, tx, blockReader, cliCtx.Args().First(), from, to, logger)
+	case "era1":
+		// era1 files only archive proof-of-work blocks
+		merge, err := firstPoSBlock(ctx, tx, blockReader, from, to)
+		if err != nil {
+			return err
+		}
+		if merge <= to {
+			if cliCtx.IsSet(ExportToFlag.Name) || merge == from {
+				return fmt.Errorf("era1 export must end before the merge, at block %d", merge)
+			}
+			to = merge - 1
+		}
+		return exportEra1(ctx, tx, blockReader, chainConfig.ChainName, cliCtx.Args().First(), from, to, logger)
+	default:
+		return fmt.Errorf("unknown export format %q, expected rlp or era1", format)
+	}
+}
+
+// openReadonlyDB opens the chain database and the snapshots of the datadir for reading.
+func openReadonlyDB(cliCtx *cli.Context, logger log.Logger) (kv.RwDB, *snapshotsync.RoSnapshots, error) {
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	db := mdbx.NewMDBX(logger).Label(kv.ChainDB).Path(dirs.Chaindata).Readonly().MustOpen()
+	snapshots := snapshotsync.NewRoSnapshots(ethconfig.NewSnapCfg(true, true, false), dirs.Snap, logger)
+	if err := snapshots.ReopenFolder(); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return db, snapshots, nil
+}
+
+// openTemporalDB wraps the chain database of a node with history v3 into the temporal database reading its history.
+func openTemporalDB(ctx context.Context, cliCtx *cli.Context, db kv.RwDB, logger log.Logger) (kv.RwDB, func(), error) {
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	agg, err := libstate.NewAggregatorV3(ctx, dirs.SnapHistory, dirs.Tmp, ethconfig.HistoryV3AggregationStep, db, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = agg.OpenFolder(); err != nil {
+		agg.Close()
+		return nil, nil, err
+	}
+	chainConfig := fromdb.ChainConfig(db)
+	tdb, err := temporal.New(db, agg, systemcontracts.SystemContractCodeLookup[chainConfig.ChainName])
+	if err != nil {
+		agg.Close()
+		return nil, nil, err
+	}
+	return tdb, func() { agg.Close() }, nil
+}
+
+// createExportFile creates the file, gzipped if its name ends with .gz. Closing the returned writer closes the file.
+func createExportFile(fn string) (io.WriteCloser, error) {
+	fh, err := os.Create(fn)
+	if err != nil {
+		return nil, err
+	}
+	w := &exportFile{fh: fh, buf: bufio.NewWriter(fh)}
+	w.w = w.buf
+	if strings.HasSuffix(fn, ".gz") {
+		w.gz = gzip.NewWriter(w.buf)
+		w.w = w.gz
+	}
+	return w, nil
+}
+
+type exportFile struct {
+	fh  *os.File
+	buf *bufio.Writer
+	gz  *gzip.Writer
+	w   io.Writer
+}
+
+func (f *exportFile) Write(p []byte) (int, error) { return f.w.Write(p) }
+
+func (f *exportFile) Close() error {
+	if f.gz != nil {
+		if err := f.gz.Close(); err != nil {
+			f.fh.Close()
+			return err
+		}
+	}
+	if err := f.buf.Flush(); err != nil {
+		f.fh.Close()
+		return err
+	}
+	return f.fh.Close()
+}
+
+func exportRLP(ctx context.Context, tx kv.Tx, blockReader services.FullBlockReader, fn string, from, to uint64, logger log.Logger) error {
+	logger.Info("Exporting blockchain", "file", fn, "from", from, "to", to)
+	w, err := createExportFile(fn)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+	for blockNum := from; blockNum <= to; blockNum++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-logEvery.C:
+			logger.Info("Exporting blockchain", "block", blockNum)
+		default:
+		}
+		block, err := blockReader.BlockByNumber(ctx, tx, blockNum)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("block %d not found", blockNum)
+		}
+		if err := rlp.Encode(w, block); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	logger.Info("Exported blockchain", "file", fn, "blocks", to-from+1)
+	return nil
+}
+
+// firstPoSBlock returns the first block of [from, to] produced after the merge, to+1 if none. Blocks produced after
+// the merge have no difficulty.
+func firstPoSBlock(ctx context.Context, tx kv.Tx, blockReader services.FullBlockReader, from, to uint64) (uint64, error) {
+	var searchErr error
+	n := sort.Search(int(to-from+1), func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		blockNum := from + uint64(i)
+		header, err := blockReader.HeaderByNumber(ctx, tx, blockNum)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		if header == nil {
+			searchErr = fmt.Errorf("header %d not found", blockNum)
+			return true
+		}
+		return blockNum > 0 && header.Difficulty.Sign() == 0
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+	return from + uint64(n), nil
+}
+
+func exportEra1(ctx context.Context, tx kv.Tx, blockReader services.FullBlockReader, network, dir string, from, to uint64, logger log.Logger) error {
+	if from%era1.BlocksPerFile != 0 {
+		return fmt.Errorf("era1 export must start at a multiple of %d, not at block %d", era1.BlocksPerFile, from)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for start := from; start <= to; start += era1.BlocksPerFile {
+		end := start + era1.BlocksPerFile - 1
+		if end > to {
+			end = to
+		}
+		if err := exportEra1File(ctx, tx, blockReader, network, dir, start, end, logger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportEra1File writes the era1 file of the blocks [start, end], named after its accumulator once complete.
+func exportEra1File(ctx context.Context, tx kv.Tx, blockReader services.FullBlockReader, network, dir string, start, end uint64, logger log.Logger) (err error) {
+	epoch := start / era1.BlocksPerFile
+	tmpName := filepath.Join(dir, fmt.Sprintf("%s-%05d.era1.tmp", network, epoch))
+	f, err := os.Create(tmpName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmpName)
+		}
+	}()
+	buf := bufio.NewWriter(f)
+	w, err := era1.NewWriter(buf, start)
+	if err != nil {
+		return err
+	}
+	for blockNum := start; blockNum <= end; blockNum++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		block, err := blockReader.BlockByNumber(ctx, tx, blockNum)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("block %d not found", blockNum)
+		}
+		td, err := rawdb.ReadTd(tx, block.Hash(), blockNum)
+		if err != nil {
+			return err
+		}
+		if td == nil {
+			return fmt.Errorf("total difficulty of block %d not found", blockNum)
+		}
+		receipts, err := exportReceipts(ctx, tx, blockReader, block)
+		if err != nil {
+			return err
+		}
+		header, err := rlp.EncodeToBytes(block.Header())
+		if err != nil {
+			return err
+		}
+		body, err := rlp.EncodeToBytes(block.Body())
+		if err != nil {
+			return err
+		}
+		if err := w.AddBlock(blockNum, block.Hash(), header, body, receipts, td); err != nil {
+			return err
+		}
+	}
+	root, err := w.Finish()
+	if err != nil {
+		return err
+	}
+	if err = buf.Flush(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	name := filepath.Join(dir, era1.Filename(network, epoch, root))
+	if err = os.Rename(tmpName, name); err != nil {
+		return err
+	}
+	logger.Info("Exported era1 file", "file", name, "from", start, "to", end, "accumulator", root)
+	return nil
+}
+
+// exportReceipts returns the RLP encoded consensus receipts of the block, from the database or the snapshots.
+func exportReceipts(ctx context.Context, tx kv.Tx, blockReader services.FullBlockReader, block *types.Block) ([]byte, error) {
+	receipts := types.Receipts{}
+	if len(block.Transactions()) > 0 {
+		receipts = rawdb.ReadRawReceipts(tx, block.NumberU64())
+		if receipts == nil {
+			var err error
+			if receipts, err = blockReader.RawReceipts(ctx, block.NumberU64()); err != nil {
+				return nil, err
+			}
+		}
+		if len(receipts) != len(block.Transactions()) {
+			return nil, fmt.Errorf("receipts of block %d not found, they may be pruned", block.NumberU64())
+		}
+	}
+	// neither the type nor the bloom are stored: the type is the one of the transaction, the bloom is derived from
+	// the logs
+	for i, receipt := range receipts {
+		receipt.Type = block.Transactions()[i].Type()
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	}
+	return rlp.EncodeToBytes(receipts)
+}
+
+func dumpState(cliCtx *cli.Context) error {
+	var logger log.Logger
+	var err error
+	if logger, err = debug.Setup(cliCtx, true /* rootLogger */); err != nil {
+		return err
+	}
+	ctx, cancel := libcommon.RootContext()
+	defer cancel()
+
+	db, snapshots, err := openReadonlyDB(cliCtx, logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer snapshots.Close()
+	historyV3 := fromdb.HistV3(db)
+	if historyV3 {
+		var closeAgg func()
+		if db, closeAgg, err = openTemporalDB(ctx, cliCtx, db, logger); err != nil {
+			return err
+		}
+		defer closeAgg()
+	}
+
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	executed, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return err
+	}
+	blockNum := executed
+	if cliCtx.IsSet(DumpStateBlockFlag.Name) {
+		blockNum = cliCtx.Uint64(DumpStateBlockFlag.Name)
+	}
+	if blockNum > executed {
+		return fmt.Errorf("state of block %d is not available, the node executed up to block %d", blockNum, executed)
+	}
+
+	var w io.Writer = os.Stdout
+	var f io.WriteCloser
+	if cliCtx.NArg() > 0 {
+		if f, err = createExportFile(cliCtx.Args().First()); err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	logger.Info("Dumping state", "block", blockNum)
+
+	excludeCode, excludeStorage := cliCtx.Bool(DumpStateNoCodeFlag.Name), cliCtx.Bool(DumpStateNoStorageFlag.Name)
+	dumper := state.NewDumper(tx, blockNum, historyV3)
+	if cliCtx.Bool(DumpStateJSONLFlag.Name) {
+		if _, err := dumper.DumpToCollector(state.NewIterativeDumpCollector(json.NewEncoder(w)), excludeCode, excludeStorage, libcommon.Address{}, 0); err != nil {
+			return err
+		}
+	} else {
+		dump := &streamingDump{w: w}
+		if _, err := dumper.DumpToCollector(dump, excludeCode, excludeStorage, libcommon.Address{}, 0); err != nil {
+			return err
+		}
+		if err := dump.finish(); err != nil {
+			return err
+		}
+	}
+	if f != nil {
+		return f.Close()
+	}
+	return nil
+}
+
+// streamingDump writes the dump as the JSON object of state.Dump, an account at a time rather than collecting them
+// all in memory. Its accounts are written in the order the dumper visits them, the one of their addresses.
+type streamingDump struct {
+	w        io.Writer
+	accounts int
+	err      error
+}
+
+func (d *streamingDump) write(s string) {
+	if d.err == nil {
+		_, d.err = io.WriteString(d.w, s)
+	}
+}
+
+// OnRoot implements state.DumpCollector interface
+func (d *streamingDump) OnRoot(root libcommon.Hash) {
+	d.write(fmt.Sprintf("{\n    \"root\": \"%x\",\n    \"accounts\": {", root))
+}
+
+// OnAccount implements state.DumpCollector interface
+func (d *streamingDump) OnAccount(addr libcommon.Address, account state.DumpAccount) {
+	if d.err != nil {
+		return
+	}
+	key, err := json.Marshal(addr)
+	if err != nil {
+		d.err = err
+		return
+	}
+	value, err := json.MarshalIndent(account, "        ", "    ")
+	if err != nil {
+		d.err = err
+		return
+	}
+	if d.accounts > 0 {
+		d.write(",")
+	}
+	d.write("\n        " + string(key) + ": " + string(value))
+	d.accounts++
+}
+
+// finish closes the JSON object and returns the first error writing the dump.
+func (d *streamingDump) finish() error {
+	if d.accounts > 0 {
+		d.write("\n    ")
+	}
+	d.write("}\n}\n")
+	return d.err
+}
@@ -51,6 +51,8 @@ func MakeApp(name string, action cli.ActionFunc, cliFlags []cli.Flag) *cli.App {
 	app.Commands = []*cli.Command{
 		&initCommand,
 		&importCommand,
+		&exportCommand,
+		&verifyCommand,
 		&snapshotCommand,
 		&supportCommand,
 		//&backupCommand,
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/datadir"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/hack/tool/fromdb"
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/eth/ethconfig/estimate"
+	"github.com/ledgerwatch/erigon/eth/ethconsensusconfig"
+	"github.com/ledgerwatch/erigon/eth/integrity"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/debug"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+)
+
+var verifyCommand = cli.Command{
+	Action: MigrateFlags(verifyBlocks),
+	Name:   "verify",
+	Usage:  "Verify the integrity of a range of blocks",
+	Flags: []cli.Flag{
+		&utils.DataDirFlag,
+		&VerifyFromFlag,
+		&VerifyToFlag,
+		&VerifyChecksFlag,
+		&VerifyWorkersFlag,
+		&VerifyProgressFlag,
+		&VerifyRestartFlag,
+	},
+	Description: `
+The verify command re-checks the blocks of the range: the header chain hashes, the transactions,
+uncles and withdrawals roots of the bodies, the senders, the receipts roots by re-executing the
+blocks, the snapshot indices of the frozen blocks and the transaction lookups.
+
+Chunks of blocks are verified in parallel, and the progress is saved after each of them so that an
+interrupted verification of the same range and checks resumes where it stopped, unless --restart is
+given. A completed verification is run again from the start. Failed checks are logged and kept in
+the progress file, and make the command exit with an error.`,
+}
+
+var (
+	VerifyFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block to verify",
+		Value: 0,
+	}
+	VerifyToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block to verify. Zero - means the last executed block.",
+		Value: 0,
+	}
+	VerifyChecksFlag = cli.StringFlag{
+		Name:  "checks",
+		Usage: "Comma separated checks to run: " + strings.Join(integrity.AllBlockChecks, ","),
+		Value: strings.Join(integrity.AllBlockChecks, ","),
+	}
+	VerifyWorkersFlag = cli.IntFlag{
+		Name:  "workers",
+		Usage: "Amount of chunks of blocks verified in parallel",
+		Value: estimate.AlmostAllCPUs(),
+	}
+	VerifyProgressFlag = cli.PathFlag{
+		Name:  "progress",
+		Usage: "File saving the progress of the verification. Defaults to verify_progress.json in the datadir.",
+	}
+	VerifyRestartFlag = cli.BoolFlag{
+		Name:  "restart",
+		Usage: "Start over instead of resuming an interrupted verification",
+	}
+)
+
+func verifyBlocks(cliCtx *cli.Context) error {
+	var logger log.Logger
+	var err error
+	if logger, err = debug.Setup(cliCtx, true /* rootLogger */); err != nil {
+		return err
+	}
+	ctx, cancel := libcommon.RootContext()
+	defer cancel()
+
+	db, snapshots, err := openReadonlyDB(cliCtx, logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	defer snapshots.Close()
+	blockReader := snapshotsync.NewBlockReader(snapshots)
+	chainConfig := fromdb.ChainConfig(db)
+	// receipts are re-executed on the historical state
+	if fromdb.HistV3(db) {
+		var closeAgg func()
+		if db, closeAgg, err = openTemporalDB(ctx, cliCtx, db, logger); err != nil {
+			return err
+		}
+		defer closeAgg()
+	}
+
+	to := cliCtx.Uint64(VerifyToFlag.Name)
+	if to == 0 {
+		if err := db.View(ctx, func(tx kv.Tx) error {
+			to, err = stages.GetStageProgress(tx, stages.Execution)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	progressFile := cliCtx.Path(VerifyProgressFlag.Name)
+	if progressFile == "" {
+		progressFile = filepath.Join(datadir.New(cliCtx.String(utils.DataDirFlag.Name)).DataDir, "verify_progress.json")
+	}
+
+	report, err := integrity.VerifyBlocks(ctx, db, blockReader, integrity.BlocksCfg{
+		From:         cliCtx.Uint64(VerifyFromFlag.Name),
+		To:           to,
+		Checks:       strings.Split(cliCtx.String(VerifyChecksFlag.Name), ","),
+		Workers:      cliCtx.Int(VerifyWorkersFlag.Name),
+		ProgressFile: progressFile,
+		Restart:      cliCtx.Bool(VerifyRestartFlag.Name),
+		ChainConfig:  chainConfig,
+		Engine:       ethconsensusconfig.CreateConsensusEngineBareBones(chainConfig, logger),
+	}, logger)
+	if err != nil {
+		return err
+	}
+	logger.Info("Verification done", "from", report.From, "to", report.To, "failures", len(report.Failures), "progress", progressFile)
+	if len(report.Failures) > 0 {
+		return fmt.Errorf("%d checks failed, see %s", len(report.Failures), progressFile)
+	}
+	return nil
+}
@@ -62,6 +62,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.HTTPApiFlag,
 	&utils.WSEnabledFlag,
 	&utils.WsCompressionFlag,
+	&utils.WSAllowedOriginsFlag,
 	&utils.HTTPTraceFlag,
 	&utils.StateCacheFlag,
 	&utils.RpcBatchConcurrencyFlag,
@@ -72,6 +73,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.RpcGasCapFlag,
 	&utils.RpcBatchLimit,
 	&utils.RpcReturnDataLimit,
+	&utils.RpcMaxGetProofRewindBlockCount,
 	&utils.TxpoolApiAddrFlag,
 	&utils.TraceMaxtracesFlag,
 	&HTTPReadTimeoutFlag,
@@ -387,6 +387,7 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logg
 		EvmCallTimeout: ctx.Duration(EvmCallTimeoutFlag.Name),
 
 		WebsocketEnabled:     ctx.IsSet(utils.WSEnabledFlag.Name),
+		WebsocketOrigins:     utils.SplitAndTrim(ctx.String(utils.WSAllowedOriginsFlag.Name)),
 		RpcBatchConcurrency:  ctx.Uint(utils.RpcBatchConcurrencyFlag.Name),
 		RpcStreamingDisable:  ctx.Bool(utils.RpcStreamingDisableFlag.Name),
 		DBReadConcurrency:    ctx.Int(utils.DBReadConcurrencyFlag.Name),
@@ -397,6 +398,8 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config, logger log.Logg
 		BatchLimit:           ctx.Int(utils.RpcBatchLimit.Name),
 		ReturnDataLimit:      ctx.Int(utils.RpcReturnDataLimit.Name),
 
+		MaxGetProofRewindBlockCount: ctx.Int(utils.RpcMaxGetProofRewindBlockCount.Name),
+
 		TxPoolApiAddr: ctx.String(utils.TxpoolApiAddrFlag.Name),
 
 		StateCache: kvcache.DefaultCoherentConfig,
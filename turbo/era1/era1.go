@@ -0,0 +1,156 @@
+// Package era1 writes era1 files, the e2store archives of the execution blocks of the chain before the merge.
+package era1
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/common/e2store"
+)
+
+// An era1 file holds up to BlocksPerFile consecutive execution blocks, laid out as:
+//
+//	version | (header | body | receipts | total-difficulty)* | accumulator | block-index
+//
+// where headers, bodies and receipts are RLP encoded and snappy framed, total difficulties are 32 bytes little endian
+// and the accumulator is the SSZ root of the (hash, total difficulty) records of the blocks of the file.
+const BlocksPerFile = 8192
+
+var (
+	typeCompressedHeader   = [2]byte{0x03, 0x00}
+	typeCompressedBody     = [2]byte{0x04, 0x00}
+	typeCompressedReceipts = [2]byte{0x05, 0x00}
+	typeTotalDifficulty    = [2]byte{0x06, 0x00}
+	typeAccumulator        = [2]byte{0x07, 0x00}
+	typeBlockIndex         = [2]byte{0x66, 0x32}
+)
+
+// Filename is the name of the era1 file of the given epoch: <network>-<epoch>-<short accumulator root>.era1.
+func Filename(network string, epoch uint64, accumulatorRoot libcommon.Hash) string {
+	return fmt.Sprintf("%s-%05d-%x.era1", network, epoch, accumulatorRoot[:4])
+}
+
+// HeaderAccumulator is the SSZ root of List[HeaderRecord, BlocksPerFile], a HeaderRecord being the container
+// of the block hash and of the total difficulty of the chain at the block.
+func HeaderAccumulator(hashes []libcommon.Hash, tds []*big.Int) (libcommon.Hash, error) {
+	if len(hashes) != len(tds) {
+		return libcommon.Hash{}, fmt.Errorf("%d block hashes for %d total difficulties", len(hashes), len(tds))
+	}
+	if len(hashes) > BlocksPerFile {
+		return libcommon.Hash{}, fmt.Errorf("%d blocks do not fit in an accumulator", len(hashes))
+	}
+	records := make([][32]byte, len(hashes))
+	for i, hash := range hashes {
+		td, err := encodeTotalDifficulty(tds[i])
+		if err != nil {
+			return libcommon.Hash{}, err
+		}
+		records[i] = utils.Keccak256(hash[:], td)
+	}
+	root, err := merkle_tree.MerkleizeVector(records, BlocksPerFile)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	length := merkle_tree.Uint64Root(uint64(len(hashes)))
+	return utils.Keccak256(root[:], length[:]), nil
+}
+
+// encodeTotalDifficulty encodes the total difficulty as a little endian uint256.
+func encodeTotalDifficulty(td *big.Int) ([]byte, error) {
+	if td.Sign() < 0 || td.BitLen() > 256 {
+		return nil, fmt.Errorf("total difficulty %d is not a uint256", td)
+	}
+	encoded := td.FillBytes(make([]byte, 32))
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return encoded, nil
+}
+
+// Writer writes an era1 file. Its blocks are added in order, then Finish writes its accumulator and index.
+type Writer struct {
+	e2           *e2store.Writer
+	startBlock   uint64
+	blockOffsets []int64
+	hashes       []libcommon.Hash
+	tds          []*big.Int
+}
+
+// NewWriter starts the era1 file whose first block is startBlock.
+func NewWriter(w io.Writer, startBlock uint64) (*Writer, error) {
+	e := &Writer{
+		e2:         e2store.NewWriter(w),
+		startBlock: startBlock,
+	}
+	if _, err := e.e2.Write(e2store.TypeVersion, nil); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// AddBlock adds the RLP encoded header, body and receipts of the block following the previously added one.
+func (e *Writer) AddBlock(number uint64, hash libcommon.Hash, header, body, receipts []byte, td *big.Int) error {
+	if expected := e.startBlock + uint64(len(e.blockOffsets)); number != expected {
+		return fmt.Errorf("block %d added to era1 file at block %d", number, expected)
+	}
+	if len(e.blockOffsets) == BlocksPerFile {
+		return fmt.Errorf("era1 file starting at block %d is full", e.startBlock)
+	}
+	encodedTd, err := encodeTotalDifficulty(td)
+	if err != nil {
+		return err
+	}
+	var offset int64
+	for i, entry := range []struct {
+		typ  [2]byte
+		data []byte
+	}{{typeCompressedHeader, header}, {typeCompressedBody, body}, {typeCompressedReceipts, receipts}} {
+		data, err := e2store.CompressFramed(entry.data)
+		if err != nil {
+			return err
+		}
+		entryOffset, err := e.e2.Write(entry.typ, data)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			offset = entryOffset
+		}
+	}
+	if _, err := e.e2.Write(typeTotalDifficulty, encodedTd); err != nil {
+		return err
+	}
+	e.blockOffsets = append(e.blockOffsets, offset)
+	e.hashes = append(e.hashes, hash)
+	e.tds = append(e.tds, new(big.Int).Set(td))
+	return nil
+}
+
+// Finish writes the accumulator and the block index of the file and returns the accumulator root.
+func (e *Writer) Finish() (libcommon.Hash, error) {
+	if len(e.blockOffsets) == 0 {
+		return libcommon.Hash{}, fmt.Errorf("era1 file starting at block %d has no blocks", e.startBlock)
+	}
+	root, err := HeaderAccumulator(e.hashes, e.tds)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	if _, err := e.e2.Write(typeAccumulator, root[:]); err != nil {
+		return libcommon.Hash{}, err
+	}
+	// offsets are relative to the index entry itself
+	indexOffset := e.e2.Offset()
+	offsets := make([]int64, len(e.blockOffsets))
+	for i, offset := range e.blockOffsets {
+		offsets[i] = offset - indexOffset
+	}
+	if _, err := e.e2.Write(typeBlockIndex, e2store.EncodeIndex(e.startBlock, offsets)); err != nil {
+		return libcommon.Hash{}, err
+	}
+	return root, nil
+}
@@ -0,0 +1,80 @@
+package era1_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/golang/snappy"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/turbo/era1"
+)
+
+func TestWriter(t *testing.T) {
+	const startBlock = era1.BlocksPerFile
+	var buf bytes.Buffer
+	w, err := era1.NewWriter(&buf, startBlock)
+	require.NoError(t, err)
+
+	var hashes []libcommon.Hash
+	var tds []*big.Int
+	for i := uint64(0); i < 3; i++ {
+		hash := libcommon.Hash{byte(i + 1)}
+		td := big.NewInt(int64(1000 + i))
+		require.NoError(t, w.AddBlock(startBlock+i, hash, []byte{0xc1, byte(i)}, []byte{0xc0}, []byte{0xc0}, td))
+		hashes = append(hashes, hash)
+		tds = append(tds, td)
+	}
+	require.Error(t, w.AddBlock(startBlock+5, libcommon.Hash{}, nil, nil, nil, big.NewInt(0)))
+	root, err := w.Finish()
+	require.NoError(t, err)
+	expected, err := era1.HeaderAccumulator(hashes, tds)
+	require.NoError(t, err)
+	require.Equal(t, expected, root)
+
+	data := buf.Bytes()
+	require.Equal(t, []byte{0x65, 0x32, 0, 0, 0, 0, 0, 0}, data[:8])
+
+	// block index: starting number, one offset per block and their count
+	indexStart := len(data) - (8 + 16 + 8*3)
+	require.Equal(t, []byte{0x66, 0x32}, data[indexStart:indexStart+2])
+	index := data[indexStart+8:]
+	require.Equal(t, uint64(startBlock), binary.LittleEndian.Uint64(index))
+	require.Equal(t, uint64(3), binary.LittleEndian.Uint64(index[len(index)-8:]))
+
+	// the accumulator precedes the index
+	accumulatorStart := indexStart - (8 + 32)
+	require.Equal(t, []byte{0x07, 0x00}, data[accumulatorStart:accumulatorStart+2])
+	require.Equal(t, root[:], data[accumulatorStart+8:indexStart])
+
+	for i := 0; i < 3; i++ {
+		offset := indexStart + int(int64(binary.LittleEndian.Uint64(index[8+8*i:])))
+		require.Equal(t, []byte{0x03, 0x00}, data[offset:offset+2])
+		length := int(binary.LittleEndian.Uint32(data[offset+2:]))
+		header, err := io.ReadAll(snappy.NewReader(bytes.NewReader(data[offset+8 : offset+8+length])))
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xc1, byte(i)}, header)
+	}
+
+	_, err = era1.HeaderAccumulator(hashes, tds[:2])
+	require.Error(t, err)
+}
+
+// The accumulators of the first blocks of mainnet, computed as the SSZ root of their HeaderRecord list by an
+// independent implementation of the era1 specification.
+func TestHeaderAccumulatorMainnet(t *testing.T) {
+	genesis := libcommon.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+	block1 := libcommon.HexToHash("0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6")
+	genesisTd, block1Td := big.NewInt(17_179_869_184), big.NewInt(34_351_349_760)
+
+	root, err := era1.HeaderAccumulator([]libcommon.Hash{genesis}, []*big.Int{genesisTd})
+	require.NoError(t, err)
+	require.Equal(t, libcommon.HexToHash("0xc26dcbaf5b6a0d60410dec217bc97d7ff112e1fa48b5809ec2b9ff33b39f07f2"), root)
+	root, err = era1.HeaderAccumulator([]libcommon.Hash{genesis, block1}, []*big.Int{genesisTd, block1Td})
+	require.NoError(t, err)
+	require.Equal(t, libcommon.HexToHash("0x31aefe616a8ca81a1978a6a494b9ba9dbeac6a5e25811c3bcf5fc3762c337f18"), root)
+}
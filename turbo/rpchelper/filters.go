@@ -354,6 +354,15 @@ func (ff *Filters) SubscribePendingTxs(size int) (<-chan []types.Transaction, Pe
 	return sub.ch, id
 }
 
+// SubscribeFilteredPendingTxs is SubscribePendingTxs only notified of the transactions match accepts, batches
+// without any are not sent at all.
+func (ff *Filters) SubscribeFilteredPendingTxs(size int, match func(types.Transaction) bool) (<-chan []types.Transaction, PendingTxsSubID) {
+	id := PendingTxsSubID(generateSubscriptionID())
+	sub := newChanSub[[]types.Transaction](size)
+	ff.pendingTxsSubs.Put(id, &filteredTxsSub{Sub: sub, match: match})
+	return sub.ch, id
+}
+
 func (ff *Filters) UnsubscribePendingTxs(id PendingTxsSubID) bool {
 	ch, ok := ff.pendingTxsSubs.Get(id)
 	if !ok {
@@ -1,15 +1,25 @@
 package rpchelper
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
 	"testing"
 
+	"github.com/holiman/uint256"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/stretchr/testify/require"
 
 	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/filters"
 	"github.com/ledgerwatch/log/v3"
 )
@@ -341,3 +351,50 @@ func TestFilters_SubscribeLogsGeneratesCorrectLogFilterRequest(t *testing.T) {
 		t.Error("5: expected topics to be empty")
 	}
 }
+
+func TestFilters_FilteredPendingTxsSubscription(t *testing.T) {
+	f := New(context.TODO(), nil, nil, nil, func() {}, log.New())
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+
+	var crit PendingTxsCriteria
+	err = json.Unmarshal([]byte(fmt.Sprintf(`{"from":"%s","to":["%s"],"minTip":"0x2","methodSelector":"0xa9059cbb"}`, sender.Hex(), address1.Hex())), &crit)
+	require.NoError(t, err)
+	matching, _ := f.SubscribeFilteredPendingTxs(10, func(txn types.Transaction) bool { return crit.Match(txn, signer) })
+	all, _ := f.SubscribePendingTxs(10)
+
+	newTx := func(nonce uint64, to libcommon.Address, tip uint64, data []byte, key *ecdsa.PrivateKey) []byte {
+		txn, err := types.SignTx(types.NewTransaction(nonce, to, uint256.NewInt(0), 50000, uint256.NewInt(tip), data), *signer, key)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, txn.MarshalBinary(&buf))
+		return buf.Bytes()
+	}
+	transfer := libcommon.FromHex("0xa9059cbb0000")
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	// only the batches with a matching transaction are sent, stripped of the others
+	f.OnNewTx(&txpool.OnAddReply{RplTxs: [][]byte{
+		newTx(0, address1, 1, transfer, key),                        // tip too low
+		newTx(1, libcommon.Address{1}, 2, transfer, key),            // other recipient
+		newTx(2, address1, 2, libcommon.FromHex("0x095ea7b3"), key), // other method
+		newTx(0, address1, 2, transfer, otherKey),                   // other sender
+	}})
+	f.OnNewTx(&txpool.OnAddReply{RplTxs: [][]byte{
+		newTx(3, address1, 3, transfer, key),
+		newTx(4, address1, 1, transfer, key),
+	}})
+
+	require.Len(t, all, 2)
+	require.Len(t, matching, 1)
+	txs := <-matching
+	require.Len(t, txs, 1)
+	require.Equal(t, uint64(3), txs[0].GetNonce())
+
+	err = json.Unmarshal([]byte(`{"methodSelector":"0xa9059c"}`), &crit)
+	require.Error(t, err)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv"
 
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/event"
 	"github.com/ledgerwatch/erigon/p2p"
 )
 
@@ -34,3 +35,12 @@ type ApiBackend interface {
 	EngineGetPayloadBodiesByHashV1(ctx context.Context, request *remote.EngineGetPayloadBodiesByHashV1Request) (*remote.EngineGetPayloadBodiesV1Response, error)
 	EngineGetPayloadBodiesByRangeV1(ctx context.Context, request *remote.EngineGetPayloadBodiesByRangeV1Request) (*remote.EngineGetPayloadBodiesV1Response, error)
 }
+
+// PeerManager - interface to add and remove the peers of the sentries running in process
+type PeerManager interface {
+	AddPeer(url string) (bool, error)
+	RemovePeer(url string) (bool, error)
+	AddTrustedPeer(url string) (bool, error)
+	RemoveTrustedPeer(url string) (bool, error)
+	SubscribePeerEvents(ch chan *p2p.PeerEvent) (event.Subscription, error)
+}
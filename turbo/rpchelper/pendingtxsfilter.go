@@ -0,0 +1,157 @@
+package rpchelper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// methodSelectorLength is the length of the selector prefixing the call data of ABI encoded calls
+const methodSelectorLength = 4
+
+// PendingTxsCriteria selects the pending transactions a subscription is notified of. A transaction has to match
+// every field set, and a field listing several values matches when any of them does. Each of From, To and
+// MethodSelector can be given as a single value or as a list.
+type PendingTxsCriteria struct {
+	From           []libcommon.Address // senders
+	To             []libcommon.Address // recipients, contract creations never match
+	MinTip         *uint256.Int        // priority fee per gas offered, gas price for legacy transactions
+	MethodSelector [][]byte            // first 4 bytes of the call data
+}
+
+// UnmarshalJSON parses {"from", "to", "minTip", "methodSelector"}.
+func (c *PendingTxsCriteria) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		From           json.RawMessage `json:"from"`
+		To             json.RawMessage `json:"to"`
+		MinTip         *hexutil.Big    `json:"minTip"`
+		MethodSelector json.RawMessage `json:"methodSelector"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var err error
+	if c.From, err = unmarshalOneOrMany[libcommon.Address](raw.From); err != nil {
+		return fmt.Errorf("invalid from: %w", err)
+	}
+	if c.To, err = unmarshalOneOrMany[libcommon.Address](raw.To); err != nil {
+		return fmt.Errorf("invalid to: %w", err)
+	}
+	if raw.MinTip != nil {
+		tip, overflow := uint256.FromBig(raw.MinTip.ToInt())
+		if overflow || raw.MinTip.ToInt().Sign() < 0 {
+			return fmt.Errorf("invalid minTip: %s", raw.MinTip)
+		}
+		c.MinTip = tip
+	}
+	selectors, err := unmarshalOneOrMany[hexutility.Bytes](raw.MethodSelector)
+	if err != nil {
+		return fmt.Errorf("invalid methodSelector: %w", err)
+	}
+	c.MethodSelector = nil
+	for _, selector := range selectors {
+		if len(selector) != methodSelectorLength {
+			return fmt.Errorf("invalid methodSelector: %s is not %d bytes long", selector, methodSelectorLength)
+		}
+		c.MethodSelector = append(c.MethodSelector, selector)
+	}
+	return nil
+}
+
+func unmarshalOneOrMany[T any](data json.RawMessage) ([]T, error) {
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	if data[0] == '[' {
+		var many []T
+		if err := json.Unmarshal(data, &many); err != nil {
+			return nil, err
+		}
+		return many, nil
+	}
+	var one T
+	if err := json.Unmarshal(data, &one); err != nil {
+		return nil, err
+	}
+	return []T{one}, nil
+}
+
+// Match reports whether txn satisfies the criteria, signer being used to recover its sender when needed.
+func (c *PendingTxsCriteria) Match(txn types.Transaction, signer *types.Signer) bool {
+	if c == nil {
+		return true
+	}
+	if len(c.To) > 0 {
+		to := txn.GetTo()
+		if to == nil || !containsAddress(c.To, *to) {
+			return false
+		}
+	}
+	if c.MinTip != nil && txn.GetTip().Lt(c.MinTip) {
+		return false
+	}
+	if len(c.MethodSelector) > 0 {
+		data := txn.GetData()
+		if len(data) < methodSelectorLength {
+			return false
+		}
+		var found bool
+		for _, selector := range c.MethodSelector {
+			if bytes.Equal(data[:methodSelectorLength], selector) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	// recovering the sender is the costliest check, it comes last
+	if len(c.From) > 0 {
+		from, ok := txn.GetSender()
+		if !ok {
+			var err error
+			if from, err = txn.Sender(*signer); err != nil {
+				return false
+			}
+		}
+		if !containsAddress(c.From, from) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAddress(addresses []libcommon.Address, addr libcommon.Address) bool {
+	for _, a := range addresses {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// filteredTxsSub only forwards to its subscription the transactions match accepts.
+type filteredTxsSub struct {
+	Sub[[]types.Transaction]
+	match func(types.Transaction) bool
+}
+
+func (s *filteredTxsSub) Send(txs []types.Transaction) {
+	matching := make([]types.Transaction, 0, len(txs))
+	for _, txn := range txs {
+		if txn != nil && s.match(txn) {
+			matching = append(matching, txn)
+		}
+	}
+	if len(matching) > 0 {
+		s.Sub.Send(matching)
+	}
+}
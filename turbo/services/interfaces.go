@@ -45,6 +45,12 @@ type TxnReader interface {
 	TxnByIdxInBlock(ctx context.Context, tx kv.Getter, blockNum uint64, i int) (txn types.Transaction, err error)
 	RawTransactions(ctx context.Context, tx kv.Getter, fromBlock, toBlock uint64) (txs [][]byte, err error)
 }
+
+type ReceiptsReader interface {
+	// RawReceipts returns the frozen receipts of the block, nil if they are not frozen. The fields derived from the
+	// block are not set.
+	RawReceipts(ctx context.Context, blockNum uint64) (types.Receipts, error)
+}
 type HeaderAndCanonicalReader interface {
 	HeaderReader
 	CanonicalReader
@@ -62,6 +68,7 @@ type FullBlockReader interface {
 	HeaderReader
 	TxnReader
 	CanonicalReader
+	ReceiptsReader
 
 	Snapshots() BlockSnapshots
 }
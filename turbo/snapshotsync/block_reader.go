@@ -34,6 +34,9 @@ func (r *RemoteBlockReader) CurrentBlock(db kv.Tx) (*types.Block, error) {
 func (r *RemoteBlockReader) RawTransactions(ctx context.Context, tx kv.Getter, fromBlock, toBlock uint64) (txs [][]byte, err error) {
 	panic("not implemented")
 }
+func (r *RemoteBlockReader) RawReceipts(ctx context.Context, blockNum uint64) (types.Receipts, error) {
+	return nil, nil
+}
 func (r *RemoteBlockReader) ReadAncestor(db kv.Getter, hash common.Hash, number, ancestor uint64, maxNonCanonical *uint64) (common.Hash, uint64) {
 	panic("not implemented")
 }
@@ -786,6 +789,66 @@ func (r *BlockReader) IterateFrozenBodies(f func(blockNum, baseTxNum, txAmount u
 	}
 	return nil
 }
+
+// VerifyFrozenBlock checks that the snapshot indices resolve the frozen block of the given canonical hash: its header
+// by number and by hash, its body by number and each of its transactions by hash. frozen is false if the block is
+// not in the snapshots.
+func (r *BlockReader) VerifyFrozenBlock(blockNum uint64, hash common.Hash) (frozen bool, err error) {
+	view := r.sn.View()
+	defer view.Close()
+
+	headerSeg, ok := view.HeadersSegment(blockNum)
+	if !ok {
+		return false, nil
+	}
+	h, buf, err := r.headerFromSnapshot(blockNum, headerSeg, nil)
+	if err != nil {
+		return true, err
+	}
+	if h == nil || h.Hash() != hash {
+		return true, fmt.Errorf("header index of %s does not resolve block %d to %x", headerSeg.seg.FilePath(), blockNum, hash)
+	}
+	if h, err = r.headerFromSnapshotByHash(hash, headerSeg, buf); err != nil {
+		return true, err
+	}
+	if h == nil || h.Number.Uint64() != blockNum {
+		return true, fmt.Errorf("header hash index of %s does not resolve %x to block %d", headerSeg.seg.FilePath(), hash, blockNum)
+	}
+
+	bodySeg, ok := view.BodiesSegment(blockNum)
+	if !ok {
+		return true, fmt.Errorf("no bodies segment for frozen block %d", blockNum)
+	}
+	body, baseTxnID, txsAmount, buf, err := r.bodyFromSnapshot(blockNum, bodySeg, buf)
+	if err != nil {
+		return true, err
+	}
+	if body == nil {
+		return true, fmt.Errorf("body index of %s does not resolve block %d", bodySeg.seg.FilePath(), blockNum)
+	}
+	txnSeg, ok := view.TxsSegment(blockNum)
+	if !ok {
+		return true, fmt.Errorf("no transactions segment for frozen block %d", blockNum)
+	}
+	txs, _, err := r.txsFromSnapshot(baseTxnID, txsAmount, txnSeg, buf)
+	if err != nil {
+		return true, err
+	}
+	if txs == nil {
+		return true, fmt.Errorf("transactions index of %s does not resolve the transactions of block %d", txnSeg.Seg.FilePath(), blockNum)
+	}
+	for i, txn := range txs {
+		found, txnBlockNum, _, err := r.txnByHash(txn.Hash(), []*TxnSegment{txnSeg}, nil)
+		if err != nil {
+			return true, err
+		}
+		if found == nil || txnBlockNum != blockNum {
+			return true, fmt.Errorf("transaction hash indices of %s do not resolve transaction %d of block %d", txnSeg.Seg.FilePath(), i, blockNum)
+		}
+	}
+	return true, nil
+}
+
 func (r *BlockReader) BadHeaderNumber(ctx context.Context, tx kv.Getter, hash common.Hash) (blockHeight *uint64, err error) {
 	return rawdb.ReadBadHeaderNumber(tx, hash)
 }
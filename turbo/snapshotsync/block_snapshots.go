@@ -12,6 +12,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -330,9 +332,12 @@ type RoSnapshots struct {
 	indicesReady  atomic.Bool
 	segmentsReady atomic.Bool
 
-	Headers *headerSegments
-	Bodies  *bodySegments
-	Txs     *txnSegments
+	Headers   *headerSegments
+	Bodies    *bodySegments
+	Txs       *txnSegments
+	Receipts  *receiptSegments
+	BorEvents *borEventSegments
+	BorSpans  *borSpanSegments
 
 	dir         string
 	segmentsMax atomic.Uint64 // all types of .seg files are available - up to this number
@@ -347,7 +352,7 @@ type RoSnapshots struct {
 //   - gaps are not allowed
 //   - segment have [from:to) semantic
 func NewRoSnapshots(cfg ethconfig.Snapshot, snapDir string, logger log.Logger) *RoSnapshots {
-	return &RoSnapshots{dir: snapDir, cfg: cfg, Headers: &headerSegments{}, Bodies: &bodySegments{}, Txs: &txnSegments{}, logger: logger}
+	return &RoSnapshots{dir: snapDir, cfg: cfg, Headers: &headerSegments{}, Bodies: &bodySegments{}, Txs: &txnSegments{}, Receipts: &receiptSegments{}, BorEvents: &borEventSegments{}, BorSpans: &borSpanSegments{}, logger: logger}
 }
 
 func (s *RoSnapshots) Cfg() ethconfig.Snapshot { return s.cfg }
@@ -381,6 +386,12 @@ func (s *RoSnapshots) DisableReadAhead() {
 	defer s.Bodies.lock.RUnlock()
 	s.Txs.lock.RLock()
 	defer s.Txs.lock.RUnlock()
+	s.Receipts.lock.RLock()
+	defer s.Receipts.lock.RUnlock()
+	s.BorEvents.lock.RLock()
+	defer s.BorEvents.lock.RUnlock()
+	s.BorSpans.lock.RLock()
+	defer s.BorSpans.lock.RUnlock()
 	for _, sn := range s.Headers.segments {
 		sn.seg.DisableReadAhead()
 	}
@@ -390,6 +401,15 @@ func (s *RoSnapshots) DisableReadAhead() {
 	for _, sn := range s.Txs.segments {
 		sn.Seg.DisableReadAhead()
 	}
+	for _, sn := range s.Receipts.segments {
+		sn.seg.DisableReadAhead()
+	}
+	for _, sn := range s.BorEvents.segments {
+		sn.seg.DisableReadAhead()
+	}
+	for _, sn := range s.BorSpans.segments {
+		sn.seg.DisableReadAhead()
+	}
 }
 func (s *RoSnapshots) EnableReadAhead() *RoSnapshots {
 	s.Headers.lock.RLock()
@@ -398,6 +418,12 @@ func (s *RoSnapshots) EnableReadAhead() *RoSnapshots {
 	defer s.Bodies.lock.RUnlock()
 	s.Txs.lock.RLock()
 	defer s.Txs.lock.RUnlock()
+	s.Receipts.lock.RLock()
+	defer s.Receipts.lock.RUnlock()
+	s.BorEvents.lock.RLock()
+	defer s.BorEvents.lock.RUnlock()
+	s.BorSpans.lock.RLock()
+	defer s.BorSpans.lock.RUnlock()
 	for _, sn := range s.Headers.segments {
 		sn.seg.EnableReadAhead()
 	}
@@ -407,6 +433,15 @@ func (s *RoSnapshots) EnableReadAhead() *RoSnapshots {
 	for _, sn := range s.Txs.segments {
 		sn.Seg.EnableReadAhead()
 	}
+	for _, sn := range s.Receipts.segments {
+		sn.seg.EnableReadAhead()
+	}
+	for _, sn := range s.BorEvents.segments {
+		sn.seg.EnableReadAhead()
+	}
+	for _, sn := range s.BorSpans.segments {
+		sn.seg.EnableReadAhead()
+	}
 	return s
 }
 func (s *RoSnapshots) EnableMadvWillNeed() *RoSnapshots {
@@ -416,6 +451,12 @@ func (s *RoSnapshots) EnableMadvWillNeed() *RoSnapshots {
 	defer s.Bodies.lock.RUnlock()
 	s.Txs.lock.RLock()
 	defer s.Txs.lock.RUnlock()
+	s.Receipts.lock.RLock()
+	defer s.Receipts.lock.RUnlock()
+	s.BorEvents.lock.RLock()
+	defer s.BorEvents.lock.RUnlock()
+	s.BorSpans.lock.RLock()
+	defer s.BorSpans.lock.RUnlock()
 	for _, sn := range s.Headers.segments {
 		sn.seg.EnableWillNeed()
 	}
@@ -425,6 +466,15 @@ func (s *RoSnapshots) EnableMadvWillNeed() *RoSnapshots {
 	for _, sn := range s.Txs.segments {
 		sn.Seg.EnableWillNeed()
 	}
+	for _, sn := range s.Receipts.segments {
+		sn.seg.EnableWillNeed()
+	}
+	for _, sn := range s.BorEvents.segments {
+		sn.seg.EnableWillNeed()
+	}
+	for _, sn := range s.BorSpans.segments {
+		sn.seg.EnableWillNeed()
+	}
 	return s
 }
 func (s *RoSnapshots) EnableMadvNormal() *RoSnapshots {
@@ -434,6 +484,12 @@ func (s *RoSnapshots) EnableMadvNormal() *RoSnapshots {
 	defer s.Bodies.lock.RUnlock()
 	s.Txs.lock.RLock()
 	defer s.Txs.lock.RUnlock()
+	s.Receipts.lock.RLock()
+	defer s.Receipts.lock.RUnlock()
+	s.BorEvents.lock.RLock()
+	defer s.BorEvents.lock.RUnlock()
+	s.BorSpans.lock.RLock()
+	defer s.BorSpans.lock.RUnlock()
 	for _, sn := range s.Headers.segments {
 		sn.seg.EnableMadvNormal()
 	}
@@ -443,6 +499,15 @@ func (s *RoSnapshots) EnableMadvNormal() *RoSnapshots {
 	for _, sn := range s.Txs.segments {
 		sn.Seg.EnableMadvNormal()
 	}
+	for _, sn := range s.Receipts.segments {
+		sn.seg.EnableMadvNormal()
+	}
+	for _, sn := range s.BorEvents.segments {
+		sn.seg.EnableMadvNormal()
+	}
+	for _, sn := range s.BorSpans.segments {
+		sn.seg.EnableMadvNormal()
+	}
 	return s
 }
 
@@ -489,6 +554,12 @@ func (s *RoSnapshots) Files() (list []string) {
 	defer s.Bodies.lock.RUnlock()
 	s.Txs.lock.RLock()
 	defer s.Txs.lock.RUnlock()
+	s.Receipts.lock.RLock()
+	defer s.Receipts.lock.RUnlock()
+	s.BorEvents.lock.RLock()
+	defer s.BorEvents.lock.RUnlock()
+	s.BorSpans.lock.RLock()
+	defer s.BorSpans.lock.RUnlock()
 	max := s.BlocksAvailable()
 	for _, seg := range s.Bodies.segments {
 		if seg.seg == nil {
@@ -520,6 +591,27 @@ func (s *RoSnapshots) Files() (list []string) {
 		_, fName := filepath.Split(seg.Seg.FilePath())
 		list = append(list, fName)
 	}
+	for _, seg := range s.Receipts.segments {
+		if seg.ranges.from > max {
+			continue
+		}
+		_, fName := filepath.Split(seg.seg.FilePath())
+		list = append(list, fName)
+	}
+	for _, seg := range s.BorEvents.segments {
+		if seg.ranges.from > max {
+			continue
+		}
+		_, fName := filepath.Split(seg.seg.FilePath())
+		list = append(list, fName)
+	}
+	for _, seg := range s.BorSpans.segments {
+		if seg.ranges.from > max {
+			continue
+		}
+		_, fName := filepath.Split(seg.seg.FilePath())
+		list = append(list, fName)
+	}
 	slices.Sort(list)
 	return list
 }
@@ -532,12 +624,36 @@ func (s *RoSnapshots) ReopenList(fileNames []string, optimistic bool) error {
 	defer s.Bodies.lock.Unlock()
 	s.Txs.lock.Lock()
 	defer s.Txs.lock.Unlock()
+	s.Receipts.lock.Lock()
+	defer s.Receipts.lock.Unlock()
+	s.BorEvents.lock.Lock()
+	defer s.BorEvents.lock.Unlock()
+	s.BorSpans.lock.Lock()
+	defer s.BorSpans.lock.Unlock()
 
 	s.closeWhatNotInList(fileNames)
 	var segmentsMax uint64
 	var segmentsMaxSet bool
 Loop:
 	for _, fName := range fileNames {
+		if r, ok := parseExtraSegmentFileName(fName, receiptsSegmentType); ok {
+			if err := s.Receipts.reopen(s.dir, fName, r, optimistic, s.logger); err != nil {
+				return err
+			}
+			continue
+		}
+		if r, ok := parseExtraSegmentFileName(fName, borEventsSegmentType); ok {
+			if err := s.BorEvents.reopen(s.dir, fName, r, optimistic, s.logger); err != nil {
+				return err
+			}
+			continue
+		}
+		if r, ok := parseExtraSegmentFileName(fName, borSpansSegmentType); ok {
+			if err := s.BorSpans.reopen(s.dir, fName, r, optimistic, s.logger); err != nil {
+				return err
+			}
+			continue
+		}
 		f, err := snaptype.ParseFileName(s.dir, fName)
 		if err != nil {
 			s.logger.Warn("invalid segment name", "err", err, "name", fName)
@@ -691,6 +807,15 @@ func (s *RoSnapshots) ReopenFolder() error {
 		_, fName := filepath.Split(f.Path)
 		list = append(list, fName)
 	}
+	for _, segType := range []string{receiptsSegmentType, borEventsSegmentType, borSpansSegmentType} {
+		ranges, err := extraSegmentRanges(s.dir, segType)
+		if err != nil {
+			return err
+		}
+		for _, r := range ranges {
+			list = append(list, extraSegmentFileName(r.from, r.to, segType))
+		}
+	}
 	return s.ReopenList(list, false)
 }
 func (s *RoSnapshots) ReopenWithDB(db kv.RoDB) error {
@@ -713,6 +838,12 @@ func (s *RoSnapshots) Close() {
 	defer s.Bodies.lock.Unlock()
 	s.Txs.lock.Lock()
 	defer s.Txs.lock.Unlock()
+	s.Receipts.lock.Lock()
+	defer s.Receipts.lock.Unlock()
+	s.BorEvents.lock.Lock()
+	defer s.BorEvents.lock.Unlock()
+	s.BorSpans.lock.Lock()
+	defer s.BorSpans.lock.Unlock()
 	s.closeWhatNotInList(nil)
 }
 
@@ -759,6 +890,9 @@ Loop3:
 		sn.close()
 		s.Txs.segments[i] = nil
 	}
+	s.Receipts.closeWhatNotInList(l)
+	s.BorEvents.closeWhatNotInList(l)
+	s.BorSpans.closeWhatNotInList(l)
 	var i int
 	for i = 0; i < len(s.Headers.segments) && s.Headers.segments[i] != nil && s.Headers.segments[i].seg != nil; i++ {
 	}
@@ -801,6 +935,12 @@ func (s *RoSnapshots) PrintDebug() {
 	defer s.Bodies.lock.RUnlock()
 	s.Txs.lock.RLock()
 	defer s.Txs.lock.RUnlock()
+	s.Receipts.lock.RLock()
+	defer s.Receipts.lock.RUnlock()
+	s.BorEvents.lock.RLock()
+	defer s.BorEvents.lock.RUnlock()
+	s.BorSpans.lock.RLock()
+	defer s.BorSpans.lock.RUnlock()
 	fmt.Println("    == Snapshots, Header")
 	for _, sn := range s.Headers.segments {
 		fmt.Printf("%d,  %t\n", sn.ranges.from, sn.idxHeaderHash == nil)
@@ -813,6 +953,18 @@ func (s *RoSnapshots) PrintDebug() {
 	for _, sn := range s.Txs.segments {
 		fmt.Printf("%d,  %t, %t\n", sn.ranges.from, sn.IdxTxnHash == nil, sn.IdxTxnHash2BlockNum == nil)
 	}
+	fmt.Println("    == Snapshots, Receipts")
+	for _, sn := range s.Receipts.segments {
+		fmt.Printf("%d,  %t\n", sn.ranges.from, sn.idxBlockNum == nil)
+	}
+	fmt.Println("    == Snapshots, BorEvents")
+	for _, sn := range s.BorEvents.segments {
+		fmt.Printf("%d,  %t\n", sn.ranges.from, sn.idxEventID == nil)
+	}
+	fmt.Println("    == Snapshots, BorSpans")
+	for _, sn := range s.BorSpans.segments {
+		fmt.Printf("%d,  %t\n", sn.ranges.from, sn.idxSpanID == nil)
+	}
 }
 
 func buildIdx(ctx context.Context, sn snaptype.FileInfo, chainID uint256.Int, tmpDir string, p *background.Progress, lvl log.Lvl, logger log.Logger) error {
@@ -867,6 +1019,43 @@ func BuildMissedIndices(logPrefix string, ctx context.Context, dirs datadir.Dirs
 			})
 		}
 	}
+	receiptsRanges, err := extraSegmentRanges(dir, receiptsSegmentType)
+	if err != nil {
+		return err
+	}
+	for _, r := range receiptsRanges {
+		if hasExtraIdxFile(dir, r, receiptsSegmentType, logger) {
+			continue
+		}
+		r := r
+		g.Go(func() error {
+			p := &background.Progress{}
+			ps.Add(p)
+			defer ps.Delete(p)
+			return ReceiptsIdx(gCtx, filepath.Join(dir, extraSegmentFileName(r.from, r.to, receiptsSegmentType)), r.from, tmpDir, p, log.LvlInfo, logger)
+		})
+	}
+	for segType, idx := range map[string]func(context.Context, string, string, *background.Progress, log.Lvl, log.Logger) error{
+		borEventsSegmentType: BorEventsIdx,
+		borSpansSegmentType:  BorSpansIdx,
+	} {
+		ranges, err := extraSegmentRanges(dir, segType)
+		if err != nil {
+			return err
+		}
+		for _, r := range ranges {
+			if hasExtraIdxFile(dir, r, segType, logger) {
+				continue
+			}
+			segPath, idx := filepath.Join(dir, extraSegmentFileName(r.from, r.to, segType)), idx
+			g.Go(func() error {
+				p := &background.Progress{}
+				ps.Add(p)
+				defer ps.Delete(p)
+				return idx(gCtx, segPath, tmpDir, p, log.LvlInfo, logger)
+			})
+		}
+	}
 	finish := make(chan struct{})
 	go func() {
 		defer close(finish)
@@ -889,6 +1078,144 @@ func BuildMissedIndices(logPrefix string, ctx context.Context, dirs datadir.Dirs
 	}
 }
 
+// The segment types below are not part of snaptype: they are optional, may have gaps, and a range of blocks is
+// available without them.
+
+func extraSegmentFileName(from, to uint64, segType string) string {
+	return snaptype.FileName(from, to, segType) + ".seg"
+}
+
+// parseExtraSegmentFileName returns the blocks range of a segment file name of the type.
+func parseExtraSegmentFileName(name, segType string) (r Range, ok bool) {
+	ext := filepath.Ext(name)
+	if ext != ".seg" {
+		return r, false
+	}
+	parts := strings.Split(strings.TrimSuffix(name, ext), "-")
+	if len(parts) != 4 || parts[0] != "v1" || parts[3] != segType {
+		return r, false
+	}
+	from, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return r, false
+	}
+	to, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil || to <= from {
+		return r, false
+	}
+	return Range{from: from * 1_000, to: to * 1_000}, true
+}
+
+// extraSegmentRanges lists the ranges of the segments of the type in dir, keeping the largest ones when they overlap.
+func extraSegmentRanges(dir, segType string) ([]Range, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ranges []Range
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if r, ok := parseExtraSegmentFileName(entry.Name(), segType); ok {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].from != ranges[j].from {
+			return ranges[i].from < ranges[j].from
+		}
+		return ranges[i].to > ranges[j].to
+	})
+	var res []Range
+	var prevTo uint64
+	for _, r := range ranges {
+		if r.from < prevTo {
+			continue
+		}
+		res = append(res, r)
+		prevTo = r.to
+	}
+	return res, nil
+}
+
+func hasExtraIdxFile(dir string, r Range, segType string, logger log.Logger) bool {
+	segName := extraSegmentFileName(r.from, r.to, segType)
+	stat, err := os.Stat(filepath.Join(dir, segName))
+	if err != nil {
+		return false
+	}
+	fName := snaptype.IdxFileName(r.from, r.to, segType)
+	idx, err := recsplit.OpenIndex(path.Join(dir, fName))
+	if err != nil {
+		return false
+	}
+	defer idx.Close()
+	// If index was created before the segment file, it needs to be ignored (and rebuilt)
+	if idx.ModTime().Before(stat.ModTime()) {
+		logger.Warn("Index file has timestamp before segment file, will be recreated", "segfile", segName, "segtime", stat.ModTime(), "idxfile", fName, "idxtime", idx.ModTime())
+		return false
+	}
+	return true
+}
+
+// ExtraSegmentFiles returns the names of the receipts and bor segments of the folder, which Segments does not list.
+func ExtraSegmentFiles(dir string) ([]string, error) {
+	var res []string
+	for _, segType := range []string{receiptsSegmentType, borEventsSegmentType, borSpansSegmentType} {
+		ranges, err := extraSegmentRanges(dir, segType)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range ranges {
+			res = append(res, extraSegmentFileName(r.from, r.to, segType))
+		}
+	}
+	return res, nil
+}
+
+// extraDownloadRequests returns the requests seeding the segments of the types produced for the range: the downloader
+// only seeds the types of snaptype on its own. As for those, only the segments of full size are seeded.
+func extraDownloadRequests(dir string, r Range, segTypes ...string) []DownloadRequest {
+	if r.to-r.from != snaptype.Erigon2SegmentSize {
+		return nil
+	}
+	var requests []DownloadRequest
+	for _, segType := range segTypes {
+		fName := extraSegmentFileName(r.from, r.to, segType)
+		if _, err := os.Stat(filepath.Join(dir, fName)); err != nil {
+			continue
+		}
+		requests = append(requests, NewDownloadRequest(nil, fName, ""))
+	}
+	return requests
+}
+
+// coveringFiles returns the files of the ranges to merge into [from, to), nil unless they cover all of it.
+func coveringFiles(ranges []Range, files []string, from, to uint64) []string {
+	idx := make([]int, len(ranges))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return ranges[idx[i]].from < ranges[idx[j]].from })
+	var toMerge []string
+	next := from
+	for _, i := range idx {
+		if ranges[i].from < from || ranges[i].to > to {
+			continue
+		}
+		if ranges[i].from != next {
+			return nil
+		}
+		toMerge = append(toMerge, files[i])
+		next = ranges[i].to
+	}
+	if next != to || len(toMerge) < 2 {
+		return nil
+	}
+	return toMerge
+}
+
 func noGaps(in []snaptype.FileInfo) (out []snaptype.FileInfo, missingSnapshots []Range) {
 	var prevTo uint64
 	for _, f := range in {
@@ -1010,12 +1337,16 @@ type BlockRetire struct {
 	logger      log.Logger
 	blockReader services.FullBlockReader
 	blockWriter *blockio.BlockWriter
+	heimdall    HeimdallReader
 }
 
 func NewBlockRetire(workers int, tmpDir string, blockReader services.FullBlockReader, blockWriter *blockio.BlockWriter, db kv.RoDB, downloader proto_downloader.DownloaderClient, notifier DBEventNotifier, logger log.Logger) *BlockRetire {
 	return &BlockRetire{workers: workers, tmpDir: tmpDir, blockReader: blockReader, blockWriter: blockWriter, db: db, downloader: downloader, notifier: notifier, logger: logger}
 }
 func (br *BlockRetire) Snapshots() *RoSnapshots { return br.blockReader.Snapshots().(*RoSnapshots) }
+
+// SetHeimdall makes the retire of Polygon blocks freeze their state sync events and spans, fetched from Heimdall.
+func (br *BlockRetire) SetHeimdall(heimdall HeimdallReader) { br.heimdall = heimdall }
 func (br *BlockRetire) NeedSaveFilesListInDB() bool {
 	return br.needSaveFilesListInDB.CompareAndSwap(true, false)
 }
@@ -1077,6 +1408,15 @@ func (br *BlockRetire) RetireBlocks(ctx context.Context, blockFrom, blockTo uint
 	if err := DumpBlocks(ctx, blockFrom, blockTo, snaptype.Erigon2SegmentSize, tmpDir, snapshots.Dir(), firstTxNum, db, workers, lvl, logger, blockReader); err != nil {
 		return fmt.Errorf("DumpBlocks: %w", err)
 	}
+	if chainConfig.Bor != nil && br.heimdall != nil {
+		// Heimdall being unavailable must not stop the blocks from being frozen: the bor segments are caught up next time
+		if err := DumpBor(ctx, blockFrom, blockTo, snaptype.Erigon2SegmentSize, tmpDir, snapshots, db, br.heimdall, workers, lvl, logger, blockReader); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Warn("[snapshots] Bor segments not frozen", "range", Range{blockFrom, blockTo}.String(), "err", err)
+		}
+	}
 	if err := snapshots.ReopenFolder(); err != nil {
 		return fmt.Errorf("reopen: %w", err)
 	}
@@ -1105,6 +1445,7 @@ func (br *BlockRetire) RetireBlocks(ctx context.Context, blockFrom, blockTo uint
 		downloadRequest := make([]DownloadRequest, 0, len(rangesToMerge))
 		for i := range rangesToMerge {
 			downloadRequest = append(downloadRequest, NewDownloadRequest(&rangesToMerge[i], "", ""))
+			downloadRequest = append(downloadRequest, extraDownloadRequests(snapshots.Dir(), rangesToMerge[i], receiptsSegmentType, borEventsSegmentType, borSpansSegmentType)...)
 		}
 
 		if err := RequestSnapshotsDownload(ctx, downloadRequest, downloader); err != nil {
@@ -1261,6 +1602,10 @@ func dumpBlocksRange(ctx context.Context, blockFrom, blockTo uint64, tmpDir, sna
 		}
 	}
 
+	if err := dumpReceiptsRange(ctx, blockFrom, blockTo, tmpDir, snapDir, chainDB, workers, lvl, logger); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -1980,6 +2325,9 @@ func (s *RoSnapshots) View() *View {
 	v.s.Headers.lock.RLock()
 	v.s.Bodies.lock.RLock()
 	v.s.Txs.lock.RLock()
+	v.s.Receipts.lock.RLock()
+	v.s.BorEvents.lock.RLock()
+	v.s.BorSpans.lock.RLock()
 	return v
 }
 
@@ -1991,6 +2339,9 @@ func (v *View) Close() {
 	v.s.Headers.lock.RUnlock()
 	v.s.Bodies.lock.RUnlock()
 	v.s.Txs.lock.RUnlock()
+	v.s.Receipts.lock.RUnlock()
+	v.s.BorEvents.lock.RUnlock()
+	v.s.BorSpans.lock.RUnlock()
 }
 func (v *View) Headers() []*HeaderSegment { return v.s.Headers.segments }
 func (v *View) Bodies() []*BodySegment    { return v.s.Bodies.segments }
@@ -2072,6 +2423,23 @@ func (m *Merger) Merge(ctx context.Context, snapshots *RoSnapshots, mergeRanges
 				}
 			}
 		}
+		receiptsToMerge := m.receiptsFilesByRange(snapshots, r.from, r.to)
+		if len(receiptsToMerge) > 0 {
+			segPath := filepath.Join(snapDir, extraSegmentFileName(r.from, r.to, receiptsSegmentType))
+			if err := m.merge(ctx, receiptsToMerge, segPath, logEvery); err != nil {
+				return fmt.Errorf("mergeByAppendSegments: %w", err)
+			}
+			if doIndex {
+				p := &background.Progress{}
+				if err := ReceiptsIdx(ctx, segPath, r.from, m.tmpDir, p, m.lvl, m.logger); err != nil {
+					return err
+				}
+			}
+		}
+		borMerged, err := m.mergeBor(ctx, snapshots, r, snapDir, doIndex, logEvery)
+		if err != nil {
+			return err
+		}
 		if err := snapshots.ReopenFolder(); err != nil {
 			return fmt.Errorf("ReopenSegments: %w", err)
 		}
@@ -2083,6 +2451,8 @@ func (m *Merger) Merge(ctx context.Context, snapshots *RoSnapshots, mergeRanges
 		for _, t := range snaptype.AllSnapshotTypes {
 			m.removeOldFiles(toMerge[t], snapDir)
 		}
+		m.removeOldFiles(receiptsToMerge, snapDir)
+		m.removeOldFiles(borMerged, snapDir)
 	}
 	m.logger.Log(m.lvl, "[snapshots] Merge done", "from", mergeRanges[0].from)
 	return nil
@@ -2,18 +2,28 @@ package snapshotsync
 
 import (
 	"context"
+	"encoding/json"
 	"path/filepath"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/background"
 	"github.com/ledgerwatch/erigon-lib/compress"
 	"github.com/ledgerwatch/erigon-lib/downloader/snaptype"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/ledgerwatch/erigon-lib/recsplit"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/consensus/bor/clerk"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall/checkpoint"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall/span"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/params/networkname"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snapcfg"
@@ -233,3 +243,238 @@ func TestParseCompressedFileName(t *testing.T) {
 	require.Equal(1_000, int(f.From))
 	require.Equal(2_000, int(f.To))
 }
+
+func TestReceiptsSegments(t *testing.T) {
+	logger := log.New()
+	dir, require := t.TempDir(), require.New(t)
+	createFile := func(from, to uint64) {
+		c, err := compress.NewCompressor(context.Background(), "test", filepath.Join(dir, extraSegmentFileName(from, to, receiptsSegmentType)), dir, 100, 1, log.LvlDebug, logger)
+		require.NoError(err)
+		defer c.Close()
+		require.NoError(c.AddWord([]byte{1}))
+		require.NoError(c.Compress())
+	}
+
+	r, ok := parseExtraSegmentFileName(extraSegmentFileName(500_000, 1_000_000, receiptsSegmentType), receiptsSegmentType)
+	require.True(ok)
+	require.Equal(Range{500_000, 1_000_000}, r)
+	_, ok = parseExtraSegmentFileName(snaptype.SegmentFileName(500_000, 1_000_000, snaptype.Bodies), receiptsSegmentType)
+	require.False(ok)
+	_, ok = parseExtraSegmentFileName(snaptype.IdxFileName(500_000, 1_000_000, receiptsSegmentType), receiptsSegmentType)
+	require.False(ok)
+
+	createFile(0, 500_000)
+	createFile(500_000, 1_000_000)
+	createFile(0, 1_000_000)
+	createFile(1_500_000, 2_000_000)
+	ranges, err := extraSegmentRanges(dir, receiptsSegmentType)
+	require.NoError(err)
+	require.Equal([]Range{{0, 1_000_000}, {1_500_000, 2_000_000}}, ranges)
+
+	s := NewRoSnapshots(ethconfig.Snapshot{Enabled: true}, dir, logger)
+	defer s.Close()
+	require.NoError(s.ReopenFolder())
+	view := s.View()
+	defer view.Close()
+	require.Equal(2, len(view.Receipts()))
+	seg, ok := view.ReceiptsSegment(1_600_000)
+	require.True(ok)
+	require.Equal(Range{1_500_000, 2_000_000}, seg.ranges)
+	_, ok = view.ReceiptsSegment(1_200_000)
+	require.False(ok)
+}
+
+func TestDumpReceipts(t *testing.T) {
+	logger := log.New()
+	ctx, dir, require := context.Background(), t.TempDir(), require.New(t)
+	db := memdb.NewTestDB(t)
+
+	txs := types.Transactions{
+		types.NewTransaction(0, libcommon.Address{1}, uint256.NewInt(1), 21_000, uint256.NewInt(1), nil),
+		types.NewEIP1559Transaction(*uint256.NewInt(1), 1, libcommon.Address{1}, uint256.NewInt(1), 21_000, uint256.NewInt(1), uint256.NewInt(1), uint256.NewInt(1), nil),
+	}
+	receipts := types.Receipts{
+		{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21_000, Logs: []*types.Log{}},
+		{Status: types.ReceiptStatusFailed, CumulativeGasUsed: 42_000, Logs: []*types.Log{{Address: libcommon.Address{2}, Topics: []libcommon.Hash{{3}}, Data: []byte{4}}}},
+	}
+	tx, err := db.BeginRw(ctx)
+	require.NoError(err)
+	defer tx.Rollback()
+	for blockNum := uint64(0); blockNum < 1_000; blockNum++ {
+		hash := libcommon.Hash{byte(blockNum >> 8), byte(blockNum)}
+		body, blockReceipts := &types.Body{}, types.Receipts{}
+		if blockNum == 7 {
+			body.Transactions, blockReceipts = txs, receipts
+		}
+		require.NoError(rawdb.WriteCanonicalHash(tx, hash, blockNum))
+		require.NoError(rawdb.WriteBody(tx, hash, blockNum, body))
+		require.NoError(rawdb.WriteReceipts(tx, blockNum, blockReceipts))
+	}
+	require.NoError(tx.Commit())
+
+	require.NoError(dumpReceiptsRange(ctx, 0, 1_000, dir, dir, db, 1, log.LvlDebug, logger))
+	s := NewRoSnapshots(ethconfig.Snapshot{Enabled: true}, dir, logger)
+	defer s.Close()
+	require.NoError(s.ReopenFolder())
+	r := NewBlockReader(s)
+
+	frozen, err := r.RawReceipts(ctx, 7)
+	require.NoError(err)
+	require.Len(frozen, 2)
+	// the type is not stored in the db, it is frozen from the transactions
+	require.Equal(types.LegacyTxType, frozen[0].Type)
+	require.Equal(types.DynamicFeeTxType, frozen[1].Type)
+	require.Equal(receipts[1].Status, frozen[1].Status)
+	require.Equal(receipts[1].CumulativeGasUsed, frozen[1].CumulativeGasUsed)
+	require.Len(frozen[1].Logs, 1)
+	require.Equal(receipts[1].Logs[0].Data, frozen[1].Logs[0].Data)
+	frozen, err = r.RawReceipts(ctx, 8)
+	require.NoError(err)
+	require.NotNil(frozen)
+	require.Empty(frozen)
+	frozen, err = r.RawReceipts(ctx, 1_000)
+	require.NoError(err)
+	require.Nil(frozen)
+}
+
+type testHeimdall struct {
+	eventsFrom []uint64
+}
+
+func (h *testHeimdall) StateSyncEvents(ctx context.Context, fromID uint64, to int64) ([]*clerk.EventRecordWithTime, error) {
+	h.eventsFrom = append(h.eventsFrom, fromID)
+	return []*clerk.EventRecordWithTime{testBorEvent(fromID)}, nil
+}
+
+// Span returns the spans of bor: the span 0 of 256 blocks, then spans of 6400 blocks.
+func (h *testHeimdall) Span(ctx context.Context, spanID uint64) (*span.HeimdallSpan, error) {
+	s := span.Span{ID: spanID, EndBlock: 255}
+	if spanID > 0 {
+		s.StartBlock, s.EndBlock = 256+(spanID-1)*6400, 255+spanID*6400
+	}
+	return &span.HeimdallSpan{Span: s, ChainID: "remote"}, nil
+}
+func (h *testHeimdall) FetchCheckpoint(ctx context.Context, number int64) (*checkpoint.Checkpoint, error) {
+	return nil, nil
+}
+func (h *testHeimdall) FetchCheckpointCount(ctx context.Context) (int64, error) { return 0, nil }
+func (h *testHeimdall) Close()                                                  {}
+
+// testBorEvent is recorded 10 seconds after the previous one.
+func testBorEvent(id uint64) *clerk.EventRecordWithTime {
+	return &clerk.EventRecordWithTime{EventRecord: clerk.EventRecord{ID: id, Data: []byte{byte(id)}}, Time: time.Unix(int64(id)*10, 0).UTC()}
+}
+
+func TestBorSegments(t *testing.T) {
+	logger := log.New()
+	dir, require := t.TempDir(), require.New(t)
+	createFile := func(from, to uint64, segType string, words [][]byte) {
+		segPath := filepath.Join(dir, extraSegmentFileName(from, to, segType))
+		c, err := compress.NewCompressor(context.Background(), "test", segPath, dir, 100, 1, log.LvlDebug, logger)
+		require.NoError(err)
+		defer c.Close()
+		for _, word := range words {
+			require.NoError(c.AddWord(word))
+		}
+		require.NoError(c.Compress())
+	}
+	marshal := func(v any) []byte {
+		word, err := json.Marshal(v)
+		require.NoError(err)
+		return word
+	}
+
+	// blocks 16 and 32 commit events 1 to 3 and 4
+	events := make([][]byte, 1_000)
+	events[16] = marshal([]*clerk.EventRecordWithTime{testBorEvent(1), testBorEvent(2), testBorEvent(3)})
+	events[32] = marshal([]*clerk.EventRecordWithTime{testBorEvent(4)})
+	createFile(0, 1_000, borEventsSegmentType, events)
+	createFile(0, 1_000, borSpansSegmentType, [][]byte{
+		marshal(&span.HeimdallSpan{Span: span.Span{ID: 0, StartBlock: 0, EndBlock: 255}}),
+		marshal(&span.HeimdallSpan{Span: span.Span{ID: 1, StartBlock: 256, EndBlock: 6655}}),
+	})
+	// blocks 1000 to 2000 commit no events
+	createFile(1_000, 2_000, borEventsSegmentType, make([][]byte, 1_000))
+	createFile(1_000, 2_000, borSpansSegmentType, [][]byte{
+		marshal(&span.HeimdallSpan{Span: span.Span{ID: 1, StartBlock: 256, EndBlock: 6655}}),
+	})
+	for _, r := range []Range{{0, 1_000}, {1_000, 2_000}} {
+		require.NoError(BorEventsIdx(context.Background(), filepath.Join(dir, extraSegmentFileName(r.from, r.to, borEventsSegmentType)), dir, &background.Progress{}, log.LvlDebug, logger))
+		require.NoError(BorSpansIdx(context.Background(), filepath.Join(dir, extraSegmentFileName(r.from, r.to, borSpansSegmentType)), dir, &background.Progress{}, log.LvlDebug, logger))
+	}
+	require.True(hasExtraIdxFile(dir, Range{1_000, 2_000}, borEventsSegmentType, logger))
+
+	s := NewRoSnapshots(ethconfig.Snapshot{Enabled: true}, dir, logger)
+	defer s.Close()
+	require.NoError(s.ReopenFolder())
+	view := s.View()
+	id, ok, err := view.borEventIDBefore(1_000)
+	require.NoError(err)
+	require.True(ok)
+	require.Equal(uint64(4), id)
+	id, ok, err = view.borEventIDBefore(2_000)
+	require.NoError(err)
+	require.True(ok)
+	require.Equal(uint64(4), id)
+	_, ok, err = view.borEventIDBefore(3_000)
+	require.NoError(err)
+	require.False(ok)
+	require.Equal(uint64(2_000), view.borFrozenTo())
+	id, ok, err = view.borSpanIDAt(2_000)
+	require.NoError(err)
+	require.True(ok)
+	require.Equal(uint64(1), id)
+	_, ok, err = view.borSpanIDAt(3_000)
+	require.NoError(err)
+	require.False(ok)
+	view.Close()
+
+	r := NewBlockReader(s)
+	frozen, complete, err := r.BorEvents(2, 25)
+	require.NoError(err)
+	require.True(complete)
+	require.Equal([]*clerk.EventRecordWithTime{testBorEvent(2)}, frozen)
+
+	heimdall := &testHeimdall{}
+	client := NewFrozenHeimdallClient(heimdall, r)
+	all, err := client.StateSyncEvents(context.Background(), 3, 100)
+	require.NoError(err)
+	require.Equal([]*clerk.EventRecordWithTime{testBorEvent(3), testBorEvent(4), testBorEvent(5)}, all)
+	require.Equal([]uint64{5}, heimdall.eventsFrom)
+
+	frozenSpan, err := client.Span(context.Background(), 1)
+	require.NoError(err)
+	require.Equal(uint64(256), frozenSpan.StartBlock)
+	remoteSpan, err := client.Span(context.Background(), 2)
+	require.NoError(err)
+	require.Equal("remote", remoteSpan.ChainID)
+}
+
+func TestDumpBorSpans(t *testing.T) {
+	require := require.New(t)
+	heimdall := &testHeimdall{}
+	dump := func(blockFrom, blockTo, spanID uint64) (ids []uint64, next uint64) {
+		next, err := DumpBorSpans(context.Background(), heimdall, blockFrom, blockTo, spanID, func(v []byte) error {
+			var s span.HeimdallSpan
+			require.NoError(json.Unmarshal(v, &s))
+			ids = append(ids, s.ID)
+			return nil
+		})
+		require.NoError(err)
+		return ids, next
+	}
+
+	ids, next := dump(0, 1_000, 0)
+	require.Equal([]uint64{0, 1}, ids)
+	require.Equal(uint64(1), next)
+	ids, next = dump(6_000, 7_000, 1)
+	require.Equal([]uint64{1, 2}, ids)
+	require.Equal(uint64(2), next)
+	// the last block of the range ends the span
+	ids, next = dump(0, 256, 0)
+	require.Equal([]uint64{0}, ids)
+	require.Equal(uint64(1), next)
+
+	_, err := DumpBorSpans(context.Background(), heimdall, 7_000, 8_000, 0, func(v []byte) error { return nil })
+	require.Error(err)
+}
@@ -0,0 +1,1012 @@
+package snapshotsync
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	common2 "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/background"
+	"github.com/ledgerwatch/erigon-lib/common/dbg"
+	"github.com/ledgerwatch/erigon-lib/compress"
+	"github.com/ledgerwatch/erigon-lib/downloader/snaptype"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/recsplit"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cmd/hack/tool/fromdb"
+	"github.com/ledgerwatch/erigon/consensus/bor/clerk"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall/checkpoint"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall/span"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/turbo/services"
+)
+
+// The state sync events and the spans of Bor are not in the db: the bor engine fetches them from Heimdall when it
+// executes the blocks committing them. The retire of Polygon blocks freezes them too, so the frozen blocks can be
+// replayed without Heimdall.
+const (
+	borEventsSegmentType = "borevents"
+	borSpansSegmentType  = "borspans"
+)
+
+// HeimdallReader is the part of the Heimdall client the frozen events and spans are fetched with.
+type HeimdallReader interface {
+	StateSyncEvents(ctx context.Context, fromID uint64, to int64) ([]*clerk.EventRecordWithTime, error)
+	Span(ctx context.Context, spanID uint64) (*span.HeimdallSpan, error)
+}
+
+// HeimdallClient has the methods of bor.IHeimdallClient, which can't be imported here.
+type HeimdallClient interface {
+	HeimdallReader
+	FetchCheckpoint(ctx context.Context, number int64) (*checkpoint.Checkpoint, error)
+	FetchCheckpointCount(ctx context.Context) (int64, error)
+	Close()
+}
+
+type BorEventSegment struct {
+	seg        *compress.Decompressor // value: json([]*clerk.EventRecordWithTime) of each block, empty if it commits none
+	idxEventID *recsplit.Index        // event_id -> bor_events_segment_offset of its block, then a last key to the end of the segment
+	ranges     Range
+}
+
+func (sn *BorEventSegment) closeSeg() {
+	if sn.seg != nil {
+		sn.seg.Close()
+		sn.seg = nil
+	}
+}
+func (sn *BorEventSegment) closeIdx() {
+	if sn.idxEventID != nil {
+		sn.idxEventID.Close()
+		sn.idxEventID = nil
+	}
+}
+func (sn *BorEventSegment) close() {
+	sn.closeSeg()
+	sn.closeIdx()
+}
+
+func (sn *BorEventSegment) reopenSeg(dir string) (err error) {
+	sn.closeSeg()
+	fileName := extraSegmentFileName(sn.ranges.from, sn.ranges.to, borEventsSegmentType)
+	sn.seg, err = compress.NewDecompressor(path.Join(dir, fileName))
+	if err != nil {
+		return fmt.Errorf("%w, fileName: %s", err, fileName)
+	}
+	return nil
+}
+func (sn *BorEventSegment) reopenIdxIfNeed(dir string, optimistic bool) (err error) {
+	if sn.idxEventID != nil {
+		return nil
+	}
+	err = sn.reopenIdx(dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			if optimistic {
+				log.Warn("[snapshots] open index", "err", err)
+			} else {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (sn *BorEventSegment) reopenIdx(dir string) (err error) {
+	sn.closeIdx()
+	if sn.seg == nil {
+		return nil
+	}
+	fileName := snaptype.IdxFileName(sn.ranges.from, sn.ranges.to, borEventsSegmentType)
+	sn.idxEventID, err = recsplit.OpenIndex(path.Join(dir, fileName))
+	if err != nil {
+		return fmt.Errorf("%w, fileName: %s", err, fileName)
+	}
+	if sn.idxEventID.ModTime().Before(sn.seg.ModTime()) {
+		// Index has been created before the segment file, needs to be ignored (and rebuilt) as inconsistent
+		sn.idxEventID.Close()
+		sn.idxEventID = nil
+	}
+	return nil
+}
+
+// lastEventID returns the id of the last event committed by the blocks of the segment, if they commit any.
+func (sn *BorEventSegment) lastEventID() (id uint64, ok bool, err error) {
+	if sn.idxEventID != nil {
+		if sn.idxEventID.KeyCount() < 2 {
+			return 0, false, nil
+		}
+		return sn.idxEventID.BaseDataID() + sn.idxEventID.KeyCount() - 2, true, nil
+	}
+	var buf []byte
+	gg := sn.seg.MakeGetter()
+	for gg.HasNext() {
+		buf, _ = gg.Next(buf[:0])
+		if len(buf) == 0 {
+			continue
+		}
+		var events []*clerk.EventRecordWithTime
+		if err := json.Unmarshal(buf, &events); err != nil {
+			return 0, false, err
+		}
+		if len(events) > 0 {
+			id, ok = events[len(events)-1].ID, true
+		}
+	}
+	return id, ok, nil
+}
+
+type BorSpanSegment struct {
+	seg       *compress.Decompressor // value: json(span.HeimdallSpan)
+	idxSpanID *recsplit.Index        // span_id -> bor_spans_segment_offset
+	ranges    Range
+}
+
+func (sn *BorSpanSegment) closeSeg() {
+	if sn.seg != nil {
+		sn.seg.Close()
+		sn.seg = nil
+	}
+}
+func (sn *BorSpanSegment) closeIdx() {
+	if sn.idxSpanID != nil {
+		sn.idxSpanID.Close()
+		sn.idxSpanID = nil
+	}
+}
+func (sn *BorSpanSegment) close() {
+	sn.closeSeg()
+	sn.closeIdx()
+}
+
+func (sn *BorSpanSegment) reopenSeg(dir string) (err error) {
+	sn.closeSeg()
+	fileName := extraSegmentFileName(sn.ranges.from, sn.ranges.to, borSpansSegmentType)
+	sn.seg, err = compress.NewDecompressor(path.Join(dir, fileName))
+	if err != nil {
+		return fmt.Errorf("%w, fileName: %s", err, fileName)
+	}
+	return nil
+}
+func (sn *BorSpanSegment) reopenIdxIfNeed(dir string, optimistic bool) (err error) {
+	if sn.idxSpanID != nil {
+		return nil
+	}
+	err = sn.reopenIdx(dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			if optimistic {
+				log.Warn("[snapshots] open index", "err", err)
+			} else {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (sn *BorSpanSegment) reopenIdx(dir string) (err error) {
+	sn.closeIdx()
+	if sn.seg == nil {
+		return nil
+	}
+	fileName := snaptype.IdxFileName(sn.ranges.from, sn.ranges.to, borSpansSegmentType)
+	sn.idxSpanID, err = recsplit.OpenIndex(path.Join(dir, fileName))
+	if err != nil {
+		return fmt.Errorf("%w, fileName: %s", err, fileName)
+	}
+	if sn.idxSpanID.ModTime().Before(sn.seg.ModTime()) {
+		// Index has been created before the segment file, needs to be ignored (and rebuilt) as inconsistent
+		sn.idxSpanID.Close()
+		sn.idxSpanID = nil
+	}
+	return nil
+}
+
+// lastSpan returns the last span of the segment, the span its last block belongs to.
+func (sn *BorSpanSegment) lastSpan() (*span.HeimdallSpan, error) {
+	var buf []byte
+	gg := sn.seg.MakeGetter()
+	for gg.HasNext() {
+		buf, _ = gg.Next(buf[:0])
+	}
+	if len(buf) == 0 {
+		return nil, fmt.Errorf("empty segment %s", sn.seg.FilePath())
+	}
+	var s span.HeimdallSpan
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+type borEventSegments struct {
+	lock     sync.RWMutex
+	segments []*BorEventSegment
+}
+
+func (s *borEventSegments) View(f func([]*BorEventSegment) error) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return f(s.segments)
+}
+
+// reopen opens the events segment of the file name, a missing file is skipped as the bor segments are optional.
+func (s *borEventSegments) reopen(dir, fName string, ranges Range, optimistic bool, logger log.Logger) error {
+	for _, sn := range s.segments {
+		_, name := filepath.Split(sn.seg.FilePath())
+		if fName == name {
+			return sn.reopenIdxIfNeed(dir, optimistic)
+		}
+	}
+
+	sn := &BorEventSegment{ranges: ranges}
+	if err := sn.reopenSeg(dir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if optimistic {
+			logger.Warn("[snapshots] open segment", "err", err)
+			return nil
+		}
+		return err
+	}
+	s.segments = append(s.segments, sn)
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].ranges.from < s.segments[j].ranges.from })
+	return sn.reopenIdxIfNeed(dir, optimistic)
+}
+
+func (s *borEventSegments) closeWhatNotInList(l []string) {
+	var segments []*BorEventSegment
+Loop:
+	for _, sn := range s.segments {
+		_, name := filepath.Split(sn.seg.FilePath())
+		for _, fName := range l {
+			if fName == name {
+				segments = append(segments, sn)
+				continue Loop
+			}
+		}
+		sn.close()
+	}
+	s.segments = segments
+}
+
+type borSpanSegments struct {
+	lock     sync.RWMutex
+	segments []*BorSpanSegment
+}
+
+func (s *borSpanSegments) View(f func([]*BorSpanSegment) error) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return f(s.segments)
+}
+
+// reopen opens the spans segment of the file name, a missing file is skipped as the bor segments are optional.
+func (s *borSpanSegments) reopen(dir, fName string, ranges Range, optimistic bool, logger log.Logger) error {
+	for _, sn := range s.segments {
+		_, name := filepath.Split(sn.seg.FilePath())
+		if fName == name {
+			return sn.reopenIdxIfNeed(dir, optimistic)
+		}
+	}
+
+	sn := &BorSpanSegment{ranges: ranges}
+	if err := sn.reopenSeg(dir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if optimistic {
+			logger.Warn("[snapshots] open segment", "err", err)
+			return nil
+		}
+		return err
+	}
+	s.segments = append(s.segments, sn)
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].ranges.from < s.segments[j].ranges.from })
+	return sn.reopenIdxIfNeed(dir, optimistic)
+}
+
+func (s *borSpanSegments) closeWhatNotInList(l []string) {
+	var segments []*BorSpanSegment
+Loop:
+	for _, sn := range s.segments {
+		_, name := filepath.Split(sn.seg.FilePath())
+		for _, fName := range l {
+			if fName == name {
+				segments = append(segments, sn)
+				continue Loop
+			}
+		}
+		sn.close()
+	}
+	s.segments = segments
+}
+
+func (v *View) BorEvents() []*BorEventSegment { return v.s.BorEvents.segments }
+func (v *View) BorSpans() []*BorSpanSegment   { return v.s.BorSpans.segments }
+
+// borEventIDBefore returns the id of the last event committed before the block, known if the events segments cover
+// all the blocks before it committing events.
+func (v *View) borEventIDBefore(blockNum uint64) (id uint64, ok bool, err error) {
+	segments := v.BorEvents()
+	next := blockNum
+	for i := len(segments) - 1; i >= 0 && next > 0; i-- {
+		sn := segments[i]
+		if sn.ranges.from >= next {
+			continue
+		}
+		if sn.ranges.to != next {
+			return 0, false, nil
+		}
+		if id, ok, err = sn.lastEventID(); ok || err != nil {
+			return id, ok, err
+		}
+		next = sn.ranges.from
+	}
+	return 0, next == 0, nil
+}
+
+// borFrozenTo returns the end of the blocks whose events and spans are frozen, from the block 0 on.
+func (v *View) borFrozenTo() uint64 {
+	var eventsTo, spansTo uint64
+	for _, sn := range v.BorEvents() {
+		if sn.ranges.from == eventsTo {
+			eventsTo = sn.ranges.to
+		}
+	}
+	for _, sn := range v.BorSpans() {
+		if sn.ranges.from == spansTo {
+			spansTo = sn.ranges.to
+		}
+	}
+	if eventsTo < spansTo {
+		return eventsTo
+	}
+	return spansTo
+}
+
+// borSpanIDAt returns the id of the span the block belongs to, known if the spans segment of the blocks before it
+// is frozen.
+func (v *View) borSpanIDAt(blockNum uint64) (id uint64, ok bool, err error) {
+	if blockNum == 0 {
+		return 0, true, nil
+	}
+	for _, sn := range v.BorSpans() {
+		if sn.ranges.to != blockNum {
+			continue
+		}
+		last, err := sn.lastSpan()
+		if err != nil {
+			return 0, false, err
+		}
+		if last.EndBlock >= blockNum {
+			return last.ID, true, nil
+		}
+		return last.ID + 1, true, nil
+	}
+	return 0, false, nil
+}
+
+// borEventsBatch is the number of sprints whose state sync events are fetched from Heimdall at once.
+const borEventsBatch = 1_000
+
+// DumpBorEvents - [from, to)
+// Format: json([]*clerk.EventRecordWithTime) of the state sync events committed by each block, empty if none. They are
+// selected the way the bor engine commits them, from the event after lastEventID on, but fetched from Heimdall for
+// borEventsBatch sprints at once.
+func DumpBorEvents(ctx context.Context, db kv.RoDB, chainConfig *chain.Config, blockReader services.FullBlockReader, heimdall HeimdallReader, blockFrom, blockTo, lastEventID uint64, lvl log.Lvl, logger log.Logger, collect func([]byte) error) (uint64, error) {
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+
+	borConfig, chainID := chainConfig.Bor, chainConfig.ChainID.String()
+	headerTime := func(blockNum uint64) (uint64, error) {
+		var header *types.Header
+		if err := db.View(ctx, func(tx kv.Tx) (err error) {
+			header, err = blockReader.HeaderByNumber(ctx, tx, blockNum)
+			return err
+		}); err != nil {
+			return 0, err
+		}
+		if header == nil {
+			return 0, fmt.Errorf("header not found: %d", blockNum)
+		}
+		return header.Time, nil
+	}
+	// stateSyncTo returns the time the events committed by the sprint start block are recorded before
+	stateSyncTo := func(blockNum, sprint uint64) (time.Time, error) {
+		if borConfig.IsIndore(blockNum) {
+			t, err := headerTime(blockNum)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(int64(t-borConfig.CalculateStateSyncDelay(blockNum)), 0), nil
+		}
+		t, err := headerTime(blockNum - sprint)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(int64(t), 0), nil
+	}
+
+	for batchFrom := blockFrom; batchFrom < blockTo; {
+		var sprintStarts []uint64
+		var tos []time.Time
+		var maxTo time.Time
+		batchTo := batchFrom
+		for ; batchTo < blockTo && len(sprintStarts) < borEventsBatch; batchTo++ {
+			sprint := borConfig.CalculateSprint(batchTo)
+			if batchTo == 0 || batchTo%sprint != 0 {
+				continue
+			}
+			to, err := stateSyncTo(batchTo, sprint)
+			if err != nil {
+				return lastEventID, err
+			}
+			sprintStarts, tos = append(sprintStarts, batchTo), append(tos, to)
+			if to.After(maxTo) {
+				maxTo = to
+			}
+		}
+		var records []*clerk.EventRecordWithTime
+		if len(sprintStarts) > 0 {
+			var err error
+			if records, err = heimdall.StateSyncEvents(ctx, lastEventID+1, maxTo.Unix()); err != nil {
+				return lastEventID, err
+			}
+		}
+
+		var sprintIdx int
+		for blockNum := batchFrom; blockNum < batchTo; blockNum++ {
+			if sprintIdx == len(sprintStarts) || sprintStarts[sprintIdx] != blockNum {
+				if err := collect(nil); err != nil {
+					return lastEventID, err
+				}
+				continue
+			}
+			to := tos[sprintIdx]
+			sprintIdx++
+
+			// the records Heimdall returns to the engine for the block
+			var fetched []*clerk.EventRecordWithTime
+			for _, record := range records {
+				if record.ID > lastEventID && record.Time.Before(to) {
+					fetched = append(fetched, record)
+				}
+			}
+			if val, ok := borConfig.OverrideStateSyncRecords[strconv.FormatUint(blockNum, 10)]; ok && val < len(fetched) {
+				fetched = fetched[0:val]
+			}
+			var events []*clerk.EventRecordWithTime
+			for _, record := range fetched {
+				// the engine stops at the first event failing its validation
+				if !record.Valid(lastEventID, to, chainID) {
+					break
+				}
+				events = append(events, record)
+				lastEventID++
+			}
+			var word []byte
+			if len(events) > 0 {
+				var err error
+				if word, err = json.Marshal(events); err != nil {
+					return lastEventID, err
+				}
+			}
+			if err := collect(word); err != nil {
+				return lastEventID, err
+			}
+		}
+		batchFrom = batchTo
+
+		select {
+		case <-ctx.Done():
+			return lastEventID, ctx.Err()
+		case <-logEvery.C:
+			var m runtime.MemStats
+			if lvl >= log.LvlInfo {
+				dbg.ReadMemStats(&m)
+			}
+			logger.Log(lvl, "[snapshots] Wrote into file", "block num", batchTo, "event id", lastEventID,
+				"alloc", common2.ByteCount(m.Alloc), "sys", common2.ByteCount(m.Sys),
+			)
+		default:
+		}
+	}
+	return lastEventID, nil
+}
+
+// DumpBorSpans - [from, to)
+// Format: json(span.HeimdallSpan) of each span the blocks belong to, from the span spanID on. It returns the id of the
+// span the block blockTo belongs to.
+func DumpBorSpans(ctx context.Context, heimdall HeimdallReader, blockFrom, blockTo, spanID uint64, collect func([]byte) error) (uint64, error) {
+	for {
+		s, err := heimdall.Span(ctx, spanID)
+		if err != nil {
+			return spanID, err
+		}
+		if s.ID != spanID {
+			return spanID, fmt.Errorf("span %d: heimdall returned span %d", spanID, s.ID)
+		}
+		if s.EndBlock < blockFrom || s.StartBlock >= blockTo {
+			return spanID, fmt.Errorf("span %d of the blocks %d-%d is out of the blocks %d-%d", spanID, s.StartBlock, s.EndBlock, blockFrom, blockTo)
+		}
+		word, err := json.Marshal(s)
+		if err != nil {
+			return spanID, err
+		}
+		if err := collect(word); err != nil {
+			return spanID, err
+		}
+		if err := ctx.Err(); err != nil {
+			return spanID, err
+		}
+		if s.EndBlock >= blockTo {
+			return spanID, nil
+		}
+		spanID++
+		if s.EndBlock == blockTo-1 {
+			return spanID, nil
+		}
+	}
+}
+
+func BorEventsIdx(ctx context.Context, segmentFilePath string, tmpDir string, p *background.Progress, lvl log.Lvl, logger log.Logger) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			_, fName := filepath.Split(segmentFilePath)
+			err = fmt.Errorf("BorEventsIdx: at=%s, %v, %s", fName, rec, dbg.Stack())
+		}
+	}()
+
+	d, err := compress.NewDecompressor(segmentFilePath)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	_, fname := filepath.Split(segmentFilePath)
+	p.Name.Store(&fname)
+	p.Total.Store(uint64(d.Count()))
+
+	var firstEventID, eventsCount uint64
+	word := make([]byte, 0, 4096)
+	g := d.MakeGetter()
+	for g.HasNext() {
+		word, _ = g.Next(word[:0])
+		if len(word) == 0 {
+			continue
+		}
+		var events []*clerk.EventRecordWithTime
+		if err := json.Unmarshal(word, &events); err != nil {
+			return err
+		}
+		if eventsCount == 0 && len(events) > 0 {
+			firstEventID = events[0].ID
+		}
+		eventsCount += uint64(len(events))
+	}
+
+	// a last key, after the events, points to the end of the segment: the index is written even if the blocks commit no
+	// events, which it tells without reading the segment
+	ext := filepath.Ext(segmentFilePath)
+	rs, err := recsplit.NewRecSplit(recsplit.RecSplitArgs{
+		KeyCount:   int(eventsCount) + 1,
+		Enums:      true,
+		BucketSize: 2000,
+		LeafSize:   8,
+		TmpDir:     tmpDir,
+		IndexFile:  segmentFilePath[0:len(segmentFilePath)-len(ext)] + ".idx",
+		BaseDataID: firstEventID,
+	}, logger)
+	if err != nil {
+		return err
+	}
+	rs.LogLvl(log.LvlDebug)
+
+	num := make([]byte, 8)
+RETRY:
+	g = d.MakeGetter()
+	var offset, nextPos uint64
+	for g.HasNext() {
+		p.Processed.Add(1)
+		word, nextPos = g.Next(word[:0])
+		if len(word) > 0 {
+			var events []*clerk.EventRecordWithTime
+			if err := json.Unmarshal(word, &events); err != nil {
+				return err
+			}
+			for _, event := range events {
+				n := binary.PutUvarint(num, event.ID-firstEventID)
+				if err := rs.AddKey(num[:n], offset); err != nil {
+					return err
+				}
+			}
+		}
+		offset = nextPos
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+	n := binary.PutUvarint(num, eventsCount)
+	if err := rs.AddKey(num[:n], offset); err != nil {
+		return err
+	}
+	if err = rs.Build(); err != nil {
+		if errors.Is(err, recsplit.ErrCollision) {
+			logger.Info("Building recsplit. Collision happened. It's ok. Restarting with another salt...", "err", err)
+			rs.ResetNextSalt()
+			p.Processed.Store(0)
+			goto RETRY
+		}
+		return fmt.Errorf("BorEventsIdx: %w", err)
+	}
+	return nil
+}
+
+func BorSpansIdx(ctx context.Context, segmentFilePath string, tmpDir string, p *background.Progress, lvl log.Lvl, logger log.Logger) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			_, fName := filepath.Split(segmentFilePath)
+			err = fmt.Errorf("BorSpansIdx: at=%s, %v, %s", fName, rec, dbg.Stack())
+		}
+	}()
+
+	num := make([]byte, 8)
+
+	d, err := compress.NewDecompressor(segmentFilePath)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	_, fname := filepath.Split(segmentFilePath)
+	p.Name.Store(&fname)
+	p.Total.Store(uint64(d.Count()))
+
+	g := d.MakeGetter()
+	if !g.HasNext() {
+		return fmt.Errorf("BorSpansIdx: empty segment %s", fname)
+	}
+	word, _ := g.Next(nil)
+	var first span.Span
+	if err := json.Unmarshal(word, &first); err != nil {
+		return err
+	}
+
+	if err := Idx(ctx, d, first.ID, tmpDir, log.LvlDebug, func(idx *recsplit.RecSplit, i, offset uint64, word []byte) error {
+		p.Processed.Add(1)
+		n := binary.PutUvarint(num, i)
+		if err := idx.AddKey(num[:n], offset); err != nil {
+			return err
+		}
+		return nil
+	}, logger); err != nil {
+		return fmt.Errorf("BorSpansIdx: %w", err)
+	}
+	return nil
+}
+
+// dumpBorRange freezes the events and spans of the blocks of the range, returning the id of the last event and the
+// id of the span the block blockTo belongs to.
+func dumpBorRange(ctx context.Context, blockFrom, blockTo, lastEventID, spanID uint64, tmpDir, snapDir string, chainDB kv.RoDB, chainConfig *chain.Config, heimdall HeimdallReader, workers int, lvl log.Lvl, logger log.Logger, blockReader services.FullBlockReader) (uint64, uint64, error) {
+	{
+		segPath := filepath.Join(snapDir, extraSegmentFileName(blockFrom, blockTo, borEventsSegmentType))
+		sn, err := compress.NewCompressor(ctx, "Snapshot BorEvents", segPath, tmpDir, compress.MinPatternScore, workers, log.LvlTrace, logger)
+		if err != nil {
+			return lastEventID, spanID, err
+		}
+		defer sn.Close()
+		if lastEventID, err = DumpBorEvents(ctx, chainDB, chainConfig, blockReader, heimdall, blockFrom, blockTo, lastEventID, lvl, logger, func(v []byte) error {
+			return sn.AddWord(v)
+		}); err != nil {
+			return lastEventID, spanID, fmt.Errorf("DumpBorEvents: %w", err)
+		}
+		if expectedCount := int(blockTo - blockFrom); sn.Count() != expectedCount {
+			return lastEventID, spanID, fmt.Errorf("incorrect bor events count: %d, expected: %d", sn.Count(), expectedCount)
+		}
+		if err := sn.Compress(); err != nil {
+			return lastEventID, spanID, fmt.Errorf("compress: %w", err)
+		}
+
+		p := &background.Progress{}
+		if err := BorEventsIdx(ctx, segPath, tmpDir, p, lvl, logger); err != nil {
+			return lastEventID, spanID, err
+		}
+	}
+
+	{
+		segPath := filepath.Join(snapDir, extraSegmentFileName(blockFrom, blockTo, borSpansSegmentType))
+		sn, err := compress.NewCompressor(ctx, "Snapshot BorSpans", segPath, tmpDir, compress.MinPatternScore, workers, log.LvlTrace, logger)
+		if err != nil {
+			return lastEventID, spanID, err
+		}
+		defer sn.Close()
+		if spanID, err = DumpBorSpans(ctx, heimdall, blockFrom, blockTo, spanID, func(v []byte) error {
+			return sn.AddWord(v)
+		}); err != nil {
+			return lastEventID, spanID, fmt.Errorf("DumpBorSpans: %w", err)
+		}
+		if err := sn.Compress(); err != nil {
+			return lastEventID, spanID, fmt.Errorf("compress: %w", err)
+		}
+
+		p := &background.Progress{}
+		if err := BorSpansIdx(ctx, segPath, tmpDir, p, lvl, logger); err != nil {
+			return lastEventID, spanID, err
+		}
+	}
+	return lastEventID, spanID, nil
+}
+
+// DumpBor freezes the events and spans of the blocks of the range. The events are identified by the id of the last
+// event committed before them, and the spans by the span of the first block: the blocks before the range whose events
+// and spans are not frozen yet, down to the block 0, are frozen first.
+func DumpBor(ctx context.Context, blockFrom, blockTo, blocksPerFile uint64, tmpDir string, snapshots *RoSnapshots, chainDB kv.RoDB, heimdall HeimdallReader, workers int, lvl log.Lvl, logger log.Logger, blockReader services.FullBlockReader) error {
+	if blocksPerFile == 0 {
+		return nil
+	}
+	view := snapshots.View()
+	if frozenTo := view.borFrozenTo(); frozenTo < blockFrom {
+		logger.Log(lvl, "[snapshots] Freeze the bor segments of the blocks before", "range", Range{frozenTo, blockFrom}.String())
+		blockFrom = frozenTo
+	}
+	lastEventID, eventsOk, err := view.borEventIDBefore(blockFrom)
+	if err != nil {
+		view.Close()
+		return err
+	}
+	spanID, spansOk, err := view.borSpanIDAt(blockFrom)
+	view.Close()
+	if err != nil {
+		return err
+	}
+	if !eventsOk || !spansOk {
+		return fmt.Errorf("the bor segments of the blocks before %d are not frozen", blockFrom)
+	}
+	chainConfig := fromdb.ChainConfig(chainDB)
+	for i := blockFrom; i < blockTo; i = chooseSegmentEnd(i, blockTo, blocksPerFile) {
+		if lastEventID, spanID, err = dumpBorRange(ctx, i, chooseSegmentEnd(i, blockTo, blocksPerFile), lastEventID, spanID, tmpDir, snapshots.Dir(), chainDB, chainConfig, heimdall, workers, lvl, logger, blockReader); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeBor merges the bor segments covering the range, returning the merged files.
+func (m *Merger) mergeBor(ctx context.Context, snapshots *RoSnapshots, r Range, snapDir string, doIndex bool, logEvery *time.Ticker) (merged []string, err error) {
+	var eventsToMerge, spansToMerge []string
+	{
+		view := snapshots.View()
+		var ranges []Range
+		var files []string
+		for _, sn := range view.BorEvents() {
+			ranges = append(ranges, sn.ranges)
+			files = append(files, sn.seg.FilePath())
+		}
+		eventsToMerge = coveringFiles(ranges, files, r.from, r.to)
+		ranges, files = nil, nil
+		for _, sn := range view.BorSpans() {
+			ranges = append(ranges, sn.ranges)
+			files = append(files, sn.seg.FilePath())
+		}
+		spansToMerge = coveringFiles(ranges, files, r.from, r.to)
+		view.Close()
+	}
+
+	if len(eventsToMerge) > 0 {
+		segPath := filepath.Join(snapDir, extraSegmentFileName(r.from, r.to, borEventsSegmentType))
+		if err := m.merge(ctx, eventsToMerge, segPath, logEvery); err != nil {
+			return nil, fmt.Errorf("mergeByAppendSegments: %w", err)
+		}
+		if doIndex {
+			p := &background.Progress{}
+			if err := BorEventsIdx(ctx, segPath, m.tmpDir, p, m.lvl, m.logger); err != nil {
+				return nil, err
+			}
+		}
+		merged = append(merged, eventsToMerge...)
+	}
+	if len(spansToMerge) > 0 {
+		segPath := filepath.Join(snapDir, extraSegmentFileName(r.from, r.to, borSpansSegmentType))
+		if err := m.mergeBorSpans(ctx, spansToMerge, segPath); err != nil {
+			return nil, fmt.Errorf("mergeBorSpans: %w", err)
+		}
+		if doIndex {
+			p := &background.Progress{}
+			if err := BorSpansIdx(ctx, segPath, m.tmpDir, p, m.lvl, m.logger); err != nil {
+				return nil, err
+			}
+		}
+		merged = append(merged, spansToMerge...)
+	}
+	return merged, nil
+}
+
+// mergeBorSpans merges spans segments, writing once the spans overlapping two consecutive ranges.
+func (m *Merger) mergeBorSpans(ctx context.Context, toMerge []string, targetFile string) error {
+	f, err := compress.NewCompressor(ctx, "Snapshots merge", targetFile, m.tmpDir, compress.MinPatternScore, m.compressWorkers, log.LvlTrace, m.logger)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var word = make([]byte, 0, 4096)
+	var written bool
+	var lastSpanID uint64
+	for _, cFile := range toMerge {
+		d, err := compress.NewDecompressor(cFile)
+		if err != nil {
+			return err
+		}
+		g := d.MakeGetter()
+		for g.HasNext() {
+			word, _ = g.Next(word[:0])
+			var s span.Span
+			if err := json.Unmarshal(word, &s); err != nil {
+				d.Close()
+				return err
+			}
+			if written && s.ID <= lastSpanID {
+				continue
+			}
+			if err := f.AddWord(word); err != nil {
+				d.Close()
+				return err
+			}
+			written, lastSpanID = true, s.ID
+		}
+		d.Close()
+	}
+	return f.Compress()
+}
+
+func (r *BlockReader) borEventsFromSnapshot(sn *BorEventSegment, offset uint64, fromID uint64, to time.Time, events []*clerk.EventRecordWithTime) (_ []*clerk.EventRecordWithTime, complete bool, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			panic(fmt.Errorf("%+v, snapshot: %d-%d, trace: %s", rec, sn.ranges.from, sn.ranges.to, dbg.Stack()))
+		}
+	}() // avoid crash because Erigon's core does many things
+
+	var buf []byte
+	gg := sn.seg.MakeGetter()
+	gg.Reset(offset)
+	for gg.HasNext() {
+		buf, _ = gg.Next(buf[:0])
+		if len(buf) == 0 {
+			continue
+		}
+		var blockEvents []*clerk.EventRecordWithTime
+		if err := json.Unmarshal(buf, &blockEvents); err != nil {
+			return events, false, err
+		}
+		for _, event := range blockEvents {
+			next := fromID + uint64(len(events))
+			if event.ID < next {
+				continue
+			}
+			if event.ID != next {
+				return events, false, fmt.Errorf("bor event %d is missing from %s", next, sn.seg.FilePath())
+			}
+			if !event.Time.Before(to) {
+				return events, true, nil
+			}
+			events = append(events, event)
+		}
+	}
+	return events, false, nil
+}
+
+// BorEvents returns the frozen state sync events from the id on, recorded before the time to. They are complete once
+// an event recorded at to or later is frozen, the events after the last frozen one being unknown.
+func (r *BlockReader) BorEvents(fromID uint64, to int64) (events []*clerk.EventRecordWithTime, complete bool, err error) {
+	view := r.sn.View()
+	defer view.Close()
+
+	toTime := time.Unix(to, 0)
+	var found bool
+	var prevTo uint64
+	for _, sn := range view.BorEvents() {
+		var offset uint64
+		if found {
+			if sn.ranges.from != prevTo {
+				break
+			}
+		} else {
+			if sn.idxEventID == nil {
+				continue
+			}
+			baseID := sn.idxEventID.BaseDataID()
+			if fromID < baseID || fromID >= baseID+sn.idxEventID.KeyCount()-1 {
+				continue
+			}
+			offset, found = sn.idxEventID.OrdinalLookup(fromID-baseID), true
+		}
+		prevTo = sn.ranges.to
+		if events, complete, err = r.borEventsFromSnapshot(sn, offset, fromID, toTime, events); complete || err != nil {
+			return events, complete, err
+		}
+	}
+	return events, false, nil
+}
+
+// BorSpan returns the frozen span, nil if it is not frozen.
+func (r *BlockReader) BorSpan(spanID uint64) (*span.HeimdallSpan, error) {
+	view := r.sn.View()
+	defer view.Close()
+
+	for _, sn := range view.BorSpans() {
+		if sn.idxSpanID == nil {
+			continue
+		}
+		baseID := sn.idxSpanID.BaseDataID()
+		if spanID < baseID || spanID >= baseID+sn.idxSpanID.KeyCount() {
+			continue
+		}
+		gg := sn.seg.MakeGetter()
+		gg.Reset(sn.idxSpanID.OrdinalLookup(spanID - baseID))
+		if !gg.HasNext() {
+			return nil, nil
+		}
+		buf, _ := gg.Next(nil)
+		var s span.HeimdallSpan
+		if err := json.Unmarshal(buf, &s); err != nil {
+			return nil, err
+		}
+		return &s, nil
+	}
+	return nil, nil
+}
+
+// FrozenHeimdallClient serves the state sync events and the spans of the frozen blocks from the snapshots, and the
+// others from Heimdall.
+type FrozenHeimdallClient struct {
+	HeimdallClient
+	blockReader *BlockReader
+}
+
+func NewFrozenHeimdallClient(client HeimdallClient, blockReader *BlockReader) *FrozenHeimdallClient {
+	return &FrozenHeimdallClient{HeimdallClient: client, blockReader: blockReader}
+}
+
+func (c *FrozenHeimdallClient) StateSyncEvents(ctx context.Context, fromID uint64, to int64) ([]*clerk.EventRecordWithTime, error) {
+	events, complete, err := c.blockReader.BorEvents(fromID, to)
+	if err != nil {
+		return nil, err
+	}
+	if complete {
+		return events, nil
+	}
+	rest, err := c.HeimdallClient.StateSyncEvents(ctx, fromID+uint64(len(events)), to)
+	if err != nil {
+		return nil, err
+	}
+	return append(events, rest...), nil
+}
+
+func (c *FrozenHeimdallClient) Span(ctx context.Context, spanID uint64) (*span.HeimdallSpan, error) {
+	s, err := c.blockReader.BorSpan(spanID)
+	if err != nil {
+		return nil, err
+	}
+	if s != nil {
+		return s, nil
+	}
+	return c.HeimdallClient.Span(ctx, spanID)
+}
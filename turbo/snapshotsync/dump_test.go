@@ -6,11 +6,14 @@ import (
 
 	"github.com/holiman/uint256"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/downloader/snaptype"
 	types2 "github.com/ledgerwatch/erigon-lib/types"
 	"github.com/ledgerwatch/erigon/common/math"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
@@ -184,3 +187,35 @@ func createDumpTestKV(t *testing.T, chainSize int) *stages.MockSentry {
 
 	return m
 }
+
+func TestVerifyFrozenBlock(t *testing.T) {
+	m := createDumpTestKV(t, 1_000)
+	require, logger, dir := require.New(t), log.New(), t.TempDir()
+	br, _ := m.NewBlocksIO()
+	require.NoError(snapshotsync.DumpBlocks(m.Ctx, 0, 1_000, snaptype.Erigon2SegmentSize, dir, dir, 0, m.DB, 1, log.LvlDebug, logger, br))
+	s := snapshotsync.NewRoSnapshots(ethconfig.Snapshot{Enabled: true}, dir, logger)
+	defer s.Close()
+	require.NoError(s.ReopenFolder())
+	r := snapshotsync.NewBlockReader(s)
+
+	tx, err := m.DB.BeginRo(m.Ctx)
+	require.NoError(err)
+	defer tx.Rollback()
+	for _, blockNum := range []uint64{0, 1, 999} {
+		hash, err := rawdb.ReadCanonicalHash(tx, blockNum)
+		require.NoError(err)
+		frozen, err := r.VerifyFrozenBlock(blockNum, hash)
+		require.NoError(err)
+		require.True(frozen)
+	}
+	// the indices do not resolve the block to another hash
+	frozen, err := r.VerifyFrozenBlock(1, libcommon.Hash{1})
+	require.True(frozen)
+	require.Error(err)
+
+	hash, err := rawdb.ReadCanonicalHash(tx, 1_000)
+	require.NoError(err)
+	frozen, err = r.VerifyFrozenBlock(1_000, hash)
+	require.NoError(err)
+	require.False(frozen)
+}
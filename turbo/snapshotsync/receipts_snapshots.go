@@ -0,0 +1,358 @@
+package snapshotsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	common2 "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/background"
+	"github.com/ledgerwatch/erigon-lib/common/dbg"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/compress"
+	"github.com/ledgerwatch/erigon-lib/downloader/snaptype"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/recsplit"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// Receipts segments are produced for the ranges whose receipts are still in the db when the blocks are retired, the
+// execution stage does not prune the receipts of the blocks not frozen yet. The receipts of the blocks they don't
+// cover, pruned before that, are re-executed, as before.
+const receiptsSegmentType = "receipts"
+
+// frozenReceipt is the encoding of a receipt in the receipts segments. The storage encoding does not keep the type of
+// the receipt, which the readers of the receipts without their block need.
+type frozenReceipt struct {
+	Type    uint8
+	Receipt *types.ReceiptForStorage
+}
+
+// ErrReceiptsNotInDB is returned when the receipts of a block to dump were pruned, or never written.
+var ErrReceiptsNotInDB = errors.New("receipts are not in db")
+
+type ReceiptSegment struct {
+	seg         *compress.Decompressor // value: rlp([]frozenReceipt)
+	idxBlockNum *recsplit.Index        // block_num_u64     -> receipts_segment_offset
+	ranges      Range
+}
+
+func (sn *ReceiptSegment) closeSeg() {
+	if sn.seg != nil {
+		sn.seg.Close()
+		sn.seg = nil
+	}
+}
+func (sn *ReceiptSegment) closeIdx() {
+	if sn.idxBlockNum != nil {
+		sn.idxBlockNum.Close()
+		sn.idxBlockNum = nil
+	}
+}
+func (sn *ReceiptSegment) close() {
+	sn.closeSeg()
+	sn.closeIdx()
+}
+
+func (sn *ReceiptSegment) reopenSeg(dir string) (err error) {
+	sn.closeSeg()
+	fileName := extraSegmentFileName(sn.ranges.from, sn.ranges.to, receiptsSegmentType)
+	sn.seg, err = compress.NewDecompressor(path.Join(dir, fileName))
+	if err != nil {
+		return fmt.Errorf("%w, fileName: %s", err, fileName)
+	}
+	return nil
+}
+func (sn *ReceiptSegment) reopenIdxIfNeed(dir string, optimistic bool) (err error) {
+	if sn.idxBlockNum != nil {
+		return nil
+	}
+	err = sn.reopenIdx(dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			if optimistic {
+				log.Warn("[snapshots] open index", "err", err)
+			} else {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (sn *ReceiptSegment) reopenIdx(dir string) (err error) {
+	sn.closeIdx()
+	if sn.seg == nil {
+		return nil
+	}
+	fileName := snaptype.IdxFileName(sn.ranges.from, sn.ranges.to, receiptsSegmentType)
+	sn.idxBlockNum, err = recsplit.OpenIndex(path.Join(dir, fileName))
+	if err != nil {
+		return fmt.Errorf("%w, fileName: %s", err, fileName)
+	}
+	if sn.idxBlockNum.ModTime().Before(sn.seg.ModTime()) {
+		// Index has been created before the segment file, needs to be ignored (and rebuilt) as inconsistent
+		sn.idxBlockNum.Close()
+		sn.idxBlockNum = nil
+	}
+	return nil
+}
+
+type receiptSegments struct {
+	lock     sync.RWMutex
+	segments []*ReceiptSegment
+}
+
+func (s *receiptSegments) View(f func([]*ReceiptSegment) error) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return f(s.segments)
+}
+
+// reopen opens the receipts segment of the file name, a missing file is skipped as the receipts segments are optional.
+func (s *receiptSegments) reopen(dir, fName string, ranges Range, optimistic bool, logger log.Logger) error {
+	for _, sn := range s.segments {
+		if sn.seg == nil {
+			continue
+		}
+		_, name := filepath.Split(sn.seg.FilePath())
+		if fName == name {
+			return sn.reopenIdxIfNeed(dir, optimistic)
+		}
+	}
+
+	sn := &ReceiptSegment{ranges: ranges}
+	if err := sn.reopenSeg(dir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if optimistic {
+			logger.Warn("[snapshots] open segment", "err", err)
+			return nil
+		}
+		return err
+	}
+	s.segments = append(s.segments, sn)
+	return sn.reopenIdxIfNeed(dir, optimistic)
+}
+
+func (s *receiptSegments) closeWhatNotInList(l []string) {
+	var segments []*ReceiptSegment
+Loop:
+	for _, sn := range s.segments {
+		if sn.seg == nil {
+			continue Loop
+		}
+		_, name := filepath.Split(sn.seg.FilePath())
+		for _, fName := range l {
+			if fName == name {
+				segments = append(segments, sn)
+				continue Loop
+			}
+		}
+		sn.close()
+	}
+	s.segments = segments
+}
+
+func (v *View) Receipts() []*ReceiptSegment { return v.s.Receipts.segments }
+func (v *View) ReceiptsSegment(blockNum uint64) (*ReceiptSegment, bool) {
+	for _, seg := range v.Receipts() {
+		if !(blockNum >= seg.ranges.from && blockNum < seg.ranges.to) {
+			continue
+		}
+		return seg, true
+	}
+	return nil, false
+}
+
+// DumpReceipts - [from, to)
+// Format: rlp([]frozenReceipt) of each block
+func DumpReceipts(ctx context.Context, db kv.RoDB, blockFrom, blockTo uint64, lvl log.Lvl, logger log.Logger, collect func([]byte) error) error {
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+
+	from := hexutility.EncodeTs(blockFrom)
+	if err := kv.BigChunks(db, kv.HeaderCanonical, from, func(tx kv.Tx, k, v []byte) (bool, error) {
+		blockNum := binary.BigEndian.Uint64(k)
+		if blockNum >= blockTo {
+			return false, nil
+		}
+		// the receipts of a block without transactions are read as nil too, so check they were written
+		has, err := tx.Has(kv.Receipts, hexutility.EncodeTs(blockNum))
+		if err != nil {
+			return false, err
+		}
+		if !has {
+			return false, fmt.Errorf("%w: block %d", ErrReceiptsNotInDB, blockNum)
+		}
+		receipts := rawdb.ReadRawReceipts(tx, blockNum)
+		// the type of a receipt is not stored in the db, it is the type of its transaction
+		body, err := rawdb.ReadBodyWithTransactions(tx, common2.BytesToHash(v), blockNum)
+		if err != nil {
+			return false, err
+		}
+		if body == nil || len(body.Transactions) != len(receipts) {
+			return false, fmt.Errorf("block %d: the transactions do not match its %d receipts", blockNum, len(receipts))
+		}
+		stored := make([]frozenReceipt, len(receipts))
+		for i, receipt := range receipts {
+			stored[i] = frozenReceipt{Type: body.Transactions[i].Type(), Receipt: (*types.ReceiptForStorage)(receipt)}
+		}
+		dataRLP, err := rlp.EncodeToBytes(stored)
+		if err != nil {
+			return false, err
+		}
+		if err := collect(dataRLP); err != nil {
+			return false, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-logEvery.C:
+			var m runtime.MemStats
+			if lvl >= log.LvlInfo {
+				dbg.ReadMemStats(&m)
+			}
+			logger.Log(lvl, "[snapshots] Wrote into file", "block num", blockNum,
+				"alloc", common2.ByteCount(m.Alloc), "sys", common2.ByteCount(m.Sys),
+			)
+		default:
+		}
+		return true, nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func ReceiptsIdx(ctx context.Context, segmentFilePath string, firstBlockNumInSegment uint64, tmpDir string, p *background.Progress, lvl log.Lvl, logger log.Logger) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			_, fName := filepath.Split(segmentFilePath)
+			err = fmt.Errorf("ReceiptsIdx: at=%s, %v, %s", fName, rec, dbg.Stack())
+		}
+	}()
+
+	num := make([]byte, 8)
+
+	d, err := compress.NewDecompressor(segmentFilePath)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	_, fname := filepath.Split(segmentFilePath)
+	p.Name.Store(&fname)
+	p.Total.Store(uint64(d.Count()))
+
+	if err := Idx(ctx, d, firstBlockNumInSegment, tmpDir, log.LvlDebug, func(idx *recsplit.RecSplit, i, offset uint64, word []byte) error {
+		p.Processed.Add(1)
+		n := binary.PutUvarint(num, i)
+		if err := idx.AddKey(num[:n], offset); err != nil {
+			return err
+		}
+		return nil
+	}, logger); err != nil {
+		return fmt.Errorf("ReceiptsIdx: %w", err)
+	}
+	return nil
+}
+
+// dumpReceiptsRange freezes the receipts of the range if they are all still in the db.
+func dumpReceiptsRange(ctx context.Context, blockFrom, blockTo uint64, tmpDir, snapDir string, chainDB kv.RoDB, workers int, lvl log.Lvl, logger log.Logger) error {
+	segPath := filepath.Join(snapDir, extraSegmentFileName(blockFrom, blockTo, receiptsSegmentType))
+	sn, err := compress.NewCompressor(ctx, "Snapshot Receipts", segPath, tmpDir, compress.MinPatternScore, workers, log.LvlTrace, logger)
+	if err != nil {
+		return err
+	}
+	defer sn.Close()
+	if err := DumpReceipts(ctx, chainDB, blockFrom, blockTo, lvl, logger, func(v []byte) error {
+		return sn.AddWord(v)
+	}); err != nil {
+		if errors.Is(err, ErrReceiptsNotInDB) {
+			logger.Log(lvl, "[snapshots] Skip receipts segment", "range", Range{blockFrom, blockTo}.String(), "err", err)
+			return nil
+		}
+		return fmt.Errorf("DumpReceipts: %w", err)
+	}
+	if expectedCount := int(blockTo - blockFrom); sn.Count() != expectedCount {
+		return fmt.Errorf("incorrect receipts count: %d, expected: %d", sn.Count(), expectedCount)
+	}
+	if err := sn.Compress(); err != nil {
+		return fmt.Errorf("compress: %w", err)
+	}
+
+	p := &background.Progress{}
+	return ReceiptsIdx(ctx, segPath, blockFrom, tmpDir, p, lvl, logger)
+}
+
+// receiptsFilesByRange returns the receipts segments to merge into the range, nil unless they cover all of it.
+func (m *Merger) receiptsFilesByRange(snapshots *RoSnapshots, from, to uint64) []string {
+	view := snapshots.View()
+	defer view.Close()
+
+	var ranges []Range
+	var files []string
+	for _, sn := range view.Receipts() {
+		ranges = append(ranges, sn.ranges)
+		files = append(files, sn.seg.FilePath())
+	}
+	return coveringFiles(ranges, files, from, to)
+}
+
+func (r *BlockReader) receiptsFromSnapshot(blockHeight uint64, sn *ReceiptSegment, buf []byte) (types.Receipts, []byte, error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			panic(fmt.Errorf("%+v, snapshot: %d-%d, trace: %s", rec, sn.ranges.from, sn.ranges.to, dbg.Stack()))
+		}
+	}() // avoid crash because Erigon's core does many things
+
+	if sn.idxBlockNum == nil {
+		return nil, buf, nil
+	}
+	receiptsOffset := sn.idxBlockNum.OrdinalLookup(blockHeight - sn.idxBlockNum.BaseDataID())
+
+	gg := sn.seg.MakeGetter()
+	gg.Reset(receiptsOffset)
+	if !gg.HasNext() {
+		return nil, buf, nil
+	}
+	buf, _ = gg.Next(buf[:0])
+	var stored []frozenReceipt
+	if err := rlp.Decode(bytes.NewReader(buf), &stored); err != nil {
+		return nil, buf, err
+	}
+	receipts := make(types.Receipts, len(stored))
+	for i, receipt := range stored {
+		receipts[i] = (*types.Receipt)(receipt.Receipt)
+		receipts[i].Type = receipt.Type
+	}
+	return receipts, buf, nil
+}
+
+// RawReceipts returns the receipts of a block frozen in the receipts segments, nil if they are not. As for
+// rawdb.ReadRawReceipts, the fields derived from the block are not set, but their type is.
+func (r *BlockReader) RawReceipts(ctx context.Context, blockHeight uint64) (types.Receipts, error) {
+	view := r.sn.View()
+	defer view.Close()
+	seg, ok := view.ReceiptsSegment(blockHeight)
+	if !ok {
+		return nil, nil
+	}
+	receipts, _, err := r.receiptsFromSnapshot(blockHeight, seg, nil)
+	return receipts, err
+}
@@ -155,9 +155,14 @@ func TraceTx(
 		if config != nil && config.TracerConfig != nil {
 			cfg = *config.TracerConfig
 		}
-		if tracer, err = tracers.New(*config.Tracer, &tracers.Context{
-			TxHash: txCtx.TxHash,
-		}, cfg); err != nil {
+		tracerCtx := &tracers.Context{
+			BlockNumber: blockCtx.BlockNumber,
+			TxHash:      txCtx.TxHash,
+		}
+		if statedb, ok := ibs.(*state.IntraBlockState); ok && txCtx.TxHash != (libcommon.Hash{}) {
+			tracerCtx.BlockHash, tracerCtx.TxIndex = statedb.BlockHash(), statedb.TxIndex()
+		}
+		if tracer, err = tracers.New(*config.Tracer, tracerCtx, cfg); err != nil {
 			stream.WriteNil()
 			return err
 		}